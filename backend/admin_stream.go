@@ -0,0 +1,54 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"sync"
+)
+
+// AdminStream pushes operational events across all debates (such as
+// judging_stage) to administrators connected to /api/admin/stream
+type AdminStream struct {
+	mutex sync.Mutex
+	conns map[*WSConn]bool
+}
+
+var adminStream = &AdminStream{conns: make(map[*WSConn]bool)}
+
+// Publish sends a message to every connected administrator
+func (s *AdminStream) Publish(msg Message) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for conn := range s.conns {
+		if err := conn.WriteJSON(msg); err != nil {
+			log.Printf("Error sending to admin stream: %v", err)
+		}
+	}
+}
+
+// handleAdminStream upgrades to a WebSocket that receives admin events until
+// the client disconnects
+func handleAdminStream(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgradeWithSubprotocol(w, r)
+	if err != nil {
+		log.Printf("Failed to upgrade admin stream connection: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	adminStream.mutex.Lock()
+	adminStream.conns[conn] = true
+	adminStream.mutex.Unlock()
+	log.Printf("Admin stream connected from %s", conn.RemoteAddr())
+
+	// Nothing is expected from the client; reading detects the disconnect
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			break
+		}
+	}
+
+	adminStream.mutex.Lock()
+	delete(adminStream.conns, conn)
+	adminStream.mutex.Unlock()
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"database/sql"
+	"time"
+)
+
+// The daily_stats and bot_stats tables aggregate debate results so stats
+// endpoints don't scan debate_results on every request. They are updated in
+// the same transaction as each result and can be rebuilt from scratch with
+// the `rebuild-stats` subcommand. bot_stats only counts ranked debates.
+
+// BotStats is the aggregated record of one bot across ranked debates
+type BotStats struct {
+	BotUUID      string  `json:"bot_uuid"`
+	BotName      string  `json:"bot_name"`
+	Debates      int     `json:"debates"`
+	Wins         int     `json:"wins"`
+	Losses       int     `json:"losses"`
+	Draws        int     `json:"draws"`
+	AverageScore float64 `json:"average_score"`
+}
+
+// DailyStats counts the debates finished on one day
+type DailyStats struct {
+	Day            string `json:"day"` // YYYY-MM-DD (UTC)
+	Debates        int    `json:"debates"`
+	RankedDebates  int    `json:"ranked_debates"`
+	SupportingWins int    `json:"supporting_wins"`
+	OpposingWins   int    `json:"opposing_wins"`
+	Draws          int    `json:"draws"`
+}
+
+const applyDailyStatsSQL = `
+	INSERT INTO daily_stats (day, ranked, winner, debates, supporting_score_sum, opposing_score_sum)
+	SELECT date(r.created_at), d.ranked, r.winner, ?1, ?1 * r.supporting_score, ?1 * r.opposing_score
+	FROM debate_results r JOIN debates d ON d.id = r.debate_id
+	WHERE r.debate_id = ?2
+	ON CONFLICT(day, ranked, winner) DO UPDATE SET
+		debates = debates + excluded.debates,
+		supporting_score_sum = supporting_score_sum + excluded.supporting_score_sum,
+		opposing_score_sum = opposing_score_sum + excluded.opposing_score_sum`
+
+const applyBotStatsSQL = `
+	INSERT INTO bot_stats (bot_uuid, bot_name, debates, wins, losses, draws, score_sum)
+	SELECT b.bot_uuid, b.bot_name, ?1,
+		?1 * (r.winner = b.side),
+		?1 * (r.winner IN ('supporting', 'opposing') AND r.winner != b.side),
+		?1 * (r.winner = 'draw'),
+		?1 * CASE b.side WHEN 'supporting' THEN r.supporting_score ELSE r.opposing_score END
+	FROM debate_results r
+	JOIN debates d ON d.id = r.debate_id
+	JOIN bots b ON b.debate_id = r.debate_id
+	WHERE r.debate_id = ?2 AND d.ranked = 1 AND b.side IN ('supporting', 'opposing')
+	ON CONFLICT(bot_uuid) DO UPDATE SET
+		bot_name = excluded.bot_name,
+		debates = debates + excluded.debates,
+		wins = wins + excluded.wins,
+		losses = losses + excluded.losses,
+		draws = draws + excluded.draws,
+		score_sum = score_sum + excluded.score_sum`
+
+// applyResultAggregates adds (sign 1) or removes (sign -1) the stored result of
+// a debate to the aggregate tables
+func applyResultAggregates(tx *sql.Tx, debateID string, sign int) error {
+	if _, err := tx.Exec(applyDailyStatsSQL, sign, debateID); err != nil {
+		return err
+	}
+	_, err := tx.Exec(applyBotStatsSQL, sign, debateID)
+	return err
+}
+
+// RebuildAggregates recomputes the aggregate tables from all stored results
+func (d *Database) RebuildAggregates() error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"daily_stats", "bot_stats"} {
+		if _, err := tx.Exec(`DELETE FROM ` + table); err != nil {
+			return err
+		}
+	}
+	rows, err := tx.Query(`SELECT debate_id FROM debate_results`)
+	if err != nil {
+		return err
+	}
+	var debateIDs []string
+	for rows.Next() {
+		var debateID string
+		if err := rows.Scan(&debateID); err != nil {
+			rows.Close()
+			return err
+		}
+		debateIDs = append(debateIDs, debateID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, debateID := range debateIDs {
+		if err := applyResultAggregates(tx, debateID, 1); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetBotStats returns ranked bot records, most wins first
+func (d *Database) GetBotStats(limit int) ([]BotStats, error) {
+	query := `SELECT bot_uuid, bot_name, debates, wins, losses, draws, score_sum
+	          FROM bot_stats WHERE debates > 0
+	          ORDER BY wins DESC, debates ASC, bot_name ASC LIMIT ?`
+
+	rows, err := d.db.Query(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := []BotStats{}
+	for rows.Next() {
+		var s BotStats
+		var scoreSum int
+		if err := rows.Scan(&s.BotUUID, &s.BotName, &s.Debates, &s.Wins, &s.Losses, &s.Draws, &scoreSum); err != nil {
+			return nil, err
+		}
+		s.AverageScore = float64(scoreSum) / float64(s.Debates)
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
+
+// GetDailyStats returns per-day debate counts since the given time, oldest first
+func (d *Database) GetDailyStats(since time.Time) ([]DailyStats, error) {
+	query := `SELECT day, SUM(debates), SUM(CASE WHEN ranked = 1 THEN debates ELSE 0 END),
+	              SUM(CASE WHEN winner = 'supporting' THEN debates ELSE 0 END),
+	              SUM(CASE WHEN winner = 'opposing' THEN debates ELSE 0 END),
+	              SUM(CASE WHEN winner = 'draw' THEN debates ELSE 0 END)
+	          FROM daily_stats WHERE day >= ?
+	          GROUP BY day HAVING SUM(debates) > 0 ORDER BY day ASC`
+
+	rows, err := d.db.Query(query, since.UTC().Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := []DailyStats{}
+	for rows.Next() {
+		var s DailyStats
+		if err := rows.Scan(&s.Day, &s.Debates, &s.RankedDebates, &s.SupportingWins, &s.OpposingWins, &s.Draws); err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
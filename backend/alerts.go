@@ -0,0 +1,39 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RaiseAlert records an alert for administrators and logs it
+func RaiseAlert(kind, message string) {
+	log.Printf("ADMIN ALERT [%s]: %s", kind, message)
+	if db == nil {
+		return
+	}
+	if err := db.AddAlert(&Alert{Kind: kind, Message: message, CreatedAt: time.Now()}); err != nil {
+		log.Printf("Failed to store admin alert: %v", err)
+	}
+}
+
+// handleAdminAlerts returns the most recent admin alerts
+func handleAdminAlerts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+
+	alerts, err := db.GetAlerts(limit)
+	if err != nil {
+		http.Error(w, "Failed to fetch alerts", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, alerts)
+}
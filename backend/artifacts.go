@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"database/sql"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// A speech may carry a few small attachments backing it up: data tables as
+// CSV or JSON and chart specs (Vega-Lite JSON). The bot sends each inline in
+// data; the server checks its type and size, stores it in the artifact store
+// and replaces data with a download URL under /api/debate/{id}/artifacts/,
+// which is what broadcasts, the debate log and exports carry. Artifacts are
+// as visible as their debate: those of a private debate need its spectator
+// token.
+
+// Attachment types
+const (
+	AttachmentCSV   = "text/csv"
+	AttachmentJSON  = "application/json"
+	AttachmentChart = "application/vnd.vegalite+json"
+)
+
+// attachmentTypes are the attachment types speeches may carry
+var attachmentTypes = []string{AttachmentCSV, AttachmentJSON, AttachmentChart}
+
+// Artifact storage backends
+const (
+	ArtifactBackendDatabase   = "database"   // In the artifacts table; shared by every instance on the database
+	ArtifactBackendFilesystem = "filesystem" // Files under artifacts.dir
+)
+
+// Attachment is a file attached to a speech
+type Attachment struct {
+	ID          string `json:"id,omitempty"`
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Size        int    `json:"size,omitempty"`
+	URL         string `json:"url,omitempty"`  // Download URL, set once stored
+	Data        string `json:"data,omitempty"` // Sent by the bot, dropped once stored
+}
+
+// ArtifactStore holds the contents of stored attachments. Their metadata is
+// always in the artifacts table.
+type ArtifactStore interface {
+	Save(id string, data []byte) error
+	Load(id string) ([]byte, error)
+}
+
+// artifacts is the configured artifact store
+var artifacts ArtifactStore
+
+// NewArtifactStore creates the configured artifact store
+func NewArtifactStore(cfg *Config, d *Database) (ArtifactStore, error) {
+	switch cfg.Artifacts.Backend {
+	case ArtifactBackendFilesystem:
+		if err := os.MkdirAll(cfg.Artifacts.Dir, 0755); err != nil {
+			return nil, err
+		}
+		return fileArtifactStore{dir: cfg.Artifacts.Dir}, nil
+	default:
+		return databaseArtifactStore{db: d}, nil
+	}
+}
+
+// databaseArtifactStore keeps contents in the artifacts table
+type databaseArtifactStore struct {
+	db *Database
+}
+
+func (s databaseArtifactStore) Save(id string, data []byte) error {
+	_, err := s.db.db.Exec(`UPDATE artifacts SET data = ? WHERE id = ?`, data, id)
+	return err
+}
+
+func (s databaseArtifactStore) Load(id string) ([]byte, error) {
+	var data []byte
+	err := s.db.db.QueryRow(`SELECT data FROM artifacts WHERE id = ?`, id).Scan(&data)
+	return data, err
+}
+
+// fileArtifactStore keeps contents in one file per artifact
+type fileArtifactStore struct {
+	dir string
+}
+
+func (s fileArtifactStore) Save(id string, data []byte) error {
+	return os.WriteFile(filepath.Join(s.dir, id), data, 0644)
+}
+
+func (s fileArtifactStore) Load(id string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(s.dir, id))
+}
+
+// generateArtifactID returns a new random artifact ID
+func generateArtifactID() string {
+	buf := make([]byte, 12)
+	rand.Read(buf)
+	return "art-" + hex.EncodeToString(buf)
+}
+
+// validateAttachment checks an attachment's name, type, size and syntax
+func validateAttachment(a *Attachment) error {
+	a.Name = filepath.Base(strings.TrimSpace(a.Name))
+	if a.Name == "" || a.Name == "." || a.Name == string(filepath.Separator) || len(a.Name) > 100 {
+		return fmt.Errorf("attachment name must be 1 to 100 characters")
+	}
+	known := false
+	for _, t := range attachmentTypes {
+		known = known || a.ContentType == t
+	}
+	if !known {
+		return fmt.Errorf("attachment %s: content_type must be one of %s", a.Name, strings.Join(attachmentTypes, ", "))
+	}
+	if a.Data == "" {
+		return fmt.Errorf("attachment %s has no data", a.Name)
+	}
+	if len(a.Data) > config.Artifacts.MaxBytes {
+		return fmt.Errorf("attachment %s is too large (maximum %d bytes)", a.Name, config.Artifacts.MaxBytes)
+	}
+	switch a.ContentType {
+	case AttachmentCSV:
+		if _, err := csv.NewReader(strings.NewReader(a.Data)).ReadAll(); err != nil {
+			return fmt.Errorf("attachment %s is not valid CSV: %v", a.Name, err)
+		}
+	default:
+		if !json.Valid([]byte(a.Data)) {
+			return fmt.Errorf("attachment %s is not valid JSON", a.Name)
+		}
+	}
+	return nil
+}
+
+// storeAttachments validates and stores the attachments of an accepted
+// speech, replacing their data with download URLs
+func storeAttachments(speech *DebateSpeech) *ErrorMessage {
+	attachments := speech.Message.Attachments
+	if len(attachments) == 0 {
+		return nil
+	}
+	invalid := func(message string) *ErrorMessage {
+		return &ErrorMessage{
+			ErrorCode:   "INVALID_ATTACHMENT",
+			Message:     message,
+			DebateID:    speech.DebateID,
+			Recoverable: true,
+		}
+	}
+	if len(attachments) > config.Artifacts.MaxPerSpeech {
+		return invalid(fmt.Sprintf("Too many attachments (maximum %d per speech)", config.Artifacts.MaxPerSpeech))
+	}
+	for i := range attachments {
+		if err := validateAttachment(&attachments[i]); err != nil {
+			return invalid(err.Error())
+		}
+	}
+
+	stored := make([]Attachment, 0, len(attachments))
+	for _, a := range attachments {
+		data := []byte(a.Data)
+		a.ID = generateArtifactID()
+		a.Size = len(data)
+		a.URL = fmt.Sprintf("/api/debate/%s/artifacts/%s", speech.DebateID, a.ID)
+		a.Data = ""
+		err := db.AddArtifact(speech.DebateID, speech.Speaker, &a)
+		if err == nil {
+			err = artifacts.Save(a.ID, data)
+		}
+		if err != nil {
+			log.Printf("Failed to store attachment %s of %s in debate %s: %v", a.Name, speech.Speaker, speech.DebateID, err)
+			return &ErrorMessage{
+				ErrorCode:   "INTERNAL_ERROR",
+				Message:     "Failed to store the attachment, try again",
+				DebateID:    speech.DebateID,
+				Recoverable: true,
+			}
+		}
+		stored = append(stored, a)
+	}
+	speech.Message.Attachments = stored
+	return nil
+}
+
+// handleDebateArtifact handles GET /api/debate/{id}/artifacts/{artifact_id}
+func handleDebateArtifact(w http.ResponseWriter, r *http.Request, debateID, artifactID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	debate, err := db.GetDebate(debateID)
+	if err != nil || checkSpectatorAccess(debate, r.URL.Query().Get("token")) != nil {
+		http.Error(w, "Debate not found", http.StatusNotFound)
+		return
+	}
+	a, err := db.GetArtifact(debateID, artifactID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Artifact not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to fetch artifact", http.StatusInternalServerError)
+		return
+	}
+	data, err := artifacts.Load(a.ID)
+	if err != nil {
+		log.Printf("Failed to load artifact %s: %v", a.ID, err)
+		http.Error(w, "Failed to fetch artifact", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", a.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.Name}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, a.Name, time.Time{}, bytes.NewReader(data))
+}
+
+// AddArtifact records a stored attachment's metadata
+func (d *Database) AddArtifact(debateID, speaker string, a *Attachment) error {
+	query := `INSERT INTO artifacts (id, debate_id, speaker, name, content_type, size, created_at)
+	          VALUES (?, ?, ?, ?, ?, ?, ?)`
+	_, err := d.db.Exec(query, a.ID, debateID, speaker, a.Name, a.ContentType, a.Size, time.Now())
+	return err
+}
+
+// GetArtifact returns the metadata of an artifact of a debate
+func (d *Database) GetArtifact(debateID, artifactID string) (*Attachment, error) {
+	a := &Attachment{}
+	query := `SELECT id, name, content_type, size FROM artifacts WHERE id = ? AND debate_id = ?`
+	err := d.db.QueryRow(query, artifactID, debateID).Scan(&a.ID, &a.Name, &a.ContentType, &a.Size)
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// encodeAttachments serializes a speech's attachments for the debate_log table
+func encodeAttachments(attachments []Attachment) string {
+	if len(attachments) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(attachments)
+	return string(data)
+}
+
+// decodeAttachments parses attachments stored by encodeAttachments
+func decodeAttachments(stored string) []Attachment {
+	if stored == "" {
+		return nil
+	}
+	var attachments []Attachment
+	json.Unmarshal([]byte(stored), &attachments)
+	return attachments
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// With auth.enabled, creating and listing debates and both WebSocket
+// upgrades need one of the API keys under auth.keys, sent as
+// "Authorization: Bearer <key>" or, for browsers that cannot set headers on
+// a WebSocket, the api_key query parameter. Keys are named so logs can tell
+// clients apart. The house bot dials this server with a key generated at
+// startup, which is never written anywhere.
+//
+// Moderation and administration (the /api/admin routes, /metrics, pausing,
+// resuming and cancelling debates, imports, tournament and league creation)
+// need a key marked admin; other keys get 403 there.
+
+// APIKey is one client's key
+type APIKey struct {
+	Name  string `yaml:"name"`
+	Key   string `yaml:"key"`
+	Admin bool   `yaml:"admin"` // May use the moderation and admin routes
+}
+
+// internalAPIKey authenticates the server's own connections, such as the house bot
+var internalAPIKey = generateInternalAPIKey()
+
+// generateInternalAPIKey returns a random key for the server's own connections
+func generateInternalAPIKey() string {
+	buf := make([]byte, 24)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// requestAPIKey returns the key a request presents, if any
+func requestAPIKey(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		if key, ok := strings.CutPrefix(header, "Bearer "); ok {
+			return strings.TrimSpace(key)
+		}
+		return strings.TrimSpace(header)
+	}
+	return r.URL.Query().Get("api_key")
+}
+
+// authenticate returns the name of the client a request's key belongs to
+func authenticate(r *http.Request) (string, bool) {
+	name, _, ok := authenticateKey(r)
+	return name, ok
+}
+
+// authenticateKey is authenticate, also reporting whether the key is an
+// admin key; the server's own key is
+func authenticateKey(r *http.Request) (name string, admin bool, ok bool) {
+	key := requestAPIKey(r)
+	if key == "" {
+		return "", false, false
+	}
+	if subtle.ConstantTimeCompare([]byte(key), []byte(internalAPIKey)) == 1 {
+		return "internal", true, true
+	}
+	for _, k := range config.Auth.Keys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(k.Key)) == 1 {
+			return k.Name, k.Admin, true
+		}
+	}
+	return "", false, false
+}
+
+// requireAPIKey rejects requests without a valid API key when auth is enabled
+func requireAPIKey(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !config.Auth.Enabled {
+			next(w, r)
+			return
+		}
+		if _, ok := authenticate(r); !ok {
+			log.Printf("Rejected unauthenticated %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="bot-debate"`)
+			http.Error(w, "Missing or invalid API key", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// requireAdminKey rejects requests without an admin API key when auth is enabled
+func requireAdminKey(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if authorizeAdmin(w, r) {
+			next(w, r)
+		}
+	}
+}
+
+// authorizeAdmin reports whether a request may use the admin routes,
+// answering 401 or 403 when it may not
+func authorizeAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if !config.Auth.Enabled {
+		return true
+	}
+	name, admin, ok := authenticateKey(r)
+	if !ok {
+		log.Printf("Rejected unauthenticated %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+		w.Header().Set("WWW-Authenticate", `Bearer realm="bot-debate"`)
+		http.Error(w, "Missing or invalid API key", http.StatusUnauthorized)
+		return false
+	}
+	if !admin {
+		log.Printf("Rejected %s %s from %s: key %s is not an admin key", r.Method, r.URL.Path, r.RemoteAddr, name)
+		http.Error(w, "Admin API key required", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// internalAuthHeader returns the headers the server's own clients send. They
+// carry the internal key with auth off too, so isInternalRequest still works.
+func internalAuthHeader() http.Header {
+	return http.Header{"Authorization": []string{"Bearer " + internalAPIKey}}
+}
+
+// isInternalRequest reports whether a request comes from the server's own clients
+func isInternalRequest(r *http.Request) bool {
+	return subtle.ConstantTimeCompare([]byte(requestAPIKey(r)), []byte(internalAPIKey)) == 1
+}
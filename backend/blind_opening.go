@@ -0,0 +1,205 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// In blind-opening debates each bot learns its side at login and submits its
+// opening statement while still waiting, without knowing who the opponent is
+// or what they wrote. Both openings are revealed together when the debate
+// starts and count as round 1.
+
+// assignBlindSide gives a newly joined bot its side: random for the first bot,
+// the remaining side for the second. Caller holds dm.mutex.
+func (dm *DebateManager) assignBlindSide(activeDebate *ActiveDebate, bot *ConnectedBot) {
+	switch {
+	case activeDebate.SupportingBot != nil:
+		activeDebate.OpposingBot = bot
+		bot.Bot.Side = "opposing"
+	case activeDebate.OpposingBot != nil:
+		activeDebate.SupportingBot = bot
+		bot.Bot.Side = "supporting"
+	case randomBool():
+		activeDebate.SupportingBot = bot
+		bot.Bot.Side = "supporting"
+	default:
+		activeDebate.OpposingBot = bot
+		bot.Bot.Side = "opposing"
+	}
+	dm.db.UpdateBotSide(bot.Bot.DebateID, bot.Bot.BotIdentifier, bot.Bot.Side)
+}
+
+// HandleOpeningSubmission stores a blind opening statement sent before the debate starts
+func (dm *DebateManager) HandleOpeningSubmission(speech *DebateSpeech, senderConn *WSConn, replyTo string) *ErrorMessage {
+	dm.mutex.RLock()
+	activeDebate, exists := dm.debates[speech.DebateID]
+	dm.mutex.RUnlock()
+
+	if !exists {
+		return &ErrorMessage{
+			ErrorCode:   "DEBATE_NOT_FOUND",
+			Message:     "Debate not found",
+			DebateID:    speech.DebateID,
+			Recoverable: false,
+		}
+	}
+
+	normalizeTranslations(activeDebate.Debate, &speech.Message)
+
+	if !activeDebate.Debate.BlindOpening {
+		return &ErrorMessage{
+			ErrorCode:   "BLIND_OPENING_DISABLED",
+			Message:     "This debate does not collect blind openings, wait for debate_start",
+			DebateID:    speech.DebateID,
+			Recoverable: true,
+		}
+	}
+
+	var speakerBot *ConnectedBot
+	for _, bot := range activeDebate.Bots {
+		if bot.Bot.BotIdentifier == speech.Speaker && bot.Bot.DebateKey == speech.DebateKey {
+			speakerBot = bot
+		}
+	}
+	if speakerBot == nil {
+		return &ErrorMessage{
+			ErrorCode:   "INVALID_DEBATE_KEY",
+			Message:     "Invalid debate key",
+			DebateID:    speech.DebateID,
+			Recoverable: false,
+		}
+	}
+
+	if errMsg := validateSpeechLength(speech, formatLimitsFor(activeDebate.Debate)); errMsg != nil {
+		return errMsg
+	}
+	if errMsg := storeAttachments(speech); errMsg != nil {
+		return errMsg
+	}
+
+	activeDebate.mutex.Lock()
+	if activeDebate.OpeningsClosed || activeDebate.Debate.Status != "waiting" {
+		activeDebate.mutex.Unlock()
+		return &ErrorMessage{
+			ErrorCode:   "OPENING_CLOSED",
+			Message:     "Opening statements are no longer accepted",
+			DebateID:    speech.DebateID,
+			Recoverable: false,
+		}
+	}
+	if activeDebate.Openings == nil {
+		activeDebate.Openings = make(map[string]DebateLogEntry)
+	}
+	if _, submitted := activeDebate.Openings[speech.Speaker]; submitted {
+		activeDebate.mutex.Unlock()
+		return &ErrorMessage{
+			ErrorCode:   "ALREADY_SUBMITTED",
+			Message:     "You have already submitted your opening statement",
+			DebateID:    speech.DebateID,
+			Recoverable: true,
+		}
+	}
+
+	activeDebate.Openings[speech.Speaker] = DebateLogEntry{
+		Round:     1,
+		Speaker:   speech.Speaker,
+		Side:      speakerBot.Bot.Side,
+		Timestamp: time.Now().Format(time.RFC3339),
+		Message:   speech.Message,
+	}
+	complete := len(activeDebate.Bots) == 2 && len(activeDebate.Openings) == 2
+	if complete {
+		activeDebate.OpeningsClosed = true
+	}
+	activeDebate.mutex.Unlock()
+
+	senderConn.WriteJSON(createReply(replyTo, "opening_received", OpeningReceived{
+		DebateID: speech.DebateID,
+		Speaker:  speech.Speaker,
+	}))
+	log.Printf("Blind opening received from %s in debate %s", speech.Speaker, speech.DebateID)
+
+	if complete {
+		go dm.startDebate(speech.DebateID)
+	}
+	return nil
+}
+
+// startOpeningDeadline gives both bots the speech timeout to submit their
+// openings once they have joined. Caller holds dm.mutex.
+func (dm *DebateManager) startOpeningDeadline(activeDebate *ActiveDebate) {
+	// The waiting timeout no longer applies, both bots are here
+	if activeDebate.WaitingTimer != nil {
+		activeDebate.WaitingTimer.Stop()
+		activeDebate.WaitingTimer = nil
+	}
+
+	debateID := activeDebate.Debate.ID
+	activeDebate.TimeoutTimer = time.AfterFunc(
+		time.Duration(formatLimitsFor(activeDebate.Debate).SpeechTimeout)*time.Second,
+		func() {
+			activeDebate.mutex.Lock()
+			if activeDebate.OpeningsClosed {
+				activeDebate.mutex.Unlock()
+				return
+			}
+			activeDebate.OpeningsClosed = true
+			activeDebate.mutex.Unlock()
+
+			log.Printf("Opening deadline passed in debate %s", debateID)
+			dm.revealOpenings(activeDebate)
+			dm.endDebate(debateID, "timeout", "speech_timeout")
+		},
+	)
+	dm.startCountdown(activeDebate, activeDebate.SupportingBot, activeDebate.OpposingBot)
+}
+
+// revealOpenings moves the submitted openings into the debate log as round 1
+// and publishes them to both bots and spectators
+func (dm *DebateManager) revealOpenings(activeDebate *ActiveDebate) {
+	debateID := activeDebate.Debate.ID
+
+	activeDebate.mutex.Lock()
+	entries := []DebateLogEntry{}
+	missing := []string{}
+	for _, bot := range []*ConnectedBot{activeDebate.SupportingBot, activeDebate.OpposingBot} {
+		if bot == nil {
+			continue
+		}
+		if entry, ok := activeDebate.Openings[bot.Bot.BotIdentifier]; ok {
+			entries = append(entries, entry)
+		} else {
+			missing = append(missing, bot.Bot.BotIdentifier)
+		}
+	}
+	activeDebate.DebateLog = append(activeDebate.DebateLog, entries...)
+	activeDebate.Openings = nil
+	activeDebate.Debate.CurrentRound = 2
+	activeDebate.mutex.Unlock()
+
+	if err := dm.db.RecordSpeeches(debateID, entries, 2); err != nil {
+		log.Printf("Failed to record the openings of debate %s: %v", debateID, err)
+	}
+	dm.translateEntries(activeDebate, entries)
+	dm.summarizeEntries(activeDebate, entries)
+	speechesAccepted(activeDebate, entries)
+	if len(missing) == 0 {
+		dm.onRoundComplete(activeDebate, 1)
+	}
+
+	revealMsg := createMessage("opening_reveal", RoundReveal{
+		DebateID: debateID,
+		Round:    1,
+		Entries:  entries,
+		Missing:  missing,
+	})
+	for _, bot := range []*ConnectedBot{activeDebate.SupportingBot, activeDebate.OpposingBot} {
+		if bot != nil && bot.Conn != nil {
+			bot.Conn.WriteJSON(revealMsg)
+		}
+	}
+	dm.broadcast <- BroadcastMessage{DebateID: debateID, Message: revealMsg}
+
+	log.Printf("Debate %s openings revealed (%d statements, missing: %v)", debateID, len(entries), missing)
+}
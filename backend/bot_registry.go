@@ -0,0 +1,223 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Bots can register through /api/bots/register for a long-lived token tied
+// to their bot_uuid. A registered bot_uuid only logs in with its token, and
+// no other bot may log in under the same identifier, so nobody can take a
+// registered bot's seat or results by claiming its UUID. With
+// debate.require_bot_token, on in the shipped config, every login needs a
+// token, which turns off the trust-anything model altogether. Only a hash of
+// each token is stored.
+//
+// A bot_uuid that has already debated can only be registered with an admin
+// key, so nobody can lock an unregistered bot out by registering its UUID
+// first. To move existing bots over, run with require_bot_token off while an
+// admin registers their UUIDs and hands out the tokens, then turn it on.
+
+// botTokenPrefix marks bot tokens, so a leaked one is easy to recognize
+const botTokenPrefix = "bdt_"
+
+// RegisterBotRequest registers a bot
+type RegisterBotRequest struct {
+	BotName string `json:"bot_name"`
+	BotUUID string `json:"bot_uuid,omitempty"` // Generated when omitted
+}
+
+// RegisterBotResponse carries the token; it is shown only once
+type RegisterBotResponse struct {
+	BotName       string `json:"bot_name"`
+	BotUUID       string `json:"bot_uuid"`
+	BotIdentifier string `json:"bot_identifier"`
+	Token         string `json:"token"`
+}
+
+// RegisteredBot is a bot holding a login token
+type RegisteredBot struct {
+	BotUUID       string
+	BotName       string
+	BotIdentifier string
+	TokenHash     string
+}
+
+// botIdentifier builds the identifier a bot is known by in debates
+func botIdentifier(name, botUUID string) string {
+	return fmt.Sprintf("%s-%s", name, botUUID[:8])
+}
+
+// hashBotToken hashes a token for storage and lookup
+func hashBotToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateBotToken returns a new random bot token
+func generateBotToken() string {
+	buf := make([]byte, 32)
+	rand.Read(buf)
+	return botTokenPrefix + hex.EncodeToString(buf)
+}
+
+// handleRegisterBot handles POST /api/bots/register
+func handleRegisterBot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req RegisterBotRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	req.BotName = strings.TrimSpace(req.BotName)
+	if req.BotName == "" {
+		http.Error(w, "bot_name is required", http.StatusBadRequest)
+		return
+	}
+	if req.BotUUID == "" {
+		req.BotUUID = uuid.New().String()
+	}
+	if len(req.BotUUID) < 8 {
+		http.Error(w, "bot_uuid must be at least 8 characters", http.StatusBadRequest)
+		return
+	}
+	if _, admin, _ := authenticateKey(r); !(config.Auth.Enabled && admin) {
+		debated, err := db.BotHasDebated(req.BotUUID)
+		if err != nil {
+			log.Printf("Failed to look up debates of bot %s: %v", req.BotUUID, err)
+			http.Error(w, "Failed to register bot", http.StatusInternalServerError)
+			return
+		}
+		if debated {
+			http.Error(w, "bot_uuid already has debate history; only an admin can register it", http.StatusConflict)
+			return
+		}
+	}
+
+	token := generateBotToken()
+	bot := &RegisteredBot{
+		BotUUID:       req.BotUUID,
+		BotName:       req.BotName,
+		BotIdentifier: botIdentifier(req.BotName, req.BotUUID),
+		TokenHash:     hashBotToken(token),
+	}
+	switch err := db.RegisterBot(bot); err {
+	case nil:
+	case errBotRegistered:
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	default:
+		log.Printf("Failed to register bot %s: %v", bot.BotIdentifier, err)
+		http.Error(w, "Failed to register bot", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Registered bot %s", bot.BotIdentifier)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(RegisterBotResponse{
+		BotName:       bot.BotName,
+		BotUUID:       bot.BotUUID,
+		BotIdentifier: bot.BotIdentifier,
+		Token:         token,
+	})
+}
+
+// checkBotToken verifies the token of a login. Registered bots must present
+// their token and log in under their registered name; unregistered bots are
+// refused when tokens are required or when their identifier collides with
+// a registered bot's.
+func checkBotToken(loginReq *LoginRequest) *LoginRejected {
+	reject := func(reason, message string) *LoginRejected {
+		return &LoginRejected{Status: "rejected", Reason: reason, Message: message, DebateID: loginReq.DebateID}
+	}
+
+	registered, err := db.GetRegisteredBot(loginReq.BotUUID)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Failed to look up bot %s: %v", loginReq.BotUUID, err)
+		return reject("internal_error", "Could not verify the bot token, try again later")
+	}
+	if registered == nil {
+		if config.Debate.RequireBotToken {
+			return reject("token_required", "This server only admits registered bots. Register via /api/bots/register and log in with the token.")
+		}
+		taken, err := db.BotIdentifierRegistered(botIdentifier(loginReq.BotName, loginReq.BotUUID))
+		if err != nil {
+			log.Printf("Failed to look up bot identifier for %s: %v", loginReq.BotUUID, err)
+			return reject("internal_error", "Could not verify the bot token, try again later")
+		}
+		if taken {
+			return reject("identifier_taken", "This bot identifier belongs to a registered bot")
+		}
+		return nil
+	}
+
+	if loginReq.Token == "" || subtle.ConstantTimeCompare([]byte(hashBotToken(loginReq.Token)), []byte(registered.TokenHash)) != 1 {
+		log.Printf("Rejected login for registered bot %s: missing or invalid token", registered.BotIdentifier)
+		return reject("invalid_token", "Missing or invalid token for this bot_uuid")
+	}
+	// The identifier stays the registered one
+	loginReq.BotName = registered.BotName
+	db.TouchRegisteredBot(registered.BotUUID)
+	return nil
+}
+
+// errBotRegistered is returned when a bot_uuid or identifier is registered already
+var errBotRegistered = fmt.Errorf("bot_uuid or bot identifier is already registered")
+
+// RegisterBot stores a registered bot
+func (d *Database) RegisterBot(bot *RegisteredBot) error {
+	query := `INSERT INTO registered_bots (bot_uuid, bot_name, bot_identifier, token_hash, created_at)
+	          VALUES (?, ?, ?, ?, ?)`
+	_, err := d.db.Exec(query, bot.BotUUID, bot.BotName, bot.BotIdentifier, bot.TokenHash, time.Now())
+	if err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		return errBotRegistered
+	}
+	return err
+}
+
+// GetRegisteredBot returns the registration of a bot_uuid
+func (d *Database) GetRegisteredBot(botUUID string) (*RegisteredBot, error) {
+	bot := &RegisteredBot{}
+	query := `SELECT bot_uuid, bot_name, bot_identifier, token_hash FROM registered_bots WHERE bot_uuid = ?`
+	err := d.db.QueryRow(query, botUUID).Scan(&bot.BotUUID, &bot.BotName, &bot.BotIdentifier, &bot.TokenHash)
+	if err != nil {
+		return nil, err
+	}
+	return bot, nil
+}
+
+// BotIdentifierRegistered reports whether a registered bot uses an identifier
+func (d *Database) BotIdentifierRegistered(identifier string) (bool, error) {
+	var count int
+	err := d.db.QueryRow(`SELECT COUNT(*) FROM registered_bots WHERE bot_identifier = ?`, identifier).Scan(&count)
+	return count > 0, err
+}
+
+// BotHasDebated reports whether a bot_uuid has joined any debate
+func (d *Database) BotHasDebated(botUUID string) (bool, error) {
+	var count int
+	err := d.db.QueryRow(`SELECT COUNT(*) FROM bots WHERE bot_uuid = ?`, botUUID).Scan(&count)
+	return count > 0, err
+}
+
+// TouchRegisteredBot records a registered bot's latest login
+func (d *Database) TouchRegisteredBot(botUUID string) error {
+	_, err := d.db.Exec(`UPDATE registered_bots SET last_login_at = ? WHERE bot_uuid = ?`, time.Now(), botUUID)
+	return err
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+)
+
+// eventCategories groups broadcast message types so spectators can choose
+// what they receive. Types not listed here (debate_start, debate_end, ...)
+// are lifecycle events and always delivered.
+var eventCategories = map[string]string{
+	"debate_update":       "speeches",
+	"round_reveal":        "speeches",
+	"opening_reveal":      "speeches",
+	"debate_waiting":      "status",
+	"judging_in_progress": "status",
+	"judging_stage":       "status",
+	"debate_overtime":     "status",
+	"debate_paused":       "status",
+	"server_shutdown":     "status",
+	"debate_resumed":      "status",
+	"debate_closing":      "status",
+	"warning_issued":      "status",
+	"participant_status":  "status",
+	"spectator_count":     "status",
+	"turn_countdown":      "timers",
+	"round_result":        "results",
+	"chat_message":        "chat",
+	"chat_history":        "chat",
+	"reaction":            "reactions",
+	"judge_commentary":    "commentary",
+	"judge_stream":        "commentary",
+	"speech_translation":  "speeches",
+}
+
+// knownEventCategories are the categories a subscriber may request
+var knownEventCategories = map[string]bool{
+	"speeches":   true,
+	"status":     true,
+	"timers":     true,
+	"results":    true,
+	"chat":       true,
+	"reactions":  true,
+	"commentary": true,
+}
+
+// EventFilter is the set of event categories a spectator connection wants.
+// A nil filter receives everything.
+type EventFilter map[string]bool
+
+// Subscriber is a spectator connection's subscription preferences
+type Subscriber struct {
+	Filter   EventFilter
+	Language string // Display language for bilingual debates
+
+	session int64 // spectator_sessions row, 0 if not recorded
+}
+
+// Wants reports whether a broadcast should reach this subscriber. Translations
+// only go to spectators displaying that language.
+func (s *Subscriber) Wants(msg Message) bool {
+	if !s.Filter.Allows(msg.Type) {
+		return false
+	}
+	if translation, ok := msg.Data.(SpeechTranslation); ok {
+		return s.Language == translation.Language
+	}
+	return true
+}
+
+// newEventFilter builds a filter from the categories named in subscribe_debate
+func newEventFilter(events []string) (EventFilter, error) {
+	if len(events) == 0 {
+		return nil, nil
+	}
+	filter := EventFilter{}
+	for _, category := range events {
+		if !knownEventCategories[category] {
+			return nil, fmt.Errorf("unknown event category %q", category)
+		}
+		filter[category] = true
+	}
+	return filter, nil
+}
+
+// Allows reports whether a message type passes the filter
+func (f EventFilter) Allows(msgType string) bool {
+	if f == nil {
+		return true
+	}
+	category, categorized := eventCategories[msgType]
+	return !categorized || f[category]
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// BudgetGuard caps LLM usage across the judge and the house bot. Calls over
+// the hourly limit are delayed (up to MaxDelay); once the daily token or cost
+// cap is reached, LLM calls are refused until the next UTC day. Once the
+// monthly token cap is reached, AI judging is off until the next UTC month.
+// The daily and monthly counters start from the usage recorded in
+// llm_usage, so a restart does not reset them.
+type BudgetGuard struct {
+	MaxCallsPerHour     int
+	MaxTokensPerDay     int
+	MaxCostPerDay       float64
+	MaxTokensPerMonth   int
+	PromptCostPer1K     float64
+	CompletionCostPer1K float64
+	MaxDelay            time.Duration
+
+	mutex       sync.Mutex
+	calls       []time.Time // Call start times within the last hour
+	day         string      // UTC date the daily counters belong to
+	tokens      int
+	cost        float64
+	month       string // UTC month the monthly counter belongs to, YYYY-MM
+	monthTokens int
+	alertedOn   map[string]string // alert kind -> day, or month for the monthly cap, it was raised
+}
+
+// errLLMBudget is wrapped by errors returned when the budget refuses a call
+var errLLMBudget = errors.New("LLM budget")
+
+// BudgetStatus is a snapshot of budget usage for the admin API
+type BudgetStatus struct {
+	CallsLastHour   int     `json:"calls_last_hour"`
+	MaxCallsPerHour int     `json:"max_calls_per_hour"`
+	TokensToday     int     `json:"tokens_today"`
+	MaxTokensPerDay int     `json:"max_tokens_per_day"`
+	CostToday       float64 `json:"cost_today"`
+	MaxCostPerDay   float64 `json:"max_cost_per_day"`
+	Exhausted       bool    `json:"exhausted"`
+
+	TokensThisMonth   int  `json:"tokens_this_month"`
+	MaxTokensPerMonth int  `json:"max_tokens_per_month"`
+	MonthlyExhausted  bool `json:"monthly_exhausted"` // AI judging is off until next month
+}
+
+// NewBudgetGuard creates a budget guard from config, starting from the
+// usage already recorded today and this month
+func NewBudgetGuard(cfg *Config, d *Database) (*BudgetGuard, error) {
+	b := cfg.ChatGPT.Budget
+	g := &BudgetGuard{
+		MaxCallsPerHour:     b.MaxCallsPerHour,
+		MaxTokensPerDay:     b.MaxTokensPerDay,
+		MaxCostPerDay:       b.MaxCostPerDay,
+		MaxTokensPerMonth:   b.MaxTokensPerMonth,
+		PromptCostPer1K:     b.PromptCostPer1K,
+		CompletionCostPer1K: b.CompletionCostPer1K,
+		MaxDelay:            time.Duration(b.MaxDelay) * time.Second,
+		alertedOn:           make(map[string]string),
+	}
+	now := time.Now().UTC()
+	g.day = now.Format("2006-01-02")
+	today, err := d.GetUsageTotals(dayStart(now))
+	if err != nil {
+		return nil, err
+	}
+	g.tokens, g.cost = today.TotalTokens, today.Cost
+	g.month = now.Format("2006-01")
+	month, err := d.GetUsageTotals(monthStart(now))
+	if err != nil {
+		return nil, err
+	}
+	g.monthTokens = month.TotalTokens
+	return g, nil
+}
+
+// dayStart returns the first instant of a time's UTC day
+func dayStart(now time.Time) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// monthStart returns the first instant of a time's UTC month
+func monthStart(now time.Time) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// rollDay resets daily counters when the UTC date changes, and the monthly
+// counter when the month does. Caller holds the mutex.
+func (b *BudgetGuard) rollDay(now time.Time) {
+	today := now.UTC().Format("2006-01-02")
+	if b.day != today {
+		b.day = today
+		b.tokens = 0
+		b.cost = 0
+	}
+	month := now.UTC().Format("2006-01")
+	if b.month != month {
+		b.month = month
+		b.monthTokens = 0
+	}
+}
+
+// pruneCalls drops call timestamps older than one hour. Caller holds the mutex.
+func (b *BudgetGuard) pruneCalls(now time.Time) {
+	cutoff := now.Add(-time.Hour)
+	i := 0
+	for i < len(b.calls) && b.calls[i].Before(cutoff) {
+		i++
+	}
+	b.calls = b.calls[i:]
+}
+
+// dailyExhausted reports whether a daily cap has been hit. Caller holds the mutex.
+func (b *BudgetGuard) dailyExhausted() (string, bool) {
+	if b.MaxTokensPerDay > 0 && b.tokens >= b.MaxTokensPerDay {
+		return fmt.Sprintf("daily token budget exhausted (%d/%d)", b.tokens, b.MaxTokensPerDay), true
+	}
+	if b.MaxCostPerDay > 0 && b.cost >= b.MaxCostPerDay {
+		return fmt.Sprintf("daily cost budget exhausted ($%.2f/$%.2f)", b.cost, b.MaxCostPerDay), true
+	}
+	return "", false
+}
+
+// Acquire reserves one LLM call, waiting for an hourly slot if necessary.
+// It returns an error when the call must not be made.
+func (b *BudgetGuard) Acquire() error {
+	if b == nil {
+		return nil
+	}
+
+	deadline := time.Now().Add(b.MaxDelay)
+	for {
+		b.mutex.Lock()
+		now := time.Now()
+		b.rollDay(now)
+		b.pruneCalls(now)
+
+		if reason, exhausted := b.dailyExhausted(); exhausted {
+			b.alertOnce("llm_budget_daily", reason)
+			b.mutex.Unlock()
+			return fmt.Errorf("%w: %s", errLLMBudget, reason)
+		}
+
+		if b.MaxCallsPerHour <= 0 || len(b.calls) < b.MaxCallsPerHour {
+			b.calls = append(b.calls, now)
+			b.mutex.Unlock()
+			return nil
+		}
+
+		// Wait until the oldest call leaves the window
+		wait := b.calls[0].Add(time.Hour).Sub(now)
+		b.alertOnce("llm_budget_hourly", fmt.Sprintf("hourly call limit reached (%d/hour), delaying LLM calls", b.MaxCallsPerHour))
+		b.mutex.Unlock()
+
+		if now.Add(wait).After(deadline) {
+			return fmt.Errorf("%w: hourly call limit reached (%d/hour)", errLLMBudget, b.MaxCallsPerHour)
+		}
+		log.Printf("LLM budget: delaying call by %v (hourly limit reached)", wait)
+		time.Sleep(wait)
+	}
+}
+
+// Record adds the token usage of a completed call
+func (b *BudgetGuard) Record(promptTokens, completionTokens int) {
+	if b == nil {
+		return
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.rollDay(time.Now())
+	b.tokens += promptTokens + completionTokens
+	b.cost += float64(promptTokens)/1000*b.PromptCostPer1K + float64(completionTokens)/1000*b.CompletionCostPer1K
+	b.monthTokens += promptTokens + completionTokens
+	if b.monthlyExhausted() && b.alertedOn["llm_budget_monthly"] != b.month {
+		b.alertedOn["llm_budget_monthly"] = b.month
+		go RaiseAlert("llm_budget_monthly", fmt.Sprintf("monthly token budget exhausted (%d/%d), AI judging is off until next month", b.monthTokens, b.MaxTokensPerMonth))
+	}
+}
+
+// monthlyExhausted reports whether the monthly token cap has been hit. Caller holds the mutex.
+func (b *BudgetGuard) monthlyExhausted() bool {
+	return b.MaxTokensPerMonth > 0 && b.monthTokens >= b.MaxTokensPerMonth
+}
+
+// MonthlyExhausted reports whether this month's token budget is used up
+func (b *BudgetGuard) MonthlyExhausted() bool {
+	if b == nil {
+		return false
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.rollDay(time.Now())
+	return b.monthlyExhausted()
+}
+
+// Status returns current usage
+func (b *BudgetGuard) Status() BudgetStatus {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	now := time.Now()
+	b.rollDay(now)
+	b.pruneCalls(now)
+	_, exhausted := b.dailyExhausted()
+
+	return BudgetStatus{
+		CallsLastHour:   len(b.calls),
+		MaxCallsPerHour: b.MaxCallsPerHour,
+		TokensToday:     b.tokens,
+		MaxTokensPerDay: b.MaxTokensPerDay,
+		CostToday:       b.cost,
+		MaxCostPerDay:   b.MaxCostPerDay,
+		Exhausted:       exhausted,
+
+		TokensThisMonth:   b.monthTokens,
+		MaxTokensPerMonth: b.MaxTokensPerMonth,
+		MonthlyExhausted:  b.monthlyExhausted(),
+	}
+}
+
+// alertOnce raises an admin alert at most once per day per kind. Caller holds the mutex.
+func (b *BudgetGuard) alertOnce(kind, message string) {
+	if b.alertedOn[kind] == b.day {
+		return
+	}
+	b.alertedOn[kind] = b.day
+	go RaiseAlert(kind, message)
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// StatusCancelled marks a debate called off through the API before it finished
+const StatusCancelled = "cancelled"
+
+var (
+	errNotCancellable = errors.New("only waiting or running debates can be cancelled")
+	errRunsElsewhere  = errors.New("debate is running on another instance")
+)
+
+// CancelDebate ends a waiting or running debate without judging it: its
+// clocks stop, the reason is stored with the cancelled status, and its bots
+// and spectators receive debate_end. A waiting debate no bot has joined yet
+// only exists in the database and is cancelled there.
+func (dm *DebateManager) CancelDebate(debateID, reason string) error {
+	dm.mutex.RLock()
+	activeDebate, exists := dm.debates[debateID]
+	dm.mutex.RUnlock()
+
+	if !exists {
+		debate, err := dm.db.GetDebate(debateID)
+		if err != nil {
+			return err
+		}
+		if debate.Status != "waiting" {
+			if isInProgress(debate.Status) {
+				return errRunsElsewhere
+			}
+			return errNotCancellable
+		}
+		if err := dm.db.CancelDebate(debateID, "waiting", reason); err != nil {
+			return err
+		}
+		log.Printf("Debate %s cancelled before any bot joined: %s", debateID, reason)
+		webhooks.Send(WebhookDebateEnd, debateID, DebateEnd{
+			DebateID:     debateID,
+			Topic:        debate.Topic,
+			TotalRounds:  debate.TotalRounds,
+			Status:       StatusCancelled,
+			DebateResult: DebateResult{Winner: "none", Reason: StatusCancelled},
+		})
+		go matchEnded(debate, nil)
+		return nil
+	}
+
+	status := activeDebate.Debate.Status
+	if status != "waiting" && !isInProgress(status) {
+		return errNotCancellable
+	}
+	for _, timer := range []*time.Timer{activeDebate.WaitingTimer, activeDebate.TimeoutTimer, activeDebate.InactivityTimer, activeDebate.MaxDurationTimer} {
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+	stopCountdown(activeDebate)
+
+	if err := dm.db.CancelDebate(debateID, status, reason); err != nil {
+		return err
+	}
+	activeDebate.Debate.Status = StatusCancelled
+	activeDebate.Debate.CancelReason = reason
+
+	supportingSide, opposingSide := activeDebate.teamName("supporting"), activeDebate.teamName("opposing")
+	if supportingSide == "" {
+		supportingSide = "未连接"
+	}
+	if opposingSide == "" {
+		opposingSide = "未连接"
+	}
+	result := cancellationResult(activeDebate, supportingSide, opposingSide, reason)
+	endMsg := createMessage("debate_end", DebateEnd{
+		DebateID:       debateID,
+		Topic:          activeDebate.Debate.Topic,
+		SupportingSide: supportingSide,
+		OpposingSide:   opposingSide,
+		TotalRounds:    activeDebate.Debate.TotalRounds,
+		Status:         StatusCancelled,
+		DebateLog:      activeDebate.DebateLog,
+		DebateResult:   *result,
+	})
+	for _, bot := range activeDebate.Bots {
+		if bot.Conn != nil {
+			bot.Conn.WriteJSON(endMsg)
+		}
+	}
+	dm.broadcast <- BroadcastMessage{DebateID: debateID, Message: endMsg}
+	webhooks.Send(WebhookDebateEnd, debateID, endMsg.Data)
+	go matchEnded(activeDebate.Debate, nil)
+
+	log.Printf("Debate %s cancelled in round %d: %s", debateID, activeDebate.Debate.CurrentRound, reason)
+	return nil
+}
+
+// cancellationResult is the result sent with the debate_end of a cancelled
+// debate. Nothing is judged and it is not stored, so it counts towards no stats.
+func cancellationResult(activeDebate *ActiveDebate, supportingSide, opposingSide, reason string) *DebateResult {
+	result := &DebateResult{
+		Winner:  "none",
+		Summary: SpeechMessage{Format: "markdown"},
+		Reason:  StatusCancelled,
+	}
+	result.Summary.Content = fmt.Sprintf(`## 辩论已取消
+
+**辩题**: %s
+
+### 正方: %s
+
+### 反方: %s
+
+### 结果
+辩论在第 %d 轮被取消，不作评判。
+
+**取消原因**: %s
+
+**获胜方**: 无`, activeDebate.Debate.Topic,
+		supportingSide, opposingSide, activeDebate.Debate.CurrentRound, reason)
+	return result
+}
+
+// handleCancelDebate handles DELETE /api/debate/{id} and POST
+// /api/debate/{id}/cancel, with an optional body {"reason": "..."}
+func handleCancelDebate(w http.ResponseWriter, r *http.Request, debateID string) {
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	if req.Reason == "" {
+		req.Reason = "Cancelled by a moderator"
+	}
+
+	switch err := debateManager.CancelDebate(debateID, req.Reason); err {
+	case nil:
+	case sql.ErrNoRows:
+		http.Error(w, "Debate not found", http.StatusNotFound)
+		return
+	case errNotCancellable, errRunsElsewhere:
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	default:
+		log.Printf("Failed to cancel debate %s: %v", debateID, err)
+		http.Error(w, "Failed to cancel debate", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]interface{}{"debate_id": debateID, "status": StatusCancelled, "reason": req.Reason})
+}
+
+// CancelDebate stores a debate as cancelled, provided it still has the
+// status the caller saw; otherwise it returns errNotCancellable
+func (d *Database) CancelDebate(debateID, fromStatus, reason string) error {
+	query := `UPDATE debates SET status = ?, cancel_reason = ?, updated_at = ? WHERE id = ? AND status = ?`
+	res, err := d.db.Exec(query, StatusCancelled, reason, time.Now(), debateID, fromStatus)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errNotCancellable
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+)
+
+// Deployments differ in what they enable: the AI judge, the house bot,
+// auth, rate limits. /api/capabilities describes this one so bot SDKs and
+// frontends can adapt instead of probing endpoints or hard-coding a setup.
+// Features the platform does not have, such as audio, are listed as false
+// so clients can tell "off here" from "unknown".
+
+// Capabilities describes what this deployment supports
+type Capabilities struct {
+	Protocol CapabilityProtocol `json:"protocol"`
+	Debate   CapabilityDebate   `json:"debate"`
+	Judge    JudgeStatus        `json:"judge"`
+	Auth     CapabilityAuth     `json:"auth"`
+	Features CapabilityFeatures `json:"features"`
+}
+
+// CapabilityProtocol is the bot and spectator wire protocol
+type CapabilityProtocol struct {
+	Subprotocols     []string            `json:"subprotocols"`
+	PayloadVersions  []int               `json:"payload_versions"`
+	MinClientVersion string              `json:"min_client_version,omitempty"`
+	MessageTypes     map[string][]string `json:"message_types"` // Direction (bot, frontend, server) -> message types
+}
+
+// CapabilityDebate is what a created debate may be
+type CapabilityDebate struct {
+	DefaultRounds     int      `json:"default_rounds"`
+	MaxRounds         int      `json:"max_rounds"` // 0 is no limit
+	Formats           []string `json:"formats"`
+	Scoring           []string `json:"scoring"`
+	VerdictLengths    []string `json:"verdict_lengths"`
+	BlindOpening      bool     `json:"blind_opening"`
+	Panels            bool     `json:"panels"` // More than two bots via seats or participants
+	SideChannel       bool     `json:"side_channel"`
+	Bilingual         bool     `json:"bilingual"`
+	AutoTranslate     bool     `json:"auto_translate"`
+	SpeechTimeout     int      `json:"speech_timeout"`
+	MinContentLength  int      `json:"min_content_length"`
+	MaxContentLength  int      `json:"max_content_length"`
+	MaxContentCeiling int      `json:"max_content_ceiling"` // Most a debate's limits may raise max_content_length to, 0 is no limit
+	MinSpeechTimeout  int      `json:"min_speech_timeout"`  // Least a debate's limits may lower speech_timeout to, 0 is no limit
+	MaxDuration       int      `json:"max_duration"`
+	AutoAssign        string   `json:"auto_assign"`      // Pairing policy for logins without a debate_id
+	DiscloseRubric    bool     `json:"disclose_rubric"`  // Whether debates tell bots the judging rubric unless created otherwise
+	OpponentSummary   bool     `json:"opponent_summary"` // Whether debates summarize the opponent in debate_update unless created otherwise
+}
+
+// CapabilityAuth is what clients must present
+type CapabilityAuth struct {
+	APIKeyRequired      bool `json:"api_key_required"`
+	BotRegistration     bool `json:"bot_registration"`
+	BotTokenRequired    bool `json:"bot_token_required"`
+	ConformanceRequired bool `json:"conformance_required"` // Ranked debates need a passing conformance run
+	RateLimited         bool `json:"rate_limited"`
+}
+
+// CapabilityFeatures are optional features
+type CapabilityFeatures struct {
+	Streaming      []string `json:"streaming"`       // Transports pushing live debate updates
+	SpeechChunks   bool     `json:"speech_chunks"`   // Speeches streamed while being written
+	Attachments    []string `json:"attachments"`     // Content types speeches may attach; empty if none
+	Audio          bool     `json:"audio"`           // Spoken speeches
+	AudienceVoting bool     `json:"audience_voting"` // Spectators voting on the outcome
+	HouseBot       bool     `json:"house_bot"`
+	Sandbox        bool     `json:"sandbox"`
+	SandboxDebate  bool     `json:"sandbox_debate"`  // Practice debates against the house bot from /api/sandbox/debate
+	TopicGenerator bool     `json:"topic_generator"` // LLM-proposed debate topics from /api/topics/generate
+	Tournaments    bool     `json:"tournaments"`
+	Leagues        bool     `json:"leagues"`
+	Search         bool     `json:"search"`
+	Export         []string `json:"export"`
+	Import         bool     `json:"import"`
+	WatchParties   bool     `json:"watch_parties"`  // Synchronized replays of finished debates
+	SpectatorChat  bool     `json:"spectator_chat"` // Spectators chatting over the frontend WebSocket
+	Webhooks       bool     `json:"webhooks"`
+	ReadOnly       bool     `json:"read_only"` // A replica: no debates run and no bots connect here
+}
+
+// currentCapabilities describes this deployment as configured
+func currentCapabilities() Capabilities {
+	d := config.Debate
+	caps := Capabilities{
+		Protocol: CapabilityProtocol{
+			Subprotocols:     supportedSubprotocols,
+			MinClientVersion: d.MinClientVersion,
+			MessageTypes:     map[string][]string{},
+		},
+		Debate: CapabilityDebate{
+			DefaultRounds:     defaultTotalRounds,
+			MaxRounds:         d.MaxRounds,
+			Formats:           []string{FormatSequential, FormatSimultaneous},
+			Scoring:           []string{ScoringHolistic, ScoringRounds, ScoringCumulative},
+			VerdictLengths:    []string{VerdictBrief, VerdictStandard, VerdictDetailed},
+			BlindOpening:      true,
+			Panels:            true,
+			SideChannel:       true,
+			Bilingual:         true,
+			AutoTranslate:     d.AutoTranslate && judgeUnavailable() == "",
+			SpeechTimeout:     d.SpeechTimeout,
+			MinContentLength:  d.MinContentLength,
+			MaxContentLength:  d.MaxContentLength,
+			MaxContentCeiling: d.MaxContentCeiling,
+			MinSpeechTimeout:  d.MinSpeechTimeout,
+			MaxDuration:       d.MaxDuration,
+			AutoAssign:        d.Pairing.Policy,
+			DiscloseRubric:    d.DiscloseRubric,
+			OpponentSummary:   d.OpponentSummary,
+		},
+		Judge: currentJudgeStatus(),
+		Auth: CapabilityAuth{
+			APIKeyRequired:      config.Auth.Enabled,
+			BotRegistration:     true,
+			BotTokenRequired:    d.RequireBotToken,
+			ConformanceRequired: d.RequireConformance,
+			RateLimited:         config.RateLimit.Enabled,
+		},
+		Features: CapabilityFeatures{
+			Streaming:      []string{"websocket", "sse"},
+			HouseBot:       config.ChatGPT.HouseBot.Enabled,
+			Sandbox:        config.ChatGPT.Sandbox.Enabled && judgeUnavailable() == "" && !chatgptClient.Mock,
+			SandboxDebate:  config.ChatGPT.Sandbox.Debate.Enabled && config.ChatGPT.HouseBot.Enabled,
+			TopicGenerator: config.ChatGPT.Topics.Enabled,
+			Tournaments:    true,
+			Leagues:        true,
+			Search:         true,
+			Export:         []string{ExportMarkdown, ExportPDF, ExportJSON},
+			Import:         true,
+			WatchParties:   true,
+			SpectatorChat:  config.Frontend.Chat.Enabled && !isReplica(),
+			Webhooks:       len(config.Webhooks.Endpoints) > 0,
+			ReadOnly:       isReplica(),
+		},
+	}
+	caps.Features.Attachments = []string{}
+	if config.Artifacts.MaxPerSpeech > 0 {
+		caps.Features.Attachments = attachmentTypes
+	}
+
+	versions := map[int]bool{}
+	for direction, specs := range messageRegistry {
+		types := make([]string, 0, len(specs))
+		for msgType, spec := range specs {
+			types = append(types, msgType)
+			for version := range spec.Payloads {
+				versions[version] = true
+			}
+		}
+		sort.Strings(types)
+		caps.Protocol.MessageTypes[direction] = types
+	}
+	for version := range versions {
+		caps.Protocol.PayloadVersions = append(caps.Protocol.PayloadVersions, version)
+	}
+	sort.Ints(caps.Protocol.PayloadVersions)
+	return caps
+}
+
+// handleCapabilities handles GET /api/capabilities
+func handleCapabilities(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, currentCapabilities())
+}
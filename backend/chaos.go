@@ -0,0 +1,130 @@
+package main
+
+import (
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// ChaosInjector randomly perturbs bot traffic so bot authors can test their
+// clients against flaky networks. It is a test-only feature: a nil injector
+// (the default) does nothing.
+type ChaosInjector struct {
+	DelayProbability     float64
+	MaxDelay             time.Duration
+	DuplicateProbability float64
+	DropPongProbability  float64
+	ErrorProbability     float64
+
+	mutex   sync.Mutex
+	pending map[*WSConn][]chaosWrite // Messages queued behind a delay, per bot; present while a drain runs
+}
+
+// chaosWrite is a bot message waiting out its injected delay
+type chaosWrite struct {
+	msg   Message
+	delay time.Duration
+}
+
+// NewChaosInjector creates a chaos injector from config, or nil when disabled
+func NewChaosInjector(cfg *Config) *ChaosInjector {
+	if !cfg.Chaos.Enabled {
+		return nil
+	}
+	log.Printf("WARNING: chaos mode enabled - bot traffic will be randomly delayed, duplicated and dropped")
+	return &ChaosInjector{
+		DelayProbability:     cfg.Chaos.DelayProbability,
+		MaxDelay:             time.Duration(cfg.Chaos.MaxDelayMs) * time.Millisecond,
+		DuplicateProbability: cfg.Chaos.DuplicateUpdateProbability,
+		DropPongProbability:  cfg.Chaos.DropPongProbability,
+		ErrorProbability:     cfg.Chaos.TransientErrorProbability,
+		pending:              make(map[*WSConn][]chaosWrite),
+	}
+}
+
+// roll returns true with the given probability
+func (c *ChaosInjector) roll(p float64) bool {
+	return p > 0 && rand.Float64() < p
+}
+
+// WriteToBot sends a message to a bot, possibly delayed, duplicated, or
+// followed by a spurious recoverable error. Callers often hold the debate's
+// lock, so a delayed message is written by a goroutine once its delay has
+// passed, and later messages to the same bot queue behind it to keep their
+// order.
+func (c *ChaosInjector) WriteToBot(conn *WSConn, msg Message) error {
+	if c == nil {
+		return conn.WriteJSON(msg)
+	}
+
+	var delay time.Duration
+	if c.roll(c.DelayProbability) && c.MaxDelay > 0 {
+		delay = time.Duration(rand.Int63n(int64(c.MaxDelay)))
+		log.Printf("Chaos: delaying %s by %v", msg.Type, delay)
+	}
+
+	c.mutex.Lock()
+	queue, draining := c.pending[conn]
+	if delay == 0 && !draining {
+		c.mutex.Unlock()
+		return c.write(conn, msg)
+	}
+	c.pending[conn] = append(queue, chaosWrite{msg: msg, delay: delay})
+	if !draining {
+		go c.drain(conn)
+	}
+	c.mutex.Unlock()
+	return nil
+}
+
+// drain writes a bot's queued messages in order, each after its delay
+func (c *ChaosInjector) drain(conn *WSConn) {
+	for {
+		c.mutex.Lock()
+		queue := c.pending[conn]
+		if len(queue) == 0 {
+			delete(c.pending, conn)
+			c.mutex.Unlock()
+			return
+		}
+		next := queue[0]
+		c.pending[conn] = queue[1:]
+		c.mutex.Unlock()
+
+		time.Sleep(next.delay)
+		c.write(conn, next.msg)
+	}
+}
+
+// write sends a message, possibly duplicated or followed by a spurious error
+func (c *ChaosInjector) write(conn *WSConn, msg Message) error {
+	if err := conn.WriteJSON(msg); err != nil {
+		return err
+	}
+
+	if msg.Type == "debate_update" && c.roll(c.DuplicateProbability) {
+		log.Printf("Chaos: duplicating debate_update")
+		conn.WriteJSON(msg)
+	}
+
+	if c.roll(c.ErrorProbability) {
+		log.Printf("Chaos: injecting transient error after %s", msg.Type)
+		conn.WriteJSON(createMessage("error", ErrorMessage{
+			ErrorCode:   "CHAOS_TRANSIENT_ERROR",
+			Message:     "Injected transient error (chaos mode), safe to ignore",
+			Recoverable: true,
+		}))
+	}
+
+	return nil
+}
+
+// DropPong reports whether a received pong should be ignored
+func (c *ChaosInjector) DropPong() bool {
+	if c == nil || !c.roll(c.DropPongProbability) {
+		return false
+	}
+	log.Printf("Chaos: dropping pong")
+	return true
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -18,6 +19,10 @@ type ChatGPTClient struct {
 	Timeout    time.Duration
 	MaxTokens  int
 	Temperature float64
+	Fallbacks  []*ChatGPTClient // Judge only: tried in order when a judge call fails, see judge_failover.go
+	Mock       bool             // Judge only: verdicts come from the mock judge, see judge_mock.go
+	DebateID   string           // Debate the client's token usage is recorded against, see usage.go
+	Purpose    string           // What the client's calls are for, e.g. UsageJudge
 }
 
 // ChatGPTMessage represents a message in the conversation
@@ -32,6 +37,9 @@ type ChatGPTRequest struct {
 	Messages    []ChatGPTMessage `json:"messages"`
 	MaxTokens   int              `json:"max_tokens,omitempty"`
 	Temperature float64          `json:"temperature,omitempty"`
+
+	Stream        bool           `json:"stream,omitempty"`
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
 }
 
 // ChatGPTResponse represents the response from ChatGPT API
@@ -67,27 +75,86 @@ func NewChatGPTClient(apiKey, apiURL, model string, timeout int, maxTokens int,
 	}
 }
 
+var (
+	errAPIKeyMissing = errors.New("ChatGPT API key not configured")
+	errEmptyResponse = errors.New("no response from ChatGPT")
+)
+
+// apiStatusError is returned when the API answers with a non-200 status
+type apiStatusError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration // From the Retry-After header, if any
+}
+
+func (e *apiStatusError) Error() string {
+	return fmt.Sprintf("API returned status %d: %s", e.StatusCode, e.Body)
+}
+
 // SendMessage sends a message to ChatGPT and returns the response
 func (c *ChatGPTClient) SendMessage(messages []ChatGPTMessage) (string, error) {
-	if c.APIKey == "" || c.APIKey == "your-api-key-here" {
-		return "", fmt.Errorf("ChatGPT API key not configured")
-	}
-
-	reqBody := ChatGPTRequest{
+	resp, err := c.post(ChatGPTRequest{
 		Model:       c.Model,
 		Messages:    messages,
 		MaxTokens:   c.MaxTokens,
 		Temperature: c.Temperature,
+	})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var chatResp ChatGPTResponse
+	if err := json.Unmarshal(body, &chatResp); err != nil {
+		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	c.recordUsage(chatResp.Usage.PromptTokens, chatResp.Usage.CompletionTokens)
+
+	if len(chatResp.Choices) == 0 {
+		return "", errEmptyResponse
+	}
+
+	return chatResp.Choices[0].Message.Content, nil
+}
+
+// post sends a chat completions request within the LLM budget. The caller
+// closes the body of the returned response, which has status 200.
+func (c *ChatGPTClient) post(reqBody ChatGPTRequest) (*http.Response, error) {
+	if c.APIKey == "" || c.APIKey == "your-api-key-here" {
+		return nil, errAPIKeyMissing
+	}
+
+	// Enforce the global LLM budget (may delay or refuse the call)
+	if err := llmBudget.Acquire(); err != nil {
+		return nil, err
 	}
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
+	// Retry a failing provider, unless its circuit breaker is open, see llm_retry.go
+	breaker := breakerFor(c.APIURL)
+	if err := breaker.Allow(); err != nil {
+		return nil, err
+	}
+	resp, err := c.sendWithRetry(jsonData)
+	breaker.Record(err)
+	return resp, err
+}
+
+// send makes one attempt at a chat completions request
+func (c *ChatGPTClient) send(jsonData []byte) (*http.Response, error) {
 	req, err := http.NewRequest("POST", c.APIURL, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -99,33 +166,50 @@ func (c *ChatGPTClient) SendMessage(messages []ChatGPTMessage) (string, error) {
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
-	}
-
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, &apiStatusError{StatusCode: resp.StatusCode, Body: string(body), RetryAfter: retryAfter(resp)}
 	}
+	return resp, nil
+}
 
-	var chatResp ChatGPTResponse
-	if err := json.Unmarshal(body, &chatResp); err != nil {
-		return "", fmt.Errorf("failed to unmarshal response: %w", err)
-	}
+// JudgeOptions override the judge model and rubric for a single call
+type JudgeOptions struct {
+	Model    string
+	Rubric   string
+	Persona  string                           // Judge panel member's framing; set by judgeWithPanel
+	Progress func(stage string)               // Called as each judging stage is reached; may be nil
+	Signals  []RelayedSignal                  // Side channel messages, shown to the judge after the speeches
+	Style    VerdictStyle                     // Shape of the summary
+	Stream   func(delta string, restart bool) // Receives the summary as the judge writes it; may be nil
+}
 
-	if len(chatResp.Choices) == 0 {
-		return "", fmt.Errorf("no response from ChatGPT")
+// report passes a judging stage to the Progress callback, if any
+func (o JudgeOptions) report(stage string) {
+	if o.Progress != nil {
+		o.Progress(stage)
 	}
+}
 
-	return chatResp.Choices[0].Message.Content, nil
+// JudgeDebate analyzes a debate and determines the winner; a close call is
+// rejudged, see settleCloseCall
+func (c *ChatGPTClient) JudgeDebate(topic string, debateLog []DebateLogEntry, supportingBot, opposingBot string, opts JudgeOptions) (*DebateResult, error) {
+	result, err := c.JudgeDebateWith(topic, debateLog, supportingBot, opposingBot, opts)
+	if err != nil {
+		return nil, err
+	}
+	return c.settleCloseCall(topic, debateLog, supportingBot, opposingBot, opts, result), nil
 }
 
-// JudgeDebate analyzes a debate and determines the winner
-func (c *ChatGPTClient) JudgeDebate(topic string, debateLog []DebateLogEntry, supportingBot, opposingBot string) (*DebateResult, error) {
+// JudgeDebateWith judges a debate using the given model and rubric overrides
+func (c *ChatGPTClient) JudgeDebateWith(topic string, debateLog []DebateLogEntry, supportingBot, opposingBot string, opts JudgeOptions) (*DebateResult, error) {
+	if opts.Persona == "" && len(config.ChatGPT.Judge.Panel) > 0 {
+		return c.judgeWithPanel(topic, debateLog, supportingBot, opposingBot, opts, config.ChatGPT.Judge.Panel)
+	}
+
 	// Build debate transcript
 	var transcript strings.Builder
 	transcript.WriteString(fmt.Sprintf("辩题: %s\n\n", topic))
@@ -140,24 +224,23 @@ func (c *ChatGPTClient) JudgeDebate(topic string, debateLog []DebateLogEntry, su
 		}
 		transcript.WriteString(fmt.Sprintf("【第%d轮 - %s】\n%s\n\n", entry.Round, sideName, entry.Message.Content))
 	}
+	transcript.WriteString(signalTranscript(opts.Signals))
 
 	// Create judge prompt
-	systemPrompt := `你是一位专业的辩论评委。请根据以下标准评判辩论：
-
-评分标准 (总分100分):
-1. 论点质量 (30分): 论点是否清晰、有力、有逻辑性
-2. 论据支持 (25分): 是否提供充分的事实、数据、案例支持
-3. 反驳能力 (20分): 是否有效反驳对方观点
-4. 表达能力 (15分): 语言是否流畅、有说服力
-5. 整体逻辑 (10分): 论证结构是否完整、严谨
-
-请按以下JSON格式返回评判结果:
-{
-  "winner": "supporting" 或 "opposing" 或 "draw",
-  "supporting_score": 0-100,
-  "opposing_score": 0-100,
-  "summary": "详细的评判总结，包括双方优缺点分析"
-}`
+	systemPrompt, _ := rubricPrompt(RubricDefault)
+	rubricID := RubricDefault
+	if opts.Rubric != "" {
+		systemPrompt = opts.Rubric
+		rubricID = RubricCustom
+	}
+	if config.ChatGPT.Judge.Feedback {
+		systemPrompt += feedbackRubric
+	}
+	systemPrompt += opts.Style.rubric()
+	rubric := snapshotRubric(rubricID, systemPrompt)
+	if opts.Persona != "" {
+		systemPrompt = opts.Persona + "\n\n" + systemPrompt
+	}
 
 	userPrompt := fmt.Sprintf("请评判以下辩论:\n\n%s", transcript.String())
 
@@ -165,16 +248,27 @@ func (c *ChatGPTClient) JudgeDebate(topic string, debateLog []DebateLogEntry, su
 		{Role: "system", Content: systemPrompt},
 		{Role: "user", Content: userPrompt},
 	}
+	opts.report(StageTranscriptPrepared)
 
-	response, err := c.SendMessage(messages)
+	client := c
+	if opts.Model != "" && opts.Model != c.Model {
+		override := *c
+		override.Model = opts.Model
+		client = &override
+	}
+
+	opts.report(StageLLMCallStarted)
+	response, client, err := client.judgeCall(messages, opts.Stream)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get judge response: %w", err)
 	}
 
 	// Parse response
+	opts.report(StageParsing)
 	result, err := c.parseJudgeResponse(response)
 	if err != nil {
 		// If parsing fails, create a fallback result
+		judgeMetrics.ObserveVerdict(VerdictUnparsed)
 		return &DebateResult{
 			Winner:          "draw",
 			SupportingScore: 50,
@@ -183,9 +277,21 @@ func (c *ChatGPTClient) JudgeDebate(topic string, debateLog []DebateLogEntry, su
 				Format:  "markdown",
 				Content: fmt.Sprintf("## AI评判结果\n\n%s\n\n注意: 自动解析失败，以原始回复为准。", response),
 			},
+			JudgeModel: client.Model,
+			Rubric:     rubric,
+			Source:     ResultSourceJudge,
+			ProducedBy: client.Model,
 		}, nil
 	}
 
+	judgeMetrics.ObserveVerdict(VerdictAI)
+	if rubricID == RubricDefault {
+		result.Breakdown = matchBreakdown(result.Breakdown, criteriaOf(RubricDefault))
+	}
+	result.JudgeModel = client.Model
+	result.Rubric = rubric
+	result.Source = ResultSourceJudge
+	result.ProducedBy = client.Model
 	return result, nil
 }
 
@@ -202,10 +308,14 @@ func (c *ChatGPTClient) parseJudgeResponse(response string) (*DebateResult, erro
 	jsonStr := response[startIdx : endIdx+1]
 
 	var judgeData struct {
-		Winner          string `json:"winner"`
-		SupportingScore int    `json:"supporting_score"`
-		OpposingScore   int    `json:"opposing_score"`
-		Summary         string `json:"summary"`
+		Winner          string                 `json:"winner"`
+		SupportingScore int                    `json:"supporting_score"`
+		OpposingScore   int                    `json:"opposing_score"`
+		Summary         string                 `json:"summary"`
+		Citations       []VerdictCitation      `json:"citations"`
+		Feedback        map[string]BotFeedback `json:"feedback"`
+		RoundCommentary []RoundComment         `json:"round_commentary"`
+		Breakdown       []CriterionScore       `json:"breakdown"`
 	}
 
 	if err := json.Unmarshal([]byte(jsonStr), &judgeData); err != nil {
@@ -225,13 +335,109 @@ func (c *ChatGPTClient) parseJudgeResponse(response string) (*DebateResult, erro
 		judgeData.Winner = "draw"
 	}
 
+	// Keep only citations that point at a side and round
+	citations := []VerdictCitation{}
+	for _, citation := range judgeData.Citations {
+		if citation.Round > 0 && (citation.Side == "supporting" || citation.Side == "opposing") {
+			citations = append(citations, citation)
+		}
+	}
+
+	// Keep only criterion scores within the rubric's range
+	var breakdown []CriterionScore
+	for _, score := range judgeData.Breakdown {
+		if score.Criterion != "" && score.Supporting >= 0 && score.Opposing >= 0 &&
+			score.Supporting <= rubricMaxScore && score.Opposing <= rubricMaxScore {
+			breakdown = append(breakdown, score)
+		}
+	}
+
+	// Keep only feedback addressed to a side
+	var feedback map[string]BotFeedback
+	for side, f := range judgeData.Feedback {
+		if side == "supporting" || side == "opposing" {
+			feedback = mergeFeedback(feedback, map[string]BotFeedback{side: f})
+		}
+	}
+
 	return &DebateResult{
 		Winner:          judgeData.Winner,
 		SupportingScore: judgeData.SupportingScore,
 		OpposingScore:   judgeData.OpposingScore,
 		Summary: SpeechMessage{
 			Format:  "markdown",
-			Content: judgeData.Summary,
+			Content: appendRoundCommentary(judgeData.Summary, judgeData.RoundCommentary),
 		},
+		Citations: citations,
+		Breakdown: breakdown,
+		Feedback:  feedback,
+	}, nil
+}
+
+// Translate renders a speech in the target language, keeping its Markdown structure
+func (c *ChatGPTClient) Translate(content, language string) (string, error) {
+	response, err := c.SendMessage([]ChatGPTMessage{
+		{Role: "system", Content: fmt.Sprintf("你是一位专业的辩论翻译。请将用户提供的辩论发言翻译为语言代码 %s 所表示的语言，保留 Markdown 格式，只输出译文。", language)},
+		{Role: "user", Content: content},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get translation: %w", err)
+	}
+	return strings.TrimSpace(response), nil
+}
+
+// roundRubric is the judge system prompt for scoring a single round
+const roundRubric = `你是一位专业的辩论评委。请只评判指定轮次中双方的表现，之前的轮次仅作为背景参考。
+
+评分标准 (每方0-100分): 论点质量、论据支持、对对方观点的反驳、表达与逻辑。
+
+请按以下JSON格式返回评判结果:
+{
+  "winner": "supporting" 或 "opposing" 或 "draw",
+  "supporting_score": 0-100,
+  "opposing_score": 0-100,
+  "summary": "本轮评语，简要说明胜负原因"
+}`
+
+// JudgeRound scores one round of a debate, using earlier rounds as context
+func (c *ChatGPTClient) JudgeRound(topic string, debateLog []DebateLogEntry, round int, supportingBot, opposingBot string) (*RoundResult, error) {
+	var transcript strings.Builder
+	transcript.WriteString(fmt.Sprintf("辩题: %s\n\n", topic))
+	transcript.WriteString(fmt.Sprintf("正方 (支持): %s\n", supportingBot))
+	transcript.WriteString(fmt.Sprintf("反方 (反对): %s\n\n", opposingBot))
+	transcript.WriteString("辩论过程:\n\n")
+
+	for _, entry := range debateLog {
+		if entry.Round > round {
+			continue
+		}
+		sideName := "正方"
+		if entry.Side == "opposing" {
+			sideName = "反方"
+		}
+		transcript.WriteString(fmt.Sprintf("【第%d轮 - %s】\n%s\n\n", entry.Round, sideName, entry.Message.Content))
+	}
+
+	userPrompt := fmt.Sprintf("请评判以下辩论的第%d轮:\n\n%s", round, transcript.String())
+
+	response, _, err := c.judgeCall([]ChatGPTMessage{
+		{Role: "system", Content: roundRubric},
+		{Role: "user", Content: userPrompt},
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get round judge response: %w", err)
+	}
+
+	result, err := c.parseJudgeResponse(response)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RoundResult{
+		Round:           round,
+		Winner:          result.Winner,
+		SupportingScore: result.SupportingScore,
+		OpposingScore:   result.OpposingScore,
+		Comment:         result.Summary.Content,
 	}, nil
 }
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"strings"
+)
+
+// VerdictCitation ties one point of the judge's verdict to the speech it hinged on
+type VerdictCitation struct {
+	Point   string `json:"point"`
+	Round   int    `json:"round"`
+	Side    string `json:"side"`            // supporting or opposing
+	Quote   string `json:"quote,omitempty"` // Passage quoted by the judge
+	Located bool   `json:"located"`         // Whether the quote was found verbatim in the speech
+}
+
+// Highlight marks a passage of a log entry that the verdict relies on
+type Highlight struct {
+	Point string `json:"point"`
+	Quote string `json:"quote,omitempty"` // Exact substring of the speech; empty when the whole speech is cited
+}
+
+// attachCitations resolves citations against the debate log and adds a
+// highlight to each cited entry. Citations pointing at a round/side with no
+// speech are left unattached.
+func attachCitations(debateLog []DebateLogEntry, citations []VerdictCitation) {
+	for i := range citations {
+		c := &citations[i]
+		for j := range debateLog {
+			entry := &debateLog[j]
+			if entry.Round != c.Round || entry.Side != c.Side {
+				continue
+			}
+
+			quote := locateQuote(entry.Message.Content, c.Quote)
+			c.Located = quote != ""
+			entry.Highlights = append(entry.Highlights, Highlight{Point: c.Point, Quote: quote})
+			break
+		}
+	}
+}
+
+// locateQuote finds the judge's quote in a speech, tolerating surrounding
+// quotation marks and whitespace. It returns the exact matched substring.
+func locateQuote(content, quote string) string {
+	quote = strings.TrimSpace(quote)
+	if quote == "" {
+		return ""
+	}
+	if strings.Contains(content, quote) {
+		return quote
+	}
+
+	trimmed := strings.TrimSpace(strings.Trim(quote, "\"'“”‘’「」『』…."))
+	if trimmed != "" && strings.Contains(content, trimmed) {
+		return trimmed
+	}
+	return ""
+}
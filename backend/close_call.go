@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+)
+
+// A verdict whose scores are within chatgpt.judge.close_call.margin is a
+// close call: the judge reruns the debate a few times at a low temperature and
+// the median rerun becomes the verdict, so near-ties depend less on sampling
+// noise. Every run, the original included, is stored with the result.
+
+// JudgeRun is one judging pass over a close-call debate
+type JudgeRun struct {
+	Run             int     `json:"run"` // 0 is the original verdict, then the reruns
+	Temperature     float64 `json:"temperature"`
+	Winner          string  `json:"winner"`
+	SupportingScore int     `json:"supporting_score"`
+	OpposingScore   int     `json:"opposing_score"`
+	Summary         string  `json:"summary"`
+}
+
+// isCloseCall reports whether a judged result is within the close call margin
+func isCloseCall(result *DebateResult) bool {
+	cc := config.ChatGPT.Judge.CloseCall
+	if !cc.Enabled || result.Winner == "none" {
+		return false
+	}
+	diff := result.SupportingScore - result.OpposingScore
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff <= cc.Margin
+}
+
+// settleCloseCall reruns the judge on a close call and returns the median
+// rerun, by score difference, with all runs attached. Other results, and
+// close calls whose reruns all fail, are returned unchanged.
+func (c *ChatGPTClient) settleCloseCall(topic string, debateLog []DebateLogEntry, supportingBot, opposingBot string, opts JudgeOptions, first *DebateResult) *DebateResult {
+	if !isCloseCall(first) {
+		return first
+	}
+	cc := config.ChatGPT.Judge.CloseCall
+	low := *c
+	low.Temperature = cc.Temperature
+
+	runs := []JudgeRun{newJudgeRun(0, c.Temperature, first)}
+	reruns := []*DebateResult{}
+	for i := 1; i <= cc.Runs; i++ {
+		result, err := low.JudgeDebateWith(topic, debateLog, supportingBot, opposingBot, opts)
+		if err != nil {
+			log.Printf("Close call rerun %d failed: %v", i, err)
+			continue
+		}
+		runs = append(runs, newJudgeRun(i, low.Temperature, result))
+		reruns = append(reruns, result)
+	}
+	if len(reruns) == 0 {
+		return first
+	}
+
+	sort.SliceStable(reruns, func(i, j int) bool {
+		return reruns[i].SupportingScore-reruns[i].OpposingScore < reruns[j].SupportingScore-reruns[j].OpposingScore
+	})
+	median := reruns[(len(reruns)-1)/2]
+	median.Runs = runs
+	median.Summary.Content += fmt.Sprintf("\n\n注: 初评双方得分 %d:%d 十分接近，评委以较低温度复评 %d 次，取中位结果。",
+		first.SupportingScore, first.OpposingScore, len(reruns))
+	log.Printf("Close call %d:%d settled as %s %d:%d after %d reruns",
+		first.SupportingScore, first.OpposingScore, median.Winner, median.SupportingScore, median.OpposingScore, len(reruns))
+	return median
+}
+
+// newJudgeRun records a judging pass
+func newJudgeRun(run int, temperature float64, result *DebateResult) JudgeRun {
+	return JudgeRun{
+		Run:             run,
+		Temperature:     temperature,
+		Winner:          result.Winner,
+		SupportingScore: result.SupportingScore,
+		OpposingScore:   result.OpposingScore,
+		Summary:         result.Summary.Content,
+	}
+}
+
+// SaveJudgeRuns replaces the close-call judge runs of a debate
+func (d *Database) SaveJudgeRuns(debateID string, runs []JudgeRun) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM judge_runs WHERE debate_id = ?`, debateID); err != nil {
+		return err
+	}
+	for _, r := range runs {
+		_, err := tx.Exec(`INSERT INTO judge_runs (debate_id, run, temperature, winner, supporting_score, opposing_score, summary)
+		                   VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			debateID, r.Run, r.Temperature, r.Winner, r.SupportingScore, r.OpposingScore, r.Summary)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetJudgeRuns retrieves the close-call judge runs of a debate in run order
+func (d *Database) GetJudgeRuns(debateID string) ([]JudgeRun, error) {
+	rows, err := d.db.Query(`SELECT run, temperature, winner, supporting_score, opposing_score, summary
+	                         FROM judge_runs WHERE debate_id = ? ORDER BY run ASC`, debateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []JudgeRun{}
+	for rows.Next() {
+		var r JudgeRun
+		if err := rows.Scan(&r.Run, &r.Temperature, &r.Winner, &r.SupportingScore, &r.OpposingScore, &r.Summary); err != nil {
+			return nil, err
+		}
+		runs = append(runs, r)
+	}
+	return runs, rows.Err()
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// Debates between their final speech and judging use the "closing" status
+const StatusClosing = "closing"
+
+// DebateClosing announces that the last speech is in and judging follows shortly
+type DebateClosing struct {
+	DebateID  string `json:"debate_id"`
+	JudgingAt string `json:"judging_at"` // When judging begins
+}
+
+// isFinished reports whether a debate status is final
+func isFinished(status string) bool {
+	return status != "waiting" && status != StatusClosing && !isInProgress(status)
+}
+
+// closeDebate completes a debate whose last round is done. With a closing
+// grace configured the debate first sits in the closing status, so the final
+// broadcasts settle before judging starts.
+func (dm *DebateManager) closeDebate(activeDebate *ActiveDebate) {
+	debateID := activeDebate.Debate.ID
+	grace := time.Duration(config.Debate.ClosingGrace) * time.Second
+	if grace <= 0 {
+		dm.endDebate(debateID, "completed", "completed")
+		return
+	}
+
+	for _, timer := range []*time.Timer{activeDebate.TimeoutTimer, activeDebate.InactivityTimer, activeDebate.MaxDurationTimer} {
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+	stopCountdown(activeDebate)
+
+	activeDebate.mutex.Lock()
+	activeDebate.Debate.Status = StatusClosing
+	activeDebate.TurnDeadline = time.Time{}
+	activeDebate.mutex.Unlock()
+	dm.db.UpdateDebateStatus(debateID, StatusClosing)
+
+	dm.notifyDebate(activeDebate, createMessage("debate_closing", DebateClosing{
+		DebateID:  debateID,
+		JudgingAt: time.Now().Add(grace).Format(time.RFC3339),
+	}))
+	log.Printf("Debate %s closing, judging in %v", debateID, grace)
+
+	time.AfterFunc(grace, func() {
+		dm.endDebate(debateID, "completed", "completed")
+	})
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// instanceStaleAfter is how long an instance may go without a heartbeat before
+// other instances stop redirecting bots to it and take over its debates
+const instanceStaleAfter = 60 * time.Second
+
+// Cluster identifies this instance among several sharing one database
+type Cluster struct {
+	InstanceID string
+	PublicURL  string
+	secret     []byte
+	db         *Database
+}
+
+// NewCluster creates the cluster identity and starts the instance heartbeat
+func NewCluster(db *Database, instanceID, publicURL, secret string) *Cluster {
+	if instanceID == "" {
+		instanceID, _ = os.Hostname()
+	}
+
+	c := &Cluster{
+		InstanceID: instanceID,
+		PublicURL:  publicURL,
+		secret:     []byte(secret),
+		db:         db,
+	}
+	go c.heartbeat()
+	return c
+}
+
+// heartbeat periodically records this instance as alive
+func (c *Cluster) heartbeat() {
+	ticker := time.NewTicker(15 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		if err := c.db.TouchInstance(c.InstanceID, c.PublicURL); err != nil {
+			log.Printf("Failed to record instance heartbeat: %v", err)
+		}
+		<-ticker.C
+	}
+}
+
+// AffinityToken returns a signed token binding a bot session to this instance.
+// Bots send it back in bot_login, or as the affinity query parameter of the
+// WebSocket URL, which load balancers can hash on. Without cluster.secret no
+// tokens are issued.
+func (c *Cluster) AffinityToken(debateID, botUUID string) string {
+	return c.signAffinity(c.InstanceID, debateID, botUUID)
+}
+
+// signAffinity signs an affinity token for an instance; all instances share the secret
+func (c *Cluster) signAffinity(instanceID, debateID, botUUID string) string {
+	if len(c.secret) == 0 {
+		return ""
+	}
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(instanceID + "|" + debateID + "|" + botUUID))
+	return instanceID + "." + hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// VerifyAffinityToken reports whether an instance of the cluster issued the
+// token for this debate and bot. Without a secret there is nothing to check.
+func (c *Cluster) VerifyAffinityToken(token, debateID, botUUID string) bool {
+	if len(c.secret) == 0 {
+		return true
+	}
+	dot := strings.LastIndex(token, ".")
+	if dot <= 0 {
+		return false
+	}
+	expected := c.signAffinity(token[:dot], debateID, botUUID)
+	return hmac.Equal([]byte(token), []byte(expected))
+}
+
+// RedirectFor returns a redirect if the debate is owned by another live
+// instance, with an affinity token for the login there
+func (c *Cluster) RedirectFor(debateID, botUUID string) *LoginRedirect {
+	if debateID == "" {
+		return nil
+	}
+
+	owner, err := c.db.GetDebateOwner(debateID)
+	if err != nil || owner == "" || owner == c.InstanceID {
+		return nil
+	}
+
+	instance, err := c.db.GetInstance(owner)
+	if err != nil || instance.PublicURL == "" || time.Since(instance.LastSeen) > instanceStaleAfter {
+		// Owner is gone; this instance will take the debate over on login
+		return nil
+	}
+
+	token := c.signAffinity(owner, debateID, botUUID)
+	target := instance.PublicURL
+	if u, err := url.Parse(target); err == nil && token != "" {
+		query := u.Query()
+		query.Set("affinity", token)
+		u.RawQuery = query.Encode()
+		target = u.String()
+	}
+	return &LoginRedirect{
+		Status:        "redirect",
+		Message:       "Debate is hosted on another instance, reconnect to the given URL",
+		DebateID:      debateID,
+		InstanceID:    owner,
+		URL:           target,
+		AffinityToken: token,
+	}
+}
+
+// ClaimDebate records this instance as the owner of a debate
+func (c *Cluster) ClaimDebate(debateID string) {
+	if err := c.db.SetDebateOwner(debateID, c.InstanceID); err != nil {
+		log.Printf("Failed to claim debate %s: %v", debateID, err)
+	}
+}
@@ -0,0 +1,543 @@
+// Command conformance runs a candidate bot through scripted protocol scenarios.
+//
+// It plays the role of the debate server: start it, point the bot at
+// ws://<listen>/debate, and it walks the bot through login, heartbeat, turn
+// taking, recoverable errors, duplicate updates, reconnects and debate end,
+// then prints a pass/fail report. With -submit and an admin key the report is
+// also posted to a debate server, which keeps the latest run per bot_uuid;
+// with debate.require_conformance on, only bots whose latest run passed may
+// enter ranked play.
+//
+//	go run ./cmd/conformance -listen 127.0.0.1:9090 -submit http://127.0.0.1:8081 -key <admin key>
+//	node debate_client.js ws://127.0.0.1:9090/debate my_bot debate-conformance
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	conformanceDebateID = "debate-conformance"
+	conformanceKey      = "key-conformance"
+	opponentIdentifier  = "sparring-00000000"
+	conformanceTopic    = "Conformance testing makes bots more reliable"
+)
+
+// Message mirrors the server's WebSocket envelope
+type Message struct {
+	Type      string          `json:"type"`
+	Timestamp string          `json:"timestamp"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// ScenarioResult is the outcome of one scripted scenario
+type ScenarioResult struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"` // pass, fail, skip
+	Detail   string `json:"detail,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Report is the full conformance report
+type Report struct {
+	BotUUID       string           `json:"bot_uuid"`
+	BotIdentifier string           `json:"bot_identifier"`
+	StartedAt     time.Time        `json:"started_at"`
+	Results       []ScenarioResult `json:"results"`
+	Conformant    bool             `json:"conformant"`
+}
+
+// session is one bot connection with a buffered inbound message stream
+type session struct {
+	conn     *websocket.Conn
+	incoming chan Message
+	closed   chan struct{}
+}
+
+// harness drives the scenarios
+type harness struct {
+	conns         chan *session
+	sess          *session
+	speechTimeout time.Duration
+	minLen        int
+	maxLen        int
+	botUUID       string
+	botIdentifier string
+	round         int
+	log           []map[string]interface{}
+	report        Report
+}
+
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+func main() {
+	listen := flag.String("listen", "127.0.0.1:9090", "address the bot should connect to")
+	speechTimeout := flag.Duration("speech-timeout", 120*time.Second, "how long to wait for a speech")
+	connectTimeout := flag.Duration("connect-timeout", 5*time.Minute, "how long to wait for the bot to connect")
+	skipReconnect := flag.Bool("skip-reconnect", false, "skip the reconnect scenario")
+	jsonOut := flag.String("json", "", "also write the report as JSON to this file")
+	submit := flag.String("submit", "", "debate server base URL to record the report on, e.g. http://127.0.0.1:8081")
+	key := flag.String("key", "", "admin API key for -submit")
+	flag.Parse()
+
+	h := &harness{
+		conns:         make(chan *session, 4),
+		speechTimeout: *speechTimeout,
+		minLen:        50,
+		maxLen:        2000,
+		round:         1,
+		report:        Report{StartedAt: time.Now()},
+	}
+
+	http.HandleFunc("/debate", h.handleConn)
+	go func() {
+		log.Fatal(http.ListenAndServe(*listen, nil))
+	}()
+
+	log.Printf("Conformance harness listening on ws://%s/debate", *listen)
+	log.Printf("Connect your bot with debate_id %q", conformanceDebateID)
+
+	select {
+	case h.sess = <-h.conns:
+	case <-time.After(*connectTimeout):
+		log.Fatalf("No bot connected within %v", *connectTimeout)
+	}
+
+	h.run("login", h.scenarioLogin)
+	h.run("heartbeat", h.scenarioHeartbeat)
+	h.run("opening_speech", h.scenarioOpeningSpeech)
+	h.run("recoverable_length_error", h.scenarioLengthError)
+	h.run("respects_opponent_turn", h.scenarioOpponentTurn)
+	h.run("duplicate_update_idempotent", h.scenarioDuplicateUpdate)
+	if *skipReconnect {
+		h.skip("reconnect", "skipped by flag")
+	} else {
+		h.run("reconnect", h.scenarioReconnect)
+	}
+	h.run("debate_end", h.scenarioDebateEnd)
+
+	h.report.Conformant = true
+	for _, r := range h.report.Results {
+		if r.Status == "fail" {
+			h.report.Conformant = false
+		}
+	}
+
+	h.printReport()
+	if *jsonOut != "" {
+		data, _ := json.MarshalIndent(h.report, "", "  ")
+		if err := os.WriteFile(*jsonOut, data, 0644); err != nil {
+			log.Printf("Failed to write JSON report: %v", err)
+		}
+	}
+	if *submit != "" {
+		if err := submitReport(*submit, *key, h.report); err != nil {
+			log.Printf("Failed to submit the report: %v", err)
+			os.Exit(2)
+		}
+		log.Printf("Report recorded on %s", *submit)
+	}
+	if !h.report.Conformant {
+		os.Exit(1)
+	}
+}
+
+// handleConn accepts a bot connection and pumps its messages
+func (h *harness) handleConn(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	s := &session{conn: conn, incoming: make(chan Message, 32), closed: make(chan struct{})}
+	go func() {
+		defer close(s.closed)
+		for {
+			var msg Message
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			s.incoming <- msg
+		}
+	}()
+	h.conns <- s
+}
+
+// run executes a scenario and records the result; scenarios after a lost connection are skipped
+func (h *harness) run(name string, fn func() error) {
+	select {
+	case <-h.sess.closed:
+		h.skip(name, "bot connection is closed")
+		return
+	default:
+	}
+
+	start := time.Now()
+	err := fn()
+	result := ScenarioResult{Name: name, Status: "pass", Duration: time.Since(start).Round(time.Millisecond).String()}
+	if err != nil {
+		result.Status = "fail"
+		result.Detail = err.Error()
+	}
+	h.report.Results = append(h.report.Results, result)
+	log.Printf("[%s] %s %s", strings.ToUpper(result.Status), name, result.Detail)
+}
+
+func (h *harness) skip(name, reason string) {
+	h.report.Results = append(h.report.Results, ScenarioResult{Name: name, Status: "skip", Detail: reason, Duration: "0s"})
+}
+
+func (h *harness) send(msgType string, data interface{}) error {
+	return h.sess.conn.WriteJSON(map[string]interface{}{
+		"type":      msgType,
+		"timestamp": time.Now().Format(time.RFC3339),
+		"data":      data,
+	})
+}
+
+// expect waits for a message of the given type, answering pings along the way
+func (h *harness) expect(msgType string, timeout time.Duration) (Message, error) {
+	deadline := time.After(timeout)
+	for {
+		select {
+		case msg := <-h.sess.incoming:
+			if msg.Type == msgType {
+				return msg, nil
+			}
+			if msg.Type == "pong" {
+				continue
+			}
+			return msg, fmt.Errorf("expected %s, got %s", msgType, msg.Type)
+		case <-h.sess.closed:
+			return Message{}, fmt.Errorf("connection closed while waiting for %s", msgType)
+		case <-deadline:
+			return Message{}, fmt.Errorf("no %s within %v", msgType, timeout)
+		}
+	}
+}
+
+// expectSilence verifies the bot sends no speech for the given duration
+func (h *harness) expectSilence(d time.Duration) error {
+	deadline := time.After(d)
+	for {
+		select {
+		case msg := <-h.sess.incoming:
+			if msg.Type == "debate_speech" {
+				return fmt.Errorf("bot spoke when it was not its turn")
+			}
+		case <-h.sess.closed:
+			return fmt.Errorf("connection closed unexpectedly")
+		case <-deadline:
+			return nil
+		}
+	}
+}
+
+func (h *harness) debateState(nextSpeaker string) map[string]interface{} {
+	return map[string]interface{}{
+		"debate_id":          conformanceDebateID,
+		"topic":              conformanceTopic,
+		"supporting_side":    h.botIdentifier,
+		"opposing_side":      opponentIdentifier,
+		"total_rounds":       3,
+		"current_round":      h.round,
+		"your_side":          "supporting",
+		"your_identifier":    h.botIdentifier,
+		"next_speaker":       nextSpeaker,
+		"timeout_seconds":    int(h.speechTimeout.Seconds()),
+		"min_content_length": h.minLen,
+		"max_content_length": h.maxLen,
+		"debate_log":         h.log,
+	}
+}
+
+// validateSpeech checks the shape of a debate_speech payload
+func (h *harness) validateSpeech(msg Message) (string, error) {
+	var speech struct {
+		DebateID  string `json:"debate_id"`
+		DebateKey string `json:"debate_key"`
+		Speaker   string `json:"speaker"`
+		Message   struct {
+			Format  string `json:"format"`
+			Content string `json:"content"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(msg.Data, &speech); err != nil {
+		return "", fmt.Errorf("malformed debate_speech: %v", err)
+	}
+	switch {
+	case speech.DebateID != conformanceDebateID:
+		return "", fmt.Errorf("wrong debate_id %q", speech.DebateID)
+	case speech.DebateKey != conformanceKey:
+		return "", fmt.Errorf("wrong debate_key")
+	case speech.Speaker != h.botIdentifier:
+		return "", fmt.Errorf("wrong speaker %q", speech.Speaker)
+	case speech.Message.Format == "":
+		return "", fmt.Errorf("missing message.format")
+	}
+	n := len(strings.TrimSpace(speech.Message.Content))
+	if n < h.minLen || n > h.maxLen {
+		return speech.Message.Content, fmt.Errorf("content length %d outside [%d, %d]", n, h.minLen, h.maxLen)
+	}
+	return speech.Message.Content, nil
+}
+
+func (h *harness) appendLog(speaker, side, content string) {
+	h.log = append(h.log, map[string]interface{}{
+		"round":     h.round,
+		"speaker":   speaker,
+		"side":      side,
+		"timestamp": time.Now().Format(time.RFC3339),
+		"message":   map[string]string{"format": "markdown", "content": content},
+	})
+}
+
+func (h *harness) scenarioLogin() error {
+	msg, err := h.expect("bot_login", 10*time.Second)
+	if err != nil {
+		return err
+	}
+	var login struct {
+		BotName  string `json:"bot_name"`
+		BotUUID  string `json:"bot_uuid"`
+		DebateID string `json:"debate_id"`
+	}
+	if err := json.Unmarshal(msg.Data, &login); err != nil {
+		return fmt.Errorf("malformed bot_login: %v", err)
+	}
+	if login.BotName == "" || len(login.BotUUID) < 8 {
+		return fmt.Errorf("bot_login must include bot_name and a bot_uuid of at least 8 characters")
+	}
+	h.botUUID = login.BotUUID
+	h.report.BotUUID = login.BotUUID
+	h.botIdentifier = fmt.Sprintf("%s-%s", login.BotName, login.BotUUID[:8])
+	h.report.BotIdentifier = h.botIdentifier
+
+	return h.send("login_confirmed", map[string]interface{}{
+		"status":         "confirmed",
+		"message":        "Conformance sandbox",
+		"debate_id":      conformanceDebateID,
+		"debate_key":     conformanceKey,
+		"bot_identifier": h.botIdentifier,
+		"topic":          conformanceTopic,
+		"joined_bots":    []string{opponentIdentifier},
+	})
+}
+
+func (h *harness) scenarioHeartbeat() error {
+	if err := h.send("ping", map[string]string{"server_time": time.Now().Format(time.RFC3339)}); err != nil {
+		return err
+	}
+	deadline := time.After(10 * time.Second)
+	for {
+		select {
+		case msg := <-h.sess.incoming:
+			if msg.Type == "pong" {
+				return nil
+			}
+		case <-h.sess.closed:
+			return fmt.Errorf("connection closed while waiting for pong")
+		case <-deadline:
+			return fmt.Errorf("no pong within 10s")
+		}
+	}
+}
+
+func (h *harness) scenarioOpeningSpeech() error {
+	if err := h.send("debate_start", h.debateState(h.botIdentifier)); err != nil {
+		return err
+	}
+	msg, err := h.expect("debate_speech", h.speechTimeout)
+	if err != nil {
+		return err
+	}
+	content, err := h.validateSpeech(msg)
+	if err != nil {
+		return err
+	}
+	h.appendLog(h.botIdentifier, "supporting", content)
+	return nil
+}
+
+func (h *harness) scenarioLengthError() error {
+	if err := h.send("error", map[string]interface{}{
+		"error_code":  "CONTENT_TOO_SHORT",
+		"message":     fmt.Sprintf("Speech content too short (minimum %d characters)", h.minLen),
+		"debate_id":   conformanceDebateID,
+		"recoverable": true,
+	}); err != nil {
+		return err
+	}
+	// A recoverable error must not make the bot drop the connection
+	select {
+	case <-h.sess.closed:
+		return fmt.Errorf("bot disconnected after a recoverable error")
+	case <-time.After(3 * time.Second):
+		return nil
+	}
+}
+
+func (h *harness) scenarioOpponentTurn() error {
+	if err := h.send("debate_update", h.debateState(opponentIdentifier)); err != nil {
+		return err
+	}
+	return h.expectSilence(5 * time.Second)
+}
+
+func (h *harness) scenarioDuplicateUpdate() error {
+	h.appendLog(opponentIdentifier, "opposing", strings.Repeat("The opposing side maintains its position. ", 3))
+	h.round++
+	state := h.debateState(h.botIdentifier)
+	if err := h.send("debate_update", state); err != nil {
+		return err
+	}
+	if err := h.send("debate_update", state); err != nil {
+		return err
+	}
+
+	msg, err := h.expect("debate_speech", h.speechTimeout)
+	if err != nil {
+		return err
+	}
+	content, err := h.validateSpeech(msg)
+	if err != nil {
+		return err
+	}
+	h.appendLog(h.botIdentifier, "supporting", content)
+
+	if err := h.expectSilence(5 * time.Second); err != nil {
+		return fmt.Errorf("bot answered a duplicated debate_update twice")
+	}
+	return nil
+}
+
+func (h *harness) scenarioReconnect() error {
+	h.sess.conn.Close()
+	<-h.sess.closed
+
+	select {
+	case h.sess = <-h.conns:
+	case <-time.After(30 * time.Second):
+		return fmt.Errorf("bot did not reconnect within 30s")
+	}
+
+	msg, err := h.expect("bot_login", 10*time.Second)
+	if err != nil {
+		return err
+	}
+	var login struct {
+		BotUUID   string `json:"bot_uuid"`
+		DebateID  string `json:"debate_id"`
+		DebateKey string `json:"debate_key"`
+	}
+	json.Unmarshal(msg.Data, &login)
+	if login.BotUUID != h.botUUID {
+		return fmt.Errorf("bot reconnected with a different bot_uuid")
+	}
+	if login.DebateID != conformanceDebateID {
+		return fmt.Errorf("bot reconnected without its debate_id")
+	}
+	if login.DebateKey != conformanceKey {
+		return fmt.Errorf("bot reconnected without its debate_key")
+	}
+	return h.send("login_confirmed", map[string]interface{}{
+		"status":         "confirmed",
+		"message":        "Reconnected",
+		"debate_id":      conformanceDebateID,
+		"debate_key":     conformanceKey,
+		"bot_identifier": h.botIdentifier,
+		"topic":          conformanceTopic,
+		"joined_bots":    []string{opponentIdentifier},
+	})
+}
+
+func (h *harness) scenarioDebateEnd() error {
+	if err := h.send("debate_end", map[string]interface{}{
+		"debate_id":       conformanceDebateID,
+		"topic":           conformanceTopic,
+		"supporting_side": h.botIdentifier,
+		"opposing_side":   opponentIdentifier,
+		"total_rounds":    3,
+		"status":          "completed",
+		"debate_log":      h.log,
+		"debate_result": map[string]interface{}{
+			"winner":           "draw",
+			"supporting_score": 50,
+			"opposing_score":   50,
+			"summary":          map[string]string{"format": "markdown", "content": "Conformance run complete."},
+			"reason":           "completed",
+		},
+	}); err != nil {
+		return err
+	}
+
+	// After the end the bot may close, but must not keep speaking
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case msg := <-h.sess.incoming:
+			if msg.Type == "debate_speech" {
+				return fmt.Errorf("bot spoke after debate_end")
+			}
+		case <-h.sess.closed:
+			return nil
+		case <-deadline:
+			return nil
+		}
+	}
+}
+
+// submitReport records the report on a debate server, see the server's conformance.go
+func submitReport(server, key string, report Report) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, strings.TrimRight(server, "/")+"/api/admin/conformance", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("server answered %s", resp.Status)
+	}
+	return nil
+}
+
+func (h *harness) printReport() {
+	fmt.Println()
+	fmt.Printf("Conformance report for %s\n", h.report.BotIdentifier)
+	fmt.Println(strings.Repeat("-", 60))
+	for _, r := range h.report.Results {
+		line := fmt.Sprintf("%-5s %-30s %8s", strings.ToUpper(r.Status), r.Name, r.Duration)
+		if r.Detail != "" {
+			line += "  " + r.Detail
+		}
+		fmt.Println(line)
+	}
+	fmt.Println(strings.Repeat("-", 60))
+	if h.report.Conformant {
+		fmt.Println("RESULT: CONFORMANT - bot may enter ranked play")
+	} else {
+		fmt.Println("RESULT: NOT CONFORMANT - fix the failures above before ranked play")
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Body encodings recorded next to message_content and summary_content. New
+// bodies are stored as zstd; the encoding column keeps rows stored with an
+// older codec readable without rewriting them.
+const (
+	EncodingPlain   = ""
+	EncodingDeflate = "deflate" // Written before zstd; only read now
+	EncodingZstd    = "zstd"
+)
+
+// The zstd encoder and decoder are safe for concurrent EncodeAll and DecodeAll calls
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedBestCompression))
+	zstdDecoder, _ = zstd.NewReader(nil)
+)
+
+// SetCompression enables compression of speech and summary bodies of at least
+// minBytes. Zero disables it; stored compressed rows are still read.
+func (d *Database) SetCompression(minBytes int) {
+	d.compressMinBytes = minBytes
+}
+
+// encodeBody returns the value to store for content and its encoding
+func (d *Database) encodeBody(content string) (interface{}, string, error) {
+	if d.compressMinBytes <= 0 || len(content) < d.compressMinBytes {
+		return content, EncodingPlain, nil
+	}
+	compressed := zstdEncoder.EncodeAll([]byte(content), nil)
+	if len(compressed) >= len(content) {
+		return content, EncodingPlain, nil
+	}
+	return compressed, EncodingZstd, nil
+}
+
+// decodeBody restores a stored body according to its encoding
+func decodeBody(stored []byte, encoding string) (string, error) {
+	switch encoding {
+	case EncodingPlain:
+		return string(stored), nil
+	case EncodingDeflate:
+		r := flate.NewReader(bytes.NewReader(stored))
+		defer r.Close()
+		content, err := io.ReadAll(r)
+		if err != nil {
+			return "", fmt.Errorf("failed to decompress body: %w", err)
+		}
+		return string(content), nil
+	case EncodingZstd:
+		content, err := zstdDecoder.DecodeAll(stored, nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to decompress body: %w", err)
+		}
+		return string(content), nil
+	default:
+		return "", fmt.Errorf("unknown body encoding %q", encoding)
+	}
+}
+
+// CompressionReport summarizes a CompressArchive run
+type CompressionReport struct {
+	Speeches    int
+	Summaries   int
+	BytesBefore int
+	BytesAfter  int
+}
+
+// CompressArchive compresses existing plain speech and summary bodies that
+// reach the configured threshold. It is safe to run repeatedly.
+func (d *Database) CompressArchive() (*CompressionReport, error) {
+	if d.compressMinBytes <= 0 {
+		return nil, fmt.Errorf("compression is disabled")
+	}
+	report := &CompressionReport{}
+
+	targets := []struct {
+		table, key, content, encoding string
+		count                         *int
+	}{
+		{"debate_log", "id", "message_content", "message_encoding", &report.Speeches},
+		{"debate_results", "debate_id", "summary_content", "summary_encoding", &report.Summaries},
+	}
+	for _, t := range targets {
+		rows, err := d.db.Query(fmt.Sprintf(`SELECT %s, %s FROM %s WHERE %s = '' AND length(%s) >= ?`,
+			t.key, t.content, t.table, t.encoding, t.content), d.compressMinBytes)
+		if err != nil {
+			return nil, err
+		}
+		type pending struct {
+			key     interface{}
+			content string
+		}
+		var todo []pending
+		for rows.Next() {
+			var p pending
+			if err := rows.Scan(&p.key, &p.content); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			todo = append(todo, p)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+
+		tx, err := d.db.Begin()
+		if err != nil {
+			return nil, err
+		}
+		update := fmt.Sprintf(`UPDATE %s SET %s = ?, %s = ? WHERE %s = ?`, t.table, t.content, t.encoding, t.key)
+		for _, p := range todo {
+			value, encoding, err := d.encodeBody(p.content)
+			if err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+			if encoding == EncodingPlain {
+				continue
+			}
+			if _, err := tx.Exec(update, value, encoding, p.key); err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+			*t.count++
+			report.BytesBefore += len(p.content)
+			report.BytesAfter += len(value.([]byte))
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 
 	"gopkg.in/yaml.v3"
 )
@@ -11,14 +12,75 @@ import (
 // Config represents the application configuration
 type Config struct {
 	Server struct {
-		Host string `yaml:"host"`
-		Port int    `yaml:"port"`
+		Host         string `yaml:"host"`
+		Port         int    `yaml:"port"`
+		FrontendPath string `yaml:"frontend_path"`
+
+		// Connection limits (seconds), guarding against slow or stalled clients
+		ReadHeaderTimeout int `yaml:"read_header_timeout"`
+		ReadTimeout       int `yaml:"read_timeout"`
+		WriteTimeout      int `yaml:"write_timeout"`
+		IdleTimeout       int `yaml:"idle_timeout"`
+		MaxHeaderBytes    int `yaml:"max_header_bytes"`
+		HandlerTimeout    int `yaml:"handler_timeout"` // Per-request limit for database-heavy API endpoints
+		ShutdownGrace     int `yaml:"shutdown_grace"`  // Seconds running debates may finish after SIGTERM, negative interrupts them at once
+
+		SnapshotOnShutdown bool `yaml:"snapshot_on_shutdown"` // Snapshot debates still running after the grace so another instance continues them
 	} `yaml:"server"`
 
+	Frontend struct {
+		IdleTimeout  int `yaml:"idle_timeout"`  // Seconds without any frame from a spectator before it is evicted
+		PingInterval int `yaml:"ping_interval"` // Seconds between server WebSocket pings to spectators
+
+		MaxSpectators int `yaml:"max_spectators"` // Live spectators per debate on this instance, the rest queue; 0 is unlimited
+
+		// Chat relays spectators' chat_message to everyone watching the debate, see spectator_chat.go
+		Chat struct {
+			Enabled   bool          `yaml:"enabled"`
+			MaxLength int           `yaml:"max_length"` // Characters per message
+			History   int           `yaml:"history"`    // Latest saved messages sent to a subscribing spectator
+			Rate      RateLimitRule `yaml:"rate"`       // Messages per client IP; per_minute 0 disables the limit
+		} `yaml:"chat"`
+	} `yaml:"frontend"`
+
 	Database struct {
-		Path string `yaml:"path"`
+		Path           string `yaml:"path"`
+		SkipMigrations bool   `yaml:"skip_migrations"` // Expect migrations to be run via the `migrate` subcommand
+		BusyTimeout    int    `yaml:"busy_timeout"`    // Milliseconds a write waits for the database lock before failing
+
+		// Compression stores long speech and summary bodies as zstd; the `compress` subcommand converts existing rows
+		Compression struct {
+			Enabled  bool `yaml:"enabled"`
+			MinBytes int  `yaml:"min_bytes"`
+		} `yaml:"compression"`
 	} `yaml:"database"`
 
+	Cluster struct {
+		InstanceID string `yaml:"instance_id"` // Defaults to INSTANCE_ID env or hostname
+		PublicURL  string `yaml:"public_url"`  // Bot WebSocket URL that reaches this instance directly
+		Secret     string `yaml:"secret"`      // Signs affinity tokens; none are issued when empty
+
+		Role                string `yaml:"role"`                  // primary runs debates; replica only serves reads and spectators
+		ReplicaPollInterval int    `yaml:"replica_poll_interval"` // Seconds between a replica's checks for debate progress
+	} `yaml:"cluster"`
+
+	Chaos struct {
+		Enabled                    bool    `yaml:"enabled"` // Test-only; refused when DEBATE_ENV=production
+		DelayProbability           float64 `yaml:"delay_probability"`
+		MaxDelayMs                 int     `yaml:"max_delay_ms"`
+		DuplicateUpdateProbability float64 `yaml:"duplicate_update_probability"`
+		DropPongProbability        float64 `yaml:"drop_pong_probability"`
+		TransientErrorProbability  float64 `yaml:"transient_error_probability"`
+	} `yaml:"chaos"`
+
+	Runtime struct {
+		Container bool `yaml:"container"` // Container mode: JSON logs to stdout
+	} `yaml:"runtime"`
+
+	Logging struct {
+		Format string `yaml:"format"` // text or json
+	} `yaml:"logging"`
+
 	Debate struct {
 		SpeechTimeout      int `yaml:"speech_timeout"`
 		InactivityTimeout  int `yaml:"inactivity_timeout"`
@@ -26,19 +88,226 @@ type Config struct {
 		WaitingTimeout     int `yaml:"waiting_timeout"`
 		MinContentLength   int `yaml:"min_content_length"`
 		MaxContentLength   int `yaml:"max_content_length"`
+		CountdownInterval  int `yaml:"countdown_interval"` // Seconds between turn_countdown broadcasts, negative disables
+		ReconnectGrace     int `yaml:"reconnect_grace"`    // Seconds a running debate stays paused for a disconnected bot, negative ends it at once
+		ClosingGrace       int `yaml:"closing_grace"`      // Seconds between the final speech and judging, negative judges at once
+		MaxViolations      int `yaml:"max_violations"`     // Consecutive rejected speeches that disqualify a bot, negative disables
+		MaxRounds          int `yaml:"max_rounds"`         // Most rounds a created debate may have, 0 is no limit
+
+		// Bounds on the limits a create request may set for its debate
+		MaxContentCeiling int `yaml:"max_content_ceiling"` // Most characters a debate may allow per speech, 0 is no limit
+		MinSpeechTimeout  int `yaml:"min_speech_timeout"`  // Shortest speech timeout a debate may set, 0 is no limit
+
+		MinClientVersion   string `yaml:"min_client_version"`  // Reject bots reporting an older protocol version
+		RequireBotToken    bool   `yaml:"require_bot_token"`   // Only admit bots registered via /api/bots/register
+		RequireConformance bool   `yaml:"require_conformance"` // Refuse ranked debates to bots without a passing conformance run, see conformance.go
+		AutoTranslate      bool   `yaml:"auto_translate"`      // Fill in missing translations in bilingual debates with the LLM
+		DiscloseRubric     bool   `yaml:"disclose_rubric"`     // Tell bots the judging rubric in debate_start unless a debate opts out
+		OpponentSummary    bool   `yaml:"opponent_summary"`    // Summarize the opponent's latest speech in debate_update unless a debate opts out
+
+		Tiebreak struct {
+			Enabled bool `yaml:"enabled"`
+			Margin  int  `yaml:"margin"` // Score gap at or below which a tiebreak round is played
+		} `yaml:"tiebreak"`
+
+		// Matchmaking queues bots that find no waiting debate and pairs them on a topic from the pool; no topics disables it
+		Matchmaking struct {
+			Topics       []string `yaml:"topics"`
+			TotalRounds  int      `yaml:"total_rounds"`
+			QueueTimeout int      `yaml:"queue_timeout"` // Seconds a bot waits for an opponent before it is rejected
+			TopicPool    bool     `yaml:"topic_pool"`    // Also draw from the topics accepted under /api/admin/topics
+		} `yaml:"matchmaking"`
+
+		// SideChannel limits the side_signal messages of debates created with side_channel
+		SideChannel struct {
+			MaxMessages int `yaml:"max_messages"` // Signals each bot may send per debate
+			MaxBytes    int `yaml:"max_bytes"`    // Combined size of one signal's kind, field names and values
+		} `yaml:"side_channel"`
+
+		// Pairing applies to bots that log in without a debate_id; 0 disables each rule
+		Pairing struct {
+			Policy         string `yaml:"policy"`          // Waiting debate offered first: oldest, newest, tags, rating, or off to require a debate_id
+			Cooldown       int    `yaml:"cooldown"`        // Seconds before the same two bots can be auto-matched again
+			CategoryWindow int    `yaml:"category_window"` // Seconds a pair's debated topic categories count as recent
+		} `yaml:"pairing"`
 	} `yaml:"debate"`
 
+	Stats struct {
+		SideBias struct {
+			Threshold  float64 `yaml:"threshold"`   // Alert when a side's share of decisive wins deviates from 50% by more than this
+			MinSamples int     `yaml:"min_samples"` // Decisive results needed before bias is evaluated
+			WindowDays int     `yaml:"window_days"` // Recent window used for the alert
+		} `yaml:"side_bias"`
+
+		// Timeseries backs /api/stats/timeseries
+		Timeseries struct {
+			CacheTTL    int `yaml:"cache_ttl"`    // Seconds a computed series is served from memory
+			DefaultDays int `yaml:"default_days"` // Range covered when a request gives no days
+			MaxDays     int `yaml:"max_days"`     // Longest range a request may ask for
+		} `yaml:"timeseries"`
+	} `yaml:"stats"`
+
+	// Redaction masks profanity and personal data in public transcript views; the database keeps the raw text
+	Redaction struct {
+		Enabled   bool     `yaml:"enabled"`
+		Words     []string `yaml:"words"`      // Matched case-insensitively anywhere in the text
+		WordLists []string `yaml:"word_lists"` // Files with one word per line
+		Patterns  []string `yaml:"patterns"`   // Extra regular expressions
+		PII       bool     `yaml:"pii"`        // Mask email addresses and phone numbers
+		Mask      string   `yaml:"mask"`
+	} `yaml:"redaction"`
+
+	// Hooks run external programs at gamemaster hook points, see hooks.go
+	Hooks struct {
+		Timeout  int           `yaml:"timeout"` // Seconds each hook program may run
+		Commands []HookCommand `yaml:"commands"`
+	} `yaml:"hooks"`
+
+	// Auth requires an API key to create and list debates and to open the bot and spectator WebSockets
+	Auth struct {
+		Enabled bool     `yaml:"enabled"`
+		Keys    []APIKey `yaml:"keys"`
+	} `yaml:"auth"`
+
+	// Webhooks POST signed lifecycle events to external URLs, see webhooks.go
+	Webhooks struct {
+		Timeout     int               `yaml:"timeout"`      // Seconds each delivery attempt may take
+		MaxAttempts int               `yaml:"max_attempts"` // Deliveries of one event before it is given up
+		Backoff     int               `yaml:"backoff"`      // Seconds before the first retry, doubled for each further one
+		Endpoints   []WebhookEndpoint `yaml:"endpoints"`
+	} `yaml:"webhooks"`
+
+	// RateLimit throttles debate creation, WebSocket connections and speeches, see rate_limit.go
+	RateLimit struct {
+		Enabled      bool          `yaml:"enabled"`
+		TrustProxy   bool          `yaml:"trust_proxy"`   // Take the client IP from the last X-Forwarded-For hop
+		CreateDebate RateLimitRule `yaml:"create_debate"` // Per client IP
+		Connect      RateLimitRule `yaml:"connect"`       // Bot and spectator WebSocket connection attempts per client IP
+		Speech       RateLimitRule `yaml:"speech"`        // Speeches and opening submissions per bot UUID
+	} `yaml:"rate_limit"`
+
+	// Reaper repairs or closes debates stuck in inconsistent states, see reaper.go
+	Reaper struct {
+		Enabled        bool `yaml:"enabled"`
+		Interval       int  `yaml:"interval"`        // Seconds between sweeps
+		AbandonedAfter int  `yaml:"abandoned_after"` // Seconds a running debate no instance holds is left before it is interrupted
+		RetainEnded    int  `yaml:"retain_ended"`    // Seconds an ended debate stays in memory once no spectator watches it
+	} `yaml:"reaper"`
+
+	// Artifacts stores files attached to speeches, see artifacts.go
+	Artifacts struct {
+		Backend      string `yaml:"backend"`        // database or filesystem
+		Dir          string `yaml:"dir"`            // Directory of the filesystem backend
+		MaxBytes     int    `yaml:"max_bytes"`      // Largest attachment
+		MaxPerSpeech int    `yaml:"max_per_speech"` // Attachments one speech may carry
+	} `yaml:"artifacts"`
+
+	// Replay runs watch parties of finished debates, see watch_party.go
+	Replay struct {
+		SpeechInterval int `yaml:"speech_interval"` // Seconds between speeches at speed 1
+		IdleTimeout    int `yaml:"idle_timeout"`    // Seconds a paused session nobody watches is kept
+		MaxSessions    int `yaml:"max_sessions"`    // Sessions one instance runs at once
+		MaxGap         int `yaml:"max_gap"`         // Timed replays: longest pause between speeches, in seconds at speed 1
+		MaxStreams     int `yaml:"max_streams"`     // Timed replays one instance plays at once
+	} `yaml:"replay"`
+
 	ChatGPT struct {
 		APIKey  string `yaml:"api_key"`
 		APIURL  string `yaml:"api_url"`
 		Model   string `yaml:"model"`
 		Timeout int    `yaml:"timeout"`
 
+		// Profiles are named provider accounts that features select with their profile setting
+		Profiles map[string]ModelProfile `yaml:"profiles"`
+
 		Judge struct {
 			Enabled     bool    `yaml:"enabled"`
+			Profile     string  `yaml:"profile"`
+			Provider    string  `yaml:"provider"` // openai (default) or mock for deterministic verdicts without an LLM, see judge_mock.go
 			MaxTokens   int     `yaml:"max_tokens"`
 			Temperature float64 `yaml:"temperature"`
+			Concurrency int     `yaml:"concurrency"` // Debates judged at once; the rest wait in a priority queue
+			Feedback    bool    `yaml:"feedback"`    // Ask the judge for per-side critique, sent privately to each bot
+			Stream      bool    `yaml:"stream"`      // Stream the verdict summary to spectators as judge_stream while it is written
+
+			// Fallback lists model profiles tried in order when a judge call on profile errors or times out
+			Fallback []string `yaml:"fallback"`
+
+			Panel []JudgePersona `yaml:"panel"` // Judge personas whose verdicts are averaged; empty for a single judge
+
+			Rubric RubricConfig `yaml:"rubric"` // Judge prompt template and weighted criteria; empty for the built-in ones
+
+			Verdict VerdictStyle `yaml:"verdict"` // Length and structure of the verdict summary; debates may override it
+
+			// CloseCall reruns the judge when the scores are within margin and takes the median verdict
+			CloseCall struct {
+				Enabled     bool    `yaml:"enabled"`
+				Margin      int     `yaml:"margin"`
+				Runs        int     `yaml:"runs"`
+				Temperature float64 `yaml:"temperature"`
+			} `yaml:"close_call"`
 		} `yaml:"judge"`
+
+		// Sandbox scores candidate speeches with the judge model outside any debate
+		Sandbox struct {
+			Enabled bool `yaml:"enabled"`
+
+			// Debate backs /api/sandbox/debate: unranked practice debates against the house bot
+			Debate struct {
+				Enabled       bool   `yaml:"enabled"`
+				Topic         string `yaml:"topic"`          // Used when the request names none
+				TotalRounds   int    `yaml:"total_rounds"`   // Used when the request gives none
+				SpeechTimeout int    `yaml:"speech_timeout"` // Relaxed seconds per speech, not bound by debate.min_speech_timeout
+			} `yaml:"debate"`
+		} `yaml:"sandbox"`
+
+		HouseBot struct {
+			Enabled     bool    `yaml:"enabled"`
+			Profile     string  `yaml:"profile"`
+			MaxTokens   int     `yaml:"max_tokens"`
+			Temperature float64 `yaml:"temperature"`
+		} `yaml:"house_bot"`
+
+		// Translation backs debate.auto_translate; it uses the judge's max_tokens and temperature
+		Translation struct {
+			Profile string `yaml:"profile"`
+		} `yaml:"translation"`
+
+		// Summary writes the opponent summaries of debates with opponent_summary
+		Summary struct {
+			Profile string `yaml:"profile"`
+		} `yaml:"summary"`
+
+		// Topics backs /api/topics/generate, which proposes debate topics for an admin to accept
+		Topics struct {
+			Enabled        bool   `yaml:"enabled"`
+			Profile        string `yaml:"profile"`
+			MaxSuggestions int    `yaml:"max_suggestions"` // Topics one request may ask for
+		} `yaml:"topics"`
+
+		// Budget caps all LLM calls (judge and house bot); 0 means unlimited
+		Budget struct {
+			MaxCallsPerHour     int     `yaml:"max_calls_per_hour"`
+			MaxTokensPerDay     int     `yaml:"max_tokens_per_day"`
+			MaxTokensPerMonth   int     `yaml:"max_tokens_per_month"` // AI judging is off for the rest of the UTC month once reached
+			MaxCostPerDay       float64 `yaml:"max_cost_per_day"` // USD estimate
+			PromptCostPer1K     float64 `yaml:"prompt_cost_per_1k"`
+			CompletionCostPer1K float64 `yaml:"completion_cost_per_1k"`
+			MaxDelay            int     `yaml:"max_delay"` // Seconds to wait for an hourly slot
+		} `yaml:"budget"`
+
+		// Retry repeats LLM calls answered with 429 or 5xx, or failing to connect, see llm_retry.go
+		Retry struct {
+			MaxAttempts int `yaml:"max_attempts"`   // Attempts per call, the first included
+			Backoff     int `yaml:"backoff_ms"`     // Milliseconds before the first retry, doubled for each further one and jittered
+			MaxBackoff  int `yaml:"max_backoff_ms"` // Longest wait between attempts, Retry-After included
+		} `yaml:"retry"`
+
+		// CircuitBreaker stops calling a provider that keeps failing, see llm_retry.go
+		CircuitBreaker struct {
+			Failures int `yaml:"failures"` // Failed calls in a row that open the breaker; negative disables it
+			Cooldown int `yaml:"cooldown"` // Seconds the breaker stays open before a trial call
+		} `yaml:"circuit_breaker"`
 	} `yaml:"chatgpt"`
 }
 
@@ -61,9 +330,58 @@ func LoadConfig(configPath string) (*Config, error) {
 	if config.Server.Port == 0 {
 		config.Server.Port = 8081
 	}
+	if config.Server.FrontendPath == "" {
+		config.Server.FrontendPath = "../frontend"
+	}
+	if config.Server.ReadHeaderTimeout == 0 {
+		config.Server.ReadHeaderTimeout = 10
+	}
+	if config.Server.ReadTimeout == 0 {
+		config.Server.ReadTimeout = 30
+	}
+	if config.Server.WriteTimeout == 0 {
+		config.Server.WriteTimeout = 60
+	}
+	if config.Server.IdleTimeout == 0 {
+		config.Server.IdleTimeout = 120
+	}
+	if config.Server.MaxHeaderBytes == 0 {
+		config.Server.MaxHeaderBytes = 1 << 20
+	}
+	if config.Server.HandlerTimeout == 0 {
+		config.Server.HandlerTimeout = 15
+	}
+	if config.Server.ShutdownGrace == 0 {
+		config.Server.ShutdownGrace = 60
+	}
+	if config.Frontend.IdleTimeout == 0 {
+		config.Frontend.IdleTimeout = 75
+	}
+	if config.Frontend.PingInterval == 0 {
+		config.Frontend.PingInterval = 25
+	}
+	if config.Frontend.Chat.MaxLength == 0 {
+		config.Frontend.Chat.MaxLength = 300
+	}
+	if config.Frontend.Chat.History == 0 {
+		config.Frontend.Chat.History = 50
+	}
 	if config.Database.Path == "" {
 		config.Database.Path = "./debate.db"
 	}
+	if config.Database.BusyTimeout == 0 {
+		config.Database.BusyTimeout = 5000
+	}
+	if config.Database.Compression.MinBytes == 0 {
+		config.Database.Compression.MinBytes = 1024
+	}
+	if config.Logging.Format == "" {
+		if config.Runtime.Container {
+			config.Logging.Format = "json"
+		} else {
+			config.Logging.Format = "text"
+		}
+	}
 	if config.ChatGPT.APIURL == "" {
 		config.ChatGPT.APIURL = "https://api.openai.com/v1/chat/completions"
 	}
@@ -79,6 +397,24 @@ func LoadConfig(configPath string) (*Config, error) {
 	if config.ChatGPT.Judge.Temperature == 0 {
 		config.ChatGPT.Judge.Temperature = 0.7
 	}
+	if config.ChatGPT.Judge.Concurrency == 0 {
+		config.ChatGPT.Judge.Concurrency = 2
+	}
+	if config.ChatGPT.Judge.CloseCall.Runs == 0 {
+		config.ChatGPT.Judge.CloseCall.Runs = 3
+	}
+	if config.ChatGPT.Judge.CloseCall.Temperature == 0 {
+		config.ChatGPT.Judge.CloseCall.Temperature = 0.2
+	}
+	if config.ChatGPT.HouseBot.MaxTokens == 0 {
+		config.ChatGPT.HouseBot.MaxTokens = 800
+	}
+	if config.ChatGPT.HouseBot.Temperature == 0 {
+		config.ChatGPT.HouseBot.Temperature = 0.9
+	}
+	if config.ChatGPT.Budget.MaxDelay == 0 {
+		config.ChatGPT.Budget.MaxDelay = 60
+	}
 	if config.Debate.SpeechTimeout == 0 {
 		config.Debate.SpeechTimeout = 120
 	}
@@ -97,6 +433,136 @@ func LoadConfig(configPath string) (*Config, error) {
 	if config.Debate.MaxContentLength == 0 {
 		config.Debate.MaxContentLength = 2000
 	}
+	if config.Debate.CountdownInterval == 0 {
+		config.Debate.CountdownInterval = 15
+	}
+	if config.Debate.ReconnectGrace == 0 {
+		config.Debate.ReconnectGrace = 60
+	}
+	if config.Debate.ClosingGrace == 0 {
+		config.Debate.ClosingGrace = 3
+	}
+	if config.Debate.MaxViolations == 0 {
+		config.Debate.MaxViolations = 5
+	}
+	if config.Debate.Matchmaking.TotalRounds == 0 {
+		config.Debate.Matchmaking.TotalRounds = 3
+	}
+	if config.Debate.Matchmaking.QueueTimeout == 0 {
+		config.Debate.Matchmaking.QueueTimeout = 300
+	}
+	if config.Debate.SideChannel.MaxMessages == 0 {
+		config.Debate.SideChannel.MaxMessages = 10
+	}
+	if config.Debate.SideChannel.MaxBytes == 0 {
+		config.Debate.SideChannel.MaxBytes = 500
+	}
+	if config.Stats.SideBias.Threshold == 0 {
+		config.Stats.SideBias.Threshold = 0.15
+	}
+	if config.Stats.SideBias.MinSamples == 0 {
+		config.Stats.SideBias.MinSamples = 30
+	}
+	if config.Stats.SideBias.WindowDays == 0 {
+		config.Stats.SideBias.WindowDays = 30
+	}
+	if config.ChatGPT.Sandbox.Debate.Topic == "" {
+		config.ChatGPT.Sandbox.Debate.Topic = "人工智能的发展利大于弊"
+	}
+	if config.ChatGPT.Sandbox.Debate.TotalRounds == 0 {
+		config.ChatGPT.Sandbox.Debate.TotalRounds = 2
+	}
+	if config.ChatGPT.Sandbox.Debate.SpeechTimeout == 0 {
+		config.ChatGPT.Sandbox.Debate.SpeechTimeout = 600
+	}
+	if config.ChatGPT.Topics.MaxSuggestions == 0 {
+		config.ChatGPT.Topics.MaxSuggestions = 10
+	}
+	if config.ChatGPT.Retry.MaxAttempts == 0 {
+		config.ChatGPT.Retry.MaxAttempts = 3
+	}
+	if config.ChatGPT.Retry.Backoff == 0 {
+		config.ChatGPT.Retry.Backoff = 500
+	}
+	if config.ChatGPT.Retry.MaxBackoff == 0 {
+		config.ChatGPT.Retry.MaxBackoff = 8000
+	}
+	if config.ChatGPT.CircuitBreaker.Failures == 0 {
+		config.ChatGPT.CircuitBreaker.Failures = 5
+	}
+	if config.ChatGPT.CircuitBreaker.Cooldown == 0 {
+		config.ChatGPT.CircuitBreaker.Cooldown = 60
+	}
+	if config.Stats.Timeseries.CacheTTL == 0 {
+		config.Stats.Timeseries.CacheTTL = 300
+	}
+	if config.Stats.Timeseries.DefaultDays == 0 {
+		config.Stats.Timeseries.DefaultDays = 90
+	}
+	if config.Stats.Timeseries.MaxDays == 0 {
+		config.Stats.Timeseries.MaxDays = 730
+	}
+	if config.Redaction.Mask == "" {
+		config.Redaction.Mask = "***"
+	}
+	if config.Debate.Pairing.Policy == "" {
+		config.Debate.Pairing.Policy = AutoAssignOldest
+	}
+	if config.Hooks.Timeout == 0 {
+		config.Hooks.Timeout = 5
+	}
+	if config.Webhooks.Timeout == 0 {
+		config.Webhooks.Timeout = 10
+	}
+	if config.Webhooks.MaxAttempts == 0 {
+		config.Webhooks.MaxAttempts = 5
+	}
+	if config.Webhooks.Backoff == 0 {
+		config.Webhooks.Backoff = 2
+	}
+	if config.Reaper.Interval == 0 {
+		config.Reaper.Interval = 60
+	}
+	if config.Reaper.AbandonedAfter == 0 {
+		config.Reaper.AbandonedAfter = 600 // 10 minutes
+	}
+	if config.Reaper.RetainEnded == 0 {
+		config.Reaper.RetainEnded = 300 // 5 minutes
+	}
+	if config.Artifacts.Backend == "" {
+		config.Artifacts.Backend = ArtifactBackendDatabase
+	}
+	if config.Artifacts.Dir == "" {
+		config.Artifacts.Dir = "./artifacts"
+	}
+	if config.Artifacts.MaxBytes == 0 {
+		config.Artifacts.MaxBytes = 65536 // 64 KB
+	}
+	if config.Artifacts.MaxPerSpeech == 0 {
+		config.Artifacts.MaxPerSpeech = 3
+	}
+	if config.Replay.SpeechInterval == 0 {
+		config.Replay.SpeechInterval = 5
+	}
+	if config.Replay.IdleTimeout == 0 {
+		config.Replay.IdleTimeout = 1800 // 30 minutes
+	}
+	if config.Replay.MaxSessions == 0 {
+		config.Replay.MaxSessions = 100
+	}
+	if config.Replay.MaxGap == 0 {
+		config.Replay.MaxGap = 30
+	}
+	if config.Replay.MaxStreams == 0 {
+		config.Replay.MaxStreams = 200
+	}
+	var problems []string
+	if err := resolveJudgePanel(config.ChatGPT.Judge.Panel); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if err := resolveJudgeRubric(&config.ChatGPT.Judge.Rubric); err != nil {
+		problems = append(problems, err.Error())
+	}
 
 	// Override API key from environment variables if present
 	// Priority: OPENAI_API_KEY > CHATGPT_API_KEY > config file
@@ -107,6 +573,64 @@ func LoadConfig(configPath string) (*Config, error) {
 		config.ChatGPT.APIKey = envKey
 		log.Printf("Using ChatGPT API key from CHATGPT_API_KEY environment variable")
 	}
+	if err := resolveModelProfiles(&config); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	// Container-friendly overrides for paths and listen address
+	if envPort := os.Getenv("PORT"); envPort != "" {
+		port, err := strconv.Atoi(envPort)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("invalid PORT environment variable: %v", err))
+		} else {
+			config.Server.Port = port
+		}
+	}
+	if envPath := os.Getenv("DATABASE_PATH"); envPath != "" {
+		config.Database.Path = envPath
+	}
+	if envPath := os.Getenv("FRONTEND_PATH"); envPath != "" {
+		config.Server.FrontendPath = envPath
+	}
+	if envID := os.Getenv("INSTANCE_ID"); envID != "" {
+		config.Cluster.InstanceID = envID
+	}
+	if envURL := os.Getenv("PUBLIC_URL"); envURL != "" {
+		config.Cluster.PublicURL = envURL
+	}
+	if envSecret := os.Getenv("CLUSTER_SECRET"); envSecret != "" {
+		config.Cluster.Secret = envSecret
+	}
+	if envRole := os.Getenv("INSTANCE_ROLE"); envRole != "" {
+		config.Cluster.Role = envRole
+	}
+	if envFormat := os.Getenv("LOG_FORMAT"); envFormat != "" {
+		config.Logging.Format = envFormat
+	}
+
+	if config.Cluster.Role == "" {
+		config.Cluster.Role = RolePrimary
+	}
+	if config.Cluster.Role != RolePrimary && config.Cluster.Role != RoleReplica {
+		problems = append(problems, fmt.Sprintf("invalid cluster role %q (expected %s or %s)", config.Cluster.Role, RolePrimary, RoleReplica))
+	}
+	if config.Cluster.ReplicaPollInterval == 0 {
+		config.Cluster.ReplicaPollInterval = 2
+	}
+
+	if config.Chaos.Enabled && os.Getenv("DEBATE_ENV") == "production" {
+		problems = append(problems, "chaos mode must not be enabled when DEBATE_ENV=production")
+	}
+	if config.Chaos.Enabled && config.Chaos.MaxDelayMs == 0 {
+		config.Chaos.MaxDelayMs = 5000
+	}
 
+	checked, warnings := checkConfig(data, &config)
+	for _, warning := range warnings {
+		log.Printf("Config warning: %s", warning)
+	}
+	if problems = append(problems, checked...); len(problems) > 0 {
+		return nil, &ConfigError{Path: configPath, Problems: problems}
+	}
 	return &config, nil
 }
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The config file is checked as a whole when it is loaded, and every problem
+// found is reported at once: settings the Config struct does not know (usually
+// typos, which yaml would otherwise silently ignore), values outside their
+// range and contradictory combinations. Settings that only degrade a feature,
+// such as an LLM feature enabled without an API key, are warnings and do not
+// stop startup. `serve --check-config` runs the same checks and exits.
+
+// ConfigError lists every problem found in a config file
+type ConfigError struct {
+	Path     string
+	Problems []string
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("%s has %d problem(s):\n  - %s", e.Path, len(e.Problems), strings.Join(e.Problems, "\n  - "))
+}
+
+// checkConfig validates a loaded config against the raw file it came from.
+// Problems make the config unusable; warnings are logged.
+func checkConfig(data []byte, cfg *Config) (problems, warnings []string) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err == nil {
+		problems = unknownKeys(&root, reflect.TypeOf(Config{}), "")
+	}
+
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+	positive := func(name string, value int) {
+		check(value > 0, "%s must be positive, got %d", name, value)
+	}
+	nonNegative := func(name string, value int) {
+		check(value >= 0, "%s must not be negative, got %d", name, value)
+	}
+	fraction := func(name string, value float64) {
+		check(value >= 0 && value <= 1, "%s must be between 0 and 1, got %g", name, value)
+	}
+	temperature := func(name string, value float64) {
+		check(value >= 0 && value <= 2, "%s must be between 0 and 2, got %g", name, value)
+	}
+
+	check(cfg.Server.Port > 0 && cfg.Server.Port < 65536, "server.port must be between 1 and 65535, got %d", cfg.Server.Port)
+	positive("server.max_header_bytes", cfg.Server.MaxHeaderBytes)
+	positive("server.handler_timeout", cfg.Server.HandlerTimeout)
+	positive("frontend.idle_timeout", cfg.Frontend.IdleTimeout)
+	positive("frontend.ping_interval", cfg.Frontend.PingInterval)
+	nonNegative("frontend.max_spectators", cfg.Frontend.MaxSpectators)
+	positive("frontend.chat.max_length", cfg.Frontend.Chat.MaxLength)
+	positive("frontend.chat.history", cfg.Frontend.Chat.History)
+	nonNegative("frontend.chat.rate.per_minute", cfg.Frontend.Chat.Rate.PerMinute)
+	check(cfg.Frontend.PingInterval < cfg.Frontend.IdleTimeout,
+		"frontend.ping_interval (%d) must be shorter than frontend.idle_timeout (%d)", cfg.Frontend.PingInterval, cfg.Frontend.IdleTimeout)
+	positive("database.busy_timeout", cfg.Database.BusyTimeout)
+	positive("database.compression.min_bytes", cfg.Database.Compression.MinBytes)
+	check(cfg.Logging.Format == "text" || cfg.Logging.Format == "json", "logging.format must be text or json, got %q", cfg.Logging.Format)
+	positive("cluster.replica_poll_interval", cfg.Cluster.ReplicaPollInterval)
+	check(cfg.Cluster.Secret != "change-me", "cluster.secret must not be the example value change-me")
+	check(cfg.Cluster.PublicURL == "" || cfg.Cluster.Secret != "", "cluster.secret is required when cluster.public_url is set")
+
+	fraction("chaos.delay_probability", cfg.Chaos.DelayProbability)
+	fraction("chaos.duplicate_update_probability", cfg.Chaos.DuplicateUpdateProbability)
+	fraction("chaos.drop_pong_probability", cfg.Chaos.DropPongProbability)
+	fraction("chaos.transient_error_probability", cfg.Chaos.TransientErrorProbability)
+	nonNegative("chaos.max_delay_ms", cfg.Chaos.MaxDelayMs)
+
+	d := cfg.Debate
+	positive("debate.speech_timeout", d.SpeechTimeout)
+	positive("debate.inactivity_timeout", d.InactivityTimeout)
+	positive("debate.max_duration", d.MaxDuration)
+	positive("debate.waiting_timeout", d.WaitingTimeout)
+	positive("debate.min_content_length", d.MinContentLength)
+	positive("debate.max_content_length", d.MaxContentLength)
+	check(d.MinContentLength <= d.MaxContentLength,
+		"debate.min_content_length (%d) must not exceed debate.max_content_length (%d)", d.MinContentLength, d.MaxContentLength)
+	nonNegative("debate.max_rounds", d.MaxRounds)
+	nonNegative("debate.max_content_ceiling", d.MaxContentCeiling)
+	nonNegative("debate.min_speech_timeout", d.MinSpeechTimeout)
+	check(d.MaxContentCeiling == 0 || d.MaxContentLength <= d.MaxContentCeiling,
+		"debate.max_content_length (%d) must not exceed debate.max_content_ceiling (%d)", d.MaxContentLength, d.MaxContentCeiling)
+	check(d.SpeechTimeout >= d.MinSpeechTimeout,
+		"debate.speech_timeout (%d) must be at least debate.min_speech_timeout (%d)", d.SpeechTimeout, d.MinSpeechTimeout)
+	check(d.MaxRounds == 0 || d.Matchmaking.TotalRounds <= d.MaxRounds,
+		"debate.matchmaking.total_rounds (%d) must not exceed debate.max_rounds (%d)", d.Matchmaking.TotalRounds, d.MaxRounds)
+	nonNegative("debate.tiebreak.margin", d.Tiebreak.Margin)
+	positive("debate.matchmaking.total_rounds", d.Matchmaking.TotalRounds)
+	positive("debate.matchmaking.queue_timeout", d.Matchmaking.QueueTimeout)
+	for i, topic := range d.Matchmaking.Topics {
+		check(strings.TrimSpace(topic) != "", "debate.matchmaking.topics[%d] is empty", i)
+	}
+	positive("debate.side_channel.max_messages", d.SideChannel.MaxMessages)
+	positive("debate.side_channel.max_bytes", d.SideChannel.MaxBytes)
+	switch d.Pairing.Policy {
+	case AutoAssignOldest, AutoAssignNewest, AutoAssignTags, AutoAssignRating, AutoAssignOff:
+	default:
+		check(false, "debate.pairing.policy must be oldest, newest, tags, rating or off, got %q", d.Pairing.Policy)
+	}
+	nonNegative("debate.pairing.cooldown", d.Pairing.Cooldown)
+	nonNegative("debate.pairing.category_window", d.Pairing.CategoryWindow)
+	if d.MinClientVersion != "" {
+		valid := true
+		for _, part := range strings.Split(strings.TrimPrefix(d.MinClientVersion, "v"), ".") {
+			if _, err := strconv.Atoi(part); err != nil {
+				valid = false
+			}
+		}
+		check(valid, "debate.min_client_version %q is not a version like 2.0", d.MinClientVersion)
+	}
+
+	check(cfg.Stats.SideBias.Threshold > 0 && cfg.Stats.SideBias.Threshold <= 0.5,
+		"stats.side_bias.threshold must be above 0 and at most 0.5, got %g", cfg.Stats.SideBias.Threshold)
+	positive("stats.side_bias.min_samples", cfg.Stats.SideBias.MinSamples)
+	positive("stats.side_bias.window_days", cfg.Stats.SideBias.WindowDays)
+	positive("stats.timeseries.cache_ttl", cfg.Stats.Timeseries.CacheTTL)
+	positive("stats.timeseries.default_days", cfg.Stats.Timeseries.DefaultDays)
+	positive("stats.timeseries.max_days", cfg.Stats.Timeseries.MaxDays)
+	check(cfg.Stats.Timeseries.DefaultDays <= cfg.Stats.Timeseries.MaxDays,
+		"stats.timeseries.default_days (%d) may not exceed max_days (%d)", cfg.Stats.Timeseries.DefaultDays, cfg.Stats.Timeseries.MaxDays)
+
+	for i, expr := range cfg.Redaction.Patterns {
+		_, err := regexp.Compile(expr)
+		check(err == nil, "redaction.patterns[%d]: %v", i, err)
+	}
+
+	positive("hooks.timeout", cfg.Hooks.Timeout)
+	for i, hook := range cfg.Hooks.Commands {
+		check(hook.Name != "", "hooks.commands[%d].name is required", i)
+		check(len(hook.Command) > 0, "hooks.commands[%d].command is required", i)
+		for _, event := range hook.Events {
+			known := false
+			for _, point := range hookPoints {
+				known = known || event == point
+			}
+			check(known, "hooks.commands[%d].events: unknown hook point %q (expected one of %s)", i, event, strings.Join(hookPoints, ", "))
+		}
+	}
+
+	check(!cfg.Auth.Enabled || len(cfg.Auth.Keys) > 0, "auth.keys needs at least one key when auth is enabled")
+	keyNames := map[string]bool{}
+	hasAdmin := false
+	for i, key := range cfg.Auth.Keys {
+		check(key.Name != "", "auth.keys[%d].name is required", i)
+		check(len(key.Key) >= 16, "auth.keys[%d].key must be at least 16 characters", i)
+		check(!keyNames[key.Name], "auth.keys[%d]: duplicate name %q", i, key.Name)
+		keyNames[key.Name] = true
+		hasAdmin = hasAdmin || key.Admin
+	}
+	if cfg.Auth.Enabled && !hasAdmin {
+		warnings = append(warnings, "auth is enabled but no auth.keys entry is admin, so the admin routes refuse every client")
+	}
+
+	positive("webhooks.timeout", cfg.Webhooks.Timeout)
+	positive("webhooks.max_attempts", cfg.Webhooks.MaxAttempts)
+	positive("webhooks.backoff", cfg.Webhooks.Backoff)
+	for i, endpoint := range cfg.Webhooks.Endpoints {
+		u, err := url.Parse(endpoint.URL)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+			"webhooks.endpoints[%d].url must be an http or https URL, got %q", i, endpoint.URL)
+		for _, event := range endpoint.Events {
+			known := false
+			for _, e := range webhookEvents {
+				known = known || event == e
+			}
+			check(known, "webhooks.endpoints[%d].events: unknown event %q (expected one of %s)", i, event, strings.Join(webhookEvents, ", "))
+		}
+	}
+
+	nonNegative("rate_limit.create_debate.per_minute", cfg.RateLimit.CreateDebate.PerMinute)
+	nonNegative("rate_limit.create_debate.burst", cfg.RateLimit.CreateDebate.Burst)
+	nonNegative("rate_limit.connect.per_minute", cfg.RateLimit.Connect.PerMinute)
+	nonNegative("rate_limit.connect.burst", cfg.RateLimit.Connect.Burst)
+	nonNegative("rate_limit.speech.per_minute", cfg.RateLimit.Speech.PerMinute)
+	nonNegative("rate_limit.speech.burst", cfg.RateLimit.Speech.Burst)
+
+	positive("reaper.interval", cfg.Reaper.Interval)
+	positive("reaper.abandoned_after", cfg.Reaper.AbandonedAfter)
+	positive("reaper.retain_ended", cfg.Reaper.RetainEnded)
+
+	check(cfg.Artifacts.Backend == ArtifactBackendDatabase || cfg.Artifacts.Backend == ArtifactBackendFilesystem,
+		"artifacts.backend must be %s or %s, got %q", ArtifactBackendDatabase, ArtifactBackendFilesystem, cfg.Artifacts.Backend)
+	positive("artifacts.max_bytes", cfg.Artifacts.MaxBytes)
+	nonNegative("artifacts.max_per_speech", cfg.Artifacts.MaxPerSpeech)
+
+	positive("replay.speech_interval", cfg.Replay.SpeechInterval)
+	positive("replay.idle_timeout", cfg.Replay.IdleTimeout)
+	positive("replay.max_sessions", cfg.Replay.MaxSessions)
+	positive("replay.max_gap", cfg.Replay.MaxGap)
+	positive("replay.max_streams", cfg.Replay.MaxStreams)
+
+	gpt := cfg.ChatGPT
+	positive("chatgpt.timeout", gpt.Timeout)
+	for _, name := range profileNames(cfg) {
+		profile := gpt.Profiles[name]
+		positive("chatgpt.profiles."+name+".timeout", profile.Timeout)
+		nonNegative("chatgpt.profiles."+name+".max_tokens", profile.MaxTokens)
+	}
+	positive("chatgpt.judge.max_tokens", gpt.Judge.MaxTokens)
+	temperature("chatgpt.judge.temperature", gpt.Judge.Temperature)
+	positive("chatgpt.judge.concurrency", gpt.Judge.Concurrency)
+	nonNegative("chatgpt.judge.close_call.margin", gpt.Judge.CloseCall.Margin)
+	positive("chatgpt.judge.close_call.runs", gpt.Judge.CloseCall.Runs)
+	temperature("chatgpt.judge.close_call.temperature", gpt.Judge.CloseCall.Temperature)
+	switch gpt.Judge.Provider {
+	case "", JudgeProviderOpenAI, JudgeProviderMock:
+	default:
+		check(false, "chatgpt.judge.provider must be openai or mock, got %q", gpt.Judge.Provider)
+	}
+	if err := gpt.Judge.Verdict.validate(); err != nil {
+		check(false, "chatgpt.judge.%v", err)
+	}
+	positive("chatgpt.sandbox.debate.total_rounds", gpt.Sandbox.Debate.TotalRounds)
+	positive("chatgpt.sandbox.debate.speech_timeout", gpt.Sandbox.Debate.SpeechTimeout)
+	check(d.MaxRounds == 0 || gpt.Sandbox.Debate.TotalRounds <= d.MaxRounds,
+		"chatgpt.sandbox.debate.total_rounds (%d) must not exceed debate.max_rounds (%d)", gpt.Sandbox.Debate.TotalRounds, d.MaxRounds)
+	positive("chatgpt.house_bot.max_tokens", gpt.HouseBot.MaxTokens)
+	temperature("chatgpt.house_bot.temperature", gpt.HouseBot.Temperature)
+	nonNegative("chatgpt.budget.max_calls_per_hour", gpt.Budget.MaxCallsPerHour)
+	nonNegative("chatgpt.budget.max_tokens_per_day", gpt.Budget.MaxTokensPerDay)
+	nonNegative("chatgpt.budget.max_tokens_per_month", gpt.Budget.MaxTokensPerMonth)
+	check(gpt.Budget.MaxCostPerDay >= 0, "chatgpt.budget.max_cost_per_day must not be negative, got %g", gpt.Budget.MaxCostPerDay)
+	nonNegative("chatgpt.budget.max_delay", gpt.Budget.MaxDelay)
+	positive("chatgpt.topics.max_suggestions", gpt.Topics.MaxSuggestions)
+	positive("chatgpt.retry.max_attempts", gpt.Retry.MaxAttempts)
+	positive("chatgpt.retry.backoff_ms", gpt.Retry.Backoff)
+	positive("chatgpt.retry.max_backoff_ms", gpt.Retry.MaxBackoff)
+	check(gpt.Retry.Backoff <= gpt.Retry.MaxBackoff,
+		"chatgpt.retry.backoff_ms (%d) may not exceed max_backoff_ms (%d)", gpt.Retry.Backoff, gpt.Retry.MaxBackoff)
+	positive("chatgpt.circuit_breaker.cooldown", gpt.CircuitBreaker.Cooldown)
+
+	// LLM features without a usable key run, but every call fails
+	needsKey := func(feature, profile string) {
+		if profile == "" {
+			profile = defaultModelProfile
+		}
+		settings, known := gpt.Profiles[profile]
+		if known && (settings.APIKey == "" || settings.APIKey == "your-api-key-here") {
+			warnings = append(warnings, fmt.Sprintf("%s is enabled but model profile %s has no API key", feature, profile))
+		}
+	}
+	if gpt.Judge.Enabled && gpt.Judge.Provider == JudgeProviderMock {
+		if gpt.Sandbox.Enabled {
+			warnings = append(warnings, "chatgpt.sandbox is enabled but the mock judge cannot score speeches")
+		}
+	} else if gpt.Judge.Enabled {
+		needsKey("chatgpt.judge", gpt.Judge.Profile)
+	} else if gpt.Sandbox.Enabled {
+		warnings = append(warnings, "chatgpt.sandbox is enabled but needs chatgpt.judge, which is disabled")
+	}
+	if gpt.HouseBot.Enabled {
+		needsKey("chatgpt.house_bot", gpt.HouseBot.Profile)
+	} else if gpt.Sandbox.Debate.Enabled {
+		warnings = append(warnings, "chatgpt.sandbox.debate is enabled but needs chatgpt.house_bot, which is disabled")
+	}
+	if d.AutoTranslate {
+		needsKey("debate.auto_translate", gpt.Translation.Profile)
+	}
+	if d.OpponentSummary {
+		needsKey("debate.opponent_summary", gpt.Summary.Profile)
+	}
+	if gpt.Topics.Enabled {
+		needsKey("chatgpt.topics", gpt.Topics.Profile)
+	}
+	return problems, warnings
+}
+
+// unknownKeys lists the mapping keys under node that have no field in t
+func unknownKeys(node *yaml.Node, t reflect.Type, path string) []string {
+	if node.Kind == yaml.DocumentNode {
+		if len(node.Content) == 0 {
+			return nil
+		}
+		return unknownKeys(node.Content[0], t, path)
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var unknown []string
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		fields := map[string]reflect.Type{}
+		for i := 0; i < t.NumField(); i++ {
+			name := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+			if name != "" && name != "-" {
+				fields[name] = t.Field(i).Type
+			}
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			field, known := fields[key]
+			if !known {
+				unknown = append(unknown, fmt.Sprintf("%s: unknown setting (line %d)", joinKey(path, key), node.Content[i].Line))
+				continue
+			}
+			unknown = append(unknown, unknownKeys(node.Content[i+1], field, joinKey(path, key))...)
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			unknown = append(unknown, unknownKeys(node.Content[i+1], t.Elem(), joinKey(path, node.Content[i].Value))...)
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return nil
+		}
+		for i, item := range node.Content {
+			unknown = append(unknown, unknownKeys(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	}
+	return unknown
+}
+
+// joinKey appends a key to a dotted settings path
+func joinKey(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// The conformance harness (cmd/conformance) walks a bot through scripted
+// protocol scenarios. Run with -submit and an admin key, it posts its report
+// to POST /api/admin/conformance, which keeps the latest run per bot_uuid.
+// With debate.require_conformance on, a bot whose latest run did not pass is
+// refused ranked debates: logins to a ranked debate, auto-assignment and the
+// lobby. Unranked and sandbox debates stay open, so a bot can be developed
+// against the real server before it passes. The house bot is exempt.
+
+// ConformanceReport is the report the harness submits
+type ConformanceReport struct {
+	BotUUID       string          `json:"bot_uuid"`
+	BotIdentifier string          `json:"bot_identifier"`
+	StartedAt     time.Time       `json:"started_at"`
+	Results       json.RawMessage `json:"results"`
+	Conformant    bool            `json:"conformant"`
+}
+
+// ConformanceRun is the latest recorded harness run of a bot
+type ConformanceRun struct {
+	ConformanceReport
+	RecordedBy string    `json:"recorded_by"` // Name of the admin key that submitted it
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// checkConformance refuses a ranked login when conformance is required and
+// the bot's latest harness run did not pass
+func checkConformance(loginReq *LoginRequest) *LoginRejected {
+	if !config.Debate.RequireConformance || loginReq.internal {
+		return nil
+	}
+	reject := func(reason, message string) *LoginRejected {
+		return &LoginRejected{Status: "rejected", Reason: reason, Message: message, DebateID: loginReq.DebateID}
+	}
+
+	run, err := db.GetConformanceRun(loginReq.BotUUID)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Failed to look up conformance of bot %s: %v", loginReq.BotUUID, err)
+		return reject("internal_error", "Could not check the bot's conformance, try again later")
+	}
+	if run == nil || !run.Conformant {
+		return reject("conformance_required", "Ranked debates need a passing conformance run for this bot_uuid. Run cmd/conformance and have an admin submit the report; unranked debates are open meanwhile.")
+	}
+	return nil
+}
+
+// handleAdminConformance handles POST /api/admin/conformance (a harness
+// report) and GET /api/admin/conformance/{bot_uuid}
+func handleAdminConformance(w http.ResponseWriter, r *http.Request) {
+	botUUID := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/admin/conformance"), "/")
+
+	switch {
+	case botUUID == "" && r.Method == http.MethodPost:
+		var report ConformanceReport
+		if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+			http.Error(w, "Invalid report", http.StatusBadRequest)
+			return
+		}
+		if report.BotUUID == "" {
+			http.Error(w, "bot_uuid is required", http.StatusBadRequest)
+			return
+		}
+		if len(report.Results) == 0 {
+			report.Results = json.RawMessage("[]")
+		}
+		name, _, _ := authenticateKey(r)
+		run := &ConformanceRun{ConformanceReport: report, RecordedBy: name, RecordedAt: time.Now()}
+		if err := db.SaveConformanceRun(run); err != nil {
+			log.Printf("Failed to save conformance run of bot %s: %v", report.BotUUID, err)
+			http.Error(w, "Failed to save conformance run", http.StatusInternalServerError)
+			return
+		}
+		log.Printf("Conformance run of bot %s recorded (conformant: %v)", report.BotUUID, report.Conformant)
+		writeJSONStatus(w, http.StatusCreated, run)
+	case botUUID != "" && r.Method == http.MethodGet:
+		run, err := db.GetConformanceRun(botUUID)
+		if err == sql.ErrNoRows {
+			http.Error(w, "No conformance run for this bot", http.StatusNotFound)
+			return
+		} else if err != nil {
+			http.Error(w, "Failed to fetch conformance run", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, run)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// SaveConformanceRun records a bot's harness run, replacing its previous one
+func (d *Database) SaveConformanceRun(run *ConformanceRun) error {
+	query := `INSERT OR REPLACE INTO bot_conformance (bot_uuid, bot_identifier, conformant, results, started_at, recorded_by, recorded_at)
+	          VALUES (?, ?, ?, ?, ?, ?, ?)`
+	_, err := d.db.Exec(query, run.BotUUID, run.BotIdentifier, run.Conformant, string(run.Results),
+		run.StartedAt, run.RecordedBy, run.RecordedAt)
+	return err
+}
+
+// GetConformanceRun returns the latest harness run of a bot_uuid
+func (d *Database) GetConformanceRun(botUUID string) (*ConformanceRun, error) {
+	run := &ConformanceRun{}
+	var results string
+	query := `SELECT bot_uuid, bot_identifier, conformant, results, started_at, recorded_by, recorded_at
+	          FROM bot_conformance WHERE bot_uuid = ?`
+	err := d.db.QueryRow(query, botUUID).Scan(&run.BotUUID, &run.BotIdentifier, &run.Conformant, &results,
+		&run.StartedAt, &run.RecordedBy, &run.RecordedAt)
+	if err != nil {
+		return nil, err
+	}
+	run.Results = json.RawMessage(results)
+	return run, nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+// countdownMarks are the remaining-second marks always announced near a deadline
+var countdownMarks = []int{30, 10, 5}
+
+// TurnCountdown tells spectators and the due speaker(s) how long is left
+type TurnCountdown struct {
+	DebateID         string   `json:"debate_id"`
+	Round            int      `json:"round"`
+	Speakers         []string `json:"speakers"` // Bots the deadline applies to
+	RemainingSeconds int      `json:"remaining_seconds"`
+	Deadline         string   `json:"deadline"`
+}
+
+// turnTimeout is the speech timeout of the turn being started: the full
+// limit, or what was left of it when a restored debate resumes
+func (a *ActiveDebate) turnTimeout() time.Duration {
+	if a.TurnTimeLeft > 0 {
+		return a.TurnTimeLeft
+	}
+	return time.Duration(formatLimitsFor(a.Debate).SpeechTimeout) * time.Second
+}
+
+// startCountdown announces the current turn deadline every countdown_interval
+// seconds and at the countdownMarks, replacing any previous countdown. It
+// should be called whenever a TimeoutTimer is armed for the speech timeout.
+func (dm *DebateManager) startCountdown(activeDebate *ActiveDebate, speakers ...*ConnectedBot) {
+	stopCountdown(activeDebate)
+	deadline := time.Now().Add(activeDebate.turnTimeout())
+	activeDebate.TurnDeadline = deadline
+	if config.Debate.CountdownInterval < 0 {
+		return
+	}
+
+	quit := make(chan struct{})
+	activeDebate.countdownQuit = quit
+
+	speakerIDs := []string{}
+	for _, bot := range speakers {
+		if bot != nil {
+			speakerIDs = append(speakerIDs, bot.Bot.BotIdentifier)
+		}
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+
+		lastSent := -1
+		for {
+			remaining := int(math.Ceil(time.Until(deadline).Seconds()))
+			if remaining <= 0 {
+				return
+			}
+			if remaining != lastSent && shouldAnnounce(remaining, lastSent) {
+				lastSent = remaining
+				msg := createMessage("turn_countdown", TurnCountdown{
+					DebateID:         activeDebate.Debate.ID,
+					Round:            activeDebate.Debate.CurrentRound,
+					Speakers:         speakerIDs,
+					RemainingSeconds: remaining,
+					Deadline:         deadline.Format(time.RFC3339),
+				})
+				for _, bot := range speakers {
+					if bot != nil && bot.Conn != nil {
+						bot.Conn.WriteJSONWithin(msg, backgroundWriteTimeout)
+					}
+				}
+				dm.broadcast <- BroadcastMessage{DebateID: activeDebate.Debate.ID, Message: msg}
+			}
+
+			select {
+			case <-quit:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// shouldAnnounce reports whether a countdown update is due at this many seconds left
+func shouldAnnounce(remaining, lastSent int) bool {
+	if lastSent < 0 {
+		return true // Announce the full clock once when the turn starts
+	}
+	if interval := config.Debate.CountdownInterval; interval > 0 && remaining%interval == 0 {
+		return true
+	}
+	for _, mark := range countdownMarks {
+		if remaining == mark {
+			return true
+		}
+	}
+	return false
+}
+
+// stopCountdown stops the countdown of the current turn, if any
+func stopCountdown(activeDebate *ActiveDebate) {
+	if activeDebate.countdownQuit != nil {
+		close(activeDebate.countdownQuit)
+		activeDebate.countdownQuit = nil
+	}
+}
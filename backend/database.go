@@ -3,6 +3,8 @@ package main
 import (
 	"database/sql"
 	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -11,105 +13,95 @@ import (
 // Database handles all database operations
 type Database struct {
 	db *sql.DB
+
+	compressMinBytes int // Bodies at least this long are stored compressed; 0 disables
+}
+
+// NewDatabase creates a new database connection.
+// Schema migrations are applied separately via Migrate.
+func NewDatabase(dbPath string, busyTimeout int) (*Database, error) {
+	return openDatabase(dbPath, "_journal_mode=WAL&"+sqlitePragmas(busyTimeout))
 }
 
-// NewDatabase creates a new database connection
-func NewDatabase(dbPath string) (*Database, error) {
-	db, err := sql.Open("sqlite3", dbPath)
+// NewReadOnlyDatabase opens a database replica without write access
+func NewReadOnlyDatabase(dbPath string, busyTimeout int) (*Database, error) {
+	return openDatabase("file:"+dbPath+"?mode=ro", sqlitePragmas(busyTimeout))
+}
+
+// sqlitePragmas are applied to every pooled connection: busy_timeout (ms)
+// makes a writer wait for the lock instead of failing with "database is
+// locked", and foreign_keys enforces the schema's references. Writable
+// databases also switch to WAL, so readers no longer block the writer.
+func sqlitePragmas(busyTimeout int) string {
+	return fmt.Sprintf("_busy_timeout=%d&_foreign_keys=on", busyTimeout)
+}
+
+// openDatabase opens dsn with the given connection parameters
+func openDatabase(dsn, params string) (*Database, error) {
+	if strings.Contains(dsn, "?") {
+		dsn += "&" + params
+	} else {
+		dsn += "?" + params
+	}
+	db, err := sql.Open("sqlite3", dsn)
 	if err != nil {
 		return nil, err
 	}
-
-	database := &Database{db: db}
-	if err := database.createTables(); err != nil {
+	if err := db.Ping(); err != nil {
 		return nil, err
 	}
 
-	return database, nil
-}
-
-// createTables initializes database schema
-func (d *Database) createTables() error {
-	schema := `
-	CREATE TABLE IF NOT EXISTS debates (
-		id TEXT PRIMARY KEY,
-		topic TEXT NOT NULL,
-		total_rounds INTEGER NOT NULL,
-		current_round INTEGER DEFAULT 1,
-		status TEXT DEFAULT 'waiting',
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	);
-
-	CREATE TABLE IF NOT EXISTS bots (
-		bot_name TEXT NOT NULL,
-		bot_uuid TEXT NOT NULL,
-		bot_identifier TEXT NOT NULL,
-		debate_id TEXT NOT NULL,
-		debate_key TEXT NOT NULL,
-		side TEXT,
-		connected_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		PRIMARY KEY (debate_id, bot_uuid),
-		FOREIGN KEY (debate_id) REFERENCES debates(id)
-	);
-
-	CREATE TABLE IF NOT EXISTS debate_log (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		debate_id TEXT NOT NULL,
-		round INTEGER NOT NULL,
-		speaker TEXT NOT NULL,
-		side TEXT NOT NULL,
-		timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
-		message_format TEXT NOT NULL,
-		message_content TEXT NOT NULL,
-		FOREIGN KEY (debate_id) REFERENCES debates(id)
-	);
-
-	CREATE TABLE IF NOT EXISTS debate_results (
-		debate_id TEXT PRIMARY KEY,
-		winner TEXT NOT NULL,
-		supporting_score INTEGER NOT NULL,
-		opposing_score INTEGER NOT NULL,
-		summary_format TEXT NOT NULL,
-		summary_content TEXT NOT NULL,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (debate_id) REFERENCES debates(id)
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_debates_status ON debates(status);
-	CREATE INDEX IF NOT EXISTS idx_bots_debate ON bots(debate_id);
-	CREATE INDEX IF NOT EXISTS idx_debate_log_debate ON debate_log(debate_id);
-	`
-
-	_, err := d.db.Exec(schema)
-	return err
+	return &Database{db: db}, nil
 }
 
-// CreateDebate creates a new debate session
-func (d *Database) CreateDebate(debate *Debate) error {
-	query := `INSERT INTO debates (id, topic, total_rounds, current_round, status, created_at, updated_at)
-	          VALUES (?, ?, ?, ?, ?, ?, ?)`
-	_, err := d.db.Exec(query, debate.ID, debate.Topic, debate.TotalRounds, debate.CurrentRound,
-		debate.Status, debate.CreatedAt, debate.UpdatedAt)
-	return err
-}
+// debateColumns is the column list matching scanDebate
+const debateColumns = `id, short_id, topic, total_rounds, current_round, status, ranked, persona_id, format, blind_opening, scoring, private, spectator_token, languages, topic_translations, category, seats, imported_from, tournament_id, league_id, side_channel, cancel_reason, verdict_style, rules, no_ai_judge, disclose_rubric, opponent_summary, limits, sandbox, created_at, updated_at`
 
-// GetDebate retrieves a debate by ID
-func (d *Database) GetDebate(debateID string) (*Debate, error) {
-	query := `SELECT id, topic, total_rounds, current_round, status, created_at, updated_at
-	          FROM debates WHERE id = ?`
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
 
+// scanDebate scans a row selected with debateColumns
+func scanDebate(row rowScanner) (*Debate, error) {
 	debate := &Debate{}
-	err := d.db.QueryRow(query, debateID).Scan(
-		&debate.ID, &debate.Topic, &debate.TotalRounds, &debate.CurrentRound,
-		&debate.Status, &debate.CreatedAt, &debate.UpdatedAt)
-
+	var languages, topicTranslations, seats, verdict, rules, limits string
+	err := row.Scan(&debate.ID, &debate.ShortID, &debate.Topic, &debate.TotalRounds, &debate.CurrentRound,
+		&debate.Status, &debate.Ranked, &debate.PersonaID, &debate.Format, &debate.BlindOpening, &debate.Scoring, &debate.Private, &debate.SpectatorToken,
+		&languages, &topicTranslations, &debate.Category, &seats, &debate.ImportedFrom, &debate.TournamentID, &debate.LeagueID, &debate.SideChannel, &debate.CancelReason, &verdict, &rules, &debate.NoAIJudge, &debate.DiscloseRubric, &debate.OpponentSummary, &limits, &debate.Sandbox, &debate.CreatedAt, &debate.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
+	if languages != "" {
+		debate.Languages = strings.Split(languages, ",")
+	}
+	if seats != "" {
+		debate.Seats = strings.Split(seats, ",")
+	}
+	debate.TopicTranslations = decodeTranslations(topicTranslations)
+	debate.Verdict = decodeVerdictStyle(verdict)
+	debate.Limits = decodeFormatLimits(limits)
+	debate.Rules = decodeRules(rules)
 	return debate, nil
 }
 
+// CreateDebate creates a new debate session
+func (d *Database) CreateDebate(debate *Debate) error {
+	query := `INSERT INTO debates (id, short_id, topic, total_rounds, current_round, status, ranked, persona_id, format, blind_opening, scoring, private, spectator_token,
+	              languages, topic_translations, category, seats, imported_from, tournament_id, league_id, side_channel, verdict_style, no_ai_judge, disclose_rubric, opponent_summary, limits, sandbox, created_at, updated_at)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := d.db.Exec(query, debate.ID, debate.ShortID, debate.Topic, debate.TotalRounds, debate.CurrentRound,
+		debate.Status, debate.Ranked, debate.PersonaID, debate.Format, debate.BlindOpening, debate.Scoring, debate.Private, debate.SpectatorToken,
+		strings.Join(debate.Languages, ","), encodeTranslations(debate.TopicTranslations), debate.Category, strings.Join(debate.Seats, ","), debate.ImportedFrom, debate.TournamentID, debate.LeagueID, debate.SideChannel, encodeVerdictStyle(debate.Verdict), debate.NoAIJudge, debate.DiscloseRubric, debate.OpponentSummary, encodeFormatLimits(debate.Limits), debate.Sandbox, debate.CreatedAt, debate.UpdatedAt)
+	return err
+}
+
+// GetDebate retrieves a debate by ID
+func (d *Database) GetDebate(debateID string) (*Debate, error) {
+	query := `SELECT ` + debateColumns + ` FROM debates WHERE id = ?`
+	return scanDebate(d.db.QueryRow(query, debateID))
+}
+
 // UpdateDebateStatus updates debate status
 func (d *Database) UpdateDebateStatus(debateID, status string) error {
 	query := `UPDATE debates SET status = ?, updated_at = ? WHERE id = ?`
@@ -117,26 +109,39 @@ func (d *Database) UpdateDebateStatus(debateID, status string) error {
 	return err
 }
 
-// UpdateDebateRound updates current round
-func (d *Database) UpdateDebateRound(debateID string, round int) error {
-	query := `UPDATE debates SET current_round = ?, updated_at = ? WHERE id = ?`
-	_, err := d.db.Exec(query, round, time.Now(), debateID)
+// StartOvertime adds a tiebreak round and moves the debate into it
+func (d *Database) StartOvertime(debateID string, round int) error {
+	query := `UPDATE debates SET total_rounds = ?, current_round = ?, status = ?, updated_at = ? WHERE id = ?`
+	_, err := d.db.Exec(query, round, round, StatusOvertime, time.Now(), debateID)
 	return err
 }
 
+// botColumns is the column list matching scanBot
+const botColumns = `bot_name, bot_uuid, bot_identifier, debate_id, debate_key, side, client_version, connected_at`
+
+// scanBot scans a row selected with botColumns
+func scanBot(row rowScanner) (*Bot, error) {
+	bot := &Bot{}
+	err := row.Scan(&bot.BotName, &bot.BotUUID, &bot.BotIdentifier, &bot.DebateID,
+		&bot.DebateKey, &bot.Side, &bot.ClientVersion, &bot.ConnectedAt)
+	if err != nil {
+		return nil, err
+	}
+	return bot, nil
+}
+
 // AddBot registers a bot to a debate
 func (d *Database) AddBot(bot *Bot) error {
-	query := `INSERT INTO bots (bot_name, bot_uuid, bot_identifier, debate_id, debate_key, side, connected_at)
-	          VALUES (?, ?, ?, ?, ?, ?, ?)`
+	query := `INSERT INTO bots (bot_name, bot_uuid, bot_identifier, debate_id, debate_key, side, client_version, connected_at)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
 	_, err := d.db.Exec(query, bot.BotName, bot.BotUUID, bot.BotIdentifier, bot.DebateID,
-		bot.DebateKey, bot.Side, bot.ConnectedAt)
+		bot.DebateKey, bot.Side, bot.ClientVersion, bot.ConnectedAt)
 	return err
 }
 
 // GetBots retrieves all bots for a debate
 func (d *Database) GetBots(debateID string) ([]*Bot, error) {
-	query := `SELECT bot_name, bot_uuid, bot_identifier, debate_id, debate_key, side, connected_at
-	          FROM bots WHERE debate_id = ?`
+	query := `SELECT ` + botColumns + ` FROM bots WHERE debate_id = ?`
 
 	rows, err := d.db.Query(query, debateID)
 	if err != nil {
@@ -146,9 +151,7 @@ func (d *Database) GetBots(debateID string) ([]*Bot, error) {
 
 	var bots []*Bot
 	for rows.Next() {
-		bot := &Bot{}
-		err := rows.Scan(&bot.BotName, &bot.BotUUID, &bot.BotIdentifier, &bot.DebateID,
-			&bot.DebateKey, &bot.Side, &bot.ConnectedAt)
+		bot, err := scanBot(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -159,18 +162,104 @@ func (d *Database) GetBots(debateID string) ([]*Bot, error) {
 
 // GetBotByIdentifier retrieves a specific bot
 func (d *Database) GetBotByIdentifier(debateID, botIdentifier string) (*Bot, error) {
-	query := `SELECT bot_name, bot_uuid, bot_identifier, debate_id, debate_key, side, connected_at
-	          FROM bots WHERE debate_id = ? AND bot_identifier = ?`
+	query := `SELECT ` + botColumns + ` FROM bots WHERE debate_id = ? AND bot_identifier = ?`
+	return scanBot(d.db.QueryRow(query, debateID, botIdentifier))
+}
 
-	bot := &Bot{}
-	err := d.db.QueryRow(query, debateID, botIdentifier).Scan(
-		&bot.BotName, &bot.BotUUID, &bot.BotIdentifier, &bot.DebateID,
-		&bot.DebateKey, &bot.Side, &bot.ConnectedAt)
+// GetClientVersionCounts returns how many bot logins used each client version
+func (d *Database) GetClientVersionCounts() (map[string]int, error) {
+	query := `SELECT client_version, COUNT(*) FROM bots GROUP BY client_version`
 
+	rows, err := d.db.Query(query)
 	if err != nil {
 		return nil, err
 	}
-	return bot, nil
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var version string
+		var count int
+		if err := rows.Scan(&version, &count); err != nil {
+			return nil, err
+		}
+		if version == "" {
+			version = "unknown"
+		}
+		counts[version] += count
+	}
+	return counts, nil
+}
+
+// WinnerDistribution summarizes stored results by winning side
+type WinnerDistribution struct {
+	Counts             map[string]int
+	AvgSupportingScore float64
+	AvgOpposingScore   float64
+}
+
+// GetWinnerDistribution counts stored ranked debate results per winner and averages the side scores
+func (d *Database) GetWinnerDistribution() (*WinnerDistribution, error) {
+	query := `SELECT winner, SUM(debates), 1.0 * SUM(supporting_score_sum) / SUM(debates), 1.0 * SUM(opposing_score_sum) / SUM(debates)
+	          FROM daily_stats WHERE ranked = 1 GROUP BY winner HAVING SUM(debates) > 0`
+
+	rows, err := d.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	dist := &WinnerDistribution{Counts: make(map[string]int)}
+	total := 0
+	var supportingSum, opposingSum float64
+	for rows.Next() {
+		var winner string
+		var count int
+		var avgSupporting, avgOpposing float64
+		if err := rows.Scan(&winner, &count, &avgSupporting, &avgOpposing); err != nil {
+			return nil, err
+		}
+		dist.Counts[winner] = count
+		total += count
+		supportingSum += avgSupporting * float64(count)
+		opposingSum += avgOpposing * float64(count)
+	}
+	if total > 0 {
+		dist.AvgSupportingScore = supportingSum / float64(total)
+		dist.AvgOpposingScore = opposingSum / float64(total)
+	}
+	return dist, rows.Err()
+}
+
+// GetWinnerCounts counts decided results of ranked debates per judge model and winner.
+// A zero since counts all results; a non-empty period groups them by strftime format (e.g. "%Y-%W").
+func (d *Database) GetWinnerCounts(since time.Time, period string) ([]WinnerCount, error) {
+	query := `SELECT r.judge_model, strftime(?, r.created_at), r.winner, COUNT(*)
+	          FROM debate_results r
+	          JOIN debates d ON d.id = r.debate_id
+	          WHERE d.ranked = 1 AND r.winner IN ('supporting', 'opposing', 'draw') AND r.created_at >= ?
+	          GROUP BY 1, 2, 3`
+	if period == "" {
+		period = "all"
+	}
+
+	rows, err := d.db.Query(query, period, since.UTC().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []WinnerCount
+	for rows.Next() {
+		var c WinnerCount
+		var bucket sql.NullString
+		if err := rows.Scan(&c.JudgeModel, &bucket, &c.Winner, &c.Count); err != nil {
+			return nil, err
+		}
+		c.Period = bucket.String
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
 }
 
 // UpdateBotSide assigns a side to a bot
@@ -182,16 +271,70 @@ func (d *Database) UpdateBotSide(debateID, botIdentifier, side string) error {
 
 // AddDebateLog adds a speech to the debate log
 func (d *Database) AddDebateLog(entry *DebateLogEntry, debateID string) error {
-	query := `INSERT INTO debate_log (debate_id, round, speaker, side, timestamp, message_format, message_content)
-	          VALUES (?, ?, ?, ?, ?, ?, ?)`
-	_, err := d.db.Exec(query, debateID, entry.Round, entry.Speaker, entry.Side,
-		entry.Timestamp, entry.Message.Format, entry.Message.Content)
+	return d.insertDebateLog(d.db, entry, debateID)
+}
+
+// RecordSpeeches adds speeches to the debate log and moves the debate to
+// round in one transaction, so the log and the round counter never disagree
+func (d *Database) RecordSpeeches(debateID string, entries []DebateLogEntry, round int) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i := range entries {
+		if err := d.insertDebateLog(tx, &entries[i], debateID); err != nil {
+			return err
+		}
+	}
+	_, err = tx.Exec(`UPDATE debates SET current_round = ?, updated_at = ? WHERE id = ?`, round, time.Now(), debateID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// insertDebateLog inserts one speech
+func (d *Database) insertDebateLog(exec execer, entry *DebateLogEntry, debateID string) error {
+	content, encoding, err := d.encodeBody(entry.Message.Content)
+	if err != nil {
+		return err
+	}
+	query := `INSERT INTO debate_log (debate_id, round, speaker, side, timestamp, message_format, message_content,
+	              message_encoding, message_language, translations, attachments)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := exec.Exec(query, debateID, entry.Round, entry.Speaker, entry.Side,
+		entry.Timestamp, entry.Message.Format, content, encoding,
+		entry.Message.Language, encodeTranslations(entry.Message.Translations), encodeAttachments(entry.Message.Attachments))
+	if err != nil {
+		return err
+	}
+	// The search index holds the text as spoken, whatever the stored encoding
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	_, err = exec.Exec(`INSERT INTO debate_log_fts(docid, message_content) VALUES (?, ?)`, id, entry.Message.Content)
+	return err
+}
+
+// UpdateDebateLogTranslations replaces the translations of one speech
+func (d *Database) UpdateDebateLogTranslations(debateID string, round int, speaker string, translations map[string]string) error {
+	query := `UPDATE debate_log SET translations = ? WHERE debate_id = ? AND round = ? AND speaker = ?`
+	_, err := d.db.Exec(query, encodeTranslations(translations), debateID, round, speaker)
 	return err
 }
 
 // GetDebateLog retrieves all speeches for a debate
 func (d *Database) GetDebateLog(debateID string) ([]DebateLogEntry, error) {
-	query := `SELECT round, speaker, side, timestamp, message_format, message_content
+	query := `SELECT round, speaker, side, timestamp, message_format, message_content, message_encoding,
+	              message_language, translations, attachments
 	          FROM debate_log WHERE debate_id = ? ORDER BY id ASC`
 
 	rows, err := d.db.Query(query, debateID)
@@ -203,12 +346,18 @@ func (d *Database) GetDebateLog(debateID string) ([]DebateLogEntry, error) {
 	var log []DebateLogEntry
 	for rows.Next() {
 		var entry DebateLogEntry
-		var format, content string
-		err := rows.Scan(&entry.Round, &entry.Speaker, &entry.Side, &entry.Timestamp, &format, &content)
+		var format, encoding, language, translations, attachments string
+		var stored []byte
+		err := rows.Scan(&entry.Round, &entry.Speaker, &entry.Side, &entry.Timestamp, &format, &stored, &encoding, &language, &translations, &attachments)
+		if err != nil {
+			return nil, err
+		}
+		content, err := decodeBody(stored, encoding)
 		if err != nil {
 			return nil, err
 		}
-		entry.Message = SpeechMessage{Format: format, Content: content}
+		entry.Message = SpeechMessage{Format: format, Content: content, Language: language, Translations: decodeTranslations(translations),
+			Attachments: decodeAttachments(attachments)}
 		log = append(log, entry)
 	}
 	return log, nil
@@ -216,74 +365,298 @@ func (d *Database) GetDebateLog(debateID string) ([]DebateLogEntry, error) {
 
 // SaveDebateResult saves the final result
 func (d *Database) SaveDebateResult(debateID string, result *DebateResult) error {
-	query := `INSERT INTO debate_results (debate_id, winner, supporting_score, opposing_score, summary_format, summary_content)
-	          VALUES (?, ?, ?, ?, ?, ?)`
-	_, err := d.db.Exec(query, debateID, result.Winner, result.SupportingScore, result.OpposingScore,
-		result.Summary.Format, result.Summary.Content)
-	return err
+	tiebreak := result.Tiebreak
+	if tiebreak == nil {
+		tiebreak = &TiebreakInfo{}
+	}
+	rubricID, rubricHash, err := d.saveRubric(result.Rubric)
+	if err != nil {
+		return err
+	}
+	summary, encoding, err := d.encodeBody(result.Summary.Content)
+	if err != nil {
+		return err
+	}
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO debate_results (debate_id, winner, supporting_score, opposing_score, summary_format, summary_content,
+	              summary_encoding, tiebreak_round, tiebreak_reason, initial_winner, initial_supporting_score, initial_opposing_score,
+	              judge_model, rubric_id, rubric_hash, breakdown, judge_ms)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err = tx.Exec(query, debateID, result.Winner, result.SupportingScore, result.OpposingScore,
+		result.Summary.Format, summary, encoding,
+		tiebreak.Round, tiebreak.Reason, tiebreak.InitialWinner, tiebreak.InitialSupportingScore, tiebreak.InitialOpposingScore,
+		result.JudgeModel, rubricID, rubricHash, encodeBreakdown(result.Breakdown), result.JudgeMs)
+	if err != nil {
+		return err
+	}
+	if err := addResultVersion(tx, debateID, result, summary, encoding, rubricID, rubricHash); err != nil {
+		return err
+	}
+	if err := applyResultAggregates(tx, debateID, 1); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// saveRubric stores a rubric's content once per hash and returns the columns to reference it
+func (d *Database) saveRubric(rubric *RubricSnapshot) (string, string, error) {
+	if rubric == nil {
+		return "", "", nil
+	}
+	query := `INSERT OR IGNORE INTO rubrics (hash, rubric_id, content) VALUES (?, ?, ?)`
+	if _, err := d.db.Exec(query, rubric.Hash, rubric.ID, rubric.Content); err != nil {
+		return "", "", err
+	}
+	return rubric.ID, rubric.Hash, nil
 }
 
 // GetDebateResult retrieves the debate result
 func (d *Database) GetDebateResult(debateID string) (*DebateResult, error) {
-	query := `SELECT winner, supporting_score, opposing_score, summary_format, summary_content
-	          FROM debate_results WHERE debate_id = ?`
+	query := `SELECT r.winner, r.supporting_score, r.opposing_score, r.summary_format, r.summary_content, r.summary_encoding,
+	              r.tiebreak_round, r.tiebreak_reason, r.initial_winner, r.initial_supporting_score, r.initial_opposing_score,
+	              r.judge_model, r.rubric_id, r.rubric_hash, COALESCE(ru.content, ''), r.breakdown, r.judge_ms,
+	              r.authoritative_version, COALESCE(v.source, ''), COALESCE(v.produced_by, '')
+	          FROM debate_results r LEFT JOIN rubrics ru ON ru.hash = r.rubric_hash
+	          LEFT JOIN result_versions v ON v.debate_id = r.debate_id AND v.version = r.authoritative_version
+	          WHERE r.debate_id = ?`
 
 	result := &DebateResult{}
-	var format, content string
+	tiebreak := &TiebreakInfo{}
+	rubric := &RubricSnapshot{}
+	var format, encoding, breakdown string
+	var stored []byte
 	err := d.db.QueryRow(query, debateID).Scan(
-		&result.Winner, &result.SupportingScore, &result.OpposingScore, &format, &content)
+		&result.Winner, &result.SupportingScore, &result.OpposingScore, &format, &stored, &encoding,
+		&tiebreak.Round, &tiebreak.Reason, &tiebreak.InitialWinner, &tiebreak.InitialSupportingScore, &tiebreak.InitialOpposingScore,
+		&result.JudgeModel, &rubric.ID, &rubric.Hash, &rubric.Content, &breakdown, &result.JudgeMs,
+		&result.Version, &result.Source, &result.ProducedBy)
 
+	if err != nil {
+		return nil, err
+	}
+	if rubric.Hash != "" {
+		result.Rubric = rubric
+	}
+	result.Breakdown = decodeBreakdown(breakdown)
+	content, err := decodeBody(stored, encoding)
 	if err != nil {
 		return nil, err
 	}
 	result.Summary = SpeechMessage{Format: format, Content: content}
+	if tiebreak.Round > 0 {
+		result.Tiebreak = tiebreak
+	}
+	if rounds, err := d.GetRoundResults(debateID); err == nil && len(rounds) > 0 {
+		result.RoundResults = rounds
+	}
+	if scores, err := d.GetRoundScores(debateID); err == nil && len(scores) > 0 {
+		result.RoundScores = scores
+	}
+	if citations, err := d.GetCitations(debateID); err == nil && len(citations) > 0 {
+		result.Citations = citations
+	}
+	if panel, err := d.GetJudgePanel(debateID); err == nil && len(panel) > 0 {
+		result.Panel = panel
+	}
+	if runs, err := d.GetJudgeRuns(debateID); err == nil && len(runs) > 0 {
+		result.Runs = runs
+	}
 	return result, nil
 }
 
-// GetAvailableDebate finds a waiting debate with less than 2 bots
-func (d *Database) GetAvailableDebate() (*Debate, error) {
+// SaveCitations replaces the verdict citations of a debate
+func (d *Database) SaveCitations(debateID string, citations []VerdictCitation) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM verdict_citations WHERE debate_id = ?`, debateID); err != nil {
+		return err
+	}
+	for i, c := range citations {
+		_, err := tx.Exec(`INSERT INTO verdict_citations (debate_id, position, point, round, side, quote, located)
+		                   VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			debateID, i, c.Point, c.Round, c.Side, c.Quote, c.Located)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetCitations retrieves the verdict citations of a debate in verdict order
+func (d *Database) GetCitations(debateID string) ([]VerdictCitation, error) {
+	query := `SELECT point, round, side, quote, located
+	          FROM verdict_citations WHERE debate_id = ? ORDER BY position ASC`
+
+	rows, err := d.db.Query(query, debateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	citations := []VerdictCitation{}
+	for rows.Next() {
+		var c VerdictCitation
+		if err := rows.Scan(&c.Point, &c.Round, &c.Side, &c.Quote, &c.Located); err != nil {
+			return nil, err
+		}
+		citations = append(citations, c)
+	}
+	return citations, rows.Err()
+}
+
+// AddRoundResult stores the judgement of one round
+func (d *Database) AddRoundResult(debateID string, r *RoundResult) error {
+	query := `INSERT OR REPLACE INTO round_results (debate_id, round, winner, supporting_score, opposing_score, comment)
+	          VALUES (?, ?, ?, ?, ?, ?)`
+	_, err := d.db.Exec(query, debateID, r.Round, r.Winner, r.SupportingScore, r.OpposingScore, r.Comment)
+	return err
+}
+
+// GetRoundResults retrieves the per-round judgements of a debate in round order
+func (d *Database) GetRoundResults(debateID string) ([]RoundResult, error) {
+	query := `SELECT round, winner, supporting_score, opposing_score, comment
+	          FROM round_results WHERE debate_id = ? ORDER BY round ASC`
+
+	rows, err := d.db.Query(query, debateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []RoundResult{}
+	for rows.Next() {
+		var r RoundResult
+		if err := rows.Scan(&r.Round, &r.Winner, &r.SupportingScore, &r.OpposingScore, &r.Comment); err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+// ReplaceDebateResult overwrites the authoritative result of a debate
+func (d *Database) ReplaceDebateResult(debateID string, result *DebateResult) error {
+	rubricID, rubricHash, err := d.saveRubric(result.Rubric)
+	if err != nil {
+		return err
+	}
+	summary, encoding, err := d.encodeBody(result.Summary.Content)
+	if err != nil {
+		return err
+	}
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Take the replaced result out of the aggregates before overwriting it
+	if err := applyResultAggregates(tx, debateID, -1); err != nil {
+		return err
+	}
+	query := `INSERT INTO debate_results (debate_id, winner, supporting_score, opposing_score, summary_format, summary_content,
+	              summary_encoding, judge_model, rubric_id, rubric_hash, breakdown, judge_ms)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	          ON CONFLICT(debate_id) DO UPDATE SET winner = excluded.winner,
+	              supporting_score = excluded.supporting_score, opposing_score = excluded.opposing_score,
+	              summary_format = excluded.summary_format, summary_content = excluded.summary_content,
+	              summary_encoding = excluded.summary_encoding, judge_model = excluded.judge_model, rubric_id = excluded.rubric_id, rubric_hash = excluded.rubric_hash,
+	              breakdown = excluded.breakdown, judge_ms = excluded.judge_ms, created_at = CURRENT_TIMESTAMP`
+	_, err = tx.Exec(query, debateID, result.Winner, result.SupportingScore, result.OpposingScore,
+		result.Summary.Format, summary, encoding, result.JudgeModel, rubricID, rubricHash, encodeBreakdown(result.Breakdown), result.JudgeMs)
+	if err != nil {
+		return err
+	}
+	if err := addResultVersion(tx, debateID, result, summary, encoding, rubricID, rubricHash); err != nil {
+		return err
+	}
+	if err := applyResultAggregates(tx, debateID, 1); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetAvailableDebates lists waiting ranked debates with less than 2 bots, oldest first.
+// Unranked (sandbox) debates are only joined by explicit debate_id.
+func (d *Database) GetAvailableDebates() ([]*Debate, error) {
 	query := `
-		SELECT d.id, d.topic, d.total_rounds, d.current_round, d.status, d.created_at, d.updated_at
+		SELECT ` + debateColumns + `
 		FROM debates d
 		LEFT JOIN (
 			SELECT debate_id, COUNT(*) as bot_count
 			FROM bots
 			GROUP BY debate_id
 		) b ON d.id = b.debate_id
-		WHERE d.status = 'waiting' AND (b.bot_count IS NULL OR b.bot_count < 2)
-		ORDER BY d.created_at ASC
-		LIMIT 1`
+		WHERE d.status = 'waiting' AND d.ranked = 1 AND d.tournament_id = '' AND d.league_id = '' AND (b.bot_count IS NULL OR b.bot_count <
+			CASE d.seats WHEN '' THEN 2 ELSE LENGTH(d.seats) - LENGTH(REPLACE(d.seats, ',', '')) + 1 END)
+		ORDER BY d.created_at ASC`
 
-	debate := &Debate{}
-	err := d.db.QueryRow(query).Scan(
-		&debate.ID, &debate.Topic, &debate.TotalRounds, &debate.CurrentRound,
-		&debate.Status, &debate.CreatedAt, &debate.UpdatedAt)
+	rows, err := d.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	if err == sql.ErrNoRows {
-		return nil, nil // No available debate
+	debates := []*Debate{}
+	for rows.Next() {
+		debate, err := scanDebate(rows)
+		if err != nil {
+			return nil, err
+		}
+		debates = append(debates, debate)
 	}
+	return debates, rows.Err()
+}
+
+// GetPairDebates returns the debates two bots have taken part in together, newest first
+func (d *Database) GetPairDebates(botUUIDA, botUUIDB string, limit int) ([]*Debate, error) {
+	query := `SELECT ` + debateColumns + ` FROM debates
+	          WHERE id IN (SELECT a.debate_id FROM bots a JOIN bots b ON b.debate_id = a.debate_id
+	                       WHERE a.bot_uuid = ? AND b.bot_uuid = ?)
+	          ORDER BY created_at DESC LIMIT ?`
+
+	rows, err := d.db.Query(query, botUUIDA, botUUIDB, limit)
 	if err != nil {
 		return nil, err
 	}
-	return debate, nil
+	defer rows.Close()
+
+	debates := []*Debate{}
+	for rows.Next() {
+		debate, err := scanDebate(rows)
+		if err != nil {
+			return nil, err
+		}
+		debates = append(debates, debate)
+	}
+	return debates, rows.Err()
 }
 
-// GetAllDebates retrieves all debates with optional status filter
-func (d *Database) GetAllDebates(status string) ([]*Debate, error) {
-	var query string
-	var rows *sql.Rows
-	var err error
+// GetAllDebates retrieves all debates with optional status filter.
+// Unranked debates are hidden from public listings unless includeUnranked is set.
+func (d *Database) GetAllDebates(status string, includeUnranked bool) ([]*Debate, error) {
+	query := `SELECT ` + debateColumns + ` FROM debates WHERE 1 = 1`
+	args := []interface{}{}
 
 	if status != "" {
-		query = `SELECT id, topic, total_rounds, current_round, status, created_at, updated_at
-		         FROM debates WHERE status = ? ORDER BY created_at DESC`
-		rows, err = d.db.Query(query, status)
-	} else {
-		query = `SELECT id, topic, total_rounds, current_round, status, created_at, updated_at
-		         FROM debates ORDER BY created_at DESC`
-		rows, err = d.db.Query(query)
+		query += ` AND status = ?`
+		args = append(args, status)
+	}
+	if !includeUnranked {
+		query += ` AND ranked = 1`
 	}
+	query += ` ORDER BY created_at DESC`
 
+	rows, err := d.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -291,9 +664,7 @@ func (d *Database) GetAllDebates(status string) ([]*Debate, error) {
 
 	var debates []*Debate
 	for rows.Next() {
-		debate := &Debate{}
-		err := rows.Scan(&debate.ID, &debate.Topic, &debate.TotalRounds, &debate.CurrentRound,
-			&debate.Status, &debate.CreatedAt, &debate.UpdatedAt)
+		debate, err := scanDebate(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -302,6 +673,264 @@ func (d *Database) GetAllDebates(status string) ([]*Debate, error) {
 	return debates, nil
 }
 
+// SetDebateOwner records which instance hosts a debate
+func (d *Database) SetDebateOwner(debateID, instanceID string) error {
+	query := `UPDATE debates SET owner_instance = ? WHERE id = ?`
+	_, err := d.db.Exec(query, instanceID, debateID)
+	return err
+}
+
+// GetDebateOwner returns the instance hosting a debate
+func (d *Database) GetDebateOwner(debateID string) (string, error) {
+	query := `SELECT COALESCE(owner_instance, '') FROM debates WHERE id = ?`
+	var owner string
+	err := d.db.QueryRow(query, debateID).Scan(&owner)
+	return owner, err
+}
+
+// TouchInstance records an instance heartbeat
+func (d *Database) TouchInstance(instanceID, publicURL string) error {
+	query := `INSERT INTO instances (instance_id, public_url, last_seen) VALUES (?, ?, ?)
+	          ON CONFLICT(instance_id) DO UPDATE SET public_url = excluded.public_url, last_seen = excluded.last_seen`
+	_, err := d.db.Exec(query, instanceID, publicURL, time.Now())
+	return err
+}
+
+// GetInstance retrieves an instance record
+func (d *Database) GetInstance(instanceID string) (*Instance, error) {
+	query := `SELECT instance_id, public_url, last_seen FROM instances WHERE instance_id = ?`
+
+	instance := &Instance{}
+	err := d.db.QueryRow(query, instanceID).Scan(&instance.InstanceID, &instance.PublicURL, &instance.LastSeen)
+	if err != nil {
+		return nil, err
+	}
+	return instance, nil
+}
+
+// CreatePersona stores a new house bot persona
+func (d *Database) CreatePersona(p *Persona) error {
+	query := `INSERT INTO personas (id, name, description, system_prompt, created_at, updated_at)
+	          VALUES (?, ?, ?, ?, ?, ?)`
+	_, err := d.db.Exec(query, p.ID, p.Name, p.Description, p.SystemPrompt, p.CreatedAt, p.UpdatedAt)
+	return err
+}
+
+// UpdatePersona updates an existing persona
+func (d *Database) UpdatePersona(p *Persona) error {
+	query := `UPDATE personas SET name = ?, description = ?, system_prompt = ?, updated_at = ? WHERE id = ?`
+	res, err := d.db.Exec(query, p.Name, p.Description, p.SystemPrompt, p.UpdatedAt, p.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeletePersona removes a persona
+func (d *Database) DeletePersona(id string) error {
+	res, err := d.db.Exec(`DELETE FROM personas WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetPersona retrieves a persona by ID
+func (d *Database) GetPersona(id string) (*Persona, error) {
+	query := `SELECT id, name, description, system_prompt, created_at, updated_at FROM personas WHERE id = ?`
+
+	p := &Persona{}
+	err := d.db.QueryRow(query, id).Scan(&p.ID, &p.Name, &p.Description, &p.SystemPrompt, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetPersonas lists all personas
+func (d *Database) GetPersonas() ([]*Persona, error) {
+	query := `SELECT id, name, description, system_prompt, created_at, updated_at FROM personas ORDER BY name ASC`
+
+	rows, err := d.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	personas := []*Persona{}
+	for rows.Next() {
+		p := &Persona{}
+		if err := rows.Scan(&p.ID, &p.Name, &p.Description, &p.SystemPrompt, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		personas = append(personas, p)
+	}
+	return personas, nil
+}
+
+// AddAlert stores an admin alert
+func (d *Database) AddAlert(alert *Alert) error {
+	query := `INSERT INTO admin_alerts (kind, message, created_at) VALUES (?, ?, ?)`
+	_, err := d.db.Exec(query, alert.Kind, alert.Message, alert.CreatedAt)
+	return err
+}
+
+// GetAlerts returns the most recent admin alerts
+func (d *Database) GetAlerts(limit int) ([]*Alert, error) {
+	query := `SELECT id, kind, message, created_at FROM admin_alerts ORDER BY id DESC LIMIT ?`
+
+	rows, err := d.db.Query(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	alerts := []*Alert{}
+	for rows.Next() {
+		alert := &Alert{}
+		if err := rows.Scan(&alert.ID, &alert.Kind, &alert.Message, &alert.CreatedAt); err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts, nil
+}
+
+// CreateRejudgeJob stores a new rejudge job run by an instance
+func (d *Database) CreateRejudgeJob(job *RejudgeJob, instanceID string) error {
+	query := `INSERT INTO rejudge_jobs (id, status, model, rubric, created_at, instance_id) VALUES (?, ?, ?, ?, ?, ?)`
+	_, err := d.db.Exec(query, job.ID, job.Status, job.Model, job.Rubric, job.CreatedAt, instanceID)
+	return err
+}
+
+// FailInterruptedRejudgeJobs marks the jobs an instance was running when it
+// stopped as failed, returning how many there were
+func (d *Database) FailInterruptedRejudgeJobs(instanceID string) (int64, error) {
+	res, err := d.db.Exec(`UPDATE rejudge_jobs SET status = 'failed', completed_at = ? WHERE status = 'running' AND instance_id IN (?, '')`,
+		time.Now(), instanceID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// CompleteRejudgeJob marks a rejudge job as completed
+func (d *Database) CompleteRejudgeJob(jobID string, completedAt time.Time) error {
+	query := `UPDATE rejudge_jobs SET status = 'completed', completed_at = ? WHERE id = ?`
+	_, err := d.db.Exec(query, completedAt, jobID)
+	return err
+}
+
+// SetRejudgeJobStatus updates a rejudge job's status
+func (d *Database) SetRejudgeJobStatus(jobID, status string) error {
+	_, err := d.db.Exec(`UPDATE rejudge_jobs SET status = ? WHERE id = ?`, status, jobID)
+	return err
+}
+
+// AddRejudgeItem stores the comparison for one debate
+func (d *Database) AddRejudgeItem(jobID string, item *RejudgeItem) error {
+	query := `INSERT INTO rejudge_items (job_id, debate_id, original_winner, original_supporting_score, original_opposing_score,
+	              new_winner, new_supporting_score, new_opposing_score, new_summary, error)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := d.db.Exec(query, jobID, item.DebateID, item.OriginalWinner, item.OriginalSupport, item.OriginalOppose,
+		item.NewWinner, item.NewSupport, item.NewOppose, item.NewSummary, item.Error)
+	return err
+}
+
+// MarkRejudgeItemApplied records that a rejudged result replaced the original
+func (d *Database) MarkRejudgeItemApplied(jobID, debateID string) error {
+	_, err := d.db.Exec(`UPDATE rejudge_items SET applied = 1 WHERE job_id = ? AND debate_id = ?`, jobID, debateID)
+	return err
+}
+
+// GetRejudgeJob retrieves a rejudge job with its items
+func (d *Database) GetRejudgeJob(jobID string) (*RejudgeJob, error) {
+	job := &RejudgeJob{}
+	var completedAt sql.NullTime
+	err := d.db.QueryRow(`SELECT id, status, model, rubric, created_at, completed_at FROM rejudge_jobs WHERE id = ?`, jobID).Scan(
+		&job.ID, &job.Status, &job.Model, &job.Rubric, &job.CreatedAt, &completedAt)
+	if err != nil {
+		return nil, err
+	}
+	if completedAt.Valid {
+		job.CompletedAt = &completedAt.Time
+	}
+
+	query := `SELECT debate_id, original_winner, original_supporting_score, original_opposing_score,
+	              new_winner, new_supporting_score, new_opposing_score, new_summary, error, applied
+	          FROM rejudge_items WHERE job_id = ? ORDER BY debate_id ASC`
+	rows, err := d.db.Query(query, jobID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	job.Items = []*RejudgeItem{}
+	for rows.Next() {
+		item := &RejudgeItem{}
+		err := rows.Scan(&item.DebateID, &item.OriginalWinner, &item.OriginalSupport, &item.OriginalOppose,
+			&item.NewWinner, &item.NewSupport, &item.NewOppose, &item.NewSummary, &item.Error, &item.Applied)
+		if err != nil {
+			return nil, err
+		}
+		if item.Error == "" {
+			item.WinnerChanged = item.NewWinner != item.OriginalWinner
+			item.SupportScoreDelta = item.NewSupport - item.OriginalSupport
+			item.OpposeScoreDelta = item.NewOppose - item.OriginalOppose
+			if item.WinnerChanged {
+				job.ChangedWinners++
+			}
+		}
+		job.Items = append(job.Items, item)
+	}
+	return job, nil
+}
+
+// GetLeaderboardStats aggregates the ranked records of every bot that has
+// debated since the given time; a zero time reads the all-time bot_stats
+func (d *Database) GetLeaderboardStats(since time.Time) ([]BotStats, error) {
+	query := `SELECT bot_uuid, bot_name, debates, wins, losses, draws, score_sum
+	          FROM bot_stats WHERE debates > 0`
+	args := []interface{}{}
+	if !since.IsZero() {
+		query = `SELECT b.bot_uuid, MAX(b.bot_name), COUNT(*),
+		              SUM(r.winner = b.side),
+		              SUM(r.winner IN ('supporting', 'opposing') AND r.winner != b.side),
+		              SUM(r.winner = 'draw'),
+		              SUM(CASE b.side WHEN 'supporting' THEN r.supporting_score ELSE r.opposing_score END)
+		          FROM debate_results r
+		          JOIN debates d ON d.id = r.debate_id
+		          JOIN bots b ON b.debate_id = r.debate_id
+		          WHERE d.ranked = 1 AND b.side IN ('supporting', 'opposing') AND r.created_at >= ?
+		          GROUP BY b.bot_uuid`
+		args = append(args, since.UTC().Format("2006-01-02 15:04:05"))
+	}
+
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := []BotStats{}
+	for rows.Next() {
+		var s BotStats
+		var scoreSum int
+		if err := rows.Scan(&s.BotUUID, &s.BotName, &s.Debates, &s.Wins, &s.Losses, &s.Draws, &scoreSum); err != nil {
+			return nil, err
+		}
+		s.AverageScore = float64(scoreSum) / float64(s.Debates)
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
+
 // Close closes the database connection
 func (d *Database) Close() error {
 	return d.db.Close()
@@ -312,3 +941,22 @@ func toJSON(v interface{}) string {
 	b, _ := json.Marshal(v)
 	return string(b)
 }
+
+// encodeTranslations stores a language -> text map as JSON ("" when empty)
+func encodeTranslations(translations map[string]string) string {
+	if len(translations) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(translations)
+	return string(data)
+}
+
+// decodeTranslations reads a map stored by encodeTranslations
+func decodeTranslations(data string) map[string]string {
+	if data == "" {
+		return nil
+	}
+	var translations map[string]string
+	json.Unmarshal([]byte(data), &translations)
+	return translations
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GET /api/debate/{id}/events is a Server-Sent Events stream of a debate, for
+// viewers behind proxies that break WebSockets. It delivers what a frontend
+// WebSocket subscription does: the debate_snapshot first, then every
+// broadcast (debate_waiting, debate_update, debate_end, ...) as an event
+// named after the message type whose data is the message. The query takes
+// the subscribe_debate options: token, events (comma separated) and lang.
+//
+// The stream ends after debate_end. A browser's EventSource reconnects on
+// its own, sending Last-Event-ID; reconnecting to a debate that is no longer
+// live answers 204, which stops it, so clients need no special handling. A
+// stream that falls eventStreamBuffer messages behind is closed and its
+// client reconnects to a fresh snapshot. SSE viewers count against
+// frontend.max_spectators, but a full debate refuses them with 503 rather
+// than queueing them. Replicas answer every request with the current
+// snapshot and a retry delay of replica_poll_interval, so the EventSource
+// polls.
+
+// errRoomFull refuses an SSE viewer of a debate at frontend.max_spectators;
+// unlike WebSocket spectators, SSE viewers are not queued
+var errRoomFull = errors.New("spectator limit reached")
+
+// eventStreamBuffer is how many messages a stream may fall behind before it is closed
+const eventStreamBuffer = 64
+
+// EventStream is one SSE viewer of a live debate
+type EventStream struct {
+	sub      *Subscriber
+	messages chan Message
+	done     chan struct{}
+	once     sync.Once
+}
+
+// newEventStream creates a stream delivering what a subscriber wants
+func newEventStream(sub *Subscriber) *EventStream {
+	return &EventStream{
+		sub:      sub,
+		messages: make(chan Message, eventStreamBuffer),
+		done:     make(chan struct{}),
+	}
+}
+
+// Send queues a message for the stream, closing the stream when its client
+// has fallen too far behind
+func (s *EventStream) Send(msg Message) {
+	select {
+	case s.messages <- msg:
+	default:
+		s.Close()
+	}
+}
+
+// Close ends the stream; its handler returns
+func (s *EventStream) Close() {
+	s.once.Do(func() { close(s.done) })
+}
+
+// AddEventStream subscribes an SSE viewer to a live debate. It fails like
+// AddFrontendConnection, and with errRoomFull when frontend.max_spectators
+// are already watching.
+func (dm *DebateManager) AddEventStream(debateID, token string, stream *EventStream) error {
+	dm.mutex.Lock()
+	defer dm.mutex.Unlock()
+
+	activeDebate, exists := dm.debates[debateID]
+	if !exists {
+		return errDebateNotFound
+	}
+	if err := checkSpectatorAccess(activeDebate.Debate, token); err != nil {
+		return err
+	}
+
+	activeDebate.mutex.Lock()
+	if limit := config.Frontend.MaxSpectators; limit > 0 && activeDebate.spectatorTotal() >= limit {
+		activeDebate.mutex.Unlock()
+		return errRoomFull
+	}
+	if activeDebate.EventStreams == nil {
+		activeDebate.EventStreams = make(map[*EventStream]bool)
+	}
+	activeDebate.EventStreams[stream] = true
+	dm.announceSpectators(activeDebate)
+	activeDebate.mutex.Unlock()
+
+	session, err := dm.db.StartSpectatorSession(debateID, cluster.InstanceID)
+	if err != nil {
+		log.Printf("Failed to record spectator session for debate %s: %v", debateID, err)
+	}
+	stream.sub.session = session
+	return nil
+}
+
+// RemoveEventStream unsubscribes an SSE viewer
+func (dm *DebateManager) RemoveEventStream(debateID string, stream *EventStream) {
+	dm.mutex.RLock()
+	activeDebate, exists := dm.debates[debateID]
+	dm.mutex.RUnlock()
+	if exists {
+		activeDebate.mutex.Lock()
+		if activeDebate.EventStreams[stream] {
+			delete(activeDebate.EventStreams, stream)
+			dm.announceSpectators(activeDebate)
+		}
+		activeDebate.mutex.Unlock()
+	}
+	dm.endSpectatorSession(stream.sub)
+}
+
+// isLive reports whether a debate is held in memory by this instance
+func (dm *DebateManager) isLive(debateID string) bool {
+	dm.mutex.RLock()
+	defer dm.mutex.RUnlock()
+	_, exists := dm.debates[debateID]
+	return exists
+}
+
+// handleDebateEvents handles GET /api/debate/{id}/events
+func handleDebateEvents(w http.ResponseWriter, r *http.Request, debateID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	var events []string
+	if value := query.Get("events"); value != "" {
+		events = strings.Split(value, ",")
+	}
+	filter, err := newEventFilter(events)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	token := query.Get("token")
+	language := query.Get("lang")
+	stream := newEventStream(&Subscriber{Filter: filter, Language: language})
+
+	err = errDebateNotFound
+	if !isReplica() {
+		err = debateManager.AddEventStream(debateID, token, stream)
+	}
+	switch err {
+	case nil:
+		defer debateManager.RemoveEventStream(debateID, stream)
+	case errDebateNotFound:
+		// Not live here: the snapshot is all there is to send
+	case errRoomFull:
+		http.Error(w, "This debate has reached its spectator limit", http.StatusServiceUnavailable)
+		return
+	default:
+		// Don't reveal that a private debate exists
+		http.Error(w, "Debate not found", http.StatusNotFound)
+		return
+	}
+
+	debate, snapshot := currentDebateState(debateID)
+	if debate == nil || checkSpectatorAccess(debate, token) != nil {
+		http.Error(w, "Debate not found", http.StatusNotFound)
+		return
+	}
+	// Ended debates stay in memory for a while; their stream is only the snapshot
+	live := err == nil && !isFinished(debate.Status)
+	if !live && r.Header.Get("Last-Event-ID") != "" && (isFinished(debate.Status) || !isReplica()) {
+		// A reconnect after the debate ended; 204 stops the EventSource
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// The stream outlives the server's write timeout
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if !live && isReplica() && !isFinished(debate.Status) {
+		fmt.Fprintf(w, "retry: %d\n\n", config.Cluster.ReplicaPollInterval*1000)
+	}
+	if err := writeEvent(w, localizeMessage(debate, *snapshot, language)); err != nil || rc.Flush() != nil || !live {
+		return
+	}
+	sendChatHistoryEvent(w, debateID, stream.sub)
+	rc.Flush()
+
+	ping := time.NewTicker(time.Duration(config.Frontend.PingInterval) * time.Second)
+	defer ping.Stop()
+	for {
+		select {
+		case msg := <-stream.messages:
+			if err := writeEvent(w, msg); err != nil || rc.Flush() != nil || msg.Type == "debate_end" {
+				return
+			}
+		case <-ping.C:
+			// Debates dropped without a debate_end, such as waiting timeouts, end the stream too
+			if !debateManager.isLive(debateID) {
+				return
+			}
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil || rc.Flush() != nil {
+				return
+			}
+		case <-stream.done:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// sendChatHistoryEvent sends an SSE viewer the debate's latest chat, like
+// sendChatHistory does for WebSocket spectators
+func sendChatHistoryEvent(w http.ResponseWriter, debateID string, sub *Subscriber) {
+	if !config.Frontend.Chat.Enabled || !sub.Filter.Allows("chat_history") {
+		return
+	}
+	messages, err := db.GetChatMessages(debateID, config.Frontend.Chat.History)
+	if err != nil {
+		log.Printf("Failed to load chat of debate %s: %v", debateID, err)
+		return
+	}
+	writeEvent(w, createMessage("chat_history", ChatHistory{DebateID: debateID, Messages: messages}))
+}
+
+// writeEvent writes a message as one SSE event, redacted like every public
+// view of a debate
+func writeEvent(w http.ResponseWriter, msg Message) error {
+	data, err := json.Marshal(redactor.Message(msg))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", msg.ID, msg.Type, data)
+	return err
+}
@@ -6,36 +6,61 @@ import (
 	"fmt"
 	"log"
 	"math/big"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/gorilla/websocket"
 )
 
 // DebateManager manages active debates and bot connections
 type DebateManager struct {
-	debates   map[string]*ActiveDebate
-	mutex     sync.RWMutex
-	db        *Database
-	broadcast chan BroadcastMessage
+	debates    map[string]*ActiveDebate
+	mutex      sync.RWMutex
+	db         *Database
+	broadcast  chan BroadcastMessage
+	judgeQueue *JudgeQueue
+	replayer   *ReplayScheduler // Timed replays of finished debates, see replay_scheduler.go
 }
 
 // ActiveDebate represents a debate in progress
 type ActiveDebate struct {
 	Debate              *Debate
-	BotA                *ConnectedBot
-	BotB                *ConnectedBot
-	SupportingBot       *ConnectedBot
+	Bots                []*ConnectedBot // Joined bots; in seat (speaking) order once the debate starts
+	SupportingBot       *ConnectedBot   // First seated bot of each side
 	OpposingBot         *ConnectedBot
 	DebateLog           []DebateLogEntry
-	FrontendConns       map[*websocket.Conn]bool
+	FrontendConns       map[*WSConn]*Subscriber
+	EventStreams        map[*EventStream]bool     // SSE spectators, see debate_events.go
+	events              *EventLog                 // Numbered broadcasts for polling clients, see state_poll.go
 	LastSpeaker         string
 	WaitingTimer        *time.Timer // Timer for waiting state timeout
 	TimeoutTimer        *time.Timer
 	InactivityTimer     *time.Timer
 	MaxDurationTimer    *time.Timer
+	PendingSpeeches     map[string]DebateLogEntry // Simultaneous mode: buffered speeches of the current round
+	Openings            map[string]DebateLogEntry // Blind opening mode: statements submitted while waiting
+	OpeningsClosed      bool                      // Blind opening mode: no more openings accepted
+	TurnDeadline        time.Time                 // When the current speech timeout expires
+	TurnTimeLeft        time.Duration             // Restored debates: speech time left for the due speaker on resume
+	Tiebreak            *TiebreakInfo             // Set once the debate has gone to a tiebreak round
+	ending              bool                      // endDebate is judging or has ended the debate
+	RoundResults        []RoundResult             // Round scoring mode: judged rounds so far
+	roundJudging        sync.WaitGroup            // Round scoring mode: round judgements in flight
+	Paused              bool                      // Clocks stopped, speeches refused until resumed
+	PauseReason         string                    // Why the debate is paused
+	PausedAt            time.Time                 // When the current pause began
+	Held                bool                      // Paused through the moderation API; only a resume call continues it
+	Disconnected        map[string]bool           // Bots that dropped and may still reconnect
+	reconnectDeadlines  map[string]int            // Per bot, bumped on every drop and reconnect so only the latest deadline ends the debate
+	resuming            sync.Mutex                // Serializes resuming after reconnects and moderator holds
+	Violations          map[string]int            // Consecutive rejected speeches per bot
+	Signals             []RelayedSignal           // Side channel messages relayed so far
+	Summaries           map[string]string         // Opponent summary mode: speech summaries by round/speaker
+	judging             *JudgingTracker           // Stage timings of the AI judgement, once it has started
+	spectatorCount      *time.Timer               // Pending spectator_count broadcast, see spectator_count.go
+	countdownQuit       chan struct{}
 	StartTime           time.Time
 	LastActivityTime    time.Time
 	mutex               sync.RWMutex
@@ -44,7 +69,7 @@ type ActiveDebate struct {
 // ConnectedBot represents a connected bot
 type ConnectedBot struct {
 	Bot              *Bot
-	Conn             *websocket.Conn
+	Conn             *WSConn
 	LastPongTime     time.Time
 	MissedPings      int
 	PingTicker       *time.Ticker
@@ -64,6 +89,8 @@ func NewDebateManager(db *Database) *DebateManager {
 		db:        db,
 		broadcast: make(chan BroadcastMessage, 100),
 	}
+	dm.judgeQueue = NewJudgeQueue(config.ChatGPT.Judge.Concurrency, dm.announceJudging)
+	dm.replayer = NewReplayScheduler()
 	go dm.handleBroadcasts()
 	return dm
 }
@@ -78,39 +105,75 @@ func (dm *DebateManager) handleBroadcasts() {
 		if !exists {
 			continue
 		}
+		debate.events.Append(msg.Message)
 
 		debate.mutex.RLock()
-		for conn := range debate.FrontendConns {
-			err := conn.WriteJSON(msg.Message)
+		for conn, sub := range debate.FrontendConns {
+			if !sub.Wants(msg.Message) {
+				continue
+			}
+			err := conn.WriteJSON(localizeMessage(debate.Debate, msg.Message, sub.Language))
 			if err != nil {
 				log.Printf("Error broadcasting to frontend: %v", err)
 			}
 		}
+		for stream := range debate.EventStreams {
+			if stream.sub.Wants(msg.Message) {
+				stream.Send(localizeMessage(debate.Debate, msg.Message, stream.sub.Language))
+			}
+		}
 		debate.mutex.RUnlock()
 	}
 }
 
 // CreateDebate creates a new debate
-func (dm *DebateManager) CreateDebate(topic string, totalRounds int) (*Debate, error) {
+func (dm *DebateManager) CreateDebate(topic string, totalRounds int, opts DebateOptions) (*Debate, error) {
 	debate := &Debate{
-		ID:           "debate-" + uuid.New().String(),
-		Topic:        topic,
-		TotalRounds:  totalRounds,
-		CurrentRound: 1,
-		Status:       "waiting",
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
+		ID:                "debate-" + uuid.New().String(),
+		ShortID:           newShortID(),
+		Topic:             topic,
+		TotalRounds:       totalRounds,
+		CurrentRound:      1,
+		Status:            "waiting",
+		Ranked:            opts.Ranked,
+		PersonaID:         opts.PersonaID,
+		Format:            opts.Format,
+		BlindOpening:      opts.BlindOpening,
+		Scoring:           opts.Scoring,
+		Private:           opts.Private,
+		Languages:         opts.Languages,
+		TopicTranslations: opts.TopicTranslations,
+		Category:          opts.Category,
+		Seats:             opts.Seats,
+		TournamentID:      opts.TournamentID,
+		LeagueID:          opts.LeagueID,
+		SideChannel:       opts.SideChannel,
+		Verdict:           opts.Verdict,
+		NoAIJudge:         opts.NoAIJudge,
+		DiscloseRubric:    opts.DiscloseRubric,
+		OpponentSummary:   opts.OpponentSummary,
+		Limits:            opts.Limits,
+		Sandbox:           opts.Sandbox,
+		CreatedAt:         time.Now(),
+		UpdatedAt:         time.Now(),
+	}
+
+	if debate.Private {
+		debate.SpectatorToken = generateSpectatorToken()
 	}
 
 	if err := dm.db.CreateDebate(debate); err != nil {
 		return nil, err
 	}
+	cluster.ClaimDebate(debate.ID)
+	webhooks.Send(WebhookDebateCreated, debate.ID, debate)
 
 	dm.mutex.Lock()
 	dm.debates[debate.ID] = &ActiveDebate{
 		Debate:        debate,
 		DebateLog:     make([]DebateLogEntry, 0),
-		FrontendConns: make(map[*websocket.Conn]bool),
+		FrontendConns: make(map[*WSConn]*Subscriber),
+		events:        newEventLog(),
 	}
 	dm.mutex.Unlock()
 
@@ -121,13 +184,54 @@ func (dm *DebateManager) CreateDebate(topic string, totalRounds int) (*Debate, e
 }
 
 // BotLogin handles bot login
-func (dm *DebateManager) BotLogin(loginReq *LoginRequest, conn *websocket.Conn) (*LoginConfirmed, *LoginRejected) {
+func (dm *DebateManager) BotLogin(loginReq *LoginRequest, conn *WSConn) (*LoginConfirmed, *LoginRejected) {
 	dm.mutex.Lock()
 	defer dm.mutex.Unlock()
 
+	// Reject clients older than the configured minimum protocol version
+	if config.Debate.MinClientVersion != "" && compareVersions(loginReq.Version, config.Debate.MinClientVersion) < 0 {
+		return nil, &LoginRejected{
+			Status:   "rejected",
+			Reason:   "upgrade_required",
+			Message:  fmt.Sprintf("Client version %q is too old, minimum supported version is %s", loginReq.Version, config.Debate.MinClientVersion),
+			DebateID: loginReq.DebateID,
+		}
+	}
+
+	// Registered bots prove their identity with their token
+	if rejected := checkBotToken(loginReq); rejected != nil {
+		return nil, rejected
+	}
+
+	// A draining server only lets bots back into debates that are already running
+	if shuttingDown.Load() {
+		if activeDebate, exists := dm.debates[loginReq.DebateID]; !exists || !isInProgress(activeDebate.Debate.Status) {
+			return nil, shutdownRejection(loginReq.DebateID)
+		}
+	}
+
+	// A bot with a waiting tournament or league match plays it first
+	if loginReq.DebateID == "" {
+		loginReq.DebateID = reservedDebateFor(loginReq.BotUUID)
+	}
+
+	// Auto-assigned and lobby debates are ranked
+	if loginReq.DebateID == "" {
+		if rejected := checkConformance(loginReq); rejected != nil {
+			return nil, rejected
+		}
+	}
+
 	// If no debate_id provided, auto-assign an available debate
+	if loginReq.DebateID == "" && config.Debate.Pairing.Policy == AutoAssignOff {
+		return nil, &LoginRejected{
+			Status:  "rejected",
+			Reason:  "debate_id_required",
+			Message: "This server does not auto-assign debates. Please specify a debate_id.",
+		}
+	}
 	if loginReq.DebateID == "" {
-		availableDebate, err := dm.db.GetAvailableDebate()
+		availableDebate, coolingDown, err := dm.chooseAutoMatch(loginReq.BotUUID, loginReq.Tags)
 		if err != nil {
 			log.Printf("Error finding available debate: %v", err)
 			return nil, &LoginRejected{
@@ -136,6 +240,13 @@ func (dm *DebateManager) BotLogin(loginReq *LoginRequest, conn *websocket.Conn)
 				Message: "No available debates found. Please create a debate first or specify a debate_id.",
 			}
 		}
+		if coolingDown {
+			return nil, &LoginRejected{
+				Status:  "rejected",
+				Reason:  "no_available_debate",
+				Message: "Only debates against recent opponents are waiting. Try again later or specify a debate_id.",
+			}
+		}
 		if availableDebate == nil {
 			return nil, &LoginRejected{
 				Status:  "rejected",
@@ -148,6 +259,18 @@ func (dm *DebateManager) BotLogin(loginReq *LoginRequest, conn *websocket.Conn)
 	}
 
 	activeDebate, exists := dm.debates[loginReq.DebateID]
+	if !exists {
+		// A debate snapshotted by another instance continues here
+		if restored := dm.restoreDebate(loginReq.DebateID); restored != nil {
+			activeDebate, exists = restored, true
+		}
+	}
+	if exists && isInProgress(activeDebate.Debate.Status) {
+		// A bot that dropped out of the running debate takes its seat back
+		if confirmed, rejected := dm.reconnectBot(activeDebate, loginReq, conn); confirmed != nil || rejected != nil {
+			return confirmed, rejected
+		}
+	}
 	if !exists {
 		// Try to load from database
 		debate, err := dm.db.GetDebate(loginReq.DebateID)
@@ -173,23 +296,42 @@ func (dm *DebateManager) BotLogin(loginReq *LoginRequest, conn *websocket.Conn)
 		activeDebate = &ActiveDebate{
 			Debate:        debate,
 			DebateLog:     make([]DebateLogEntry, 0),
-			FrontendConns: make(map[*websocket.Conn]bool),
+			FrontendConns: make(map[*WSConn]*Subscriber),
+			events:        newEventLog(),
 		}
 		dm.debates[loginReq.DebateID] = activeDebate
+		cluster.ClaimDebate(loginReq.DebateID)
+	}
+
+	// Tournament and league matches are reserved for their two bots
+	if botA, botB, reserved := reservedBots(activeDebate.Debate); reserved && botA != loginReq.BotUUID && botB != loginReq.BotUUID {
+		return nil, &LoginRejected{
+			Status:   "rejected",
+			Reason:   "not_entrant",
+			Message:  "This debate is a match between other bots",
+			DebateID: loginReq.DebateID,
+		}
+	}
+
+	if activeDebate.Debate.Ranked {
+		if rejected := checkConformance(loginReq); rejected != nil {
+			return nil, rejected
+		}
 	}
 
 	// Check if debate is full
-	if activeDebate.BotA != nil && activeDebate.BotB != nil {
+	seats := len(activeDebate.Debate.seatSides())
+	if len(activeDebate.Bots) >= seats {
 		return nil, &LoginRejected{
 			Status:   "rejected",
 			Reason:   "debate_full",
-			Message:  "Debate already has two bots",
+			Message:  fmt.Sprintf("Debate already has %d bots", seats),
 			DebateID: loginReq.DebateID,
 		}
 	}
 
 	// Generate bot identifier and debate key
-	botIdentifier := fmt.Sprintf("%s-%s", loginReq.BotName, loginReq.BotUUID[:8])
+	botIdentifier := botIdentifier(loginReq.BotName, loginReq.BotUUID)
 	debateKey := generateDebateKey()
 
 	bot := &Bot{
@@ -198,6 +340,7 @@ func (dm *DebateManager) BotLogin(loginReq *LoginRequest, conn *websocket.Conn)
 		BotIdentifier: botIdentifier,
 		DebateID:      loginReq.DebateID,
 		DebateKey:     debateKey,
+		ClientVersion: loginReq.Version,
 		ConnectedAt:   time.Now(),
 	}
 
@@ -217,21 +360,15 @@ func (dm *DebateManager) BotLogin(loginReq *LoginRequest, conn *websocket.Conn)
 		Conn: conn,
 	}
 
-	// Assign bot slot
-	if activeDebate.BotA == nil {
-		activeDebate.BotA = connectedBot
-	} else {
-		activeDebate.BotB = connectedBot
+	activeDebate.Bots = append(activeDebate.Bots, connectedBot)
+
+	// Blind openings need the side before the debate starts
+	if activeDebate.Debate.BlindOpening {
+		dm.assignBlindSide(activeDebate, connectedBot)
 	}
 
 	// Build list of already joined bots (excluding the current bot)
-	joinedBots := []string{}
-	if activeDebate.BotA != nil && activeDebate.BotA.Bot.BotIdentifier != botIdentifier {
-		joinedBots = append(joinedBots, activeDebate.BotA.Bot.BotIdentifier)
-	}
-	if activeDebate.BotB != nil && activeDebate.BotB.Bot.BotIdentifier != botIdentifier {
-		joinedBots = append(joinedBots, activeDebate.BotB.Bot.BotIdentifier)
-	}
+	joinedBots := activeDebate.joinedIdentifiers(botIdentifier)
 
 	confirmed := &LoginConfirmed{
 		Status:        "confirmed",
@@ -241,16 +378,25 @@ func (dm *DebateManager) BotLogin(loginReq *LoginRequest, conn *websocket.Conn)
 		BotIdentifier: botIdentifier,
 		Topic:         activeDebate.Debate.Topic,
 		JoinedBots:    joinedBots,
+		Languages:     activeDebate.Debate.Languages,
+		SideChannel:   activeDebate.Debate.SideChannel,
+		Sandbox:       activeDebate.Debate.Sandbox,
 	}
 
-	// Broadcast waiting status to frontend
-	allJoinedBots := []string{}
-	if activeDebate.BotA != nil {
-		allJoinedBots = append(allJoinedBots, activeDebate.BotA.Bot.BotIdentifier)
-	}
-	if activeDebate.BotB != nil {
-		allJoinedBots = append(allJoinedBots, activeDebate.BotB.Bot.BotIdentifier)
+	if activeDebate.Debate.BlindOpening {
+		// Keep the opponent anonymous until the openings are revealed
+		confirmed.Message = "Submit your opening statement while waiting for the other bot"
+		confirmed.JoinedBots = []string{}
+		confirmed.YourSide = bot.Side
+		confirmed.TotalRounds = activeDebate.Debate.TotalRounds
+		confirmed.BlindOpening = true
+		limits := formatLimitsFor(activeDebate.Debate)
+		confirmed.MinContentLength = limits.MinContentLength
+		confirmed.MaxContentLength = limits.MaxContentLength
 	}
+
+	// Broadcast waiting status to frontend
+	allJoinedBots := activeDebate.joinedIdentifiers("")
 	dm.broadcast <- BroadcastMessage{
 		DebateID: loginReq.DebateID,
 		Message: createMessage("debate_waiting", DebateWaiting{
@@ -262,9 +408,13 @@ func (dm *DebateManager) BotLogin(loginReq *LoginRequest, conn *websocket.Conn)
 		}),
 	}
 
-	// If both bots are connected, start debate
-	if activeDebate.BotA != nil && activeDebate.BotB != nil {
-		go dm.startDebate(loginReq.DebateID)
+	// Once every seat is taken, start debate (blind openings start once both statements are in)
+	if len(activeDebate.Bots) == seats {
+		if activeDebate.Debate.BlindOpening {
+			dm.startOpeningDeadline(activeDebate)
+		} else {
+			go dm.startDebate(loginReq.DebateID)
+		}
 	}
 
 	return confirmed, nil
@@ -288,65 +438,75 @@ func (dm *DebateManager) startDebate(debateID string) {
 		activeDebate.WaitingTimer = nil
 	}
 
-	// Randomly assign sides
-	if randomBool() {
-		activeDebate.SupportingBot = activeDebate.BotA
-		activeDebate.OpposingBot = activeDebate.BotB
-	} else {
-		activeDebate.SupportingBot = activeDebate.BotB
-		activeDebate.OpposingBot = activeDebate.BotA
-	}
-
-	// Update sides in database
-	dm.db.UpdateBotSide(debateID, activeDebate.SupportingBot.Bot.BotIdentifier, "supporting")
-	dm.db.UpdateBotSide(debateID, activeDebate.OpposingBot.Bot.BotIdentifier, "opposing")
-
-	activeDebate.SupportingBot.Bot.Side = "supporting"
-	activeDebate.OpposingBot.Bot.Side = "opposing"
+	// Randomly assign seats and sides (blind-opening bots already got their side at login)
+	dm.assignSeats(activeDebate)
 
 	// Update debate status
 	dm.db.UpdateDebateStatus(debateID, "active")
 	activeDebate.Debate.Status = "active"
 
-	// Send debate start to both bots
-	startMsgA := createMessage("debate_start", DebateStart{
-		DebateID:         debateID,
-		Topic:            activeDebate.Debate.Topic,
-		SupportingSide:   activeDebate.SupportingBot.Bot.BotIdentifier,
-		OpposingSide:     activeDebate.OpposingBot.Bot.BotIdentifier,
-		TotalRounds:      activeDebate.Debate.TotalRounds,
-		CurrentRound:     1,
-		YourSide:         activeDebate.SupportingBot.Bot.Side,
-		YourIdentifier:   activeDebate.SupportingBot.Bot.BotIdentifier,
-		NextSpeaker:      activeDebate.SupportingBot.Bot.BotIdentifier,
-		TimeoutSeconds:   120,
-		MinContentLength: config.Debate.MinContentLength,
-		MaxContentLength: config.Debate.MaxContentLength,
-	})
+	// Reveal blind openings as round 1
+	if activeDebate.Debate.BlindOpening {
+		if activeDebate.TimeoutTimer != nil {
+			activeDebate.TimeoutTimer.Stop()
+		}
+		dm.revealOpenings(activeDebate)
+		if activeDebate.Debate.CurrentRound > activeDebate.Debate.TotalRounds {
+			dm.closeDebate(activeDebate)
+			return
+		}
+	}
 
-	startMsgB := createMessage("debate_start", DebateStart{
-		DebateID:         debateID,
-		Topic:            activeDebate.Debate.Topic,
-		SupportingSide:   activeDebate.SupportingBot.Bot.BotIdentifier,
-		OpposingSide:     activeDebate.OpposingBot.Bot.BotIdentifier,
-		TotalRounds:      activeDebate.Debate.TotalRounds,
-		CurrentRound:     1,
-		YourSide:         activeDebate.OpposingBot.Bot.Side,
-		YourIdentifier:   activeDebate.OpposingBot.Bot.BotIdentifier,
-		NextSpeaker:      activeDebate.SupportingBot.Bot.BotIdentifier,
-		TimeoutSeconds:   120,
-		MinContentLength: config.Debate.MinContentLength,
-		MaxContentLength: config.Debate.MaxContentLength,
-	})
+	// In simultaneous mode both bots are due to speak from the start
+	firstSpeaker := activeDebate.Bots[0].Bot.BotIdentifier
+	if activeDebate.Debate.Format == FormatSimultaneous {
+		activeDebate.PendingSpeeches = make(map[string]DebateLogEntry)
+	}
 
-	activeDebate.SupportingBot.Conn.WriteJSON(startMsgA)
-	activeDebate.OpposingBot.Conn.WriteJSON(startMsgB)
+	// Send debate start to every bot
+	rules := dm.rulesFor(activeDebate.Debate)
+	limits := formatLimitsFor(activeDebate.Debate)
+	var startMsgs []Message
+	for _, bot := range activeDebate.Bots {
+		nextSpeaker := firstSpeaker
+		if activeDebate.Debate.Format == FormatSimultaneous {
+			nextSpeaker = bot.Bot.BotIdentifier
+		}
+		startMsg := createMessage("debate_start", DebateStart{
+			DebateID:         debateID,
+			Topic:            activeDebate.Debate.Topic,
+			SupportingSide:   activeDebate.teamName("supporting"),
+			OpposingSide:     activeDebate.teamName("opposing"),
+			Participants:     activeDebate.participants(),
+			TotalRounds:      activeDebate.Debate.TotalRounds,
+			CurrentRound:     activeDebate.Debate.CurrentRound,
+			YourSide:         bot.Bot.Side,
+			YourIdentifier:   bot.Bot.BotIdentifier,
+			NextSpeaker:      nextSpeaker,
+			TimeoutSeconds:   limits.SpeechTimeout,
+			MinContentLength: limits.MinContentLength,
+			MaxContentLength: limits.MaxContentLength,
+			Format:           activeDebate.Debate.Format,
+			DebateLog:        activeDebate.DebateLog,
+			Rules:            rules,
+			Judging:          rules.Judge.Mode,
+			Rubric:           rubricDisclosure(activeDebate.Debate, rules),
+		})
+		chaos.WriteToBot(bot.Conn, startMsg)
+		startMsgs = append(startMsgs, startMsg)
+	}
 
 	// Broadcast to frontend
 	dm.broadcast <- BroadcastMessage{
 		DebateID: debateID,
-		Message:  startMsgA,
+		Message:  startMsgs[0],
 	}
+	webhooks.Send(WebhookDebateStart, debateID, WebhookDebateStartData{
+		Debate:         activeDebate.Debate,
+		SupportingSide: activeDebate.teamName("supporting"),
+		OpposingSide:   activeDebate.teamName("opposing"),
+		Participants:   activeDebate.participants(),
+	})
 
 	// Set timing
 	activeDebate.StartTime = time.Now()
@@ -354,16 +514,25 @@ func (dm *DebateManager) startDebate(debateID string) {
 	activeDebate.LastSpeaker = ""
 
 	// Start timers
-	dm.startTimeout(debateID, activeDebate.SupportingBot.Bot.BotIdentifier)
+	if activeDebate.Debate.Format == FormatSimultaneous {
+		dm.startRoundDeadline(debateID, activeDebate.Debate.CurrentRound)
+	} else {
+		dm.startTimeout(debateID, firstSpeaker)
+	}
 	dm.startInactivityTimer(debateID)
 	dm.startMaxDurationTimer(debateID)
 
 	log.Printf("Debate %s started: %s (supporting) vs %s (opposing)",
-		debateID, activeDebate.SupportingBot.Bot.BotIdentifier, activeDebate.OpposingBot.Bot.BotIdentifier)
+		debateID, activeDebate.teamName("supporting"), activeDebate.teamName("opposing"))
 }
 
 // HandleSpeech processes a bot's speech
-func (dm *DebateManager) HandleSpeech(speech *DebateSpeech, senderConn *websocket.Conn) *ErrorMessage {
+func (dm *DebateManager) HandleSpeech(speech *DebateSpeech, senderConn *WSConn, replyTo string) *ErrorMessage {
+	return dm.trackViolations(speech, dm.acceptSpeech(speech, senderConn, replyTo))
+}
+
+// acceptSpeech validates a speech and adds it to the debate
+func (dm *DebateManager) acceptSpeech(speech *DebateSpeech, senderConn *WSConn, replyTo string) *ErrorMessage {
 	dm.mutex.RLock()
 	activeDebate, exists := dm.debates[speech.DebateID]
 	dm.mutex.RUnlock()
@@ -378,14 +547,8 @@ func (dm *DebateManager) HandleSpeech(speech *DebateSpeech, senderConn *websocke
 	}
 
 	// Verify debate key
-	var speakerBot *ConnectedBot
-	if activeDebate.SupportingBot != nil && activeDebate.SupportingBot.Bot.BotIdentifier == speech.Speaker {
-		speakerBot = activeDebate.SupportingBot
-	} else if activeDebate.OpposingBot != nil && activeDebate.OpposingBot.Bot.BotIdentifier == speech.Speaker {
-		speakerBot = activeDebate.OpposingBot
-	}
-
-	if speakerBot == nil || speakerBot.Bot.DebateKey != speech.DebateKey {
+	speakerBot := activeDebate.findBot(speech.Speaker)
+	if speakerBot == nil || speakerBot.Bot.Side == "" || speakerBot.Bot.DebateKey != speech.DebateKey {
 		return &ErrorMessage{
 			ErrorCode:   "INVALID_DEBATE_KEY",
 			Message:     "Invalid debate key",
@@ -394,6 +557,29 @@ func (dm *DebateManager) HandleSpeech(speech *DebateSpeech, senderConn *websocke
 		}
 	}
 
+	if activeDebate.Paused {
+		return &ErrorMessage{
+			ErrorCode:   "DEBATE_PAUSED",
+			Message:     "The debate is paused, wait for debate_resumed",
+			DebateID:    speech.DebateID,
+			Recoverable: true,
+		}
+	}
+	if !isInProgress(activeDebate.Debate.Status) {
+		return &ErrorMessage{
+			ErrorCode:   "DEBATE_NOT_ACTIVE",
+			Message:     "The debate is not accepting speeches",
+			DebateID:    speech.DebateID,
+			Recoverable: false,
+		}
+	}
+
+	normalizeTranslations(activeDebate.Debate, &speech.Message)
+
+	if activeDebate.Debate.Format == FormatSimultaneous {
+		return dm.handleSimultaneousSpeech(activeDebate, speakerBot, speech, replyTo)
+	}
+
 	// Check turn
 	expectedSpeaker := dm.getNextSpeaker(activeDebate)
 	if speech.Speaker != expectedSpeaker {
@@ -415,23 +601,27 @@ func (dm *DebateManager) HandleSpeech(speech *DebateSpeech, senderConn *websocke
 	dm.resetInactivityTimer(speech.DebateID)
 
 	// Validate content length
+	limits := formatLimitsFor(activeDebate.Debate)
 	contentLen := len(strings.TrimSpace(speech.Message.Content))
-	if contentLen < config.Debate.MinContentLength {
+	if contentLen < limits.MinContentLength {
 		return &ErrorMessage{
 			ErrorCode:   "CONTENT_TOO_SHORT",
-			Message:     fmt.Sprintf("Speech content too short (minimum %d characters)", config.Debate.MinContentLength),
+			Message:     fmt.Sprintf("Speech content too short (minimum %d characters)", limits.MinContentLength),
 			DebateID:    speech.DebateID,
 			Recoverable: true,
 		}
 	}
-	if contentLen > config.Debate.MaxContentLength {
+	if contentLen > limits.MaxContentLength {
 		return &ErrorMessage{
 			ErrorCode:   "CONTENT_TOO_LONG",
-			Message:     fmt.Sprintf("Speech content too long (maximum %d characters)", config.Debate.MaxContentLength),
+			Message:     fmt.Sprintf("Speech content too long (maximum %d characters)", limits.MaxContentLength),
 			DebateID:    speech.DebateID,
 			Recoverable: true,
 		}
 	}
+	if errMsg := storeAttachments(speech); errMsg != nil {
+		return errMsg
+	}
 
 	// Add to debate log
 	logEntry := DebateLogEntry{
@@ -447,30 +637,34 @@ func (dm *DebateManager) HandleSpeech(speech *DebateSpeech, senderConn *websocke
 	activeDebate.LastSpeaker = speech.Speaker
 	activeDebate.mutex.Unlock()
 
-	// Save to database
-	dm.db.AddDebateLog(&logEntry, speech.DebateID)
+	// Determine next speaker and the round that follows
+	nextSpeaker, roundComplete := activeDebate.seatAfter(speech.Speaker)
+	nextRound := activeDebate.Debate.CurrentRound
+	if roundComplete {
+		nextRound++
+	}
 
-	// Determine next speaker and update round
-	var nextSpeaker string
+	// Save the speech and the round together
+	if err := dm.db.RecordSpeeches(speech.DebateID, []DebateLogEntry{logEntry}, nextRound); err != nil {
+		log.Printf("Failed to record speech in debate %s: %v", speech.DebateID, err)
+	}
+	dm.translateEntries(activeDebate, []DebateLogEntry{logEntry})
+	dm.summarizeEntries(activeDebate, []DebateLogEntry{logEntry})
+	speechesAccepted(activeDebate, []DebateLogEntry{logEntry})
 
-	if speech.Speaker == activeDebate.SupportingBot.Bot.BotIdentifier {
-		// Supporting spoke, opposing is next
-		nextSpeaker = activeDebate.OpposingBot.Bot.BotIdentifier
-	} else {
-		// Opposing spoke, round complete, supporting starts next round
-		activeDebate.Debate.CurrentRound++
-		dm.db.UpdateDebateRound(speech.DebateID, activeDebate.Debate.CurrentRound)
+	if roundComplete {
+		// Last seat spoke, the first seat starts the next round
+		dm.onRoundComplete(activeDebate, activeDebate.Debate.CurrentRound)
+		activeDebate.Debate.CurrentRound = nextRound
 
 		// Check if debate is complete
 		if activeDebate.Debate.CurrentRound > activeDebate.Debate.TotalRounds {
-			dm.endDebate(speech.DebateID, "completed", "completed")
+			dm.closeDebate(activeDebate)
 			return nil
 		}
-
-		nextSpeaker = activeDebate.SupportingBot.Bot.BotIdentifier
 	}
 
-	// Send update to both bots
+	// Send update to every bot
 	dm.sendDebateUpdate(activeDebate, nextSpeaker)
 
 	// Start timeout for next speaker
@@ -479,64 +673,52 @@ func (dm *DebateManager) HandleSpeech(speech *DebateSpeech, senderConn *websocke
 	return nil
 }
 
-// sendDebateUpdate sends current debate state to both bots
+// sendDebateUpdate sends current debate state to every bot
 func (dm *DebateManager) sendDebateUpdate(activeDebate *ActiveDebate, nextSpeaker string) {
 	activeDebate.mutex.RLock()
 	defer activeDebate.mutex.RUnlock()
 
-	// Send to supporting bot
-	updateMsgA := createMessage("debate_update", DebateUpdate{
-		DebateID:         activeDebate.Debate.ID,
-		Topic:            activeDebate.Debate.Topic,
-		SupportingSide:   activeDebate.SupportingBot.Bot.BotIdentifier,
-		OpposingSide:     activeDebate.OpposingBot.Bot.BotIdentifier,
-		TotalRounds:      activeDebate.Debate.TotalRounds,
-		CurrentRound:     activeDebate.Debate.CurrentRound,
-		YourSide:         "supporting",
-		YourIdentifier:   activeDebate.SupportingBot.Bot.BotIdentifier,
-		NextSpeaker:      nextSpeaker,
-		TimeoutSeconds:   120,
-		MinContentLength: config.Debate.MinContentLength,
-		MaxContentLength: config.Debate.MaxContentLength,
-		DebateLog:        activeDebate.DebateLog,
-	})
-
-	// Send to opposing bot
-	updateMsgB := createMessage("debate_update", DebateUpdate{
-		DebateID:         activeDebate.Debate.ID,
-		Topic:            activeDebate.Debate.Topic,
-		SupportingSide:   activeDebate.SupportingBot.Bot.BotIdentifier,
-		OpposingSide:     activeDebate.OpposingBot.Bot.BotIdentifier,
-		TotalRounds:      activeDebate.Debate.TotalRounds,
-		CurrentRound:     activeDebate.Debate.CurrentRound,
-		YourSide:         "opposing",
-		YourIdentifier:   activeDebate.OpposingBot.Bot.BotIdentifier,
-		NextSpeaker:      nextSpeaker,
-		TimeoutSeconds:   120,
-		MinContentLength: config.Debate.MinContentLength,
-		MaxContentLength: config.Debate.MaxContentLength,
-		DebateLog:        activeDebate.DebateLog,
-	})
-
-	activeDebate.SupportingBot.Conn.WriteJSON(updateMsgA)
-	activeDebate.OpposingBot.Conn.WriteJSON(updateMsgB)
+	limits := formatLimitsFor(activeDebate.Debate)
+	var updateMsgs []Message
+	for _, bot := range activeDebate.Bots {
+		updateMsg := createMessage("debate_update", DebateUpdate{
+			DebateID:         activeDebate.Debate.ID,
+			Topic:            activeDebate.Debate.Topic,
+			SupportingSide:   activeDebate.teamName("supporting"),
+			OpposingSide:     activeDebate.teamName("opposing"),
+			Participants:     activeDebate.participants(),
+			TotalRounds:      activeDebate.Debate.TotalRounds,
+			CurrentRound:     activeDebate.Debate.CurrentRound,
+			YourSide:         bot.Bot.Side,
+			YourIdentifier:   bot.Bot.BotIdentifier,
+			NextSpeaker:      nextSpeaker,
+			TimeoutSeconds:   limits.SpeechTimeout,
+			MinContentLength: limits.MinContentLength,
+			MaxContentLength: limits.MaxContentLength,
+			DebateLog:        activeDebate.DebateLog,
+			Status:           activeDebate.Debate.Status,
+			Scores:           activeDebate.runningScore(),
+			OpponentSummary:  activeDebate.opponentSummary(bot),
+			Spectators:       activeDebate.spectatorTotal(),
+		})
+		chaos.WriteToBot(bot.Conn, updateMsg)
+		updateMsgs = append(updateMsgs, updateMsg)
+	}
 
 	// Broadcast to frontend
 	dm.broadcast <- BroadcastMessage{
 		DebateID: activeDebate.Debate.ID,
-		Message:  updateMsgA,
+		Message:  updateMsgs[0],
 	}
 }
 
 // getNextSpeaker determines who should speak next
 func (dm *DebateManager) getNextSpeaker(activeDebate *ActiveDebate) string {
 	if activeDebate.LastSpeaker == "" {
-		return activeDebate.SupportingBot.Bot.BotIdentifier
-	}
-	if activeDebate.LastSpeaker == activeDebate.SupportingBot.Bot.BotIdentifier {
-		return activeDebate.OpposingBot.Bot.BotIdentifier
+		return activeDebate.Bots[0].Bot.BotIdentifier
 	}
-	return activeDebate.SupportingBot.Bot.BotIdentifier
+	nextSpeaker, _ := activeDebate.seatAfter(activeDebate.LastSpeaker)
+	return nextSpeaker
 }
 
 // startTimeout starts a timeout timer for a speaker
@@ -550,16 +732,20 @@ func (dm *DebateManager) startTimeout(debateID, speaker string) {
 	}
 
 	activeDebate.TimeoutTimer = time.AfterFunc(
-		time.Duration(config.Debate.SpeechTimeout)*time.Second,
+		activeDebate.turnTimeout(),
 		func() {
 			log.Printf("%d Timeout for %s in debate %s ",
-				config.Debate.SpeechTimeout,
+				formatLimitsFor(activeDebate.Debate).SpeechTimeout,
 				speaker,
 				debateID,
 			)
 			dm.endDebate(debateID, "timeout", "speech_timeout")
 		},
 	)
+
+	if bot := activeDebate.findBot(speaker); bot != nil {
+		dm.startCountdown(activeDebate, bot)
+	}
 }
 
 // endDebate ends a debate and generates summary
@@ -568,11 +754,28 @@ func (dm *DebateManager) endDebate(debateID, status, reason string) {
 	dm.mutex.RLock()
 	activeDebate, exists := dm.debates[debateID]
 	dm.mutex.RUnlock()
-
 	if !exists {
 		return
 	}
 
+	// Only the first caller judges; a disconnect, the reaper or a reconnect
+	// deadline arriving while the judge runs finds the debate closing
+	activeDebate.mutex.Lock()
+	if activeDebate.ending || activeDebate.Debate.Status == StatusCancelled {
+		activeDebate.mutex.Unlock()
+		return
+	}
+	activeDebate.ending = true
+	closing := isInProgress(activeDebate.Debate.Status)
+	if closing {
+		activeDebate.Debate.Status = StatusClosing
+		activeDebate.TurnDeadline = time.Time{}
+	}
+	activeDebate.mutex.Unlock()
+	if closing {
+		dm.db.UpdateDebateStatus(debateID, StatusClosing)
+	}
+
 	// Cancel any pending timers
 	if activeDebate.WaitingTimer != nil {
 		activeDebate.WaitingTimer.Stop()
@@ -586,28 +789,68 @@ func (dm *DebateManager) endDebate(debateID, status, reason string) {
 	if activeDebate.MaxDurationTimer != nil {
 		activeDebate.MaxDurationTimer.Stop()
 	}
+	stopCountdown(activeDebate)
+
+	// Generate summary (simplified - in production, use AI)
+	var result *DebateResult
+	if identifier := disqualifiedBot(reason); identifier != "" {
+		result = dm.disqualificationResult(activeDebate, identifier)
+	} else if status == "completed" && scoresRounds(activeDebate.Debate) {
+		result = dm.roundScoredResult(activeDebate, reason)
+	} else {
+		result = dm.generateDebateResult(activeDebate, status, reason)
+	}
+
+	// A draw or too-close result on completion earns one tiebreak round
+	if status == "completed" && dm.needsTiebreak(activeDebate, result) {
+		dm.startTiebreak(activeDebate, result)
+		return
+	}
+	result.Tiebreak = activeDebate.Tiebreak
+	applyDebateEndHooks(activeDebate, status, result)
+
+	// Thread the verdict's citations back to the speeches they refer to
+	if len(result.Citations) > 0 {
+		activeDebate.mutex.Lock()
+		attachCitations(activeDebate.DebateLog, result.Citations)
+		activeDebate.mutex.Unlock()
+	}
 
 	// Update status
 	dm.db.UpdateDebateStatus(debateID, status)
 	activeDebate.Debate.Status = status
 
-	// Generate summary (simplified - in production, use AI)
-	result := dm.generateDebateResult(activeDebate, status, reason)
-
 	// Save result
-	dm.db.SaveDebateResult(debateID, result)
+	if err := dm.db.SaveDebateResult(debateID, result); err != nil {
+		log.Printf("Failed to save result of debate %s: %v", debateID, err)
+		webhooks.Send(WebhookError, debateID, WebhookErrorData{Stage: "save_result", Message: err.Error()})
+	}
+	if len(result.Citations) > 0 {
+		dm.db.SaveCitations(debateID, result.Citations)
+	}
+	if len(result.Panel) > 0 {
+		dm.db.SaveJudgePanel(debateID, result.Panel)
+	}
+	if len(result.Runs) > 0 {
+		dm.db.SaveJudgeRuns(debateID, result.Runs)
+	}
+	if activeDebate.judging != nil {
+		activeDebate.judging.Stage(StageSaved)
+	}
+	go checkSideBias()
+	go matchEnded(activeDebate.Debate, result)
 
 	// Get bot identifiers safely
 	supportingSide := "未连接"
 	opposingSide := "未连接"
 	if activeDebate.SupportingBot != nil {
-		supportingSide = activeDebate.SupportingBot.Bot.BotIdentifier
+		supportingSide = activeDebate.teamName("supporting")
 	}
 	if activeDebate.OpposingBot != nil {
-		opposingSide = activeDebate.OpposingBot.Bot.BotIdentifier
+		opposingSide = activeDebate.teamName("opposing")
 	}
 
-	// Send end message to both bots
+	// Send end message to every bot
 	endMsg := createMessage("debate_end", DebateEnd{
 		DebateID:       debateID,
 		Topic:          activeDebate.Debate.Topic,
@@ -617,24 +860,47 @@ func (dm *DebateManager) endDebate(debateID, status, reason string) {
 		Status:         status,
 		DebateLog:      activeDebate.DebateLog,
 		DebateResult:   *result,
+		Judging:        resultJudging(result),
 	})
 
-	if activeDebate.SupportingBot != nil && activeDebate.SupportingBot.Conn != nil {
-		activeDebate.SupportingBot.Conn.WriteJSON(endMsg)
-	}
-	if activeDebate.OpposingBot != nil && activeDebate.OpposingBot.Conn != nil {
-		activeDebate.OpposingBot.Conn.WriteJSON(endMsg)
-	}
+	// Feedback goes first, clients usually disconnect on debate_end
+	dm.sendFeedback(activeDebate, result)
+	dm.sendDebateEnd(activeDebate, endMsg)
 
 	// Broadcast to frontend
 	dm.broadcast <- BroadcastMessage{
 		DebateID: debateID,
 		Message:  endMsg,
 	}
+	webhooks.Send(WebhookDebateEnd, debateID, endMsg.Data)
 
 	log.Printf("Debate %s ended with status: %s", debateID, status)
 }
 
+// announceJudging sends a debate's judging queue status to its bots and spectators
+func (dm *DebateManager) announceJudging(debateID, status string, position, queueLength int) {
+	dm.mutex.RLock()
+	activeDebate, exists := dm.debates[debateID]
+	dm.mutex.RUnlock()
+
+	if !exists {
+		return
+	}
+
+	msg := createMessage("judging_in_progress", JudgingProgress{
+		DebateID:    debateID,
+		Status:      status,
+		Position:    position,
+		QueueLength: queueLength,
+	})
+	for _, bot := range activeDebate.Bots {
+		if bot.Conn != nil {
+			bot.Conn.WriteJSONWithin(msg, backgroundWriteTimeout)
+		}
+	}
+	dm.broadcast <- BroadcastMessage{DebateID: debateID, Message: msg}
+}
+
 // generateDebateResult creates a debate result (simplified)
 // reason: specific reason for ending (e.g., "completed", "speech_timeout", "inactivity_timeout", "max_duration_timeout", "bot_disconnected_{bot_id}", "heartbeat_timeout_{bot_id}")
 func (dm *DebateManager) generateDebateResult(activeDebate *ActiveDebate, status, reason string) *DebateResult {
@@ -651,33 +917,48 @@ func (dm *DebateManager) generateDebateResult(activeDebate *ActiveDebate, status
 
 	// Check if we should use ChatGPT for judging
 	// Only use ChatGPT if:
-	// 1. ChatGPT is enabled
+	// 1. ChatGPT is available and the debate did not opt out
 	// 2. Both bots are present
 	// 3. Both sides have spoken (at least 1 speech each)
-	shouldUseAI := chatgptClient != nil &&
+	shouldUseAI := judgingFor(activeDebate.Debate) == JudgeModeAI &&
 		activeDebate.SupportingBot != nil &&
 		activeDebate.OpposingBot != nil &&
 		supportingCount > 0 &&
 		opposingCount > 0
 
 	if shouldUseAI {
-		result, err := chatgptClient.JudgeDebate(
-			activeDebate.Debate.Topic,
-			activeDebate.DebateLog,
-			activeDebate.SupportingBot.Bot.BotIdentifier,
-			activeDebate.OpposingBot.Bot.BotIdentifier,
-		)
+		var result *DebateResult
+		var err error
+		var tracker *JudgingTracker
+		dm.judgeQueue.Run(activeDebate.Debate, activeDebate.DebateLog, func() {
+			tracker = dm.trackJudging(activeDebate)
+			opts := JudgeOptions{Progress: tracker.Stage, Signals: activeDebate.Signals, Style: verdictStyleFor(activeDebate.Debate)}
+			if config.ChatGPT.Judge.Stream {
+				opts.Stream = tracker.Stream
+			}
+			result, err = chatgptClient.forDebate(activeDebate.Debate.ID, UsageJudge).JudgeDebate(
+				activeDebate.Debate.Topic,
+				activeDebate.DebateLog,
+				activeDebate.teamName("supporting"),
+				activeDebate.teamName("opposing"),
+				opts,
+			)
+		})
 		if err == nil {
+			result.JudgeMs = tracker.Elapsed().Milliseconds()
 			log.Printf("ChatGPT judge completed for debate %s: %s wins", activeDebate.Debate.ID, result.Winner)
 			return result
 		}
+		tracker.Fail(err)
 		log.Printf("ChatGPT judge failed, using fallback: %v", err)
+		webhooks.Send(WebhookError, activeDebate.Debate.ID, WebhookErrorData{Stage: "judge", Message: err.Error()})
 	} else if status == "timeout" && (supportingCount == 0 || opposingCount == 0) {
 		log.Printf("Skipping AI judge for debate %s: timeout with insufficient speeches (supporting: %d, opposing: %d)",
 			activeDebate.Debate.ID, supportingCount, opposingCount)
 	}
 
 	// Fallback: simple scoring or timeout result
+	judgeMetrics.ObserveVerdict(VerdictFallback)
 
 	supportingScore := 45 + (supportingCount * 2)
 	opposingScore := 45 + (opposingCount * 2)
@@ -710,14 +991,14 @@ func (dm *DebateManager) generateDebateResult(activeDebate *ActiveDebate, status
 	supportingID := "未连接"
 	opposingID := "未连接"
 	if activeDebate.SupportingBot != nil {
-		supportingID = activeDebate.SupportingBot.Bot.BotIdentifier
+		supportingID = activeDebate.teamName("supporting")
 	}
 	if activeDebate.OpposingBot != nil {
-		opposingID = activeDebate.OpposingBot.Bot.BotIdentifier
+		opposingID = activeDebate.teamName("opposing")
 	}
 
 	// Generate reason description
-	reasonDesc := dm.getReasonDescription(reason, supportingID, opposingID)
+	reasonDesc := dm.getReasonDescription(activeDebate.Debate, reason, supportingID, opposingID)
 
 	// Generate summary based on status
 	var summary string
@@ -759,6 +1040,10 @@ func (dm *DebateManager) generateDebateResult(activeDebate *ActiveDebate, status
 			opposingID, opposingCount,
 			reasonDesc)
 	} else {
+		note := "注: 使用简单计分规则，ChatGPT评判不可用。"
+		if activeDebate.Debate.NoAIJudge {
+			note = "注: 本场辩论未启用 AI 评判，使用简单计分规则。"
+		}
 		summary = fmt.Sprintf(`## 辩论总结
 
 **辩题**: %s
@@ -774,12 +1059,12 @@ func (dm *DebateManager) generateDebateResult(activeDebate *ActiveDebate, status
 ### 结果
 **获胜方**: %s
 
-注: 使用简单计分规则，ChatGPT评判不可用。
+%s
 
 感谢两位选手的精彩辩论！`, activeDebate.Debate.Topic,
 			supportingID, supportingCount, supportingScore,
 			opposingID, opposingCount, opposingScore,
-			winner)
+			winner, note)
 	}
 
 	return &DebateResult{
@@ -791,28 +1076,46 @@ func (dm *DebateManager) generateDebateResult(activeDebate *ActiveDebate, status
 			Content: summary,
 		},
 		Reason: reason,
+		Source: ResultSourceFallback,
 	}
 }
 
 // AddFrontendConnection adds a frontend WebSocket connection
-func (dm *DebateManager) AddFrontendConnection(debateID string, conn *websocket.Conn) error {
+func (dm *DebateManager) AddFrontendConnection(debateID, token string, sub *Subscriber, conn *WSConn) error {
 	dm.mutex.Lock()
 	defer dm.mutex.Unlock()
 
 	activeDebate, exists := dm.debates[debateID]
 	if !exists {
-		return fmt.Errorf("debate not found")
+		return errDebateNotFound
+	}
+	if err := checkSpectatorAccess(activeDebate.Debate, token); err != nil {
+		return err
 	}
 
 	activeDebate.mutex.Lock()
-	activeDebate.FrontendConns[conn] = true
+	previous := activeDebate.FrontendConns[conn]
+	activeDebate.FrontendConns[conn] = sub
+	if previous == nil {
+		dm.announceSpectators(activeDebate)
+	}
 	activeDebate.mutex.Unlock()
 
+	// Resubscribing replaces the session rather than opening a second one
+	if previous != nil {
+		dm.endSpectatorSession(previous)
+	}
+	session, err := dm.db.StartSpectatorSession(debateID, cluster.InstanceID)
+	if err != nil {
+		log.Printf("Failed to record spectator session for debate %s: %v", debateID, err)
+	}
+	sub.session = session
+
 	return nil
 }
 
 // RemoveFrontendConnection removes a frontend connection
-func (dm *DebateManager) RemoveFrontendConnection(debateID string, conn *websocket.Conn) {
+func (dm *DebateManager) RemoveFrontendConnection(debateID string, conn *WSConn) {
 	dm.mutex.Lock()
 	defer dm.mutex.Unlock()
 
@@ -822,8 +1125,26 @@ func (dm *DebateManager) RemoveFrontendConnection(debateID string, conn *websock
 	}
 
 	activeDebate.mutex.Lock()
+	sub := activeDebate.FrontendConns[conn]
 	delete(activeDebate.FrontendConns, conn)
+	if sub != nil {
+		dm.announceSpectators(activeDebate)
+	}
 	activeDebate.mutex.Unlock()
+
+	if sub != nil {
+		dm.endSpectatorSession(sub)
+	}
+}
+
+// endSpectatorSession records that a spectator stopped watching
+func (dm *DebateManager) endSpectatorSession(sub *Subscriber) {
+	if sub.session == 0 {
+		return
+	}
+	if err := dm.db.EndSpectatorSession(sub.session); err != nil {
+		log.Printf("Failed to close spectator session %d: %v", sub.session, err)
+	}
 }
 
 // Helper functions
@@ -834,19 +1155,53 @@ func generateDebateKey() string {
 	return "key-" + hex.EncodeToString(bytes)
 }
 
+// compareVersions compares dotted numeric versions ("2.0" < "2.1" < "10.0").
+// Missing or non-numeric components count as 0.
+func compareVersions(a, b string) int {
+	pa := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	pb := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var na, nb int
+		if i < len(pa) {
+			na, _ = strconv.Atoi(pa[i])
+		}
+		if i < len(pb) {
+			nb, _ = strconv.Atoi(pb[i])
+		}
+		if na != nb {
+			if na < nb {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
 func randomBool() bool {
 	n, _ := rand.Int(rand.Reader, big.NewInt(2))
 	return n.Int64() == 1
 }
 
 func createMessage(msgType string, data interface{}) Message {
+	if !isRegisteredMessage(msgType) {
+		log.Printf("Sending unregistered message type %q", msgType)
+	}
 	return Message{
+		ID:        "msg-" + uuid.New().String(),
 		Type:      msgType,
 		Timestamp: time.Now().Format(time.RFC3339),
 		Data:      data,
 	}
 }
 
+// createReply builds a message answering the given id
+func createReply(replyTo, msgType string, data interface{}) Message {
+	msg := createMessage(msgType, data)
+	msg.ReplyTo = replyTo
+	return msg
+}
+
 // startInactivityTimer starts the inactivity timeout timer
 func (dm *DebateManager) startInactivityTimer(debateID string) {
 	dm.mutex.RLock()
@@ -893,9 +1248,10 @@ func (dm *DebateManager) startMaxDurationTimer(debateID string) {
 		return
 	}
 
-	maxDuration := time.Duration(config.Debate.MaxDuration) * time.Second
+	// StartTime is pushed back by pauses, so this is the full limit on a fresh start
+	remaining := time.Until(activeDebate.StartTime.Add(time.Duration(config.Debate.MaxDuration) * time.Second))
 
-	activeDebate.MaxDurationTimer = time.AfterFunc(maxDuration, func() {
+	activeDebate.MaxDurationTimer = time.AfterFunc(remaining, func() {
 		elapsed := time.Since(activeDebate.StartTime)
 		log.Printf("Max duration timeout for debate %s (running for %v)", debateID, elapsed)
 		dm.endDebate(debateID, "timeout", "max_duration_timeout")
@@ -913,7 +1269,11 @@ func (dm *DebateManager) startWaitingTimer(debateID string) {
 		return
 	}
 
-	waitingTimeout := time.Duration(config.Debate.WaitingTimeout) * time.Second
+	// Counted from creation, so a timer restarted by the reaper keeps the deadline
+	waitingTimeout := time.Duration(config.Debate.WaitingTimeout)*time.Second - time.Since(activeDebate.Debate.CreatedAt)
+	if waitingTimeout < 0 {
+		waitingTimeout = 0
+	}
 
 	activeDebate.WaitingTimer = time.AfterFunc(waitingTimeout, func() {
 		dm.mutex.RLock()
@@ -936,6 +1296,8 @@ func (dm *DebateManager) startWaitingTimer(debateID string) {
 			dm.mutex.Lock()
 			delete(dm.debates, debateID)
 			dm.mutex.Unlock()
+
+			go matchEnded(debate.Debate, nil)
 		}
 	})
 
@@ -943,12 +1305,12 @@ func (dm *DebateManager) startWaitingTimer(debateID string) {
 }
 
 // getReasonDescription returns a human-readable description of the debate end reason
-func (dm *DebateManager) getReasonDescription(reason, supportingBot, opposingBot string) string {
+func (dm *DebateManager) getReasonDescription(debate *Debate, reason, supportingBot, opposingBot string) string {
 	switch {
 	case reason == "completed":
 		return "辩论正常完成"
 	case reason == "speech_timeout":
-		return fmt.Sprintf("发言超时（Bot 未在 %d 秒内发言）", config.Debate.SpeechTimeout)
+		return fmt.Sprintf("发言超时（Bot 未在 %d 秒内发言）", formatLimitsFor(debate).SpeechTimeout)
 	case reason == "inactivity_timeout":
 		return fmt.Sprintf("长时间无活动（超过 %d 秒无新发言）", config.Debate.InactivityTimeout)
 	case reason == "max_duration_timeout":
@@ -959,13 +1321,18 @@ func (dm *DebateManager) getReasonDescription(reason, supportingBot, opposingBot
 	case strings.HasPrefix(reason, "heartbeat_timeout_"):
 		botID := strings.TrimPrefix(reason, "heartbeat_timeout_")
 		return fmt.Sprintf("Bot %s 心跳超时（连续 3 次未响应 pong）", botID)
+	case strings.HasPrefix(reason, "disqualified_"):
+		botID := strings.TrimPrefix(reason, "disqualified_")
+		return fmt.Sprintf("Bot %s 连续 %d 次违规发言，被取消资格", botID, config.Debate.MaxViolations)
+	case reason == ReasonNoBotsConnected:
+		return "所有 Bot 均已断开连接"
 	default:
 		return reason
 	}
 }
 
 // HandleBotDisconnect handles bot disconnection (including heartbeat timeout)
-func (dm *DebateManager) HandleBotDisconnect(debateID, botIdentifier string, reason string) {
+func (dm *DebateManager) HandleBotDisconnect(debateID, botIdentifier string, reason string, conn *WSConn) {
 	dm.mutex.RLock()
 	activeDebate, exists := dm.debates[debateID]
 	dm.mutex.RUnlock()
@@ -975,11 +1342,22 @@ func (dm *DebateManager) HandleBotDisconnect(debateID, botIdentifier string, rea
 		return
 	}
 
+	// A bot that already reconnected leaves its old connection behind
+	bot := activeDebate.findBot(botIdentifier)
+	if bot != nil && bot.Conn != conn {
+		log.Printf("Ignoring stale connection of bot %s in debate %s", botIdentifier, debateID)
+		return
+	}
+
 	log.Printf("Bot %s disconnected from debate %s (reason: %s, status: %s)",
 		botIdentifier, debateID, reason, activeDebate.Debate.Status)
 
 	// Only end debate if it's currently active
-	if activeDebate.Debate.Status == "active" {
+	if isInProgress(activeDebate.Debate.Status) && bot != nil && config.Debate.ReconnectGrace > 0 {
+		dm.holdForReconnect(activeDebate, bot, reason)
+	} else if isInProgress(activeDebate.Debate.Status) {
+		dm.markGone(activeDebate, botIdentifier)
+		dm.announceParticipant(activeDebate, botIdentifier, ParticipantDisconnected, 0, time.Time{})
 		log.Printf("Ending debate %s due to bot %s disconnection", debateID, botIdentifier)
 		// Include bot identifier in the reason
 		detailedReason := fmt.Sprintf("%s_%s", reason, botIdentifier)
@@ -987,5 +1365,8 @@ func (dm *DebateManager) HandleBotDisconnect(debateID, botIdentifier string, rea
 	} else if activeDebate.Debate.Status == "waiting" {
 		// If still waiting for bots to join, just log it
 		log.Printf("Bot %s disconnected while debate %s is still waiting", botIdentifier, debateID)
+	} else if activeDebate.Debate.Status == StatusClosing {
+		// Judging is still to come; the result waits for the bot's next login
+		dm.markGone(activeDebate, botIdentifier)
 	}
 }
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// A debate template is a named set of creation settings managed under
+// /api/admin/templates. POST /api/debate/create with template_id starts from
+// the template: settings the request leaves unset are taken from it, and
+// without a topic the template's topic_pattern is filled in from topic_vars,
+// each {name} in the pattern replaced by topic_vars[name]. Switches a
+// template turns on (blind_opening, private, side_channel, no_ai_judge)
+// cannot be turned off by the request.
+
+// topicVarPattern matches a {name} placeholder in a topic pattern
+var topicVarPattern = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// TemplateSettings are the creation settings a template supplies; they mean
+// what the CreateDebateRequest fields of the same name do
+type TemplateSettings struct {
+	TotalRounds     int           `json:"total_rounds,omitempty"`
+	Ranked          *bool         `json:"ranked,omitempty"`
+	Format          string        `json:"format,omitempty"`
+	BlindOpening    bool          `json:"blind_opening,omitempty"`
+	Scoring         string        `json:"scoring,omitempty"`
+	Private         bool          `json:"private,omitempty"`
+	SideChannel     bool          `json:"side_channel,omitempty"`
+	NoAIJudge       bool          `json:"no_ai_judge,omitempty"`
+	DiscloseRubric  *bool         `json:"disclose_rubric,omitempty"`
+	OpponentSummary *bool         `json:"opponent_summary,omitempty"`
+	Verdict         *VerdictStyle `json:"verdict,omitempty"`
+	Limits          *FormatLimits `json:"limits,omitempty"` // Speech timeout and content lengths
+	Languages       []string      `json:"languages,omitempty"`
+	Category        string        `json:"category,omitempty"`
+}
+
+// DebateTemplate is a saved set of debate creation settings
+type DebateTemplate struct {
+	ID           string           `json:"id"`
+	Name         string           `json:"name"`
+	Description  string           `json:"description"`
+	TopicPattern string           `json:"topic_pattern"` // e.g. "{city}是否应该禁止燃油车", filled from topic_vars
+	Settings     TemplateSettings `json:"settings"`
+	CreatedAt    time.Time        `json:"created_at"`
+	UpdatedAt    time.Time        `json:"updated_at"`
+}
+
+// validate rejects templates whose settings a create request would reject
+func (t *DebateTemplate) validate() error {
+	s := t.Settings
+	if t.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if s.TotalRounds < 0 {
+		return fmt.Errorf("total_rounds may not be negative")
+	}
+	if err := validateRounds(s.TotalRounds); err != nil {
+		return err
+	}
+	switch s.Format {
+	case "", FormatSequential, FormatSimultaneous:
+	default:
+		return fmt.Errorf("unknown format %q", s.Format)
+	}
+	switch s.Scoring {
+	case "", ScoringHolistic, ScoringRounds, ScoringCumulative:
+	default:
+		return fmt.Errorf("unknown scoring mode %q", s.Scoring)
+	}
+	if err := validateLanguages(s.Languages, nil); err != nil {
+		return err
+	}
+	if err := s.Verdict.validate(); err != nil {
+		return err
+	}
+	return s.Limits.validate()
+}
+
+// fillTopic fills a topic pattern's placeholders from vars
+func fillTopic(pattern string, vars map[string]string) (string, error) {
+	var missing []string
+	topic := topicVarPattern.ReplaceAllStringFunc(pattern, func(placeholder string) string {
+		name := placeholder[1 : len(placeholder)-1]
+		value := strings.TrimSpace(vars[name])
+		if value == "" {
+			missing = append(missing, name)
+		}
+		return value
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("topic_vars is missing %s", strings.Join(missing, ", "))
+	}
+	return strings.TrimSpace(topic), nil
+}
+
+// applyTemplate fills the settings a create request leaves unset from its
+// template
+func applyTemplate(req *CreateDebateRequest, t *DebateTemplate) error {
+	if req.Topic == "" && t.TopicPattern != "" {
+		topic, err := fillTopic(t.TopicPattern, req.TopicVars)
+		if err != nil {
+			return err
+		}
+		req.Topic = topic
+	}
+
+	s := t.Settings
+	if req.TotalRounds <= 0 {
+		req.TotalRounds = s.TotalRounds
+	}
+	if req.Ranked == nil {
+		req.Ranked = s.Ranked
+	}
+	if req.Format == "" {
+		req.Format = s.Format
+	}
+	if req.Scoring == "" {
+		req.Scoring = s.Scoring
+	}
+	req.BlindOpening = req.BlindOpening || s.BlindOpening
+	req.Private = req.Private || s.Private
+	req.SideChannel = req.SideChannel || s.SideChannel
+	req.NoAIJudge = req.NoAIJudge || s.NoAIJudge
+	if req.DiscloseRubric == nil {
+		req.DiscloseRubric = s.DiscloseRubric
+	}
+	if req.OpponentSummary == nil {
+		req.OpponentSummary = s.OpponentSummary
+	}
+	if req.Verdict == nil {
+		req.Verdict = s.Verdict
+	}
+	if req.Limits == nil {
+		req.Limits = s.Limits
+	}
+	if req.Languages == nil {
+		req.Languages = s.Languages
+	}
+	if req.Category == "" {
+		req.Category = s.Category
+	}
+	return nil
+}
+
+// handleTemplates handles /api/admin/templates (list, create) and
+// /api/admin/templates/{id} (get, update, delete)
+func handleTemplates(w http.ResponseWriter, r *http.Request) {
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/admin/templates"), "/")
+
+	if id == "" {
+		switch r.Method {
+		case http.MethodGet:
+			templates, err := db.GetTemplates()
+			if err != nil {
+				http.Error(w, "Failed to fetch templates", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, templates)
+		case http.MethodPost:
+			createTemplate(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		template, err := db.GetTemplate(id)
+		if err != nil {
+			http.Error(w, "Template not found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, template)
+	case http.MethodPut:
+		updateTemplate(w, r, id)
+	case http.MethodDelete:
+		if err := db.DeleteTemplate(id); err == sql.ErrNoRows {
+			http.Error(w, "Template not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			http.Error(w, "Failed to delete template", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func createTemplate(w http.ResponseWriter, r *http.Request) {
+	var template DebateTemplate
+	if err := json.NewDecoder(r.Body).Decode(&template); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if err := template.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if template.ID == "" {
+		template.ID = "template-" + uuid.New().String()[:8]
+	}
+	template.CreatedAt = time.Now()
+	template.UpdatedAt = template.CreatedAt
+
+	if err := db.CreateTemplate(&template); err != nil {
+		http.Error(w, "Failed to create template", http.StatusConflict)
+		return
+	}
+
+	log.Printf("Debate template created: %s (%s)", template.ID, template.Name)
+	writeJSONStatus(w, http.StatusCreated, template)
+}
+
+// updateTemplate replaces a template's name, description, pattern and
+// settings; omitted name and description are kept
+func updateTemplate(w http.ResponseWriter, r *http.Request, id string) {
+	existing, err := db.GetTemplate(id)
+	if err != nil {
+		http.Error(w, "Template not found", http.StatusNotFound)
+		return
+	}
+
+	var req DebateTemplate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if req.Name != "" {
+		existing.Name = req.Name
+	}
+	if req.Description != "" {
+		existing.Description = req.Description
+	}
+	existing.TopicPattern = req.TopicPattern
+	existing.Settings = req.Settings
+	if err := existing.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	existing.UpdatedAt = time.Now()
+
+	if err := db.UpdateTemplate(existing); err != nil {
+		http.Error(w, "Failed to update template", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, existing)
+}
+
+// CreateTemplate stores a new debate template
+func (d *Database) CreateTemplate(t *DebateTemplate) error {
+	settings, err := json.Marshal(t.Settings)
+	if err != nil {
+		return err
+	}
+	query := `INSERT INTO debate_templates (id, name, description, topic_pattern, settings, created_at, updated_at)
+	          VALUES (?, ?, ?, ?, ?, ?, ?)`
+	_, err = d.db.Exec(query, t.ID, t.Name, t.Description, t.TopicPattern, string(settings), t.CreatedAt, t.UpdatedAt)
+	return err
+}
+
+// UpdateTemplate updates an existing debate template
+func (d *Database) UpdateTemplate(t *DebateTemplate) error {
+	settings, err := json.Marshal(t.Settings)
+	if err != nil {
+		return err
+	}
+	query := `UPDATE debate_templates SET name = ?, description = ?, topic_pattern = ?, settings = ?, updated_at = ? WHERE id = ?`
+	res, err := d.db.Exec(query, t.Name, t.Description, t.TopicPattern, string(settings), t.UpdatedAt, t.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteTemplate removes a debate template
+func (d *Database) DeleteTemplate(id string) error {
+	res, err := d.db.Exec(`DELETE FROM debate_templates WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// templateColumns are the columns scanTemplate reads
+const templateColumns = `id, name, description, topic_pattern, settings, created_at, updated_at`
+
+// scanTemplate scans templateColumns
+func scanTemplate(scan func(dest ...interface{}) error) (*DebateTemplate, error) {
+	t := &DebateTemplate{}
+	var settings string
+	if err := scan(&t.ID, &t.Name, &t.Description, &t.TopicPattern, &settings, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(settings), &t.Settings); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// GetTemplate returns a debate template
+func (d *Database) GetTemplate(id string) (*DebateTemplate, error) {
+	return scanTemplate(d.db.QueryRow(`SELECT `+templateColumns+` FROM debate_templates WHERE id = ?`, id).Scan)
+}
+
+// GetTemplates lists all debate templates
+func (d *Database) GetTemplates() ([]*DebateTemplate, error) {
+	rows, err := d.db.Query(`SELECT ` + templateColumns + ` FROM debate_templates ORDER BY name ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := []*DebateTemplate{}
+	for rows.Next() {
+		t, err := scanTemplate(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+	return templates, rows.Err()
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Transcripts can be downloaded as a document: Markdown for reading and
+// editing, PDF for sharing, and JSON in the import format, so an export from
+// one instance can be imported into another. Exports are public views and go
+// through the redactor.
+
+// Export formats
+const (
+	ExportMarkdown = "markdown"
+	ExportPDF      = "pdf"
+	ExportJSON     = "json"
+)
+
+// exportPlatform is the platform recorded in JSON exports
+const exportPlatform = "bot-debate"
+
+// transcript is everything an export renders
+type transcript struct {
+	Debate *Debate
+	Bots   []*Bot
+	Log    []DebateLogEntry
+	Result *DebateResult // nil until the debate is judged
+}
+
+// handleExportDebate handles GET /api/debate/{id}/export?format=markdown|pdf|json
+func handleExportDebate(w http.ResponseWriter, r *http.Request, debateID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = ExportMarkdown
+	}
+	if format != ExportMarkdown && format != ExportPDF && format != ExportJSON {
+		http.Error(w, "format must be markdown, pdf or json", http.StatusBadRequest)
+		return
+	}
+
+	debate, err := db.GetDebate(debateID)
+	if err != nil || checkSpectatorAccess(debate, r.URL.Query().Get("token")) != nil {
+		http.Error(w, "Debate not found", http.StatusNotFound)
+		return
+	}
+	t, err := loadTranscript(debate)
+	if err != nil {
+		http.Error(w, "Failed to load transcript", http.StatusInternalServerError)
+		return
+	}
+
+	name := debate.ID
+	if debate.ShortID != "" {
+		name = "debate-" + debate.ShortID
+	}
+	switch format {
+	case ExportMarkdown:
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.md"`, name))
+		w.Write([]byte(t.markdown()))
+	case ExportPDF:
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.pdf"`, name))
+		w.Write(renderPDF(t.Debate.Topic, t.markdown()))
+	case ExportJSON:
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, name))
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(t.importRequest())
+	}
+}
+
+// loadTranscript gathers a debate's bots, log and result, redacted
+func loadTranscript(debate *Debate) (*transcript, error) {
+	bots, err := db.GetBots(debate.ID)
+	if err != nil {
+		return nil, err
+	}
+	debateLog, err := db.GetDebateLog(debate.ID)
+	if err != nil {
+		return nil, err
+	}
+	result, _ := db.GetDebateResult(debate.ID)
+	return &transcript{
+		Debate: redactor.Debate(debate),
+		Bots:   bots,
+		Log:    redactor.Log(debateLog),
+		Result: redactor.Result(result),
+	}, nil
+}
+
+// sideBots returns the names of the bots on a side
+func (t *transcript) sideBots(side string) []string {
+	var names []string
+	for _, bot := range t.Bots {
+		if bot.Side == side {
+			names = append(names, bot.BotName)
+		}
+	}
+	return names
+}
+
+// winnerLabel describes a result's winner
+func winnerLabel(winner string) string {
+	switch winner {
+	case "supporting":
+		return "正方"
+	case "opposing":
+		return "反方"
+	case "draw":
+		return "平局"
+	case RoundUnscored:
+		return "未计分"
+	}
+	return "无"
+}
+
+// markdown renders the transcript as a Markdown document
+func (t *transcript) markdown() string {
+	var b strings.Builder
+	debate := t.Debate
+	b.WriteString(fmt.Sprintf("# %s\n\n", debate.Topic))
+	b.WriteString(fmt.Sprintf("- **辩论 ID**: %s\n", debate.ID))
+	b.WriteString(fmt.Sprintf("- **状态**: %s\n", debate.Status))
+	b.WriteString(fmt.Sprintf("- **轮数**: %d\n", debate.TotalRounds))
+	b.WriteString(fmt.Sprintf("- **创建时间**: %s\n", debate.CreatedAt.Format(time.RFC3339)))
+	for _, side := range []string{"supporting", "opposing"} {
+		names := t.sideBots(side)
+		if len(names) == 0 {
+			names = []string{"未连接"}
+		}
+		b.WriteString(fmt.Sprintf("- **%s**: %s\n", sideName(side), strings.Join(names, ", ")))
+	}
+
+	b.WriteString("\n## 辩论记录\n")
+	if len(t.Log) == 0 {
+		b.WriteString("\n暂无发言。\n")
+	}
+	for _, entry := range t.Log {
+		b.WriteString(fmt.Sprintf("\n### 第%d轮 · %s · %s\n\n", entry.Round, sideName(entry.Side), entry.Speaker))
+		b.WriteString(strings.TrimSpace(entry.Message.Content))
+		b.WriteString("\n")
+		if len(entry.Message.Attachments) > 0 {
+			b.WriteString("\n**附件**:\n")
+			for _, a := range entry.Message.Attachments {
+				b.WriteString(fmt.Sprintf("- [%s](%s)（%s，%d 字节）\n", a.Name, a.URL, a.ContentType, a.Size))
+			}
+		}
+	}
+
+	if t.Result != nil {
+		result := t.Result
+		b.WriteString("\n## 评判结果\n\n")
+		b.WriteString(fmt.Sprintf("- **获胜方**: %s\n", winnerLabel(result.Winner)))
+		b.WriteString(fmt.Sprintf("- **得分**: 正方 %d : 反方 %d\n", result.SupportingScore, result.OpposingScore))
+		if result.JudgeModel != "" {
+			b.WriteString(fmt.Sprintf("- **评委模型**: %s\n", result.JudgeModel))
+		}
+		for _, round := range result.RoundResults {
+			b.WriteString(fmt.Sprintf("- **第%d轮**: %s（%d : %d）%s\n", round.Round, winnerLabel(round.Winner),
+				round.SupportingScore, round.OpposingScore, round.Comment))
+		}
+		for _, score := range result.Breakdown {
+			name := score.Name
+			if name == "" {
+				name = score.Criterion
+			}
+			b.WriteString(fmt.Sprintf("- **%s**: 正方 %d : 反方 %d\n", name, score.Supporting, score.Opposing))
+		}
+		if summary := strings.TrimSpace(result.Summary.Content); summary != "" {
+			b.WriteString("\n" + summary + "\n")
+		}
+	}
+	return b.String()
+}
+
+// importRequest renders the transcript in the format /api/debate/import accepts
+func (t *transcript) importRequest() *ImportDebateRequest {
+	started := t.Debate.CreatedAt
+	req := &ImportDebateRequest{
+		Platform:  exportPlatform,
+		Topic:     t.Debate.Topic,
+		Category:  t.Debate.Category,
+		Ranked:    t.Debate.Ranked,
+		Speeches:  []ImportedSpeech{},
+		StartedAt: &started,
+	}
+	supporting, opposing := findSides(t.Bots)
+	if supporting != nil {
+		req.Supporting = ImportedBot{Name: supporting.BotName, UUID: supporting.BotUUID}
+	}
+	if opposing != nil {
+		req.Opposing = ImportedBot{Name: opposing.BotName, UUID: opposing.BotUUID}
+	}
+	for _, entry := range t.Log {
+		req.Speeches = append(req.Speeches, ImportedSpeech{
+			Round:       entry.Round,
+			Side:        entry.Side,
+			Format:      entry.Message.Format,
+			Timestamp:   entry.Timestamp,
+			Content:     entry.Message.Content,
+			Attachments: entry.Message.Attachments,
+		})
+	}
+	if t.Result != nil {
+		req.Result = &ImportedResult{
+			Winner:          t.Result.Winner,
+			SupportingScore: t.Result.SupportingScore,
+			OpposingScore:   t.Result.OpposingScore,
+			Summary:         t.Result.Summary.Content,
+		}
+	}
+	return req
+}
@@ -0,0 +1,64 @@
+package main
+
+// feedbackRubric is appended to the judge prompt when chatgpt.judge.feedback is set
+const feedbackRubric = `
+
+另外，请在 JSON 中增加 "feedback" 字段，分别给双方写出改进建议（只会私下发给对应的 Bot）:
+  "feedback": {
+    "supporting": {"strengths": ["优点"], "weaknesses": ["不足"], "missed_rebuttals": ["本应反驳却没有反驳的对方论点"]},
+    "opposing": {"strengths": [...], "weaknesses": [...], "missed_rebuttals": [...]}
+  }
+每项列出 1-3 条具体、可操作的内容。`
+
+// BotFeedback is the judge's critique of one side, meant to help its developer improve the bot
+type BotFeedback struct {
+	Strengths       []string `json:"strengths"`
+	Weaknesses      []string `json:"weaknesses"`
+	MissedRebuttals []string `json:"missed_rebuttals"`
+}
+
+// TrainingFeedback is sent privately to each bot after judging
+type TrainingFeedback struct {
+	DebateID string `json:"debate_id"`
+	Side     string `json:"side"`
+	BotFeedback
+}
+
+// mergeFeedback appends the critique points of b to a, per side
+func mergeFeedback(a, b map[string]BotFeedback) map[string]BotFeedback {
+	if a == nil {
+		a = map[string]BotFeedback{}
+	}
+	for side, f := range b {
+		merged, ok := a[side]
+		if !ok {
+			merged = BotFeedback{Strengths: []string{}, Weaknesses: []string{}, MissedRebuttals: []string{}}
+		}
+		merged.Strengths = append(merged.Strengths, f.Strengths...)
+		merged.Weaknesses = append(merged.Weaknesses, f.Weaknesses...)
+		merged.MissedRebuttals = append(merged.MissedRebuttals, f.MissedRebuttals...)
+		a[side] = merged
+	}
+	return a
+}
+
+// sendFeedback sends each connected bot the judge's critique of its own side
+func (dm *DebateManager) sendFeedback(activeDebate *ActiveDebate, result *DebateResult) {
+	if len(result.Feedback) == 0 {
+		return
+	}
+	for _, bot := range activeDebate.Bots {
+		if bot.Conn == nil {
+			continue
+		}
+		feedback, ok := result.Feedback[bot.Bot.Side]
+		if !ok {
+			continue
+		}
+		bot.Conn.WriteJSON(createMessage("feedback", TrainingFeedback{
+			DebateID:    activeDebate.Debate.ID,
+			Side:        bot.Bot.Side,
+			BotFeedback: feedback,
+		}))
+	}
+}
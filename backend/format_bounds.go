@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// A create request may give a debate its own speech timeout and content
+// length limits under the limits field. The deployment bounds what it may
+// ask for: debate.max_rounds caps total_rounds of debates, tournament
+// matches and league fixtures alike, debate.max_content_ceiling caps the
+// content length a debate may allow and debate.min_speech_timeout is the
+// shortest speech timeout it may set, so a public instance can refuse a
+// 10,000-round debate or one whose bots get a second per speech.
+
+// FormatLimits are a debate's speech timeout and content length limits.
+// Zero fields of a per-debate override fall back to the configured ones.
+type FormatLimits struct {
+	SpeechTimeout    int `json:"speech_timeout,omitempty"`     // Seconds a bot has for each speech
+	MinContentLength int `json:"min_content_length,omitempty"` // Fewest characters in a speech
+	MaxContentLength int `json:"max_content_length,omitempty"` // Most characters in a speech
+}
+
+// validateRounds rejects round counts above debate.max_rounds
+func validateRounds(totalRounds int) error {
+	if max := config.Debate.MaxRounds; max > 0 && totalRounds > max {
+		return fmt.Errorf("total_rounds may not exceed %d", max)
+	}
+	return nil
+}
+
+// validate rejects per-debate limits outside the configured bounds
+func (l *FormatLimits) validate() error {
+	if l == nil {
+		return nil
+	}
+	d := config.Debate
+	if l.SpeechTimeout < 0 || l.MinContentLength < 0 || l.MaxContentLength < 0 {
+		return fmt.Errorf("limits may not be negative")
+	}
+	if l.SpeechTimeout > 0 && l.SpeechTimeout < d.MinSpeechTimeout {
+		return fmt.Errorf("speech_timeout must be at least %d seconds", d.MinSpeechTimeout)
+	}
+	if d.MaxContentCeiling > 0 && l.MaxContentLength > d.MaxContentCeiling {
+		return fmt.Errorf("max_content_length may not exceed %d", d.MaxContentCeiling)
+	}
+	if d.MaxContentCeiling > 0 && l.MinContentLength > d.MaxContentCeiling {
+		return fmt.Errorf("min_content_length may not exceed %d", d.MaxContentCeiling)
+	}
+	limits := formatLimitsFor(&Debate{Limits: l})
+	if limits.MinContentLength > limits.MaxContentLength {
+		return fmt.Errorf("min_content_length (%d) may not exceed max_content_length (%d)", limits.MinContentLength, limits.MaxContentLength)
+	}
+	return nil
+}
+
+// formatLimitsFor returns the configured limits with a debate's overrides
+// applied
+func formatLimitsFor(debate *Debate) FormatLimits {
+	d := config.Debate
+	limits := FormatLimits{
+		SpeechTimeout:    d.SpeechTimeout,
+		MinContentLength: d.MinContentLength,
+		MaxContentLength: d.MaxContentLength,
+	}
+	if debate == nil || debate.Limits == nil {
+		return limits
+	}
+	if debate.Limits.SpeechTimeout > 0 {
+		limits.SpeechTimeout = debate.Limits.SpeechTimeout
+	}
+	if debate.Limits.MinContentLength > 0 {
+		limits.MinContentLength = debate.Limits.MinContentLength
+	}
+	if debate.Limits.MaxContentLength > 0 {
+		limits.MaxContentLength = debate.Limits.MaxContentLength
+	}
+	return limits
+}
+
+// encodeFormatLimits stores per-debate limits as JSON, "" for none
+func encodeFormatLimits(limits *FormatLimits) string {
+	if limits == nil {
+		return ""
+	}
+	data, _ := json.Marshal(limits)
+	return string(data)
+}
+
+// decodeFormatLimits reads limits stored by encodeFormatLimits
+func decodeFormatLimits(data string) *FormatLimits {
+	if data == "" {
+		return nil
+	}
+	var limits FormatLimits
+	if json.Unmarshal([]byte(data), &limits) != nil {
+		return nil
+	}
+	return &limits
+}
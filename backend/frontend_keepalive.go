@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// startFrontendKeepalive arms the idle read deadline of a spectator socket and
+// pings it periodically. Any message or pong pushes the deadline back, so only
+// clients that have gone silent are evicted. Close the returned channel to
+// stop pinging.
+func startFrontendKeepalive(conn *WSConn) chan struct{} {
+	extendFrontendDeadline(conn)
+	conn.SetPongHandler(func(string) error {
+		extendFrontendDeadline(conn)
+		return nil
+	})
+
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(time.Duration(config.Frontend.PingInterval) * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				// Control frames may be written concurrently with broadcasts
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)); err != nil {
+					log.Printf("Failed to ping frontend %s: %v", conn.RemoteAddr(), err)
+					return
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return stop
+}
+
+// extendFrontendDeadline restarts the idle timeout of a spectator socket
+func extendFrontendDeadline(conn *WSConn) {
+	conn.SetReadDeadline(time.Now().Add(time.Duration(config.Frontend.IdleTimeout) * time.Second))
+}
+
+// isIdleTimeout reports whether a read failed because the idle deadline passed
+func isIdleTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Gamemaster hooks let a deployment run its own logic at points of a debate
+// without changing the manager. A hook is anything implementing Hook: Go code
+// compiled into the server registers one with RegisterHook from an init
+// function, and hooks.commands in config.yml wraps external programs that
+// read the event as JSON on stdin. on_speech_accepted and on_round_complete
+// are notifications delivered in order on a background queue; on_debate_end
+// runs before the result is saved, and its response may adjust the scores or
+// the winner.
+
+// Hook points
+const (
+	HookSpeechAccepted = "on_speech_accepted"
+	HookRoundComplete  = "on_round_complete"
+	HookDebateEnd      = "on_debate_end"
+)
+
+// hookPoints lists the hook points a hook may subscribe to
+var hookPoints = []string{HookSpeechAccepted, HookRoundComplete, HookDebateEnd}
+
+// HookEvent is what a hook receives
+type HookEvent struct {
+	Hook           string          `json:"hook"`
+	DebateID       string          `json:"debate_id"`
+	Topic          string          `json:"topic"`
+	Format         string          `json:"format"`
+	Round          int             `json:"round"`
+	TotalRounds    int             `json:"total_rounds"`
+	SupportingSide string          `json:"supporting_side"`
+	OpposingSide   string          `json:"opposing_side"`
+	Speech         *DebateLogEntry `json:"speech,omitempty"` // on_speech_accepted
+	Status         string          `json:"status,omitempty"` // on_debate_end
+	Result         *DebateResult   `json:"result,omitempty"` // on_debate_end, before adjustments
+}
+
+// HookResponse lets an on_debate_end hook adjust the result; other hook
+// points ignore it
+type HookResponse struct {
+	ScoreAdjustments map[string]int `json:"score_adjustments,omitempty"` // supporting/opposing -> points added
+	Winner           string         `json:"winner,omitempty"`            // Overrides the winner: supporting, opposing or draw
+	Note             string         `json:"note,omitempty"`              // Logged with the adjustment
+}
+
+// Hook is a gamemaster extension
+type Hook interface {
+	Name() string
+	Wants(hook string) bool
+	Handle(event *HookEvent) (*HookResponse, error)
+}
+
+// HookCommand configures an external program as a hook
+type HookCommand struct {
+	Name    string   `yaml:"name"`
+	Command []string `yaml:"command"` // Program and arguments; the event arrives as JSON on stdin
+	Events  []string `yaml:"events"`  // Hook points to receive; empty receives all
+}
+
+var (
+	hooksMutex sync.RWMutex
+	hooks      []Hook
+	hookQueue  chan HookEvent
+	hookOnce   sync.Once
+)
+
+// RegisterHook adds a hook; call it from an init function or before serving
+func RegisterHook(h Hook) {
+	hooksMutex.Lock()
+	defer hooksMutex.Unlock()
+	hooks = append(hooks, h)
+}
+
+// loadHookCommands registers the hooks configured under hooks.commands
+func loadHookCommands(cfg *Config) {
+	for _, c := range cfg.Hooks.Commands {
+		RegisterHook(&commandHook{cmd: c, timeout: time.Duration(cfg.Hooks.Timeout) * time.Second})
+		log.Printf("Hook %s registered (%s)", c.Name, strings.Join(c.Command, " "))
+	}
+}
+
+// hooksFor returns the registered hooks subscribed to a hook point
+func hooksFor(hook string) []Hook {
+	hooksMutex.RLock()
+	defer hooksMutex.RUnlock()
+	var subscribed []Hook
+	for _, h := range hooks {
+		if h.Wants(hook) {
+			subscribed = append(subscribed, h)
+		}
+	}
+	return subscribed
+}
+
+// notifyHooks queues a notification event; events are delivered one at a
+// time in the order they were queued, and dropped if the queue is full
+func notifyHooks(event HookEvent) {
+	if len(hooksFor(event.Hook)) == 0 {
+		return
+	}
+	hookOnce.Do(func() {
+		hookQueue = make(chan HookEvent, 256)
+		go func() {
+			for event := range hookQueue {
+				for _, h := range hooksFor(event.Hook) {
+					if _, err := h.Handle(&event); err != nil {
+						log.Printf("Hook %s failed on %s for debate %s: %v", h.Name(), event.Hook, event.DebateID, err)
+					}
+				}
+			}
+		}()
+	})
+	select {
+	case hookQueue <- event:
+	default:
+		log.Printf("Hook queue full, dropped %s for debate %s", event.Hook, event.DebateID)
+	}
+}
+
+// hookEvent fills in the debate fields shared by every hook point
+func hookEvent(hook string, activeDebate *ActiveDebate, round int) HookEvent {
+	return HookEvent{
+		Hook:           hook,
+		DebateID:       activeDebate.Debate.ID,
+		Topic:          activeDebate.Debate.Topic,
+		Format:         activeDebate.Debate.Format,
+		Round:          round,
+		TotalRounds:    activeDebate.Debate.TotalRounds,
+		SupportingSide: activeDebate.teamName("supporting"),
+		OpposingSide:   activeDebate.teamName("opposing"),
+	}
+}
+
+// speechesAccepted notifies hooks of speeches that entered the debate log
+func speechesAccepted(activeDebate *ActiveDebate, entries []DebateLogEntry) {
+	for i := range entries {
+		event := hookEvent(HookSpeechAccepted, activeDebate, entries[i].Round)
+		event.Speech = &entries[i]
+		notifyHooks(event)
+	}
+}
+
+// applyDebateEndHooks runs the on_debate_end hooks in registration order and
+// applies their adjustments to the result before it is saved
+func applyDebateEndHooks(activeDebate *ActiveDebate, status string, result *DebateResult) {
+	for _, h := range hooksFor(HookDebateEnd) {
+		event := hookEvent(HookDebateEnd, activeDebate, activeDebate.Debate.CurrentRound)
+		event.Status = status
+		snapshot := *result
+		event.Result = &snapshot
+
+		resp, err := h.Handle(&event)
+		if err != nil {
+			log.Printf("Hook %s failed on %s for debate %s: %v", h.Name(), HookDebateEnd, event.DebateID, err)
+			continue
+		}
+		if resp == nil || (len(resp.ScoreAdjustments) == 0 && resp.Winner == "") {
+			continue
+		}
+		adjustResult(result, resp)
+		log.Printf("Hook %s adjusted debate %s to %d:%d, winner %s %s",
+			h.Name(), event.DebateID, result.SupportingScore, result.OpposingScore, result.Winner, resp.Note)
+	}
+}
+
+// adjustResult applies a hook response to a result. Adjusted scores stay
+// within 0-100 and decide the winner unless the response names one.
+func adjustResult(result *DebateResult, resp *HookResponse) {
+	clamp := func(score int) int {
+		if score < 0 {
+			return 0
+		}
+		if score > 100 {
+			return 100
+		}
+		return score
+	}
+	if len(resp.ScoreAdjustments) > 0 {
+		result.SupportingScore = clamp(result.SupportingScore + resp.ScoreAdjustments["supporting"])
+		result.OpposingScore = clamp(result.OpposingScore + resp.ScoreAdjustments["opposing"])
+		if result.Winner != "none" {
+			switch {
+			case result.SupportingScore > result.OpposingScore:
+				result.Winner = "supporting"
+			case result.OpposingScore > result.SupportingScore:
+				result.Winner = "opposing"
+			default:
+				result.Winner = "draw"
+			}
+		}
+	}
+	switch resp.Winner {
+	case "supporting", "opposing", "draw":
+		result.Winner = resp.Winner
+	}
+}
+
+// commandHook runs an external program per event: the event is written to
+// its stdin as JSON, and a non-empty stdout is read as a HookResponse
+type commandHook struct {
+	cmd     HookCommand
+	timeout time.Duration
+}
+
+func (h *commandHook) Name() string {
+	return h.cmd.Name
+}
+
+func (h *commandHook) Wants(hook string) bool {
+	if len(h.cmd.Events) == 0 {
+		return true
+	}
+	for _, event := range h.cmd.Events {
+		if event == hook {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *commandHook) Handle(event *HookEvent) (*HookResponse, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, h.cmd.Command[0], h.cmd.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(payload)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("timed out after %v", h.timeout)
+		}
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	if len(bytes.TrimSpace(out)) == 0 {
+		return nil, nil
+	}
+	var resp HookResponse
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return nil, fmt.Errorf("invalid response: %v", err)
+	}
+	return &resp, nil
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// defaultPersonaID is used when a house opponent is requested without a persona
+const defaultPersonaID = "calm-academic"
+
+// HouseBot is the built-in AI opponent. It joins debates through the regular
+// bot WebSocket protocol, so the debate manager treats it like any other bot.
+type HouseBot struct {
+	DebateID string
+	Persona  *Persona
+	client   *ChatGPTClient
+
+	conn          *WSConn
+	botIdentifier string
+	debateKey     string
+}
+
+// StartHouseBot connects a house bot with the given persona to a debate
+func StartHouseBot(debateID string, persona *Persona) {
+	hb := &HouseBot{
+		DebateID: debateID,
+		Persona:  persona,
+		client:   houseBotClient.forDebate(debateID, UsageHouseBot),
+	}
+	if err := hb.run(); err != nil {
+		log.Printf("House bot for debate %s stopped: %v", debateID, err)
+	}
+}
+
+// localBotURL returns the bot WebSocket URL of this server
+func localBotURL() string {
+	host := config.Server.Host
+	if host == "" || host == "0.0.0.0" {
+		host = "127.0.0.1"
+	}
+	return fmt.Sprintf("ws://%s:%d/debate", host, config.Server.Port)
+}
+
+// run logs in and plays the debate until it ends
+func (hb *HouseBot) run() error {
+	dialer := websocket.Dialer{Subprotocols: []string{SubprotocolV1JSON}}
+	conn, _, err := dialer.Dial(localBotURL(), internalAuthHeader())
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer conn.Close()
+	hb.conn = newWSConn(conn)
+
+	// Each house bot registers a fresh identity, so it gets in when only
+	// registered bots are admitted
+	name, botUUID, token := "house-"+hb.Persona.ID, uuid.New().String(), generateBotToken()
+	if err := db.RegisterBot(&RegisteredBot{
+		BotUUID:       botUUID,
+		BotName:       name,
+		BotIdentifier: botIdentifier(name, botUUID),
+		TokenHash:     hashBotToken(token),
+	}); err != nil {
+		return fmt.Errorf("failed to register: %w", err)
+	}
+
+	login := createMessage("bot_login", LoginRequest{
+		BotName:  name,
+		BotUUID:  botUUID,
+		DebateID: hb.DebateID,
+		Version:  "2.0",
+		Token:    token,
+	})
+	if err := conn.WriteJSON(login); err != nil {
+		return err
+	}
+
+	for {
+		var msg Message
+		if err := conn.ReadJSON(&msg); err != nil {
+			return err
+		}
+		data, _ := json.Marshal(msg.Data)
+
+		switch msg.Type {
+		case "login_confirmed":
+			var confirmed LoginConfirmed
+			json.Unmarshal(data, &confirmed)
+			hb.botIdentifier = confirmed.BotIdentifier
+			hb.debateKey = confirmed.DebateKey
+			log.Printf("House bot %s (persona %s) joined debate %s", hb.botIdentifier, hb.Persona.ID, hb.DebateID)
+			if confirmed.BlindOpening {
+				hb.speak("opening_submission", &DebateUpdate{
+					Topic:            confirmed.Topic,
+					TotalRounds:      confirmed.TotalRounds,
+					CurrentRound:     1,
+					YourSide:         confirmed.YourSide,
+					MinContentLength: confirmed.MinContentLength,
+					MaxContentLength: confirmed.MaxContentLength,
+				})
+			}
+
+		case "login_rejected":
+			var rejected LoginRejected
+			json.Unmarshal(data, &rejected)
+			return fmt.Errorf("login rejected: %s", rejected.Message)
+
+		case "debate_start", "debate_update":
+			var update DebateUpdate
+			json.Unmarshal(data, &update)
+			if update.NextSpeaker == hb.botIdentifier {
+				hb.speak("debate_speech", &update)
+			}
+
+		case "ping":
+			conn.WriteJSON(createReply(msg.ID, "pong", map[string]string{"client_time": getNow()}))
+
+		case "debate_end":
+			return nil
+		}
+	}
+}
+
+// speak generates and submits a speech (or blind opening) for the current turn
+func (hb *HouseBot) speak(msgType string, update *DebateUpdate) {
+	content := hb.generate(update)
+
+	// Respect the server's length limits
+	if update.MaxContentLength > 0 && len(content) > update.MaxContentLength {
+		content = truncateUTF8(content, update.MaxContentLength)
+	}
+	for len(strings.TrimSpace(content)) < update.MinContentLength {
+		content += "\n\n我方立场明确，论证如上，请对方正面回应。"
+	}
+
+	hb.conn.WriteJSON(createMessage(msgType, DebateSpeech{
+		DebateID:  hb.DebateID,
+		DebateKey: hb.debateKey,
+		Speaker:   hb.botIdentifier,
+		Message:   SpeechMessage{Format: "markdown", Content: content},
+	}))
+}
+
+// generate asks the LLM for a speech in the persona's voice, with a canned fallback
+func (hb *HouseBot) generate(update *DebateUpdate) string {
+	sideName := "正方（支持）"
+	if update.YourSide == "opposing" {
+		sideName = "反方（反对）"
+	}
+
+	if hb.client != nil {
+		var history strings.Builder
+		for _, entry := range update.DebateLog {
+			speaker := "正方"
+			if entry.Side == "opposing" {
+				speaker = "反方"
+			}
+			history.WriteString(fmt.Sprintf("【第%d轮 - %s】\n%s\n\n", entry.Round, speaker, entry.Message.Content))
+		}
+		if history.Len() == 0 {
+			history.WriteString("辩论刚刚开始，请进行开场陈述。")
+		}
+
+		userPrompt := fmt.Sprintf("辩题: %s\n你的立场: %s\n当前轮次: 第%d轮（共%d轮）\n\n历史记录:\n%s\n\n要求: 使用 Markdown 格式，长度 %d-%d 字符，直接输出发言内容。",
+			update.Topic, sideName, update.CurrentRound, update.TotalRounds, history.String(),
+			update.MinContentLength, update.MaxContentLength)
+
+		response, err := hb.client.SendMessage([]ChatGPTMessage{
+			{Role: "system", Content: hb.Persona.SystemPrompt},
+			{Role: "user", Content: userPrompt},
+		})
+		if err == nil && strings.TrimSpace(response) != "" {
+			return response
+		}
+		log.Printf("House bot LLM call failed for debate %s, using fallback speech: %v", hb.DebateID, err)
+	}
+
+	return fmt.Sprintf("## %s 第%d轮发言\n\n关于「%s」，我方作为%s坚持自己的立场。对方的论述尚未给出充分的证据，我方认为核心问题在于论证的前提与实际情况并不相符。",
+		hb.Persona.Name, update.CurrentRound, update.Topic, sideName)
+}
+
+// truncateUTF8 shortens s to at most n bytes without splitting a rune
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
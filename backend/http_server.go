@@ -0,0 +1,27 @@
+package main
+
+import (
+	"net/http"
+	"time"
+)
+
+// newHTTPServer builds the server with the configured connection limits so
+// slow clients cannot hold connections open indefinitely. WebSocket
+// connections clear these deadlines when they are upgraded.
+func newHTTPServer(addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: time.Duration(config.Server.ReadHeaderTimeout) * time.Second,
+		ReadTimeout:       time.Duration(config.Server.ReadTimeout) * time.Second,
+		WriteTimeout:      time.Duration(config.Server.WriteTimeout) * time.Second,
+		IdleTimeout:       time.Duration(config.Server.IdleTimeout) * time.Second,
+		MaxHeaderBytes:    config.Server.MaxHeaderBytes,
+	}
+}
+
+// withHandlerTimeout bounds a database-heavy endpoint. It must not wrap
+// WebSocket handlers, which need to hijack the connection.
+func withHandlerTimeout(handler http.HandlerFunc) http.Handler {
+	return http.TimeoutHandler(handler, time.Duration(config.Server.HandlerTimeout)*time.Second, "Request timed out")
+}
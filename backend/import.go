@@ -0,0 +1,278 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Transcripts recorded on other platforms can be imported as completed
+// debates, so they show up in listings, stats and the debate viewer like any
+// other. Without a supplied result the AI judge scores them.
+
+// maxImportedSpeeches bounds the size of an imported transcript
+const maxImportedSpeeches = 200
+
+// ImportedBot is one side of an imported debate
+type ImportedBot struct {
+	Name string `json:"name"`
+	UUID string `json:"uuid,omitempty"` // Derived from the platform and name when omitted
+}
+
+// ImportedSpeech is one speech of an imported transcript
+type ImportedSpeech struct {
+	Round     int    `json:"round"`
+	Side      string `json:"side"`                // supporting or opposing
+	Format    string `json:"format,omitempty"`    // Defaults to markdown
+	Timestamp string `json:"timestamp,omitempty"` // RFC 3339
+	Content   string `json:"content"`
+
+	Attachments []Attachment `json:"attachments,omitempty"` // Exported for reference; not imported
+}
+
+// ImportedResult is a verdict recorded alongside an imported transcript
+type ImportedResult struct {
+	Winner          string `json:"winner"` // supporting, opposing, draw or none
+	SupportingScore int    `json:"supporting_score"`
+	OpposingScore   int    `json:"opposing_score"`
+	Summary         string `json:"summary,omitempty"`
+}
+
+// ImportDebateRequest is an externally recorded debate transcript
+type ImportDebateRequest struct {
+	Platform   string           `json:"platform"` // Where the debate took place
+	Topic      string           `json:"topic"`
+	Category   string           `json:"category,omitempty"`
+	Ranked     bool             `json:"ranked,omitempty"` // Count towards bot stats; off by default
+	Supporting ImportedBot      `json:"supporting"`
+	Opposing   ImportedBot      `json:"opposing"`
+	Speeches   []ImportedSpeech `json:"speeches"`
+	Result     *ImportedResult  `json:"result,omitempty"` // Judged on import when omitted
+	StartedAt  *time.Time       `json:"started_at,omitempty"`
+}
+
+// ImportDebateResponse is returned once the transcript is stored
+type ImportDebateResponse struct {
+	DebateID string `json:"debate_id"`
+	Speeches int    `json:"speeches"`
+	Judging  bool   `json:"judging"` // The AI judge is scoring the debate in the background
+}
+
+// identifier is the bot identifier, built like the one given at bot login
+func (b ImportedBot) identifier() string {
+	return fmt.Sprintf("%s-%s", b.Name, b.UUID[:8])
+}
+
+// validate checks an import request and fills in its defaults
+func (req *ImportDebateRequest) validate() error {
+	req.Platform = strings.TrimSpace(req.Platform)
+	req.Topic = strings.TrimSpace(req.Topic)
+	if req.Platform == "" || req.Topic == "" {
+		return fmt.Errorf("platform and topic are required")
+	}
+	for _, bot := range []*ImportedBot{&req.Supporting, &req.Opposing} {
+		bot.Name = strings.TrimSpace(bot.Name)
+		if bot.Name == "" {
+			return fmt.Errorf("both bots need a name")
+		}
+		if bot.UUID == "" {
+			// Stable per platform and name, so repeated imports of a bot share its stats
+			bot.UUID = uuid.NewSHA1(uuid.NameSpaceURL, []byte(req.Platform+"/"+bot.Name)).String()
+		} else if len(bot.UUID) < 8 {
+			return fmt.Errorf("bot uuid %q is too short", bot.UUID)
+		}
+	}
+	if req.Supporting.UUID == req.Opposing.UUID {
+		return fmt.Errorf("supporting and opposing bots must differ")
+	}
+
+	if len(req.Speeches) == 0 {
+		return fmt.Errorf("transcript has no speeches")
+	}
+	if len(req.Speeches) > maxImportedSpeeches {
+		return fmt.Errorf("transcript has more than %d speeches", maxImportedSpeeches)
+	}
+	for i := range req.Speeches {
+		speech := &req.Speeches[i]
+		if speech.Side != "supporting" && speech.Side != "opposing" {
+			return fmt.Errorf("speech %d: side must be supporting or opposing", i+1)
+		}
+		if speech.Round < 1 {
+			return fmt.Errorf("speech %d: round must be at least 1", i+1)
+		}
+		if i > 0 && speech.Round < req.Speeches[i-1].Round {
+			return fmt.Errorf("speech %d: speeches must be in round order", i+1)
+		}
+		if strings.TrimSpace(speech.Content) == "" {
+			return fmt.Errorf("speech %d: content is empty", i+1)
+		}
+		if speech.Format == "" {
+			speech.Format = "markdown"
+		}
+		if speech.Timestamp != "" {
+			if _, err := time.Parse(time.RFC3339, speech.Timestamp); err != nil {
+				return fmt.Errorf("speech %d: timestamp must be RFC 3339", i+1)
+			}
+		}
+	}
+
+	if req.Result != nil {
+		switch req.Result.Winner {
+		case "supporting", "opposing", "draw", "none":
+		default:
+			return fmt.Errorf("result winner must be supporting, opposing, draw or none")
+		}
+	}
+	return nil
+}
+
+// handleImportDebate handles POST /api/debate/import
+func handleImportDebate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req ImportDebateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if err := req.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	debate, debateLog, err := importDebate(&req)
+	if err != nil {
+		log.Printf("Failed to import debate from %s: %v", req.Platform, err)
+		http.Error(w, "Failed to import debate", http.StatusInternalServerError)
+		return
+	}
+
+	response := ImportDebateResponse{DebateID: debate.ID, Speeches: len(debateLog)}
+	if req.Result == nil && chatgptClient != nil {
+		response.Judging = true
+		go judgeImportedDebate(debate, debateLog, req.Supporting.identifier(), req.Opposing.identifier())
+	}
+
+	log.Printf("Imported debate %s from %s (%d speeches)", debate.ID, req.Platform, len(debateLog))
+	writeJSONStatus(w, http.StatusCreated, response)
+}
+
+// importDebate stores the debate, its bots, transcript and supplied result
+func importDebate(req *ImportDebateRequest) (*Debate, []DebateLogEntry, error) {
+	now := time.Now()
+	startedAt := now
+	if req.StartedAt != nil {
+		startedAt = *req.StartedAt
+	}
+	lastRound := req.Speeches[len(req.Speeches)-1].Round
+
+	debate := &Debate{
+		ID:           "debate-" + uuid.New().String(),
+		ShortID:      newShortID(),
+		Topic:        req.Topic,
+		TotalRounds:  lastRound,
+		CurrentRound: lastRound,
+		Status:       "completed",
+		Ranked:       req.Ranked,
+		Format:       FormatSequential,
+		Scoring:      ScoringHolistic,
+		Category:     strings.ToLower(strings.TrimSpace(req.Category)),
+		ImportedFrom: req.Platform,
+		CreatedAt:    startedAt,
+		UpdatedAt:    now,
+	}
+	if err := db.CreateDebate(debate); err != nil {
+		return nil, nil, err
+	}
+
+	identifiers := map[string]string{}
+	for side, imported := range map[string]ImportedBot{"supporting": req.Supporting, "opposing": req.Opposing} {
+		bot := &Bot{
+			BotName:       imported.Name,
+			BotUUID:       imported.UUID,
+			BotIdentifier: imported.identifier(),
+			DebateID:      debate.ID,
+			DebateKey:     uuid.New().String(),
+			Side:          side,
+			ClientVersion: "imported",
+			ConnectedAt:   startedAt,
+		}
+		if err := db.AddBot(bot); err != nil {
+			return nil, nil, err
+		}
+		identifiers[side] = bot.BotIdentifier
+	}
+
+	debateLog := make([]DebateLogEntry, 0, len(req.Speeches))
+	for _, speech := range req.Speeches {
+		entry := DebateLogEntry{
+			Round:     speech.Round,
+			Speaker:   identifiers[speech.Side],
+			Side:      speech.Side,
+			Timestamp: speech.Timestamp,
+			Message:   SpeechMessage{Format: speech.Format, Content: speech.Content},
+		}
+		if entry.Timestamp == "" {
+			entry.Timestamp = startedAt.Format(time.RFC3339)
+		}
+		if err := db.AddDebateLog(&entry, debate.ID); err != nil {
+			return nil, nil, err
+		}
+		debateLog = append(debateLog, entry)
+	}
+
+	if req.Result != nil {
+		result := &DebateResult{
+			Winner:          req.Result.Winner,
+			SupportingScore: req.Result.SupportingScore,
+			OpposingScore:   req.Result.OpposingScore,
+			Summary:         SpeechMessage{Format: "markdown", Content: req.Result.Summary},
+			Reason:          "completed",
+			Source:          ResultSourceImport,
+			ProducedBy:      req.Platform,
+		}
+		if err := db.SaveDebateResult(debate.ID, result); err != nil {
+			return nil, nil, err
+		}
+		go checkSideBias()
+	}
+	return debate, debateLog, nil
+}
+
+// judgeImportedDebate runs the AI judge on an imported transcript, sharing the
+// judging queue with debates that end live
+func judgeImportedDebate(debate *Debate, debateLog []DebateLogEntry, supportingBot, opposingBot string) {
+	var result *DebateResult
+	var err error
+	debateManager.judgeQueue.Run(debate, debateLog, func() {
+		result, err = chatgptClient.forDebate(debate.ID, UsageJudge).JudgeDebate(debate.Topic, debateLog, supportingBot, opposingBot, JudgeOptions{Style: verdictStyleFor(debate)})
+	})
+	if err != nil {
+		log.Printf("Failed to judge imported debate %s: %v", debate.ID, err)
+		return
+	}
+	result.Reason = "completed"
+	if err := db.SaveDebateResult(debate.ID, result); err != nil {
+		log.Printf("Failed to save result of imported debate %s: %v", debate.ID, err)
+		return
+	}
+	if len(result.Citations) > 0 {
+		db.SaveCitations(debate.ID, result.Citations)
+	}
+	if len(result.Panel) > 0 {
+		db.SaveJudgePanel(debate.ID, result.Panel)
+	}
+	if len(result.Runs) > 0 {
+		db.SaveJudgeRuns(debate.ID, result.Runs)
+	}
+	go checkSideBias()
+	log.Printf("Judged imported debate %s: %s wins", debate.ID, result.Winner)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/url"
+	"time"
+)
+
+// The judge may list fallback model profiles under chatgpt.judge.fallback.
+// When a judge call to its own profile errors or times out, the same prompt
+// goes to each fallback in order, and only when all of them fail does the
+// debate fall back to heuristic scoring. A refused call (LLM budget
+// exhausted) is not retried, the budget covers every provider. The result
+// records the model that actually answered.
+
+// newJudgeFallbacks creates the judge's fallback clients from chatgpt.judge.fallback
+func newJudgeFallbacks() []*ChatGPTClient {
+	judge := config.ChatGPT.Judge
+	fallbacks := make([]*ChatGPTClient, 0, len(judge.Fallback))
+	for _, name := range judge.Fallback {
+		fallbacks = append(fallbacks, newProfileClient(name, judge.MaxTokens, judge.Temperature))
+	}
+	return fallbacks
+}
+
+// judgeCall sends a judge prompt, failing over to the fallback providers in
+// order. It returns the client that answered. A non-nil stream receives the
+// summary of each attempt as it is written, see judge_stream.go.
+func (c *ChatGPTClient) judgeCall(messages []ChatGPTMessage, stream func(delta string, restart bool)) (string, *ChatGPTClient, error) {
+	client := c
+	response, err := client.timedJudgeCall(messages, stream)
+
+	for _, fallback := range c.Fallbacks {
+		if err == nil || errors.Is(err, errLLMBudget) {
+			break
+		}
+		log.Printf("Judge provider %s (%s) failed, falling back to %s (%s): %v",
+			providerHost(client), client.Model, providerHost(fallback), fallback.Model, err)
+		client = fallback.forDebate(c.DebateID, c.Purpose)
+		response, err = client.timedJudgeCall(messages, stream)
+	}
+	return response, client, err
+}
+
+// timedJudgeCall makes one judge call and records its latency
+func (c *ChatGPTClient) timedJudgeCall(messages []ChatGPTMessage, stream func(delta string, restart bool)) (string, error) {
+	start := time.Now()
+	var response string
+	var err error
+	switch {
+	case c.Mock:
+		response, err = mockJudgeCall(messages, stream)
+	case stream != nil:
+		response, err = c.StreamMessage(messages, newSummaryStream(stream).Write)
+	default:
+		response, err = c.SendMessage(messages)
+	}
+	judgeMetrics.ObserveCall(time.Since(start), err)
+	return response, err
+}
+
+// providerHost returns the host of a client's API
+func providerHost(c *ChatGPTClient) string {
+	if u, err := url.Parse(c.APIURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return c.APIURL
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// latencyWindow is how many recent judge calls latency percentiles are computed over
+const latencyWindow = 1000
+
+// Verdict sources recorded for each debate judgement
+const (
+	VerdictAI       = "ai"       // Parsed AI verdict
+	VerdictUnparsed = "unparsed" // AI replied but the verdict could not be parsed (recorded as a draw)
+	VerdictFallback = "fallback" // AI skipped or failed; speech-count scoring used
+)
+
+// JudgeMetrics tracks judge call latency, failures and verdict sources in memory
+type JudgeMetrics struct {
+	mutex     sync.Mutex
+	latencies []time.Duration // Ring buffer of the most recent call latencies
+	next      int
+	calls     int
+	totalTime time.Duration
+	failures  map[string]int // error class -> count
+	verdicts  map[string]int // verdict source -> count
+}
+
+// JudgeMetricsReport is the payload of the admin judge metrics endpoint
+type JudgeMetricsReport struct {
+	Calls          int                `json:"calls"`
+	Failures       map[string]int     `json:"failures"`
+	FailureRate    float64            `json:"failure_rate"`
+	LatencyMs      map[string]int64   `json:"latency_ms"` // p50, p90, p99, max over the recent window
+	Verdicts       map[string]int     `json:"verdicts"`
+	FallbackRate   float64            `json:"fallback_rate"`
+	Winners        map[string]int     `json:"winners"`         // Stored ranked results by winner side
+	SupportingRate float64            `json:"supporting_rate"` // Share of decisive results won by the supporting side
+	AverageScores  map[string]float64 `json:"average_scores"`
+	QueueLength    int                `json:"queue_length"` // Debates waiting for a free judge worker
+}
+
+// NewJudgeMetrics creates an empty metrics tracker
+func NewJudgeMetrics() *JudgeMetrics {
+	return &JudgeMetrics{
+		latencies: make([]time.Duration, 0, latencyWindow),
+		failures:  make(map[string]int),
+		verdicts:  make(map[string]int),
+	}
+}
+
+// ObserveCall records the latency and outcome of one judge LLM call
+func (m *JudgeMetrics) ObserveCall(latency time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.calls++
+	m.totalTime += latency
+	if len(m.latencies) < latencyWindow {
+		m.latencies = append(m.latencies, latency)
+	} else {
+		m.latencies[m.next] = latency
+		m.next = (m.next + 1) % latencyWindow
+	}
+	if err != nil {
+		m.failures[classifyJudgeError(err)]++
+	}
+}
+
+// ObserveVerdict records where a debate verdict came from
+func (m *JudgeMetrics) ObserveVerdict(source string) {
+	if m == nil {
+		return
+	}
+	m.mutex.Lock()
+	m.verdicts[source]++
+	m.mutex.Unlock()
+}
+
+// classifyJudgeError buckets a judge call error for the failure counters
+func classifyJudgeError(err error) string {
+	var statusErr *apiStatusError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, errLLMBudget):
+		return "budget"
+	case errors.Is(err, errAPIKeyMissing):
+		return "not_configured"
+	case errors.As(err, &statusErr):
+		if statusErr.StatusCode == http.StatusTooManyRequests {
+			return "rate_limited"
+		}
+		if statusErr.StatusCode >= 500 {
+			return "http_5xx"
+		}
+		return "http_4xx"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.As(err, &netErr):
+		return "network"
+	case errors.Is(err, errEmptyResponse):
+		return "empty_response"
+	default:
+		return "other"
+	}
+}
+
+// percentile returns the p-th percentile of sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(float64(len(sorted)-1) * p)
+	return sorted[idx]
+}
+
+// snapshot copies the counters and sorted latency window
+func (m *JudgeMetrics) snapshot() (calls int, total time.Duration, sorted []time.Duration, failures, verdicts map[string]int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	sorted = append([]time.Duration(nil), m.latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	failures = make(map[string]int, len(m.failures))
+	for k, v := range m.failures {
+		failures[k] = v
+	}
+	verdicts = make(map[string]int, len(m.verdicts))
+	for k, v := range m.verdicts {
+		verdicts[k] = v
+	}
+	return m.calls, m.totalTime, sorted, failures, verdicts
+}
+
+// Report builds the admin report, combining in-memory counters with stored results
+func (m *JudgeMetrics) Report() (*JudgeMetricsReport, error) {
+	calls, _, sorted, failures, verdicts := m.snapshot()
+
+	report := &JudgeMetricsReport{
+		Calls:     calls,
+		Failures:  failures,
+		LatencyMs: make(map[string]int64),
+		Verdicts:  verdicts,
+	}
+	if calls > 0 {
+		failed := 0
+		for _, count := range failures {
+			failed += count
+		}
+		report.FailureRate = float64(failed) / float64(calls)
+	}
+	report.LatencyMs["p50"] = percentile(sorted, 0.50).Milliseconds()
+	report.LatencyMs["p90"] = percentile(sorted, 0.90).Milliseconds()
+	report.LatencyMs["p99"] = percentile(sorted, 0.99).Milliseconds()
+	report.LatencyMs["max"] = percentile(sorted, 1).Milliseconds()
+
+	judged := 0
+	for _, count := range verdicts {
+		judged += count
+	}
+	if judged > 0 {
+		report.FallbackRate = float64(verdicts[VerdictFallback]+verdicts[VerdictUnparsed]) / float64(judged)
+	}
+
+	winners, err := db.GetWinnerDistribution()
+	if err != nil {
+		return nil, err
+	}
+	report.Winners = winners.Counts
+	if decisive := winners.Counts["supporting"] + winners.Counts["opposing"]; decisive > 0 {
+		report.SupportingRate = float64(winners.Counts["supporting"]) / float64(decisive)
+	}
+	report.AverageScores = map[string]float64{
+		"supporting": winners.AvgSupportingScore,
+		"opposing":   winners.AvgOpposingScore,
+	}
+	return report, nil
+}
+
+// handleAdminJudgeMetrics returns judge latency, failure and outcome metrics
+func handleAdminJudgeMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	report, err := judgeMetrics.Report()
+	if err != nil {
+		http.Error(w, "Failed to fetch metrics", http.StatusInternalServerError)
+		return
+	}
+	report.QueueLength = debateManager.judgeQueue.Length()
+	writeJSON(w, report)
+}
+
+// handleMetrics exposes judge metrics in the Prometheus text format
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	calls, total, sorted, failures, verdicts := judgeMetrics.snapshot()
+
+	var out strings.Builder
+	out.WriteString("# HELP debate_judge_latency_seconds Judge LLM call latency over the recent window.\n")
+	out.WriteString("# TYPE debate_judge_latency_seconds summary\n")
+	for _, q := range []float64{0.5, 0.9, 0.99} {
+		fmt.Fprintf(&out, "debate_judge_latency_seconds{quantile=\"%g\"} %g\n", q, percentile(sorted, q).Seconds())
+	}
+	fmt.Fprintf(&out, "debate_judge_latency_seconds_sum %g\n", total.Seconds())
+	fmt.Fprintf(&out, "debate_judge_latency_seconds_count %d\n", calls)
+
+	out.WriteString("# HELP debate_judge_failures_total Failed judge LLM calls by error class.\n")
+	out.WriteString("# TYPE debate_judge_failures_total counter\n")
+	writeLabeledCounts(&out, "debate_judge_failures_total", "class", failures)
+
+	out.WriteString("# HELP debate_judge_verdicts_total Debate verdicts by source (ai, unparsed, fallback).\n")
+	out.WriteString("# TYPE debate_judge_verdicts_total counter\n")
+	writeLabeledCounts(&out, "debate_judge_verdicts_total", "source", verdicts)
+
+	if winners, err := db.GetWinnerDistribution(); err == nil {
+		out.WriteString("# HELP debate_results_winner Stored ranked debate results by winning side.\n")
+		out.WriteString("# TYPE debate_results_winner gauge\n")
+		writeLabeledCounts(&out, "debate_results_winner", "winner", winners.Counts)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write([]byte(out.String()))
+}
+
+// writeLabeledCounts writes one sample per label value in a stable order
+func writeLabeledCounts(out *strings.Builder, name, label string, counts map[string]int) {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(out, "%s{%s=%q} %d\n", name, label, k, counts[k])
+	}
+}
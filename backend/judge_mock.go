@@ -0,0 +1,348 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// With chatgpt.judge.provider set to mock the judge never calls an LLM. It
+// reads the speeches back out of the judge prompt and scores each side on
+// simple features of its text: length, evidence markers, rebuttal markers
+// and structure markers. The answer is built in the format the real judge is
+// asked for and goes through the same parsing, panel, close-call and
+// streaming paths, so end-to-end tests and demos exercise the whole judging
+// pipeline without network calls or API keys. The same transcript always
+// gets the same verdict.
+
+// Judge providers for chatgpt.judge.provider
+const (
+	JudgeProviderOpenAI = "openai" // Chat completions API of the judge's model profile, the default
+	JudgeProviderMock   = "mock"   // Deterministic verdicts from transcript features
+)
+
+// mockJudgeModel is the judge model recorded with mock verdicts
+const mockJudgeModel = "mock-judge"
+
+// newMockJudge creates the judge client for chatgpt.judge.provider mock
+func newMockJudge() *ChatGPTClient {
+	return &ChatGPTClient{Model: mockJudgeModel, Mock: true}
+}
+
+var (
+	mockSpeechHeader = regexp.MustCompile(`(?m)^【第(\d+)轮 - (正方|反方)】\n`)
+	mockRoundPrompt  = regexp.MustCompile(`请评判以下辩论的第(\d+)轮`)
+)
+
+// Markers counted in a side's speeches, lowercase
+var (
+	mockEvidenceMarkers  = []string{"数据", "研究", "统计", "报告", "例如", "比如", "案例", "%", "according to", "study", "data", "for example"}
+	mockRebuttalMarkers  = []string{"对方", "反驳", "然而", "但是", "并非", "opponent", "however", "but "}
+	mockStructureMarkers = []string{"首先", "其次", "最后", "因此", "综上", "第一", "第二", "first", "second", "finally", "therefore"}
+)
+
+// mockSpeech is one speech read back from a judge prompt
+type mockSpeech struct {
+	Round   int
+	Side    string
+	Content string
+}
+
+// mockFeatures are what the mock judge measures in one side's speeches
+type mockFeatures struct {
+	Speeches  int
+	Runes     int
+	Sentences int
+	Evidence  int
+	Rebuttal  int
+	Structure int
+}
+
+// mockJudgeCall answers a judge prompt with the mock judge, feeding a
+// non-nil stream the summary as the real judge's streaming call would
+func mockJudgeCall(messages []ChatGPTMessage, stream func(delta string, restart bool)) (string, error) {
+	var system, user string
+	for _, m := range messages {
+		switch m.Role {
+		case "system":
+			system += m.Content
+		case "user":
+			user += m.Content
+		}
+	}
+	answer := mockJudgeAnswer(system, user)
+	if stream != nil {
+		s := newSummaryStream(stream)
+		runes := []rune(answer)
+		for i := 0; i < len(runes); i += 16 {
+			s.Write(string(runes[i:min(i+16, len(runes))]))
+		}
+	}
+	return answer, nil
+}
+
+// mockJudgeAnswer builds the verdict JSON for a judge prompt
+func mockJudgeAnswer(system, user string) string {
+	speeches := mockSpeeches(user)
+	if m := mockRoundPrompt.FindStringSubmatch(user); m != nil {
+		round, _ := strconv.Atoi(m[1])
+		var inRound []mockSpeech
+		for _, s := range speeches {
+			if s.Round == round {
+				inRound = append(inRound, s)
+			}
+		}
+		speeches = inRound
+	}
+
+	features := map[string]mockFeatures{
+		"supporting": mockMeasure(speeches, "supporting"),
+		"opposing":   mockMeasure(speeches, "opposing"),
+	}
+
+	criteria := criteriaOf(RubricDefault)
+	var breakdown []CriterionScore
+	supportingScore, opposingScore := 0, 0
+	for _, c := range criteria {
+		score := CriterionScore{
+			Criterion:  c.ID,
+			Name:       c.Name,
+			Supporting: mockCriterionScore(c, features["supporting"]),
+			Opposing:   mockCriterionScore(c, features["opposing"]),
+		}
+		breakdown = append(breakdown, score)
+		supportingScore += score.Supporting
+		opposingScore += score.Opposing
+	}
+
+	winner := "draw"
+	if supportingScore > opposingScore {
+		winner = "supporting"
+	} else if opposingScore > supportingScore {
+		winner = "opposing"
+	}
+
+	answer := map[string]interface{}{
+		"winner":           winner,
+		"supporting_score": supportingScore,
+		"opposing_score":   opposingScore,
+		"summary":          mockSummary(winner, features, breakdown),
+		"breakdown":        breakdown,
+		"citations":        mockCitations(speeches),
+	}
+	if strings.Contains(system, feedbackRubric) {
+		answer["feedback"] = map[string]BotFeedback{
+			"supporting": mockFeedback(breakdown, func(s CriterionScore) (int, int) { return s.Supporting, s.Opposing }),
+			"opposing":   mockFeedback(breakdown, func(s CriterionScore) (int, int) { return s.Opposing, s.Supporting }),
+		}
+	}
+	data, _ := json.Marshal(answer)
+	return string(data)
+}
+
+// mockSpeeches reads the speeches out of a judge prompt's transcript
+func mockSpeeches(prompt string) []mockSpeech {
+	// Side channel signals follow the speeches and are not scored
+	if i := strings.Index(prompt, "双方私下沟通记录"); i >= 0 {
+		prompt = prompt[:i]
+	}
+	headers := mockSpeechHeader.FindAllStringSubmatchIndex(prompt, -1)
+	speeches := make([]mockSpeech, 0, len(headers))
+	for i, h := range headers {
+		end := len(prompt)
+		if i+1 < len(headers) {
+			end = headers[i+1][0]
+		}
+		round, _ := strconv.Atoi(prompt[h[2]:h[3]])
+		side := "supporting"
+		if prompt[h[4]:h[5]] == "反方" {
+			side = "opposing"
+		}
+		speeches = append(speeches, mockSpeech{Round: round, Side: side, Content: strings.TrimSpace(prompt[h[1]:end])})
+	}
+	return speeches
+}
+
+// mockMeasure measures one side's speeches
+func mockMeasure(speeches []mockSpeech, side string) mockFeatures {
+	var f mockFeatures
+	for _, s := range speeches {
+		if s.Side != side {
+			continue
+		}
+		text := strings.ToLower(s.Content)
+		f.Speeches++
+		f.Runes += utf8.RuneCountInString(s.Content)
+		f.Sentences += len(mockSentences(s.Content))
+		f.Evidence += mockCount(text, mockEvidenceMarkers)
+		f.Rebuttal += mockCount(text, mockRebuttalMarkers)
+		f.Structure += mockCount(text, mockStructureMarkers)
+		for _, line := range strings.Split(text, "\n") {
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "#") || (line != "" && line[0] >= '1' && line[0] <= '9' && strings.HasPrefix(line[1:], ".")) {
+				f.Structure++
+			}
+		}
+	}
+	return f
+}
+
+// mockCount counts the occurrences of markers in text
+func mockCount(text string, markers []string) int {
+	n := 0
+	for _, m := range markers {
+		n += strings.Count(text, m)
+	}
+	return n
+}
+
+// mockSentences splits a speech into its sentences
+func mockSentences(content string) []string {
+	var sentences []string
+	for _, s := range strings.FieldsFunc(content, func(r rune) bool {
+		return strings.ContainsRune("。！？!?.\n", r)
+	}) {
+		if s = strings.TrimSpace(s); s != "" {
+			sentences = append(sentences, s)
+		}
+	}
+	return sentences
+}
+
+// mockSaturate maps a count onto [0, 1), reaching half at half
+func mockSaturate(x, half int) float64 {
+	return float64(x) / float64(x+half)
+}
+
+// mockStrength rates a side on one criterion, between 0 and 1
+func mockStrength(id string, f mockFeatures) float64 {
+	if f.Speeches == 0 {
+		return 0
+	}
+	switch id {
+	case "argument_quality":
+		return mockSaturate(f.Runes/f.Speeches, 300)
+	case "evidence":
+		return mockSaturate(f.Evidence, 3)
+	case "rebuttal":
+		return mockSaturate(f.Rebuttal, 2)
+	case "delivery":
+		return mockSaturate(f.Sentences, 8)
+	case "structure":
+		return mockSaturate(f.Structure, 2)
+	}
+	// Criteria of a configured rubric the mock does not know get the average
+	var sum float64
+	for _, known := range []string{"argument_quality", "evidence", "rebuttal", "delivery", "structure"} {
+		sum += mockStrength(known, f)
+	}
+	return sum / 5
+}
+
+// mockCriterionScore scores a side on a criterion out of its weight; a side
+// that spoke at all gets at least half of it
+func mockCriterionScore(c RubricCriterion, f mockFeatures) int {
+	if f.Speeches == 0 {
+		return 0
+	}
+	return int(float64(c.Weight)*(0.5+0.5*mockStrength(c.ID, f)) + 0.5)
+}
+
+// mockSummary explains a mock verdict
+func mockSummary(winner string, features map[string]mockFeatures, breakdown []CriterionScore) string {
+	var b strings.Builder
+	b.WriteString("模拟评委根据发言特征计分（未调用 AI 模型）。\n\n")
+	for _, side := range []string{"supporting", "opposing"} {
+		f := features[side]
+		b.WriteString(fmt.Sprintf("- %s: %d 篇发言，共 %d 字，论据标记 %d 处，反驳标记 %d 处，结构标记 %d 处\n",
+			mockSideName(side), f.Speeches, f.Runes, f.Evidence, f.Rebuttal, f.Structure))
+	}
+	if winner == "draw" {
+		b.WriteString("\n双方得分相同，判为平局。")
+		return b.String()
+	}
+	lead, gap := "", 0
+	for _, s := range breakdown {
+		d := s.Supporting - s.Opposing
+		if winner == "opposing" {
+			d = -d
+		}
+		if d > gap {
+			lead, gap = s.Name, d
+		}
+	}
+	b.WriteString(fmt.Sprintf("\n%s获胜", mockSideName(winner)))
+	if lead != "" {
+		b.WriteString(fmt.Sprintf("，在「%s」上领先最多", lead))
+	}
+	b.WriteString("。")
+	return b.String()
+}
+
+// mockCitations quotes the first sentence of each side's longest speech
+func mockCitations(speeches []mockSpeech) []VerdictCitation {
+	citations := []VerdictCitation{}
+	for _, side := range []string{"supporting", "opposing"} {
+		var longest *mockSpeech
+		for i := range speeches {
+			s := &speeches[i]
+			if s.Side == side && (longest == nil || utf8.RuneCountInString(s.Content) > utf8.RuneCountInString(longest.Content)) {
+				longest = s
+			}
+		}
+		if longest == nil {
+			continue
+		}
+		sentences := mockSentences(longest.Content)
+		if len(sentences) == 0 {
+			continue
+		}
+		quote := []rune(sentences[0])
+		if len(quote) > 60 {
+			quote = quote[:60]
+		}
+		citations = append(citations, VerdictCitation{
+			Point: mockSideName(side) + "篇幅最长的一篇发言",
+			Round: longest.Round,
+			Side:  side,
+			Quote: string(quote),
+		})
+	}
+	return citations
+}
+
+// mockFeedback names a side's best and worst criteria relative to its
+// opponent; scores returns the side's score and its opponent's
+func mockFeedback(breakdown []CriterionScore, scores func(CriterionScore) (int, int)) BotFeedback {
+	feedback := BotFeedback{Strengths: []string{}, Weaknesses: []string{}, MissedRebuttals: []string{}}
+	best, worst := -1, -1
+	var bestGap, worstGap int
+	for i, s := range breakdown {
+		own, other := scores(s)
+		gap := own - other
+		if best < 0 || gap > bestGap {
+			best, bestGap = i, gap
+		}
+		if worst < 0 || gap < worstGap {
+			worst, worstGap = i, gap
+		}
+	}
+	if best >= 0 && bestGap > 0 {
+		feedback.Strengths = append(feedback.Strengths, fmt.Sprintf("「%s」得分高于对方", breakdown[best].Name))
+	}
+	if worst >= 0 && worstGap < 0 {
+		feedback.Weaknesses = append(feedback.Weaknesses, fmt.Sprintf("「%s」得分低于对方", breakdown[worst].Name))
+	}
+	return feedback
+}
+
+// mockSideName is a side's name as the judge prompt writes it
+func mockSideName(side string) string {
+	if side == "opposing" {
+		return "反方"
+	}
+	return "正方"
+}
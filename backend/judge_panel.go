@@ -0,0 +1,241 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+)
+
+// JudgePersona is one member of the judge panel. Each persona judges the
+// debate with its own framing and the final verdict averages their scores.
+type JudgePersona struct {
+	ID     string  `yaml:"id"`
+	Name   string  `yaml:"name"`   // Defaults to the built-in persona's name
+	Prompt string  `yaml:"prompt"` // Framing placed before the rubric; defaults to the built-in persona's
+	Weight float64 `yaml:"weight"` // Share in the aggregated scores, defaults to 1
+}
+
+// builtinJudgePersonas can be listed in chatgpt.judge.panel by id alone
+var builtinJudgePersonas = map[string]JudgePersona{
+	"coach": {
+		Name:   "辩论教练",
+		Prompt: "你是一位经验丰富的正式辩论教练，重视论证结构、规则意识、攻防是否到位。请以教练的眼光严格评判。",
+	},
+	"audience": {
+		Name:   "普通观众",
+		Prompt: "你是一位没有专业背景的普通观众，重视表达是否清楚易懂、是否让你信服。请以普通观众的感受评判。",
+	},
+	"expert": {
+		Name:   "领域专家",
+		Prompt: "你是辩题所涉领域的专家，重视事实是否准确、论据是否可靠、是否有概念错误。请以专家的标准评判。",
+	},
+}
+
+// PanelVerdict is one judge persona's verdict on a debate
+type PanelVerdict struct {
+	PersonaID       string  `json:"persona_id"`
+	PersonaName     string  `json:"persona_name"`
+	Weight          float64 `json:"weight"`
+	Winner          string  `json:"winner"`
+	SupportingScore int     `json:"supporting_score"`
+	OpposingScore   int     `json:"opposing_score"`
+	Summary         string  `json:"summary"`
+}
+
+// resolveJudgePanel fills in built-in persona defaults and checks the panel
+func resolveJudgePanel(panel []JudgePersona) error {
+	seen := map[string]bool{}
+	for i := range panel {
+		p := &panel[i]
+		if p.ID == "" {
+			return fmt.Errorf("judge panel entry %d has no id", i+1)
+		}
+		if seen[p.ID] {
+			return fmt.Errorf("judge persona %q is listed twice", p.ID)
+		}
+		seen[p.ID] = true
+
+		builtin, known := builtinJudgePersonas[p.ID]
+		if p.Prompt == "" {
+			if !known {
+				return fmt.Errorf("judge persona %q needs a prompt", p.ID)
+			}
+			p.Prompt = builtin.Prompt
+		}
+		if p.Name == "" {
+			p.Name = builtin.Name
+			if p.Name == "" {
+				p.Name = p.ID
+			}
+		}
+		if p.Weight < 0 {
+			return fmt.Errorf("judge persona %q has a negative weight", p.ID)
+		}
+		if p.Weight == 0 {
+			p.Weight = 1
+		}
+	}
+	return nil
+}
+
+// judgeWithPanel judges the debate once per persona and aggregates the
+// verdicts. Personas whose call fails are left out of the aggregate.
+func (c *ChatGPTClient) judgeWithPanel(topic string, debateLog []DebateLogEntry, supportingBot, opposingBot string, opts JudgeOptions, panel []JudgePersona) (*DebateResult, error) {
+	var base *DebateResult
+	verdicts := []PanelVerdict{}
+	citations := []VerdictCitation{}
+	var breakdowns [][]CriterionScore
+	var feedback map[string]BotFeedback
+	var lastErr error
+
+	for _, persona := range panel {
+		personaOpts := opts
+		personaOpts.Persona = persona.Prompt
+		result, err := c.JudgeDebateWith(topic, debateLog, supportingBot, opposingBot, personaOpts)
+		if err != nil {
+			log.Printf("Judge persona %s failed: %v", persona.ID, err)
+			lastErr = err
+			continue
+		}
+		if base == nil {
+			base = result
+		}
+		verdicts = append(verdicts, PanelVerdict{
+			PersonaID:       persona.ID,
+			PersonaName:     persona.Name,
+			Weight:          persona.Weight,
+			Winner:          result.Winner,
+			SupportingScore: result.SupportingScore,
+			OpposingScore:   result.OpposingScore,
+			Summary:         result.Summary.Content,
+		})
+		citations = append(citations, result.Citations...)
+		breakdowns = append(breakdowns, result.Breakdown)
+		if len(result.Feedback) > 0 {
+			feedback = mergeFeedback(feedback, result.Feedback)
+		}
+	}
+	if base == nil {
+		return nil, lastErr
+	}
+
+	var weights, supporting, opposing float64
+	for _, v := range verdicts {
+		weights += v.Weight
+		supporting += v.Weight * float64(v.SupportingScore)
+		opposing += v.Weight * float64(v.OpposingScore)
+	}
+	result := &DebateResult{
+		SupportingScore: int(math.Round(supporting / weights)),
+		OpposingScore:   int(math.Round(opposing / weights)),
+		Summary:         SpeechMessage{Format: "markdown", Content: panelSummary(verdicts)},
+		Citations:       citations,
+		Breakdown:       averageBreakdown(verdicts, breakdowns),
+		JudgeModel:      base.JudgeModel,
+		Rubric:          base.Rubric,
+		Source:          base.Source,
+		ProducedBy:      base.ProducedBy,
+		Panel:           verdicts,
+		Feedback:        feedback,
+	}
+	switch {
+	case result.SupportingScore > result.OpposingScore:
+		result.Winner = "supporting"
+	case result.OpposingScore > result.SupportingScore:
+		result.Winner = "opposing"
+	default:
+		result.Winner = "draw"
+	}
+	return result, nil
+}
+
+// averageBreakdown averages the personas' criterion scores by their weights,
+// keeping the criteria in the order they first appear
+func averageBreakdown(verdicts []PanelVerdict, breakdowns [][]CriterionScore) []CriterionScore {
+	type total struct{ weight, supporting, opposing float64 }
+	totals := map[string]*total{}
+	var order []string
+	for i, breakdown := range breakdowns {
+		weight := verdicts[i].Weight
+		for _, score := range breakdown {
+			t, seen := totals[score.Criterion]
+			if !seen {
+				t = &total{}
+				totals[score.Criterion] = t
+				order = append(order, score.Criterion)
+			}
+			t.weight += weight
+			t.supporting += weight * float64(score.Supporting)
+			t.opposing += weight * float64(score.Opposing)
+		}
+	}
+	var averaged []CriterionScore
+	for _, criterion := range order {
+		t := totals[criterion]
+		averaged = append(averaged, CriterionScore{
+			Criterion:  criterion,
+			Supporting: int(math.Round(t.supporting / t.weight)),
+			Opposing:   int(math.Round(t.opposing / t.weight)),
+		})
+	}
+	return averaged
+}
+
+// panelSummary renders the panel's scores followed by each persona's summary
+func panelSummary(verdicts []PanelVerdict) string {
+	var b strings.Builder
+	b.WriteString("## 评委团评判\n\n| 评委 | 权重 | 胜方 | 正方得分 | 反方得分 |\n|------|------|------|----------|----------|\n")
+	for _, v := range verdicts {
+		fmt.Fprintf(&b, "| %s | %g | %s | %d | %d |\n", v.PersonaName, v.Weight, roundWinnerName(v.Winner), v.SupportingScore, v.OpposingScore)
+	}
+	for _, v := range verdicts {
+		fmt.Fprintf(&b, "\n### %s\n\n%s\n", v.PersonaName, v.Summary)
+	}
+	return b.String()
+}
+
+// SaveJudgePanel replaces the panel verdicts of a debate
+func (d *Database) SaveJudgePanel(debateID string, verdicts []PanelVerdict) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM judge_panel WHERE debate_id = ?`, debateID); err != nil {
+		return err
+	}
+	for i, v := range verdicts {
+		_, err := tx.Exec(`INSERT INTO judge_panel (debate_id, position, persona_id, persona_name, weight, winner,
+		                       supporting_score, opposing_score, summary)
+		                   VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			debateID, i, v.PersonaID, v.PersonaName, v.Weight, v.Winner, v.SupportingScore, v.OpposingScore, v.Summary)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetJudgePanel retrieves the panel verdicts of a debate in panel order
+func (d *Database) GetJudgePanel(debateID string) ([]PanelVerdict, error) {
+	query := `SELECT persona_id, persona_name, weight, winner, supporting_score, opposing_score, summary
+	          FROM judge_panel WHERE debate_id = ? ORDER BY position ASC`
+
+	rows, err := d.db.Query(query, debateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	verdicts := []PanelVerdict{}
+	for rows.Next() {
+		var v PanelVerdict
+		if err := rows.Scan(&v.PersonaID, &v.PersonaName, &v.Weight, &v.Winner, &v.SupportingScore, &v.OpposingScore, &v.Summary); err != nil {
+			return nil, err
+		}
+		verdicts = append(verdicts, v)
+	}
+	return verdicts, rows.Err()
+}
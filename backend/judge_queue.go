@@ -0,0 +1,120 @@
+package main
+
+import (
+	"sort"
+	"sync"
+)
+
+// Judging progress states
+const (
+	JudgingQueued  = "queued"
+	JudgingRunning = "judging"
+)
+
+// JudgeQueue limits how many debates are judged at once. When many debates
+// end together, ranked debates go first, then smaller transcripts, then the
+// earliest arrivals.
+type JudgeQueue struct {
+	workers int
+	notify  func(debateID, status string, position, queueLength int)
+
+	mutex   sync.Mutex
+	pending []*judgeJob
+	active  int
+	seq     uint64
+}
+
+type judgeJob struct {
+	debateID string
+	ranked   bool
+	size     int
+	seq      uint64
+	run      func()
+	done     chan struct{}
+}
+
+// NewJudgeQueue creates a queue running up to workers judgements at a time.
+// notify is called whenever a debate's place in the queue changes.
+func NewJudgeQueue(workers int, notify func(debateID, status string, position, queueLength int)) *JudgeQueue {
+	if workers < 1 {
+		workers = 1
+	}
+	return &JudgeQueue{workers: workers, notify: notify}
+}
+
+// Run queues the judgement of a debate and blocks until judge has run
+func (q *JudgeQueue) Run(debate *Debate, debateLog []DebateLogEntry, judge func()) {
+	size := 0
+	for _, entry := range debateLog {
+		size += len(entry.Message.Content)
+	}
+	job := &judgeJob{
+		debateID: debate.ID,
+		ranked:   debate.Ranked,
+		size:     size,
+		run:      judge,
+		done:     make(chan struct{}),
+	}
+
+	q.mutex.Lock()
+	q.seq++
+	job.seq = q.seq
+	q.pending = append(q.pending, job)
+	sort.SliceStable(q.pending, func(i, j int) bool {
+		a, b := q.pending[i], q.pending[j]
+		if a.ranked != b.ranked {
+			return a.ranked
+		}
+		if a.size != b.size {
+			return a.size < b.size
+		}
+		return a.seq < b.seq
+	})
+	q.mutex.Unlock()
+
+	q.dispatch()
+	<-job.done
+}
+
+// dispatch starts queued jobs while workers are free and announces the new positions
+func (q *JudgeQueue) dispatch() {
+	q.mutex.Lock()
+	started := []*judgeJob{}
+	for q.active < q.workers && len(q.pending) > 0 {
+		job := q.pending[0]
+		q.pending = q.pending[1:]
+		q.active++
+		started = append(started, job)
+	}
+	waiting := append([]*judgeJob{}, q.pending...)
+	q.mutex.Unlock()
+
+	for _, job := range started {
+		go q.execute(job)
+	}
+	for _, job := range started {
+		q.notify(job.debateID, JudgingRunning, 0, len(waiting))
+	}
+	// A newcomer may have pushed others back, a started job moved them up
+	for i, job := range waiting {
+		q.notify(job.debateID, JudgingQueued, i+1, len(waiting))
+	}
+}
+
+func (q *JudgeQueue) execute(job *judgeJob) {
+	defer func() {
+		q.mutex.Lock()
+		q.active--
+		q.mutex.Unlock()
+		close(job.done)
+		q.dispatch()
+	}()
+	job.run()
+}
+
+// Length returns the number of debates waiting to be judged
+func (q *JudgeQueue) Length() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return len(q.pending)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// Debates are judged heuristically, by the speech-count fallback scoring,
+// when the AI judge is switched off, enabled without an API key, out of
+// monthly tokens (see usage.go), or opted out of at creation with
+// no_ai_judge. DebateStart, DebateEnd and the rules
+// card say which judging applies, and /api/judge/status lets organizers
+// check the AI judge before creating debates.
+
+// Reasons the AI judge is unavailable
+const (
+	JudgeUnavailableDisabled      = "disabled"       // chatgpt.judge.enabled is false
+	JudgeUnavailableNotConfigured = "not_configured" // Enabled without an API key
+)
+
+// JudgeStatus is the availability of the AI judge
+type JudgeStatus struct {
+	Available       bool     `json:"available"`
+	Judging         string   `json:"judging"`            // How new debates are judged: ai or heuristic
+	Reason          string   `json:"reason,omitempty"`   // Why the AI judge is unavailable
+	Provider        string   `json:"provider,omitempty"` // Host of the judge's API
+	Model           string   `json:"model,omitempty"`
+	Panel           []string `json:"panel,omitempty"`     // Judge persona IDs when a panel is configured
+	Fallbacks       []string `json:"fallbacks,omitempty"` // Model profiles tried in order when the judge's provider fails
+	Feedback        bool     `json:"feedback"`
+	QueueLength     int      `json:"queue_length"`              // Debates waiting for a judge slot
+	BudgetExhausted bool     `json:"budget_exhausted"`          // Judge calls fail until the daily LLM budget resets
+	CircuitBreaker  string   `json:"circuit_breaker,omitempty"` // State of the judge provider's circuit breaker: closed, open or half_open
+}
+
+// judgeUnavailable returns why the AI judge cannot run; empty if it can
+func judgeUnavailable() string {
+	if chatgptClient == nil {
+		return JudgeUnavailableDisabled
+	}
+	if chatgptClient.Mock {
+		return ""
+	}
+	if chatgptClient.APIKey == "" || chatgptClient.APIKey == "your-api-key-here" {
+		return JudgeUnavailableNotConfigured
+	}
+	if llmBudget.MonthlyExhausted() {
+		return JudgeUnavailableMonthlyBudget
+	}
+	return ""
+}
+
+// judgingFor returns how a debate is judged: by the AI judge when it is
+// available and the debate did not opt out, heuristically otherwise
+func judgingFor(debate *Debate) string {
+	if debate.NoAIJudge || judgeUnavailable() != "" {
+		return JudgeModeHeuristic
+	}
+	return JudgeModeAI
+}
+
+// resultJudging returns how a result was actually reached; an AI debate
+// whose judge call failed ends up heuristic
+func resultJudging(result *DebateResult) string {
+	if result.JudgeModel != "" {
+		return JudgeModeAI
+	}
+	return JudgeModeHeuristic
+}
+
+// handleJudgeStatus handles GET /api/judge/status
+func handleJudgeStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, currentJudgeStatus())
+}
+
+// currentJudgeStatus reports the AI judge's availability
+func currentJudgeStatus() JudgeStatus {
+	status := JudgeStatus{
+		Judging:         JudgeModeHeuristic,
+		Reason:          judgeUnavailable(),
+		Feedback:        config.ChatGPT.Judge.Feedback,
+		QueueLength:     debateManager.judgeQueue.Length(),
+		BudgetExhausted: llmBudget.Status().Exhausted,
+	}
+	if status.Reason == "" {
+		status.Available = true
+		status.Judging = JudgeModeAI
+		status.Model = chatgptClient.Model
+		if chatgptClient.Mock {
+			status.Provider = JudgeProviderMock
+		} else {
+			if u, err := url.Parse(chatgptClient.APIURL); err == nil {
+				status.Provider = u.Host
+			}
+			status.CircuitBreaker = breakerFor(chatgptClient.APIURL).State()
+		}
+		for _, persona := range config.ChatGPT.Judge.Panel {
+			status.Panel = append(status.Panel, persona.ID)
+		}
+		status.Fallbacks = config.ChatGPT.Judge.Fallback
+	}
+	return status
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// With chatgpt.judge.stream the judge is called with the streaming chat
+// completions API. The summary field of its JSON verdict is picked out of
+// the answer as it arrives and broadcast to the debate's spectators as
+// judge_stream, so they can read the verdict being written instead of
+// waiting for all of it. The result is still parsed from the full answer and
+// arrives with debate_end. Panels, close-call reruns and fallback providers
+// call the judge again; the first piece of each call is marked restart.
+
+// JudgeStream is the next piece of the summary the judge is writing
+type JudgeStream struct {
+	DebateID string `json:"debate_id"`
+	Delta    string `json:"delta"`
+	Restart  bool   `json:"restart,omitempty"` // A new judge call began; discard the text streamed so far
+}
+
+// StreamOptions asks a streaming API to report token usage in its last chunk
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// streamChunk is one server-sent event of a streaming chat completion
+type streamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// StreamMessage sends a message with the streaming API, passing each piece
+// of the answer to onDelta as it arrives, and returns the whole answer
+func (c *ChatGPTClient) StreamMessage(messages []ChatGPTMessage, onDelta func(string)) (string, error) {
+	resp, err := c.post(ChatGPTRequest{
+		Model:         c.Model,
+		Messages:      messages,
+		MaxTokens:     c.MaxTokens,
+		Temperature:   c.Temperature,
+		Stream:        true,
+		StreamOptions: &StreamOptions{IncludeUsage: true},
+	})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var answer strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+		var chunk streamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return "", fmt.Errorf("failed to unmarshal stream chunk: %w", err)
+		}
+		if chunk.Usage != nil {
+			c.recordUsage(chunk.Usage.PromptTokens, chunk.Usage.CompletionTokens)
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content != "" {
+				answer.WriteString(choice.Delta.Content)
+				onDelta(choice.Delta.Content)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	if answer.Len() == 0 {
+		return "", errEmptyResponse
+	}
+	return answer.String(), nil
+}
+
+// Where a summaryStream is in the judge's answer
+const (
+	seekingSummaryField = iota
+	seekingSummaryValue
+	inSummary
+	summaryDone
+)
+
+// summaryField is the field of the verdict JSON that is streamed
+const summaryField = `"summary"`
+
+// summaryStream picks the summary string out of a judge answer as it is
+// streamed, decoding its JSON escapes
+type summaryStream struct {
+	emit    func(delta string, restart bool)
+	buf     string
+	pos     int // Next byte of buf to look at
+	state   int
+	started bool
+}
+
+// newSummaryStream starts reading one judge answer
+func newSummaryStream(emit func(delta string, restart bool)) *summaryStream {
+	return &summaryStream{emit: emit}
+}
+
+// Write takes the next piece of the answer and emits any summary text it completes
+func (s *summaryStream) Write(piece string) {
+	s.buf += piece
+	for {
+		switch s.state {
+		case seekingSummaryField:
+			i := strings.Index(s.buf[s.pos:], summaryField)
+			if i < 0 {
+				// Keep a partial key at the end in view
+				if n := len(s.buf) - len(summaryField); n > s.pos {
+					s.pos = n
+				}
+				return
+			}
+			s.pos += i + len(summaryField)
+			s.state = seekingSummaryValue
+		case seekingSummaryValue:
+			for s.pos < len(s.buf) && strings.IndexByte(" \t\r\n:", s.buf[s.pos]) >= 0 {
+				s.pos++
+			}
+			if s.pos == len(s.buf) {
+				return
+			}
+			if s.buf[s.pos] != '"' {
+				s.state = seekingSummaryField
+				continue
+			}
+			s.pos++
+			s.state = inSummary
+		case inSummary:
+			end, closed := scanJSONString(s.buf, s.pos)
+			var text string
+			if end > s.pos && json.Unmarshal([]byte(`"`+s.buf[s.pos:end]+`"`), &text) == nil && text != "" {
+				s.emit(text, !s.started)
+				s.started = true
+			}
+			s.pos = end
+			if closed {
+				s.state = summaryDone
+			}
+			return
+		default:
+			return
+		}
+	}
+}
+
+// scanJSONString scans the body of a JSON string from buf[from:]. It returns
+// where the complete part ends, not splitting an escape, and whether the
+// closing quote is there.
+func scanJSONString(buf string, from int) (int, bool) {
+	i := from
+	for i < len(buf) {
+		switch buf[i] {
+		case '"':
+			return i, true
+		case '\\':
+			if i+1 >= len(buf) {
+				return i, false
+			}
+			if buf[i+1] != 'u' {
+				i += 2
+				continue
+			}
+			width := 6
+			if i+3 < len(buf) && strings.IndexByte("dD", buf[i+2]) >= 0 && strings.IndexByte("89abAB", buf[i+3]) >= 0 {
+				width = 12 // High surrogate, decoded together with the low one after it
+			}
+			if i+width > len(buf) {
+				return i, false
+			}
+			i += width
+		default:
+			i++
+		}
+	}
+	return i, false
+}
+
+// Stream broadcasts the next piece of the summary to the debate's spectators
+func (t *JudgingTracker) Stream(delta string, restart bool) {
+	msg := createMessage("judge_stream", JudgeStream{DebateID: t.activeDebate.Debate.ID, Delta: delta, Restart: restart})
+	t.dm.broadcast <- BroadcastMessage{DebateID: t.activeDebate.Debate.ID, Message: msg}
+}
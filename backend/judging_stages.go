@@ -0,0 +1,88 @@
+package main
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// AI judgements report each stage they reach as judging_stage, to the
+// debate's spectators and to the admin stream, so long verdict waits show
+// where the time goes and a failure names the stage it happened in. Panels
+// and close-call reruns call the judge several times, so the LLM stages can
+// repeat before saved.
+
+// Judging stages
+const (
+	StageTranscriptPrepared = "transcript_prepared" // Prompt built from the debate log
+	StageLLMCallStarted     = "llm_call_started"    // Request sent to the judge model
+	StageParsing            = "parsing"             // Response received, verdict being read
+	StageSaved              = "saved"               // Result stored
+	StageFailed             = "failed"              // Judging gave up; the fallback result is used
+)
+
+// JudgingStage reports a judgement reaching a stage
+type JudgingStage struct {
+	DebateID    string `json:"debate_id"`
+	Stage       string `json:"stage"`
+	ElapsedMs   int64  `json:"elapsed_ms"`             // Since judging started
+	DurationMs  int64  `json:"duration_ms"`            // Time spent since the previous stage
+	FailedStage string `json:"failed_stage,omitempty"` // Stage the judgement was in when it failed
+	Error       string `json:"error,omitempty"`
+}
+
+// JudgingTracker times the stages of one debate's judgement
+type JudgingTracker struct {
+	dm           *DebateManager
+	activeDebate *ActiveDebate
+
+	mutex   sync.Mutex
+	started time.Time
+	last    time.Time
+	stage   string
+}
+
+// trackJudging starts timing a debate's judgement
+func (dm *DebateManager) trackJudging(activeDebate *ActiveDebate) *JudgingTracker {
+	now := time.Now()
+	t := &JudgingTracker{dm: dm, activeDebate: activeDebate, started: now, last: now}
+	activeDebate.judging = t
+	return t
+}
+
+// Stage records that the judgement reached stage and announces it
+func (t *JudgingTracker) Stage(stage string) {
+	t.publish(JudgingStage{Stage: stage})
+}
+
+// Elapsed returns how long the judgement has taken so far
+func (t *JudgingTracker) Elapsed() time.Duration {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return time.Since(t.started)
+}
+
+// Fail announces that judging failed in its current stage
+func (t *JudgingTracker) Fail(err error) {
+	t.mutex.Lock()
+	failed := t.stage
+	t.mutex.Unlock()
+	t.publish(JudgingStage{Stage: StageFailed, FailedStage: failed, Error: err.Error()})
+	log.Printf("Judging debate %s failed during %s: %v", t.activeDebate.Debate.ID, failed, err)
+}
+
+// publish stamps a stage event with its timings and sends it to spectators and admins
+func (t *JudgingTracker) publish(event JudgingStage) {
+	t.mutex.Lock()
+	now := time.Now()
+	event.DebateID = t.activeDebate.Debate.ID
+	event.ElapsedMs = now.Sub(t.started).Milliseconds()
+	event.DurationMs = now.Sub(t.last).Milliseconds()
+	t.last = now
+	t.stage = event.Stage
+	t.mutex.Unlock()
+
+	msg := createMessage("judging_stage", event)
+	t.dm.broadcast <- BroadcastMessage{DebateID: event.DebateID, Message: msg}
+	adminStream.Publish(msg)
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+)
+
+// maxDebateLanguages caps how many languages one debate may carry
+const maxDebateLanguages = 4
+
+var languageCodePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{2,8})?$`)
+
+// validateLanguages checks the languages and topic translations of a bilingual debate
+func validateLanguages(languages []string, topicTranslations map[string]string) error {
+	if len(languages) > maxDebateLanguages {
+		return fmt.Errorf("at most %d languages are supported", maxDebateLanguages)
+	}
+	seen := map[string]bool{}
+	for _, lang := range languages {
+		if !languageCodePattern.MatchString(lang) {
+			return fmt.Errorf("invalid language code %q", lang)
+		}
+		if seen[lang] {
+			return fmt.Errorf("duplicate language %q", lang)
+		}
+		seen[lang] = true
+	}
+	for lang := range topicTranslations {
+		if !seen[lang] {
+			return fmt.Errorf("topic translation for %q, which is not one of the debate languages", lang)
+		}
+	}
+	return nil
+}
+
+// hasLanguage reports whether the debate is conducted in lang
+func (d *Debate) hasLanguage(lang string) bool {
+	for _, l := range d.Languages {
+		if l == lang {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeTranslations keeps only the translations a debate asks for. Speeches
+// in single-language debates carry no translations; each translation obeys
+// the same length cap as the original.
+func normalizeTranslations(debate *Debate, msg *SpeechMessage) {
+	if len(debate.Languages) == 0 {
+		msg.Translations = nil
+		return
+	}
+	if msg.Language == "" {
+		msg.Language = debate.Languages[0]
+	}
+	kept := map[string]string{}
+	for lang, content := range msg.Translations {
+		if lang == msg.Language || !debate.hasLanguage(lang) || content == "" {
+			continue
+		}
+		if len(content) > formatLimitsFor(debate).MaxContentLength*2 {
+			continue
+		}
+		kept[lang] = content
+	}
+	msg.Translations = nil
+	if len(kept) > 0 {
+		msg.Translations = kept
+	}
+}
+
+// localizeLog returns a copy of the log showing each speech in lang where a
+// translation exists. Translation maps are dropped to keep the payload small.
+func localizeLog(debateLog []DebateLogEntry, lang string) []DebateLogEntry {
+	if debateLog == nil {
+		return nil
+	}
+	localized := make([]DebateLogEntry, len(debateLog))
+	for i, entry := range debateLog {
+		if translated, ok := entry.Message.Translations[lang]; ok && entry.Message.Language != lang {
+			entry.Message.Content = translated
+			entry.Message.Language = lang
+		}
+		entry.Message.Translations = nil
+		localized[i] = entry
+	}
+	return localized
+}
+
+// localizeTopic returns the topic in lang when a translation exists
+func localizeTopic(debate *Debate, lang string) string {
+	if translated, ok := debate.TopicTranslations[lang]; ok {
+		return translated
+	}
+	return debate.Topic
+}
+
+// localizeMessage rewrites a spectator message into the subscriber's display
+// language. Messages without speeches pass through unchanged.
+func localizeMessage(debate *Debate, msg Message, lang string) Message {
+	if lang == "" || !debate.hasLanguage(lang) {
+		return msg
+	}
+	switch data := msg.Data.(type) {
+	case DebateUpdate:
+		data.Topic = localizeTopic(debate, lang)
+		data.DebateLog = localizeLog(data.DebateLog, lang)
+		msg.Data = data
+	case DebateStart:
+		data.Topic = localizeTopic(debate, lang)
+		data.DebateLog = localizeLog(data.DebateLog, lang)
+		msg.Data = data
+	case DebateEnd:
+		data.Topic = localizeTopic(debate, lang)
+		data.DebateLog = localizeLog(data.DebateLog, lang)
+		msg.Data = data
+	case DebateWaiting:
+		data.Topic = localizeTopic(debate, lang)
+		msg.Data = data
+	case DebateSnapshot:
+		data.Topic = localizeTopic(debate, lang)
+		data.DebateLog = localizeLog(data.DebateLog, lang)
+		msg.Data = data
+	case RoundReveal:
+		data.Entries = localizeLog(data.Entries, lang)
+		msg.Data = data
+	}
+	return msg
+}
+
+// translateEntries fills in missing translations for revealed speeches in the
+// background and pushes each one to spectators as speech_translation
+func (dm *DebateManager) translateEntries(activeDebate *ActiveDebate, entries []DebateLogEntry) {
+	debate := activeDebate.Debate
+	if translator == nil || len(debate.Languages) < 2 {
+		return
+	}
+
+	go func() {
+		for _, entry := range entries {
+			for _, lang := range debate.Languages {
+				if lang == entry.Message.Language || entry.Message.Translations[lang] != "" {
+					continue
+				}
+				translated, err := translator.forDebate(debate.ID, UsageTranslation).Translate(entry.Message.Content, lang)
+				if err != nil {
+					log.Printf("Failed to translate speech by %s into %s in debate %s: %v", entry.Speaker, lang, debate.ID, err)
+					continue
+				}
+				dm.storeTranslation(activeDebate, entry.Round, entry.Speaker, lang, translated)
+			}
+		}
+	}()
+}
+
+// storeTranslation records a translation on the live log and in the database, then broadcasts it
+func (dm *DebateManager) storeTranslation(activeDebate *ActiveDebate, round int, speaker, lang, content string) {
+	activeDebate.mutex.Lock()
+	var translations map[string]string
+	for i := range activeDebate.DebateLog {
+		entry := &activeDebate.DebateLog[i]
+		if entry.Round != round || entry.Speaker != speaker {
+			continue
+		}
+		// Copy so messages already built from the old map are not mutated
+		updated := make(map[string]string, len(entry.Message.Translations)+1)
+		for k, v := range entry.Message.Translations {
+			updated[k] = v
+		}
+		updated[lang] = content
+		entry.Message.Translations = updated
+		translations = updated
+	}
+	activeDebate.mutex.Unlock()
+
+	if translations == nil {
+		return
+	}
+	if err := dm.db.UpdateDebateLogTranslations(activeDebate.Debate.ID, round, speaker, translations); err != nil {
+		log.Printf("Failed to store translation for debate %s: %v", activeDebate.Debate.ID, err)
+	}
+
+	dm.broadcast <- BroadcastMessage{
+		DebateID: activeDebate.Debate.ID,
+		Message: createMessage("speech_translation", SpeechTranslation{
+			DebateID: activeDebate.Debate.ID,
+			Round:    round,
+			Speaker:  speaker,
+			Language: lang,
+			Content:  content,
+		}),
+	}
+}
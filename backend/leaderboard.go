@@ -0,0 +1,163 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Leaderboard sort orders
+const (
+	LeaderboardByWins   = "wins"
+	LeaderboardByRating = "rating"
+	LeaderboardByScore  = "score"  // Average judge score
+	LeaderboardByPoints = "points" // A win scores 1, a draw ½
+)
+
+// leaderboardWindows maps the window parameter to its length in days; "all"
+// covers every ranked debate
+var leaderboardWindows = map[string]int{
+	"all": 0,
+	"7d":  7,
+	"30d": 30,
+}
+
+// maxLeaderboardPageSize bounds per_page
+const maxLeaderboardPageSize = 100
+
+// LeaderboardEntry is one ranked bot
+type LeaderboardEntry struct {
+	Rank int `json:"rank"`
+	BotStats
+	Points float64 `json:"points"` // Wins plus half the draws
+	Rating float64 `json:"rating"`
+}
+
+// botPoints scores a win 1 and a draw ½
+func botPoints(s BotStats) float64 {
+	return float64(s.Wins) + float64(s.Draws)/2
+}
+
+// Leaderboard is one page of bots ranked over a time window
+type Leaderboard struct {
+	Sort    string             `json:"sort"`
+	Window  string             `json:"window"`
+	Page    int                `json:"page"`
+	PerPage int                `json:"per_page"`
+	Total   int                `json:"total"` // Ranked bots across all pages
+	Bots    []LeaderboardEntry `json:"bots"`
+}
+
+// botRating is the lower bound of the 95% Wilson interval of a bot's points
+// share (a win scores 1, a draw ½), scaled to 0-100. Bots with few debates
+// rate below bots that win as often over many.
+func botRating(s BotStats) float64 {
+	if s.Debates == 0 {
+		return 0
+	}
+	const z = 1.96
+	n := float64(s.Debates)
+	p := botPoints(s) / n
+	bound := (p + z*z/(2*n) - z*math.Sqrt((p*(1-p)+z*z/(4*n))/n)) / (1 + z*z/n)
+	return math.Round(bound*1000) / 10
+}
+
+// rankBots orders bots by the given sort, breaking ties by the other
+// measures. Equal wins are split by points, so a draw beats a loss.
+func rankBots(entries []LeaderboardEntry, by string) {
+	keys := func(e LeaderboardEntry) []float64 {
+		switch by {
+		case LeaderboardByRating:
+			return []float64{e.Rating, e.Points, e.AverageScore}
+		case LeaderboardByScore:
+			return []float64{e.AverageScore, e.Rating, e.Points}
+		case LeaderboardByPoints:
+			return []float64{e.Points, e.Rating, e.AverageScore}
+		}
+		return []float64{float64(e.Wins), e.Points, e.Rating, e.AverageScore}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := keys(entries[i]), keys(entries[j])
+		for k := range a {
+			if a[k] != b[k] {
+				return a[k] > b[k]
+			}
+		}
+		return entries[i].BotName < entries[j].BotName
+	})
+}
+
+// handleLeaderboard handles GET /api/leaderboard?sort=&window=&page=&per_page=
+func handleLeaderboard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	board := Leaderboard{Sort: query.Get("sort"), Window: query.Get("window"), Page: 1, PerPage: 20}
+	switch board.Sort {
+	case "":
+		board.Sort = LeaderboardByWins
+	case LeaderboardByWins, LeaderboardByRating, LeaderboardByScore, LeaderboardByPoints:
+	default:
+		http.Error(w, "sort must be wins, points, rating or score", http.StatusBadRequest)
+		return
+	}
+	if board.Window == "" {
+		board.Window = "all"
+	}
+	days, known := leaderboardWindows[board.Window]
+	if !known {
+		http.Error(w, "window must be all, 7d or 30d", http.StatusBadRequest)
+		return
+	}
+	if page := query.Get("page"); page != "" {
+		n, err := strconv.Atoi(page)
+		if err != nil || n < 1 {
+			http.Error(w, "page must be a positive number", http.StatusBadRequest)
+			return
+		}
+		board.Page = n
+	}
+	if perPage := query.Get("per_page"); perPage != "" {
+		n, err := strconv.Atoi(perPage)
+		if err != nil || n < 1 || n > maxLeaderboardPageSize {
+			http.Error(w, "per_page must be between 1 and 100", http.StatusBadRequest)
+			return
+		}
+		board.PerPage = n
+	}
+
+	var since time.Time
+	if days > 0 {
+		since = time.Now().AddDate(0, 0, -days)
+	}
+	stats, err := db.GetLeaderboardStats(since)
+	if err != nil {
+		http.Error(w, "Failed to fetch leaderboard", http.StatusInternalServerError)
+		return
+	}
+
+	entries := make([]LeaderboardEntry, len(stats))
+	for i, s := range stats {
+		entries[i] = LeaderboardEntry{BotStats: s, Points: botPoints(s), Rating: botRating(s)}
+	}
+	rankBots(entries, board.Sort)
+	for i := range entries {
+		entries[i].Rank = i + 1
+	}
+
+	board.Total = len(entries)
+	board.Bots = []LeaderboardEntry{}
+	if start := (board.Page - 1) * board.PerPage; start < len(entries) {
+		end := start + board.PerPage
+		if end > len(entries) {
+			end = len(entries)
+		}
+		board.Bots = entries[start:end]
+	}
+	writeJSON(w, board)
+}
@@ -0,0 +1,538 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Leagues pair every entered bot with every other once, cycling through a
+// pool of topics. Like tournament matches, fixture debates are reserved for
+// their two bots and a bot logging in without a debate_id is sent to its next
+// fixture. A bot plays one fixture at a time; the next ones are created as
+// earlier ones finish. Results accumulate in a standings table.
+
+// League statuses
+const (
+	LeagueActive    = "active"
+	LeagueCompleted = "completed"
+)
+
+// League fixture statuses
+const (
+	FixturePending   = "pending"   // Waiting for both bots to be free
+	FixtureScheduled = "scheduled" // Fixture debate created
+	FixtureCompleted = "completed"
+)
+
+// FixtureDraw is the winner recorded for a drawn fixture
+const FixtureDraw = "draw"
+
+// Standings points per fixture
+const (
+	leagueWinPoints  = 3
+	leagueDrawPoints = 1
+)
+
+// maxLeagueBots bounds the number of fixtures, which grows with its square
+const maxLeagueBots = 16
+
+// leagueMutex serializes league advancement on this instance
+var leagueMutex sync.Mutex
+
+// LeagueEntrant is a bot entered into a league
+type LeagueEntrant struct {
+	BotName string `json:"bot_name"`
+	BotUUID string `json:"bot_uuid"`
+}
+
+// LeagueStanding is a bot's row of the standings table
+type LeagueStanding struct {
+	BotUUID string `json:"bot_uuid"`
+	BotName string `json:"bot_name"`
+	Played  int    `json:"played"`
+	Wins    int    `json:"wins"`
+	Draws   int    `json:"draws"`
+	Losses  int    `json:"losses"`
+	Points  int    `json:"points"`
+
+	HeadToHead int `json:"head_to_head,omitempty"` // Points taken off the bots level on points with this one
+}
+
+// LeagueFixture is one pairing of the league
+type LeagueFixture struct {
+	LeagueID string `json:"-"`
+	Fixture  int    `json:"fixture"`
+	BotA     string `json:"bot_a_uuid"`
+	BotB     string `json:"bot_b_uuid"`
+	Topic    string `json:"topic"`
+	DebateID string `json:"debate_id,omitempty"`
+	Winner   string `json:"winner_uuid,omitempty"` // FixtureDraw for a draw
+	Status   string `json:"status"`
+}
+
+// League is a round-robin competition and its current state
+type League struct {
+	ID           string           `json:"league_id"`
+	Name         string           `json:"name"`
+	Topics       []string         `json:"topics"`
+	DebateRounds int              `json:"debate_rounds"` // Rounds of each fixture debate
+	Status       string           `json:"status"`
+	Standings    []LeagueStanding `json:"standings"` // Ranked as described at rankStandings
+	Fixtures     []LeagueFixture  `json:"fixtures"`
+	CreatedAt    time.Time        `json:"created_at"`
+	UpdatedAt    time.Time        `json:"updated_at"`
+}
+
+// LeagueStandings is the response of GET /api/league/{id}/standings
+type LeagueStandings struct {
+	LeagueID  string           `json:"league_id"`
+	Name      string           `json:"name"`
+	Status    string           `json:"status"`
+	Standings []LeagueStanding `json:"standings"`
+}
+
+// CreateLeagueRequest enters bots into a new league
+type CreateLeagueRequest struct {
+	Name        string          `json:"name"`
+	Topics      []string        `json:"topics"`       // Fixtures cycle through the pool
+	TotalRounds int             `json:"total_rounds"` // Rounds of each fixture debate
+	Bots        []LeagueEntrant `json:"bots"`
+}
+
+// newLeague lays out a fixture for every pair of entrants
+func newLeague(req *CreateLeagueRequest) *League {
+	now := time.Now()
+	l := &League{
+		ID:           "league-" + uuid.New().String()[:8],
+		Name:         req.Name,
+		Topics:       req.Topics,
+		DebateRounds: req.TotalRounds,
+		Status:       LeagueActive,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	for _, bot := range req.Bots {
+		l.Standings = append(l.Standings, LeagueStanding{BotUUID: bot.BotUUID, BotName: bot.BotName})
+	}
+	for i := range req.Bots {
+		for j := i + 1; j < len(req.Bots); j++ {
+			n := len(l.Fixtures)
+			l.Fixtures = append(l.Fixtures, LeagueFixture{
+				LeagueID: l.ID,
+				Fixture:  n + 1,
+				BotA:     req.Bots[i].BotUUID,
+				BotB:     req.Bots[j].BotUUID,
+				Topic:    l.Topics[n%len(l.Topics)],
+				Status:   FixturePending,
+			})
+		}
+	}
+	return l
+}
+
+// scheduleLeagueFixtures creates the debates of pending fixtures whose two
+// bots are not already playing another fixture of the league
+func scheduleLeagueFixtures(l *League) {
+	busy := map[string]bool{}
+	for _, fixture := range l.Fixtures {
+		if fixture.Status == FixtureScheduled {
+			busy[fixture.BotA] = true
+			busy[fixture.BotB] = true
+		}
+	}
+
+	for i := range l.Fixtures {
+		fixture := &l.Fixtures[i]
+		if fixture.Status != FixturePending || busy[fixture.BotA] || busy[fixture.BotB] {
+			continue
+		}
+
+		// Another instance may be scheduling the same fixture
+		claimed, err := db.ClaimLeagueFixture(l.ID, fixture.Fixture)
+		if err != nil || !claimed {
+			continue
+		}
+		busy[fixture.BotA] = true
+		busy[fixture.BotB] = true
+		debate, err := debateManager.CreateDebate(fixture.Topic, l.DebateRounds, DebateOptions{
+			Ranked:   true,
+			Format:   FormatSequential,
+			Scoring:  ScoringHolistic,
+			LeagueID: l.ID,
+		})
+		if err != nil {
+			log.Printf("Failed to create debate for league %s fixture %d: %v", l.ID, fixture.Fixture, err)
+			continue
+		}
+		if err := db.SetLeagueFixtureDebate(l.ID, fixture.Fixture, debate.ID); err != nil {
+			log.Printf("Failed to link debate %s to league %s: %v", debate.ID, l.ID, err)
+			continue
+		}
+		fixture.Status = FixtureScheduled
+		fixture.DebateID = debate.ID
+		log.Printf("League %s fixture %d: %s vs %s in debate %s", l.ID, fixture.Fixture, fixture.BotA, fixture.BotB, debate.ID)
+	}
+}
+
+// decideFixture picks the winner of a finished fixture debate: the judged
+// winner, else the only bot that showed up, else a draw. result is nil when
+// the debate never started.
+func decideFixture(fixture *LeagueFixture, result *DebateResult) string {
+	sides, joined := matchAttendance(fixture.DebateID)
+	if result != nil && sides[result.Winner] != "" {
+		return sides[result.Winner]
+	}
+	if joined[fixture.BotA] != joined[fixture.BotB] {
+		if joined[fixture.BotA] {
+			return fixture.BotA
+		}
+		return fixture.BotB
+	}
+	return FixtureDraw
+}
+
+// advanceLeague records the outcome of a finished fixture debate in the
+// standings and schedules the fixtures it frees up. result is nil when the
+// debate timed out before it started.
+func advanceLeague(debateID string, result *DebateResult) {
+	leagueMutex.Lock()
+	defer leagueMutex.Unlock()
+
+	fixture, err := db.GetLeagueFixtureByDebate(debateID)
+	if err != nil || fixture.Status != FixtureScheduled {
+		return
+	}
+
+	winner := decideFixture(fixture, result)
+	if err := db.RecordLeagueFixture(fixture, winner); err != nil {
+		log.Printf("Failed to record league %s fixture %d: %v", fixture.LeagueID, fixture.Fixture, err)
+		return
+	}
+
+	l, err := db.GetLeague(fixture.LeagueID)
+	if err != nil {
+		log.Printf("Failed to load league %s: %v", fixture.LeagueID, err)
+		return
+	}
+	scheduleLeagueFixtures(l)
+
+	for _, f := range l.Fixtures {
+		if f.Status != FixtureCompleted {
+			return
+		}
+	}
+	if err := db.CompleteLeague(l.ID); err != nil {
+		log.Printf("Failed to complete league %s: %v", l.ID, err)
+		return
+	}
+	log.Printf("League %s completed, %s tops the standings", l.ID, l.Standings[0].BotUUID)
+}
+
+// handleCreateLeague handles POST /api/league/create
+func handleCreateLeague(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req CreateLeagueRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	topics := req.Topics[:0]
+	for _, topic := range req.Topics {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			topics = append(topics, topic)
+		}
+	}
+	req.Topics = topics
+	if len(req.Topics) == 0 {
+		http.Error(w, "At least one topic is required", http.StatusBadRequest)
+		return
+	}
+	if req.TotalRounds <= 0 {
+		req.TotalRounds = 3
+	}
+	if err := validateRounds(req.TotalRounds); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.Bots) < 2 || len(req.Bots) > maxLeagueBots {
+		http.Error(w, fmt.Sprintf("A league needs between 2 and %d bots", maxLeagueBots), http.StatusBadRequest)
+		return
+	}
+	entered := map[string]bool{}
+	for _, bot := range req.Bots {
+		if bot.BotName == "" || len(bot.BotUUID) < 8 {
+			http.Error(w, "Every bot needs a bot_name and bot_uuid", http.StatusBadRequest)
+			return
+		}
+		if entered[bot.BotUUID] {
+			http.Error(w, fmt.Sprintf("Bot %s is entered twice", bot.BotUUID), http.StatusBadRequest)
+			return
+		}
+		entered[bot.BotUUID] = true
+	}
+	if req.Name == "" {
+		req.Name = req.Topics[0]
+	}
+
+	l := newLeague(&req)
+	leagueMutex.Lock()
+	err := db.CreateLeague(l)
+	if err == nil {
+		scheduleLeagueFixtures(l)
+	}
+	leagueMutex.Unlock()
+	if err != nil {
+		log.Printf("Failed to create league: %v", err)
+		http.Error(w, "Failed to create league", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("League %s created with %d bots (%d fixtures)", l.ID, len(l.Standings), len(l.Fixtures))
+	writeJSONStatus(w, http.StatusCreated, l)
+}
+
+// handleLeagueRoutes handles GET /api/league/{id} and
+// GET /api/league/{id}/standings
+func handleLeagueRoutes(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/league/"), "/"), "/")
+	if parts[0] == "" || len(parts) > 2 || (len(parts) == 2 && parts[1] != "standings") {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	l, err := db.GetLeague(parts[0])
+	if err != nil {
+		http.Error(w, "League not found", http.StatusNotFound)
+		return
+	}
+	if len(parts) == 2 {
+		writeJSON(w, LeagueStandings{LeagueID: l.ID, Name: l.Name, Status: l.Status, Standings: l.Standings})
+		return
+	}
+	writeJSON(w, l)
+}
+
+// CreateLeague stores a new league with its standings and fixtures
+func (d *Database) CreateLeague(l *League) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`INSERT INTO leagues (id, name, topics, debate_rounds, status, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		l.ID, l.Name, toJSON(l.Topics), l.DebateRounds, l.Status, l.CreatedAt, l.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	for _, standing := range l.Standings {
+		_, err := tx.Exec(`INSERT INTO league_standings (league_id, bot_uuid, bot_name) VALUES (?, ?, ?)`,
+			l.ID, standing.BotUUID, standing.BotName)
+		if err != nil {
+			return err
+		}
+	}
+	for _, fixture := range l.Fixtures {
+		_, err := tx.Exec(`INSERT INTO league_fixtures (league_id, fixture, bot_a_uuid, bot_b_uuid, topic, status) VALUES (?, ?, ?, ?, ?, ?)`,
+			l.ID, fixture.Fixture, fixture.BotA, fixture.BotB, fixture.Topic, fixture.Status)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetLeague loads a league with its standings and fixtures
+func (d *Database) GetLeague(leagueID string) (*League, error) {
+	l := &League{}
+	var topics string
+	err := d.db.QueryRow(`SELECT id, name, topics, debate_rounds, status, created_at, updated_at FROM leagues WHERE id = ?`, leagueID).
+		Scan(&l.ID, &l.Name, &topics, &l.DebateRounds, &l.Status, &l.CreatedAt, &l.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	json.Unmarshal([]byte(topics), &l.Topics)
+
+	rows, err := d.db.Query(`SELECT bot_uuid, bot_name, played, wins, draws, losses, points
+		FROM league_standings WHERE league_id = ? ORDER BY points DESC, wins DESC, bot_name`, leagueID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var s LeagueStanding
+		if err := rows.Scan(&s.BotUUID, &s.BotName, &s.Played, &s.Wins, &s.Draws, &s.Losses, &s.Points); err != nil {
+			return nil, err
+		}
+		l.Standings = append(l.Standings, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	fixtureRows, err := d.db.Query(`SELECT league_id, fixture, bot_a_uuid, bot_b_uuid, topic, debate_id, winner_uuid, status
+		FROM league_fixtures WHERE league_id = ? ORDER BY fixture`, leagueID)
+	if err != nil {
+		return nil, err
+	}
+	defer fixtureRows.Close()
+	for fixtureRows.Next() {
+		var f LeagueFixture
+		if err := fixtureRows.Scan(&f.LeagueID, &f.Fixture, &f.BotA, &f.BotB, &f.Topic, &f.DebateID, &f.Winner, &f.Status); err != nil {
+			return nil, err
+		}
+		l.Fixtures = append(l.Fixtures, f)
+	}
+	if err := fixtureRows.Err(); err != nil {
+		return nil, err
+	}
+	l.rankStandings()
+	return l, nil
+}
+
+// rankStandings orders the standings by points, then by the points bots level
+// on points took off each other, then by wins, then by name. A draw is worth
+// leagueDrawPoints to both bots wherever points are counted.
+func (l *League) rankStandings() {
+	level := map[string]int{}
+	for _, s := range l.Standings {
+		level[s.BotUUID] = s.Points
+	}
+	headToHead := map[string]int{}
+	for _, f := range l.Fixtures {
+		if f.Status != FixtureCompleted || level[f.BotA] != level[f.BotB] {
+			continue
+		}
+		switch f.Winner {
+		case FixtureDraw:
+			headToHead[f.BotA] += leagueDrawPoints
+			headToHead[f.BotB] += leagueDrawPoints
+		case f.BotA, f.BotB:
+			headToHead[f.Winner] += leagueWinPoints
+		}
+	}
+	for i := range l.Standings {
+		l.Standings[i].HeadToHead = headToHead[l.Standings[i].BotUUID]
+	}
+
+	sort.SliceStable(l.Standings, func(i, j int) bool {
+		a, b := l.Standings[i], l.Standings[j]
+		switch {
+		case a.Points != b.Points:
+			return a.Points > b.Points
+		case a.HeadToHead != b.HeadToHead:
+			return a.HeadToHead > b.HeadToHead
+		case a.Wins != b.Wins:
+			return a.Wins > b.Wins
+		}
+		return a.BotName < b.BotName
+	})
+}
+
+// GetLeagueFixtureByDebate returns the fixture played in a debate
+func (d *Database) GetLeagueFixtureByDebate(debateID string) (*LeagueFixture, error) {
+	f := &LeagueFixture{}
+	err := d.db.QueryRow(`SELECT league_id, fixture, bot_a_uuid, bot_b_uuid, topic, debate_id, winner_uuid, status
+		FROM league_fixtures WHERE debate_id = ?`, debateID).
+		Scan(&f.LeagueID, &f.Fixture, &f.BotA, &f.BotB, &f.Topic, &f.DebateID, &f.Winner, &f.Status)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// GetLeagueDebateForBot returns the oldest waiting fixture debate of a bot
+func (d *Database) GetLeagueDebateForBot(botUUID string) (string, error) {
+	var debateID string
+	err := d.db.QueryRow(`
+		SELECT f.debate_id FROM league_fixtures f
+		JOIN debates d ON d.id = f.debate_id
+		WHERE f.status = ? AND d.status = 'waiting' AND (f.bot_a_uuid = ? OR f.bot_b_uuid = ?)
+		ORDER BY d.created_at LIMIT 1`, FixtureScheduled, botUUID, botUUID).Scan(&debateID)
+	return debateID, err
+}
+
+// ClaimLeagueFixture marks a pending fixture as scheduled. It reports false if
+// the fixture was already scheduled.
+func (d *Database) ClaimLeagueFixture(leagueID string, fixture int) (bool, error) {
+	res, err := d.db.Exec(`UPDATE league_fixtures SET status = ? WHERE league_id = ? AND fixture = ? AND status = ?`,
+		FixtureScheduled, leagueID, fixture, FixturePending)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// SetLeagueFixtureDebate links a scheduled fixture to its debate
+func (d *Database) SetLeagueFixtureDebate(leagueID string, fixture int, debateID string) error {
+	_, err := d.db.Exec(`UPDATE league_fixtures SET debate_id = ? WHERE league_id = ? AND fixture = ?`,
+		debateID, leagueID, fixture)
+	return err
+}
+
+// RecordLeagueFixture completes a fixture and adds its outcome to both bots'
+// standings. winner is a bot UUID or FixtureDraw.
+func (d *Database) RecordLeagueFixture(f *LeagueFixture, winner string) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`UPDATE league_fixtures SET winner_uuid = ?, status = ? WHERE league_id = ? AND fixture = ? AND status = ?`,
+		winner, FixtureCompleted, f.LeagueID, f.Fixture, FixtureScheduled)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		// Already recorded, possibly by another instance
+		return err
+	}
+
+	for _, bot := range []string{f.BotA, f.BotB} {
+		wins, draws, losses, points := 0, 0, 0, 0
+		switch winner {
+		case FixtureDraw:
+			draws, points = 1, leagueDrawPoints
+		case bot:
+			wins, points = 1, leagueWinPoints
+		default:
+			losses = 1
+		}
+		_, err := tx.Exec(`UPDATE league_standings
+			SET played = played + 1, wins = wins + ?, draws = draws + ?, losses = losses + ?, points = points + ?
+			WHERE league_id = ? AND bot_uuid = ?`,
+			wins, draws, losses, points, f.LeagueID, bot)
+		if err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`UPDATE leagues SET updated_at = ? WHERE id = ?`, time.Now(), f.LeagueID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CompleteLeague marks a league whose fixtures have all been played
+func (d *Database) CompleteLeague(leagueID string) error {
+	_, err := d.db.Exec(`UPDATE leagues SET status = ?, updated_at = ? WHERE id = ?`, LeagueCompleted, time.Now(), leagueID)
+	return err
+}
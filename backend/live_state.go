@@ -0,0 +1,110 @@
+package main
+
+import "time"
+
+// The debates table is written at status changes and after each speech, so
+// between those it trails the debate running in memory. GET /api/debate/{id}
+// overlays LiveState for debates running on this instance, giving REST
+// clients the same view a spectator's WebSocket has.
+
+// LiveState is the in-memory state of a running debate
+type LiveState struct {
+	Status                 string              `json:"status"`
+	CurrentRound           int                 `json:"current_round"`
+	TotalRounds            int                 `json:"total_rounds"`
+	NextSpeaker            string              `json:"next_speaker,omitempty"`     // Sequential debates: the bot due to speak
+	PendingSpeakers        []string            `json:"pending_speakers,omitempty"` // Simultaneous rounds and blind openings: bots yet to submit
+	Deadline               string              `json:"deadline,omitempty"`         // When the due speech times out
+	RemainingSeconds       int                 `json:"remaining_seconds,omitempty"`
+	DebateRemainingSeconds int                 `json:"debate_remaining_seconds,omitempty"` // Left of debate.max_duration
+	Paused                 bool                `json:"paused"`
+	PauseReason            string              `json:"pause_reason,omitempty"`
+	Participants           []ParticipantStatus `json:"participants"` // Connection health of each joined bot
+	UpdatedAt              time.Time           `json:"updated_at"`
+}
+
+// LiveState returns the state of a debate running on this instance; false
+// if it is not in memory
+func (dm *DebateManager) LiveState(debateID string) (*LiveState, bool) {
+	dm.mutex.RLock()
+	activeDebate, exists := dm.debates[debateID]
+	dm.mutex.RUnlock()
+	if !exists {
+		return nil, false
+	}
+
+	activeDebate.mutex.RLock()
+	defer activeDebate.mutex.RUnlock()
+
+	debate := activeDebate.Debate
+	now := time.Now()
+	state := &LiveState{
+		Status:       debate.Status,
+		CurrentRound: debate.CurrentRound,
+		TotalRounds:  debate.TotalRounds,
+		Paused:       activeDebate.Paused,
+		PauseReason:  activeDebate.PauseReason,
+		Participants: []ParticipantStatus{},
+		UpdatedAt:    now,
+	}
+
+	for _, bot := range activeDebate.Bots {
+		participant := ParticipantStatus{
+			DebateID:    debate.ID,
+			Bot:         bot.Bot.BotIdentifier,
+			Side:        bot.Bot.Side,
+			Status:      ParticipantConnected,
+			MissedPings: bot.MissedPings,
+		}
+		if activeDebate.Disconnected[bot.Bot.BotIdentifier] {
+			participant.Status = ParticipantReconnecting
+		} else if bot.Conn == nil {
+			participant.Status = ParticipantDisconnected
+		}
+		state.Participants = append(state.Participants, participant)
+	}
+
+	switch {
+	case activeDebate.Paused:
+		// Nobody is due while the clocks are stopped
+	case debate.Status == "waiting":
+		if debate.BlindOpening && !activeDebate.OpeningsClosed {
+			for _, bot := range activeDebate.Bots {
+				if _, submitted := activeDebate.Openings[bot.Bot.BotIdentifier]; !submitted {
+					state.PendingSpeakers = append(state.PendingSpeakers, bot.Bot.BotIdentifier)
+				}
+			}
+		}
+	case !isInProgress(debate.Status):
+		// Closing or ended; nobody speaks
+	case debate.Format == FormatSimultaneous:
+		for _, bot := range activeDebate.Bots {
+			if _, submitted := activeDebate.PendingSpeeches[bot.Bot.BotIdentifier]; !submitted {
+				state.PendingSpeakers = append(state.PendingSpeakers, bot.Bot.BotIdentifier)
+			}
+		}
+	default:
+		state.NextSpeaker = dm.getNextSpeaker(activeDebate)
+	}
+
+	if !activeDebate.Paused && activeDebate.TurnDeadline.After(now) {
+		state.Deadline = activeDebate.TurnDeadline.Format(time.RFC3339)
+		state.RemainingSeconds = int(activeDebate.TurnDeadline.Sub(now).Seconds())
+	}
+	if isInProgress(debate.Status) && !activeDebate.StartTime.IsZero() {
+		budget := activeDebate.StartTime.Add(time.Duration(config.Debate.MaxDuration) * time.Second).Sub(now)
+		if budget > 0 {
+			state.DebateRemainingSeconds = int(budget.Seconds())
+		}
+	}
+	return state, true
+}
+
+// overlay copies the live status and round onto a debate read from the database
+func (s *LiveState) overlay(debate *Debate) *Debate {
+	live := *debate
+	live.Status = s.Status
+	live.CurrentRound = s.CurrentRound
+	live.TotalRounds = s.TotalRounds
+	return &live
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Every LLM call goes through post, which retries a request the provider
+// answered with 429 or 5xx, or that could not reach it, after a jittered
+// exponential backoff (chatgpt.retry). A Retry-After header on the answer is
+// honoured up to the longest backoff. Timeouts are not retried, the call has
+// already taken as long as it may.
+//
+// Each provider API URL has a circuit breaker (chatgpt.circuit_breaker).
+// Once circuit_breaker.failures calls in a row have failed despite their
+// retries, the breaker opens and calls to that provider fail at once, so the
+// judge moves on to its fallback profiles instead of every debate waiting out
+// the retries. After
+// cooldown one trial call is let through; its success closes the breaker.
+// Opening raises an admin alert, and the judge's breaker state is reported
+// in /api/judge/status.
+
+// errCircuitOpen is returned for calls to a provider whose breaker is open
+var errCircuitOpen = errors.New("LLM provider circuit breaker is open")
+
+// retryable reports whether a failed attempt is worth repeating
+func retryable(err error) bool {
+	var statusErr *apiStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return false
+	}
+	return err != nil && !errors.Is(err, errLLMBudget) && !errors.Is(err, errCircuitOpen)
+}
+
+// retryDelay returns how long to wait before attempt (2 for the first retry):
+// the doubled backoff with up to half of it taken off at random, or the
+// provider's Retry-After, capped at the longest backoff
+func retryDelay(attempt int, err error) time.Duration {
+	settings := config.ChatGPT.Retry
+	limit := time.Duration(settings.MaxBackoff) * time.Millisecond
+	var statusErr *apiStatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+		return min(statusErr.RetryAfter, limit)
+	}
+	delay := time.Duration(settings.Backoff) * time.Millisecond << (attempt - 2)
+	if delay <= 0 || delay > limit {
+		delay = limit
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// retryAfter reads a Retry-After header given in seconds
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// sendWithRetry sends a request body to the client's API, retrying failed
+// attempts that are worth it
+func (c *ChatGPTClient) sendWithRetry(body []byte) (*http.Response, error) {
+	attempts := max(config.ChatGPT.Retry.MaxAttempts, 1)
+	for attempt := 1; ; attempt++ {
+		resp, err := c.send(body)
+		if err == nil || attempt >= attempts || !retryable(err) {
+			return resp, err
+		}
+		delay := retryDelay(attempt+1, err)
+		log.Printf("LLM call to %s failed (attempt %d of %d), retrying in %s: %v", providerHost(c), attempt, attempts, delay, err)
+		time.Sleep(delay)
+	}
+}
+
+// Circuit breaker states
+const (
+	BreakerClosed   = "closed"    // Calls go through
+	BreakerOpen     = "open"      // Calls fail at once until the cooldown is over
+	BreakerHalfOpen = "half_open" // One trial call is under way
+)
+
+// CircuitBreaker tracks the failures of one LLM provider
+type CircuitBreaker struct {
+	url string
+
+	mutex    sync.Mutex
+	state    string
+	failures int       // Failed calls in a row
+	openedAt time.Time // When the breaker last opened
+}
+
+// circuitBreakers holds a breaker per provider API URL
+var circuitBreakers = struct {
+	sync.Mutex
+	byURL map[string]*CircuitBreaker
+}{byURL: make(map[string]*CircuitBreaker)}
+
+// breakerFor returns the circuit breaker of a provider API URL
+func breakerFor(url string) *CircuitBreaker {
+	circuitBreakers.Lock()
+	defer circuitBreakers.Unlock()
+	b, ok := circuitBreakers.byURL[url]
+	if !ok {
+		b = &CircuitBreaker{url: url, state: BreakerClosed}
+		circuitBreakers.byURL[url] = b
+	}
+	return b
+}
+
+// Allow lets a call through unless the breaker is open. Once the cooldown
+// is over, the first call is let through as the trial.
+func (b *CircuitBreaker) Allow() error {
+	if config.ChatGPT.CircuitBreaker.Failures <= 0 {
+		return nil
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	switch b.state {
+	case BreakerOpen:
+		cooldown := time.Duration(config.ChatGPT.CircuitBreaker.Cooldown) * time.Second
+		if time.Since(b.openedAt) < cooldown {
+			return fmt.Errorf("%w (%s)", errCircuitOpen, b.url)
+		}
+		b.state = BreakerHalfOpen
+		return nil
+	case BreakerHalfOpen:
+		return fmt.Errorf("%w (%s, trial call under way)", errCircuitOpen, b.url)
+	}
+	return nil
+}
+
+// Record counts the outcome of a call the breaker let through. Only the
+// provider failing counts against it; a request it rejected, say for a bad
+// key, shows it is up.
+func (b *CircuitBreaker) Record(err error) {
+	threshold := config.ChatGPT.CircuitBreaker.Failures
+	if threshold <= 0 {
+		return
+	}
+	failed := retryable(err)
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		failed = true
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if !failed {
+		if b.state == BreakerHalfOpen {
+			log.Printf("LLM provider %s recovered, circuit breaker closed", b.url)
+		}
+		b.state = BreakerClosed
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= threshold {
+		if b.state == BreakerClosed {
+			go RaiseAlert("llm_circuit_open", fmt.Sprintf("LLM provider %s failed %d calls in a row; calls to it fail at once for %ds: %v",
+				b.url, b.failures, config.ChatGPT.CircuitBreaker.Cooldown, err))
+		}
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// State returns the breaker's state
+func (b *CircuitBreaker) State() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.state
+}
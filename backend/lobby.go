@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Bots that log in without a debate_id when no debate is waiting enter the
+// matchmaking lobby instead of being rejected. When a compatible second bot
+// is queued, the server creates a debate on a topic from the matchmaking pool
+// and logs both bots into it, which starts the debate; until they have
+// joined, the debate is reserved for them. Queued bots hear
+// login_queued on entry and periodically while they wait; a bot still queued
+// after the queue timeout is rejected as before. The lobby is per instance.
+
+// LoginQueued tells a bot it is waiting in the matchmaking lobby
+type LoginQueued struct {
+	Status   string `json:"status"`   // Always "queued"
+	Position int    `json:"position"` // 1 is the next bot to be matched
+	Message  string `json:"message"`
+	Deadline string `json:"deadline"` // When the bot is rejected if still unmatched
+}
+
+// lobbyUpdateInterval is how often queued bots hear their position, which
+// also detects bots that have gone away
+const lobbyUpdateInterval = 15 * time.Second
+
+// lobbyReservation is how long a matched debate is held for its two bots
+const lobbyReservation = 30 * time.Second
+
+// lobbyEntry is a bot waiting in the lobby
+type lobbyEntry struct {
+	botUUID string
+	conn    *WSConn
+	matched chan string // Receives the debate the bot was matched into
+}
+
+// Lobby queues bots waiting for an opponent
+type Lobby struct {
+	mutex   sync.Mutex
+	waiting []*lobbyEntry
+
+	// reserved is read while the debate manager is locked, so it has its own
+	// mutex that is never held across debate manager calls
+	reservedMutex sync.Mutex
+	reserved      map[string][2]string // Matched debate -> its two bots
+}
+
+var lobby = &Lobby{reserved: make(map[string][2]string)}
+
+// matchmakingEnabled reports whether unmatched bots queue instead of being rejected
+func matchmakingEnabled() bool {
+	return len(matchmakingTopics()) > 0
+}
+
+// compatible reports whether two queued bots may be matched: they are
+// different bots outside the pairing cooldown
+func compatible(botA, botB string) bool {
+	if botA == botB {
+		return false
+	}
+	cooldown := config.Debate.Pairing.Cooldown
+	if cooldown <= 0 {
+		return true
+	}
+	history, err := db.GetPairDebates(botA, botB, 1)
+	if err != nil {
+		return false
+	}
+	return len(history) == 0 || time.Since(history[0].CreatedAt) >= time.Duration(cooldown)*time.Second
+}
+
+// Wait queues a bot until it is matched and returns the debate it was matched
+// into, or "" if the queue timeout passed or the bot went away. msg is the
+// login message being answered.
+func (l *Lobby) Wait(botUUID string, conn *WSConn, msg *Message) string {
+	entry := &lobbyEntry{botUUID: botUUID, conn: conn, matched: make(chan string, 1)}
+	if debateID := l.join(entry); debateID != "" {
+		return debateID
+	}
+	defer l.leave(entry)
+
+	timeout := time.Duration(config.Debate.Matchmaking.QueueTimeout) * time.Second
+	deadline := time.Now().Add(timeout)
+	expired := time.NewTimer(timeout)
+	defer expired.Stop()
+	ticker := time.NewTicker(lobbyUpdateInterval)
+	defer ticker.Stop()
+
+	notify := func() error {
+		return conn.WriteJSON(createReply(msg.ID, "login_queued", LoginQueued{
+			Status:   "queued",
+			Position: l.position(entry),
+			Message:  "No opponent is waiting, you are queued for matchmaking",
+			Deadline: deadline.Format(time.RFC3339),
+		}))
+	}
+	if notify() != nil {
+		return ""
+	}
+	log.Printf("Bot %s queued for matchmaking", botUUID)
+
+	for {
+		select {
+		case debateID := <-entry.matched:
+			return debateID
+		case <-expired.C:
+			return ""
+		case <-ticker.C:
+			if notify() != nil {
+				return ""
+			}
+		}
+	}
+}
+
+// join matches a bot with the first compatible queued bot, returning the new
+// debate, or queues it and returns ""
+func (l *Lobby) join(entry *lobbyEntry) string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for i, other := range l.waiting {
+		if !compatible(entry.botUUID, other.botUUID) {
+			continue
+		}
+		debate, err := l.createMatch()
+		if err != nil {
+			log.Printf("Failed to create matchmaking debate: %v", err)
+			break
+		}
+		l.reserve(debate.ID, other.botUUID, entry.botUUID)
+		l.waiting = append(l.waiting[:i], l.waiting[i+1:]...)
+		other.matched <- debate.ID
+		log.Printf("Matchmaking paired %s with %s in debate %s", other.botUUID, entry.botUUID, debate.ID)
+		return debate.ID
+	}
+	l.waiting = append(l.waiting, entry)
+	return ""
+}
+
+// createMatch creates a ranked debate on a random topic from the pool
+func (l *Lobby) createMatch() (*Debate, error) {
+	pool := matchmakingTopics()
+	if len(pool) == 0 {
+		return nil, fmt.Errorf("the matchmaking topic pool is empty")
+	}
+	topic := pool[rand.Intn(len(pool))]
+	return debateManager.CreateDebate(topic.Topic, config.Debate.Matchmaking.TotalRounds, DebateOptions{
+		Ranked:   true,
+		Format:   FormatSequential,
+		Scoring:  ScoringHolistic,
+		Category: topic.Category,
+	})
+}
+
+// reserve holds a matched debate for its two bots for a while
+func (l *Lobby) reserve(debateID, botA, botB string) {
+	l.reservedMutex.Lock()
+	defer l.reservedMutex.Unlock()
+	l.reserved[debateID] = [2]string{botA, botB}
+	time.AfterFunc(lobbyReservation, func() {
+		l.reservedMutex.Lock()
+		defer l.reservedMutex.Unlock()
+		delete(l.reserved, debateID)
+	})
+}
+
+// reservedFor returns the bots a matched debate is held for
+func (l *Lobby) reservedFor(debateID string) (botA, botB string, reserved bool) {
+	l.reservedMutex.Lock()
+	defer l.reservedMutex.Unlock()
+	bots, reserved := l.reserved[debateID]
+	return bots[0], bots[1], reserved
+}
+
+// leave removes a bot from the queue if it is still there
+func (l *Lobby) leave(entry *lobbyEntry) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for i, other := range l.waiting {
+		if other == entry {
+			l.waiting = append(l.waiting[:i], l.waiting[i+1:]...)
+			return
+		}
+	}
+}
+
+// position returns a queued bot's place in the queue, 0 once it has left
+func (l *Lobby) position(entry *lobbyEntry) int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for i, other := range l.waiting {
+		if other == entry {
+			return i + 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"strings"
+	"time"
+)
+
+// jsonLogWriter turns each line written by the standard logger into a JSON object
+type jsonLogWriter struct {
+	out io.Writer
+}
+
+func (w *jsonLogWriter) Write(p []byte) (int, error) {
+	msg := strings.TrimRight(string(p), "\n")
+	entry := map[string]string{
+		"time":  time.Now().UTC().Format(time.RFC3339Nano),
+		"level": logLevel(msg),
+		"msg":   msg,
+	}
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := w.out.Write(append(b, '\n')); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// logLevel guesses a severity from the message text, since call sites use plain log.Printf
+func logLevel(msg string) string {
+	lower := strings.ToLower(msg)
+	switch {
+	case strings.HasPrefix(lower, "failed") || strings.HasPrefix(lower, "error") ||
+		strings.Contains(lower, " failed") || strings.Contains(lower, "error "):
+		return "error"
+	case strings.Contains(lower, "timeout") || strings.Contains(lower, "warning"):
+		return "warn"
+	default:
+		return "info"
+	}
+}
+
+// setupLogging configures the standard logger according to the logging config
+func setupLogging(format string, out io.Writer) {
+	log.SetOutput(out)
+	if format == "json" {
+		log.SetFlags(0)
+		log.SetOutput(&jsonLogWriter{out: out})
+	}
+}
@@ -2,11 +2,13 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
-	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -18,6 +20,7 @@ var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool {
 		return true // Allow all origins for development
 	},
+	Subprotocols: supportedSubprotocols,
 }
 
 var (
@@ -25,73 +28,379 @@ var (
 	debateManager *DebateManager
 	config        *Config
 	chatgptClient *ChatGPTClient
+	// houseBotClient generates speeches for the built-in AI opponent
+	houseBotClient *ChatGPTClient
+	llmBudget      *BudgetGuard
+	judgeMetrics   *JudgeMetrics
+	cluster        *Cluster
+	chaos          *ChaosInjector
+	redactor       *Redactor
+	// spectators holds live frontend subscriptions (debateManager, or the replica feed)
+	spectators SpectatorHub
+	// translator fills in missing translations in bilingual debates
+	translator *ChatGPTClient
 )
 
+var ready atomic.Bool
+
 func main() {
+	// Subcommand is the first non-flag argument; default is serve
+	cmd := "serve"
+	args := os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd = args[0]
+		args = args[1:]
+	}
+
+	flags := flag.NewFlagSet(cmd, flag.ExitOnError)
+	configPath := flags.String("config", envOrDefault("CONFIG_PATH", "config.yml"), "path to config.yml")
+	checkOnly := flags.Bool("check-config", false, "validate the config, report every problem and exit")
+	flags.Parse(args)
+
 	// Load configuration
 	var err error
-	config, err = LoadConfig("config.yml")
+	config, err = LoadConfig(*configPath)
+	if *checkOnly {
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s is valid\n", *configPath)
+		return
+	}
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
+	logOutput := os.Stderr
+	if config.Runtime.Container {
+		logOutput = os.Stdout
+	}
+	setupLogging(config.Logging.Format, logOutput)
 	log.Printf("Configuration loaded successfully")
 
+	switch cmd {
+	case "serve":
+		runServe()
+	case "migrate":
+		runMigrate()
+	case "compress":
+		runCompress()
+	case "rebuild-stats":
+		runRebuildStats()
+	default:
+		log.Fatalf("Unknown command: %s (expected serve, migrate, compress or rebuild-stats)", cmd)
+	}
+}
+
+// runMigrate applies pending schema migrations and exits
+func runMigrate() {
+	database, err := NewDatabase(config.Database.Path, config.Database.BusyTimeout)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	if err := database.Migrate(); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+	version, _ := database.SchemaVersion()
+	log.Printf("Database schema is at version %d", version)
+}
+
+// runCompress compresses the existing speech and summary bodies that reach
+// the configured threshold and exits
+func runCompress() {
+	if !config.Database.Compression.Enabled {
+		log.Fatalf("Database compression is disabled in the config")
+	}
+	database, err := NewDatabase(config.Database.Path, config.Database.BusyTimeout)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	if version, _ := database.SchemaVersion(); version < latestSchemaVersion() {
+		log.Fatalf("Database schema is at version %d, run migrate first", version)
+	}
+	database.SetCompression(config.Database.Compression.MinBytes)
+	report, err := database.CompressArchive()
+	if err != nil {
+		log.Fatalf("Failed to compress database: %v", err)
+	}
+	log.Printf("Compressed %d speeches and %d summaries (%d -> %d bytes); run VACUUM to reclaim the space",
+		report.Speeches, report.Summaries, report.BytesBefore, report.BytesAfter)
+}
+
+// runRebuildStats recomputes the stats aggregate tables from the stored results and exits
+func runRebuildStats() {
+	database, err := NewDatabase(config.Database.Path, config.Database.BusyTimeout)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	if version, _ := database.SchemaVersion(); version < latestSchemaVersion() {
+		log.Fatalf("Database schema is at version %d, run migrate first", version)
+	}
+	if err := database.RebuildAggregates(); err != nil {
+		log.Fatalf("Failed to rebuild stats: %v", err)
+	}
+	log.Printf("Stats aggregates rebuilt")
+}
+
+// runServe starts the HTTP server
+func runServe() {
+	var err error
+
+	// Health endpoints are served before the database is ready
+	http.HandleFunc("/healthz", handleHealthz)
+	http.HandleFunc("/readyz", handleReadyz)
+
+	addr := fmt.Sprintf("%s:%d", config.Server.Host, config.Server.Port)
+	server := newHTTPServer(addr, readinessGate(replicaGuard(http.DefaultServeMux)))
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+
 	// Initialize database
-	db, err = NewDatabase(config.Database.Path)
+	if isReplica() {
+		db, err = NewReadOnlyDatabase(config.Database.Path, config.Database.BusyTimeout)
+	} else {
+		db, err = NewDatabase(config.Database.Path, config.Database.BusyTimeout)
+	}
 	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 	defer db.Close()
+	if config.Database.Compression.Enabled {
+		db.SetCompression(config.Database.Compression.MinBytes)
+	}
+
+	if config.Database.SkipMigrations || isReplica() {
+		waitForSchema(db)
+	} else if err := db.Migrate(); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	// Replicas host no debates, so they take no part in bot routing
+	if !isReplica() {
+		cluster = NewCluster(db, config.Cluster.InstanceID, config.Cluster.PublicURL, config.Cluster.Secret)
+		log.Printf("Instance ID: %s", cluster.InstanceID)
+		if dropped, err := db.DropOpenSpectatorSessions(cluster.InstanceID); err != nil {
+			log.Printf("Failed to drop stale spectator sessions: %v", err)
+		} else if dropped > 0 {
+			log.Printf("Dropped %d spectator sessions left open by the previous run", dropped)
+		}
+		if failed, err := db.FailInterruptedRejudgeJobs(cluster.InstanceID); err != nil {
+			log.Printf("Failed to close interrupted rejudge jobs: %v", err)
+		} else if failed > 0 {
+			log.Printf("Marked %d rejudge jobs interrupted by the previous run as failed", failed)
+		}
+		if indexed, err := db.IndexMissingSpeeches(); err != nil {
+			log.Printf("Failed to index speeches for search: %v", err)
+		} else if indexed > 0 {
+			log.Printf("Indexed %d speeches for search", indexed)
+		}
+	}
+
+	if llmBudget, err = NewBudgetGuard(config, db); err != nil {
+		log.Fatalf("Failed to load LLM usage: %v", err)
+	}
+	webhooks = NewWebhookDispatcher(config)
+	rateLimits = NewRateLimits(config)
+	chatLimiter = NewRateLimiter("chat", config.Frontend.Chat.Rate)
+	judgeMetrics = NewJudgeMetrics()
+	if artifacts, err = NewArtifactStore(config, db); err != nil {
+		log.Fatalf("Failed to initialize artifact storage: %v", err)
+	}
 
 	// Initialize ChatGPT client
-	if config.ChatGPT.Judge.Enabled {
-		chatgptClient = NewChatGPTClient(
-			config.ChatGPT.APIKey,
-			config.ChatGPT.APIURL,
-			config.ChatGPT.Model,
-			config.ChatGPT.Timeout,
+	if config.ChatGPT.Judge.Enabled && config.ChatGPT.Judge.Provider == JudgeProviderMock {
+		chatgptClient = newMockJudge()
+		log.Printf("Mock judge enabled: verdicts are computed from transcript features, no LLM is called")
+	} else if config.ChatGPT.Judge.Enabled {
+		chatgptClient = newProfileClient(
+			config.ChatGPT.Judge.Profile,
 			config.ChatGPT.Judge.MaxTokens,
 			config.ChatGPT.Judge.Temperature,
 		)
-		if config.ChatGPT.APIKey != "" && config.ChatGPT.APIKey != "your-api-key-here" {
-			log.Printf("ChatGPT judge enabled (model: %s)", config.ChatGPT.Model)
+		chatgptClient.Fallbacks = newJudgeFallbacks()
+		if chatgptClient.APIKey != "" && chatgptClient.APIKey != "your-api-key-here" {
+			log.Printf("ChatGPT judge enabled (model: %s, fallbacks: %d)", chatgptClient.Model, len(chatgptClient.Fallbacks))
 		} else {
 			log.Printf("ChatGPT judge disabled (API key not configured)")
 		}
 	}
 
+	if config.ChatGPT.HouseBot.Enabled {
+		houseBotClient = newProfileClient(
+			config.ChatGPT.HouseBot.Profile,
+			config.ChatGPT.HouseBot.MaxTokens,
+			config.ChatGPT.HouseBot.Temperature,
+		)
+	}
+
+	if config.Debate.AutoTranslate {
+		translator = newProfileClient(
+			config.ChatGPT.Translation.Profile,
+			config.ChatGPT.Judge.MaxTokens,
+			config.ChatGPT.Judge.Temperature,
+		)
+	}
+	summarizer = newProfileClient(config.ChatGPT.Summary.Profile, summaryMaxTokens, summaryTemperature)
+	if config.ChatGPT.Topics.Enabled {
+		topicGenerator = newProfileClient(config.ChatGPT.Topics.Profile, topicsMaxTokens, topicsTemperature)
+	}
+
+	chaos = NewChaosInjector(config)
+	loadHookCommands(config)
+
+	redactor, err = NewRedactor(config)
+	if err != nil {
+		log.Fatalf("Failed to load redaction rules: %v", err)
+	}
+
 	// Initialize debate manager
 	debateManager = NewDebateManager(db)
+	spectators = debateManager
+	if isReplica() {
+		spectators = NewReplicaFeed(time.Duration(config.Cluster.ReplicaPollInterval) * time.Second)
+		log.Printf("Running as read-only replica")
+	}
+	StartReaper(debateManager, config)
 
 	// Setup routes
-	http.HandleFunc("/debate", handleBotWebSocket)
-	http.HandleFunc("/frontend", handleFrontendWebSocket)
-	http.HandleFunc("/api/debates", handleDebatesAPI)
-	http.HandleFunc("/api/debate/create", handleCreateDebate)
-	http.HandleFunc("/api/debate/", handleGetDebate)
+	http.HandleFunc("/debate", rateLimitByIP(rateLimits.Connect, requireAPIKey(handleBotWebSocket)))
+	http.HandleFunc("/frontend", rateLimitByIP(rateLimits.Connect, requireAPIKey(handleFrontendWebSocket)))
+	http.Handle("/api/debates", withHandlerTimeout(requireAPIKey(handleDebatesAPI)))
+	http.HandleFunc("/api/debate/create", rateLimitByIP(rateLimits.Create, requireAPIKey(handleCreateDebate)))
+	http.HandleFunc("/api/debate/import", requireAdminKey(handleImportDebate))
+	debateRoutes := withHandlerTimeout(handleDebateRoutes)
+	debateEvents := rateLimitByIP(rateLimits.Connect, requireAPIKey(func(w http.ResponseWriter, r *http.Request) {
+		handleDebateEvents(w, r, resolveDebateID(strings.Split(strings.Trim(r.URL.Path, "/"), "/")[2]))
+	}))
+	debateState := rateLimitByIP(rateLimits.Connect, requireAPIKey(func(w http.ResponseWriter, r *http.Request) {
+		handleDebateState(w, r, resolveDebateID(strings.Split(strings.Trim(r.URL.Path, "/"), "/")[2]))
+	}))
+	http.HandleFunc("/api/debate/", func(w http.ResponseWriter, r *http.Request) {
+		// Rejudging waits for the judge model, longer than the handler timeout allows
+		if parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/"); len(parts) == 4 && parts[3] == "rejudge" {
+			requireAdminKey(func(w http.ResponseWriter, r *http.Request) {
+				handleRejudgeDebate(w, r, resolveDebateID(parts[2]))
+			})(w, r)
+			return
+		}
+		// The SSE stream is long-lived, like a spectator WebSocket
+		if parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/"); len(parts) == 4 && parts[3] == "events" {
+			debateEvents(w, r)
+			return
+		}
+		// State requests long-poll for up to maxStateWait
+		if parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/"); len(parts) == 4 && parts[3] == "state" {
+			debateState(w, r)
+			return
+		}
+		debateRoutes.ServeHTTP(w, r)
+	})
+	http.HandleFunc("/api/bots/register", requireAPIKey(handleRegisterBot))
+	http.HandleFunc("/api/judge/status", handleJudgeStatus)
+	http.HandleFunc("/api/capabilities", handleCapabilities)
+	http.HandleFunc("/api/sandbox/score-speech", rateLimitByIP(rateLimits.Create, requireAPIKey(handleSandboxScoreSpeech)))
+	http.HandleFunc("/api/sandbox/debate", rateLimitByIP(rateLimits.Create, requireAPIKey(handleSandboxDebate)))
+	http.HandleFunc("/api/topics/generate", rateLimitByIP(rateLimits.Create, requireAPIKey(handleGenerateTopics)))
+	http.HandleFunc("/api/tournament/create", requireAdminKey(handleCreateTournament))
+	http.Handle("/api/tournament/", withHandlerTimeout(handleTournamentRoutes))
+	http.Handle("/api/replay/", withHandlerTimeout(handleReplayRoutes))
+	http.HandleFunc("/api/league/create", requireAdminKey(handleCreateLeague))
+	http.Handle("/api/league/", withHandlerTimeout(handleLeagueRoutes))
+	http.Handle("/api/leaderboard", withHandlerTimeout(handleLeaderboard))
+	http.Handle("/api/search", withHandlerTimeout(handleSearch))
+	http.Handle("/api/stats/timeseries", withHandlerTimeout(handleStatsTimeseries))
+	http.Handle("/api/stats/usage", withHandlerTimeout(handleStatsUsage))
+	http.Handle("/api/admin/stats", withHandlerTimeout(requireAdminKey(handleAdminStats)))
+	http.HandleFunc("/api/admin/alerts", requireAdminKey(handleAdminAlerts))
+	http.HandleFunc("/api/admin/reaper", requireAdminKey(handleAdminReaper))
+	http.HandleFunc("/api/admin/judge-metrics", requireAdminKey(handleAdminJudgeMetrics))
+	http.HandleFunc("/api/admin/stream", requireAdminKey(handleAdminStream))
+	http.HandleFunc("/api/admin/signals/", requireAdminKey(handleAdminSignals))
+	http.Handle("/metrics", withHandlerTimeout(requireAdminKey(handleMetrics)))
+	http.HandleFunc("/api/admin/rejudge", requireAdminKey(handleRejudgeJobs))
+	http.HandleFunc("/api/admin/rejudge/", requireAdminKey(handleRejudgeJobs))
+	http.HandleFunc("/api/admin/conformance", requireAdminKey(handleAdminConformance))
+	http.HandleFunc("/api/admin/conformance/", requireAdminKey(handleAdminConformance))
+	http.HandleFunc("/api/admin/personas", requireAdminKey(handlePersonas))
+	http.HandleFunc("/api/admin/personas/", requireAdminKey(handlePersonas))
+	http.HandleFunc("/api/admin/templates", requireAdminKey(handleTemplates))
+	http.HandleFunc("/api/admin/templates/", requireAdminKey(handleTemplates))
+	http.HandleFunc("/api/admin/topics", requireAdminKey(handleTopics))
+	http.HandleFunc("/api/admin/topics/", requireAdminKey(handleTopics))
 
 	// Serve static frontend files
-	frontendPath := "../frontend"
+	frontendPath := config.Server.FrontendPath
 	if _, err := os.Stat(frontendPath); !os.IsNotExist(err) {
 		fs := http.FileServer(http.Dir(frontendPath))
 		http.Handle("/", fs)
 	}
 
-	// Start server
-	addr := fmt.Sprintf("%s:%d", config.Server.Host, config.Server.Port)
+	ready.Store(true)
+
 	log.Printf("Server starting on %s", addr)
 	log.Printf("Bot WebSocket: ws://%s/debate", addr)
 	log.Printf("Frontend WebSocket: ws://%s/frontend", addr)
 	log.Printf("Frontend UI: http://%s", addr)
 
-	if err := http.ListenAndServe(addr, nil); err != nil {
-		log.Fatalf("Server failed to start: %v", err)
+	waitForShutdown(server, serverErr)
+}
+
+// waitForSchema blocks until an external `migrate` run has brought the schema up to date
+func waitForSchema(database *Database) {
+	for {
+		version, err := database.SchemaVersion()
+		if err == nil && version >= latestSchemaVersion() {
+			return
+		}
+		log.Printf("Waiting for database migrations (schema version %d, want %d)", version, latestSchemaVersion())
+		time.Sleep(2 * time.Second)
 	}
 }
 
+// readinessGate rejects non-health requests until the server has finished starting up
+func readinessGate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !ready.Load() && r.URL.Path != "/healthz" && r.URL.Path != "/readyz" {
+			http.Error(w, "Service not ready", http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleHealthz reports liveness
+func handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// handleReadyz reports readiness (database open and migrated, not draining)
+func handleReadyz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if shuttingDown.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"status": "draining"})
+		return
+	}
+	if !ready.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"status": "starting"})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]string{"status": "ready"})
+}
+
 // handleBotWebSocket handles WebSocket connections from bots
 func handleBotWebSocket(w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+	conn, err := upgradeWithSubprotocol(w, r)
 	if err != nil {
 		log.Printf("Failed to upgrade connection: %v", err)
 		return
@@ -101,39 +410,74 @@ func handleBotWebSocket(w http.ResponseWriter, r *http.Request) {
 	log.Printf("Bot connected from %s", conn.RemoteAddr())
 
 	// Wait for login message
-	var msg Message
-	if err := conn.ReadJSON(&msg); err != nil {
+	_, raw, err := conn.ReadMessage()
+	if err != nil {
 		log.Printf("Error reading login message: %v", err)
 		return
 	}
 
+	msg, errMsg := decodeMessage(raw, FromBot)
+	if errMsg != nil {
+		errMsg.Recoverable = false
+		writeReply(conn, msg, "error", errMsg)
+		return
+	}
 	if msg.Type != "bot_login" {
-		sendError(conn, "INVALID_MESSAGE_TYPE", "Expected bot_login message", "", false)
+		writeReply(conn, msg, "error", ErrorMessage{
+			ErrorCode:   "INVALID_MESSAGE_TYPE",
+			Message:     "Expected bot_login message",
+			Recoverable: false,
+		})
 		return
 	}
+	loginReq := *msg.Data.(*LoginRequest)
+	loginReq.DebateID = resolveDebateID(loginReq.DebateID)
+	loginReq.internal = isInternalRequest(r)
 
-	// Parse login request
-	loginData, err := json.Marshal(msg.Data)
-	if err != nil {
-		sendError(conn, "INVALID_MESSAGE_FORMAT", "Failed to parse login data", "", false)
+	// A bot logging in again must bring an affinity token issued for its debate
+	if loginReq.AffinityToken == "" {
+		loginReq.AffinityToken = r.URL.Query().Get("affinity")
+	}
+	if loginReq.AffinityToken != "" && !cluster.VerifyAffinityToken(loginReq.AffinityToken, loginReq.DebateID, loginReq.BotUUID) {
+		writeReply(conn, msg, "login_rejected", LoginRejected{
+			Status:   "rejected",
+			Reason:   "invalid_affinity_token",
+			Message:  "The affinity token was not issued for this debate and bot_uuid",
+			DebateID: loginReq.DebateID,
+		})
 		return
 	}
 
-	var loginReq LoginRequest
-	if err := json.Unmarshal(loginData, &loginReq); err != nil {
-		sendError(conn, "INVALID_MESSAGE_FORMAT", "Invalid login request format", "", false)
+	// Send the bot to the instance that owns its debate, if that is not us
+	if redirect := cluster.RedirectFor(loginReq.DebateID, loginReq.BotUUID); redirect != nil {
+		writeReply(conn, msg, "login_redirect", redirect)
+		log.Printf("Redirected bot %s to instance %s for debate %s", loginReq.BotName, redirect.InstanceID, loginReq.DebateID)
 		return
 	}
 
-	// Process login
+	// Process login; with matchmaking, a bot finding no debate waits in the lobby
 	confirmed, rejected := debateManager.BotLogin(&loginReq, conn)
+	if rejected != nil && rejected.Reason == "no_available_debate" && matchmakingEnabled() {
+		if debateID := lobby.Wait(loginReq.BotUUID, conn, msg); debateID != "" {
+			loginReq.DebateID = debateID
+			confirmed, rejected = debateManager.BotLogin(&loginReq, conn)
+		} else {
+			rejected.Message = "No opponent was found before the matchmaking queue timed out"
+		}
+	}
 	if rejected != nil {
-		conn.WriteJSON(createMessage("login_rejected", rejected))
+		writeReply(conn, msg, "login_rejected", rejected)
 		return
 	}
+	confirmed.InstanceID = cluster.InstanceID
+	confirmed.AffinityToken = cluster.AffinityToken(confirmed.DebateID, loginReq.BotUUID)
 
-	conn.WriteJSON(createMessage("login_confirmed", confirmed))
+	writeReply(conn, msg, "login_confirmed", confirmed)
 	log.Printf("Bot %s logged in to debate %s", confirmed.BotIdentifier, loginReq.DebateID)
+	if confirmed.Reconnected {
+		debateManager.ResumeAfterReconnect(confirmed.DebateID, conn)
+	}
+	deliverPendingResults(conn, loginReq.BotUUID)
 
 	// Start heartbeat monitoring for this bot
 	quitHeartbeat := make(chan bool)
@@ -147,11 +491,15 @@ func handleBotWebSocket(w http.ResponseWriter, r *http.Request) {
 		for {
 			select {
 			case <-ticker.C:
+				// Spectators hear about a bot falling behind before it is dropped
+				if missedPings > 0 && missedPings < 3 {
+					debateManager.ReportHeartbeat(loginReq.DebateID, confirmed.BotIdentifier, missedPings)
+				}
 				// Check if we missed too many pongs (3 strikes)
 				if missedPings >= 3 {
 					log.Printf("Bot %s missed 3 pings, disconnecting", confirmed.BotIdentifier)
 					// Handle heartbeat timeout
-					debateManager.HandleBotDisconnect(loginReq.DebateID, confirmed.BotIdentifier, "heartbeat_timeout")
+					debateManager.HandleBotDisconnect(loginReq.DebateID, confirmed.BotIdentifier, "heartbeat_timeout", conn)
 					conn.Close()
 					return
 				}
@@ -176,23 +524,60 @@ func handleBotWebSocket(w http.ResponseWriter, r *http.Request) {
 
 	// Handle subsequent messages
 	for {
-		var msg Message
-		if err := conn.ReadJSON(&msg); err != nil {
+		_, raw, err := conn.ReadMessage()
+		if err != nil {
 			log.Printf("Bot disconnected: %v", err)
 			// Handle bot disconnection
-			debateManager.HandleBotDisconnect(loginReq.DebateID, confirmed.BotIdentifier, "connection_lost")
+			debateManager.HandleBotDisconnect(loginReq.DebateID, confirmed.BotIdentifier, "connection_lost", conn)
 			break
 		}
 
+		msg, errMsg := decodeMessage(raw, FromBot)
+		if errMsg != nil {
+			errMsg.DebateID = loginReq.DebateID
+			writeReply(conn, msg, "error", errMsg)
+			continue
+		}
+
 		switch msg.Type {
 		case "debate_speech":
-			handleBotSpeech(conn, msg)
+			if allowSpeech(conn, msg, loginReq.BotUUID, loginReq.DebateID) {
+				handleBotSpeech(conn, msg)
+			}
+		case "opening_submission":
+			if allowSpeech(conn, msg, loginReq.BotUUID, loginReq.DebateID) {
+				handleOpeningSubmission(conn, msg)
+			}
+		case "side_signal":
+			signal := msg.Data.(*SideSignal)
+			signal.DebateID = resolveDebateID(signal.DebateID)
+			if errMsg := debateManager.HandleSideSignal(signal, conn, msg.ID); errMsg != nil {
+				writeReply(conn, msg, "error", errMsg)
+			}
+		case "get_state":
+			stateReq := msg.Data.(*StateRequest)
+			stateReq.DebateID = resolveDebateID(stateReq.DebateID)
+			if errMsg := debateManager.HandleStateRequest(stateReq, conn, msg.ID); errMsg != nil {
+				writeReply(conn, msg, "error", errMsg)
+			}
 		case "pong":
-			// Reset missed pings counter when pong is received
+			if chaos.DropPong() {
+				continue
+			}
+			// Reset missed pings counter when pong is received; more than the
+			// ping just sent means spectators were told the bot fell behind
+			if missedPings > 1 {
+				debateManager.ReportHeartbeat(loginReq.DebateID, confirmed.BotIdentifier, 0)
+			}
 			missedPings = 0
 			log.Printf("Received pong from bot %s", confirmed.BotIdentifier)
-		default:
-			log.Printf("Unknown message type from bot: %s", msg.Type)
+		case "bot_login":
+			writeReply(conn, msg, "error", ErrorMessage{
+				ErrorCode:   "ALREADY_LOGGED_IN",
+				Message:     "This connection is already logged in",
+				DebateID:    loginReq.DebateID,
+				Recoverable: true,
+			})
 		}
 	}
 
@@ -201,144 +586,291 @@ func handleBotWebSocket(w http.ResponseWriter, r *http.Request) {
 }
 
 // handleBotSpeech processes a speech from a bot
-func handleBotSpeech(conn *websocket.Conn, msg Message) {
-	speechData, err := json.Marshal(msg.Data)
-	if err != nil {
-		sendError(conn, "INVALID_MESSAGE_FORMAT", "Failed to parse speech data", "", true)
-		return
-	}
+func handleBotSpeech(conn *WSConn, msg *Message) {
+	speech := msg.Data.(*DebateSpeech)
+	speech.DebateID = resolveDebateID(speech.DebateID)
 
-	var speech DebateSpeech
-	if err := json.Unmarshal(speechData, &speech); err != nil {
-		sendError(conn, "INVALID_MESSAGE_FORMAT", "Invalid speech format", "", true)
-		return
+	// Process speech
+	if errMsg := debateManager.HandleSpeech(speech, conn, msg.ID); errMsg != nil {
+		writeReply(conn, msg, "error", errMsg)
 	}
+}
 
-	// Process speech
-	if errMsg := debateManager.HandleSpeech(&speech, conn); errMsg != nil {
-		conn.WriteJSON(createMessage("error", errMsg))
+// handleOpeningSubmission handles a blind opening statement sent before the debate starts
+func handleOpeningSubmission(conn *WSConn, msg *Message) {
+	speech := msg.Data.(*DebateSpeech)
+	speech.DebateID = resolveDebateID(speech.DebateID)
+
+	if errMsg := debateManager.HandleOpeningSubmission(speech, conn, msg.ID); errMsg != nil {
+		writeReply(conn, msg, "error", errMsg)
 	}
 }
 
 // handleFrontendWebSocket handles WebSocket connections from frontend
 func handleFrontendWebSocket(w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+	conn, err := upgradeWithSubprotocol(w, r)
 	if err != nil {
 		log.Printf("Failed to upgrade frontend connection: %v", err)
 		return
 	}
 	defer conn.Close()
 
+	stopKeepalive := startFrontendKeepalive(conn)
+	defer close(stopKeepalive)
+
 	log.Printf("Frontend connected from %s", conn.RemoteAddr())
 
-	var debateID string
+	var debateID, tournamentID string
+	var replay *ReplaySession
+	chat := NewChatSession(clientIP(r))
 
 	// Wait for subscribe message
 	for {
-		var msg Message
-		if err := conn.ReadJSON(&msg); err != nil {
-			log.Printf("Frontend disconnected: %v", err)
+		_, raw, err := conn.ReadMessage()
+		if err != nil {
+			if isIdleTimeout(err) {
+				log.Printf("Frontend %s idle for %ds, disconnecting", conn.RemoteAddr(), config.Frontend.IdleTimeout)
+			} else {
+				log.Printf("Frontend disconnected: %v", err)
+			}
 			break
 		}
+		extendFrontendDeadline(conn)
+
+		msg, errMsg := decodeMessage(raw, FromFrontend)
+		if errMsg != nil {
+			errMsg.DebateID = debateID
+			writeReply(conn, msg, "error", errMsg)
+			continue
+		}
 
 		switch msg.Type {
 		case "subscribe_debate":
-			data, _ := json.Marshal(msg.Data)
-			var sub SubscribeDebate
-			if err := json.Unmarshal(data, &sub); err != nil {
+			sub := msg.Data.(*SubscribeDebate)
+			sub.DebateID = resolveDebateID(sub.DebateID)
+
+			filter, err := newEventFilter(sub.Events)
+			if err != nil {
+				writeReply(conn, msg, "error", ErrorMessage{
+					ErrorCode:   "INVALID_FIELD",
+					Message:     err.Error(),
+					DebateID:    sub.DebateID,
+					Details:     "events",
+					Recoverable: true,
+				})
 				continue
 			}
 
-			debateID = sub.DebateID
-			if err := debateManager.AddFrontendConnection(debateID, conn); err != nil {
-				log.Printf("Failed to subscribe: %v", err)
+			// Switching debates drops the previous subscription
+			if debateID != "" && debateID != sub.DebateID {
+				rooms.Leave(debateID, conn)
+				debateID = ""
+			}
+
+			subscriber := &Subscriber{Filter: filter, Language: sub.Language}
+			position, err := rooms.Join(sub.DebateID, sub.Token, subscriber, conn)
+			if err != nil {
+				if err != errDebateNotFound {
+					writeReply(conn, msg, "subscribe_rejected", subscribeRejection(sub.DebateID, err))
+					continue
+				}
+				// Finished debates are no longer live but can still be viewed
+				debate, dbErr := db.GetDebate(sub.DebateID)
+				if dbErr != nil {
+					writeReply(conn, msg, "subscribe_rejected", subscribeRejection(sub.DebateID, errDebateNotFound))
+					continue
+				}
+				if err := checkSpectatorAccess(debate, sub.Token); err != nil {
+					writeReply(conn, msg, "subscribe_rejected", subscribeRejection(sub.DebateID, err))
+					continue
+				}
+				sendCurrentDebateState(conn, sub.DebateID, sub.Language)
+				sendChatHistory(conn, sub.DebateID, subscriber)
 				continue
 			}
 
+			debateID = sub.DebateID
+			if position > 0 {
+				writeReply(conn, msg, "room_full", roomFull(debateID, position))
+				continue
+			}
 			log.Printf("Frontend subscribed to debate %s", debateID)
 
 			// Send current state
-			sendCurrentDebateState(conn, debateID)
+			sendCurrentDebateState(conn, debateID, sub.Language)
+			sendChatHistory(conn, debateID, subscriber)
+
+		case "unsubscribe_debate":
+			if debateID == "" {
+				writeReply(conn, msg, "error", ErrorMessage{
+					ErrorCode:   "NOT_SUBSCRIBED",
+					Message:     "Not subscribed to any debate",
+					Recoverable: true,
+				})
+				continue
+			}
+			rooms.Leave(debateID, conn)
+			log.Printf("Frontend unsubscribed from debate %s", debateID)
+			debateID = ""
+
+		case "subscribe_tournament":
+			sub := msg.Data.(*SubscribeTournament)
+			tournament, err := db.GetTournament(sub.TournamentID)
+			if err != nil {
+				writeReply(conn, msg, "error", ErrorMessage{
+					ErrorCode:   "TOURNAMENT_NOT_FOUND",
+					Message:     "Tournament not found",
+					Details:     sub.TournamentID,
+					Recoverable: true,
+				})
+				continue
+			}
+			if tournamentID != "" {
+				tournamentSubscribers.Unsubscribe(tournamentID, conn)
+			}
+			tournamentID = tournament.ID
+			tournamentSubscribers.Subscribe(tournamentID, conn)
+			writeReply(conn, msg, "tournament_state", tournament)
+
+		case "unsubscribe_tournament":
+			if tournamentID == "" {
+				writeReply(conn, msg, "error", ErrorMessage{
+					ErrorCode:   "NOT_SUBSCRIBED",
+					Message:     "Not subscribed to any tournament",
+					Recoverable: true,
+				})
+				continue
+			}
+			tournamentSubscribers.Unsubscribe(tournamentID, conn)
+			tournamentID = ""
+
+		case "join_replay":
+			join := msg.Data.(*JoinReplay)
+			session := replays.Get(join.SessionID)
+			if session == nil {
+				writeReply(conn, msg, "error", ErrorMessage{
+					ErrorCode:   "REPLAY_NOT_FOUND",
+					Message:     "Replay session not found",
+					Details:     join.SessionID,
+					Recoverable: true,
+				})
+				continue
+			}
+			if replay != nil {
+				replay.Leave(conn)
+			}
+			replay = session
+			replay.Join(conn, msg)
+
+		case "leave_replay":
+			if replay == nil {
+				writeReply(conn, msg, "error", ErrorMessage{
+					ErrorCode:   "NOT_SUBSCRIBED",
+					Message:     "Not watching any replay",
+					Recoverable: true,
+				})
+				continue
+			}
+			replay.Leave(conn)
+			replay = nil
+
+		case "replay_debate":
+			handleReplayDebate(conn, msg)
+
+		case "stop_replay":
+			if !debateManager.replayer.Stop(conn) {
+				writeReply(conn, msg, "error", ErrorMessage{
+					ErrorCode:   "NOT_SUBSCRIBED",
+					Message:     "Not watching any replay",
+					Recoverable: true,
+				})
+			}
+
+		case "chat_message":
+			chat.Send(conn, msg, debateID)
 
 		case "ping":
-			conn.WriteJSON(createMessage("pong", map[string]string{
+			writeReply(conn, msg, "pong", map[string]string{
 				"server_time": getNow(),
-			}))
+			})
 		}
 	}
 
 	// Cleanup on disconnect
 	if debateID != "" {
-		debateManager.RemoveFrontendConnection(debateID, conn)
+		rooms.Leave(debateID, conn)
+	}
+	if tournamentID != "" {
+		tournamentSubscribers.Unsubscribe(tournamentID, conn)
 	}
+	if replay != nil {
+		replay.Leave(conn)
+	}
+	debateManager.replayer.Stop(conn)
 }
 
 // sendCurrentDebateState sends the current debate state to a newly connected frontend
-func sendCurrentDebateState(conn *websocket.Conn, debateID string) {
+func sendCurrentDebateState(conn *WSConn, debateID, language string) {
+	debate, msg := currentDebateState(debateID)
+	if msg != nil {
+		conn.WriteJSON(localizeMessage(debate, *msg, language))
+	}
+}
+
+// currentDebateState builds the debate_snapshot describing a debate's stored
+// state. Both are nil when the debate does not exist.
+func currentDebateState(debateID string) (*Debate, *Message) {
 	debate, err := db.GetDebate(debateID)
 	if err != nil {
-		return
+		return nil, nil
 	}
 
 	bots, _ := db.GetBots(debateID)
 	debateLog, _ := db.GetDebateLog(debateID)
+	if debateLog == nil {
+		debateLog = []DebateLogEntry{}
+	}
 
-	var supportingBot, opposingBot *Bot
+	limits := formatLimitsFor(debate)
+	snapshot := DebateSnapshot{
+		DebateID:         debateID,
+		Topic:            debate.Topic,
+		Status:           debate.Status,
+		Format:           debate.Format,
+		Scoring:          debate.Scoring,
+		Ranked:           debate.Ranked,
+		TotalRounds:      debate.TotalRounds,
+		CurrentRound:     debate.CurrentRound,
+		JoinedBots:       []string{},
+		MinContentLength: limits.MinContentLength,
+		MaxContentLength: limits.MaxContentLength,
+		TimeoutSeconds:   limits.SpeechTimeout,
+		DebateLog:        debateLog,
+		Rules:            debate.Rules,
+		Sequence:         len(debateLog),
+	}
 	for _, bot := range bots {
+		snapshot.JoinedBots = append(snapshot.JoinedBots, bot.BotIdentifier)
 		if bot.Side == "supporting" {
-			supportingBot = bot
+			snapshot.SupportingSide = bot.BotIdentifier
 		} else if bot.Side == "opposing" {
-			opposingBot = bot
+			snapshot.OpposingSide = bot.BotIdentifier
 		}
 	}
-
 	if debate.Status == "completed" || debate.Status == "timeout" {
-		// Send debate end
-		result, _ := db.GetDebateResult(debateID)
-		if result != nil {
-			endMsg := createMessage("debate_end", DebateEnd{
-				DebateID:       debateID,
-				Topic:          debate.Topic,
-				SupportingSide: supportingBot.BotIdentifier,
-				OpposingSide:   opposingBot.BotIdentifier,
-				TotalRounds:    debate.TotalRounds,
-				Status:         debate.Status,
-				DebateLog:      debateLog,
-				DebateResult:   *result,
-			})
-			conn.WriteJSON(endMsg)
-		}
-	} else if debate.Status == "active" && supportingBot != nil && opposingBot != nil {
-		// Send debate update
-		updateMsg := createMessage("debate_update", DebateUpdate{
-			DebateID:         debateID,
-			Topic:            debate.Topic,
-			SupportingSide:   supportingBot.BotIdentifier,
-			OpposingSide:     opposingBot.BotIdentifier,
-			TotalRounds:      debate.TotalRounds,
-			CurrentRound:     debate.CurrentRound,
-			MinContentLength: config.Debate.MinContentLength,
-			MaxContentLength: config.Debate.MaxContentLength,
-			DebateLog:        debateLog,
-		})
-		conn.WriteJSON(updateMsg)
-	} else if debate.Status == "waiting" {
-		// Send debate waiting state with joined bots
-		joinedBots := []string{}
-		for _, bot := range bots {
-			joinedBots = append(joinedBots, bot.BotIdentifier)
+		if result, err := db.GetDebateResult(debateID); err == nil {
+			attachCitations(debateLog, result.Citations)
+			snapshot.DebateResult = result
 		}
-		waitingMsg := createMessage("debate_waiting", DebateWaiting{
-			DebateID:    debateID,
-			Topic:       debate.Topic,
-			TotalRounds: debate.TotalRounds,
-			Status:      debate.Status,
-			JoinedBots:  joinedBots,
-		})
-		conn.WriteJSON(waitingMsg)
 	}
+
+	msg := createMessage("debate_snapshot", snapshot)
+	return debate, &msg
 }
 
+// defaultTotalRounds is used when a create request gives no total_rounds
+const defaultTotalRounds = 5
+
 // handleCreateDebate handles debate creation from frontend
 func handleCreateDebate(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -346,32 +878,145 @@ func handleCreateDebate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if shuttingDown.Load() {
+		http.Error(w, "Server is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+
 	var req CreateDebateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
 
+	if req.TemplateID != "" {
+		template, err := db.GetTemplate(req.TemplateID)
+		if err != nil {
+			http.Error(w, "Template not found", http.StatusBadRequest)
+			return
+		}
+		if err := applyTemplate(&req, template); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
 	if req.Topic == "" {
 		http.Error(w, "Topic is required", http.StatusBadRequest)
 		return
 	}
 
 	if req.TotalRounds <= 0 {
-		req.TotalRounds = 5
+		req.TotalRounds = defaultTotalRounds
+	}
+	if err := validateRounds(req.TotalRounds); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	opts := DebateOptions{Ranked: true, Format: FormatSequential, BlindOpening: req.BlindOpening, Private: req.Private, SideChannel: req.SideChannel, NoAIJudge: req.NoAIJudge}
+	if req.Ranked != nil {
+		opts.Ranked = *req.Ranked
+	}
+	opts.DiscloseRubric = config.Debate.DiscloseRubric
+	if req.DiscloseRubric != nil {
+		opts.DiscloseRubric = *req.DiscloseRubric
 	}
+	opts.OpponentSummary = config.Debate.OpponentSummary
+	if req.OpponentSummary != nil {
+		opts.OpponentSummary = *req.OpponentSummary
+	}
+	switch req.Scoring {
+	case "", ScoringHolistic:
+		opts.Scoring = ScoringHolistic
+	case ScoringRounds, ScoringCumulative:
+		opts.Scoring = req.Scoring
+	default:
+		http.Error(w, "Unknown scoring mode", http.StatusBadRequest)
+		return
+	}
+	switch req.Format {
+	case "", FormatSequential:
+	case FormatSimultaneous:
+		opts.Format = FormatSimultaneous
+	default:
+		http.Error(w, "Unknown format", http.StatusBadRequest)
+		return
+	}
+	if err := validateLanguages(req.Languages, req.TopicTranslations); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	opts.Languages = req.Languages
+	opts.TopicTranslations = req.TopicTranslations
+	opts.Category = strings.ToLower(strings.TrimSpace(req.Category))
+	seats, err := parseSeats(req.Seats, req.Participants)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if seats != nil && (opts.Format == FormatSimultaneous || opts.BlindOpening) {
+		http.Error(w, "Panel debates only support the sequential format without blind openings", http.StatusBadRequest)
+		return
+	}
+	opts.Seats = seats
+	if err := req.Verdict.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	opts.Verdict = req.Verdict
+	if err := req.Limits.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	opts.Limits = req.Limits
 
-	debate, err := debateManager.CreateDebate(req.Topic, req.TotalRounds)
+	var persona *Persona
+	if req.HouseOpponent || req.PersonaID != "" {
+		personaID := req.PersonaID
+		if personaID == "" {
+			personaID = defaultPersonaID
+		}
+		p, err := db.GetPersona(personaID)
+		if err != nil {
+			http.Error(w, "Persona not found", http.StatusBadRequest)
+			return
+		}
+		persona = p
+		opts.PersonaID = p.ID
+	}
+
+	debate, err := debateManager.CreateDebate(req.Topic, req.TotalRounds, opts)
 	if err != nil {
 		http.Error(w, "Failed to create debate", http.StatusInternalServerError)
 		return
 	}
 
 	response := DebateCreated{
-		DebateID:    debate.ID,
-		Topic:       debate.Topic,
-		TotalRounds: debate.TotalRounds,
-		Status:      debate.Status,
+		DebateID:     debate.ID,
+		ShortID:      debate.ShortID,
+		Topic:        debate.Topic,
+		TotalRounds:  debate.TotalRounds,
+		Status:       debate.Status,
+		Ranked:       debate.Ranked,
+		PersonaID:    debate.PersonaID,
+		Format:       debate.Format,
+		BlindOpening: debate.BlindOpening,
+		Scoring:      debate.Scoring,
+
+		Seats: debate.Seats,
+
+		Private:        debate.Private,
+		SpectatorToken: debate.SpectatorToken,
+		SideChannel:    debate.SideChannel,
+
+		Verdict: debate.Verdict,
+		Limits:  debate.Limits,
+		Judging: judgingFor(debate),
+	}
+
+	if persona != nil {
+		go StartHouseBot(debate.ID, persona)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -387,40 +1032,100 @@ func handleDebatesAPI(w http.ResponseWriter, r *http.Request) {
 	}
 
 	status := r.URL.Query().Get("status")
-	debates, err := db.GetAllDebates(status)
+	includeUnranked := r.URL.Query().Get("include_unranked") == "true"
+	debates, err := db.GetAllDebates(status, includeUnranked)
 	if err != nil {
 		http.Error(w, "Failed to fetch debates", http.StatusInternalServerError)
 		return
 	}
 
+	// Private debates are only reachable with their spectator token
+	public := []*Debate{}
+	for _, debate := range debates {
+		if !debate.Private {
+			public = append(public, debate)
+		}
+	}
+	debates = public
+	for i, debate := range debates {
+		debates[i] = redactor.Debate(debate)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(debates)
 }
 
+// handleDebateRoutes handles GET /api/debate/{id} and its sub-resources
+func handleDebateRoutes(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/debate/"), "/"), "/")
+	parts[0] = resolveDebateID(parts[0])
+
+	switch {
+	case parts[0] == "":
+		http.NotFound(w, r)
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		if authorizeAdmin(w, r) {
+			handleCancelDebate(w, r, parts[0])
+		}
+	case len(parts) == 1:
+		handleGetDebate(w, r, parts[0])
+	case len(parts) == 2 && parts[1] == "results":
+		handleDebateResults(w, r, parts[0])
+	case len(parts) == 2 && (parts[1] == "pause" || parts[1] == "resume"):
+		if authorizeAdmin(w, r) {
+			handleDebatePause(w, r, parts[0], parts[1] == "pause")
+		}
+	case len(parts) == 2 && parts[1] == "cancel":
+		if authorizeAdmin(w, r) {
+			handleCancelDebate(w, r, parts[0])
+		}
+	case len(parts) == 2 && parts[1] == "export":
+		handleExportDebate(w, r, parts[0])
+	case len(parts) == 3 && parts[1] == "artifacts":
+		handleDebateArtifact(w, r, parts[0], parts[2])
+	case len(parts) == 2 && parts[1] == "replay":
+		handleCreateReplay(w, r, parts[0])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
 // handleGetDebate returns a specific debate
-func handleGetDebate(w http.ResponseWriter, r *http.Request) {
+func handleGetDebate(w http.ResponseWriter, r *http.Request, debateID string) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	debateID := filepath.Base(r.URL.Path)
 	debate, err := db.GetDebate(debateID)
 	if err != nil {
 		http.Error(w, "Debate not found", http.StatusNotFound)
 		return
 	}
+	if err := checkSpectatorAccess(debate, r.URL.Query().Get("token")); err != nil {
+		// Don't reveal that a private debate exists
+		http.Error(w, "Debate not found", http.StatusNotFound)
+		return
+	}
 
 	bots, _ := db.GetBots(debateID)
 	debateLog, _ := db.GetDebateLog(debateID)
 	result, _ := db.GetDebateResult(debateID)
+	if result != nil {
+		attachCitations(debateLog, result.Citations)
+	}
 
 	response := map[string]interface{}{
-		"debate":     debate,
-		"bots":       bots,
-		"debate_log": debateLog,
-		"result":     result,
+		"bots":       publicBots(bots),
+		"debate_log": redactor.Log(debateLog),
+		"result":     redactor.Result(result),
+	}
+	// A debate running here is ahead of its row; report the live state
+	if live, ok := debateManager.LiveState(debateID); ok {
+		debate = live.overlay(debate)
+		response["live"] = live
 	}
+	response["debate"] = redactor.Debate(debate)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
@@ -428,7 +1133,19 @@ func handleGetDebate(w http.ResponseWriter, r *http.Request) {
 
 // Helper functions
 
-func sendError(conn *websocket.Conn, errorCode, message, debateID string, recoverable bool) {
+// findSides returns the supporting and opposing bots of a debate, if assigned
+func findSides(bots []*Bot) (supporting, opposing *Bot) {
+	for _, bot := range bots {
+		if bot.Side == "supporting" {
+			supporting = bot
+		} else if bot.Side == "opposing" {
+			opposing = bot
+		}
+	}
+	return supporting, opposing
+}
+
+func sendError(conn *WSConn, errorCode, message, debateID string, recoverable bool) {
 	errMsg := createMessage("error", ErrorMessage{
 		ErrorCode:   errorCode,
 		Message:     message,
@@ -438,6 +1155,38 @@ func sendError(conn *websocket.Conn, errorCode, message, debateID string, recove
 	conn.WriteJSON(errMsg)
 }
 
+// writeReply sends a message answering req; req may be nil when the request could not be read
+func writeReply(conn *WSConn, req *Message, msgType string, data interface{}) {
+	replyTo := ""
+	if req != nil {
+		replyTo = req.ID
+	}
+	if msgType == "error" {
+		data = explainError(data)
+	}
+	conn.WriteJSON(createReply(replyTo, msgType, data))
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeJSONStatus is writeJSON with a status other than 200; the header has
+// to be set before the status is written
+func writeJSONStatus(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func envOrDefault(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
 func getNow() string {
-	return createMessage("", nil).Timestamp
+	return time.Now().Format(time.RFC3339)
 }
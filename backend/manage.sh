@@ -81,6 +81,11 @@ case "$1" in
         fi
         ;;
 
+    migrate)
+        echo "🗄️  Running database migrations..."
+        $BINARY migrate
+        ;;
+
     logs)
         if [ -f "$LOG_FILE" ]; then
             tail -f "$LOG_FILE"
@@ -93,13 +98,14 @@ case "$1" in
     *)
         echo "Debate Platform Server Manager"
         echo ""
-        echo "Usage: $0 {start|stop|restart|status|logs}"
+        echo "Usage: $0 {start|stop|restart|status|migrate|logs}"
         echo ""
         echo "Commands:"
         echo "  start    - Start the server"
         echo "  stop     - Stop the server"
         echo "  restart  - Restart the server"
         echo "  status   - Show server status"
+        echo "  migrate  - Apply database migrations"
         echo "  logs     - Follow server logs"
         exit 1
         ;;
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Message directions
+const (
+	FromBot      = "bot"      // Bot -> server
+	FromFrontend = "frontend" // Spectator -> server
+	FromServer   = "server"   // Server -> bot or spectator
+)
+
+// currentPayloadVersion is assumed when a message carries no version
+const currentPayloadVersion = 1
+
+// MessageSpec describes one message type: the fields its data must carry and
+// the payload struct for each supported version
+type MessageSpec struct {
+	Required []string
+	Payloads map[int]func() interface{}
+}
+
+// v1 registers a single version-1 payload struct
+func v1(newPayload func() interface{}) map[int]func() interface{} {
+	return map[int]func() interface{}{1: newPayload}
+}
+
+func heartbeatPayload() interface{} { return &map[string]string{} }
+
+// messageRegistry is the central list of message types per direction.
+// Inbound messages are validated against it before any handler sees them.
+var messageRegistry = map[string]map[string]MessageSpec{
+	FromBot: {
+		"bot_login":          {Required: []string{"bot_name"}, Payloads: v1(func() interface{} { return &LoginRequest{} })},
+		"debate_speech":      {Required: []string{"debate_id", "debate_key", "speaker", "message"}, Payloads: v1(func() interface{} { return &DebateSpeech{} })},
+		"opening_submission": {Required: []string{"debate_id", "debate_key", "speaker", "message"}, Payloads: v1(func() interface{} { return &DebateSpeech{} })},
+		"get_state":          {Required: []string{"debate_id", "debate_key"}, Payloads: v1(func() interface{} { return &StateRequest{} })},
+		"side_signal":        {Required: []string{"debate_id", "debate_key", "speaker", "kind"}, Payloads: v1(func() interface{} { return &SideSignal{} })},
+		"pong":               {Payloads: v1(heartbeatPayload)},
+	},
+	FromFrontend: {
+		"subscribe_debate":       {Required: []string{"debate_id"}, Payloads: v1(func() interface{} { return &SubscribeDebate{} })},
+		"unsubscribe_debate":     {Payloads: v1(heartbeatPayload)},
+		"subscribe_tournament":   {Required: []string{"tournament_id"}, Payloads: v1(func() interface{} { return &SubscribeTournament{} })},
+		"unsubscribe_tournament": {Payloads: v1(heartbeatPayload)},
+		"join_replay":            {Required: []string{"session_id"}, Payloads: v1(func() interface{} { return &JoinReplay{} })},
+		"leave_replay":           {Payloads: v1(heartbeatPayload)},
+		"replay_debate":          {Required: []string{"debate_id"}, Payloads: v1(func() interface{} { return &ReplayDebate{} })},
+		"stop_replay":            {Payloads: v1(heartbeatPayload)},
+		"chat_message":           {Required: []string{"content"}, Payloads: v1(func() interface{} { return &ChatSend{} })},
+		"ping":                   {Payloads: v1(heartbeatPayload)},
+	},
+	FromServer: {
+		"login_confirmed":     {Payloads: v1(func() interface{} { return &LoginConfirmed{} })},
+		"login_rejected":      {Payloads: v1(func() interface{} { return &LoginRejected{} })},
+		"login_redirect":      {Payloads: v1(func() interface{} { return &LoginRedirect{} })},
+		"debate_waiting":      {Payloads: v1(func() interface{} { return &DebateWaiting{} })},
+		"debate_start":        {Payloads: v1(func() interface{} { return &DebateStart{} })},
+		"debate_update":       {Payloads: v1(func() interface{} { return &DebateUpdate{} })},
+		"debate_state":        {Payloads: v1(func() interface{} { return &DebateUpdate{} })},
+		"speech_received":     {Payloads: v1(func() interface{} { return &SpeechReceived{} })},
+		"opening_received":    {Payloads: v1(func() interface{} { return &OpeningReceived{} })},
+		"opening_reveal":      {Payloads: v1(func() interface{} { return &RoundReveal{} })},
+		"round_reveal":        {Payloads: v1(func() interface{} { return &RoundReveal{} })},
+		"round_result":        {Payloads: v1(func() interface{} { return &RoundResultMessage{} })},
+		"speech_translation":  {Payloads: v1(func() interface{} { return &SpeechTranslation{} })},
+		"turn_countdown":      {Payloads: v1(func() interface{} { return &TurnCountdown{} })},
+		"debate_overtime":     {Payloads: v1(func() interface{} { return &DebateOvertime{} })},
+		"judging_in_progress": {Payloads: v1(func() interface{} { return &JudgingProgress{} })},
+		"judging_stage":       {Payloads: v1(func() interface{} { return &JudgingStage{} })},
+		"judge_stream":        {Payloads: v1(func() interface{} { return &JudgeStream{} })},
+		"debate_end":          {Payloads: v1(func() interface{} { return &DebateEnd{} })},
+		"result_available":    {Payloads: v1(func() interface{} { return &ResultAvailable{} })},
+		"debate_snapshot":     {Payloads: v1(func() interface{} { return &DebateSnapshot{} })},
+		"feedback":            {Payloads: v1(func() interface{} { return &TrainingFeedback{} })},
+		"debate_paused":       {Payloads: v1(func() interface{} { return &DebatePaused{} })},
+		"server_shutdown":     {Payloads: v1(func() interface{} { return &ServerShutdown{} })},
+		"debate_resumed":      {Payloads: v1(func() interface{} { return &DebateResumed{} })},
+		"debate_closing":      {Payloads: v1(func() interface{} { return &DebateClosing{} })},
+		"warning_issued":      {Payloads: v1(func() interface{} { return &WarningIssued{} })},
+		"participant_status":  {Payloads: v1(func() interface{} { return &ParticipantStatus{} })},
+		"login_queued":        {Payloads: v1(func() interface{} { return &LoginQueued{} })},
+		"side_signal":         {Payloads: v1(func() interface{} { return &RelayedSignal{} })},
+		"signal_accepted":     {Payloads: v1(func() interface{} { return &SignalAccepted{} })},
+		"tournament_state":    {Payloads: v1(func() interface{} { return &Tournament{} })},
+		"replay_state":        {Payloads: v1(func() interface{} { return &ReplayState{} })},
+		"replay_event":        {Payloads: v1(func() interface{} { return &ReplayEvent{} })},
+		"replay_start":        {Payloads: v1(func() interface{} { return &ReplayStart{} })},
+		"replay_entry":        {Payloads: v1(func() interface{} { return &ReplayEntry{} })},
+		"replay_end":          {Payloads: v1(func() interface{} { return &ReplayEnd{} })},
+		"subscribe_rejected":  {Payloads: v1(func() interface{} { return &SubscribeRejected{} })},
+		"room_full":           {Payloads: v1(func() interface{} { return &RoomFull{} })},
+		"spectator_count":     {Payloads: v1(func() interface{} { return &SpectatorCount{} })},
+		"chat_message":        {Payloads: v1(func() interface{} { return &ChatMessage{} })},
+		"chat_history":        {Payloads: v1(func() interface{} { return &ChatHistory{} })},
+		"error":               {Payloads: v1(func() interface{} { return &ErrorMessage{} })},
+		"ping":                {Payloads: v1(heartbeatPayload)},
+		"pong":                {Payloads: v1(heartbeatPayload)},
+	},
+}
+
+// isRegisteredMessage reports whether a message type is known in any direction
+func isRegisteredMessage(msgType string) bool {
+	for _, specs := range messageRegistry {
+		if _, ok := specs[msgType]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeMessage decodes and validates a raw inbound message against the
+// registry. On success msg.Data holds a pointer to the typed payload. On
+// failure it returns a recoverable error; msg is still returned when the
+// envelope could be read, so the error can reply to its id.
+func decodeMessage(raw []byte, direction string) (*Message, *ErrorMessage) {
+	var envelope struct {
+		ID        string          `json:"id"`
+		ReplyTo   string          `json:"reply_to"`
+		Type      string          `json:"type"`
+		Version   int             `json:"version"`
+		Timestamp string          `json:"timestamp"`
+		Data      json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return nil, &ErrorMessage{
+			ErrorCode:   "INVALID_MESSAGE_FORMAT",
+			Message:     "Message is not valid JSON",
+			Details:     err.Error(),
+			Recoverable: true,
+		}
+	}
+	msg := &Message{
+		ID:        envelope.ID,
+		ReplyTo:   envelope.ReplyTo,
+		Type:      envelope.Type,
+		Version:   envelope.Version,
+		Timestamp: envelope.Timestamp,
+	}
+	if envelope.Type == "" {
+		return msg, &ErrorMessage{
+			ErrorCode:   "MISSING_FIELD",
+			Message:     "Message has no type",
+			Details:     "type",
+			Recoverable: true,
+		}
+	}
+
+	spec, known := messageRegistry[direction][envelope.Type]
+	if !known {
+		return msg, &ErrorMessage{
+			ErrorCode:   "UNKNOWN_MESSAGE_TYPE",
+			Message:     fmt.Sprintf("Unknown message type %q", envelope.Type),
+			Details:     envelope.Type,
+			Recoverable: true,
+		}
+	}
+
+	version := envelope.Version
+	if version == 0 {
+		version = currentPayloadVersion
+	}
+	newPayload, supported := spec.Payloads[version]
+	if !supported {
+		return msg, &ErrorMessage{
+			ErrorCode:   "UNSUPPORTED_VERSION",
+			Message:     fmt.Sprintf("%s version %d is not supported", envelope.Type, version),
+			Details:     fmt.Sprintf("%d", version),
+			Recoverable: true,
+		}
+	}
+
+	fields := map[string]interface{}{}
+	hasData := len(envelope.Data) > 0 && string(envelope.Data) != "null"
+	if hasData {
+		if err := json.Unmarshal(envelope.Data, &fields); err != nil {
+			return msg, &ErrorMessage{
+				ErrorCode:   "INVALID_MESSAGE_FORMAT",
+				Message:     "Message data must be a JSON object",
+				Details:     err.Error(),
+				Recoverable: true,
+			}
+		}
+	}
+
+	missing := []string{}
+	for _, field := range spec.Required {
+		if value, ok := fields[field]; !ok || value == nil || value == "" {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		return msg, &ErrorMessage{
+			ErrorCode:   "MISSING_FIELD",
+			Message:     fmt.Sprintf("%s requires %s", envelope.Type, strings.Join(missing, ", ")),
+			Details:     strings.Join(missing, ","),
+			Recoverable: true,
+		}
+	}
+
+	payload := newPayload()
+	if hasData {
+		if err := json.Unmarshal(envelope.Data, payload); err != nil {
+			return msg, &ErrorMessage{
+				ErrorCode:   "INVALID_MESSAGE_FORMAT",
+				Message:     fmt.Sprintf("Invalid %s payload", envelope.Type),
+				Details:     err.Error(),
+				Recoverable: true,
+			}
+		}
+	}
+	msg.Data = payload
+	return msg, nil
+}
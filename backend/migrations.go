@@ -0,0 +1,880 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+// Migration is a single versioned schema change
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// migrations lists every schema change in order. Append new entries; never edit applied ones.
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "initial_schema",
+		SQL: `
+	CREATE TABLE IF NOT EXISTS debates (
+		id TEXT PRIMARY KEY,
+		topic TEXT NOT NULL,
+		total_rounds INTEGER NOT NULL,
+		current_round INTEGER DEFAULT 1,
+		status TEXT DEFAULT 'waiting',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS bots (
+		bot_name TEXT NOT NULL,
+		bot_uuid TEXT NOT NULL,
+		bot_identifier TEXT NOT NULL,
+		debate_id TEXT NOT NULL,
+		debate_key TEXT NOT NULL,
+		side TEXT,
+		connected_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (debate_id, bot_uuid),
+		FOREIGN KEY (debate_id) REFERENCES debates(id)
+	);
+
+	CREATE TABLE IF NOT EXISTS debate_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		debate_id TEXT NOT NULL,
+		round INTEGER NOT NULL,
+		speaker TEXT NOT NULL,
+		side TEXT NOT NULL,
+		timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+		message_format TEXT NOT NULL,
+		message_content TEXT NOT NULL,
+		FOREIGN KEY (debate_id) REFERENCES debates(id)
+	);
+
+	CREATE TABLE IF NOT EXISTS debate_results (
+		debate_id TEXT PRIMARY KEY,
+		winner TEXT NOT NULL,
+		supporting_score INTEGER NOT NULL,
+		opposing_score INTEGER NOT NULL,
+		summary_format TEXT NOT NULL,
+		summary_content TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (debate_id) REFERENCES debates(id)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_debates_status ON debates(status);
+	CREATE INDEX IF NOT EXISTS idx_bots_debate ON bots(debate_id);
+	CREATE INDEX IF NOT EXISTS idx_debate_log_debate ON debate_log(debate_id);
+	`,
+	},
+	{
+		Version: 2,
+		Name:    "instance_affinity",
+		SQL: `
+	ALTER TABLE debates ADD COLUMN owner_instance TEXT DEFAULT '';
+
+	CREATE TABLE IF NOT EXISTS instances (
+		instance_id TEXT PRIMARY KEY,
+		public_url TEXT NOT NULL DEFAULT '',
+		last_seen DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	`,
+	},
+	{
+		Version: 3,
+		Name:    "ranked_debates",
+		SQL: `
+	ALTER TABLE debates ADD COLUMN ranked INTEGER NOT NULL DEFAULT 1;
+	CREATE INDEX IF NOT EXISTS idx_debates_ranked ON debates(ranked);
+	`,
+	},
+	{
+		Version: 4,
+		Name:    "bot_client_version",
+		SQL: `
+	ALTER TABLE bots ADD COLUMN client_version TEXT NOT NULL DEFAULT '';
+	`,
+	},
+	{
+		Version: 5,
+		Name:    "house_bot_personas",
+		SQL: `
+	CREATE TABLE IF NOT EXISTS personas (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		system_prompt TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	ALTER TABLE debates ADD COLUMN persona_id TEXT NOT NULL DEFAULT '';
+
+	INSERT OR IGNORE INTO personas (id, name, description, system_prompt) VALUES
+	('aggressive-litigator', 'Aggressive Litigator', '咄咄逼人的诉讼律师，逐条攻击对方论点',
+	 '你是一位咄咄逼人的诉讼律师。你的风格是犀利、直接，逐条拆解对方论点中的漏洞，用反问和质询施压，绝不让步。'),
+	('calm-academic', 'Calm Academic', '冷静的学者，重视证据与严谨论证',
+	 '你是一位冷静理性的学者。你的风格是平和、严谨，引用研究与数据支撑论点，承认对方合理之处后再给出更有力的反驳。'),
+	('devils-advocate', 'Devil''s Advocate', '魔鬼代言人，专挑反直觉角度',
+	 '你是一位魔鬼代言人。你善于从反直觉、出人意料的角度切入，挑战常识假设，用思想实验和极端案例检验对方立场。');
+	`,
+	},
+	{
+		Version: 6,
+		Name:    "admin_alerts",
+		SQL: `
+	CREATE TABLE IF NOT EXISTS admin_alerts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		kind TEXT NOT NULL,
+		message TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	`,
+	},
+	{
+		Version: 7,
+		Name:    "rejudge_jobs",
+		SQL: `
+	CREATE TABLE IF NOT EXISTS rejudge_jobs (
+		id TEXT PRIMARY KEY,
+		status TEXT NOT NULL,
+		model TEXT NOT NULL,
+		rubric TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		completed_at DATETIME
+	);
+
+	CREATE TABLE IF NOT EXISTS rejudge_items (
+		job_id TEXT NOT NULL,
+		debate_id TEXT NOT NULL,
+		original_winner TEXT NOT NULL DEFAULT '',
+		original_supporting_score INTEGER NOT NULL DEFAULT 0,
+		original_opposing_score INTEGER NOT NULL DEFAULT 0,
+		new_winner TEXT NOT NULL DEFAULT '',
+		new_supporting_score INTEGER NOT NULL DEFAULT 0,
+		new_opposing_score INTEGER NOT NULL DEFAULT 0,
+		new_summary TEXT NOT NULL DEFAULT '',
+		error TEXT NOT NULL DEFAULT '',
+		applied INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (job_id, debate_id),
+		FOREIGN KEY (job_id) REFERENCES rejudge_jobs(id)
+	);
+	`,
+	},
+	{
+		Version: 8,
+		Name:    "debate_format",
+		SQL: `
+	ALTER TABLE debates ADD COLUMN format TEXT NOT NULL DEFAULT 'sequential';
+	`,
+	},
+	{
+		Version: 9,
+		Name:    "blind_opening",
+		SQL: `
+	ALTER TABLE debates ADD COLUMN blind_opening INTEGER NOT NULL DEFAULT 0;
+	`,
+	},
+	{
+		Version: 10,
+		Name:    "tiebreak_results",
+		SQL: `
+	ALTER TABLE debate_results ADD COLUMN tiebreak_round INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE debate_results ADD COLUMN tiebreak_reason TEXT NOT NULL DEFAULT '';
+	ALTER TABLE debate_results ADD COLUMN initial_winner TEXT NOT NULL DEFAULT '';
+	ALTER TABLE debate_results ADD COLUMN initial_supporting_score INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE debate_results ADD COLUMN initial_opposing_score INTEGER NOT NULL DEFAULT 0;
+	`,
+	},
+	{
+		Version: 11,
+		Name:    "round_scoring",
+		SQL: `
+	ALTER TABLE debates ADD COLUMN scoring TEXT NOT NULL DEFAULT 'holistic';
+
+	CREATE TABLE IF NOT EXISTS round_results (
+		debate_id TEXT NOT NULL,
+		round INTEGER NOT NULL,
+		winner TEXT NOT NULL,
+		supporting_score INTEGER NOT NULL,
+		opposing_score INTEGER NOT NULL,
+		comment TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (debate_id, round),
+		FOREIGN KEY (debate_id) REFERENCES debates(id)
+	);
+	`,
+	},
+	{
+		Version: 12,
+		Name:    "verdict_citations",
+		SQL: `
+	CREATE TABLE IF NOT EXISTS verdict_citations (
+		debate_id TEXT NOT NULL,
+		position INTEGER NOT NULL,
+		point TEXT NOT NULL,
+		round INTEGER NOT NULL,
+		side TEXT NOT NULL,
+		quote TEXT NOT NULL DEFAULT '',
+		located INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (debate_id, position),
+		FOREIGN KEY (debate_id) REFERENCES debates(id)
+	);
+	`,
+	},
+	{
+		Version: 13,
+		Name:    "private_debates",
+		SQL: `
+	ALTER TABLE debates ADD COLUMN private INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE debates ADD COLUMN spectator_token TEXT NOT NULL DEFAULT '';
+	`,
+	}, {
+		Version: 14,
+		Name:    "judge_model",
+		SQL: `
+	ALTER TABLE debate_results ADD COLUMN judge_model TEXT NOT NULL DEFAULT '';
+	`,
+	},
+	{
+		Version: 15,
+		Name:    "rubric_snapshots",
+		SQL: `
+	CREATE TABLE IF NOT EXISTS rubrics (
+		hash TEXT PRIMARY KEY,
+		rubric_id TEXT NOT NULL,
+		content TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	ALTER TABLE debate_results ADD COLUMN rubric_id TEXT NOT NULL DEFAULT '';
+	ALTER TABLE debate_results ADD COLUMN rubric_hash TEXT NOT NULL DEFAULT '';
+	`,
+	},
+	{
+		Version: 16,
+		Name:    "bilingual_debates",
+		SQL: `
+	ALTER TABLE debates ADD COLUMN languages TEXT NOT NULL DEFAULT '';
+	ALTER TABLE debates ADD COLUMN topic_translations TEXT NOT NULL DEFAULT '';
+	ALTER TABLE debate_log ADD COLUMN message_language TEXT NOT NULL DEFAULT '';
+	ALTER TABLE debate_log ADD COLUMN translations TEXT NOT NULL DEFAULT '';
+	`,
+	},
+	{
+		Version: 17,
+		Name:    "body_encoding",
+		SQL: `
+	ALTER TABLE debate_log ADD COLUMN message_encoding TEXT NOT NULL DEFAULT '';
+	ALTER TABLE debate_results ADD COLUMN summary_encoding TEXT NOT NULL DEFAULT '';
+	`,
+	},
+	{
+		Version: 18,
+		Name:    "stats_aggregates",
+		SQL: `
+	CREATE TABLE IF NOT EXISTS daily_stats (
+		day TEXT NOT NULL,
+		ranked BOOLEAN NOT NULL,
+		winner TEXT NOT NULL,
+		debates INTEGER NOT NULL DEFAULT 0,
+		supporting_score_sum INTEGER NOT NULL DEFAULT 0,
+		opposing_score_sum INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (day, ranked, winner)
+	);
+
+	CREATE TABLE IF NOT EXISTS bot_stats (
+		bot_uuid TEXT PRIMARY KEY,
+		bot_name TEXT NOT NULL,
+		debates INTEGER NOT NULL DEFAULT 0,
+		wins INTEGER NOT NULL DEFAULT 0,
+		losses INTEGER NOT NULL DEFAULT 0,
+		draws INTEGER NOT NULL DEFAULT 0,
+		score_sum INTEGER NOT NULL DEFAULT 0
+	);
+
+	INSERT INTO daily_stats (day, ranked, winner, debates, supporting_score_sum, opposing_score_sum)
+	SELECT date(r.created_at), d.ranked, r.winner, COUNT(*), SUM(r.supporting_score), SUM(r.opposing_score)
+	FROM debate_results r JOIN debates d ON d.id = r.debate_id
+	GROUP BY 1, 2, 3;
+
+	INSERT INTO bot_stats (bot_uuid, bot_name, debates, wins, losses, draws, score_sum)
+	SELECT b.bot_uuid, MAX(b.bot_name), COUNT(*),
+		SUM(r.winner = b.side),
+		SUM(r.winner IN ('supporting', 'opposing') AND r.winner != b.side),
+		SUM(r.winner = 'draw'),
+		SUM(CASE b.side WHEN 'supporting' THEN r.supporting_score ELSE r.opposing_score END)
+	FROM debate_results r
+	JOIN debates d ON d.id = r.debate_id
+	JOIN bots b ON b.debate_id = r.debate_id
+	WHERE d.ranked = 1 AND b.side IN ('supporting', 'opposing')
+	GROUP BY b.bot_uuid;
+	`,
+	},
+	{
+		Version: 19,
+		Name:    "result_versions",
+		SQL: `
+	CREATE TABLE IF NOT EXISTS result_versions (
+		debate_id TEXT NOT NULL,
+		version INTEGER NOT NULL,
+		source TEXT NOT NULL,
+		produced_by TEXT NOT NULL DEFAULT '',
+		winner TEXT NOT NULL,
+		supporting_score INTEGER NOT NULL,
+		opposing_score INTEGER NOT NULL,
+		summary_format TEXT NOT NULL,
+		summary_content TEXT NOT NULL,
+		summary_encoding TEXT NOT NULL DEFAULT '',
+		judge_model TEXT NOT NULL DEFAULT '',
+		rubric_id TEXT NOT NULL DEFAULT '',
+		rubric_hash TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (debate_id, version),
+		FOREIGN KEY (debate_id) REFERENCES debates(id)
+	);
+
+	ALTER TABLE debate_results ADD COLUMN authoritative_version INTEGER NOT NULL DEFAULT 0;
+
+	INSERT INTO result_versions (debate_id, version, source, produced_by, winner, supporting_score, opposing_score,
+		summary_format, summary_content, summary_encoding, judge_model, rubric_id, rubric_hash, created_at)
+	SELECT r.debate_id, 1,
+		CASE
+			WHEN EXISTS (SELECT 1 FROM rejudge_items i WHERE i.debate_id = r.debate_id AND i.applied = 1) THEN 'rejudge'
+			WHEN EXISTS (SELECT 1 FROM round_results rr WHERE rr.debate_id = r.debate_id) THEN 'round_scoring'
+			WHEN r.judge_model != '' THEN 'ai_judge'
+			ELSE 'fallback'
+		END,
+		r.judge_model, r.winner, r.supporting_score, r.opposing_score,
+		r.summary_format, r.summary_content, r.summary_encoding, r.judge_model, r.rubric_id, r.rubric_hash, r.created_at
+	FROM debate_results r;
+
+	UPDATE debate_results SET authoritative_version = 1;
+	`,
+	},
+	{
+		Version: 20,
+		Name:    "debate_category",
+		SQL: `
+	ALTER TABLE debates ADD COLUMN category TEXT NOT NULL DEFAULT '';
+	`,
+	},
+	{
+		Version: 21,
+		Name:    "judge_panel",
+		SQL: `
+	CREATE TABLE IF NOT EXISTS judge_panel (
+		debate_id TEXT NOT NULL,
+		position INTEGER NOT NULL,
+		persona_id TEXT NOT NULL,
+		persona_name TEXT NOT NULL,
+		weight REAL NOT NULL DEFAULT 1,
+		winner TEXT NOT NULL,
+		supporting_score INTEGER NOT NULL,
+		opposing_score INTEGER NOT NULL,
+		summary TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY (debate_id, position),
+		FOREIGN KEY (debate_id) REFERENCES debates(id)
+	);
+	`,
+	},
+	{
+		Version: 22,
+		Name:    "spectator_sessions",
+		SQL: `
+	CREATE TABLE IF NOT EXISTS spectator_sessions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		debate_id TEXT NOT NULL,
+		instance_id TEXT NOT NULL DEFAULT '',
+		joined_at DATETIME NOT NULL,
+		left_at DATETIME,
+		FOREIGN KEY (debate_id) REFERENCES debates(id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_spectator_sessions_joined ON spectator_sessions(joined_at);
+	`,
+	},
+	{
+		Version: 23,
+		Name:    "debate_seats",
+		SQL: `
+	ALTER TABLE debates ADD COLUMN seats TEXT NOT NULL DEFAULT '';
+	`,
+	},
+	{
+		Version: 24,
+		Name:    "debate_imported_from",
+		SQL: `
+	ALTER TABLE debates ADD COLUMN imported_from TEXT NOT NULL DEFAULT '';
+	`,
+	},
+	{
+		Version: 25,
+		Name:    "tournaments",
+		SQL: `
+	CREATE TABLE IF NOT EXISTS tournaments (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		topic TEXT NOT NULL,
+		debate_rounds INTEGER NOT NULL,
+		bracket_rounds INTEGER NOT NULL,
+		status TEXT NOT NULL,
+		winner_uuid TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS tournament_entrants (
+		tournament_id TEXT NOT NULL,
+		seed INTEGER NOT NULL,
+		bot_uuid TEXT NOT NULL,
+		bot_name TEXT NOT NULL,
+		PRIMARY KEY (tournament_id, seed),
+		FOREIGN KEY (tournament_id) REFERENCES tournaments(id)
+	);
+	CREATE TABLE IF NOT EXISTS tournament_matches (
+		tournament_id TEXT NOT NULL,
+		round INTEGER NOT NULL,
+		slot INTEGER NOT NULL,
+		bot_a_uuid TEXT NOT NULL DEFAULT '',
+		bot_b_uuid TEXT NOT NULL DEFAULT '',
+		debate_id TEXT NOT NULL DEFAULT '',
+		winner_uuid TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL,
+		PRIMARY KEY (tournament_id, round, slot),
+		FOREIGN KEY (tournament_id) REFERENCES tournaments(id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_tournament_matches_debate ON tournament_matches(debate_id);
+	ALTER TABLE debates ADD COLUMN tournament_id TEXT NOT NULL DEFAULT '';
+	`,
+	},
+	{
+		Version: 26,
+		Name:    "leagues",
+		SQL: `
+	CREATE TABLE IF NOT EXISTS leagues (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		topics TEXT NOT NULL,
+		debate_rounds INTEGER NOT NULL,
+		status TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS league_standings (
+		league_id TEXT NOT NULL,
+		bot_uuid TEXT NOT NULL,
+		bot_name TEXT NOT NULL,
+		played INTEGER NOT NULL DEFAULT 0,
+		wins INTEGER NOT NULL DEFAULT 0,
+		draws INTEGER NOT NULL DEFAULT 0,
+		losses INTEGER NOT NULL DEFAULT 0,
+		points INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (league_id, bot_uuid),
+		FOREIGN KEY (league_id) REFERENCES leagues(id)
+	);
+	CREATE TABLE IF NOT EXISTS league_fixtures (
+		league_id TEXT NOT NULL,
+		fixture INTEGER NOT NULL,
+		bot_a_uuid TEXT NOT NULL,
+		bot_b_uuid TEXT NOT NULL,
+		topic TEXT NOT NULL,
+		debate_id TEXT NOT NULL DEFAULT '',
+		winner_uuid TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL,
+		PRIMARY KEY (league_id, fixture),
+		FOREIGN KEY (league_id) REFERENCES leagues(id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_league_fixtures_debate ON league_fixtures(debate_id);
+	ALTER TABLE debates ADD COLUMN league_id TEXT NOT NULL DEFAULT '';
+	`,
+	},
+	{
+		Version: 27,
+		Name:    "judge_runs",
+		SQL: `
+	CREATE TABLE IF NOT EXISTS judge_runs (
+		debate_id TEXT NOT NULL,
+		run INTEGER NOT NULL,
+		temperature REAL NOT NULL,
+		winner TEXT NOT NULL,
+		supporting_score INTEGER NOT NULL,
+		opposing_score INTEGER NOT NULL,
+		summary TEXT NOT NULL,
+		PRIMARY KEY (debate_id, run),
+		FOREIGN KEY (debate_id) REFERENCES debates(id)
+	);
+	`,
+	},
+	{
+		Version: 28,
+		Name:    "debate_short_ids",
+		SQL: `
+	ALTER TABLE debates ADD COLUMN short_id TEXT NOT NULL DEFAULT '';
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_debates_short_id ON debates(short_id) WHERE short_id != '';
+	`,
+	},
+	{
+		Version: 29,
+		Name:    "side_channel",
+		SQL: `
+	ALTER TABLE debates ADD COLUMN side_channel BOOLEAN NOT NULL DEFAULT 0;
+	CREATE TABLE IF NOT EXISTS side_signals (
+		debate_id TEXT NOT NULL,
+		seq INTEGER NOT NULL,
+		round INTEGER NOT NULL,
+		sender TEXT NOT NULL,
+		side TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		fields TEXT NOT NULL,
+		created_at TEXT NOT NULL,
+		PRIMARY KEY (debate_id, seq),
+		FOREIGN KEY (debate_id) REFERENCES debates(id)
+	);
+	`,
+	},
+	{
+		Version: 30,
+		Name:    "debate_snapshots",
+		SQL: `
+	CREATE TABLE IF NOT EXISTS debate_snapshots (
+		debate_id TEXT PRIMARY KEY,
+		instance_id TEXT NOT NULL,
+		snapshot TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		FOREIGN KEY (debate_id) REFERENCES debates(id)
+	);
+	`,
+	},
+	{
+		Version: 31,
+		Name:    "cancel_reason",
+		SQL: `
+	ALTER TABLE debates ADD COLUMN cancel_reason TEXT NOT NULL DEFAULT '';
+	`,
+	},
+	{
+		Version: 32,
+		Name:    "tournament_match_decisions",
+		SQL: `
+	ALTER TABLE tournament_matches ADD COLUMN decided_by TEXT NOT NULL DEFAULT '';
+	ALTER TABLE tournament_matches ADD COLUMN drawn BOOLEAN NOT NULL DEFAULT 0;
+	`,
+	},
+	{
+		Version: 33,
+		Name:    "verdict_style",
+		SQL: `
+	ALTER TABLE debates ADD COLUMN verdict_style TEXT NOT NULL DEFAULT '';
+	`,
+	},
+	{
+		Version: 34,
+		Name:    "debate_log_fts",
+		SQL: `
+	CREATE VIRTUAL TABLE debate_log_fts USING fts4(content="debate_log", message_content, tokenize=unicode61);
+	CREATE TRIGGER debate_log_fts_insert AFTER INSERT ON debate_log BEGIN
+		INSERT INTO debate_log_fts(docid, message_content) VALUES (new.id, new.message_content);
+	END;
+	CREATE TRIGGER debate_log_fts_before_update BEFORE UPDATE ON debate_log BEGIN
+		DELETE FROM debate_log_fts WHERE docid = old.id;
+	END;
+	CREATE TRIGGER debate_log_fts_after_update AFTER UPDATE ON debate_log BEGIN
+		INSERT INTO debate_log_fts(docid, message_content) VALUES (new.id, new.message_content);
+	END;
+	CREATE TRIGGER debate_log_fts_delete BEFORE DELETE ON debate_log BEGIN
+		DELETE FROM debate_log_fts WHERE docid = old.id;
+	END;
+	INSERT INTO debate_log_fts(debate_log_fts) VALUES ('rebuild');
+	`,
+	},
+	{
+		Version: 35,
+		Name:    "debate_rules",
+		SQL: `
+	ALTER TABLE debates ADD COLUMN rules TEXT NOT NULL DEFAULT '';
+	`,
+	},
+	{
+		Version: 36,
+		Name:    "registered_bots",
+		SQL: `
+	CREATE TABLE registered_bots (
+		bot_uuid TEXT PRIMARY KEY,
+		bot_name TEXT NOT NULL,
+		bot_identifier TEXT NOT NULL UNIQUE,
+		token_hash TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		last_login_at DATETIME
+	);
+	`,
+	},
+	{
+		Version: 37,
+		Name:    "debate_no_ai_judge",
+		SQL: `
+	ALTER TABLE debates ADD COLUMN no_ai_judge INTEGER NOT NULL DEFAULT 0;
+	`,
+	},
+	{
+		Version: 38,
+		Name:    "reaper_actions",
+		SQL: `
+	CREATE TABLE IF NOT EXISTS reaper_actions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		debate_id TEXT NOT NULL,
+		action TEXT NOT NULL,
+		reason TEXT NOT NULL,
+		from_status TEXT NOT NULL,
+		to_status TEXT NOT NULL DEFAULT '',
+		instance_id TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_reaper_actions_debate ON reaper_actions(debate_id);
+	`,
+	},
+	{
+		Version: 39,
+		Name:    "round_scores",
+		SQL: `
+	CREATE TABLE IF NOT EXISTS round_scores (
+		debate_id TEXT NOT NULL,
+		round INTEGER NOT NULL,
+		rounds_scored INTEGER NOT NULL,
+		supporting_total INTEGER NOT NULL,
+		opposing_total INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (debate_id, round),
+		FOREIGN KEY (debate_id) REFERENCES debates(id)
+	);
+	`,
+	},
+	{
+		Version: 40,
+		Name:    "artifacts",
+		SQL: `
+	CREATE TABLE IF NOT EXISTS artifacts (
+		id TEXT PRIMARY KEY,
+		debate_id TEXT NOT NULL,
+		speaker TEXT NOT NULL,
+		name TEXT NOT NULL,
+		content_type TEXT NOT NULL,
+		size INTEGER NOT NULL,
+		data BLOB,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (debate_id) REFERENCES debates(id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_artifacts_debate ON artifacts(debate_id);
+	ALTER TABLE debate_log ADD COLUMN attachments TEXT NOT NULL DEFAULT '';
+	`,
+	},
+	{
+		Version: 41,
+		Name:    "debate_disclose_rubric",
+		SQL: `
+	ALTER TABLE debates ADD COLUMN disclose_rubric INTEGER NOT NULL DEFAULT 0;
+	`,
+	},
+	{
+		Version: 42,
+		Name:    "bot_result_pending",
+		SQL: `
+	ALTER TABLE bots ADD COLUMN result_pending INTEGER NOT NULL DEFAULT 0;
+	CREATE INDEX IF NOT EXISTS idx_bots_result_pending ON bots(bot_uuid) WHERE result_pending = 1;
+	`,
+	},
+	{
+		Version: 43,
+		Name:    "debate_opponent_summary",
+		SQL: `
+	ALTER TABLE debates ADD COLUMN opponent_summary INTEGER NOT NULL DEFAULT 0;
+	`,
+	},
+	{
+		Version: 44,
+		Name:    "debate_format_limits",
+		SQL: `
+	ALTER TABLE debates ADD COLUMN limits TEXT NOT NULL DEFAULT '';
+	`,
+	},
+	{
+		Version: 45,
+		Name:    "result_breakdown",
+		SQL: `
+	ALTER TABLE debate_results ADD COLUMN breakdown TEXT NOT NULL DEFAULT '';
+	`,
+	},
+	{
+		Version: 46,
+		Name:    "judge_latency",
+		SQL: `
+	ALTER TABLE debate_results ADD COLUMN judge_ms INTEGER NOT NULL DEFAULT 0;
+	CREATE INDEX IF NOT EXISTS idx_debate_results_created ON debate_results(created_at);
+	`,
+	},
+	{
+		Version: 47,
+		Name:    "sandbox_debates",
+		SQL: `
+	ALTER TABLE debates ADD COLUMN sandbox BOOLEAN NOT NULL DEFAULT 0;
+	`,
+	},
+	{
+		Version: 48,
+		Name:    "llm_usage",
+		SQL: `
+	CREATE TABLE IF NOT EXISTS llm_usage (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		debate_id TEXT NOT NULL DEFAULT '',
+		purpose TEXT NOT NULL,
+		model TEXT NOT NULL,
+		prompt_tokens INTEGER NOT NULL,
+		completion_tokens INTEGER NOT NULL,
+		cost REAL NOT NULL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_llm_usage_created ON llm_usage(created_at);
+	CREATE INDEX IF NOT EXISTS idx_llm_usage_debate ON llm_usage(debate_id);
+	`,
+	},
+	{
+		Version: 49,
+		Name:    "spectator_chat",
+		SQL: `
+	CREATE TABLE IF NOT EXISTS spectator_chat (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		debate_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		content TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (debate_id) REFERENCES debates(id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_spectator_chat_debate ON spectator_chat(debate_id, id);
+	`,
+	},
+	{
+		Version: 50,
+		Name:    "debate_templates",
+		SQL: `
+	CREATE TABLE IF NOT EXISTS debate_templates (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		topic_pattern TEXT NOT NULL DEFAULT '',
+		settings TEXT NOT NULL DEFAULT '{}',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	`,
+	},
+	{
+		Version: 51,
+		Name:    "topics",
+		SQL: `
+	CREATE TABLE IF NOT EXISTS topics (
+		id TEXT PRIMARY KEY,
+		topic TEXT NOT NULL UNIQUE,
+		category TEXT NOT NULL DEFAULT '',
+		difficulty TEXT NOT NULL DEFAULT '',
+		rationale TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL DEFAULT 'suggested',
+		source TEXT NOT NULL DEFAULT 'ai',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_topics_status ON topics(status);
+	`,
+	},
+	{
+		Version: 52,
+		Name:    "rejudge_job_instance",
+		SQL: `
+	ALTER TABLE rejudge_jobs ADD COLUMN instance_id TEXT NOT NULL DEFAULT '';
+	`,
+	},
+	{
+		Version: 53,
+		Name:    "debate_log_fts_decoded",
+		SQL: `
+	DROP TRIGGER debate_log_fts_insert;
+	DROP TRIGGER debate_log_fts_before_update;
+	DROP TRIGGER debate_log_fts_after_update;
+	DROP TABLE debate_log_fts;
+	CREATE VIRTUAL TABLE debate_log_fts USING fts4(message_content, tokenize=unicode61);
+	INSERT INTO debate_log_fts(docid, message_content)
+		SELECT id, message_content FROM debate_log WHERE message_encoding = '';
+	`,
+	},
+	{
+		Version: 54,
+		Name:    "bot_conformance",
+		SQL: `
+	CREATE TABLE IF NOT EXISTS bot_conformance (
+		bot_uuid TEXT PRIMARY KEY,
+		bot_identifier TEXT NOT NULL DEFAULT '',
+		conformant BOOLEAN NOT NULL,
+		results TEXT NOT NULL DEFAULT '[]',
+		started_at DATETIME,
+		recorded_by TEXT NOT NULL DEFAULT '',
+		recorded_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	`,
+	},
+}
+
+// latestSchemaVersion returns the version of the newest known migration
+func latestSchemaVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// ensureMigrationsTable creates the bookkeeping table for applied migrations
+func (d *Database) ensureMigrationsTable() error {
+	_, err := d.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	return err
+}
+
+// SchemaVersion returns the highest applied migration version (0 if none)
+func (d *Database) SchemaVersion() (int, error) {
+	if err := d.ensureMigrationsTable(); err != nil {
+		return 0, err
+	}
+	var version int
+	err := d.db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	return version, err
+}
+
+// Migrate applies all pending migrations, each in its own transaction
+func (d *Database) Migrate() error {
+	current, err := d.SchemaVersion()
+	if err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+
+		tx, err := d.db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(m.SQL); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Name, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version, name) VALUES (?, ?)`, m.Version, m.Name); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		log.Printf("Applied migration %d: %s", m.Version, m.Name)
+	}
+
+	return nil
+}
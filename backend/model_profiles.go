@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+)
+
+// Model profiles name an LLM provider account: its key, endpoint, model and
+// limits. LLM features (judge, house bot, translation) pick a profile by name
+// under chatgpt.profiles, so each can use a different provider or account.
+// The top-level chatgpt api_key, api_url, model and timeout form the
+// "default" profile, used by features that name none.
+
+// defaultModelProfile is the profile built from the top-level chatgpt settings
+const defaultModelProfile = "default"
+
+// ModelProfile is one LLM endpoint and account
+type ModelProfile struct {
+	APIKey    string `yaml:"api_key"`
+	APIKeyEnv string `yaml:"api_key_env"` // Environment variable holding the key; overrides api_key when set
+	APIURL    string `yaml:"api_url"`     // Defaults to the default profile's
+	Model     string `yaml:"model"`       // Defaults to the default profile's
+	Timeout   int    `yaml:"timeout"`     // Seconds; defaults to the default profile's
+	MaxTokens int    `yaml:"max_tokens"`  // Caps the completion tokens any feature may request; 0 is no cap
+}
+
+// resolveModelProfiles adds the default profile, fills in missing profile
+// settings from it, and checks that every feature names a known profile
+func resolveModelProfiles(cfg *Config) error {
+	if cfg.ChatGPT.Profiles == nil {
+		cfg.ChatGPT.Profiles = make(map[string]ModelProfile)
+	}
+	base := ModelProfile{
+		APIKey:  cfg.ChatGPT.APIKey,
+		APIURL:  cfg.ChatGPT.APIURL,
+		Model:   cfg.ChatGPT.Model,
+		Timeout: cfg.ChatGPT.Timeout,
+	}
+	if _, exists := cfg.ChatGPT.Profiles[defaultModelProfile]; !exists {
+		cfg.ChatGPT.Profiles[defaultModelProfile] = base
+	}
+
+	for name, profile := range cfg.ChatGPT.Profiles {
+		if profile.APIKeyEnv != "" {
+			if key := os.Getenv(profile.APIKeyEnv); key != "" {
+				profile.APIKey = key
+			} else {
+				log.Printf("Model profile %s: environment variable %s is not set", name, profile.APIKeyEnv)
+			}
+		}
+		if profile.APIKey == "" {
+			profile.APIKey = base.APIKey
+		}
+		if profile.APIURL == "" {
+			profile.APIURL = base.APIURL
+		}
+		if profile.Model == "" {
+			profile.Model = base.Model
+		}
+		if profile.Timeout == 0 {
+			profile.Timeout = base.Timeout
+		}
+		cfg.ChatGPT.Profiles[name] = profile
+	}
+
+	for feature, name := range map[string]string{
+		"judge":       cfg.ChatGPT.Judge.Profile,
+		"house_bot":   cfg.ChatGPT.HouseBot.Profile,
+		"translation": cfg.ChatGPT.Translation.Profile,
+		"summary":     cfg.ChatGPT.Summary.Profile,
+		"topics":      cfg.ChatGPT.Topics.Profile,
+	} {
+		if name == "" {
+			continue
+		}
+		if _, exists := cfg.ChatGPT.Profiles[name]; !exists {
+			return fmt.Errorf("chatgpt.%s.profile: unknown model profile %q (known: %v)", feature, name, profileNames(cfg))
+		}
+	}
+	for _, name := range cfg.ChatGPT.Judge.Fallback {
+		if _, exists := cfg.ChatGPT.Profiles[name]; !exists {
+			return fmt.Errorf("chatgpt.judge.fallback: unknown model profile %q (known: %v)", name, profileNames(cfg))
+		}
+	}
+	return nil
+}
+
+// profileNames lists the configured model profiles
+func profileNames(cfg *Config) []string {
+	names := make([]string, 0, len(cfg.ChatGPT.Profiles))
+	for name := range cfg.ChatGPT.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// modelProfile returns a profile by name, the default profile for ""
+func modelProfile(name string) ModelProfile {
+	if name == "" {
+		name = defaultModelProfile
+	}
+	return config.ChatGPT.Profiles[name]
+}
+
+// newProfileClient creates an LLM client for a feature on the named profile
+func newProfileClient(name string, maxTokens int, temperature float64) *ChatGPTClient {
+	profile := modelProfile(name)
+	if profile.MaxTokens > 0 && maxTokens > profile.MaxTokens {
+		maxTokens = profile.MaxTokens
+	}
+	return NewChatGPTClient(profile.APIKey, profile.APIURL, profile.Model, profile.Timeout, maxTokens, temperature)
+}
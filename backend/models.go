@@ -6,13 +6,37 @@ import (
 
 // Debate represents a debate session
 type Debate struct {
-	ID           string    `json:"debate_id"`
-	Topic        string    `json:"topic"`
-	TotalRounds  int       `json:"total_rounds"`
-	CurrentRound int       `json:"current_round"`
-	Status       string    `json:"status"` // waiting, active, completed, timeout, error
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID                string            `json:"debate_id"`
+	ShortID           string            `json:"short_id,omitempty"` // Short form of the ID, accepted wherever debate_id is
+	Topic             string            `json:"topic"`
+	TotalRounds       int               `json:"total_rounds"`
+	CurrentRound      int               `json:"current_round"`
+	Status            string            `json:"status"`               // waiting, active, completed, timeout, cancelled, error
+	Ranked            bool              `json:"ranked"`               // false for sandbox/practice debates excluded from stats
+	PersonaID         string            `json:"persona_id,omitempty"` // House bot persona, if the house bot takes part
+	Format            string            `json:"format"`               // sequential or simultaneous
+	BlindOpening      bool              `json:"blind_opening"`        // Openings submitted before the debate starts
+	Scoring           string            `json:"scoring"`              // holistic, rounds or cumulative
+	Private           bool              `json:"private"`              // Spectators need the spectator token
+	SpectatorToken    string            `json:"-"`
+	Languages         []string          `json:"languages,omitempty"`          // Bilingual debates: languages speeches are provided in
+	TopicTranslations map[string]string `json:"topic_translations,omitempty"` // language -> translated topic
+	Category          string            `json:"category,omitempty"`           // Topic category, used to vary auto-matched pairings
+	Seats             []string          `json:"seats,omitempty"`              // Panel debates: side of each seat in speaking order
+	ImportedFrom      string            `json:"imported_from,omitempty"`      // Platform an imported transcript was recorded on
+	TournamentID      string            `json:"tournament_id,omitempty"`      // Tournament the debate is a match of; only its two bots may join
+	LeagueID          string            `json:"league_id,omitempty"`          // League the debate is a fixture of; only its two bots may join
+	SideChannel       bool              `json:"side_channel,omitempty"`       // Bots may exchange side_signal messages
+	CancelReason      string            `json:"cancel_reason,omitempty"`      // Why a cancelled debate was called off
+	Verdict           *VerdictStyle     `json:"verdict,omitempty"`            // Overrides chatgpt.judge.verdict for this debate
+	Rules             *RulesCard        `json:"rules,omitempty"`              // Rules in force, fixed when the debate starts
+	NoAIJudge         bool              `json:"no_ai_judge,omitempty"`        // Judged heuristically even when the AI judge is available
+	DiscloseRubric    bool              `json:"disclose_rubric,omitempty"`    // Bots are told the judging rubric in debate_start
+	OpponentSummary   bool              `json:"opponent_summary,omitempty"`   // Updates carry an LLM summary of the opponent's latest speech
+	Limits            *FormatLimits     `json:"limits,omitempty"`             // Overrides the configured speech timeout and content lengths
+	Sandbox           bool              `json:"sandbox,omitempty"`            // Onboarding debate from /api/sandbox/debate; errors carry an explanation
+	CreatedAt         time.Time         `json:"created_at"`
+	UpdatedAt         time.Time         `json:"updated_at"`
 }
 
 // Bot represents a bot participant
@@ -22,23 +46,60 @@ type Bot struct {
 	BotIdentifier string    `json:"bot_identifier"` // name+uuid (first 8 chars)
 	DebateID      string    `json:"debate_id"`
 	DebateKey     string    `json:"debate_key"`
-	Side          string    `json:"side"` // supporting, opposing, or empty
+	Side          string    `json:"side"`           // supporting, opposing, or empty
+	ClientVersion string    `json:"client_version"` // Version reported in bot_login
+	ConnectedAt   time.Time `json:"connected_at"`
+}
+
+// PublicBot is a bot as shown to spectators. The bot_uuid and debate_key
+// stay private, since together with the debate they let a bot back into
+// its seat.
+type PublicBot struct {
+	BotName       string    `json:"bot_name"`
+	BotIdentifier string    `json:"bot_identifier"`
+	Side          string    `json:"side"`
+	ClientVersion string    `json:"client_version"`
 	ConnectedAt   time.Time `json:"connected_at"`
 }
 
+// publicBots returns the spectator view of a debate's bots
+func publicBots(bots []*Bot) []PublicBot {
+	public := make([]PublicBot, len(bots))
+	for i, bot := range bots {
+		public[i] = PublicBot{
+			BotName:       bot.BotName,
+			BotIdentifier: bot.BotIdentifier,
+			Side:          bot.Side,
+			ClientVersion: bot.ClientVersion,
+			ConnectedAt:   bot.ConnectedAt,
+		}
+	}
+	return public
+}
+
 // Message represents a base WebSocket message
 type Message struct {
+	ID        string      `json:"id,omitempty"`       // Unique per message
+	ReplyTo   string      `json:"reply_to,omitempty"` // id of the message this one answers
 	Type      string      `json:"type"`
+	Version   int         `json:"version,omitempty"` // Payload version, 1 when omitted
 	Timestamp string      `json:"timestamp"`
 	Data      interface{} `json:"data"`
 }
 
 // LoginRequest from bot
 type LoginRequest struct {
-	BotName  string `json:"bot_name"`
-	BotUUID  string `json:"bot_uuid"`
-	DebateID string `json:"debate_id"`
-	Version  string `json:"version,omitempty"`
+	BotName  string   `json:"bot_name"`
+	BotUUID  string   `json:"bot_uuid"`
+	DebateID string   `json:"debate_id"`
+	Version  string   `json:"version,omitempty"`
+	Tags     []string `json:"tags,omitempty"`  // Preferred topic categories when auto-assigned under the tags policy
+	Token    string   `json:"token,omitempty"` // Bot token from /api/bots/register; required for registered bots
+
+	DebateKey     string `json:"debate_key,omitempty"`     // From the first login_confirmed; required to take back a seat after a disconnect
+	AffinityToken string `json:"affinity_token,omitempty"` // From login_confirmed or login_redirect; checked when logging in again
+
+	internal bool // Sent by the server's own client, the house bot
 }
 
 // LoginConfirmed response
@@ -49,7 +110,31 @@ type LoginConfirmed struct {
 	DebateKey     string   `json:"debate_key"`
 	BotIdentifier string   `json:"bot_identifier"`
 	Topic         string   `json:"topic"`
-	JoinedBots    []string `json:"joined_bots"` // List of bot identifiers that have already joined
+	JoinedBots    []string `json:"joined_bots"`              // List of bot identifiers that have already joined
+	InstanceID    string   `json:"instance_id,omitempty"`    // Instance hosting this debate
+	AffinityToken string   `json:"affinity_token,omitempty"` // Signed instance token; send it back when logging in again
+	Languages     []string `json:"languages,omitempty"`      // Bilingual debates: speeches may carry translations into these
+	Reconnected   bool     `json:"reconnected,omitempty"`    // Took back a seat in a running debate after a disconnect
+	SideChannel   bool     `json:"side_channel,omitempty"`   // side_signal messages may be sent to the other bots
+	Sandbox       bool     `json:"sandbox,omitempty"`        // Onboarding debate: errors carry an explanation
+
+	// Blind opening debates: side and limits for the opening statement sent before debate_start
+	BlindOpening     bool   `json:"blind_opening,omitempty"`
+	YourSide         string `json:"your_side,omitempty"`
+	TotalRounds      int    `json:"total_rounds,omitempty"`
+	MinContentLength int    `json:"min_content_length,omitempty"`
+	MaxContentLength int    `json:"max_content_length,omitempty"`
+}
+
+// LoginRedirect tells a bot to reconnect to the instance that owns its debate
+type LoginRedirect struct {
+	Status     string `json:"status"`
+	Message    string `json:"message"`
+	DebateID   string `json:"debate_id"`
+	InstanceID string `json:"instance_id"`
+	URL        string `json:"url"` // Carries the affinity query parameter
+
+	AffinityToken string `json:"affinity_token,omitempty"` // For the login at URL
 }
 
 // LoginRejected response
@@ -63,24 +148,33 @@ type LoginRejected struct {
 
 // DebateStart notification
 type DebateStart struct {
-	DebateID         string `json:"debate_id"`
-	Topic            string `json:"topic"`
-	SupportingSide   string `json:"supporting_side"`
-	OpposingSide     string `json:"opposing_side"`
-	TotalRounds      int    `json:"total_rounds"`
-	CurrentRound     int    `json:"current_round"`
-	YourSide         string `json:"your_side"`
-	YourIdentifier   string `json:"your_identifier"`
-	NextSpeaker      string `json:"next_speaker"`
-	TimeoutSeconds   int    `json:"timeout_seconds"`
-	MinContentLength int    `json:"min_content_length"`
-	MaxContentLength int    `json:"max_content_length"`
+	DebateID         string           `json:"debate_id"`
+	Topic            string           `json:"topic"`
+	SupportingSide   string           `json:"supporting_side"`
+	OpposingSide     string           `json:"opposing_side"`
+	Participants     []Participant    `json:"participants,omitempty"` // Panel debates: every seated bot in speaking order
+	TotalRounds      int              `json:"total_rounds"`
+	CurrentRound     int              `json:"current_round"`
+	YourSide         string           `json:"your_side"`
+	YourIdentifier   string           `json:"your_identifier"`
+	NextSpeaker      string           `json:"next_speaker"`
+	TimeoutSeconds   int              `json:"timeout_seconds"`
+	MinContentLength int              `json:"min_content_length"`
+	MaxContentLength int              `json:"max_content_length"`
+	Format           string           `json:"format,omitempty"`     // sequential or simultaneous
+	DebateLog        []DebateLogEntry `json:"debate_log,omitempty"` // Revealed blind openings, if any
+	Rules            *RulesCard       `json:"rules,omitempty"`      // Rules the debate runs under
+	Judging          string           `json:"judging"`              // ai or heuristic
+	Rubric           *DisclosedRubric `json:"rubric,omitempty"`     // Judging criteria and weights, when the debate discloses them
 }
 
 // SpeechMessage content
 type SpeechMessage struct {
-	Format  string `json:"format"`
-	Content string `json:"content"`
+	Format       string            `json:"format"`
+	Content      string            `json:"content"`
+	Language     string            `json:"language,omitempty"`     // Language of Content
+	Translations map[string]string `json:"translations,omitempty"` // language -> translated content (bilingual debates)
+	Attachments  []Attachment      `json:"attachments,omitempty"`  // Supporting data tables and chart specs
 }
 
 // DebateSpeech from bot
@@ -98,6 +192,8 @@ type DebateLogEntry struct {
 	Side      string        `json:"side"`
 	Timestamp string        `json:"timestamp"`
 	Message   SpeechMessage `json:"message"`
+
+	Highlights []Highlight `json:"highlights,omitempty"` // Passages the verdict hinged on
 }
 
 // DebateUpdate to bots
@@ -106,6 +202,7 @@ type DebateUpdate struct {
 	Topic            string           `json:"topic"`
 	SupportingSide   string           `json:"supporting_side"`
 	OpposingSide     string           `json:"opposing_side"`
+	Participants     []Participant    `json:"participants,omitempty"` // Panel debates: every seated bot in speaking order
 	TotalRounds      int              `json:"total_rounds"`
 	CurrentRound     int              `json:"current_round"`
 	YourSide         string           `json:"your_side"`
@@ -115,15 +212,113 @@ type DebateUpdate struct {
 	MinContentLength int              `json:"min_content_length"`
 	MaxContentLength int              `json:"max_content_length"`
 	DebateLog        []DebateLogEntry `json:"debate_log"`
+	Format           string           `json:"format,omitempty"`           // sequential or simultaneous
+	Status           string           `json:"status,omitempty"`           // active, or overtime during a tiebreak round
+	Scores           *RoundScore      `json:"scores,omitempty"`           // Round-scored debates: running totals once a round is judged
+	OpponentSummary  *OpponentSummary `json:"opponent_summary,omitempty"` // Debates with opponent_summary: the other side's latest speech in brief
+	Spectators       int              `json:"spectators"`                 // Spectators watching live on this instance
+
+	// Set in debate_state replies to get_state
+	Deadline               string `json:"deadline,omitempty"`                 // When the current turn times out
+	RemainingSeconds       int    `json:"remaining_seconds,omitempty"`        // Seconds left in the current turn
+	DebateRemainingSeconds int    `json:"debate_remaining_seconds,omitempty"` // Seconds left before max_duration ends the debate
+}
+
+// SpeechReceived acknowledges a buffered speech in simultaneous mode
+type SpeechReceived struct {
+	DebateID string   `json:"debate_id"`
+	Round    int      `json:"round"`
+	Speaker  string   `json:"speaker"`
+	Waiting  []string `json:"waiting"` // Bots that have not yet submitted this round
+}
+
+// OpeningReceived acknowledges a blind opening statement
+type OpeningReceived struct {
+	DebateID string `json:"debate_id"`
+	Speaker  string `json:"speaker"`
+}
+
+// RoundReveal publishes all speeches of a simultaneous round at once
+type RoundReveal struct {
+	DebateID string           `json:"debate_id"`
+	Round    int              `json:"round"`
+	Entries  []DebateLogEntry `json:"entries"`
+	Missing  []string         `json:"missing,omitempty"` // Bots that missed the round deadline
 }
 
 // DebateResult summary
 type DebateResult struct {
-	Winner          string        `json:"winner"`
-	SupportingScore int           `json:"supporting_score"`
-	OpposingScore   int           `json:"opposing_score"`
-	Summary         SpeechMessage `json:"summary"`
-	Reason          string        `json:"reason,omitempty"` // Reason for debate end (e.g., "completed", "bot_disconnected", "heartbeat_timeout", "max_duration_timeout")
+	Winner          string            `json:"winner"`
+	SupportingScore int               `json:"supporting_score"`
+	OpposingScore   int               `json:"opposing_score"`
+	Summary         SpeechMessage     `json:"summary"`
+	Reason          string            `json:"reason,omitempty"`        // Reason for debate end (e.g., "completed", "bot_disconnected", "heartbeat_timeout", "max_duration_timeout")
+	Tiebreak        *TiebreakInfo     `json:"tiebreak,omitempty"`      // Set when the debate went to a tiebreak round
+	RoundResults    []RoundResult     `json:"round_results,omitempty"` // Round scoring mode: per-round judgements
+	RoundScores     []RoundScore      `json:"round_scores,omitempty"`  // Round-scored debates: cumulative standing after each judged round
+	Citations       []VerdictCitation `json:"citations,omitempty"`     // Verdict points and the speeches they refer to
+	JudgeModel      string            `json:"judge_model,omitempty"`   // LLM model that produced the verdict; empty for fallback scoring
+	Rubric          *RubricSnapshot   `json:"rubric,omitempty"`        // Judge prompt behind the verdict; nil for fallback scoring
+	Source          string            `json:"source,omitempty"`        // What produced the result: ai_judge, fallback, round_scoring, rejudge, imported or forfeit
+	ProducedBy      string            `json:"produced_by,omitempty"`   // Judge model, rejudge job or admin behind the result
+	Version         int               `json:"version,omitempty"`       // Authoritative result version, see /api/debate/{id}/results
+	Panel           []PanelVerdict    `json:"panel,omitempty"`         // Individual verdicts when a judge panel is configured
+	Runs            []JudgeRun        `json:"runs,omitempty"`          // Close calls: every judging pass; the verdict is the median rerun
+	Breakdown       []CriterionScore  `json:"breakdown,omitempty"`     // Each side's score on each criterion of the judge rubric
+	JudgeMs         int64             `json:"judge_ms,omitempty"`      // How long the AI judgement took; 0 when no judge was called
+
+	Feedback map[string]BotFeedback `json:"-"` // side -> private critique, sent to each bot as feedback
+}
+
+// RoundResult is the judgement of a single round in round scoring mode
+type RoundResult struct {
+	Round           int    `json:"round"`
+	Winner          string `json:"winner"` // supporting, opposing, draw or unscored
+	SupportingScore int    `json:"supporting_score"`
+	OpposingScore   int    `json:"opposing_score"`
+	Comment         string `json:"comment"`
+}
+
+// RoundScore is the standing of a round-scored debate: points totalled over
+// the rounds judged so far
+type RoundScore struct {
+	Round           int `json:"round"`         // Latest judged round
+	RoundsScored    int `json:"rounds_scored"` // Rounds judged so far
+	SupportingTotal int `json:"supporting_total"`
+	OpposingTotal   int `json:"opposing_total"`
+}
+
+// RoundResultMessage announces a judged round
+type RoundResultMessage struct {
+	DebateID string `json:"debate_id"`
+	RoundResult
+	Scores *RoundScore `json:"scores,omitempty"` // Standing including this round
+}
+
+// TiebreakInfo records why a tiebreak round was played and the result it overturned or confirmed
+type TiebreakInfo struct {
+	Round                  int    `json:"round"`
+	Reason                 string `json:"reason"` // draw or close_scores
+	InitialWinner          string `json:"initial_winner"`
+	InitialSupportingScore int    `json:"initial_supporting_score"`
+	InitialOpposingScore   int    `json:"initial_opposing_score"`
+}
+
+// DebateOvertime announces a tiebreak round
+type DebateOvertime struct {
+	DebateID        string `json:"debate_id"`
+	Round           int    `json:"round"`
+	Reason          string `json:"reason"` // draw or close_scores
+	SupportingScore int    `json:"supporting_score"`
+	OpposingScore   int    `json:"opposing_score"`
+}
+
+// JudgingProgress tells bots and spectators where a finished debate stands in the judging queue
+type JudgingProgress struct {
+	DebateID    string `json:"debate_id"`
+	Status      string `json:"status"`       // queued or judging
+	Position    int    `json:"position"`     // 1-based place in the queue; 0 while judging
+	QueueLength int    `json:"queue_length"` // Debates waiting to be judged
 }
 
 // DebateEnd notification
@@ -136,6 +331,7 @@ type DebateEnd struct {
 	Status         string           `json:"status"`
 	DebateLog      []DebateLogEntry `json:"debate_log"`
 	DebateResult   DebateResult     `json:"debate_result"`
+	Judging        string           `json:"judging,omitempty"` // How the result was reached: ai or heuristic; empty when cancelled
 }
 
 // DebateWaiting notification (waiting for bots to join)
@@ -147,6 +343,29 @@ type DebateWaiting struct {
 	JoinedBots  []string `json:"joined_bots"` // List of bot identifiers that have joined
 }
 
+// DebateSnapshot is the full state of a debate sent to a spectator on
+// subscribe, whatever its status
+type DebateSnapshot struct {
+	DebateID         string           `json:"debate_id"`
+	Topic            string           `json:"topic"`
+	Status           string           `json:"status"`
+	Format           string           `json:"format"`
+	Scoring          string           `json:"scoring"`
+	Ranked           bool             `json:"ranked"`
+	TotalRounds      int              `json:"total_rounds"`
+	CurrentRound     int              `json:"current_round"`
+	SupportingSide   string           `json:"supporting_side,omitempty"`
+	OpposingSide     string           `json:"opposing_side,omitempty"`
+	JoinedBots       []string         `json:"joined_bots"`
+	MinContentLength int              `json:"min_content_length"`
+	MaxContentLength int              `json:"max_content_length"`
+	TimeoutSeconds   int              `json:"timeout_seconds"`
+	DebateLog        []DebateLogEntry `json:"debate_log"`
+	DebateResult     *DebateResult    `json:"debate_result,omitempty"` // Set once the debate has been judged
+	Rules            *RulesCard       `json:"rules,omitempty"`         // Set once the debate has started
+	Sequence         int              `json:"sequence"`                // Number of log entries included; a resuming client only needs later ones
+}
+
 // ErrorMessage to bot
 type ErrorMessage struct {
 	ErrorCode   string `json:"error_code"`
@@ -154,6 +373,7 @@ type ErrorMessage struct {
 	DebateID    string `json:"debate_id,omitempty"`
 	Details     string `json:"details,omitempty"`
 	Recoverable bool   `json:"recoverable"`
+	Explanation string `json:"explanation,omitempty"` // Sandbox debates: what went wrong and how to fix it
 }
 
 // CreateDebateRequest from frontend
@@ -161,17 +381,131 @@ type CreateDebateRequest struct {
 	Topic       string `json:"topic"`
 	TotalRounds int    `json:"total_rounds"`
 	CreatedBy   string `json:"created_by,omitempty"`
+	Ranked      *bool  `json:"ranked,omitempty"` // Defaults to true
+
+	Format        string `json:"format,omitempty"`         // sequential (default) or simultaneous
+	BlindOpening  bool   `json:"blind_opening,omitempty"`  // Collect openings before sides see each other
+	Scoring       string `json:"scoring,omitempty"`        // holistic (default), rounds or cumulative
+	Private       bool   `json:"private,omitempty"`        // Hide from listings and require a spectator token
+	HouseOpponent bool   `json:"house_opponent,omitempty"` // Let the built-in AI take one side
+	PersonaID     string `json:"persona_id,omitempty"`     // House bot persona (defaults to calm-academic)
+	SideChannel   bool   `json:"side_channel,omitempty"`   // Let bots exchange side_signal messages
+	NoAIJudge     bool   `json:"no_ai_judge,omitempty"`    // Judge heuristically even when the AI judge is available
+
+	DiscloseRubric  *bool `json:"disclose_rubric,omitempty"`  // Tell the bots the judging rubric; defaults to debate.disclose_rubric
+	OpponentSummary *bool `json:"opponent_summary,omitempty"` // Summarize each bot's opponent in debate_update; defaults to debate.opponent_summary
+
+	Verdict *VerdictStyle `json:"verdict,omitempty"` // Judge summary length, structure and round commentary
+	Limits  *FormatLimits `json:"limits,omitempty"`  // Speech timeout and content lengths, within the configured bounds
+
+	Languages         []string          `json:"languages,omitempty"`          // Bilingual debate languages, e.g. ["zh", "en"]
+	TopicTranslations map[string]string `json:"topic_translations,omitempty"` // language -> translated topic
+	Category          string            `json:"category,omitempty"`           // Topic category, e.g. "technology"
+
+	// Panel debates with more than two bots: either the side of each seat in
+	// speaking order, or a bot count whose sides alternate from supporting
+	Seats        []string `json:"seats,omitempty"`
+	Participants int      `json:"participants,omitempty"`
+
+	// Start from a saved template, see debate_templates.go
+	TemplateID string            `json:"template_id,omitempty"`
+	TopicVars  map[string]string `json:"topic_vars,omitempty"` // Fill the template's topic_pattern when no topic is given
+}
+
+// DebateOptions are per-debate settings chosen at creation time
+type DebateOptions struct {
+	Ranked       bool
+	PersonaID    string // Non-empty when the house bot joins
+	Format       string
+	BlindOpening bool
+	Scoring      string
+	Private      bool
+
+	Languages         []string
+	TopicTranslations map[string]string
+	Category          string
+	Seats             []string
+	TournamentID      string
+	LeagueID          string
+	SideChannel       bool
+	NoAIJudge         bool
+	DiscloseRubric    bool
+	OpponentSummary   bool
+	Verdict           *VerdictStyle
+	Limits            *FormatLimits
+	Sandbox           bool
+}
+
+// Persona is a stored system prompt for the house AI opponent
+type Persona struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Description  string    `json:"description"`
+	SystemPrompt string    `json:"system_prompt"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
 }
 
 // DebateCreated response
 type DebateCreated struct {
-	DebateID    string `json:"debate_id"`
-	Topic       string `json:"topic"`
-	TotalRounds int    `json:"total_rounds"`
-	Status      string `json:"status"`
+	DebateID     string `json:"debate_id"`
+	ShortID      string `json:"short_id"`
+	Topic        string `json:"topic"`
+	TotalRounds  int    `json:"total_rounds"`
+	Status       string `json:"status"`
+	Ranked       bool   `json:"ranked"`
+	PersonaID    string `json:"persona_id,omitempty"`
+	Format       string `json:"format"`
+	BlindOpening bool   `json:"blind_opening"`
+	Scoring      string `json:"scoring"`
+
+	Seats []string `json:"seats,omitempty"`
+
+	Private        bool   `json:"private"`
+	SpectatorToken string `json:"spectator_token,omitempty"` // Share with spectators of a private debate
+	SideChannel    bool   `json:"side_channel,omitempty"`
+
+	Verdict *VerdictStyle `json:"verdict,omitempty"`
+	Limits  *FormatLimits `json:"limits,omitempty"`
+	Judging string        `json:"judging"` // How the debate will be judged as things stand: ai or heuristic
+}
+
+// Instance is a server instance sharing the database
+type Instance struct {
+	InstanceID string    `json:"instance_id"`
+	PublicURL  string    `json:"public_url"`
+	LastSeen   time.Time `json:"last_seen"`
+}
+
+// Alert is an operational alert for administrators
+type Alert struct {
+	ID        int64     `json:"id"`
+	Kind      string    `json:"kind"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 // SubscribeDebate from frontend
 type SubscribeDebate struct {
+	DebateID string   `json:"debate_id"`
+	Token    string   `json:"token,omitempty"`    // Spectator token, required for private debates
+	Events   []string `json:"events,omitempty"`   // Event categories to receive (speeches, status, timers, results, chat, reactions, commentary); all when empty
+	Language string   `json:"language,omitempty"` // Display language for bilingual debates; originals when empty
+}
+
+// SpeechTranslation delivers an auto-generated translation after the speech was revealed
+type SpeechTranslation struct {
+	DebateID string `json:"debate_id"`
+	Round    int    `json:"round"`
+	Speaker  string `json:"speaker"`
+	Language string `json:"language"`
+	Content  string `json:"content"`
+}
+
+// SubscribeRejected tells a spectator why it cannot watch a debate
+type SubscribeRejected struct {
+	Status   string `json:"status"`
+	Reason   string `json:"reason"` // debate_not_found, token_required or invalid_token
+	Message  string `json:"message"`
 	DebateID string `json:"debate_id"`
 }
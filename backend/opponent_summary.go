@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Lightweight bots may not want to read the whole transcript every turn.
+// In debates created with opponent_summary, each accepted speech is
+// summarized in one neutral paragraph by the LLM before the next update goes
+// out, and every debate_update tells each bot what the other side last said
+// in brief. A speech whose summary fails is simply not summarized.
+
+// summaryMaxTokens and summaryTemperature tune the summary calls
+const (
+	summaryMaxTokens   = 400
+	summaryTemperature = 0.3
+)
+
+// summaryPrompt is the system prompt of the summarizer
+const summaryPrompt = `你是一位中立的辩论记录员。请用一段话（不超过150字）客观概括下面这篇辩论发言的主要论点和论据，不作任何评价，不添加发言中没有的内容。
+请使用与发言相同的语言，只输出摘要本身。`
+
+// summarizer writes opponent summaries
+var summarizer *ChatGPTClient
+
+// OpponentSummary is the gist of the other side's latest speech
+type OpponentSummary struct {
+	Round   int    `json:"round"`
+	Speaker string `json:"speaker"`
+	Side    string `json:"side"`
+	Summary string `json:"summary"`
+}
+
+// summaryKey identifies a speech in ActiveDebate.Summaries
+func summaryKey(round int, speaker string) string {
+	return fmt.Sprintf("%d/%s", round, speaker)
+}
+
+// summarizeEntries summarizes newly accepted speeches of a debate with
+// opponent_summary. It blocks until the summaries are written or have failed.
+func (dm *DebateManager) summarizeEntries(activeDebate *ActiveDebate, entries []DebateLogEntry) {
+	if !activeDebate.Debate.OpponentSummary || summarizer == nil {
+		return
+	}
+	for _, entry := range entries {
+		summary, err := summarizer.forDebate(activeDebate.Debate.ID, UsageSummary).SendMessage([]ChatGPTMessage{
+			{Role: "system", Content: summaryPrompt},
+			{Role: "user", Content: entry.Message.Content},
+		})
+		if err != nil {
+			log.Printf("Failed to summarize speech by %s in debate %s: %v", entry.Speaker, activeDebate.Debate.ID, err)
+			continue
+		}
+
+		activeDebate.mutex.Lock()
+		if activeDebate.Summaries == nil {
+			activeDebate.Summaries = make(map[string]string)
+		}
+		activeDebate.Summaries[summaryKey(entry.Round, entry.Speaker)] = strings.TrimSpace(summary)
+		activeDebate.mutex.Unlock()
+	}
+}
+
+// opponentSummary returns the summary of the latest speech from the other
+// side of bot, nil if it has none. Caller holds activeDebate.mutex.
+func (a *ActiveDebate) opponentSummary(bot *ConnectedBot) *OpponentSummary {
+	for i := len(a.DebateLog) - 1; i >= 0; i-- {
+		entry := a.DebateLog[i]
+		if entry.Side == bot.Bot.Side {
+			continue
+		}
+		summary, ok := a.Summaries[summaryKey(entry.Round, entry.Speaker)]
+		if !ok {
+			return nil
+		}
+		return &OpponentSummary{
+			Round:   entry.Round,
+			Speaker: entry.Speaker,
+			Side:    entry.Side,
+			Summary: summary,
+		}
+	}
+	return nil
+}
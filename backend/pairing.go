@@ -0,0 +1,142 @@
+package main
+
+import (
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Auto-assign policies: the order in which waiting debates are offered to a
+// bot that logs in without a debate_id
+const (
+	AutoAssignOldest = "oldest" // Longest-waiting debate first
+	AutoAssignNewest = "newest" // Most recently created debate first
+	AutoAssignTags   = "tags"   // Debates in a category named in the login's tags first
+	AutoAssignRating = "rating" // Debates whose waiting bot is rated closest first; empty debates last
+	AutoAssignOff    = "off"    // No auto-assignment, logins need a debate_id
+)
+
+// pairHistoryLimit bounds how many shared debates of a pair are considered
+const pairHistoryLimit = 50
+
+// chooseAutoMatch picks the waiting debate for a bot that logged in without a
+// debate_id. Debates whose waiting bot debated this one within the pairing
+// cooldown are skipped. Among the rest, debates in a topic category the pair
+// has not debated recently come first, then the first in policy order.
+// coolingDown reports that debates were waiting but all were skipped for the
+// cooldown.
+func (dm *DebateManager) chooseAutoMatch(botUUID string, tags []string) (debate *Debate, coolingDown bool, err error) {
+	available, err := dm.db.GetAvailableDebates()
+	if err != nil {
+		return nil, false, err
+	}
+	// Debates just created by matchmaking are held for the matched bots
+	candidates := available[:0]
+	for _, candidate := range available {
+		if _, _, reserved := lobby.reservedFor(candidate.ID); !reserved {
+			candidates = append(candidates, candidate)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, false, nil
+	}
+	if err := dm.orderCandidates(candidates, botUUID, tags); err != nil {
+		return nil, false, err
+	}
+	pairing := config.Debate.Pairing
+	if pairing.Cooldown <= 0 && pairing.CategoryWindow <= 0 {
+		return candidates[0], false, nil
+	}
+
+	now := time.Now()
+	var repeatCategory *Debate
+	for _, candidate := range candidates {
+		opponent := dm.waitingOpponent(candidate.ID, botUUID)
+		if opponent == "" {
+			return candidate, false, nil
+		}
+		history, err := dm.db.GetPairDebates(botUUID, opponent, pairHistoryLimit)
+		if err != nil {
+			return nil, false, err
+		}
+		if pairing.Cooldown > 0 && len(history) > 0 &&
+			now.Sub(history[0].CreatedAt) < time.Duration(pairing.Cooldown)*time.Second {
+			log.Printf("Pairing cooldown: skipping debate %s for bot %s", candidate.ID, botUUID)
+			continue
+		}
+		if pairing.CategoryWindow > 0 && debatedCategoryRecently(history, candidate.Category, now.Add(-time.Duration(pairing.CategoryWindow)*time.Second)) {
+			if repeatCategory == nil {
+				repeatCategory = candidate
+			}
+			continue
+		}
+		return candidate, false, nil
+	}
+	return repeatCategory, repeatCategory == nil, nil
+}
+
+// waitingOpponent returns the UUID of the bot already waiting in a debate, if any
+func (dm *DebateManager) waitingOpponent(debateID, botUUID string) string {
+	bots, _ := dm.db.GetBots(debateID)
+	for _, bot := range bots {
+		if bot.BotUUID != botUUID {
+			return bot.BotUUID
+		}
+	}
+	return ""
+}
+
+// debatedCategoryRecently reports whether a pair's history has a debate in
+// category created after since. Uncategorized debates never repeat.
+func debatedCategoryRecently(history []*Debate, category string, since time.Time) bool {
+	if category == "" {
+		return false
+	}
+	for _, debate := range history {
+		if debate.CreatedAt.After(since) && debate.Category == category {
+			return true
+		}
+	}
+	return false
+}
+
+// orderCandidates sorts waiting debates, oldest first, into the order of the
+// configured auto-assign policy
+func (dm *DebateManager) orderCandidates(candidates []*Debate, botUUID string, tags []string) error {
+	switch config.Debate.Pairing.Policy {
+	case AutoAssignNewest:
+		for i, j := 0, len(candidates)-1; i < j; i, j = i+1, j-1 {
+			candidates[i], candidates[j] = candidates[j], candidates[i]
+		}
+	case AutoAssignTags:
+		wanted := map[string]bool{}
+		for _, tag := range tags {
+			wanted[strings.ToLower(strings.TrimSpace(tag))] = true
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return wanted[candidates[i].Category] && !wanted[candidates[j].Category]
+		})
+	case AutoAssignRating:
+		stats, err := dm.db.GetLeaderboardStats(time.Time{})
+		if err != nil {
+			return err
+		}
+		ratings := map[string]float64{}
+		for _, s := range stats {
+			ratings[s.BotUUID] = botRating(s)
+		}
+		gap := map[string]float64{}
+		for _, candidate := range candidates {
+			gap[candidate.ID] = math.Inf(1)
+			if opponent := dm.waitingOpponent(candidate.ID, botUUID); opponent != "" {
+				gap[candidate.ID] = math.Abs(ratings[opponent] - ratings[botUUID])
+			}
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return gap[candidates[i].ID] < gap[candidates[j].ID]
+		})
+	}
+	return nil
+}
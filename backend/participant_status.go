@@ -0,0 +1,61 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// Spectators receive participant_status whenever a bot's connection health
+// changes, so a long silence on screen can be told apart from a bot that is
+// thinking: a bot falls behind on pings, drops and is waited for, comes back,
+// or is gone for good.
+
+// Participant connection states
+const (
+	ParticipantConnected    = "connected"    // missed_pings > 0 while pings go unanswered
+	ParticipantReconnecting = "reconnecting" // Dropped; the debate waits until reconnect_deadline
+	ParticipantDisconnected = "disconnected" // Dropped and not waited for
+)
+
+// ParticipantStatus is the connection health of one bot
+type ParticipantStatus struct {
+	DebateID          string `json:"debate_id"`
+	Bot               string `json:"bot"`
+	Side              string `json:"side,omitempty"`
+	Status            string `json:"status"`
+	MissedPings       int    `json:"missed_pings"`
+	ReconnectDeadline string `json:"reconnect_deadline,omitempty"`
+}
+
+// ReportHeartbeat announces how many pings a bot has left unanswered; 0
+// after a pong means the bot caught up again
+func (dm *DebateManager) ReportHeartbeat(debateID, identifier string, missedPings int) {
+	dm.mutex.RLock()
+	activeDebate, exists := dm.debates[debateID]
+	dm.mutex.RUnlock()
+	if !exists || !isRunning(activeDebate.Debate.Status) {
+		return
+	}
+	if missedPings > 0 {
+		log.Printf("Bot %s in debate %s has %d unanswered pings", identifier, debateID, missedPings)
+	}
+	dm.announceParticipant(activeDebate, identifier, ParticipantConnected, missedPings, time.Time{})
+}
+
+// announceParticipant broadcasts a bot's connection health to the spectators
+// of its debate. deadline is only set while the bot is reconnecting.
+func (dm *DebateManager) announceParticipant(activeDebate *ActiveDebate, identifier, status string, missedPings int, deadline time.Time) {
+	notice := ParticipantStatus{
+		DebateID:    activeDebate.Debate.ID,
+		Bot:         identifier,
+		Status:      status,
+		MissedPings: missedPings,
+	}
+	if bot := activeDebate.findBot(identifier); bot != nil {
+		notice.Side = bot.Bot.Side
+	}
+	if !deadline.IsZero() {
+		notice.ReconnectDeadline = deadline.Format(time.RFC3339)
+	}
+	dm.broadcast <- BroadcastMessage{DebateID: activeDebate.Debate.ID, Message: createMessage("participant_status", notice)}
+}
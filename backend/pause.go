@@ -0,0 +1,309 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Pause reasons
+const (
+	PauseBotDisconnected = "bot_disconnected" // A bot dropped and has reconnect_grace seconds to come back
+	PauseModerator       = "moderator"        // Held through POST /api/debate/{id}/pause until /resume
+)
+
+var (
+	errDebateNotRunning = errors.New("debate is not running on this instance")
+	errNotHeld          = errors.New("debate was not paused through the API")
+	errBotMissing       = errors.New("a bot is still disconnected")
+)
+
+// DebatePaused tells bots and spectators that the debate clock is stopped
+type DebatePaused struct {
+	DebateID       string `json:"debate_id"`
+	Reason         string `json:"reason"`
+	Bot            string `json:"bot,omitempty"`             // Bot that disconnected
+	ResumeDeadline string `json:"resume_deadline,omitempty"` // The debate ends if the bot is not back by then
+	Note           string `json:"note,omitempty"`            // Moderator's explanation
+}
+
+// DebateResumed tells bots and spectators that the debate clock runs again
+type DebateResumed struct {
+	DebateID string `json:"debate_id"`
+}
+
+// pauseDebate stops the speech, inactivity and max duration clocks of a
+// running debate. Speeches are refused until resumeDebate.
+func (dm *DebateManager) pauseDebate(activeDebate *ActiveDebate, notice DebatePaused) {
+	if !activeDebate.Paused {
+		activeDebate.Paused = true
+		activeDebate.PausedAt = time.Now()
+		for _, timer := range []*time.Timer{activeDebate.TimeoutTimer, activeDebate.InactivityTimer, activeDebate.MaxDurationTimer} {
+			if timer != nil {
+				timer.Stop()
+			}
+		}
+		stopCountdown(activeDebate)
+	}
+	activeDebate.PauseReason = notice.Reason
+
+	notice.DebateID = activeDebate.Debate.ID
+	dm.notifyDebate(activeDebate, createMessage("debate_paused", notice))
+}
+
+// resumeDebate restarts the clocks of a paused debate and sends both bots
+// their current state. The due speaker gets a full speech timeout (or, in a
+// restored debate, what was left of it); time spent paused does not count
+// towards max_duration.
+func (dm *DebateManager) resumeDebate(activeDebate *ActiveDebate) {
+	if !activeDebate.Paused {
+		return
+	}
+	debateID := activeDebate.Debate.ID
+	activeDebate.Paused = false
+	activeDebate.PauseReason = ""
+	activeDebate.StartTime = activeDebate.StartTime.Add(time.Since(activeDebate.PausedAt))
+	activeDebate.LastActivityTime = time.Now()
+
+	// Bots hear about the resumption before the countdown starts writing to them
+	activeDebate.TurnDeadline = time.Now().Add(activeDebate.turnTimeout())
+	dm.notifyDebate(activeDebate, createMessage("debate_resumed", DebateResumed{DebateID: debateID}))
+	activeDebate.mutex.RLock()
+	for _, bot := range activeDebate.Bots {
+		chaos.WriteToBot(bot.Conn, createMessage("debate_update", dm.debateState(activeDebate, bot)))
+	}
+	activeDebate.mutex.RUnlock()
+
+	if activeDebate.Debate.Format == FormatSimultaneous {
+		dm.startRoundDeadline(debateID, activeDebate.Debate.CurrentRound)
+	} else {
+		dm.startTimeout(debateID, dm.getNextSpeaker(activeDebate))
+	}
+	activeDebate.TurnTimeLeft = 0
+	dm.startInactivityTimer(debateID)
+	dm.startMaxDurationTimer(debateID)
+
+	log.Printf("Debate %s resumed", debateID)
+}
+
+// notifyDebate sends a message to the connected bots and spectators of a debate
+func (dm *DebateManager) notifyDebate(activeDebate *ActiveDebate, msg Message) {
+	for _, bot := range activeDebate.Bots {
+		if bot.Conn != nil && !activeDebate.Disconnected[bot.Bot.BotIdentifier] {
+			bot.Conn.WriteJSON(msg)
+		}
+	}
+	dm.broadcast <- BroadcastMessage{DebateID: activeDebate.Debate.ID, Message: msg}
+}
+
+// holdForReconnect pauses a running debate after a bot dropped and ends it
+// if the bot has not logged back in within the reconnect grace period
+func (dm *DebateManager) holdForReconnect(activeDebate *ActiveDebate, bot *ConnectedBot, reason string) {
+	debateID := activeDebate.Debate.ID
+	identifier := bot.Bot.BotIdentifier
+	grace := time.Duration(config.Debate.ReconnectGrace) * time.Second
+
+	activeDebate.mutex.Lock()
+	if activeDebate.Disconnected == nil {
+		activeDebate.Disconnected = make(map[string]bool)
+	}
+	if activeDebate.reconnectDeadlines == nil {
+		activeDebate.reconnectDeadlines = make(map[string]int)
+	}
+	activeDebate.Disconnected[identifier] = true
+	activeDebate.reconnectDeadlines[identifier]++
+	generation := activeDebate.reconnectDeadlines[identifier]
+	activeDebate.mutex.Unlock()
+	deadline := time.Now().Add(grace)
+
+	log.Printf("Pausing debate %s for up to %v while bot %s reconnects", debateID, grace, identifier)
+	dm.announceParticipant(activeDebate, identifier, ParticipantReconnecting, 0, deadline)
+	dm.pauseDebate(activeDebate, DebatePaused{
+		Reason:         PauseBotDisconnected,
+		Bot:            identifier,
+		ResumeDeadline: deadline.Format(time.RFC3339),
+	})
+
+	time.AfterFunc(grace, func() {
+		dm.mutex.RLock()
+		current, exists := dm.debates[debateID]
+		dm.mutex.RUnlock()
+		if !exists || current != activeDebate {
+			return
+		}
+		// A bot that came back, or dropped again since, is not due here
+		activeDebate.mutex.RLock()
+		due := activeDebate.Disconnected[identifier] && activeDebate.reconnectDeadlines[identifier] == generation
+		activeDebate.mutex.RUnlock()
+		if !due {
+			return
+		}
+		log.Printf("Bot %s did not reconnect to debate %s in time", identifier, debateID)
+		dm.endDebate(debateID, "timeout", fmt.Sprintf("%s_%s", reason, identifier))
+	})
+}
+
+// reconnectBot gives a bot that dropped out of a running debate its seat
+// back, if the login presents the seat's debate_key. It returns nil for both
+// when the login is not such a reconnect. The bot counts as disconnected
+// until ResumeAfterReconnect, so nothing else writes to its connection
+// before its login_confirmed. Caller holds dm.mutex.
+func (dm *DebateManager) reconnectBot(activeDebate *ActiveDebate, loginReq *LoginRequest, conn *WSConn) (*LoginConfirmed, *LoginRejected) {
+	activeDebate.mutex.Lock()
+	var bot *ConnectedBot
+	for _, candidate := range activeDebate.Bots {
+		if candidate.Bot.BotUUID == loginReq.BotUUID && activeDebate.Disconnected[candidate.Bot.BotIdentifier] {
+			bot = candidate
+		}
+	}
+	if bot == nil {
+		activeDebate.mutex.Unlock()
+		return nil, nil
+	}
+	if subtle.ConstantTimeCompare([]byte(loginReq.DebateKey), []byte(bot.Bot.DebateKey)) != 1 {
+		activeDebate.mutex.Unlock()
+		log.Printf("Rejected reconnect of bot %s to debate %s: wrong debate_key", bot.Bot.BotIdentifier, activeDebate.Debate.ID)
+		return nil, &LoginRejected{
+			Status:   "rejected",
+			Reason:   "invalid_debate_key",
+			Message:  "Reconnecting requires the debate_key from the first login_confirmed",
+			DebateID: activeDebate.Debate.ID,
+		}
+	}
+
+	bot.Conn = conn
+	// The reconnect deadline of this drop no longer applies
+	activeDebate.reconnectDeadlines[bot.Bot.BotIdentifier]++
+	activeDebate.mutex.Unlock()
+	log.Printf("Bot %s reconnected to debate %s", bot.Bot.BotIdentifier, activeDebate.Debate.ID)
+
+	limits := formatLimitsFor(activeDebate.Debate)
+	return &LoginConfirmed{
+		Status:           "confirmed",
+		Message:          "Reconnected to the running debate",
+		DebateID:         activeDebate.Debate.ID,
+		DebateKey:        bot.Bot.DebateKey,
+		BotIdentifier:    bot.Bot.BotIdentifier,
+		Topic:            activeDebate.Debate.Topic,
+		JoinedBots:       activeDebate.joinedIdentifiers(bot.Bot.BotIdentifier),
+		Languages:        activeDebate.Debate.Languages,
+		Reconnected:      true,
+		YourSide:         bot.Bot.Side,
+		TotalRounds:      activeDebate.Debate.TotalRounds,
+		MinContentLength: limits.MinContentLength,
+		MaxContentLength: limits.MaxContentLength,
+	}, nil
+}
+
+// ResumeAfterReconnect marks the bot on conn as back and resumes the debate
+// once no bot is missing, otherwise it only sends the bot the current state.
+// Bots reconnecting at once (as after a restore) are handled one at a time.
+func (dm *DebateManager) ResumeAfterReconnect(debateID string, conn *WSConn) {
+	dm.mutex.Lock()
+	activeDebate, exists := dm.debates[debateID]
+	var identifier string
+	if exists {
+		activeDebate.mutex.Lock()
+		for _, bot := range activeDebate.Bots {
+			if bot.Conn == conn {
+				identifier = bot.Bot.BotIdentifier
+				delete(activeDebate.Disconnected, identifier)
+			}
+		}
+		activeDebate.mutex.Unlock()
+	}
+	dm.mutex.Unlock()
+	if !exists {
+		return
+	}
+	dm.announceParticipant(activeDebate, identifier, ParticipantConnected, 0, time.Time{})
+
+	activeDebate.resuming.Lock()
+	defer activeDebate.resuming.Unlock()
+	if len(activeDebate.Disconnected) == 0 && activeDebate.PauseReason == PauseBotDisconnected && !activeDebate.Held {
+		dm.resumeDebate(activeDebate)
+		return
+	}
+
+	activeDebate.mutex.RLock()
+	defer activeDebate.mutex.RUnlock()
+	for _, bot := range activeDebate.Bots {
+		if bot.Conn == conn {
+			conn.WriteJSON(createMessage("debate_update", dm.debateState(activeDebate, bot)))
+		}
+	}
+}
+
+// HoldDebate pauses a running debate for moderation or maintenance. It stays
+// paused, even through bot reconnects, until ReleaseDebate.
+func (dm *DebateManager) HoldDebate(debateID, note string) error {
+	dm.mutex.RLock()
+	activeDebate, exists := dm.debates[debateID]
+	dm.mutex.RUnlock()
+	if !exists || !isInProgress(activeDebate.Debate.Status) {
+		return errDebateNotRunning
+	}
+
+	activeDebate.resuming.Lock()
+	defer activeDebate.resuming.Unlock()
+	activeDebate.Held = true
+	dm.pauseDebate(activeDebate, DebatePaused{Reason: PauseModerator, Note: note})
+	log.Printf("Debate %s held by a moderator: %s", debateID, note)
+	return nil
+}
+
+// ReleaseDebate resumes a debate held by HoldDebate once all its bots are connected
+func (dm *DebateManager) ReleaseDebate(debateID string) error {
+	dm.mutex.RLock()
+	activeDebate, exists := dm.debates[debateID]
+	dm.mutex.RUnlock()
+	if !exists || !isInProgress(activeDebate.Debate.Status) {
+		return errDebateNotRunning
+	}
+
+	activeDebate.resuming.Lock()
+	defer activeDebate.resuming.Unlock()
+	if !activeDebate.Held {
+		return errNotHeld
+	}
+	if len(activeDebate.Disconnected) > 0 {
+		return errBotMissing
+	}
+	activeDebate.Held = false
+	dm.resumeDebate(activeDebate)
+	return nil
+}
+
+// handleDebatePause handles POST /api/debate/{id}/pause (optional body
+// {"note": "..."}) and POST /api/debate/{id}/resume
+func handleDebatePause(w http.ResponseWriter, r *http.Request, debateID string, pause bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var err error
+	if pause {
+		var req struct {
+			Note string `json:"note"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		err = debateManager.HoldDebate(debateID, req.Note)
+	} else {
+		err = debateManager.ReleaseDebate(debateID)
+	}
+	switch err {
+	case nil:
+	case errDebateNotRunning:
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	default:
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	writeJSON(w, map[string]interface{}{"debate_id": debateID, "paused": pause})
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// renderPDF lays out a Markdown document as a plain A4 PDF. Text is set in
+// STSong-Light, one of the standard Chinese fonts PDF readers supply, so
+// nothing needs embedding and Chinese and Latin text both render. Markdown
+// is only interpreted as far as headings go; other markup prints as written,
+// minus the bold markers.
+
+// Page geometry in points
+const (
+	pdfPageWidth  = 595
+	pdfPageHeight = 842
+	pdfMargin     = 56
+	pdfBodySize   = 10.5
+)
+
+// pdfLine is one laid-out line of text
+type pdfLine struct {
+	Text string
+	Size float64
+}
+
+// renderPDF renders a Markdown document to PDF bytes
+func renderPDF(title, markdown string) []byte {
+	var lines []pdfLine
+	for _, raw := range strings.Split(markdown, "\n") {
+		size := pdfBodySize
+		text := strings.NewReplacer("**", "", "\t", "    ", "\r", "").Replace(raw)
+		switch {
+		case strings.HasPrefix(text, "# "):
+			size, text = 18, text[2:]
+		case strings.HasPrefix(text, "## "):
+			size, text = 14, text[3:]
+		case strings.HasPrefix(text, "### "):
+			size, text = 12, text[4:]
+		}
+		if size != pdfBodySize && len(lines) > 0 {
+			lines = append(lines, pdfLine{Size: size / 2})
+		}
+		for _, wrapped := range wrapPDFText(text, size, pdfPageWidth-2*pdfMargin) {
+			lines = append(lines, pdfLine{Text: wrapped, Size: size})
+		}
+	}
+
+	var pages []string
+	var page strings.Builder
+	y := float64(pdfPageHeight - pdfMargin)
+	for _, line := range lines {
+		leading := line.Size * 1.5
+		if y-leading < pdfMargin {
+			pages = append(pages, page.String())
+			page.Reset()
+			y = pdfPageHeight - pdfMargin
+		}
+		y -= leading
+		if line.Text != "" {
+			fmt.Fprintf(&page, "BT /F1 %.1f Tf 1 0 0 1 %d %.1f Tm <%s> Tj ET\n", line.Size, pdfMargin, y, pdfHex(line.Text))
+		}
+	}
+	pages = append(pages, page.String())
+	return writePDF(title, pages)
+}
+
+// pdfRuneWidth is a rune's advance as a fraction of the font size: Latin
+// characters are half width, everything else full width
+func pdfRuneWidth(r rune) float64 {
+	if r < 0x80 {
+		return 0.5
+	}
+	return 1
+}
+
+// wrapPDFText breaks text into lines no wider than width points, preferring
+// to break at spaces
+func wrapPDFText(text string, size, width float64) []string {
+	if text == "" {
+		return []string{""}
+	}
+	var lines []string
+	for text != "" {
+		used, cut, lastSpace := 0.0, len(text), -1
+		for i, r := range text {
+			used += pdfRuneWidth(r) * size
+			if used > width {
+				cut = i
+				if lastSpace > 0 {
+					cut = lastSpace
+				}
+				break
+			}
+			if r == ' ' {
+				lastSpace = i
+			}
+		}
+		if cut == 0 {
+			_, cut = utf8.DecodeRuneInString(text)
+		}
+		lines = append(lines, strings.TrimRight(text[:cut], " "))
+		text = strings.TrimLeft(text[cut:], " ")
+	}
+	return lines
+}
+
+// pdfHex encodes text as the UCS-2 hex string the UniGB-UCS2-H encoding
+// expects. Characters outside the Basic Multilingual Plane become "?".
+func pdfHex(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		if r > 0xFFFF || utf16.IsSurrogate(r) || r < 0x20 {
+			r = '?'
+		}
+		fmt.Fprintf(&b, "%04X", r)
+	}
+	return b.String()
+}
+
+// writePDF assembles the document objects around the page content streams
+func writePDF(title string, pages []string) []byte {
+	var buf bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1-5: catalog, page tree and fonts; pages follow in pairs and the
+	// info dictionary comes last
+	const firstPage = 6
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	object("<< /Type /Font /Subtype /Type0 /BaseFont /STSong-Light /Encoding /UniGB-UCS2-H /DescendantFonts [4 0 R] >>")
+	object("<< /Type /Font /Subtype /CIDFontType0 /BaseFont /STSong-Light" +
+		" /CIDSystemInfo << /Registry (Adobe) /Ordering (GB1) /Supplement 2 >>" +
+		" /FontDescriptor 5 0 R /DW 1000 /W [1 95 500] >>")
+	object("<< /Type /FontDescriptor /FontName /STSong-Light /Flags 6 /FontBBox [-25 -254 1000 880]" +
+		" /ItalicAngle 0 /Ascent 880 /Descent -120 /CapHeight 880 /StemV 93 >>")
+
+	for i, content := range pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, firstPage+2*i+1))
+
+		var stream bytes.Buffer
+		zw := zlib.NewWriter(&stream)
+		zw.Write([]byte(content))
+		zw.Close()
+		object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", stream.Len(), stream.String()))
+	}
+	object(fmt.Sprintf("<< /Title <FEFF%s> /Producer (bot-debate) >>", pdfHex(title)))
+	info := len(offsets)
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R >>\nstartxref\n%d\n%%EOF\n", len(offsets)+1, info, xref)
+	return buf.Bytes()
+}
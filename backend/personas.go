@@ -0,0 +1,114 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// handlePersonas handles /api/admin/personas (list, create) and
+// /api/admin/personas/{id} (get, update, delete)
+func handlePersonas(w http.ResponseWriter, r *http.Request) {
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/admin/personas"), "/")
+
+	if id == "" {
+		switch r.Method {
+		case http.MethodGet:
+			personas, err := db.GetPersonas()
+			if err != nil {
+				http.Error(w, "Failed to fetch personas", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, personas)
+		case http.MethodPost:
+			createPersona(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		persona, err := db.GetPersona(id)
+		if err != nil {
+			http.Error(w, "Persona not found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, persona)
+	case http.MethodPut:
+		updatePersona(w, r, id)
+	case http.MethodDelete:
+		if err := db.DeletePersona(id); err == sql.ErrNoRows {
+			http.Error(w, "Persona not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			http.Error(w, "Failed to delete persona", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func createPersona(w http.ResponseWriter, r *http.Request) {
+	var persona Persona
+	if err := json.NewDecoder(r.Body).Decode(&persona); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if persona.Name == "" || persona.SystemPrompt == "" {
+		http.Error(w, "Name and system_prompt are required", http.StatusBadRequest)
+		return
+	}
+
+	if persona.ID == "" {
+		persona.ID = "persona-" + uuid.New().String()[:8]
+	}
+	persona.CreatedAt = time.Now()
+	persona.UpdatedAt = persona.CreatedAt
+
+	if err := db.CreatePersona(&persona); err != nil {
+		http.Error(w, "Failed to create persona", http.StatusConflict)
+		return
+	}
+
+	log.Printf("Persona created: %s (%s)", persona.ID, persona.Name)
+	writeJSONStatus(w, http.StatusCreated, persona)
+}
+
+func updatePersona(w http.ResponseWriter, r *http.Request, id string) {
+	existing, err := db.GetPersona(id)
+	if err != nil {
+		http.Error(w, "Persona not found", http.StatusNotFound)
+		return
+	}
+
+	var req Persona
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if req.Name != "" {
+		existing.Name = req.Name
+	}
+	if req.Description != "" {
+		existing.Description = req.Description
+	}
+	if req.SystemPrompt != "" {
+		existing.SystemPrompt = req.SystemPrompt
+	}
+	existing.UpdatedAt = time.Now()
+
+	if err := db.UpdatePersona(existing); err != nil {
+		http.Error(w, "Failed to update persona", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, existing)
+}
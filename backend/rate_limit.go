@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// With rate_limit.enabled, debate creation and WebSocket connection attempts
+// are limited per client IP, and speeches and opening submissions per bot
+// UUID. Each limit is a token bucket: per_minute tokens refill steadily up
+// to burst. Requests over the limit get 429 with Retry-After; speeches get a
+// recoverable RATE_LIMITED error and do not count as violations. The
+// server's own connections, such as the house bot's, are exempt.
+
+// RateLimitRule is one limit; per_minute 0 disables it
+type RateLimitRule struct {
+	PerMinute int `yaml:"per_minute"`
+	Burst     int `yaml:"burst"` // Requests allowed at once; defaults to 1
+}
+
+// RateLimiter keeps a token bucket per key
+type RateLimiter struct {
+	name  string
+	rate  float64 // Tokens per second
+	burst float64
+
+	mutex     sync.Mutex
+	buckets   map[string]*rateBucket
+	lastPrune time.Time
+}
+
+type rateBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// RateLimits are the limiters in force; nil limiters allow everything
+type RateLimits struct {
+	Create  *RateLimiter
+	Connect *RateLimiter
+	Speech  *RateLimiter
+}
+
+// rateLimits is empty when rate limiting is disabled
+var rateLimits = &RateLimits{}
+
+// NewRateLimits creates the configured limiters
+func NewRateLimits(cfg *Config) *RateLimits {
+	r := cfg.RateLimit
+	if !r.Enabled {
+		return &RateLimits{}
+	}
+	return &RateLimits{
+		Create:  NewRateLimiter("create_debate", r.CreateDebate),
+		Connect: NewRateLimiter("connect", r.Connect),
+		Speech:  NewRateLimiter("speech", r.Speech),
+	}
+}
+
+// NewRateLimiter creates a limiter for a rule; nil if the rule is disabled
+func NewRateLimiter(name string, rule RateLimitRule) *RateLimiter {
+	if rule.PerMinute <= 0 {
+		return nil
+	}
+	burst := rule.Burst
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		name:    name,
+		rate:    float64(rule.PerMinute) / 60,
+		burst:   float64(burst),
+		buckets: make(map[string]*rateBucket),
+	}
+}
+
+// Allow takes a token from a key's bucket. When none is left it returns how
+// long until the next one.
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now()
+	l.prune(now)
+
+	bucket, exists := l.buckets[key]
+	if !exists {
+		bucket = &rateBucket{tokens: l.burst, last: now}
+		l.buckets[key] = bucket
+	}
+	bucket.tokens = math.Min(l.burst, bucket.tokens+now.Sub(bucket.last).Seconds()*l.rate)
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		wait := time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second))
+		return false, wait
+	}
+	bucket.tokens--
+	return true, 0
+}
+
+// prune drops buckets that have refilled, at most once a minute. Caller
+// holds the mutex.
+func (l *RateLimiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < time.Minute {
+		return
+	}
+	l.lastPrune = now
+	full := time.Duration(l.burst / l.rate * float64(time.Second))
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.last) >= full {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// retryAfterSeconds rounds a wait up to whole seconds
+func retryAfterSeconds(wait time.Duration) int {
+	return int(math.Ceil(wait.Seconds()))
+}
+
+// clientIP returns the address a request came from. With
+// rate_limit.trust_proxy it is the last X-Forwarded-For hop, the one the
+// trusted proxy appended; earlier hops are whatever the client sent.
+func clientIP(r *http.Request) string {
+	if config.RateLimit.TrustProxy {
+		if values := r.Header.Values("X-Forwarded-For"); len(values) > 0 {
+			hops := strings.Split(values[len(values)-1], ",")
+			if last := strings.TrimSpace(hops[len(hops)-1]); last != "" {
+				return last
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// rateLimitByIP rejects requests from an IP over the limiter's rate with 429
+func rateLimitByIP(limiter *RateLimiter, next http.HandlerFunc) http.HandlerFunc {
+	if limiter == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if name, ok := authenticate(r); ok && name == "internal" {
+			next(w, r)
+			return
+		}
+		ip := clientIP(r)
+		if allowed, wait := limiter.Allow(ip); !allowed {
+			seconds := retryAfterSeconds(wait)
+			log.Printf("Rate limited %s %s from %s (%s)", r.Method, r.URL.Path, ip, limiter.name)
+			w.Header().Set("Retry-After", fmt.Sprintf("%d", seconds))
+			http.Error(w, fmt.Sprintf("Rate limit exceeded, retry in %d seconds", seconds), http.StatusTooManyRequests)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// allowSpeech checks a bot's speech rate, replying RATE_LIMITED when it is
+// over the limit
+func allowSpeech(conn *WSConn, msg *Message, botUUID, debateID string) bool {
+	allowed, wait := rateLimits.Speech.Allow(botUUID)
+	if allowed {
+		return true
+	}
+	seconds := retryAfterSeconds(wait)
+	writeReply(conn, msg, "error", &ErrorMessage{
+		ErrorCode:   "RATE_LIMITED",
+		Message:     fmt.Sprintf("Too many submissions, retry in %d seconds", seconds),
+		DebateID:    debateID,
+		Details:     fmt.Sprintf("%d", seconds),
+		Recoverable: true,
+	})
+	return false
+}
@@ -0,0 +1,415 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// The reaper sweeps for debates left in states nothing will move them out
+// of: debates the database says are running but no live instance holds
+// (the instance crashed or restarted), waiting debates with no waiting timer
+// (loaded from the database after a restart) or whose creator is gone,
+// running debates with no bot connected and no reconnect pending, and
+// in-memory entries whose debate has finished or vanished from the
+// database. A finding is only acted on when the next sweep still sees it,
+// so debates caught mid-transition are left alone. Every repair is recorded
+// in reaper_actions with its reason, listed at /api/admin/reaper. Ended
+// debates are also dropped from memory once retain_ended has passed and no
+// spectator is watching.
+
+// Reaper actions
+const (
+	ReaperInterrupted    = "interrupted"     // Running in the database, held by no live instance
+	ReaperTimedOut       = "timed_out"       // Waiting past debate.waiting_timeout with nothing to time it out
+	ReaperTimerRestarted = "timer_restarted" // Waiting in memory without a waiting timer
+	ReaperEnded          = "ended"           // Running in memory with no bot connected or reconnecting
+	ReaperOrphanRemoved  = "orphan_removed"  // In memory, but finished or missing in the database
+)
+
+// ReasonNoBotsConnected ends a running debate every bot has left
+const ReasonNoBotsConnected = "no_bots_connected"
+
+// ReaperAction is one repair, as recorded in reaper_actions
+type ReaperAction struct {
+	ID         int64     `json:"id"`
+	DebateID   string    `json:"debate_id"`
+	Action     string    `json:"action"`
+	Reason     string    `json:"reason"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status,omitempty"`
+	InstanceID string    `json:"instance_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// UnfinishedDebate is a debate the database has as waiting or running
+type UnfinishedDebate struct {
+	ID          string
+	Status      string
+	Owner       string
+	OwnerSeen   time.Time // Last heartbeat of the owner; zero if it has none
+	HasSnapshot bool
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// Reaper runs the periodic sweeps
+type Reaper struct {
+	dm             *DebateManager
+	interval       time.Duration
+	abandonedAfter time.Duration
+	retainEnded    time.Duration
+
+	mutex    sync.Mutex
+	suspects map[string]time.Time // debate ID + action -> first sweep that saw it
+	ended    map[string]time.Time // Ended debate ID -> first sweep that saw it ended
+}
+
+// StartReaper starts sweeping if the reaper is enabled; replicas never sweep
+func StartReaper(dm *DebateManager, cfg *Config) *Reaper {
+	r := cfg.Reaper
+	if !r.Enabled || isReplica() {
+		return nil
+	}
+	reaper := &Reaper{
+		dm:             dm,
+		interval:       time.Duration(r.Interval) * time.Second,
+		abandonedAfter: time.Duration(r.AbandonedAfter) * time.Second,
+		retainEnded:    time.Duration(r.RetainEnded) * time.Second,
+		suspects:       make(map[string]time.Time),
+		ended:          make(map[string]time.Time),
+	}
+	go func() {
+		ticker := time.NewTicker(reaper.interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if shuttingDown.Load() {
+				return
+			}
+			reaper.Sweep()
+		}
+	}()
+	log.Printf("Reaper started (interval: %v)", reaper.interval)
+	return reaper
+}
+
+// Sweep runs one pass over the database and the in-memory debates
+func (r *Reaper) Sweep() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	seen := map[string]bool{}
+	confirmed := func(debateID, action string) bool {
+		key := debateID + "/" + action
+		seen[key] = true
+		first, exists := r.suspects[key]
+		if !exists {
+			r.suspects[key] = now
+			return false
+		}
+		return now.Sub(first) >= r.interval
+	}
+
+	r.sweepDatabase(now, confirmed)
+	r.sweepMemory(now, confirmed)
+
+	for key := range r.suspects {
+		if !seen[key] {
+			delete(r.suspects, key)
+		}
+	}
+}
+
+// sweepDatabase closes unfinished debates that no live instance holds
+func (r *Reaper) sweepDatabase(now time.Time, confirmed func(debateID, action string) bool) {
+	debates, err := r.dm.db.GetUnfinishedDebates()
+	if err != nil {
+		log.Printf("Reaper failed to list unfinished debates: %v", err)
+		return
+	}
+	waitingTimeout := time.Duration(config.Debate.WaitingTimeout) * time.Second
+
+	for _, d := range debates {
+		r.dm.mutex.RLock()
+		_, inMemory := r.dm.debates[d.ID]
+		r.dm.mutex.RUnlock()
+		if inMemory {
+			continue
+		}
+		// Another live instance is running it
+		if d.Owner != "" && d.Owner != cluster.InstanceID && now.Sub(d.OwnerSeen) <= instanceStaleAfter {
+			continue
+		}
+
+		switch {
+		case d.Status == "waiting":
+			// Created debates wait in the memory of the instance that made them;
+			// past the timeout plus a sweep, that instance did not time it out
+			if now.Sub(d.CreatedAt) < waitingTimeout+r.interval || !confirmed(d.ID, ReaperTimedOut) {
+				continue
+			}
+			r.closeStored(d, "timeout", ReaperTimedOut, "waiting timeout passed with no instance holding the debate")
+		case isRunning(d.Status):
+			// A snapshot is picked up by the first bot to log back in; give up on
+			// it, like on a debate no instance holds, once it has gone untouched
+			if now.Sub(d.UpdatedAt) < r.abandonedAfter || !confirmed(d.ID, ReaperInterrupted) {
+				continue
+			}
+			reason := "no live instance holds the running debate"
+			if d.HasSnapshot {
+				reason = "no bot came back to continue the snapshotted debate"
+			}
+			r.closeStored(d, StatusInterrupted, ReaperInterrupted, reason)
+		}
+	}
+}
+
+// closeStored moves a debate that is only in the database to a final status
+func (r *Reaper) closeStored(d UnfinishedDebate, status, action, reason string) {
+	closed, err := r.dm.db.CloseStaleDebate(d.ID, d.Status, status)
+	if err != nil {
+		log.Printf("Reaper failed to close debate %s: %v", d.ID, err)
+		return
+	}
+	if !closed {
+		return // It moved on in the meantime
+	}
+	r.record(d.ID, action, reason, d.Status, status)
+
+	debate, err := r.dm.db.GetDebate(d.ID)
+	if err != nil {
+		return
+	}
+	webhooks.Send(WebhookDebateEnd, d.ID, DebateEnd{
+		DebateID:     d.ID,
+		Topic:        debate.Topic,
+		TotalRounds:  debate.TotalRounds,
+		Status:       status,
+		DebateResult: DebateResult{Winner: "none", Reason: action},
+	})
+	go matchEnded(debate, nil)
+}
+
+// sweepMemory repairs in-memory debates and drops ended ones
+func (r *Reaper) sweepMemory(now time.Time, confirmed func(debateID, action string) bool) {
+	r.dm.mutex.RLock()
+	debates := make(map[string]*ActiveDebate, len(r.dm.debates))
+	for id, activeDebate := range r.dm.debates {
+		debates[id] = activeDebate
+	}
+	r.dm.mutex.RUnlock()
+
+	for debateID, activeDebate := range debates {
+		activeDebate.mutex.RLock()
+		status := activeDebate.Debate.Status
+		spectators := activeDebate.spectatorTotal()
+		connected := 0
+		for _, bot := range activeDebate.Bots {
+			if bot.Conn != nil {
+				connected++
+			}
+		}
+		reconnecting := activeDebate.Paused || activeDebate.Held || len(activeDebate.Disconnected) > 0
+		activeDebate.mutex.RUnlock()
+
+		if status != "waiting" && !isRunning(status) {
+			r.evictEnded(debateID, activeDebate, now, spectators)
+			continue
+		}
+		delete(r.ended, debateID)
+
+		stored, err := r.dm.db.GetDebate(debateID)
+		if err == sql.ErrNoRows || (err == nil && stored.Status != "waiting" && !isRunning(stored.Status)) {
+			if !confirmed(debateID, ReaperOrphanRemoved) {
+				continue
+			}
+			toStatus := ""
+			if stored != nil {
+				toStatus = stored.Status
+			}
+			r.removeOrphan(debateID, activeDebate, status, toStatus)
+			continue
+		}
+
+		switch {
+		case status == "waiting":
+			r.dm.mutex.RLock()
+			timerless := activeDebate.WaitingTimer == nil && activeDebate.TimeoutTimer == nil
+			r.dm.mutex.RUnlock()
+			if timerless && confirmed(debateID, ReaperTimerRestarted) {
+				r.dm.startWaitingTimer(debateID)
+				r.record(debateID, ReaperTimerRestarted, "waiting debate had no waiting timer", status, status)
+			}
+		case isInProgress(status) && connected == 0 && !reconnecting:
+			if confirmed(debateID, ReaperEnded) {
+				r.record(debateID, ReaperEnded, "no bot connected and none reconnecting", status, "timeout")
+				go r.dm.endDebate(debateID, "timeout", ReasonNoBotsConnected)
+			}
+		}
+	}
+}
+
+// evictEnded drops an ended debate from memory once retain_ended has passed
+// and no spectator is left
+func (r *Reaper) evictEnded(debateID string, activeDebate *ActiveDebate, now time.Time, spectators int) {
+	first, exists := r.ended[debateID]
+	if !exists {
+		r.ended[debateID] = now
+		return
+	}
+	if spectators > 0 || now.Sub(first) < r.retainEnded {
+		return
+	}
+	r.dm.mutex.Lock()
+	if r.dm.debates[debateID] == activeDebate {
+		delete(r.dm.debates, debateID)
+	}
+	r.dm.mutex.Unlock()
+	delete(r.ended, debateID)
+	log.Printf("Reaper dropped ended debate %s from memory", debateID)
+}
+
+// removeOrphan stops the clocks of an in-memory debate the database has
+// finished or lost, and drops it
+func (r *Reaper) removeOrphan(debateID string, activeDebate *ActiveDebate, fromStatus, toStatus string) {
+	r.dm.mutex.Lock()
+	if r.dm.debates[debateID] != activeDebate {
+		r.dm.mutex.Unlock()
+		return
+	}
+	delete(r.dm.debates, debateID)
+	r.dm.mutex.Unlock()
+
+	for _, timer := range []*time.Timer{activeDebate.WaitingTimer, activeDebate.TimeoutTimer, activeDebate.InactivityTimer, activeDebate.MaxDurationTimer} {
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+	stopCountdown(activeDebate)
+
+	reason := "debate no longer exists in the database"
+	if toStatus != "" {
+		reason = "database has the debate as " + toStatus
+	}
+	r.record(debateID, ReaperOrphanRemoved, reason, fromStatus, toStatus)
+}
+
+// record stores and logs a repair
+func (r *Reaper) record(debateID, action, reason, fromStatus, toStatus string) {
+	log.Printf("Reaper %s debate %s (%s -> %s): %s", action, debateID, fromStatus, toStatus, reason)
+	err := r.dm.db.AddReaperAction(&ReaperAction{
+		DebateID:   debateID,
+		Action:     action,
+		Reason:     reason,
+		FromStatus: fromStatus,
+		ToStatus:   toStatus,
+		InstanceID: cluster.InstanceID,
+		CreatedAt:  time.Now(),
+	})
+	if err != nil {
+		log.Printf("Failed to record reaper action for debate %s: %v", debateID, err)
+	}
+}
+
+// handleAdminReaper returns the most recent reaper actions
+func handleAdminReaper(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+
+	actions, err := db.GetReaperActions(limit)
+	if err != nil {
+		http.Error(w, "Failed to fetch reaper actions", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, actions)
+}
+
+// GetUnfinishedDebates returns the debates stored as waiting or running,
+// with their owner's last heartbeat and whether a snapshot awaits them
+func (d *Database) GetUnfinishedDebates() ([]UnfinishedDebate, error) {
+	query := `SELECT d.id, d.status, COALESCE(d.owner_instance, ''), i.last_seen, s.debate_id IS NOT NULL, d.created_at, d.updated_at
+	          FROM debates d
+	          LEFT JOIN instances i ON i.instance_id = d.owner_instance
+	          LEFT JOIN debate_snapshots s ON s.debate_id = d.id
+	          WHERE d.status IN (?, ?, ?, ?)`
+	rows, err := d.db.Query(query, "waiting", "active", StatusOvertime, StatusClosing)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var debates []UnfinishedDebate
+	for rows.Next() {
+		var u UnfinishedDebate
+		var ownerSeen sql.NullTime
+		if err := rows.Scan(&u.ID, &u.Status, &u.Owner, &ownerSeen, &u.HasSnapshot, &u.CreatedAt, &u.UpdatedAt); err != nil {
+			return nil, err
+		}
+		u.OwnerSeen = ownerSeen.Time
+		debates = append(debates, u)
+	}
+	return debates, rows.Err()
+}
+
+// CloseStaleDebate moves a debate from fromStatus to status and drops any
+// snapshot of it; false if its status had already changed
+func (d *Database) CloseStaleDebate(debateID, fromStatus, status string) (bool, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`UPDATE debates SET status = ?, owner_instance = '', updated_at = ? WHERE id = ? AND status = ?`,
+		status, time.Now(), debateID, fromStatus)
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+	if _, err := tx.Exec(`DELETE FROM debate_snapshots WHERE debate_id = ?`, debateID); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// AddReaperAction records a reaper repair
+func (d *Database) AddReaperAction(a *ReaperAction) error {
+	query := `INSERT INTO reaper_actions (debate_id, action, reason, from_status, to_status, instance_id, created_at)
+	          VALUES (?, ?, ?, ?, ?, ?, ?)`
+	_, err := d.db.Exec(query, a.DebateID, a.Action, a.Reason, a.FromStatus, a.ToStatus, a.InstanceID, a.CreatedAt)
+	return err
+}
+
+// GetReaperActions returns the most recent reaper repairs, newest first
+func (d *Database) GetReaperActions(limit int) ([]ReaperAction, error) {
+	query := `SELECT id, debate_id, action, reason, from_status, to_status, instance_id, created_at
+	          FROM reaper_actions ORDER BY id DESC LIMIT ?`
+	rows, err := d.db.Query(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	actions := []ReaperAction{}
+	for rows.Next() {
+		var a ReaperAction
+		if err := rows.Scan(&a.ID, &a.DebateID, &a.Action, &a.Reason, &a.FromStatus, &a.ToStatus, &a.InstanceID, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		actions = append(actions, a)
+	}
+	return actions, rows.Err()
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Built-in PII patterns, applied when redaction.pii is set
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	phonePattern = regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?(?:\(\d{2,4}\)|\b\d{2,4})[\s.-]?\d{3,4}[\s.-]?\d{4}\b`)
+)
+
+// Redactor masks profanity and personal data in public views of a debate.
+// Transcripts are stored raw; redaction happens on the way out.
+type Redactor struct {
+	patterns []*regexp.Regexp
+	mask     string
+}
+
+// NewRedactor compiles the configured word lists and patterns. It returns nil
+// when redaction is disabled, and a nil Redactor leaves text unchanged.
+func NewRedactor(config *Config) (*Redactor, error) {
+	cfg := config.Redaction
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	words := append([]string{}, cfg.Words...)
+	for _, path := range cfg.WordLists {
+		listed, err := readWordList(path)
+		if err != nil {
+			return nil, err
+		}
+		words = append(words, listed...)
+	}
+
+	r := &Redactor{mask: cfg.Mask}
+	if len(words) > 0 {
+		quoted := make([]string, len(words))
+		for i, word := range words {
+			quoted[i] = regexp.QuoteMeta(word)
+		}
+		r.patterns = append(r.patterns, regexp.MustCompile(`(?i)`+strings.Join(quoted, "|")))
+	}
+	if cfg.PII {
+		r.patterns = append(r.patterns, emailPattern, phonePattern)
+	}
+	for _, expr := range cfg.Patterns {
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", expr, err)
+		}
+		r.patterns = append(r.patterns, pattern)
+	}
+	return r, nil
+}
+
+// readWordList reads one word per line, skipping blank lines and # comments
+func readWordList(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open redaction word list: %w", err)
+	}
+	defer f.Close()
+
+	words := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	return words, scanner.Err()
+}
+
+// Redact masks every match in s
+func (r *Redactor) Redact(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+	for _, pattern := range r.patterns {
+		s = pattern.ReplaceAllLiteralString(s, r.mask)
+	}
+	return s
+}
+
+// Debate returns a copy of the debate with its topic redacted
+func (r *Redactor) Debate(debate *Debate) *Debate {
+	if r == nil || debate == nil {
+		return debate
+	}
+	redacted := *debate
+	redacted.Topic = r.Redact(debate.Topic)
+	redacted.TopicTranslations = r.translations(debate.TopicTranslations)
+	return &redacted
+}
+
+// Log returns a copy of the debate log with speeches, translations and highlighted quotes redacted
+func (r *Redactor) Log(debateLog []DebateLogEntry) []DebateLogEntry {
+	if r == nil || debateLog == nil {
+		return debateLog
+	}
+	redacted := make([]DebateLogEntry, len(debateLog))
+	for i, entry := range debateLog {
+		entry.Message.Content = r.Redact(entry.Message.Content)
+		entry.Message.Translations = r.translations(entry.Message.Translations)
+		if entry.Highlights != nil {
+			highlights := make([]Highlight, len(entry.Highlights))
+			for j, h := range entry.Highlights {
+				highlights[j] = Highlight{Point: r.Redact(h.Point), Quote: r.Redact(h.Quote)}
+			}
+			entry.Highlights = highlights
+		}
+		redacted[i] = entry
+	}
+	return redacted
+}
+
+// Result returns a copy of the result with the summary, round comments, citations and panel summaries redacted
+func (r *Redactor) Result(result *DebateResult) *DebateResult {
+	if r == nil || result == nil {
+		return result
+	}
+	redacted := *result
+	redacted.Summary.Content = r.Redact(result.Summary.Content)
+	if result.RoundResults != nil {
+		redacted.RoundResults = make([]RoundResult, len(result.RoundResults))
+		for i, rr := range result.RoundResults {
+			rr.Comment = r.Redact(rr.Comment)
+			redacted.RoundResults[i] = rr
+		}
+	}
+	if result.Citations != nil {
+		redacted.Citations = make([]VerdictCitation, len(result.Citations))
+		for i, c := range result.Citations {
+			c.Point = r.Redact(c.Point)
+			c.Quote = r.Redact(c.Quote)
+			redacted.Citations[i] = c
+		}
+	}
+	if result.Panel != nil {
+		redacted.Panel = make([]PanelVerdict, len(result.Panel))
+		for i, v := range result.Panel {
+			v.Summary = r.Redact(v.Summary)
+			redacted.Panel[i] = v
+		}
+	}
+	return &redacted
+}
+
+// Message returns a copy of a spectator message with its topic, speeches and
+// result redacted, for streams that deliver debates as they happen
+func (r *Redactor) Message(msg Message) Message {
+	if r == nil {
+		return msg
+	}
+	switch data := msg.Data.(type) {
+	case DebateUpdate:
+		data.Topic = r.Redact(data.Topic)
+		data.DebateLog = r.Log(data.DebateLog)
+		msg.Data = data
+	case DebateStart:
+		data.Topic = r.Redact(data.Topic)
+		data.DebateLog = r.Log(data.DebateLog)
+		msg.Data = data
+	case DebateEnd:
+		data.Topic = r.Redact(data.Topic)
+		data.DebateLog = r.Log(data.DebateLog)
+		data.DebateResult = *r.Result(&data.DebateResult)
+		msg.Data = data
+	case DebateWaiting:
+		data.Topic = r.Redact(data.Topic)
+		msg.Data = data
+	case DebateSnapshot:
+		data.Topic = r.Redact(data.Topic)
+		data.DebateLog = r.Log(data.DebateLog)
+		data.DebateResult = r.Result(data.DebateResult)
+		msg.Data = data
+	case RoundReveal:
+		data.Entries = r.Log(data.Entries)
+		msg.Data = data
+	case RoundResultMessage:
+		data.Comment = r.Redact(data.Comment)
+		msg.Data = data
+	case SpeechTranslation:
+		data.Content = r.Redact(data.Content)
+		msg.Data = data
+	case JudgeStream:
+		data.Delta = r.Redact(data.Delta)
+		msg.Data = data
+	}
+	return msg
+}
+
+func (r *Redactor) translations(translations map[string]string) map[string]string {
+	if translations == nil {
+		return nil
+	}
+	redacted := make(map[string]string, len(translations))
+	for lang, content := range translations {
+		redacted[lang] = r.Redact(content)
+	}
+	return redacted
+}
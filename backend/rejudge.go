@@ -0,0 +1,325 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RejudgeJobRequest selects historical debates to rejudge under a new rubric/model
+type RejudgeJobRequest struct {
+	DebateIDs []string `json:"debate_ids,omitempty"` // Explicit debates; otherwise the most recent completed ones
+	Limit     int      `json:"limit,omitempty"`
+	Model     string   `json:"model,omitempty"`
+	Rubric    string   `json:"rubric,omitempty"`
+}
+
+// RejudgeJob is a batch rejudge run and its diff report
+type RejudgeJob struct {
+	ID             string         `json:"job_id"`
+	Status         string         `json:"status"` // running, completed, applied, partial or failed
+	Model          string         `json:"model"`
+	Rubric         string         `json:"rubric,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	CompletedAt    *time.Time     `json:"completed_at,omitempty"`
+	Items          []*RejudgeItem `json:"items"`
+	ChangedWinners int            `json:"changed_winners"`
+}
+
+// RejudgeItem compares the authoritative result of one debate with its rejudged result
+type RejudgeItem struct {
+	DebateID          string `json:"debate_id"`
+	OriginalWinner    string `json:"original_winner"`
+	OriginalSupport   int    `json:"original_supporting_score"`
+	OriginalOppose    int    `json:"original_opposing_score"`
+	NewWinner         string `json:"new_winner,omitempty"`
+	NewSupport        int    `json:"new_supporting_score"`
+	NewOppose         int    `json:"new_opposing_score"`
+	NewSummary        string `json:"new_summary,omitempty"`
+	WinnerChanged     bool   `json:"winner_changed"`
+	SupportScoreDelta int    `json:"supporting_score_delta"`
+	OpposeScoreDelta  int    `json:"opposing_score_delta"`
+	Error             string `json:"error,omitempty"`
+	Applied           bool   `json:"applied"`
+}
+
+// handleRejudgeJobs handles POST /api/admin/rejudge (start a job),
+// GET /api/admin/rejudge/{job_id} (report) and
+// POST /api/admin/rejudge/{job_id}/confirm (apply new results)
+func handleRejudgeJobs(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/admin/rejudge"), "/"), "/")
+
+	switch {
+	case parts[0] == "" && r.Method == http.MethodPost:
+		startRejudgeJob(w, r)
+	case parts[0] != "" && len(parts) == 1 && r.Method == http.MethodGet:
+		job, err := db.GetRejudgeJob(parts[0])
+		if err != nil {
+			http.Error(w, "Job not found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, job)
+	case len(parts) == 2 && parts[1] == "confirm" && r.Method == http.MethodPost:
+		confirmRejudgeJob(w, r, parts[0])
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func startRejudgeJob(w http.ResponseWriter, r *http.Request) {
+	if chatgptClient == nil {
+		http.Error(w, "AI judge is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req RejudgeJobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	debateIDs := req.DebateIDs
+	if len(debateIDs) == 0 {
+		limit := req.Limit
+		if limit <= 0 || limit > 200 {
+			limit = 20
+		}
+		debates, err := db.GetAllDebates("completed", true)
+		if err != nil {
+			http.Error(w, "Failed to select debates", http.StatusInternalServerError)
+			return
+		}
+		for i := 0; i < len(debates) && i < limit; i++ {
+			debateIDs = append(debateIDs, debates[i].ID)
+		}
+	}
+	if len(debateIDs) == 0 {
+		http.Error(w, "No debates to rejudge", http.StatusBadRequest)
+		return
+	}
+
+	model := req.Model
+	if model == "" {
+		model = chatgptClient.Model
+	}
+
+	job := &RejudgeJob{
+		ID:        "rejudge-" + uuid.New().String()[:8],
+		Status:    "running",
+		Model:     model,
+		Rubric:    req.Rubric,
+		CreatedAt: time.Now(),
+	}
+	if err := db.CreateRejudgeJob(job, cluster.InstanceID); err != nil {
+		http.Error(w, "Failed to create job", http.StatusInternalServerError)
+		return
+	}
+
+	go runRejudgeJob(job, debateIDs)
+
+	log.Printf("Rejudge job %s started for %d debates (model: %s)", job.ID, len(debateIDs), model)
+	writeJSONStatus(w, http.StatusAccepted, job)
+}
+
+// runRejudgeJob judges each debate in turn and stores the comparison
+func runRejudgeJob(job *RejudgeJob, debateIDs []string) {
+	opts := JudgeOptions{Model: job.Model, Rubric: job.Rubric}
+
+	for _, debateID := range debateIDs {
+		item := &RejudgeItem{DebateID: debateID}
+
+		if original, err := db.GetDebateResult(debateID); err == nil {
+			item.OriginalWinner = original.Winner
+			item.OriginalSupport = original.SupportingScore
+			item.OriginalOppose = original.OpposingScore
+		}
+
+		result, err := rejudgeDebate(debateID, opts)
+		if err != nil {
+			item.Error = err.Error()
+		} else {
+			item.NewWinner = result.Winner
+			item.NewSupport = result.SupportingScore
+			item.NewOppose = result.OpposingScore
+			item.NewSummary = result.Summary.Content
+			item.WinnerChanged = item.NewWinner != item.OriginalWinner
+			item.SupportScoreDelta = item.NewSupport - item.OriginalSupport
+			item.OpposeScoreDelta = item.NewOppose - item.OriginalOppose
+		}
+
+		if err := db.AddRejudgeItem(job.ID, item); err != nil {
+			log.Printf("Failed to store rejudge item for %s: %v", debateID, err)
+		}
+	}
+
+	if err := db.CompleteRejudgeJob(job.ID, time.Now()); err != nil {
+		log.Printf("Failed to complete rejudge job %s: %v", job.ID, err)
+	}
+	log.Printf("Rejudge job %s completed", job.ID)
+}
+
+// rejudgeDebate runs the AI judge on a stored transcript
+func rejudgeDebate(debateID string, opts JudgeOptions) (*DebateResult, error) {
+	debate, err := db.GetDebate(debateID)
+	if err != nil {
+		return nil, fmt.Errorf("debate not found")
+	}
+	bots, _ := db.GetBots(debateID)
+	supportingBot, opposingBot := findSides(bots)
+	if supportingBot == nil || opposingBot == nil {
+		return nil, fmt.Errorf("debate has no assigned sides")
+	}
+	debateLog, err := db.GetDebateLog(debateID)
+	if err != nil || len(debateLog) == 0 {
+		return nil, fmt.Errorf("debate has no transcript")
+	}
+
+	if debate.SideChannel {
+		opts.Signals, _ = db.GetSideSignals(debateID)
+	}
+	opts.Style = verdictStyleFor(debate)
+	return chatgptClient.forDebate(debate.ID, UsageJudge).JudgeDebateWith(debate.Topic, debateLog,
+		supportingBot.BotIdentifier, opposingBot.BotIdentifier, opts)
+}
+
+// confirmRejudgeJob replaces the authoritative results with the rejudged
+// ones. The job is applied once every item is; items that failed to rejudge
+// or to save leave it partial, and confirming a partial job again retries
+// the items not yet applied.
+func confirmRejudgeJob(w http.ResponseWriter, r *http.Request, jobID string) {
+	job, err := db.GetRejudgeJob(jobID)
+	if err != nil {
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	}
+	if job.Status != "completed" && job.Status != "partial" {
+		http.Error(w, "Job is not completed", http.StatusConflict)
+		return
+	}
+
+	failed := 0
+	for _, item := range job.Items {
+		if item.Applied {
+			continue
+		}
+		if item.Error != "" || item.NewWinner == "" {
+			failed++
+			continue
+		}
+		result := &DebateResult{
+			Winner:          item.NewWinner,
+			SupportingScore: item.NewSupport,
+			OpposingScore:   item.NewOppose,
+			Summary:         SpeechMessage{Format: "markdown", Content: item.NewSummary},
+			JudgeModel:      job.Model,
+			Rubric:          snapshotRubric(RubricDefault, config.ChatGPT.Judge.Rubric.prompt),
+			Source:          ResultSourceRejudge,
+			ProducedBy:      "rejudge job " + jobID,
+		}
+		if job.Rubric != "" {
+			result.Rubric = snapshotRubric(RubricCustom, job.Rubric)
+		}
+		if err := db.ReplaceDebateResult(item.DebateID, result); err != nil {
+			log.Printf("Failed to apply rejudged result for %s: %v", item.DebateID, err)
+			failed++
+			continue
+		}
+		// The old verdict's citations, panel and close-call runs no longer apply
+		db.SaveCitations(item.DebateID, nil)
+		db.SaveJudgePanel(item.DebateID, nil)
+		db.SaveJudgeRuns(item.DebateID, nil)
+		db.MarkRejudgeItemApplied(jobID, item.DebateID)
+		item.Applied = true
+	}
+
+	job.Status = "applied"
+	if failed > 0 {
+		job.Status = "partial"
+	}
+	db.SetRejudgeJobStatus(jobID, job.Status)
+	go checkSideBias()
+	log.Printf("Rejudge job %s %s (%d of %d items not applied)", jobID, job.Status, failed, len(job.Items))
+	writeJSON(w, job)
+}
+
+// handleRejudgeDebate handles POST /api/debate/{id}/rejudge. The AI judge
+// reruns on the stored transcript, optionally with another model, and the
+// verdict is saved as the debate's next result version, leaving the earlier
+// ones in /api/debate/{id}/results. It needs an admin key, and private
+// debates their spectator token as ?token=.
+func handleRejudgeDebate(w http.ResponseWriter, r *http.Request, debateID string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if reason := judgeUnavailable(); reason != "" {
+		http.Error(w, "AI judge is not available: "+reason, http.StatusServiceUnavailable)
+		return
+	}
+
+	var req struct {
+		Model string `json:"model"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+	}
+
+	debate, err := db.GetDebate(debateID)
+	if err != nil || checkSpectatorAccess(debate, r.URL.Query().Get("token")) != nil {
+		// Don't reveal that a private debate exists
+		http.Error(w, "Debate not found", http.StatusNotFound)
+		return
+	}
+	if !isFinished(debate.Status) {
+		http.Error(w, "Debate is still running", http.StatusConflict)
+		return
+	}
+	if _, err := db.GetDebateResult(debateID); err != nil {
+		http.Error(w, "Debate has no result to rejudge", http.StatusConflict)
+		return
+	}
+
+	start := time.Now()
+	result, err := rejudgeDebate(debateID, JudgeOptions{Model: req.Model})
+	if errors.Is(err, errLLMBudget) {
+		http.Error(w, "LLM budget exhausted, try again later", http.StatusTooManyRequests)
+		return
+	} else if err != nil {
+		log.Printf("Rejudging debate %s failed: %v", debateID, err)
+		http.Error(w, "Failed to rejudge debate: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	result.JudgeMs = time.Since(start).Milliseconds()
+	result.Source = ResultSourceRejudge
+
+	if err := db.ReplaceDebateResult(debateID, result); err != nil {
+		log.Printf("Failed to save rejudged result for %s: %v", debateID, err)
+		http.Error(w, "Failed to save result", http.StatusInternalServerError)
+		return
+	}
+	db.SaveCitations(debateID, result.Citations)
+	db.SaveJudgePanel(debateID, result.Panel)
+	db.SaveJudgeRuns(debateID, result.Runs)
+	go checkSideBias()
+
+	saved, err := db.GetDebateResult(debateID)
+	if err != nil {
+		http.Error(w, "Failed to load result", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Debate %s rejudged by %s as result version %d", debateID, saved.JudgeModel, saved.Version)
+	writeJSON(w, map[string]interface{}{
+		"debate_id": debateID,
+		"version":   saved.Version,
+		"result":    redactor.Result(saved),
+	})
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// A spectator sends replay_debate to watch a finished debate play out again
+// on its own, at the pace it was argued. The server answers replay_start,
+// then sends each speech as replay_entry when its time comes, the gaps
+// between speeches taken from their timestamps and divided by the requested
+// speed, and finishes with replay_end carrying the result. Gaps longer than
+// replay.max_gap, such as a bot running out its speech timeout, are
+// shortened to it. Unlike a watch party the replay is private to the
+// connection; stop_replay, another replay_debate or disconnecting ends it.
+// One scheduler in the DebateManager drives every replay from a single timer.
+
+// ReplayDebate asks for a timed replay of a finished debate
+type ReplayDebate struct {
+	DebateID string  `json:"debate_id"`
+	Token    string  `json:"token,omitempty"` // Spectator token of a private debate
+	Speed    float64 `json:"speed,omitempty"` // Defaults to 1, at most maxReplaySpeed
+}
+
+// ReplayStart describes a timed replay about to play
+type ReplayStart struct {
+	DebateID       string  `json:"debate_id"`
+	Topic          string  `json:"topic"`
+	SupportingSide string  `json:"supporting_side"`
+	OpposingSide   string  `json:"opposing_side"`
+	TotalRounds    int     `json:"total_rounds"`
+	TotalEntries   int     `json:"total_entries"`
+	Speed          float64 `json:"speed"`
+	Duration       int     `json:"duration"` // Seconds the replay takes at this speed
+}
+
+// ReplayEntry is the next speech of a timed replay
+type ReplayEntry struct {
+	DebateID     string         `json:"debate_id"`
+	Position     int            `json:"position"` // Speeches sent including this one
+	TotalEntries int            `json:"total_entries"`
+	Offset       int            `json:"offset"` // Milliseconds into the replay at this speed
+	Entry        DebateLogEntry `json:"entry"`
+}
+
+// ReplayEnd closes a timed replay
+type ReplayEnd struct {
+	DebateID string        `json:"debate_id"`
+	Status   string        `json:"status"` // How the debate ended
+	Result   *DebateResult `json:"result,omitempty"`
+}
+
+// timedReplay is one connection's replay
+type timedReplay struct {
+	conn     *WSConn
+	debateID string
+	status   string
+	entries  []DebateLogEntry
+	offsets  []time.Duration // When each entry is due, from the start, at the replay's speed
+	result   *DebateResult
+	started  time.Time
+	position int // Entries sent
+	index    int // In the scheduler's queue
+}
+
+// due returns when the next entry is sent; a replay without entries ends at once
+func (r *timedReplay) due() time.Time {
+	if r.position >= len(r.offsets) {
+		return r.started
+	}
+	return r.started.Add(r.offsets[r.position])
+}
+
+// replayQueue orders replays by their next due entry
+type replayQueue []*timedReplay
+
+func (q replayQueue) Len() int           { return len(q) }
+func (q replayQueue) Less(i, j int) bool { return q[i].due().Before(q[j].due()) }
+func (q replayQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index, q[j].index = i, j
+}
+func (q *replayQueue) Push(x interface{}) {
+	r := x.(*timedReplay)
+	r.index = len(*q)
+	*q = append(*q, r)
+}
+func (q *replayQueue) Pop() interface{} {
+	old := *q
+	r := old[len(old)-1]
+	*q = old[:len(old)-1]
+	r.index = -1
+	return r
+}
+
+// ReplayScheduler sends the entries of every timed replay when they are due
+type ReplayScheduler struct {
+	mutex   sync.Mutex
+	replays map[*WSConn]*timedReplay
+	queue   replayQueue
+	wake    chan struct{}
+}
+
+// NewReplayScheduler creates a scheduler and starts its loop
+func NewReplayScheduler() *ReplayScheduler {
+	s := &ReplayScheduler{
+		replays: make(map[*WSConn]*timedReplay),
+		wake:    make(chan struct{}, 1),
+	}
+	go s.run()
+	return s
+}
+
+// replayOffsets spaces entries as they were argued, scaled by speed, with
+// every gap capped at replay.max_gap. Entries whose timestamps cannot be
+// read follow replay.speech_interval.
+func replayOffsets(entries []DebateLogEntry, speed float64) []time.Duration {
+	maxGap := time.Duration(config.Replay.MaxGap) * time.Second
+	offsets := make([]time.Duration, len(entries))
+	var previous time.Time
+	var elapsed time.Duration
+	for i, entry := range entries {
+		at, err := time.Parse(time.RFC3339, entry.Timestamp)
+		gap := time.Duration(config.Replay.SpeechInterval) * time.Second
+		if err == nil && !previous.IsZero() {
+			gap = max(at.Sub(previous), 0)
+		}
+		if i == 0 {
+			gap = 0
+		}
+		if err == nil {
+			previous = at
+		}
+		elapsed += time.Duration(float64(min(gap, maxGap)) / speed)
+		offsets[i] = elapsed
+	}
+	return offsets
+}
+
+// Start replays a finished debate to a connection, replacing any replay it
+// was watching, and replies replay_start before the first entry. It fails
+// with errTooManyReplays when replay.max_streams replays are playing.
+func (s *ReplayScheduler) Start(conn *WSConn, req *Message, debate *Debate, speed float64) error {
+	debateLog, err := db.GetDebateLog(debate.ID)
+	if err != nil {
+		return err
+	}
+	bots, _ := db.GetBots(debate.ID)
+	result, _ := db.GetDebateResult(debate.ID)
+	if result != nil {
+		attachCitations(debateLog, result.Citations)
+	}
+
+	r := &timedReplay{
+		conn:     conn,
+		debateID: debate.ID,
+		status:   debate.Status,
+		entries:  redactor.Log(debateLog),
+		result:   redactor.Result(result),
+	}
+	r.offsets = replayOffsets(r.entries, speed)
+	start := &ReplayStart{
+		DebateID:     debate.ID,
+		Topic:        redactor.Debate(debate).Topic,
+		TotalRounds:  debate.TotalRounds,
+		TotalEntries: len(r.entries),
+		Speed:        speed,
+	}
+	if len(r.offsets) > 0 {
+		start.Duration = int(r.offsets[len(r.offsets)-1].Seconds())
+	}
+	if supporting, opposing := findSides(bots); supporting != nil && opposing != nil {
+		start.SupportingSide, start.OpposingSide = supporting.BotIdentifier, opposing.BotIdentifier
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, replacing := s.replays[conn]; !replacing && len(s.replays) >= config.Replay.MaxStreams {
+		return errTooManyReplays
+	}
+	s.remove(conn)
+	writeReply(conn, req, "replay_start", start)
+	r.started = time.Now()
+	s.replays[conn] = r
+	heap.Push(&s.queue, r)
+	s.notify()
+	return nil
+}
+
+// Stop ends a connection's replay; false if it was not watching one
+func (s *ReplayScheduler) Stop(conn *WSConn) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.remove(conn)
+}
+
+// remove drops a connection's replay. Caller holds s.mutex.
+func (s *ReplayScheduler) remove(conn *WSConn) bool {
+	r, exists := s.replays[conn]
+	if !exists {
+		return false
+	}
+	delete(s.replays, conn)
+	if r.index >= 0 {
+		heap.Remove(&s.queue, r.index)
+	}
+	return true
+}
+
+// notify wakes the loop to look at the queue again
+func (s *ReplayScheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// replaySend is a message due on a connection
+type replaySend struct {
+	conn *WSConn
+	msg  Message
+}
+
+// run sends due entries, then sleeps until the next one is due
+func (s *ReplayScheduler) run() {
+	timer := time.NewTimer(time.Hour)
+	for {
+		sends := s.advance()
+		for _, send := range sends {
+			// A client that cannot keep up loses its replay rather than holding up the others
+			if err := send.conn.WriteJSONWithin(send.msg, backgroundWriteTimeout); err != nil {
+				s.Stop(send.conn)
+			}
+		}
+
+		s.mutex.Lock()
+		wait := time.Hour
+		if len(s.queue) > 0 {
+			wait = time.Until(s.queue[0].due())
+		}
+		s.mutex.Unlock()
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+		select {
+		case <-timer.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// advance collects the entries that are due, and the ends of finished replays
+func (s *ReplayScheduler) advance() []replaySend {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var sends []replaySend
+	now := time.Now()
+	for len(s.queue) > 0 && !s.queue[0].due().After(now) {
+		r := s.queue[0]
+		if r.position < len(r.entries) {
+			r.position++
+			sends = append(sends, replaySend{r.conn, createMessage("replay_entry", ReplayEntry{
+				DebateID:     r.debateID,
+				Position:     r.position,
+				TotalEntries: len(r.entries),
+				Offset:       int(r.offsets[r.position-1].Milliseconds()),
+				Entry:        r.entries[r.position-1],
+			})})
+		}
+		if r.position < len(r.entries) {
+			heap.Fix(&s.queue, 0)
+			continue
+		}
+		heap.Pop(&s.queue)
+		delete(s.replays, r.conn)
+		sends = append(sends, replaySend{r.conn, createMessage("replay_end", ReplayEnd{DebateID: r.debateID, Status: r.status, Result: r.result})})
+	}
+	return sends
+}
+
+// handleReplayDebate starts a timed replay for a spectator
+func handleReplayDebate(conn *WSConn, msg *Message) {
+	req := msg.Data.(*ReplayDebate)
+	req.DebateID = resolveDebateID(req.DebateID)
+	reject := func(code, message, details string) {
+		writeReply(conn, msg, "error", ErrorMessage{
+			ErrorCode:   code,
+			Message:     message,
+			DebateID:    req.DebateID,
+			Details:     details,
+			Recoverable: true,
+		})
+	}
+
+	speed := req.Speed
+	if speed == 0 {
+		speed = 1
+	}
+	if speed < 0 || speed > maxReplaySpeed {
+		reject("INVALID_FIELD", fmt.Sprintf("speed must be above 0 and at most %d", maxReplaySpeed), "speed")
+		return
+	}
+	debate, err := db.GetDebate(req.DebateID)
+	if err != nil || checkSpectatorAccess(debate, req.Token) != nil {
+		// Don't reveal that a private debate exists
+		reject("DEBATE_NOT_FOUND", "Debate not found", "")
+		return
+	}
+	if !isFinished(debate.Status) {
+		reject("DEBATE_NOT_FINISHED", "Only finished debates can be replayed", debate.Status)
+		return
+	}
+
+	if err := debateManager.replayer.Start(conn, msg, debate, speed); err == errTooManyReplays {
+		reject("REPLAY_LIMIT_REACHED", err.Error(), "")
+	} else if err != nil {
+		log.Printf("Failed to start replay of debate %s: %v", debate.ID, err)
+		reject("INTERNAL_ERROR", "Failed to load debate", "")
+	}
+}
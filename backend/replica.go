@@ -0,0 +1,167 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Instance roles
+const (
+	RolePrimary = "primary" // Runs debates
+	RoleReplica = "replica" // Serves read endpoints and spectators from a read-only database
+)
+
+// SpectatorHub tracks live spectator subscriptions: the debate manager on a
+// primary, the replica feed on a read-only replica
+type SpectatorHub interface {
+	AddFrontendConnection(debateID, token string, sub *Subscriber, conn *WSConn) error
+	RemoveFrontendConnection(debateID string, conn *WSConn)
+}
+
+// isReplica reports whether this instance runs as a read-only replica
+func isReplica() bool {
+	return config.Cluster.Role == RoleReplica
+}
+
+// replicaGuard rejects writes and bot connections on a read-only replica
+func replicaGuard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isReplica() && (r.URL.Path == "/debate" || (r.Method != http.MethodGet && r.Method != http.MethodHead)) {
+			http.Error(w, "Read-only replica, send writes and bot connections to a primary instance", http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ReplicaFeed pushes debate progress to spectators of a replica. Debates run
+// on a primary, so the feed polls the replicated database and resends the
+// debate_snapshot whenever it changes.
+type ReplicaFeed struct {
+	interval time.Duration
+
+	mutex   sync.Mutex
+	debates map[string]*replicaDebate
+}
+
+type replicaDebate struct {
+	conns       map[*WSConn]*Subscriber
+	fingerprint [sha256.Size]byte
+}
+
+// NewReplicaFeed creates the feed and starts polling
+func NewReplicaFeed(interval time.Duration) *ReplicaFeed {
+	f := &ReplicaFeed{
+		interval: interval,
+		debates:  make(map[string]*replicaDebate),
+	}
+	go f.poll()
+	return f
+}
+
+// AddFrontendConnection subscribes a spectator to a live debate. Finished
+// debates return errDebateNotFound, like on a primary.
+func (f *ReplicaFeed) AddFrontendConnection(debateID, token string, sub *Subscriber, conn *WSConn) error {
+	debate, msg := currentDebateState(debateID)
+	if debate == nil {
+		return errDebateNotFound
+	}
+	if err := checkSpectatorAccess(debate, token); err != nil {
+		return err
+	}
+	if isFinished(debate.Status) {
+		return errDebateNotFound
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	rd, exists := f.debates[debateID]
+	if !exists {
+		rd = &replicaDebate{
+			conns:       make(map[*WSConn]*Subscriber),
+			fingerprint: stateFingerprint(msg),
+		}
+		f.debates[debateID] = rd
+	}
+	rd.conns[conn] = sub
+	return nil
+}
+
+// RemoveFrontendConnection unsubscribes a spectator
+func (f *ReplicaFeed) RemoveFrontendConnection(debateID string, conn *WSConn) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	rd, exists := f.debates[debateID]
+	if !exists {
+		return
+	}
+	delete(rd.conns, conn)
+	if len(rd.conns) == 0 {
+		delete(f.debates, debateID)
+	}
+}
+
+// poll refreshes every watched debate on each tick
+func (f *ReplicaFeed) poll() {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		f.mutex.Lock()
+		debateIDs := make([]string, 0, len(f.debates))
+		for debateID := range f.debates {
+			debateIDs = append(debateIDs, debateID)
+		}
+		f.mutex.Unlock()
+
+		for _, debateID := range debateIDs {
+			f.refresh(debateID)
+		}
+	}
+}
+
+// refresh resends a debate's state to its spectators if it changed since the
+// last poll. Once the debate has finished it is no longer watched.
+func (f *ReplicaFeed) refresh(debateID string) {
+	debate, msg := currentDebateState(debateID)
+	if debate == nil {
+		return
+	}
+	finished := isFinished(debate.Status)
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	rd, exists := f.debates[debateID]
+	if !exists {
+		return
+	}
+	fingerprint := stateFingerprint(msg)
+	if rd.fingerprint == fingerprint {
+		return
+	}
+	rd.fingerprint = fingerprint
+
+	for conn, sub := range rd.conns {
+		if !sub.Wants(*msg) {
+			continue
+		}
+		if err := conn.WriteJSON(localizeMessage(debate, *msg, sub.Language)); err != nil {
+			log.Printf("Error sending replica update to frontend: %v", err)
+		}
+	}
+	if finished {
+		delete(f.debates, debateID)
+	}
+}
+
+// stateFingerprint identifies a state message by its payload; message ids and
+// timestamps differ on every build and are ignored
+func stateFingerprint(msg *Message) [sha256.Size]byte {
+	data, _ := json.Marshal(msg.Data)
+	return sha256.Sum256(append([]byte(msg.Type+"|"), data...))
+}
@@ -0,0 +1,64 @@
+package main
+
+// Tournament and league matches, and debates created by matchmaking, are
+// ordinary debates reserved for two bots.
+// These helpers let the debate manager treat both kinds alike.
+
+// reservedDebateFor returns the waiting match debate of a bot, tournament
+// matches first, or "" if it has none
+func reservedDebateFor(botUUID string) string {
+	if debateID, err := db.GetTournamentDebateForBot(botUUID); err == nil {
+		return debateID
+	}
+	if debateID, err := db.GetLeagueDebateForBot(botUUID); err == nil {
+		return debateID
+	}
+	return ""
+}
+
+// reservedBots returns the two bots a match debate is reserved for. A match
+// whose pairing cannot be loaded admits nobody.
+func reservedBots(debate *Debate) (botA, botB string, reserved bool) {
+	if botA, botB, reserved := lobby.reservedFor(debate.ID); reserved {
+		return botA, botB, true
+	}
+	switch {
+	case debate.TournamentID != "":
+		if match, err := db.GetTournamentMatchByDebate(debate.ID); err == nil {
+			return match.BotA, match.BotB, true
+		}
+		return "", "", true
+	case debate.LeagueID != "":
+		if fixture, err := db.GetLeagueFixtureByDebate(debate.ID); err == nil {
+			return fixture.BotA, fixture.BotB, true
+		}
+		return "", "", true
+	}
+	return "", "", false
+}
+
+// matchEnded records the outcome of a finished match debate in its
+// tournament or league. result is nil when the debate never started.
+func matchEnded(debate *Debate, result *DebateResult) {
+	switch {
+	case debate.TournamentID != "":
+		advanceTournament(debate.ID, result)
+	case debate.LeagueID != "":
+		advanceLeague(debate.ID, result)
+	}
+}
+
+// matchAttendance returns the bot on each side of a match debate and the bots
+// that joined it at all
+func matchAttendance(debateID string) (sides map[string]string, joined map[string]bool) {
+	sides = map[string]string{}
+	joined = map[string]bool{}
+	bots, _ := db.GetBots(debateID)
+	for _, bot := range bots {
+		joined[bot.BotUUID] = true
+		if bot.Side != "" {
+			sides[bot.Side] = bot.BotUUID
+		}
+	}
+	return sides, joined
+}
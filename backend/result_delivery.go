@@ -0,0 +1,130 @@
+package main
+
+import (
+	"log"
+	"strings"
+)
+
+// A bot that is offline when its debate is judged misses debate_end. The
+// result is then flagged as pending for that bot in the bots table, and the
+// next time its bot_uuid logs in, on any instance, it gets one
+// result_available per pending result right after login_confirmed. The flag
+// is cleared once the message is written.
+
+// ResultAvailable is a debate_end delivered late, with the side the bot played
+type ResultAvailable struct {
+	DebateEnd
+	BotIdentifier string `json:"bot_identifier"`
+	Side          string `json:"side"`
+}
+
+// sendDebateEnd sends debate_end to a debate's bots and keeps the result
+// pending for those that are not connected to receive it
+func (dm *DebateManager) sendDebateEnd(activeDebate *ActiveDebate, endMsg Message) {
+	for _, bot := range activeDebate.Bots {
+		delivered := false
+		if bot.Conn != nil {
+			delivered = bot.Conn.WriteJSON(endMsg) == nil && !activeDebate.Disconnected[bot.Bot.BotIdentifier]
+		}
+		if delivered {
+			continue
+		}
+		if err := dm.db.SetResultPending(activeDebate.Debate.ID, bot.Bot.BotUUID, true); err != nil {
+			log.Printf("Failed to keep the result of debate %s for bot %s: %v", activeDebate.Debate.ID, bot.Bot.BotIdentifier, err)
+			continue
+		}
+		log.Printf("Bot %s is offline, result of debate %s kept for its next login", bot.Bot.BotIdentifier, activeDebate.Debate.ID)
+	}
+}
+
+// markGone records that a bot left a debate for good, so its result is kept
+// for its next login rather than written to the dead connection
+func (dm *DebateManager) markGone(activeDebate *ActiveDebate, identifier string) {
+	if activeDebate.Disconnected == nil {
+		activeDebate.Disconnected = make(map[string]bool)
+	}
+	activeDebate.Disconnected[identifier] = true
+}
+
+// deliverPendingResults sends a bot that just logged in the results it missed
+func deliverPendingResults(conn *WSConn, botUUID string) {
+	pending, err := db.GetPendingResults(botUUID)
+	if err != nil {
+		log.Printf("Failed to look up pending results of bot %s: %v", botUUID, err)
+		return
+	}
+	for _, bot := range pending {
+		msg, ok := pendingResultMessage(bot)
+		if ok && conn.WriteJSON(msg) != nil {
+			return // Still pending; the bot gets it on its next login
+		}
+		db.SetResultPending(bot.DebateID, botUUID, false)
+		if ok {
+			log.Printf("Delivered the result of debate %s to bot %s", bot.DebateID, bot.BotIdentifier)
+		}
+	}
+}
+
+// pendingResultMessage rebuilds the debate_end a bot missed from the
+// database. It reports false when the debate has no stored result.
+func pendingResultMessage(bot *Bot) (Message, bool) {
+	debate, err := db.GetDebate(bot.DebateID)
+	if err != nil {
+		return Message{}, false
+	}
+	result, err := db.GetDebateResult(bot.DebateID)
+	if err != nil || result == nil {
+		log.Printf("Debate %s has no stored result to deliver to bot %s", bot.DebateID, bot.BotIdentifier)
+		return Message{}, false
+	}
+	debateLog, _ := db.GetDebateLog(bot.DebateID)
+	attachCitations(debateLog, result.Citations)
+	bots, _ := db.GetBots(bot.DebateID)
+
+	sides := map[string][]string{}
+	for _, b := range bots {
+		sides[b.Side] = append(sides[b.Side], b.BotIdentifier)
+	}
+	return createMessage("result_available", ResultAvailable{
+		DebateEnd: DebateEnd{
+			DebateID:       debate.ID,
+			Topic:          debate.Topic,
+			SupportingSide: strings.Join(sides["supporting"], " / "),
+			OpposingSide:   strings.Join(sides["opposing"], " / "),
+			TotalRounds:    debate.TotalRounds,
+			Status:         debate.Status,
+			DebateLog:      debateLog,
+			DebateResult:   *result,
+			Judging:        resultJudging(result),
+		},
+		BotIdentifier: bot.BotIdentifier,
+		Side:          bot.Side,
+	}), true
+}
+
+// SetResultPending flags or clears a bot's undelivered debate result
+func (d *Database) SetResultPending(debateID, botUUID string, pending bool) error {
+	_, err := d.db.Exec(`UPDATE bots SET result_pending = ? WHERE debate_id = ? AND bot_uuid = ?`, pending, debateID, botUUID)
+	return err
+}
+
+// GetPendingResults returns the debates of a bot whose results it has not received, oldest first
+func (d *Database) GetPendingResults(botUUID string) ([]*Bot, error) {
+	query := `SELECT ` + botColumns + ` FROM bots WHERE bot_uuid = ? AND result_pending = 1 ORDER BY connected_at`
+
+	rows, err := d.db.Query(query, botUUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bots []*Bot
+	for rows.Next() {
+		bot, err := scanBot(rows)
+		if err != nil {
+			return nil, err
+		}
+		bots = append(bots, bot)
+	}
+	return bots, nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+)
+
+// Result sources. Every stored result is kept as a numbered version in
+// result_versions; debate_results holds the authoritative one.
+const (
+	ResultSourceJudge    = "ai_judge"      // LLM verdict at debate end
+	ResultSourceFallback = "fallback"      // Speech-count scoring when no judge ran
+	ResultSourceRounds   = "round_scoring" // Per-round judgements summed up
+	ResultSourceRejudge  = "rejudge"       // Applied from an admin rejudge job or POST /api/debate/{id}/rejudge
+	ResultSourceImport   = "imported"      // Supplied with an imported transcript
+	ResultSourceForfeit  = "forfeit"       // A bot was disqualified for repeated rule violations
+)
+
+// ResultVersion is one stored result of a debate and how it differs from the previous version
+type ResultVersion struct {
+	Version              int           `json:"version"`
+	Source               string        `json:"source"`
+	ProducedBy           string        `json:"produced_by,omitempty"` // Judge model, rejudge job or admin behind the version
+	Winner               string        `json:"winner"`
+	SupportingScore      int           `json:"supporting_score"`
+	OpposingScore        int           `json:"opposing_score"`
+	Summary              SpeechMessage `json:"summary"`
+	JudgeModel           string        `json:"judge_model,omitempty"`
+	RubricID             string        `json:"rubric_id,omitempty"`
+	RubricHash           string        `json:"rubric_hash,omitempty"`
+	CreatedAt            string        `json:"created_at"`
+	Authoritative        bool          `json:"authoritative"`
+	WinnerChanged        bool          `json:"winner_changed"`
+	SupportingScoreDelta int           `json:"supporting_score_delta"`
+	OpposingScoreDelta   int           `json:"opposing_score_delta"`
+}
+
+// addResultVersion records result as the next version of a debate's result
+// and makes it the authoritative one. summary is the encoded summary body.
+func addResultVersion(tx *sql.Tx, debateID string, result *DebateResult, summary interface{}, encoding, rubricID, rubricHash string) error {
+	var version int
+	err := tx.QueryRow(`SELECT COALESCE(MAX(version), 0) + 1 FROM result_versions WHERE debate_id = ?`, debateID).Scan(&version)
+	if err != nil {
+		return err
+	}
+	query := `INSERT INTO result_versions (debate_id, version, source, produced_by, winner, supporting_score, opposing_score,
+	              summary_format, summary_content, summary_encoding, judge_model, rubric_id, rubric_hash)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err = tx.Exec(query, debateID, version, result.Source, result.ProducedBy, result.Winner,
+		result.SupportingScore, result.OpposingScore, result.Summary.Format, summary, encoding,
+		result.JudgeModel, rubricID, rubricHash)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`UPDATE debate_results SET authoritative_version = ? WHERE debate_id = ?`, version, debateID)
+	return err
+}
+
+// GetResultVersions returns every result version of a debate, oldest first
+func (d *Database) GetResultVersions(debateID string) ([]ResultVersion, error) {
+	query := `SELECT v.version, v.source, v.produced_by, v.winner, v.supporting_score, v.opposing_score,
+	              v.summary_format, v.summary_content, v.summary_encoding, v.judge_model, v.rubric_id, v.rubric_hash,
+	              v.created_at, v.version = r.authoritative_version
+	          FROM result_versions v JOIN debate_results r ON r.debate_id = v.debate_id
+	          WHERE v.debate_id = ? ORDER BY v.version ASC`
+
+	rows, err := d.db.Query(query, debateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []ResultVersion{}
+	for rows.Next() {
+		var v ResultVersion
+		var stored []byte
+		var encoding string
+		if err := rows.Scan(&v.Version, &v.Source, &v.ProducedBy, &v.Winner, &v.SupportingScore, &v.OpposingScore,
+			&v.Summary.Format, &stored, &encoding, &v.JudgeModel, &v.RubricID, &v.RubricHash,
+			&v.CreatedAt, &v.Authoritative); err != nil {
+			return nil, err
+		}
+		if v.Summary.Content, err = decodeBody(stored, encoding); err != nil {
+			return nil, err
+		}
+		if len(versions) > 0 {
+			prev := versions[len(versions)-1]
+			v.WinnerChanged = v.Winner != prev.Winner
+			v.SupportingScoreDelta = v.SupportingScore - prev.SupportingScore
+			v.OpposingScoreDelta = v.OpposingScore - prev.OpposingScore
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+// handleDebateResults handles GET /api/debate/{id}/results
+func handleDebateResults(w http.ResponseWriter, r *http.Request, debateID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	debate, err := db.GetDebate(debateID)
+	if err != nil || checkSpectatorAccess(debate, r.URL.Query().Get("token")) != nil {
+		http.Error(w, "Debate not found", http.StatusNotFound)
+		return
+	}
+	versions, err := db.GetResultVersions(debateID)
+	if err != nil {
+		http.Error(w, "Failed to load results", http.StatusInternalServerError)
+		return
+	}
+	for i := range versions {
+		versions[i].Summary.Content = redactor.Redact(versions[i].Summary.Content)
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"debate_id": debateID,
+		"versions":  versions,
+	})
+}
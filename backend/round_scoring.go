@@ -0,0 +1,264 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Debate scoring modes
+const (
+	ScoringHolistic   = "holistic"   // One judgement of the whole debate
+	ScoringRounds     = "rounds"     // Each round is won or lost, most rounds wins
+	ScoringCumulative = "cumulative" // Each round is scored, the most points over all rounds wins
+)
+
+// RoundUnscored is the winner of a round the AI judge failed to score; it
+// counts for neither side and is left out of the totals
+const RoundUnscored = "unscored"
+
+// roundJudgeAttempts is how often a failing round judgement is tried, with
+// roundJudgeBackoff before the first retry, doubled for each further one
+const (
+	roundJudgeAttempts = 3
+	roundJudgeBackoff  = 2 * time.Second
+)
+
+// scoresRounds reports whether a debate is judged round by round
+func scoresRounds(debate *Debate) bool {
+	return debate.Scoring == ScoringRounds || debate.Scoring == ScoringCumulative
+}
+
+// runningScore totals the rounds judged so far; nil before the first.
+// Caller holds activeDebate.mutex.
+func (activeDebate *ActiveDebate) runningScore() *RoundScore {
+	if len(activeDebate.RoundResults) == 0 {
+		return nil
+	}
+	score := &RoundScore{}
+	for _, r := range activeDebate.RoundResults {
+		if r.Round > score.Round {
+			score.Round = r.Round
+		}
+		if r.Winner == RoundUnscored {
+			continue
+		}
+		score.RoundsScored++
+		score.SupportingTotal += r.SupportingScore
+		score.OpposingTotal += r.OpposingScore
+	}
+	return score
+}
+
+// onRoundComplete notifies the on_round_complete hooks and judges a finished
+// round in the background when the debate is scored by round. endDebate
+// waits for pending round judgements.
+func (dm *DebateManager) onRoundComplete(activeDebate *ActiveDebate, round int) {
+	notifyHooks(hookEvent(HookRoundComplete, activeDebate, round))
+	if !scoresRounds(activeDebate.Debate) {
+		return
+	}
+
+	activeDebate.mutex.RLock()
+	debateLog := make([]DebateLogEntry, len(activeDebate.DebateLog))
+	copy(debateLog, activeDebate.DebateLog)
+	activeDebate.mutex.RUnlock()
+
+	activeDebate.roundJudging.Add(1)
+	go func() {
+		defer activeDebate.roundJudging.Done()
+
+		result := dm.judgeRound(activeDebate, debateLog, round)
+		if err := dm.db.AddRoundResult(activeDebate.Debate.ID, result); err != nil {
+			log.Printf("Failed to store round %d result for debate %s: %v", round, activeDebate.Debate.ID, err)
+		}
+
+		activeDebate.mutex.Lock()
+		activeDebate.RoundResults = append(activeDebate.RoundResults, *result)
+		score := activeDebate.runningScore()
+		activeDebate.mutex.Unlock()
+
+		// Rounds may be judged out of order; the standing is stored under the
+		// round whose judgement produced it
+		standing := *score
+		standing.Round = round
+		if err := dm.db.AddRoundScore(activeDebate.Debate.ID, &standing); err != nil {
+			log.Printf("Failed to store round %d score for debate %s: %v", round, activeDebate.Debate.ID, err)
+		}
+
+		msg := createMessage("round_result", RoundResultMessage{
+			DebateID:    activeDebate.Debate.ID,
+			RoundResult: *result,
+			Scores:      score,
+		})
+		for _, bot := range activeDebate.Bots {
+			if bot.Conn != nil {
+				bot.Conn.WriteJSONWithin(msg, backgroundWriteTimeout)
+			}
+		}
+		dm.broadcast <- BroadcastMessage{DebateID: activeDebate.Debate.ID, Message: msg}
+
+		log.Printf("Debate %s round %d judged: %s (%d:%d)", activeDebate.Debate.ID, round,
+			result.Winner, result.SupportingScore, result.OpposingScore)
+	}()
+}
+
+// judgeRound asks the AI judge for a single round, scoring it a draw when the
+// judge is unavailable. A failing judgement is retried; a round that still
+// cannot be judged is left unscored rather than counted as a draw.
+func (dm *DebateManager) judgeRound(activeDebate *ActiveDebate, debateLog []DebateLogEntry, round int) *RoundResult {
+	if judgingFor(activeDebate.Debate) == JudgeModeAI {
+		backoff := roundJudgeBackoff
+		for attempt := 1; ; attempt++ {
+			result, err := chatgptClient.forDebate(activeDebate.Debate.ID, UsageJudge).JudgeRound(activeDebate.Debate.Topic, debateLog, round,
+				activeDebate.teamName("supporting"), activeDebate.teamName("opposing"))
+			if err == nil {
+				return result
+			}
+			log.Printf("Round judge failed for debate %s round %d (attempt %d of %d): %v",
+				activeDebate.Debate.ID, round, attempt, roundJudgeAttempts, err)
+			// The budget covers every attempt
+			if attempt == roundJudgeAttempts || errors.Is(err, errLLMBudget) {
+				break
+			}
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+		return &RoundResult{Round: round, Winner: RoundUnscored,
+			Comment: "AI 评委本轮评判失败，本轮不计分。"}
+	}
+	return &RoundResult{Round: round, Winner: "draw", SupportingScore: 50, OpposingScore: 50,
+		Comment: "AI 评委未启用，本轮记为平局。"}
+}
+
+// roundScoredResult decides a round-scored debate by the majority of rounds
+// won or, with cumulative scoring, by the points totalled over all rounds
+func (dm *DebateManager) roundScoredResult(activeDebate *ActiveDebate, reason string) *DebateResult {
+	activeDebate.roundJudging.Wait()
+
+	activeDebate.mutex.RLock()
+	rounds := make([]RoundResult, len(activeDebate.RoundResults))
+	copy(rounds, activeDebate.RoundResults)
+	activeDebate.mutex.RUnlock()
+	sort.Slice(rounds, func(i, j int) bool { return rounds[i].Round < rounds[j].Round })
+
+	supportingWins, opposingWins := 0, 0
+	supportingTotal, opposingTotal := 0, 0
+	var table strings.Builder
+	table.WriteString("| 轮次 | 胜方 | 正方得分 | 反方得分 |\n|---|---|---|---|\n")
+	scored := 0
+	for _, r := range rounds {
+		switch r.Winner {
+		case "supporting":
+			supportingWins++
+		case "opposing":
+			opposingWins++
+		}
+		if r.Winner != RoundUnscored {
+			scored++
+			supportingTotal += r.SupportingScore
+			opposingTotal += r.OpposingScore
+		}
+		table.WriteString(fmt.Sprintf("| 第%d轮 | %s | %d | %d |\n", r.Round, roundWinnerName(r.Winner), r.SupportingScore, r.OpposingScore))
+	}
+
+	cumulative := activeDebate.Debate.Scoring == ScoringCumulative
+	supportingLead, opposingLead := supportingWins, opposingWins
+	if cumulative {
+		supportingLead, opposingLead = supportingTotal, opposingTotal
+	}
+	winner := "draw"
+	if supportingLead > opposingLead {
+		winner = "supporting"
+	} else if opposingLead > supportingLead {
+		winner = "opposing"
+	}
+
+	// Overall scores are the averages of the scored rounds
+	supportingScore, opposingScore := 50, 50
+	if scored > 0 {
+		supportingScore = supportingTotal / scored
+		opposingScore = opposingTotal / scored
+	}
+
+	summary := fmt.Sprintf(`## 辩论总结（按轮计分）
+
+**辩题**: %s
+
+%s
+### 结果
+- 正方赢得 %d 轮，反方赢得 %d 轮
+- **获胜方**: %s`, activeDebate.Debate.Topic, table.String(), supportingWins, opposingWins, roundWinnerName(winner))
+	if cumulative {
+		summary = fmt.Sprintf(`## 辩论总结（累计计分）
+
+**辩题**: %s
+
+%s
+### 结果
+- 正方累计 %d 分，反方累计 %d 分
+- 正方赢得 %d 轮，反方赢得 %d 轮
+- **获胜方**: %s`, activeDebate.Debate.Topic, table.String(), supportingTotal, opposingTotal, supportingWins, opposingWins, roundWinnerName(winner))
+	}
+
+	result := &DebateResult{
+		Winner:          winner,
+		SupportingScore: supportingScore,
+		OpposingScore:   opposingScore,
+		Summary:         SpeechMessage{Format: "markdown", Content: summary},
+		Reason:          reason,
+		RoundResults:    rounds,
+		Source:          ResultSourceRounds,
+	}
+	if judgingFor(activeDebate.Debate) == JudgeModeAI && len(rounds) > 0 {
+		result.JudgeModel = chatgptClient.Model
+		result.ProducedBy = chatgptClient.Model
+		result.Rubric = snapshotRubric(RubricRounds, roundRubric)
+	}
+	return result
+}
+
+// roundWinnerName renders a winner value for summaries
+func roundWinnerName(winner string) string {
+	switch winner {
+	case "supporting":
+		return "正方"
+	case "opposing":
+		return "反方"
+	case RoundUnscored:
+		return "未计分"
+	}
+	return "平局"
+}
+
+// AddRoundScore stores the cumulative standing after a judged round
+func (d *Database) AddRoundScore(debateID string, score *RoundScore) error {
+	query := `INSERT OR REPLACE INTO round_scores (debate_id, round, rounds_scored, supporting_total, opposing_total)
+	          VALUES (?, ?, ?, ?, ?)`
+	_, err := d.db.Exec(query, debateID, score.Round, score.RoundsScored, score.SupportingTotal, score.OpposingTotal)
+	return err
+}
+
+// GetRoundScores returns the cumulative standings of a debate in round order
+func (d *Database) GetRoundScores(debateID string) ([]RoundScore, error) {
+	query := `SELECT round, rounds_scored, supporting_total, opposing_total
+	          FROM round_scores WHERE debate_id = ? ORDER BY round ASC`
+	rows, err := d.db.Query(query, debateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	scores := []RoundScore{}
+	for rows.Next() {
+		var score RoundScore
+		if err := rows.Scan(&score.Round, &score.RoundsScored, &score.SupportingTotal, &score.OpposingTotal); err != nil {
+			return nil, err
+		}
+		scores = append(scores, score)
+	}
+	return scores, rows.Err()
+}
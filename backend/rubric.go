@@ -0,0 +1,232 @@
+package main
+
+import (
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// The judge's rubric is a text/template rendered once at startup with the
+// weighted criteria of chatgpt.judge.rubric. Without a template file the
+// one built in from rubrics/default.tmpl is used, and without criteria the
+// built-in five. The judge scores each criterion in a breakdown, which is
+// stored with the result.
+
+// Rubric ids recorded with each AI verdict
+const (
+	RubricDefault = "default" // The configured judge rubric, see RubricConfig
+	RubricRounds  = "rounds"  // roundRubric, used per round in round scoring mode
+	RubricCustom  = "custom"  // Supplied by an admin rejudge job
+)
+
+// RubricCriterion is one criterion a rubric scores on
+type RubricCriterion struct {
+	ID          string `yaml:"id" json:"id"`
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description" json:"description"`
+	Weight      int    `yaml:"weight" json:"weight,omitempty"` // Points out of 100; 0 when the rubric does not weight its criteria
+}
+
+// CriterionScore is what each side scored on one criterion of the rubric
+type CriterionScore struct {
+	Criterion  string `json:"criterion"`      // RubricCriterion id
+	Name       string `json:"name,omitempty"` // Criterion name, for display
+	Supporting int    `json:"supporting"`
+	Opposing   int    `json:"opposing"`
+}
+
+// RubricConfig is the judge's rubric under chatgpt.judge.rubric
+type RubricConfig struct {
+	Template string            `yaml:"template"` // Path of a text/template judge prompt; empty for the built-in one
+	Criteria []RubricCriterion `yaml:"criteria"` // Weighted criteria, weights adding up to 100; empty for the built-in ones
+
+	prompt string // Rendered by resolveJudgeRubric
+}
+
+// defaultRubricTemplate is the judge prompt used unless a template file is configured
+//
+//go:embed rubrics/default.tmpl
+var defaultRubricTemplate string
+
+// rubricMaxScore is the most points a side can score under the judge rubric
+const rubricMaxScore = 100
+
+// rubricCriteria lists the criteria of each built-in rubric; keep them in
+// step with the prompts in rubrics/ and chatgpt.go
+var rubricCriteria = map[string][]RubricCriterion{
+	RubricDefault: {
+		{ID: "argument_quality", Name: "论点质量", Description: "论点是否清晰、有力、有逻辑性", Weight: 30},
+		{ID: "evidence", Name: "论据支持", Description: "是否提供充分的事实、数据、案例支持", Weight: 25},
+		{ID: "rebuttal", Name: "反驳能力", Description: "是否有效反驳对方观点", Weight: 20},
+		{ID: "delivery", Name: "表达能力", Description: "语言是否流畅、有说服力", Weight: 15},
+		{ID: "structure", Name: "整体逻辑", Description: "论证结构是否完整、严谨", Weight: 10},
+	},
+	RubricRounds: {
+		{ID: "argument_quality", Name: "论点质量", Description: "本轮论点是否清晰、有力"},
+		{ID: "evidence", Name: "论据支持", Description: "本轮论点是否有事实、数据、案例支持"},
+		{ID: "rebuttal", Name: "反驳", Description: "是否有效反驳对方观点"},
+		{ID: "delivery", Name: "表达与逻辑", Description: "表达是否流畅、论证是否严谨"},
+	},
+}
+
+// resolveJudgeRubric fills in the built-in criteria, checks them and
+// renders the judge prompt
+func resolveJudgeRubric(rubric *RubricConfig) error {
+	if len(rubric.Criteria) == 0 {
+		rubric.Criteria = rubricCriteria[RubricDefault]
+	}
+	seen := map[string]bool{}
+	total := 0
+	for i, c := range rubric.Criteria {
+		if c.ID == "" || c.Name == "" {
+			return fmt.Errorf("judge rubric criterion %d needs an id and a name", i+1)
+		}
+		if seen[c.ID] {
+			return fmt.Errorf("judge rubric criterion %q is listed twice", c.ID)
+		}
+		seen[c.ID] = true
+		if c.Weight <= 0 {
+			return fmt.Errorf("judge rubric criterion %q needs a positive weight", c.ID)
+		}
+		total += c.Weight
+	}
+	if total != rubricMaxScore {
+		return fmt.Errorf("judge rubric criterion weights add up to %d, not %d", total, rubricMaxScore)
+	}
+
+	text := defaultRubricTemplate
+	if rubric.Template != "" {
+		data, err := os.ReadFile(rubric.Template)
+		if err != nil {
+			return fmt.Errorf("failed to read judge rubric template: %w", err)
+		}
+		text = string(data)
+	}
+	tmpl, err := template.New("rubric").Funcs(template.FuncMap{
+		"inc": func(i int) int { return i + 1 },
+	}).Parse(text)
+	if err != nil {
+		return fmt.Errorf("invalid judge rubric template: %w", err)
+	}
+	var prompt strings.Builder
+	err = tmpl.Execute(&prompt, struct {
+		MaxScore int
+		Criteria []RubricCriterion
+	}{rubricMaxScore, rubric.Criteria})
+	if err != nil {
+		return fmt.Errorf("invalid judge rubric template: %w", err)
+	}
+	rubric.prompt = strings.TrimSpace(prompt.String())
+	return nil
+}
+
+// rubricPrompt returns the judge prompt of a rubric, reporting false for
+// rubrics that are not built in or configured
+func rubricPrompt(id string) (string, bool) {
+	switch id {
+	case RubricDefault:
+		return config.ChatGPT.Judge.Rubric.prompt, true
+	case RubricRounds:
+		return roundRubric, true
+	}
+	return "", false
+}
+
+// criteriaOf returns the criteria a rubric scores on
+func criteriaOf(id string) []RubricCriterion {
+	if id == RubricDefault {
+		return config.ChatGPT.Judge.Rubric.Criteria
+	}
+	return rubricCriteria[id]
+}
+
+// DisclosedRubric is the rubric a debate will be judged by, told to its
+// bots in debate_start when the debate discloses it
+type DisclosedRubric struct {
+	ID       string            `json:"id"`
+	Hash     string            `json:"hash"` // Matches the rubric hash recorded with the verdict
+	MaxScore int               `json:"max_score"`
+	Criteria []RubricCriterion `json:"criteria"`
+}
+
+// rubricDisclosure returns the rubric to disclose to a debate's bots; nil
+// unless the debate discloses it and is judged by the AI judge
+func rubricDisclosure(debate *Debate, rules *RulesCard) *DisclosedRubric {
+	if !debate.DiscloseRubric || rules.Judge.Mode != JudgeModeAI {
+		return nil
+	}
+	prompt, known := rubricPrompt(rules.Judge.Rubric)
+	if !known {
+		return nil
+	}
+	return &DisclosedRubric{
+		ID:       rules.Judge.Rubric,
+		Hash:     snapshotRubric(rules.Judge.Rubric, prompt).Hash,
+		MaxScore: rubricMaxScore,
+		Criteria: criteriaOf(rules.Judge.Rubric),
+	}
+}
+
+// RubricSnapshot identifies the exact judge prompt behind a verdict. The
+// content is stored once per hash so old results stay interpretable after
+// the rubric changes.
+type RubricSnapshot struct {
+	ID      string `json:"id"`
+	Hash    string `json:"hash"`
+	Content string `json:"content,omitempty"`
+}
+
+// snapshotRubric hashes a rubric's content
+func snapshotRubric(id, content string) *RubricSnapshot {
+	sum := sha256.Sum256([]byte(content))
+	return &RubricSnapshot{
+		ID:      id,
+		Hash:    hex.EncodeToString(sum[:]),
+		Content: content,
+	}
+}
+
+// matchBreakdown keeps the judge's scores on the rubric's own criteria, in
+// rubric order, named, and within each criterion's weight
+func matchBreakdown(breakdown []CriterionScore, criteria []RubricCriterion) []CriterionScore {
+	scores := map[string]CriterionScore{}
+	for _, score := range breakdown {
+		scores[score.Criterion] = score
+	}
+	var matched []CriterionScore
+	for _, c := range criteria {
+		score, ok := scores[c.ID]
+		if !ok || score.Supporting > c.Weight || score.Opposing > c.Weight {
+			continue
+		}
+		score.Name = c.Name
+		matched = append(matched, score)
+	}
+	return matched
+}
+
+// encodeBreakdown stores a result's criterion scores as JSON, "" for none
+func encodeBreakdown(breakdown []CriterionScore) string {
+	if len(breakdown) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(breakdown)
+	return string(data)
+}
+
+// decodeBreakdown reads criterion scores stored by encodeBreakdown
+func decodeBreakdown(data string) []CriterionScore {
+	if data == "" {
+		return nil
+	}
+	var breakdown []CriterionScore
+	if json.Unmarshal([]byte(data), &breakdown) != nil {
+		return nil
+	}
+	return breakdown
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+)
+
+// The rules card states the rules a debate runs under in one machine-readable
+// object. It is composed when the debate starts, from the debate's own
+// options and the configuration in force at that moment, stored with the
+// debate and sent in debate_start to bots and spectators, so a later config
+// change does not alter what the participants were told.
+
+// Debate phases, in the order they can occur
+const (
+	PhaseBlindOpening = "blind_opening" // Round 1 submitted before the start, revealed together
+	PhaseRounds       = "rounds"        // Regular speaking rounds
+	PhaseTiebreak     = "tiebreak"      // Extra round played when the judge's scores are close
+	PhaseClosing      = "closing"       // Pause between the final speech and judging
+	PhaseJudging      = "judging"
+)
+
+// Judging modes of a rules card
+const (
+	JudgeModeAI        = "ai"        // The LLM judge decides, falling back to scoring if it fails
+	JudgeModeHeuristic = "heuristic" // Decided by the fallback scoring: no LLM judge available, or the debate opted out
+)
+
+// RulesCard is the set of rules a debate runs under
+type RulesCard struct {
+	Format      string          `json:"format"`  // sequential or simultaneous
+	Scoring     string          `json:"scoring"` // holistic, rounds or cumulative
+	TotalRounds int             `json:"total_rounds"`
+	Seats       []string        `json:"seats,omitempty"` // Panel debates: side of each seat in speaking order
+	Phases      []RulesPhase    `json:"phases"`
+	Budgets     RulesBudgets    `json:"budgets"`
+	Moderation  RulesModeration `json:"moderation"`
+	Judge       RulesJudge      `json:"judge"`
+	Languages   []string        `json:"languages,omitempty"` // Bilingual debates: languages each speech is provided in
+	SideChannel bool            `json:"side_channel"`
+}
+
+// RulesPhase is one phase of a debate. Rounds is 0 for phases without
+// speeches and for a tiebreak that may not happen.
+type RulesPhase struct {
+	Name   string `json:"name"`
+	Rounds int    `json:"rounds,omitempty"`
+}
+
+// RulesBudgets are the time and length limits; seconds, 0 or less is no limit
+type RulesBudgets struct {
+	SpeechTimeout     int `json:"speech_timeout"`
+	InactivityTimeout int `json:"inactivity_timeout"`
+	MaxDuration       int `json:"max_duration"`
+	ReconnectGrace    int `json:"reconnect_grace"`
+	MinContentLength  int `json:"min_content_length"`
+	MaxContentLength  int `json:"max_content_length"`
+	SideSignals       int `json:"side_signals,omitempty"` // side_signal messages each bot may send
+}
+
+// RulesModeration is how speeches are policed
+type RulesModeration struct {
+	MaxViolations    int    `json:"max_violations"` // Consecutive rejected speeches that disqualify a bot, 0 or less never
+	Redaction        bool   `json:"redaction"`      // Public views mask listed words
+	RedactPII        bool   `json:"redact_pii"`     // Public views mask email addresses and phone numbers
+	MinClientVersion string `json:"min_client_version,omitempty"`
+}
+
+// RulesJudge is how the debate is decided
+type RulesJudge struct {
+	Mode      string       `json:"mode"`             // ai or heuristic
+	Rubric    string       `json:"rubric"`           // Rubric id recorded with the verdict
+	Disclosed bool         `json:"rubric_disclosed"` // The bots were told the rubric's criteria and weights
+	Panel     []string     `json:"panel,omitempty"`  // Judge persona ids whose verdicts are averaged
+	Feedback  bool         `json:"feedback"`         // Each bot receives private critique
+	Verdict   VerdictStyle `json:"verdict"`
+}
+
+// composeRules builds the rules card of a debate from its options and the
+// current configuration
+func composeRules(debate *Debate) *RulesCard {
+	d := config.Debate
+	limits := formatLimitsFor(debate)
+	rules := &RulesCard{
+		Format:      debate.Format,
+		Scoring:     debate.Scoring,
+		TotalRounds: debate.TotalRounds,
+		Seats:       debate.Seats,
+		Budgets: RulesBudgets{
+			SpeechTimeout:     limits.SpeechTimeout,
+			InactivityTimeout: d.InactivityTimeout,
+			MaxDuration:       d.MaxDuration,
+			ReconnectGrace:    d.ReconnectGrace,
+			MinContentLength:  limits.MinContentLength,
+			MaxContentLength:  limits.MaxContentLength,
+		},
+		Moderation: RulesModeration{
+			MaxViolations:    d.MaxViolations,
+			Redaction:        config.Redaction.Enabled,
+			RedactPII:        config.Redaction.Enabled && config.Redaction.PII,
+			MinClientVersion: d.MinClientVersion,
+		},
+		Judge: RulesJudge{
+			Mode:      judgingFor(debate),
+			Rubric:    RubricDefault,
+			Disclosed: debate.DiscloseRubric && judgingFor(debate) == JudgeModeAI,
+			Feedback:  config.ChatGPT.Judge.Feedback,
+			Verdict:   verdictStyleFor(debate),
+		},
+		Languages:   debate.Languages,
+		SideChannel: debate.SideChannel,
+	}
+	if debate.SideChannel {
+		rules.Budgets.SideSignals = d.SideChannel.MaxMessages
+	}
+
+	rounds := debate.TotalRounds
+	if debate.BlindOpening {
+		rules.Phases = append(rules.Phases, RulesPhase{Name: PhaseBlindOpening, Rounds: 1})
+		rounds--
+	}
+	if rounds > 0 {
+		rules.Phases = append(rules.Phases, RulesPhase{Name: PhaseRounds, Rounds: rounds})
+	}
+	if d.Tiebreak.Enabled {
+		rules.Phases = append(rules.Phases, RulesPhase{Name: PhaseTiebreak})
+	}
+	if d.ClosingGrace > 0 {
+		rules.Phases = append(rules.Phases, RulesPhase{Name: PhaseClosing})
+	}
+	rules.Phases = append(rules.Phases, RulesPhase{Name: PhaseJudging})
+
+	if rules.Judge.Mode == JudgeModeAI {
+		if scoresRounds(debate) {
+			rules.Judge.Rubric = RubricRounds
+		}
+		for _, persona := range config.ChatGPT.Judge.Panel {
+			rules.Judge.Panel = append(rules.Judge.Panel, persona.ID)
+		}
+	}
+	return rules
+}
+
+// rulesFor returns the stored rules card of a debate, composing and storing
+// it the first time
+func (dm *DebateManager) rulesFor(debate *Debate) *RulesCard {
+	if debate.Rules != nil {
+		return debate.Rules
+	}
+	debate.Rules = composeRules(debate)
+	if err := dm.db.SaveDebateRules(debate.ID, debate.Rules); err != nil {
+		log.Printf("Failed to store rules of debate %s: %v", debate.ID, err)
+	}
+	return debate.Rules
+}
+
+// encodeRules stores a rules card as JSON, "" for none
+func encodeRules(rules *RulesCard) string {
+	if rules == nil {
+		return ""
+	}
+	data, _ := json.Marshal(rules)
+	return string(data)
+}
+
+// decodeRules reads a rules card stored by encodeRules
+func decodeRules(data string) *RulesCard {
+	if data == "" {
+		return nil
+	}
+	var rules RulesCard
+	if json.Unmarshal([]byte(data), &rules) != nil {
+		return nil
+	}
+	return &rules
+}
+
+// SaveDebateRules stores the rules card of a debate
+func (d *Database) SaveDebateRules(debateID string, rules *RulesCard) error {
+	query := `UPDATE debates SET rules = ?, updated_at = ? WHERE id = ?`
+	_, err := d.db.Exec(query, encodeRules(rules), time.Now(), debateID)
+	return err
+}
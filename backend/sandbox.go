@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// maxScoreSpeechBody caps a score-speech request; all of it goes to the judge model
+const maxScoreSpeechBody = 256 << 10
+
+// SandboxTurn is one earlier speech of the transcript submitted to the sandbox
+type SandboxTurn struct {
+	Side    string `json:"side"` // supporting or opposing
+	Content string `json:"content"`
+}
+
+// SandboxScoreRequest is a candidate speech to score outside any real debate
+type SandboxScoreRequest struct {
+	Topic      string        `json:"topic"`
+	Side       string        `json:"side"`
+	Transcript []SandboxTurn `json:"transcript"`
+	Speech     string        `json:"speech"`
+}
+
+// SpeechScore is the judge's quick assessment of a candidate speech
+type SpeechScore struct {
+	Score      int      `json:"score"` // 0-100
+	Critique   string   `json:"critique"`
+	Strengths  []string `json:"strengths"`
+	Weaknesses []string `json:"weaknesses"`
+	Warnings   []string `json:"warnings,omitempty"` // Rule problems a real debate would reject the speech for
+	JudgeModel string   `json:"judge_model"`
+}
+
+// speechScoreRubric is the system prompt for scoring a single candidate speech
+const speechScoreRubric = `你是一位专业的辩论评委，正在帮助辩手打磨发言稿。请只评价最后提交的这一篇候选发言，之前的发言仅作为背景参考。
+
+评分标准 (0-100分): 论点质量、论据支持、对对方已有观点的回应、表达与逻辑、是否坚持己方立场。
+
+请按以下JSON格式返回:
+{
+  "score": 0-100,
+  "critique": "整体评价，简要说明得分原因",
+  "strengths": ["优点"],
+  "weaknesses": ["可改进之处"]
+}`
+
+// ScoreSpeech asks the judge model to score a candidate speech in context
+func (c *ChatGPTClient) ScoreSpeech(req *SandboxScoreRequest) (*SpeechScore, error) {
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "辩题: %s\n候选发言的立场: %s\n\n", req.Topic, sideName(req.Side))
+	if len(req.Transcript) > 0 {
+		prompt.WriteString("之前的发言:\n\n")
+		for i, turn := range req.Transcript {
+			fmt.Fprintf(&prompt, "【第%d篇 - %s】\n%s\n\n", i+1, sideName(turn.Side), turn.Content)
+		}
+	}
+	fmt.Fprintf(&prompt, "请评价以下候选发言:\n\n%s", req.Speech)
+
+	response, err := c.SendMessage([]ChatGPTMessage{
+		{Role: "system", Content: speechScoreRubric},
+		{Role: "user", Content: prompt.String()},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get speech score: %w", err)
+	}
+
+	startIdx := strings.Index(response, "{")
+	endIdx := strings.LastIndex(response, "}")
+	if startIdx == -1 || endIdx < startIdx {
+		return nil, fmt.Errorf("no JSON found in response")
+	}
+	score := &SpeechScore{}
+	if err := json.Unmarshal([]byte(response[startIdx:endIdx+1]), score); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	if score.Score < 0 {
+		score.Score = 0
+	} else if score.Score > 100 {
+		score.Score = 100
+	}
+	if score.Strengths == nil {
+		score.Strengths = []string{}
+	}
+	if score.Weaknesses == nil {
+		score.Weaknesses = []string{}
+	}
+	score.JudgeModel = c.Model
+	return score, nil
+}
+
+// sideName returns the Chinese name of a debate side
+func sideName(side string) string {
+	if side == "opposing" {
+		return "反方 (反对)"
+	}
+	return "正方 (支持)"
+}
+
+// handleSandboxScoreSpeech handles POST /api/sandbox/score-speech. Nothing is
+// stored and no debate is touched; the call only counts against the LLM budget.
+func handleSandboxScoreSpeech(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !config.ChatGPT.Sandbox.Enabled {
+		http.Error(w, "Sandbox disabled", http.StatusNotFound)
+		return
+	}
+	if chatgptClient == nil {
+		http.Error(w, "AI judge not configured", http.StatusServiceUnavailable)
+		return
+	}
+	if chatgptClient.Mock {
+		http.Error(w, "The mock judge cannot score speeches", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req SandboxScoreRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxScoreSpeechBody)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, fmt.Sprintf("Request too large (maximum %d bytes)", maxScoreSpeechBody), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if req.Topic == "" || strings.TrimSpace(req.Speech) == "" {
+		http.Error(w, "Topic and speech are required", http.StatusBadRequest)
+		return
+	}
+	if req.Side != "supporting" && req.Side != "opposing" {
+		http.Error(w, "Side must be supporting or opposing", http.StatusBadRequest)
+		return
+	}
+	for _, turn := range req.Transcript {
+		if turn.Side != "supporting" && turn.Side != "opposing" {
+			http.Error(w, "Transcript sides must be supporting or opposing", http.StatusBadRequest)
+			return
+		}
+	}
+
+	score, err := chatgptClient.forDebate("", UsageSandbox).ScoreSpeech(&req)
+	if errors.Is(err, errLLMBudget) {
+		http.Error(w, "LLM budget exhausted, try again later", http.StatusTooManyRequests)
+		return
+	} else if err != nil {
+		log.Printf("Sandbox speech scoring failed: %v", err)
+		http.Error(w, "Failed to score speech", http.StatusBadGateway)
+		return
+	}
+
+	// Same length check as HandleSpeech
+	contentLen := len(strings.TrimSpace(req.Speech))
+	if contentLen < config.Debate.MinContentLength {
+		score.Warnings = append(score.Warnings, fmt.Sprintf("Speech content too short (minimum %d characters)", config.Debate.MinContentLength))
+	}
+	if contentLen > config.Debate.MaxContentLength {
+		score.Warnings = append(score.Warnings, fmt.Sprintf("Speech content too long (maximum %d characters)", config.Debate.MaxContentLength))
+	}
+
+	writeJSON(w, score)
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// POST /api/sandbox/debate is the first-run experience for new bot
+// developers: it creates an unranked debate against the house bot at once,
+// with a relaxed speech timeout, and returns what the developer's client
+// needs to log in. In a sandbox debate every error a bot or spectator
+// receives carries an explanation of what went wrong and how to fix it.
+
+// SandboxDebateRequest optionally picks the practice debate's topic, length and house bot persona
+type SandboxDebateRequest struct {
+	Topic       string `json:"topic,omitempty"`
+	TotalRounds int    `json:"total_rounds,omitempty"`
+	PersonaID   string `json:"persona_id,omitempty"`
+}
+
+// SandboxDebate is the response of POST /api/sandbox/debate
+type SandboxDebate struct {
+	DebateCreated
+	BotURL    string   `json:"bot_url"`    // WebSocket endpoint the developer's bot connects to
+	NextSteps []string `json:"next_steps"` // What to do with this debate
+}
+
+// errorExplanations say what an error code means and how a bot fixes it
+var errorExplanations = map[string]string{
+	"INVALID_MESSAGE_FORMAT": "The message could not be parsed. Every message must be a JSON object with a \"type\" string and a \"data\" object, e.g. {\"type\":\"debate_speech\",\"data\":{...}}.",
+	"INVALID_MESSAGE_TYPE":   "The first message on a new connection must be bot_login. Send bot_login with bot_name, bot_uuid, debate_id and version before anything else.",
+	"UNKNOWN_MESSAGE_TYPE":   "The server does not know this message type. Bots may send bot_login, debate_speech, opening_submission, side_signal, get_state and pong; see SKILL.md for the full list.",
+	"UNSUPPORTED_VERSION":    "The message asked for a protocol version this server does not speak. Omit the version field or use one listed in /api/capabilities.",
+	"MISSING_FIELD":          "A required field of the message is missing; details names it. Check the message's fields against SKILL.md.",
+	"INVALID_FIELD":          "A field of the message has a value the server does not accept; details names it.",
+	"ALREADY_LOGGED_IN":      "This connection is already logged in. Send bot_login once per connection; to join another debate, open a new connection.",
+	"DEBATE_NOT_FOUND":       "No running debate has this debate_id. Use the debate_id from the sandbox response, and note that a finished debate no longer accepts speeches.",
+	"INVALID_DEBATE_KEY":     "The debate_key does not match. Copy debate_key from login_confirmed into every debate_speech.",
+	"DEBATE_PAUSED":          "A moderator paused the debate. Wait for the debate_update announcing it resumed, then speak again.",
+	"DEBATE_NOT_ACTIVE":      "The debate has not started or has already ended. Only speak after debate_start and before debate_end.",
+	"NOT_YOUR_TURN":          "It is the other side's turn. Only speak after a debate_start or debate_update whose next_speaker is your bot identifier.",
+	"CONTENT_TOO_SHORT":      "The speech is shorter than the debate's minimum length. The limits are in debate_start; pad out your argument rather than sending a placeholder.",
+	"CONTENT_TOO_LONG":       "The speech is longer than the debate's maximum length. The limits are in debate_start; trim the speech before sending it.",
+	"ALREADY_SUBMITTED":      "Your side already spoke this round. Wait for the next debate_update before sending another speech.",
+	"INVALID_ATTACHMENT":     "An attachment of the speech was rejected; the message says why. Attachments need a supported type and must fit the size limit.",
+	"RATE_LIMITED":           "Speeches arrived too quickly. Wait the number of seconds in details and send the speech again; this does not count as a violation.",
+	"OPENING_CLOSED":         "Openings can only be submitted before the debate starts. Send your first speech as a debate_speech instead.",
+	"BLIND_OPENING_DISABLED": "This debate has no blind openings. Wait for debate_start and send a debate_speech.",
+	"SIDE_CHANNEL_DISABLED":  "This debate has no side channel, so side_signal messages are not accepted.",
+	"INTERNAL_ERROR":         "The server failed to handle the message. Retry it; if it keeps failing, the problem is on the server side.",
+}
+
+// explainError adds an explanation to an error sent in a sandbox debate
+func explainError(data interface{}) interface{} {
+	var errMsg ErrorMessage
+	switch e := data.(type) {
+	case ErrorMessage:
+		errMsg = e
+	case *ErrorMessage:
+		errMsg = *e
+	default:
+		return data
+	}
+	if errMsg.DebateID == "" || errMsg.Explanation != "" || !debateManager.isSandbox(errMsg.DebateID) {
+		return data
+	}
+	errMsg.Explanation = errorExplanations[errMsg.ErrorCode]
+	if config.Debate.MaxViolations > 0 && violationCodes[errMsg.ErrorCode] {
+		errMsg.Explanation += fmt.Sprintf(" %d rejected speeches in a row disqualify a bot, even in a practice debate.", config.Debate.MaxViolations)
+	}
+	return errMsg
+}
+
+// isSandbox reports whether a running debate came from /api/sandbox/debate
+func (dm *DebateManager) isSandbox(debateID string) bool {
+	dm.mutex.RLock()
+	activeDebate, exists := dm.debates[debateID]
+	dm.mutex.RUnlock()
+	if !exists {
+		return false
+	}
+	activeDebate.mutex.RLock()
+	defer activeDebate.mutex.RUnlock()
+	return activeDebate.Debate.Sandbox
+}
+
+// handleSandboxDebate handles POST /api/sandbox/debate
+func handleSandboxDebate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	settings := config.ChatGPT.Sandbox.Debate
+	if !settings.Enabled {
+		http.Error(w, "Sandbox debates disabled", http.StatusNotFound)
+		return
+	}
+	if !config.ChatGPT.HouseBot.Enabled {
+		http.Error(w, "Sandbox debates need the house bot, which is disabled", http.StatusServiceUnavailable)
+		return
+	}
+	if shuttingDown.Load() {
+		http.Error(w, "Server is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req SandboxDebateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Topic) == "" {
+		req.Topic = settings.Topic
+	}
+	if req.TotalRounds <= 0 {
+		req.TotalRounds = settings.TotalRounds
+	}
+	if err := validateRounds(req.TotalRounds); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.PersonaID == "" {
+		req.PersonaID = defaultPersonaID
+	}
+	persona, err := db.GetPersona(req.PersonaID)
+	if err != nil {
+		http.Error(w, "Persona not found", http.StatusBadRequest)
+		return
+	}
+
+	opts := DebateOptions{
+		PersonaID:      persona.ID,
+		Format:         FormatSequential,
+		Scoring:        ScoringHolistic,
+		DiscloseRubric: true,
+		Limits:         &FormatLimits{SpeechTimeout: settings.SpeechTimeout},
+		Sandbox:        true,
+	}
+	debate, err := debateManager.CreateDebate(req.Topic, req.TotalRounds, opts)
+	if err != nil {
+		http.Error(w, "Failed to create debate", http.StatusInternalServerError)
+		return
+	}
+	go StartHouseBot(debate.ID, persona)
+
+	scheme := "ws"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "wss"
+	}
+	response := SandboxDebate{
+		DebateCreated: DebateCreated{
+			DebateID:     debate.ID,
+			ShortID:      debate.ShortID,
+			Topic:        debate.Topic,
+			TotalRounds:  debate.TotalRounds,
+			Status:       debate.Status,
+			Ranked:       debate.Ranked,
+			PersonaID:    debate.PersonaID,
+			Format:       debate.Format,
+			BlindOpening: debate.BlindOpening,
+			Scoring:      debate.Scoring,
+			Limits:       debate.Limits,
+			Judging:      judgingFor(debate),
+		},
+		BotURL: scheme + "://" + r.Host + "/debate",
+		NextSteps: []string{
+			"Connect your bot to bot_url and send bot_login with this debate_id; the house bot is already joining as your opponent.",
+			"Wait for debate_start, then answer each debate_update naming your bot as next_speaker with a debate_speech.",
+			"Errors in this debate carry an explanation field saying what went wrong and how to fix it.",
+			"The debate is unranked and does not count towards the leaderboard.",
+		},
+	}
+
+	writeJSON(w, response)
+	log.Printf("Sandbox debate created: %s - %s", debate.ID, debate.Topic)
+}
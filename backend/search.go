@@ -0,0 +1,253 @@
+package main
+
+import (
+	"database/sql"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Transcripts are searchable through debate_log_fts, an FTS4 index holding the
+// decoded text of every speech. Compressed bodies can only be decoded in Go,
+// so speeches are indexed as they are saved, and at startup any speech
+// missing from the index is added; a trigger drops deleted speeches. The
+// index tokenizes on spaces and punctuation, which does not split Chinese or
+// Japanese text into words, so queries in those scripts fall back to a
+// substring scan of the indexed text. Private debates never appear in
+// results.
+
+// Search result bounds
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 200
+	snippetRunes       = 40 // Context kept on each side of a substring match
+)
+
+// Markers snippet() puts around matches; replaced by <mark> once the
+// highlight is escaped
+const (
+	markOpen  = "\x02"
+	markClose = "\x03"
+)
+
+// SpeechMatch is a speech matching a search, with the matched terms marked
+type SpeechMatch struct {
+	Round     int    `json:"round"`
+	Side      string `json:"side"`
+	Speaker   string `json:"speaker"`
+	Highlight string `json:"highlight"` // HTML-escaped excerpt, matches wrapped in <mark>
+}
+
+// DebateMatch is a debate with the speeches matching a search
+type DebateMatch struct {
+	DebateID  string        `json:"debate_id"`
+	Topic     string        `json:"topic"`
+	Status    string        `json:"status"`
+	CreatedAt time.Time     `json:"created_at"`
+	Matches   []SpeechMatch `json:"matches"`
+}
+
+// SearchResults is the response of GET /api/search
+type SearchResults struct {
+	Query   string        `json:"query"`
+	Total   int           `json:"total"` // Matched speeches, up to limit
+	Debates []DebateMatch `json:"debates"`
+}
+
+// handleSearch handles GET /api/search?q=&limit=
+func handleSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		http.Error(w, "q is required", http.StatusBadRequest)
+		return
+	}
+	limit := defaultSearchLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxSearchLimit {
+			http.Error(w, "limit must be between 1 and 200", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	results, err := db.SearchTranscripts(query, limit)
+	if err != nil {
+		http.Error(w, "Failed to search transcripts", http.StatusInternalServerError)
+		return
+	}
+	for i := range results.Debates {
+		results.Debates[i].Topic = redactor.Redact(results.Debates[i].Topic)
+		for j := range results.Debates[i].Matches {
+			match := &results.Debates[i].Matches[j]
+			match.Highlight = markHighlight(redactor.Redact(match.Highlight))
+		}
+	}
+	writeJSON(w, results)
+}
+
+// markHighlight escapes an excerpt and turns the match markers into <mark> tags
+func markHighlight(excerpt string) string {
+	excerpt = html.EscapeString(excerpt)
+	excerpt = strings.ReplaceAll(excerpt, markOpen, "<mark>")
+	return strings.ReplaceAll(excerpt, markClose, "</mark>")
+}
+
+// needsSubstringSearch reports whether a query contains a script the index
+// cannot split into words
+func needsSubstringSearch(query string) bool {
+	for _, r := range query {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+			return true
+		}
+	}
+	return false
+}
+
+// ftsQuery quotes each term of a query, so punctuation and operators in user
+// input match literally and every term must appear
+func ftsQuery(query string) string {
+	terms := strings.Fields(query)
+	for i, term := range terms {
+		terms[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+	}
+	return strings.Join(terms, " ")
+}
+
+// substringSnippet cuts an excerpt around the first occurrence of term and
+// marks every occurrence in it
+func substringSnippet(content, term string) string {
+	start := strings.Index(content, term)
+	if start < 0 {
+		return ""
+	}
+	from, to := start, start+len(term)
+	for i := 0; i < snippetRunes && from > 0; i++ {
+		_, size := utf8.DecodeLastRuneInString(content[:from])
+		from -= size
+	}
+	for i := 0; i < snippetRunes && to < len(content); i++ {
+		_, size := utf8.DecodeRuneInString(content[to:])
+		to += size
+	}
+
+	excerpt := strings.ReplaceAll(content[from:to], term, markOpen+term+markClose)
+	if from > 0 {
+		excerpt = "…" + excerpt
+	}
+	if to < len(content) {
+		excerpt += "…"
+	}
+	return excerpt
+}
+
+// SearchTranscripts finds the speeches of public debates matching a query,
+// newest debates first, grouped by debate
+func (d *Database) SearchTranscripts(query string, limit int) (*SearchResults, error) {
+	var rows *sql.Rows
+	var err error
+	substring := needsSubstringSearch(query)
+	if substring {
+		pattern := "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(query) + "%"
+		rows, err = d.db.Query(`
+			SELECT l.debate_id, d.topic, d.status, d.created_at, l.round, l.side, l.speaker, f.message_content
+			FROM debate_log_fts f
+			JOIN debate_log l ON l.id = f.docid
+			JOIN debates d ON d.id = l.debate_id
+			WHERE f.message_content LIKE ? ESCAPE '\' AND d.private = 0
+			ORDER BY d.created_at DESC, l.id LIMIT ?`, pattern, limit)
+	} else {
+		rows, err = d.db.Query(`
+			SELECT l.debate_id, d.topic, d.status, d.created_at, l.round, l.side, l.speaker,
+			       snippet(debate_log_fts, ?, ?, '…', -1, 24)
+			FROM debate_log_fts f
+			JOIN debate_log l ON l.id = f.docid
+			JOIN debates d ON d.id = l.debate_id
+			WHERE debate_log_fts MATCH ? AND d.private = 0
+			ORDER BY d.created_at DESC, l.id LIMIT ?`, markOpen, markClose, ftsQuery(query), limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := &SearchResults{Query: query, Debates: []DebateMatch{}}
+	index := map[string]int{}
+	for rows.Next() {
+		var debate DebateMatch
+		var match SpeechMatch
+		if err := rows.Scan(&debate.DebateID, &debate.Topic, &debate.Status, &debate.CreatedAt,
+			&match.Round, &match.Side, &match.Speaker, &match.Highlight); err != nil {
+			return nil, err
+		}
+		if substring {
+			match.Highlight = substringSnippet(match.Highlight, query)
+		}
+
+		i, seen := index[debate.DebateID]
+		if !seen {
+			i = len(results.Debates)
+			index[debate.DebateID] = i
+			results.Debates = append(results.Debates, debate)
+		}
+		results.Debates[i].Matches = append(results.Debates[i].Matches, match)
+		results.Total++
+	}
+	return results, rows.Err()
+}
+
+// IndexMissingSpeeches adds the decoded text of speeches missing from the
+// search index, such as compressed ones saved before it held decoded text,
+// and returns how many were added
+func (d *Database) IndexMissingSpeeches() (int, error) {
+	rows, err := d.db.Query(`
+		SELECT id, message_content, message_encoding FROM debate_log
+		WHERE id NOT IN (SELECT docid FROM debate_log_fts)`)
+	if err != nil {
+		return 0, err
+	}
+	type missing struct {
+		id      int64
+		content string
+	}
+	var todo []missing
+	for rows.Next() {
+		var m missing
+		var stored []byte
+		var encoding string
+		if err := rows.Scan(&m.id, &stored, &encoding); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if m.content, err = decodeBody(stored, encoding); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		todo = append(todo, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	for _, m := range todo {
+		if _, err := tx.Exec(`INSERT INTO debate_log_fts(docid, message_content) VALUES (?, ?)`, m.id, m.content); err != nil {
+			return 0, err
+		}
+	}
+	return len(todo), tx.Commit()
+}
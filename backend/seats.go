@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// A debate has one seat per participating bot. Each seat belongs to the
+// supporting or opposing side and the seat order is the speaking order
+// within a round. Classic debates have the two seats of defaultSeats; panel
+// debates list theirs in Debate.Seats, so several bots can argue the same
+// side as a team.
+
+// maxSeats bounds the number of bots in one debate
+const maxSeats = 8
+
+// defaultSeats are the seats of a classic one-on-one debate
+var defaultSeats = []string{"supporting", "opposing"}
+
+// Participant is a seated bot as announced to bots in panel debates
+type Participant struct {
+	Identifier string `json:"identifier"`
+	Side       string `json:"side"`
+}
+
+// parseSeats turns the seats or participants of a create request into the
+// seats to store. Classic one-on-one debates store none.
+func parseSeats(seats []string, participants int) ([]string, error) {
+	if len(seats) == 0 {
+		if participants == 0 || participants == len(defaultSeats) {
+			return nil, nil
+		}
+		if participants < len(defaultSeats) || participants > maxSeats {
+			return nil, fmt.Errorf("participants must be between %d and %d", len(defaultSeats), maxSeats)
+		}
+		// Sides alternate, starting with the supporting side
+		for i := 0; i < participants; i++ {
+			seats = append(seats, defaultSeats[i%2])
+		}
+		return seats, nil
+	}
+
+	if len(seats) > maxSeats {
+		return nil, fmt.Errorf("a debate has at most %d seats", maxSeats)
+	}
+	count := map[string]int{}
+	for _, side := range seats {
+		if side != "supporting" && side != "opposing" {
+			return nil, fmt.Errorf("seat side %q must be supporting or opposing", side)
+		}
+		count[side]++
+	}
+	if count["supporting"] == 0 || count["opposing"] == 0 {
+		return nil, fmt.Errorf("seats need at least one supporting and one opposing bot")
+	}
+	if strings.Join(seats, ",") == strings.Join(defaultSeats, ",") {
+		return nil, nil
+	}
+	return seats, nil
+}
+
+// seatSides returns the side of each seat in speaking order
+func (d *Debate) seatSides() []string {
+	if len(d.Seats) > 0 {
+		return d.Seats
+	}
+	return defaultSeats
+}
+
+// isPanel reports whether the debate has more than the classic two seats
+func (d *Debate) isPanel() bool {
+	return len(d.Seats) > 0
+}
+
+// assignSeats seats the joined bots in random order, sets their sides and
+// picks the first bot of each side as its SupportingBot/OpposingBot.
+// Blind-opening bots already got their side at login and keep it.
+func (dm *DebateManager) assignSeats(activeDebate *ActiveDebate) {
+	bots := activeDebate.Bots
+	if activeDebate.Debate.BlindOpening {
+		activeDebate.Bots = []*ConnectedBot{activeDebate.SupportingBot, activeDebate.OpposingBot}
+		return
+	}
+
+	rand.Shuffle(len(bots), func(i, j int) { bots[i], bots[j] = bots[j], bots[i] })
+	activeDebate.SupportingBot = nil
+	activeDebate.OpposingBot = nil
+	for i, side := range activeDebate.Debate.seatSides() {
+		bot := bots[i]
+		bot.Bot.Side = side
+		dm.db.UpdateBotSide(activeDebate.Debate.ID, bot.Bot.BotIdentifier, side)
+		if side == "supporting" && activeDebate.SupportingBot == nil {
+			activeDebate.SupportingBot = bot
+		}
+		if side == "opposing" && activeDebate.OpposingBot == nil {
+			activeDebate.OpposingBot = bot
+		}
+	}
+}
+
+// findBot returns the joined bot with the given identifier, if any
+func (a *ActiveDebate) findBot(identifier string) *ConnectedBot {
+	for _, bot := range a.Bots {
+		if bot.Bot.BotIdentifier == identifier {
+			return bot
+		}
+	}
+	return nil
+}
+
+// seatAfter returns the identifier of the bot speaking after the given one
+// and whether the round is complete once the given bot has spoken
+func (a *ActiveDebate) seatAfter(identifier string) (string, bool) {
+	for i, bot := range a.Bots {
+		if bot.Bot.BotIdentifier == identifier {
+			next := (i + 1) % len(a.Bots)
+			return a.Bots[next].Bot.BotIdentifier, next == 0
+		}
+	}
+	return a.Bots[0].Bot.BotIdentifier, false
+}
+
+// teamName names the bots on one side, joining teammates with " / "
+func (a *ActiveDebate) teamName(side string) string {
+	names := []string{}
+	for _, bot := range a.Bots {
+		if bot.Bot.Side == side {
+			names = append(names, bot.Bot.BotIdentifier)
+		}
+	}
+	return strings.Join(names, " / ")
+}
+
+// participants lists the seated bots of a panel debate, nil for classic debates
+func (a *ActiveDebate) participants() []Participant {
+	if !a.Debate.isPanel() {
+		return nil
+	}
+	list := make([]Participant, 0, len(a.Bots))
+	for _, bot := range a.Bots {
+		list = append(list, Participant{Identifier: bot.Bot.BotIdentifier, Side: bot.Bot.Side})
+	}
+	return list
+}
+
+// joinedIdentifiers lists the identifiers of the joined bots except the given one
+func (a *ActiveDebate) joinedIdentifiers(except string) []string {
+	identifiers := []string{}
+	for _, bot := range a.Bots {
+		if bot.Bot.BotIdentifier != except {
+			identifiers = append(identifiers, bot.Bot.BotIdentifier)
+		}
+	}
+	return identifiers
+}
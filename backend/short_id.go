@@ -0,0 +1,68 @@
+package main
+
+import (
+	"crypto/rand"
+	"strings"
+)
+
+// Debates get a short ID besides their full "debate-<uuid>" ID: eight
+// characters of Crockford base32, easy to read out and type into a bot
+// config. Short IDs are accepted wherever a debate_id is and matched case
+// insensitively, with the letters commonly mistaken for digits (O, I, L)
+// read as those digits and hyphens ignored.
+
+// shortIDAlphabet is Crockford's base32 alphabet, without I, L, O and U
+const shortIDAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// shortIDLength is the number of characters in a short ID (40 bits)
+const shortIDLength = 8
+
+// newShortID returns a random short debate ID
+func newShortID() string {
+	buf := make([]byte, shortIDLength)
+	rand.Read(buf)
+	for i, b := range buf {
+		buf[i] = shortIDAlphabet[b%32]
+	}
+	return string(buf)
+}
+
+// normalizeShortID canonicalizes a typed short ID, or returns "" if the input
+// cannot be one
+func normalizeShortID(id string) string {
+	id = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(id), "-", ""))
+	id = strings.NewReplacer("O", "0", "I", "1", "L", "1").Replace(id)
+	if len(id) != shortIDLength {
+		return ""
+	}
+	for _, c := range id {
+		if !strings.ContainsRune(shortIDAlphabet, c) {
+			return ""
+		}
+	}
+	return id
+}
+
+// resolveDebateID returns the full ID of the debate a client referred to by
+// either its full or short ID. Unknown IDs are returned unchanged, so callers
+// report them as they always have.
+func resolveDebateID(id string) string {
+	if id == "" || strings.HasPrefix(id, "debate-") {
+		return id
+	}
+	shortID := normalizeShortID(id)
+	if shortID == "" {
+		return id
+	}
+	if debateID, err := db.GetDebateIDByShortID(shortID); err == nil {
+		return debateID
+	}
+	return id
+}
+
+// GetDebateIDByShortID looks up the full ID of a debate by its short ID
+func (d *Database) GetDebateIDByShortID(shortID string) (string, error) {
+	var debateID string
+	err := d.db.QueryRow(`SELECT id FROM debates WHERE short_id = ?`, shortID).Scan(&debateID)
+	return debateID, err
+}
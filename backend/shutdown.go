@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// On SIGTERM or SIGINT the server drains instead of dropping connections
+// mid-round: /readyz reports draining, new logins and debate creation are
+// refused, and every bot and spectator receives server_shutdown. Running
+// debates get server.shutdown_grace seconds to finish and be judged; those
+// still running after that are stored as interrupted at their current round
+// before the connections and the database are closed, or, with
+// server.snapshot_on_shutdown, snapshotted so that another instance can
+// continue them (see snapshot.go).
+
+// StatusInterrupted marks a debate that was still running when the server shut down
+const StatusInterrupted = "interrupted"
+
+// Shutdown phases
+const (
+	ShutdownDraining = "draining" // Running debates may still finish
+	ShutdownClosing  = "closing"  // Connections are about to close
+)
+
+// shuttingDown is set once the server starts draining
+var shuttingDown atomic.Bool
+
+// ServerShutdown tells bots and spectators that the server is going away
+type ServerShutdown struct {
+	DebateID    string `json:"debate_id,omitempty"`
+	Phase       string `json:"phase"`
+	Deadline    string `json:"deadline,omitempty"`    // Running debates are interrupted if not finished by then
+	Interrupted bool   `json:"interrupted,omitempty"` // The debate was cut off and stored at its current round
+	Snapshot    bool   `json:"snapshot,omitempty"`    // The debate was saved and continues once its bots log in again
+	Message     string `json:"message"`
+}
+
+// shutdownRejection is the login rejection sent while the server drains
+func shutdownRejection(debateID string) *LoginRejected {
+	return &LoginRejected{
+		Status:     "rejected",
+		Reason:     "server_shutdown",
+		Message:    "The server is shutting down and accepts no new logins",
+		DebateID:   debateID,
+		RetryAfter: 30,
+	}
+}
+
+// waitForShutdown blocks until the HTTP server fails or a termination
+// signal arrives, in which case it drains the server
+func waitForShutdown(server *http.Server, serverErr <-chan error) {
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGTERM, os.Interrupt)
+
+	select {
+	case err := <-serverErr:
+		log.Fatalf("Server failed to start: %v", err)
+	case sig := <-stop:
+		log.Printf("Received %v, draining before shutdown", sig)
+		drainAndShutdown(server)
+	}
+}
+
+// drainAndShutdown stops new work, waits for running debates up to the
+// shutdown grace, stores the rest as interrupted and closes every connection
+func drainAndShutdown(server *http.Server) {
+	shuttingDown.Store(true)
+
+	grace := time.Duration(config.Server.ShutdownGrace) * time.Second
+	if grace < 0 {
+		grace = 0
+	}
+	deadline := time.Now().Add(grace)
+	debateManager.announceShutdown(ServerShutdown{
+		Phase:    ShutdownDraining,
+		Deadline: deadline.Format(time.RFC3339),
+		Message:  "The server is shutting down; running debates may finish until the deadline",
+	})
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	for debateManager.runningDebates() > 0 && time.Now().Before(deadline) {
+		<-ticker.C
+	}
+	ticker.Stop()
+
+	if config.Server.SnapshotOnShutdown {
+		if saved := debateManager.snapshotRunning(); saved > 0 {
+			log.Printf("Snapshotted %d running debates for another instance to continue", saved)
+		}
+	}
+	if interrupted := debateManager.interruptRunning(); interrupted > 0 {
+		log.Printf("Interrupted %d debates still running at shutdown", interrupted)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("HTTP server shutdown: %v", err)
+	}
+	debateManager.closeConnections()
+	log.Printf("Server stopped")
+}
+
+// announceShutdown sends server_shutdown to the bots and spectators of every debate
+func (dm *DebateManager) announceShutdown(notice ServerShutdown) {
+	dm.mutex.RLock()
+	defer dm.mutex.RUnlock()
+	for _, activeDebate := range dm.debates {
+		notice.DebateID = activeDebate.Debate.ID
+		dm.notifyDebate(activeDebate, createMessage("server_shutdown", notice))
+	}
+	adminStream.Publish(createMessage("server_shutdown", ServerShutdown{Phase: notice.Phase, Deadline: notice.Deadline, Message: notice.Message}))
+}
+
+// isRunning reports whether a debate has started and not yet stored its result
+func isRunning(status string) bool {
+	return isInProgress(status) || status == StatusClosing
+}
+
+// runningDebates counts the debates that have started and are not finished,
+// including those being judged
+func (dm *DebateManager) runningDebates() int {
+	dm.mutex.RLock()
+	defer dm.mutex.RUnlock()
+	running := 0
+	for _, activeDebate := range dm.debates {
+		if isRunning(activeDebate.Debate.Status) {
+			running++
+		}
+	}
+	return running
+}
+
+// interruptRunning stops the clocks of every debate still running and
+// stores it as interrupted at its current round, returning how many there were
+func (dm *DebateManager) interruptRunning() int {
+	dm.mutex.RLock()
+	defer dm.mutex.RUnlock()
+	interrupted := 0
+	for debateID, activeDebate := range dm.debates {
+		if !isRunning(activeDebate.Debate.Status) {
+			continue
+		}
+		for _, timer := range []*time.Timer{activeDebate.TimeoutTimer, activeDebate.InactivityTimer, activeDebate.MaxDurationTimer} {
+			if timer != nil {
+				timer.Stop()
+			}
+		}
+		stopCountdown(activeDebate)
+
+		round := activeDebate.Debate.CurrentRound
+		if err := dm.db.InterruptDebate(debateID, round); err != nil {
+			log.Printf("Failed to store interrupted debate %s: %v", debateID, err)
+		}
+		activeDebate.Debate.Status = StatusInterrupted
+		dm.notifyDebate(activeDebate, createMessage("server_shutdown", ServerShutdown{
+			DebateID:    debateID,
+			Phase:       ShutdownClosing,
+			Interrupted: true,
+			Message:     fmt.Sprintf("The debate was interrupted by a server shutdown in round %d", round),
+		}))
+		interrupted++
+	}
+	return interrupted
+}
+
+// snapshotRunning snapshots every debate in progress and returns how many
+// were saved. Debates in their closing grace or being judged cannot be
+// continued elsewhere and are left to interruptRunning.
+func (dm *DebateManager) snapshotRunning() int {
+	dm.mutex.Lock()
+	defer dm.mutex.Unlock()
+	saved := 0
+	for debateID, activeDebate := range dm.debates {
+		if !isInProgress(activeDebate.Debate.Status) || activeDebate.judging != nil {
+			continue
+		}
+		snap := dm.snapshotDebate(activeDebate)
+		if err := dm.db.SaveDebateSnapshot(snap, cluster.InstanceID); err != nil {
+			log.Printf("Failed to snapshot debate %s: %v", debateID, err)
+			continue
+		}
+		activeDebate.Debate.Status = StatusMigrated
+		dm.notifyDebate(activeDebate, createMessage("server_shutdown", ServerShutdown{
+			DebateID: debateID,
+			Phase:    ShutdownClosing,
+			Snapshot: true,
+			Message:  fmt.Sprintf("The debate was saved in round %d and continues when the bots log in again", activeDebate.Debate.CurrentRound),
+		}))
+		saved++
+	}
+	return saved
+}
+
+// closeConnections closes the WebSocket of every bot and spectator with a
+// going-away close frame. Broadcasts are sent asynchronously, so they get a
+// moment to flush first.
+func (dm *DebateManager) closeConnections() {
+	time.Sleep(200 * time.Millisecond)
+	closeFrame := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+
+	dm.mutex.RLock()
+	defer dm.mutex.RUnlock()
+	for _, activeDebate := range dm.debates {
+		for _, bot := range activeDebate.Bots {
+			if bot.Conn != nil {
+				bot.Conn.WriteControl(websocket.CloseMessage, closeFrame, time.Now().Add(time.Second))
+				bot.Conn.Close()
+			}
+		}
+		activeDebate.mutex.RLock()
+		for conn := range activeDebate.FrontendConns {
+			conn.WriteControl(websocket.CloseMessage, closeFrame, time.Now().Add(time.Second))
+			conn.Close()
+		}
+		for stream := range activeDebate.EventStreams {
+			stream.Close()
+		}
+		activeDebate.mutex.RUnlock()
+	}
+}
+
+// InterruptDebate stores a debate cut off by a server shutdown at its current round
+func (d *Database) InterruptDebate(debateID string, round int) error {
+	query := `UPDATE debates SET status = ?, current_round = ?, updated_at = ? WHERE id = ?`
+	_, err := d.db.Exec(query, StatusInterrupted, round, time.Now(), debateID)
+	return err
+}
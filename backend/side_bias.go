@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// fallbackJudge labels results produced without an LLM judge
+const fallbackJudge = "fallback"
+
+// WinnerCount is one row of the winner aggregation behind side-bias reports
+type WinnerCount struct {
+	JudgeModel string
+	Period     string
+	Winner     string
+	Count      int
+}
+
+// SideStats summarizes wins by side for one slice of results
+type SideStats struct {
+	Supporting     int     `json:"supporting"`
+	Opposing       int     `json:"opposing"`
+	Draws          int     `json:"draws"`
+	SupportingRate float64 `json:"supporting_rate"` // Share of decisive results won by the supporting side
+	Bias           float64 `json:"bias"`            // SupportingRate - 0.5; positive favours supporting
+	Biased         bool    `json:"biased"`          // Enough samples and |bias| above the threshold
+}
+
+// SidePeriod is the side stats of one week
+type SidePeriod struct {
+	Period string `json:"period"` // YYYY-WW
+	SideStats
+}
+
+// SideBiasReport is the side-bias section of the admin stats
+type SideBiasReport struct {
+	Threshold  float64               `json:"threshold"`
+	MinSamples int                   `json:"min_samples"`
+	WindowDays int                   `json:"window_days"`
+	Overall    SideStats             `json:"overall"`
+	Recent     SideStats             `json:"recent"` // Last window_days
+	ByModel    map[string]*SideStats `json:"by_model"`
+	Weekly     []SidePeriod          `json:"weekly"`
+}
+
+// sideBiasAlerted remembers which scopes are currently flagged so each crossing alerts once
+var (
+	sideBiasMutex   sync.Mutex
+	sideBiasAlerted = make(map[string]bool)
+)
+
+// add counts one aggregated row
+func (s *SideStats) add(winner string, count int) {
+	switch winner {
+	case "supporting":
+		s.Supporting += count
+	case "opposing":
+		s.Opposing += count
+	case "draw":
+		s.Draws += count
+	}
+}
+
+// evaluate computes the rates and flags bias
+func (s *SideStats) evaluate(threshold float64, minSamples int) {
+	decisive := s.Supporting + s.Opposing
+	if decisive == 0 {
+		return
+	}
+	s.SupportingRate = float64(s.Supporting) / float64(decisive)
+	s.Bias = s.SupportingRate - 0.5
+	s.Biased = decisive >= minSamples && math.Abs(s.Bias) > threshold
+}
+
+// computeSideBias builds the side-bias report from stored results of ranked
+// debates; sandbox and other unranked games would skew it
+func computeSideBias() (*SideBiasReport, error) {
+	cfg := config.Stats.SideBias
+	report := &SideBiasReport{
+		Threshold:  cfg.Threshold,
+		MinSamples: cfg.MinSamples,
+		WindowDays: cfg.WindowDays,
+		ByModel:    make(map[string]*SideStats),
+	}
+
+	all, err := db.GetWinnerCounts(time.Time{}, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range all {
+		model := c.JudgeModel
+		if model == "" {
+			model = fallbackJudge
+		}
+		if report.ByModel[model] == nil {
+			report.ByModel[model] = &SideStats{}
+		}
+		report.ByModel[model].add(c.Winner, c.Count)
+		report.Overall.add(c.Winner, c.Count)
+	}
+
+	since := time.Now().AddDate(0, 0, -cfg.WindowDays)
+	recent, err := db.GetWinnerCounts(since, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range recent {
+		report.Recent.add(c.Winner, c.Count)
+	}
+
+	weekly, err := db.GetWinnerCounts(time.Time{}, "%Y-%W")
+	if err != nil {
+		return nil, err
+	}
+	periods := make(map[string]*SidePeriod)
+	for _, c := range weekly {
+		if periods[c.Period] == nil {
+			periods[c.Period] = &SidePeriod{Period: c.Period}
+		}
+		periods[c.Period].add(c.Winner, c.Count)
+	}
+	for _, p := range periods {
+		p.evaluate(cfg.Threshold, cfg.MinSamples)
+		report.Weekly = append(report.Weekly, *p)
+	}
+	sort.Slice(report.Weekly, func(i, j int) bool { return report.Weekly[i].Period < report.Weekly[j].Period })
+
+	report.Overall.evaluate(cfg.Threshold, cfg.MinSamples)
+	report.Recent.evaluate(cfg.Threshold, cfg.MinSamples)
+	for _, s := range report.ByModel {
+		s.evaluate(cfg.Threshold, cfg.MinSamples)
+	}
+	return report, nil
+}
+
+// checkSideBias raises an admin alert when the recent window or a judge model
+// starts favouring one side beyond the configured threshold
+func checkSideBias() {
+	report, err := computeSideBias()
+	if err != nil {
+		log.Printf("Failed to compute side bias: %v", err)
+		return
+	}
+
+	scopes := map[string]SideStats{
+		fmt.Sprintf("last %d days", report.WindowDays): report.Recent,
+	}
+	for model, stats := range report.ByModel {
+		if model != fallbackJudge {
+			scopes["judge model "+model] = *stats
+		}
+	}
+
+	sideBiasMutex.Lock()
+	defer sideBiasMutex.Unlock()
+	for scope, stats := range scopes {
+		if stats.Biased && !sideBiasAlerted[scope] {
+			favoured := "supporting"
+			if stats.Bias < 0 {
+				favoured = "opposing"
+			}
+			RaiseAlert("side_bias", fmt.Sprintf("%s favours the %s side: %.0f%% supporting wins over %d decisive debates (threshold ±%.0f%%)",
+				scope, favoured, stats.SupportingRate*100, stats.Supporting+stats.Opposing, report.Threshold*100))
+		}
+		sideBiasAlerted[scope] = stats.Biased
+	}
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Debates created with side_channel let their bots exchange short structured
+// messages (side_signal) while the debate runs, for negotiation-style
+// experiments. The server relays each signal to the other bots and logs it;
+// spectators never see them, but the judge reads them alongside the
+// transcript and administrators see them on the admin stream and at
+// /api/admin/signals/{debate_id}. The channel is off unless a debate asks
+// for it, and debate.side_channel limits how much each bot may send.
+
+// signalKindPattern restricts kind to a short lowercase label
+var signalKindPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+// maxSignalFields bounds the number of fields in one signal
+const maxSignalFields = 8
+
+// SideSignal is a side-channel message sent by a bot
+type SideSignal struct {
+	DebateID  string            `json:"debate_id"`
+	DebateKey string            `json:"debate_key"`
+	Speaker   string            `json:"speaker"`
+	Kind      string            `json:"kind"`             // Label such as offer, accept or reject
+	Fields    map[string]string `json:"fields,omitempty"` // Structured content
+}
+
+// RelayedSignal is a side-channel message as relayed and logged by the server
+type RelayedSignal struct {
+	DebateID  string            `json:"debate_id"`
+	Seq       int               `json:"seq"` // 1-based order within the debate
+	Round     int               `json:"round"`
+	From      string            `json:"from"`
+	Side      string            `json:"side"`
+	Kind      string            `json:"kind"`
+	Fields    map[string]string `json:"fields,omitempty"`
+	Timestamp string            `json:"timestamp"`
+}
+
+// SignalAccepted confirms a relayed signal to its sender
+type SignalAccepted struct {
+	DebateID  string `json:"debate_id"`
+	Seq       int    `json:"seq"`
+	Remaining int    `json:"remaining"` // Signals the bot may still send in this debate
+}
+
+// validateSignal checks a signal's shape against the side channel limits
+func validateSignal(signal *SideSignal) error {
+	if !signalKindPattern.MatchString(signal.Kind) {
+		return fmt.Errorf("kind must be a lowercase label of at most 32 characters")
+	}
+	if len(signal.Fields) > maxSignalFields {
+		return fmt.Errorf("at most %d fields are allowed", maxSignalFields)
+	}
+	size := len(signal.Kind)
+	for key, value := range signal.Fields {
+		size += len(key) + len(value)
+	}
+	if size > config.Debate.SideChannel.MaxBytes {
+		return fmt.Errorf("signal is %d bytes, the limit is %d", size, config.Debate.SideChannel.MaxBytes)
+	}
+	return nil
+}
+
+// HandleSideSignal relays a bot's side-channel message to the other bots
+func (dm *DebateManager) HandleSideSignal(signal *SideSignal, conn *WSConn, replyTo string) *ErrorMessage {
+	dm.mutex.RLock()
+	activeDebate, exists := dm.debates[signal.DebateID]
+	dm.mutex.RUnlock()
+
+	if !exists {
+		return &ErrorMessage{
+			ErrorCode:   "DEBATE_NOT_FOUND",
+			Message:     "Debate not found",
+			DebateID:    signal.DebateID,
+			Recoverable: false,
+		}
+	}
+	sender := activeDebate.findBot(signal.Speaker)
+	if sender == nil || sender.Bot.DebateKey != signal.DebateKey {
+		return &ErrorMessage{
+			ErrorCode:   "INVALID_DEBATE_KEY",
+			Message:     "Invalid debate key",
+			DebateID:    signal.DebateID,
+			Recoverable: false,
+		}
+	}
+	if !activeDebate.Debate.SideChannel {
+		return &ErrorMessage{
+			ErrorCode:   "SIDE_CHANNEL_DISABLED",
+			Message:     "This debate was not created with a side channel",
+			DebateID:    signal.DebateID,
+			Recoverable: false,
+		}
+	}
+	if !isInProgress(activeDebate.Debate.Status) {
+		return &ErrorMessage{
+			ErrorCode:   "DEBATE_NOT_ACTIVE",
+			Message:     "Signals can only be sent while the debate runs",
+			DebateID:    signal.DebateID,
+			Recoverable: true,
+		}
+	}
+	if err := validateSignal(signal); err != nil {
+		return &ErrorMessage{
+			ErrorCode:   "INVALID_SIGNAL",
+			Message:     err.Error(),
+			DebateID:    signal.DebateID,
+			Recoverable: true,
+		}
+	}
+
+	activeDebate.mutex.Lock()
+	sent := 0
+	for _, s := range activeDebate.Signals {
+		if s.From == signal.Speaker {
+			sent++
+		}
+	}
+	if sent >= config.Debate.SideChannel.MaxMessages {
+		activeDebate.mutex.Unlock()
+		return &ErrorMessage{
+			ErrorCode:   "SIGNAL_LIMIT_REACHED",
+			Message:     fmt.Sprintf("Each bot may send at most %d signals per debate", config.Debate.SideChannel.MaxMessages),
+			DebateID:    signal.DebateID,
+			Recoverable: true,
+		}
+	}
+	relayed := RelayedSignal{
+		DebateID:  signal.DebateID,
+		Seq:       len(activeDebate.Signals) + 1,
+		Round:     activeDebate.Debate.CurrentRound,
+		From:      signal.Speaker,
+		Side:      sender.Bot.Side,
+		Kind:      signal.Kind,
+		Fields:    signal.Fields,
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	activeDebate.Signals = append(activeDebate.Signals, relayed)
+	activeDebate.mutex.Unlock()
+
+	if err := dm.db.AddSideSignal(&relayed); err != nil {
+		log.Printf("Failed to log side signal %d of debate %s: %v", relayed.Seq, relayed.DebateID, err)
+	}
+
+	msg := createMessage("side_signal", relayed)
+	for _, bot := range activeDebate.Bots {
+		if bot != sender && bot.Conn != nil && !activeDebate.Disconnected[bot.Bot.BotIdentifier] {
+			bot.Conn.WriteJSON(msg)
+		}
+	}
+	adminStream.Publish(msg)
+	conn.WriteJSON(createReply(replyTo, "signal_accepted", SignalAccepted{
+		DebateID:  relayed.DebateID,
+		Seq:       relayed.Seq,
+		Remaining: config.Debate.SideChannel.MaxMessages - sent - 1,
+	}))
+	return nil
+}
+
+// signalTranscript renders side-channel messages for the judge prompt
+func signalTranscript(signals []RelayedSignal) string {
+	if len(signals) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("双方私下沟通记录 (side channel):\n\n")
+	for _, s := range signals {
+		sideName := "正方"
+		if s.Side == "opposing" {
+			sideName = "反方"
+		}
+		fields, _ := json.Marshal(s.Fields)
+		b.WriteString(fmt.Sprintf("【第%d轮 - %s - %s】%s\n", s.Round, sideName, s.Kind, fields))
+	}
+	return b.String() + "\n"
+}
+
+// handleAdminSignals handles GET /api/admin/signals/{debate_id}
+func handleAdminSignals(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	debateID := resolveDebateID(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/admin/signals"), "/"))
+	if debateID == "" {
+		http.Error(w, "Debate ID required", http.StatusBadRequest)
+		return
+	}
+	signals, err := db.GetSideSignals(debateID)
+	if err != nil {
+		http.Error(w, "Failed to fetch signals", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, signals)
+}
+
+// AddSideSignal logs a relayed side-channel message
+func (d *Database) AddSideSignal(s *RelayedSignal) error {
+	fields, err := json.Marshal(s.Fields)
+	if err != nil {
+		return err
+	}
+	_, err = d.db.Exec(`INSERT INTO side_signals (debate_id, seq, round, sender, side, kind, fields, created_at)
+	                    VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		s.DebateID, s.Seq, s.Round, s.From, s.Side, s.Kind, string(fields), s.Timestamp)
+	return err
+}
+
+// GetSideSignals retrieves a debate's side-channel messages in order
+func (d *Database) GetSideSignals(debateID string) ([]RelayedSignal, error) {
+	rows, err := d.db.Query(`SELECT debate_id, seq, round, sender, side, kind, fields, created_at
+	                         FROM side_signals WHERE debate_id = ? ORDER BY seq ASC`, debateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	signals := []RelayedSignal{}
+	for rows.Next() {
+		var s RelayedSignal
+		var fields string
+		if err := rows.Scan(&s.DebateID, &s.Seq, &s.Round, &s.From, &s.Side, &s.Kind, &fields, &s.Timestamp); err != nil {
+			return nil, err
+		}
+		json.Unmarshal([]byte(fields), &s.Fields)
+		signals = append(signals, s)
+	}
+	return signals, rows.Err()
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Debate formats
+const (
+	FormatSequential   = "sequential"   // Supporting then opposing, each seeing the other's speech
+	FormatSimultaneous = "simultaneous" // Both submit blind each round, revealed together
+)
+
+// handleSimultaneousSpeech buffers a speech until both sides have submitted
+// for the round (or the round deadline passes), then reveals them together
+func (dm *DebateManager) handleSimultaneousSpeech(activeDebate *ActiveDebate, speakerBot *ConnectedBot, speech *DebateSpeech, replyTo string) *ErrorMessage {
+	if errMsg := validateSpeechLength(speech, formatLimitsFor(activeDebate.Debate)); errMsg != nil {
+		return errMsg
+	}
+	if errMsg := storeAttachments(speech); errMsg != nil {
+		return errMsg
+	}
+
+	activeDebate.mutex.Lock()
+	if _, submitted := activeDebate.PendingSpeeches[speech.Speaker]; submitted {
+		activeDebate.mutex.Unlock()
+		return &ErrorMessage{
+			ErrorCode:   "ALREADY_SUBMITTED",
+			Message:     "You have already submitted your speech for this round",
+			DebateID:    speech.DebateID,
+			Recoverable: true,
+		}
+	}
+
+	round := activeDebate.Debate.CurrentRound
+	activeDebate.PendingSpeeches[speech.Speaker] = DebateLogEntry{
+		Round:     round,
+		Speaker:   speech.Speaker,
+		Side:      speakerBot.Bot.Side,
+		Timestamp: time.Now().Format(time.RFC3339),
+		Message:   speech.Message,
+	}
+	allIn := len(activeDebate.PendingSpeeches) == 2
+	waiting := dm.waitingSpeakers(activeDebate)
+	activeDebate.LastActivityTime = time.Now()
+	activeDebate.mutex.Unlock()
+
+	dm.resetInactivityTimer(speech.DebateID)
+
+	// Acknowledge without revealing anything about the opponent's speech
+	speakerBot.Conn.WriteJSON(createReply(replyTo, "speech_received", SpeechReceived{
+		DebateID: speech.DebateID,
+		Round:    round,
+		Speaker:  speech.Speaker,
+		Waiting:  waiting,
+	}))
+
+	if allIn {
+		dm.revealRound(speech.DebateID, round)
+	}
+	return nil
+}
+
+// waitingSpeakers lists bots that have not yet submitted this round. Caller holds the lock.
+func (dm *DebateManager) waitingSpeakers(activeDebate *ActiveDebate) []string {
+	waiting := []string{}
+	for _, bot := range []*ConnectedBot{activeDebate.SupportingBot, activeDebate.OpposingBot} {
+		if _, ok := activeDebate.PendingSpeeches[bot.Bot.BotIdentifier]; !ok {
+			waiting = append(waiting, bot.Bot.BotIdentifier)
+		}
+	}
+	return waiting
+}
+
+// revealRound publishes the buffered speeches of a round and advances the debate.
+// It is a no-op if the round has already been revealed.
+func (dm *DebateManager) revealRound(debateID string, round int) {
+	dm.mutex.RLock()
+	activeDebate, exists := dm.debates[debateID]
+	dm.mutex.RUnlock()
+
+	if !exists {
+		return
+	}
+
+	activeDebate.mutex.Lock()
+	if activeDebate.Debate.CurrentRound != round || !isInProgress(activeDebate.Debate.Status) {
+		activeDebate.mutex.Unlock()
+		return
+	}
+	if len(activeDebate.PendingSpeeches) == 0 {
+		// Nobody submitted before the deadline
+		activeDebate.mutex.Unlock()
+		dm.endDebate(debateID, "timeout", "speech_timeout")
+		return
+	}
+
+	if activeDebate.TimeoutTimer != nil {
+		activeDebate.TimeoutTimer.Stop()
+	}
+
+	// Reveal in side order so the log reads supporting, opposing
+	entries := []DebateLogEntry{}
+	missing := []string{}
+	for _, bot := range []*ConnectedBot{activeDebate.SupportingBot, activeDebate.OpposingBot} {
+		if entry, ok := activeDebate.PendingSpeeches[bot.Bot.BotIdentifier]; ok {
+			entries = append(entries, entry)
+		} else {
+			missing = append(missing, bot.Bot.BotIdentifier)
+		}
+	}
+	activeDebate.DebateLog = append(activeDebate.DebateLog, entries...)
+	activeDebate.PendingSpeeches = make(map[string]DebateLogEntry)
+	activeDebate.Debate.CurrentRound++
+	nextRound := activeDebate.Debate.CurrentRound
+	activeDebate.mutex.Unlock()
+
+	if err := dm.db.RecordSpeeches(debateID, entries, nextRound); err != nil {
+		log.Printf("Failed to record round %d of debate %s: %v", round, debateID, err)
+	}
+	dm.translateEntries(activeDebate, entries)
+	dm.summarizeEntries(activeDebate, entries)
+	speechesAccepted(activeDebate, entries)
+	dm.onRoundComplete(activeDebate, round)
+
+	revealMsg := createMessage("round_reveal", RoundReveal{
+		DebateID: debateID,
+		Round:    round,
+		Entries:  entries,
+		Missing:  missing,
+	})
+	activeDebate.SupportingBot.Conn.WriteJSON(revealMsg)
+	activeDebate.OpposingBot.Conn.WriteJSON(revealMsg)
+	dm.broadcast <- BroadcastMessage{DebateID: debateID, Message: revealMsg}
+
+	log.Printf("Debate %s round %d revealed (%d speeches, missing: %v)", debateID, round, len(entries), missing)
+
+	if nextRound > activeDebate.Debate.TotalRounds {
+		dm.closeDebate(activeDebate)
+		return
+	}
+
+	dm.sendSimultaneousUpdate(activeDebate)
+	dm.startRoundDeadline(debateID, nextRound)
+}
+
+// sendSimultaneousUpdate tells each bot that it is due to speak in the new round
+func (dm *DebateManager) sendSimultaneousUpdate(activeDebate *ActiveDebate) {
+	activeDebate.mutex.RLock()
+	defer activeDebate.mutex.RUnlock()
+
+	limits := formatLimitsFor(activeDebate.Debate)
+	build := func(bot *ConnectedBot, nextSpeaker string) Message {
+		return createMessage("debate_update", DebateUpdate{
+			DebateID:         activeDebate.Debate.ID,
+			Topic:            activeDebate.Debate.Topic,
+			SupportingSide:   activeDebate.SupportingBot.Bot.BotIdentifier,
+			OpposingSide:     activeDebate.OpposingBot.Bot.BotIdentifier,
+			TotalRounds:      activeDebate.Debate.TotalRounds,
+			CurrentRound:     activeDebate.Debate.CurrentRound,
+			YourSide:         bot.Bot.Side,
+			YourIdentifier:   bot.Bot.BotIdentifier,
+			NextSpeaker:      nextSpeaker,
+			TimeoutSeconds:   limits.SpeechTimeout,
+			MinContentLength: limits.MinContentLength,
+			MaxContentLength: limits.MaxContentLength,
+			DebateLog:        activeDebate.DebateLog,
+			Format:           FormatSimultaneous,
+			Status:           activeDebate.Debate.Status,
+			Scores:           activeDebate.runningScore(),
+			OpponentSummary:  activeDebate.opponentSummary(bot),
+			Spectators:       activeDebate.spectatorTotal(),
+		})
+	}
+
+	chaos.WriteToBot(activeDebate.SupportingBot.Conn, build(activeDebate.SupportingBot, activeDebate.SupportingBot.Bot.BotIdentifier))
+	chaos.WriteToBot(activeDebate.OpposingBot.Conn, build(activeDebate.OpposingBot, activeDebate.OpposingBot.Bot.BotIdentifier))
+
+	// Spectators see both sides as due
+	dm.broadcast <- BroadcastMessage{
+		DebateID: activeDebate.Debate.ID,
+		Message:  build(activeDebate.SupportingBot, ""),
+	}
+}
+
+// startRoundDeadline reveals whatever has been submitted once the speech timeout passes
+func (dm *DebateManager) startRoundDeadline(debateID string, round int) {
+	dm.mutex.RLock()
+	activeDebate, exists := dm.debates[debateID]
+	dm.mutex.RUnlock()
+
+	if !exists {
+		return
+	}
+
+	activeDebate.TimeoutTimer = time.AfterFunc(
+		activeDebate.turnTimeout(),
+		func() {
+			log.Printf("Round %d deadline passed in debate %s", round, debateID)
+			dm.revealRound(debateID, round)
+		},
+	)
+	dm.startCountdown(activeDebate, activeDebate.SupportingBot, activeDebate.OpposingBot)
+}
+
+// validateSpeechLength checks a buffered speech against the debate's content limits
+func validateSpeechLength(speech *DebateSpeech, limits FormatLimits) *ErrorMessage {
+	contentLen := len(strings.TrimSpace(speech.Message.Content))
+	if contentLen < limits.MinContentLength {
+		return &ErrorMessage{
+			ErrorCode:   "CONTENT_TOO_SHORT",
+			Message:     fmt.Sprintf("Speech content too short (minimum %d characters)", limits.MinContentLength),
+			DebateID:    speech.DebateID,
+			Recoverable: true,
+		}
+	}
+	if contentLen > limits.MaxContentLength {
+		return &ErrorMessage{
+			ErrorCode:   "CONTENT_TOO_LONG",
+			Message:     fmt.Sprintf("Speech content too long (maximum %d characters)", limits.MaxContentLength),
+			DebateID:    speech.DebateID,
+			Recoverable: true,
+		}
+	}
+	return nil
+}
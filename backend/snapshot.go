@@ -0,0 +1,199 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+)
+
+// A running debate can be snapshotted to the database and restored by any
+// instance, so the backend can be redeployed without ending debates. With
+// server.snapshot_on_shutdown, debates still running when the shutdown grace
+// expires are snapshotted instead of interrupted and their ownership is
+// released. The first bot to log back in, on whichever instance, restores
+// the debate there: it resumes paused, as if every bot had dropped, and
+// continues once all of them are back within reconnect_grace. The speech
+// clock and the max_duration clock carry over what was left of them.
+
+// StatusMigrated marks, in memory only, a debate that was snapshotted and
+// continues on another instance; the database keeps its running status
+const StatusMigrated = "migrated"
+
+// MigrationSnapshot is the in-memory state of a running debate that is not
+// already in the database
+type MigrationSnapshot struct {
+	DebateID        string                    `json:"debate_id"`
+	Seats           []Bot                     `json:"seats"`      // Bots in speaking order
+	LogLength       int                       `json:"log_length"` // Speeches in the log when the snapshot was taken
+	LastSpeaker     string                    `json:"last_speaker,omitempty"`
+	ElapsedMs       int64                     `json:"elapsed_ms"`             // Time counted towards max_duration
+	TurnLeftMs      int64                     `json:"turn_left_ms,omitempty"` // Speech time the due speaker had left; 0 gives a full turn
+	PendingSpeeches map[string]DebateLogEntry `json:"pending_speeches,omitempty"`
+	Tiebreak        *TiebreakInfo             `json:"tiebreak,omitempty"`
+	RoundResults    []RoundResult             `json:"round_results,omitempty"`
+	Violations      map[string]int            `json:"violations,omitempty"`
+	Signals         []RelayedSignal           `json:"signals,omitempty"`
+	TakenAt         time.Time                 `json:"taken_at"`
+}
+
+// snapshotDebate builds the snapshot of a running debate and stops its clocks
+func (dm *DebateManager) snapshotDebate(activeDebate *ActiveDebate) *MigrationSnapshot {
+	for _, timer := range []*time.Timer{activeDebate.TimeoutTimer, activeDebate.InactivityTimer, activeDebate.MaxDurationTimer} {
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+	stopCountdown(activeDebate)
+
+	activeDebate.mutex.RLock()
+	defer activeDebate.mutex.RUnlock()
+	now := time.Now()
+	snap := &MigrationSnapshot{
+		DebateID:        activeDebate.Debate.ID,
+		LogLength:       len(activeDebate.DebateLog),
+		LastSpeaker:     activeDebate.LastSpeaker,
+		PendingSpeeches: activeDebate.PendingSpeeches,
+		Tiebreak:        activeDebate.Tiebreak,
+		RoundResults:    activeDebate.RoundResults,
+		Violations:      activeDebate.Violations,
+		Signals:         activeDebate.Signals,
+		TakenAt:         now,
+	}
+	for _, bot := range activeDebate.Bots {
+		snap.Seats = append(snap.Seats, *bot.Bot)
+	}
+	// A paused debate's clocks stopped when the pause began, and resuming gives a full turn
+	if activeDebate.Paused {
+		snap.ElapsedMs = activeDebate.PausedAt.Sub(activeDebate.StartTime).Milliseconds()
+	} else {
+		snap.ElapsedMs = now.Sub(activeDebate.StartTime).Milliseconds()
+		if activeDebate.TurnDeadline.After(now) {
+			snap.TurnLeftMs = activeDebate.TurnDeadline.Sub(now).Milliseconds()
+		}
+	}
+	return snap
+}
+
+// restoreDebate rebuilds a debate from its snapshot, if another instance left
+// one, and waits for its bots to reconnect. Caller holds dm.mutex.
+func (dm *DebateManager) restoreDebate(debateID string) *ActiveDebate {
+	snap, err := dm.db.TakeDebateSnapshot(debateID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("Failed to load the snapshot of debate %s: %v", debateID, err)
+		}
+		return nil
+	}
+	debate, err := dm.db.GetDebate(debateID)
+	if err != nil || !isInProgress(debate.Status) {
+		log.Printf("Discarding the snapshot of debate %s, which is no longer running", debateID)
+		return nil
+	}
+	debateLog, err := dm.db.GetDebateLog(debateID)
+	if err != nil {
+		log.Printf("Failed to restore the log of debate %s: %v", debateID, err)
+		return nil
+	}
+	if len(debateLog) != snap.LogLength {
+		log.Printf("Debate %s log has %d speeches, its snapshot expected %d; using the stored log", debateID, len(debateLog), snap.LogLength)
+	}
+	if debateLog == nil {
+		debateLog = make([]DebateLogEntry, 0)
+	}
+
+	now := time.Now()
+	activeDebate := &ActiveDebate{
+		Debate:           debate,
+		DebateLog:        debateLog,
+		FrontendConns:    make(map[*WSConn]*Subscriber),
+		events:           newEventLog(),
+		LastSpeaker:      snap.LastSpeaker,
+		PendingSpeeches:  snap.PendingSpeeches,
+		OpeningsClosed:   true,
+		Tiebreak:         snap.Tiebreak,
+		RoundResults:     snap.RoundResults,
+		Violations:       snap.Violations,
+		Signals:          snap.Signals,
+		TurnTimeLeft:     time.Duration(snap.TurnLeftMs) * time.Millisecond,
+		StartTime:        now.Add(-time.Duration(snap.ElapsedMs) * time.Millisecond),
+		LastActivityTime: now,
+	}
+	if activeDebate.PendingSpeeches == nil {
+		activeDebate.PendingSpeeches = make(map[string]DebateLogEntry)
+	}
+	for i := range snap.Seats {
+		bot := &ConnectedBot{Bot: &snap.Seats[i], LastPongTime: now}
+		activeDebate.Bots = append(activeDebate.Bots, bot)
+		if bot.Bot.Side == "supporting" && activeDebate.SupportingBot == nil {
+			activeDebate.SupportingBot = bot
+		}
+		if bot.Bot.Side == "opposing" && activeDebate.OpposingBot == nil {
+			activeDebate.OpposingBot = bot
+		}
+	}
+
+	dm.debates[debateID] = activeDebate
+	cluster.ClaimDebate(debateID)
+	for _, bot := range activeDebate.Bots {
+		dm.holdForReconnect(activeDebate, bot, "bot_disconnected")
+	}
+	log.Printf("Restored debate %s from a snapshot taken %v ago (round %d, %d bots)",
+		debateID, now.Sub(snap.TakenAt).Round(time.Millisecond), debate.CurrentRound, len(activeDebate.Bots))
+	return activeDebate
+}
+
+// SaveDebateSnapshot stores a debate's snapshot and releases its ownership
+// so that the next instance its bots reach takes it over
+func (d *Database) SaveDebateSnapshot(snap *MigrationSnapshot, instanceID string) error {
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`INSERT OR REPLACE INTO debate_snapshots (debate_id, instance_id, snapshot, created_at)
+	                  VALUES (?, ?, ?, ?)`, snap.DebateID, instanceID, string(data), snap.TakenAt)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE debates SET owner_instance = '' WHERE id = ?`, snap.DebateID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// TakeDebateSnapshot removes and returns a debate's snapshot; of several
+// instances racing for it only one gets it, the others get sql.ErrNoRows
+func (d *Database) TakeDebateSnapshot(debateID string) (*MigrationSnapshot, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var data string
+	if err := tx.QueryRow(`SELECT snapshot FROM debate_snapshots WHERE debate_id = ?`, debateID).Scan(&data); err != nil {
+		return nil, err
+	}
+	res, err := tx.Exec(`DELETE FROM debate_snapshots WHERE debate_id = ?`, debateID)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, sql.ErrNoRows
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	var snap MigrationSnapshot
+	if err := json.Unmarshal([]byte(data), &snap); err != nil {
+		return nil, err
+	}
+	return &snap, nil
+}
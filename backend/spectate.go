@@ -0,0 +1,54 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+)
+
+// Subscribe failures returned by AddFrontendConnection
+var (
+	errDebateNotFound = errors.New("debate not found")
+	errTokenRequired  = errors.New("spectator token required")
+	errInvalidToken   = errors.New("invalid spectator token")
+)
+
+// generateSpectatorToken creates the token that grants access to a private debate
+func generateSpectatorToken() string {
+	bytes := make([]byte, 16)
+	rand.Read(bytes)
+	return "spec-" + hex.EncodeToString(bytes)
+}
+
+// checkSpectatorAccess enforces debate visibility: public debates are open to
+// everyone, private ones need the spectator token issued at creation
+func checkSpectatorAccess(debate *Debate, token string) error {
+	if !debate.Private {
+		return nil
+	}
+	if token == "" {
+		return errTokenRequired
+	}
+	if !hmac.Equal([]byte(token), []byte(debate.SpectatorToken)) {
+		return errInvalidToken
+	}
+	return nil
+}
+
+// subscribeRejection builds the subscribe_rejected payload for an access error
+func subscribeRejection(debateID string, err error) SubscribeRejected {
+	reason := "debate_not_found"
+	switch err {
+	case errTokenRequired:
+		reason = "token_required"
+	case errInvalidToken:
+		reason = "invalid_token"
+	}
+	return SubscribeRejected{
+		Status:   "rejected",
+		Reason:   reason,
+		Message:  err.Error(),
+		DebateID: debateID,
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+// Spectator sessions are recorded anonymously: one row per live subscription
+// with only its debate and join/leave times. Replicas cannot write, so
+// spectators watching through a replica are not counted.
+
+// SpectatorStats aggregates the spectator sessions of one debate
+type SpectatorStats struct {
+	DebateID            string  `json:"debate_id"`
+	Sessions            int     `json:"sessions"`
+	PeakViewers         int     `json:"peak_viewers"`          // Most sessions open at the same time
+	AverageWatchSeconds float64 `json:"average_watch_seconds"` // Over finished sessions
+	TotalWatchSeconds   float64 `json:"total_watch_seconds"`
+}
+
+// StartSpectatorSession records a spectator joining a live debate hosted by instanceID
+func (d *Database) StartSpectatorSession(debateID, instanceID string) (int64, error) {
+	res, err := d.db.Exec(`INSERT INTO spectator_sessions (debate_id, instance_id, joined_at) VALUES (?, ?, ?)`,
+		debateID, instanceID, time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// EndSpectatorSession records a spectator leaving
+func (d *Database) EndSpectatorSession(id int64) error {
+	_, err := d.db.Exec(`UPDATE spectator_sessions SET left_at = ? WHERE id = ? AND left_at IS NULL`, time.Now().UTC(), id)
+	return err
+}
+
+// DropOpenSpectatorSessions removes the sessions an instance left open when it
+// last stopped. Their real leave time is unknown, so they would skew watch
+// time and peaks.
+func (d *Database) DropOpenSpectatorSessions(instanceID string) (int64, error) {
+	res, err := d.db.Exec(`DELETE FROM spectator_sessions WHERE instance_id = ? AND left_at IS NULL`, instanceID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// GetSpectatorStats aggregates the sessions that began since the given time
+// per debate, most peak viewers first. Sessions still open count as watching
+// until now for the peak.
+func (d *Database) GetSpectatorStats(since time.Time, limit int) ([]SpectatorStats, error) {
+	rows, err := d.db.Query(`SELECT debate_id, joined_at, left_at FROM spectator_sessions WHERE joined_at >= ?`, since.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type viewerEvent struct {
+		at    time.Time
+		delta int
+	}
+	events := map[string][]viewerEvent{}
+	byDebate := map[string]*SpectatorStats{}
+	finished := map[string]int{}
+	now := time.Now().UTC()
+	for rows.Next() {
+		var debateID string
+		var joinedAt time.Time
+		var leftAt *time.Time
+		if err := rows.Scan(&debateID, &joinedAt, &leftAt); err != nil {
+			return nil, err
+		}
+		stats, ok := byDebate[debateID]
+		if !ok {
+			stats = &SpectatorStats{DebateID: debateID}
+			byDebate[debateID] = stats
+		}
+		stats.Sessions++
+		end := now
+		if leftAt != nil {
+			end = *leftAt
+			stats.TotalWatchSeconds += end.Sub(joinedAt).Seconds()
+			finished[debateID]++
+		}
+		events[debateID] = append(events[debateID], viewerEvent{joinedAt, 1}, viewerEvent{end, -1})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	stats := make([]SpectatorStats, 0, len(byDebate))
+	for debateID, s := range byDebate {
+		// Sweep joins and leaves in time order; a leave at the same instant as a join goes first
+		evs := events[debateID]
+		sort.Slice(evs, func(i, j int) bool {
+			if evs[i].at.Equal(evs[j].at) {
+				return evs[i].delta < evs[j].delta
+			}
+			return evs[i].at.Before(evs[j].at)
+		})
+		viewers := 0
+		for _, ev := range evs {
+			viewers += ev.delta
+			if viewers > s.PeakViewers {
+				s.PeakViewers = viewers
+			}
+		}
+		if finished[debateID] > 0 {
+			s.AverageWatchSeconds = s.TotalWatchSeconds / float64(finished[debateID])
+		}
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].PeakViewers != stats[j].PeakViewers {
+			return stats[i].PeakViewers > stats[j].PeakViewers
+		}
+		return stats[i].DebateID < stats[j].DebateID
+	})
+	if len(stats) > limit {
+		stats = stats[:limit]
+	}
+	return stats, nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Spectators subscribed to a live debate may send chat_message over the
+// frontend WebSocket. Each message is saved in spectator_chat and relayed to
+// everyone watching the debate as chat_message, in the chat event category.
+// On subscribing, live or finished, a spectator gets the latest saved
+// messages as chat_history. Flood protection: frontend.chat.rate limits
+// messages per client IP, messages over max_length are rejected, and a
+// connection may not repeat its last message within chatRepeatWindow.
+// Replicas are read-only and do not take chat.
+
+// chatRepeatWindow is how long a connection may not send the same message again
+const chatRepeatWindow = 30 * time.Second
+
+// defaultChatName is shown for spectators who give no name
+const defaultChatName = "观众"
+
+// maxChatNameLength caps spectator names, in characters
+const maxChatNameLength = 24
+
+// ChatSend is a spectator's chat message
+type ChatSend struct {
+	Name    string `json:"name,omitempty"` // Shown with the message; defaultChatName when empty
+	Content string `json:"content"`
+}
+
+// ChatMessage is a chat message relayed to spectators
+type ChatMessage struct {
+	ID        int64     `json:"id"`
+	DebateID  string    `json:"debate_id"`
+	Name      string    `json:"name"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ChatHistory is the latest chat of a debate, oldest first
+type ChatHistory struct {
+	DebateID string        `json:"debate_id"`
+	Messages []ChatMessage `json:"messages"`
+}
+
+// chatLimiter limits chat messages per client IP; nil allows everything
+var chatLimiter *RateLimiter
+
+// ChatSession is one spectator connection's chat state
+type ChatSession struct {
+	ip       string
+	last     string
+	lastSent time.Time
+}
+
+// NewChatSession starts the chat state of a connection from a client IP
+func NewChatSession(ip string) *ChatSession {
+	return &ChatSession{ip: ip}
+}
+
+// Send checks a spectator's chat message, saves it and relays it to the
+// debate's spectators; problems are replied as recoverable errors
+func (s *ChatSession) Send(conn *WSConn, msg *Message, debateID string) {
+	chat := msg.Data.(*ChatSend)
+	reject := func(code, message, details string) {
+		writeReply(conn, msg, "error", ErrorMessage{
+			ErrorCode:   code,
+			Message:     message,
+			DebateID:    debateID,
+			Details:     details,
+			Recoverable: true,
+		})
+	}
+
+	if !config.Frontend.Chat.Enabled || isReplica() {
+		reject("CHAT_DISABLED", "Spectator chat is not available on this server", "")
+		return
+	}
+	if debateID == "" {
+		reject("NOT_SUBSCRIBED", "Subscribe to a live debate before chatting", "")
+		return
+	}
+	content := strings.TrimSpace(chat.Content)
+	if content == "" {
+		reject("MISSING_FIELD", "chat_message requires content", "content")
+		return
+	}
+	if length := utf8.RuneCountInString(content); length > config.Frontend.Chat.MaxLength {
+		reject("CONTENT_TOO_LONG", fmt.Sprintf("Chat messages are limited to %d characters, got %d", config.Frontend.Chat.MaxLength, length),
+			fmt.Sprintf("%d", config.Frontend.Chat.MaxLength))
+		return
+	}
+	if content == s.last && time.Since(s.lastSent) < chatRepeatWindow {
+		reject("DUPLICATE_MESSAGE", "You just sent this message", "")
+		return
+	}
+	if allowed, wait := chatLimiter.Allow(s.ip); !allowed {
+		seconds := retryAfterSeconds(wait)
+		reject("RATE_LIMITED", fmt.Sprintf("Too many chat messages, retry in %d seconds", seconds), fmt.Sprintf("%d", seconds))
+		return
+	}
+
+	name := strings.TrimSpace(chat.Name)
+	if name == "" {
+		name = defaultChatName
+	}
+	if runes := []rune(name); len(runes) > maxChatNameLength {
+		name = string(runes[:maxChatNameLength])
+	}
+	message := &ChatMessage{
+		DebateID:  debateID,
+		Name:      redactor.Redact(name),
+		Content:   redactor.Redact(content),
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := db.SaveChatMessage(message); err != nil {
+		log.Printf("Failed to save chat message in debate %s: %v", debateID, err)
+		reject("INTERNAL_ERROR", "Failed to send chat message", "")
+		return
+	}
+	s.last, s.lastSent = content, time.Now()
+
+	debateManager.broadcast <- BroadcastMessage{DebateID: debateID, Message: createMessage("chat_message", *message)}
+}
+
+// sendChatHistory sends a subscribing spectator the debate's latest chat,
+// unless it did not ask for chat events
+func sendChatHistory(conn *WSConn, debateID string, sub *Subscriber) {
+	if !config.Frontend.Chat.Enabled || !sub.Filter.Allows("chat_history") {
+		return
+	}
+	messages, err := db.GetChatMessages(debateID, config.Frontend.Chat.History)
+	if err != nil {
+		log.Printf("Failed to load chat of debate %s: %v", debateID, err)
+		return
+	}
+	conn.WriteJSON(createMessage("chat_history", ChatHistory{DebateID: debateID, Messages: messages}))
+}
+
+// SaveChatMessage stores a chat message and sets its ID
+func (d *Database) SaveChatMessage(m *ChatMessage) error {
+	res, err := d.db.Exec(`INSERT INTO spectator_chat (debate_id, name, content, created_at) VALUES (?, ?, ?, ?)`,
+		m.DebateID, m.Name, m.Content, m.CreatedAt)
+	if err != nil {
+		return err
+	}
+	m.ID, err = res.LastInsertId()
+	return err
+}
+
+// GetChatMessages returns a debate's latest chat messages, oldest first
+func (d *Database) GetChatMessages(debateID string, limit int) ([]ChatMessage, error) {
+	rows, err := d.db.Query(`SELECT id, debate_id, name, content, created_at FROM (
+			SELECT * FROM spectator_chat WHERE debate_id = ? ORDER BY id DESC LIMIT ?
+		) ORDER BY id`, debateID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []ChatMessage{}
+	for rows.Next() {
+		var m ChatMessage
+		if err := rows.Scan(&m.ID, &m.DebateID, &m.Name, &m.Content, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		messages = append(messages, m)
+	}
+	return messages, rows.Err()
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"time"
+)
+
+// The number of spectators watching a live debate on this instance, over
+// WebSockets or the SSE stream, is broadcast as spectator_count whenever
+// someone subscribes or leaves, and is carried in every debate_update and
+// debate_state, so bots and viewers can see the audience size. Joins and
+// leaves within spectatorCountDelay are announced once, so a crowd arriving
+// at once does not flood the room.
+
+// spectatorCountDelay is how long changes are collected before the count is broadcast
+const spectatorCountDelay = time.Second
+
+// SpectatorCount is the audience size of a live debate
+type SpectatorCount struct {
+	DebateID   string `json:"debate_id"`
+	Spectators int    `json:"spectators"`
+}
+
+// announceSpectators schedules a spectator_count broadcast, unless one is
+// already pending. Caller holds activeDebate.mutex.
+func (dm *DebateManager) announceSpectators(activeDebate *ActiveDebate) {
+	if activeDebate.spectatorCount != nil {
+		return
+	}
+	activeDebate.spectatorCount = time.AfterFunc(spectatorCountDelay, func() {
+		activeDebate.mutex.Lock()
+		activeDebate.spectatorCount = nil
+		count := SpectatorCount{DebateID: activeDebate.Debate.ID, Spectators: activeDebate.spectatorTotal()}
+		activeDebate.mutex.Unlock()
+
+		dm.broadcast <- BroadcastMessage{DebateID: count.DebateID, Message: createMessage("spectator_count", count)}
+	})
+}
+
+// spectatorTotal counts the WebSocket and SSE spectators of a debate. Caller
+// holds the mutex.
+func (a *ActiveDebate) spectatorTotal() int {
+	return len(a.FrontendConns) + len(a.EventStreams)
+}
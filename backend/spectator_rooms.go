@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+)
+
+// Spectator rooms cap how many spectators watch one live debate on this
+// instance (frontend.max_spectators). Spectators subscribing to a full room
+// wait in a queue and hear room_full with their position, again whenever it
+// changes; as admitted spectators leave, the head of the queue is subscribed
+// and sent the debate_snapshot as if it had just subscribed. Finished
+// debates are served from the database and never count against the cap.
+
+// RoomFull tells a spectator it is queued for a full debate room
+type RoomFull struct {
+	DebateID      string `json:"debate_id"`
+	Position      int    `json:"position"` // 1 is the next spectator admitted
+	MaxSpectators int    `json:"max_spectators"`
+	Message       string `json:"message"`
+}
+
+// roomSeat is a spectator waiting for a place in a room
+type roomSeat struct {
+	conn  *WSConn
+	token string
+	sub   *Subscriber
+}
+
+// spectatorRoom is one debate's admitted spectators and queue
+type spectatorRoom struct {
+	admitted map[*WSConn]bool
+	queue    []*roomSeat
+}
+
+// SpectatorRooms gates live subscriptions to the spectator hub
+type SpectatorRooms struct {
+	mutex sync.Mutex
+	rooms map[string]*spectatorRoom
+}
+
+var rooms = &SpectatorRooms{rooms: make(map[string]*spectatorRoom)}
+
+// Join subscribes a spectator to a live debate, or queues it when the room is
+// full and returns its queue position. Errors are those of
+// AddFrontendConnection; queued spectators are checked for access first.
+func (r *SpectatorRooms) Join(debateID, token string, sub *Subscriber, conn *WSConn) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	room := r.rooms[debateID]
+	if room == nil {
+		room = &spectatorRoom{admitted: make(map[*WSConn]bool)}
+	}
+	limit := config.Frontend.MaxSpectators
+	if limit <= 0 || room.admitted[conn] || (len(room.admitted) < limit && len(room.queue) == 0) {
+		if err := spectators.AddFrontendConnection(debateID, token, sub, conn); err != nil {
+			return 0, err
+		}
+		room.admitted[conn] = true
+		r.rooms[debateID] = room
+		return 0, nil
+	}
+
+	for i, seat := range room.queue {
+		if seat.conn == conn {
+			seat.token, seat.sub = token, sub
+			return i + 1, nil
+		}
+	}
+	debate, err := db.GetDebate(debateID)
+	if err != nil || isFinished(debate.Status) {
+		return 0, errDebateNotFound
+	}
+	if err := checkSpectatorAccess(debate, token); err != nil {
+		return 0, err
+	}
+	room.queue = append(room.queue, &roomSeat{conn: conn, token: token, sub: sub})
+	r.rooms[debateID] = room
+	log.Printf("Debate %s is full (%d spectators), queued %s at position %d", debateID, limit, conn.RemoteAddr(), len(room.queue))
+	return len(room.queue), nil
+}
+
+// Leave unsubscribes or dequeues a spectator, admitting the next queued
+// spectator when a place frees up
+func (r *SpectatorRooms) Leave(debateID string, conn *WSConn) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	room := r.rooms[debateID]
+	if room == nil {
+		spectators.RemoveFrontendConnection(debateID, conn)
+		return
+	}
+	if room.admitted[conn] {
+		delete(room.admitted, conn)
+		spectators.RemoveFrontendConnection(debateID, conn)
+		r.admit(debateID, room)
+	} else {
+		for i, seat := range room.queue {
+			if seat.conn == conn {
+				room.queue = append(room.queue[:i], room.queue[i+1:]...)
+				r.notifyQueue(debateID, room, i)
+				break
+			}
+		}
+	}
+	if len(room.admitted) == 0 && len(room.queue) == 0 {
+		delete(r.rooms, debateID)
+	}
+}
+
+// admit moves queued spectators into free places; spectators of a debate
+// that has meanwhile finished get its final state instead
+func (r *SpectatorRooms) admit(debateID string, room *spectatorRoom) {
+	admitted := 0
+	for len(room.queue) > 0 && len(room.admitted) < config.Frontend.MaxSpectators {
+		seat := room.queue[0]
+		room.queue = room.queue[1:]
+		admitted++
+		if err := spectators.AddFrontendConnection(debateID, seat.token, seat.sub, seat.conn); err != nil {
+			sendCurrentDebateState(seat.conn, debateID, seat.sub.Language)
+			continue
+		}
+		room.admitted[seat.conn] = true
+		log.Printf("Admitted queued spectator %s to debate %s", seat.conn.RemoteAddr(), debateID)
+		sendCurrentDebateState(seat.conn, debateID, seat.sub.Language)
+		sendChatHistory(seat.conn, debateID, seat.sub)
+	}
+	if admitted > 0 {
+		r.notifyQueue(debateID, room, 0)
+	}
+}
+
+// notifyQueue sends room_full to queued spectators from index from on, whose
+// positions have changed
+func (r *SpectatorRooms) notifyQueue(debateID string, room *spectatorRoom, from int) {
+	for i := from; i < len(room.queue); i++ {
+		room.queue[i].conn.WriteJSON(createMessage("room_full", roomFull(debateID, i+1)))
+	}
+}
+
+// roomFull builds the room_full payload for a queue position
+func roomFull(debateID string, position int) RoomFull {
+	return RoomFull{
+		DebateID:      debateID,
+		Position:      position,
+		MaxSpectators: config.Frontend.MaxSpectators,
+		Message:       fmt.Sprintf("This debate has reached %d spectators, you are number %d in the queue", config.Frontend.MaxSpectators, position),
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GET /api/debate/{id}/state?since=<seq> lets simple clients follow a debate
+// by polling. Every broadcast of a live debate is numbered in order; the
+// response carries the events numbered after since and the latest number,
+// to pass as since next time. Without since, or when the events after since
+// are no longer kept (a live debate keeps its last liveStateBuffer), the
+// response carries a debate_snapshot to start over from instead. With
+// wait=<seconds>, up to maxStateWait, the request long-polls: it returns as
+// soon as there are newer events, or empty-handed when the wait is over.
+// The query also takes the subscribe_debate options token, events and lang.
+// Snapshots and events are redacted like every public view of a debate.
+// Debates not live on this instance, finished or served by a replica, are
+// always answered with their snapshot; stop polling once the status is
+// final.
+
+// liveStateBuffer is how many recent events a live debate keeps for polling clients
+const liveStateBuffer = 256
+
+// maxStateWait caps how long a state request long-polls
+const maxStateWait = 25 * time.Second
+
+// SequencedEvent is a broadcast numbered within its debate
+type SequencedEvent struct {
+	Seq     int64   `json:"seq"` // 1-based order within the debate
+	Message Message `json:"message"`
+}
+
+// EventLog numbers a live debate's broadcasts and keeps the latest
+type EventLog struct {
+	mutex   sync.Mutex
+	seq     int64
+	events  []SequencedEvent // Oldest first, at most liveStateBuffer
+	changed chan struct{}    // Closed and replaced on every append
+}
+
+// newEventLog creates an empty event log
+func newEventLog() *EventLog {
+	return &EventLog{changed: make(chan struct{})}
+}
+
+// Append numbers a broadcast and wakes long-polling clients
+func (l *EventLog) Append(msg Message) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.seq++
+	l.events = append(l.events, SequencedEvent{Seq: l.seq, Message: msg})
+	if len(l.events) > liveStateBuffer {
+		l.events = l.events[len(l.events)-liveStateBuffer:]
+	}
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// Since returns the events numbered after seq and the latest number.
+// complete is false when some of those events are no longer kept, or seq is
+// from elsewhere; changed is closed on the next append.
+func (l *EventLog) Since(seq int64) (events []SequencedEvent, latest int64, complete bool, changed <-chan struct{}) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if seq < 0 || seq > l.seq || (len(l.events) > 0 && seq < l.events[0].Seq-1) {
+		return nil, l.seq, false, l.changed
+	}
+	for _, event := range l.events {
+		if event.Seq > seq {
+			events = append(events, event)
+		}
+	}
+	return events, l.seq, true, l.changed
+}
+
+// StateUpdate is the response of GET /api/debate/{id}/state
+type StateUpdate struct {
+	DebateID string           `json:"debate_id"`
+	Status   string           `json:"status"`
+	Seq      int64            `json:"seq"` // Pass as since to get later events
+	Events   []SequencedEvent `json:"events"`
+	Snapshot *Message         `json:"snapshot,omitempty"` // Set when the client must start over from the debate's current state
+	Live     *LiveState       `json:"live,omitempty"`     // Set while the debate runs on this instance
+}
+
+// eventLog returns the event log of a debate live on this instance
+func (dm *DebateManager) eventLog(debateID string) *EventLog {
+	dm.mutex.RLock()
+	defer dm.mutex.RUnlock()
+	if activeDebate, exists := dm.debates[debateID]; exists {
+		return activeDebate.events
+	}
+	return nil
+}
+
+// handleDebateState handles GET /api/debate/{id}/state?since=<seq>[&wait=<seconds>]
+func handleDebateState(w http.ResponseWriter, r *http.Request, debateID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	since := int64(-1) // No since: start from the snapshot
+	if value := query.Get("since"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || parsed < 0 {
+			http.Error(w, "since must be a non-negative sequence number", http.StatusBadRequest)
+			return
+		}
+		since = parsed
+	}
+	var wait time.Duration
+	if value := query.Get("wait"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds < 0 {
+			http.Error(w, "wait must be a non-negative number of seconds", http.StatusBadRequest)
+			return
+		}
+		wait = min(time.Duration(seconds)*time.Second, maxStateWait)
+	}
+	var events []string
+	if value := query.Get("events"); value != "" {
+		events = strings.Split(value, ",")
+	}
+	filter, err := newEventFilter(events)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sub := &Subscriber{Filter: filter, Language: query.Get("lang")}
+
+	debate, err := db.GetDebate(debateID)
+	if err != nil || checkSpectatorAccess(debate, query.Get("token")) != nil {
+		// Don't reveal that a private debate exists
+		http.Error(w, "Debate not found", http.StatusNotFound)
+		return
+	}
+
+	var eventLog *EventLog
+	if !isReplica() && !isFinished(debate.Status) {
+		eventLog = debateManager.eventLog(debateID)
+	}
+	state := StateUpdate{DebateID: debateID, Status: debate.Status, Events: []SequencedEvent{}}
+	if eventLog == nil {
+		debate, state.Snapshot = currentDebateState(debateID)
+		if debate == nil {
+			http.Error(w, "Debate not found", http.StatusNotFound)
+			return
+		}
+		state.Status = debate.Status
+		localized := redactor.Message(localizeMessage(debate, *state.Snapshot, sub.Language))
+		state.Snapshot = &localized
+		writeJSON(w, state)
+		return
+	}
+
+	deadline := time.NewTimer(wait)
+	defer deadline.Stop()
+	for {
+		newer, latest, complete, changed := eventLog.Since(since)
+		state.Seq = latest
+		if !complete || since < 0 {
+			// seq was read before the snapshot is built, so an event in
+			// between is sent again rather than missed
+			debate, state.Snapshot = currentDebateState(debateID)
+			if debate == nil {
+				http.Error(w, "Debate not found", http.StatusNotFound)
+				return
+			}
+			localized := redactor.Message(localizeMessage(debate, *state.Snapshot, sub.Language))
+			state.Status, state.Snapshot = debate.Status, &localized
+			break
+		}
+		for _, event := range newer {
+			if sub.Wants(event.Message) {
+				event.Message = redactor.Message(localizeMessage(debate, event.Message, sub.Language))
+				state.Events = append(state.Events, event)
+			}
+		}
+		if len(state.Events) > 0 || wait == 0 {
+			break
+		}
+		// Nothing the client wants yet: wait for more, skipping what was seen
+		since = latest
+		select {
+		case <-changed:
+			continue
+		case <-deadline.C:
+		case <-r.Context().Done():
+			return
+		}
+		break
+	}
+	if live, ok := debateManager.LiveState(debateID); ok {
+		state.Status, state.Live = live.Status, live
+	}
+	writeJSON(w, state)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// AdminStats is the payload of the admin stats endpoint
+type AdminStats struct {
+	ClientVersions map[string]int   `json:"client_versions"` // Bot logins per reported client version
+	LLMBudget      BudgetStatus     `json:"llm_budget"`
+	SideBias       *SideBiasReport  `json:"side_bias"`
+	TopBots        []BotStats       `json:"top_bots"`   // Ranked debates, most wins first
+	Daily          []DailyStats     `json:"daily"`      // Last 30 days
+	Spectators     []SpectatorStats `json:"spectators"` // Debates watched in the last 30 days, most peak viewers first
+}
+
+// handleAdminStats returns operational statistics for administrators
+func handleAdminStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	versions, err := db.GetClientVersionCounts()
+	if err != nil {
+		http.Error(w, "Failed to fetch stats", http.StatusInternalServerError)
+		return
+	}
+
+	sideBias, err := computeSideBias()
+	if err != nil {
+		http.Error(w, "Failed to fetch stats", http.StatusInternalServerError)
+		return
+	}
+
+	topBots, err := db.GetBotStats(20)
+	if err != nil {
+		http.Error(w, "Failed to fetch stats", http.StatusInternalServerError)
+		return
+	}
+	daily, err := db.GetDailyStats(time.Now().AddDate(0, 0, -30))
+	if err != nil {
+		http.Error(w, "Failed to fetch stats", http.StatusInternalServerError)
+		return
+	}
+
+	spectatorStats, err := db.GetSpectatorStats(time.Now().AddDate(0, 0, -30), 50)
+	if err != nil {
+		http.Error(w, "Failed to fetch stats", http.StatusInternalServerError)
+		return
+	}
+
+	stats := AdminStats{
+		ClientVersions: versions,
+		LLMBudget:      llmBudget.Status(),
+		SideBias:       sideBias,
+		TopBots:        topBots,
+		Daily:          daily,
+		Spectators:     spectatorStats,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// GET /api/stats/timeseries returns debate results aggregated into day, week
+// or month buckets for charting trends. Each metric is one SQL query whose
+// window functions add a running total or a moving average over the buckets.
+// Series are cached in memory for stats.timeseries.cache_ttl seconds, so a
+// busy stats page doesn't scan debate_results on every load. Buckets without
+// results are left out.
+
+// Timeseries intervals
+const (
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+)
+
+// timeseriesBuckets maps an interval to the SQL expression of the first day
+// of the bucket a result falls in. Weeks start on Monday.
+var timeseriesBuckets = map[string]string{
+	IntervalDay:   "date(r.created_at)",
+	IntervalWeek:  "date(r.created_at, '-6 days', 'weekday 1')",
+	IntervalMonth: "date(r.created_at, 'start of month')",
+}
+
+// movingAverageBuckets is how many buckets, up to the current one, moving averages span
+const movingAverageBuckets = 7
+
+// timeseriesMetric is the query behind one metric. The query selects the
+// bucket and then one value per column; %[1]s is the bucket expression and
+// %[2]d the moving average's preceding buckets.
+type timeseriesMetric struct {
+	columns []string
+	query   string
+}
+
+var timeseriesMetrics = map[string]timeseriesMetric{
+	// Results produced per bucket and the running total over the range
+	"debates": {
+		columns: []string{"debates", "ranked", "draws", "cumulative"},
+		query: `WITH buckets AS (
+			SELECT %[1]s AS bucket, COUNT(*) AS debates, SUM(d.ranked) AS ranked, SUM(r.winner = 'draw') AS draws
+			FROM debate_results r JOIN debates d ON d.id = r.debate_id
+			WHERE r.created_at >= ?
+			GROUP BY bucket)
+		SELECT bucket, debates, ranked, draws, SUM(debates) OVER (ORDER BY bucket)
+		FROM buckets ORDER BY bucket`,
+	},
+	// Average scores of each side, with their moving averages
+	"avg_scores": {
+		columns: []string{"supporting", "opposing", "supporting_moving_avg", "opposing_moving_avg"},
+		query: `WITH buckets AS (
+			SELECT %[1]s AS bucket, AVG(r.supporting_score) AS supporting, AVG(r.opposing_score) AS opposing
+			FROM debate_results r
+			WHERE r.created_at >= ?
+			GROUP BY bucket)
+		SELECT bucket, supporting, opposing,
+			AVG(supporting) OVER (ORDER BY bucket ROWS BETWEEN %[2]d PRECEDING AND CURRENT ROW),
+			AVG(opposing) OVER (ORDER BY bucket ROWS BETWEEN %[2]d PRECEDING AND CURRENT ROW)
+		FROM buckets ORDER BY bucket`,
+	},
+	// How long AI judgements took, in milliseconds; results no judge was called for are left out
+	"judge_latency": {
+		columns: []string{"judged", "avg_ms", "max_ms", "avg_ms_moving_avg"},
+		query: `WITH buckets AS (
+			SELECT %[1]s AS bucket, COUNT(*) AS judged, AVG(r.judge_ms) AS avg_ms, MAX(r.judge_ms) AS max_ms
+			FROM debate_results r
+			WHERE r.created_at >= ? AND r.judge_ms > 0
+			GROUP BY bucket)
+		SELECT bucket, judged, avg_ms, max_ms,
+			AVG(avg_ms) OVER (ORDER BY bucket ROWS BETWEEN %[2]d PRECEDING AND CURRENT ROW)
+		FROM buckets ORDER BY bucket`,
+	},
+}
+
+// TimeseriesPoint is one bucket of a series
+type TimeseriesPoint struct {
+	Bucket string             `json:"bucket"` // First day of the bucket, YYYY-MM-DD (UTC)
+	Values map[string]float64 `json:"values"`
+}
+
+// Timeseries is the response of GET /api/stats/timeseries
+type Timeseries struct {
+	Metric     string            `json:"metric"`
+	Interval   string            `json:"interval"`
+	Since      string            `json:"since"` // First day covered, YYYY-MM-DD (UTC)
+	Points     []TimeseriesPoint `json:"points"`
+	ComputedAt time.Time         `json:"computed_at"`
+}
+
+// GetTimeseries aggregates debate results since a day into buckets
+func (d *Database) GetTimeseries(metric, interval string, since time.Time) ([]TimeseriesPoint, error) {
+	m := timeseriesMetrics[metric]
+	query := fmt.Sprintf(m.query, timeseriesBuckets[interval], movingAverageBuckets-1)
+	rows, err := d.db.Query(query, since.UTC().Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := []TimeseriesPoint{}
+	for rows.Next() {
+		var bucket string
+		values := make([]float64, len(m.columns))
+		dest := []interface{}{&bucket}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		point := TimeseriesPoint{Bucket: bucket, Values: make(map[string]float64, len(m.columns))}
+		for i, column := range m.columns {
+			point.Values[column] = values[i]
+		}
+		points = append(points, point)
+	}
+	return points, rows.Err()
+}
+
+// TimeseriesCache keeps computed series for stats.timeseries.cache_ttl
+type TimeseriesCache struct {
+	mutex   sync.Mutex
+	entries map[string]*Timeseries
+}
+
+var timeseriesCache = &TimeseriesCache{entries: make(map[string]*Timeseries)}
+
+// Get returns the series for a request, computing it when it is not cached
+// or has expired
+func (c *TimeseriesCache) Get(metric, interval string, days int) (*Timeseries, error) {
+	key := fmt.Sprintf("%s/%s/%d", metric, interval, days)
+	ttl := time.Duration(config.Stats.Timeseries.CacheTTL) * time.Second
+
+	c.mutex.Lock()
+	cached, ok := c.entries[key]
+	c.mutex.Unlock()
+	if ok && time.Since(cached.ComputedAt) < ttl {
+		return cached, nil
+	}
+
+	now := time.Now().UTC()
+	since := now.AddDate(0, 0, -days+1)
+	points, err := db.GetTimeseries(metric, interval, since)
+	if err != nil {
+		return nil, err
+	}
+	series := &Timeseries{
+		Metric:     metric,
+		Interval:   interval,
+		Since:      since.Format("2006-01-02"),
+		Points:     points,
+		ComputedAt: now,
+	}
+
+	c.mutex.Lock()
+	// Drop expired series so the cache stays as small as the requests made within a TTL
+	for k, s := range c.entries {
+		if time.Since(s.ComputedAt) >= ttl {
+			delete(c.entries, k)
+		}
+	}
+	if ttl > 0 {
+		c.entries[key] = series
+	}
+	c.mutex.Unlock()
+	return series, nil
+}
+
+// handleStatsTimeseries handles GET /api/stats/timeseries?metric=&interval=&days=
+func handleStatsTimeseries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	metric := query.Get("metric")
+	if _, known := timeseriesMetrics[metric]; !known {
+		http.Error(w, "metric must be debates, avg_scores or judge_latency", http.StatusBadRequest)
+		return
+	}
+	interval := query.Get("interval")
+	if interval == "" {
+		interval = IntervalDay
+	}
+	if _, known := timeseriesBuckets[interval]; !known {
+		http.Error(w, "interval must be day, week or month", http.StatusBadRequest)
+		return
+	}
+	days := config.Stats.Timeseries.DefaultDays
+	if value := query.Get("days"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > config.Stats.Timeseries.MaxDays {
+			http.Error(w, fmt.Sprintf("days must be between 1 and %d", config.Stats.Timeseries.MaxDays), http.StatusBadRequest)
+			return
+		}
+		days = n
+	}
+
+	series, err := timeseriesCache.Get(metric, interval, days)
+	if err != nil {
+		http.Error(w, "Failed to fetch stats", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, series)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/websocket"
+)
+
+// Subprotocol names follow botdebate.v<version>.<encoding>
+const SubprotocolV1JSON = "botdebate.v1.json"
+
+var errUnsupportedSubprotocol = errors.New("client offered no supported subprotocol")
+
+// supportedSubprotocols lists the negotiable subprotocols in order of preference
+var supportedSubprotocols = []string{SubprotocolV1JSON}
+
+// negotiateSubprotocol checks the subprotocols a client offered. Clients that
+// offer none are accepted as legacy v1 JSON; clients that offer only unknown
+// ones are rejected before the upgrade so the mismatch is visible in the handshake.
+func negotiateSubprotocol(r *http.Request) bool {
+	offered := websocket.Subprotocols(r)
+	if len(offered) == 0 {
+		return true
+	}
+	for _, name := range offered {
+		for _, supported := range supportedSubprotocols {
+			if name == supported {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// upgradeWithSubprotocol negotiates the subprotocol and upgrades the connection.
+// On a mismatch it answers 400 with the supported list in Sec-WebSocket-Protocol.
+func upgradeWithSubprotocol(w http.ResponseWriter, r *http.Request) (*WSConn, error) {
+	if !negotiateSubprotocol(r) {
+		w.Header().Set("Sec-WebSocket-Protocol", strings.Join(supportedSubprotocols, ", "))
+		http.Error(w, "Unsupported subprotocol; supported: "+strings.Join(supportedSubprotocols, ", "), http.StatusBadRequest)
+		return nil, errUnsupportedSubprotocol
+	}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return nil, err
+	}
+	return newWSConn(conn), nil
+}
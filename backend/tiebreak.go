@@ -0,0 +1,91 @@
+package main
+
+import (
+	"log"
+)
+
+// Debates in their tiebreak round use the "overtime" status
+const StatusOvertime = "overtime"
+
+// isInProgress reports whether a debate status means bots are still speaking
+func isInProgress(status string) bool {
+	return status == "active" || status == StatusOvertime
+}
+
+// needsTiebreak reports whether a completed debate's result is close enough
+// to play one extra round. Only one tiebreak round is ever played.
+func (dm *DebateManager) needsTiebreak(activeDebate *ActiveDebate, result *DebateResult) bool {
+	if !config.Debate.Tiebreak.Enabled || activeDebate.Tiebreak != nil {
+		return false
+	}
+	// "none" is the fallback scorer's undecided result, not a judged draw
+	if result.Winner == "none" {
+		return false
+	}
+	if result.Winner == "draw" {
+		return true
+	}
+	diff := result.SupportingScore - result.OpposingScore
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff <= config.Debate.Tiebreak.Margin
+}
+
+// startTiebreak adds a sudden-death round after a drawn or too-close judgement.
+// The debate is judged again, including the extra round, when it completes.
+func (dm *DebateManager) startTiebreak(activeDebate *ActiveDebate, initial *DebateResult) {
+	debateID := activeDebate.Debate.ID
+
+	reason := "close_scores"
+	if initial.Winner == "draw" {
+		reason = "draw"
+	}
+
+	activeDebate.mutex.Lock()
+	activeDebate.Debate.TotalRounds++
+	activeDebate.Debate.CurrentRound = activeDebate.Debate.TotalRounds
+	activeDebate.Debate.Status = StatusOvertime
+	activeDebate.ending = false
+	activeDebate.LastSpeaker = ""
+	activeDebate.PendingSpeeches = make(map[string]DebateLogEntry)
+	activeDebate.Tiebreak = &TiebreakInfo{
+		Round:                  activeDebate.Debate.TotalRounds,
+		Reason:                 reason,
+		InitialWinner:          initial.Winner,
+		InitialSupportingScore: initial.SupportingScore,
+		InitialOpposingScore:   initial.OpposingScore,
+	}
+	round := activeDebate.Debate.CurrentRound
+	activeDebate.mutex.Unlock()
+
+	dm.db.StartOvertime(debateID, round)
+
+	overtimeMsg := createMessage("debate_overtime", DebateOvertime{
+		DebateID:        debateID,
+		Round:           round,
+		Reason:          reason,
+		SupportingScore: initial.SupportingScore,
+		OpposingScore:   initial.OpposingScore,
+	})
+	for _, bot := range activeDebate.Bots {
+		bot.Conn.WriteJSON(overtimeMsg)
+	}
+	dm.broadcast <- BroadcastMessage{DebateID: debateID, Message: overtimeMsg}
+
+	log.Printf("Debate %s goes to tiebreak round %d (%s, %d:%d)",
+		debateID, round, reason, initial.SupportingScore, initial.OpposingScore)
+
+	dm.startInactivityTimer(debateID)
+	dm.startMaxDurationTimer(debateID)
+
+	if activeDebate.Debate.Format == FormatSimultaneous {
+		dm.sendSimultaneousUpdate(activeDebate)
+		dm.startRoundDeadline(debateID, round)
+		return
+	}
+
+	first := activeDebate.Bots[0].Bot.BotIdentifier
+	dm.sendDebateUpdate(activeDebate, first)
+	dm.startTimeout(debateID, first)
+}
@@ -0,0 +1,532 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// POST /api/topics/generate asks the LLM to propose debate topics in a
+// category at a difficulty. Every new suggestion is stored in the topics
+// table as suggested, and an admin accepts or rejects it under
+// /api/admin/topics/{id}, where topics can also be added by hand. With
+// debate.matchmaking.topic_pool, accepted topics join
+// debate.matchmaking.topics in the matchmaking pool, and a match on one of
+// them takes its category.
+
+// Topic statuses
+const (
+	TopicSuggested = "suggested"
+	TopicAccepted  = "accepted"
+	TopicRejected  = "rejected"
+)
+
+// Topic difficulties
+const (
+	DifficultyEasy   = "easy"
+	DifficultyMedium = "medium"
+	DifficultyHard   = "hard"
+)
+
+// Where a topic came from
+const (
+	TopicSourceAI    = "ai"
+	TopicSourceAdmin = "admin"
+)
+
+// topicsMaxTokens and topicsTemperature tune the topic generation calls;
+// suggestions should vary between requests
+const (
+	topicsMaxTokens   = 1200
+	topicsTemperature = 0.9
+)
+
+// topicsAvoidLimit is how many existing topics of the category the prompt
+// asks the model not to repeat
+const topicsAvoidLimit = 30
+
+// topicGenerator proposes debate topics; nil when chatgpt.topics is disabled
+var topicGenerator *ChatGPTClient
+
+// topicPoolTTL is how long the accepted topics are cached for matchmaking,
+// which looks at the pool on every login without a debate; changes made on
+// another instance reach this one's pool within it
+const topicPoolTTL = time.Minute
+
+// topicPool caches the accepted topics
+var topicPool struct {
+	mutex  sync.Mutex
+	topics []*DebateTopic
+	loaded time.Time
+}
+
+// DebateTopic is a debate topic in the topic pool
+type DebateTopic struct {
+	ID         string    `json:"id"`
+	Topic      string    `json:"topic"`
+	Category   string    `json:"category,omitempty"`
+	Difficulty string    `json:"difficulty,omitempty"`
+	Rationale  string    `json:"rationale,omitempty"` // Why the model thinks the topic debates well
+	Status     string    `json:"status"`              // suggested, accepted or rejected
+	Source     string    `json:"source"`              // ai or admin
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// GenerateTopicsRequest asks for topic suggestions
+type GenerateTopicsRequest struct {
+	Category   string `json:"category,omitempty"`   // e.g. "technology"; any category when empty
+	Difficulty string `json:"difficulty,omitempty"` // easy, medium (default) or hard
+	Count      int    `json:"count,omitempty"`      // Defaults to 5, at most chatgpt.topics.max_suggestions
+}
+
+// GenerateTopicsResponse lists the stored suggestions; proposals repeating a
+// topic already in the pool are dropped
+type GenerateTopicsResponse struct {
+	Suggestions []*DebateTopic `json:"suggestions"`
+	Duplicates  int            `json:"duplicates"`
+}
+
+// validDifficulty reports whether a difficulty is known
+func validDifficulty(difficulty string) bool {
+	switch difficulty {
+	case DifficultyEasy, DifficultyMedium, DifficultyHard:
+		return true
+	}
+	return false
+}
+
+// difficultyNames describes each difficulty to the model
+var difficultyNames = map[string]string{
+	DifficultyEasy:   "简单：贴近日常生活，普通人无需专业知识即可展开论证",
+	DifficultyMedium: "中等：需要一定的背景知识，正反双方都有充分的论证空间",
+	DifficultyHard:   "困难：涉及专业知识或深层价值冲突，需要严谨的论证和取舍",
+}
+
+// topicsPrompt is the system prompt of the topic generator
+const topicsPrompt = `你是一位经验丰富的辩论赛命题人。请根据要求提出新的辩题。
+
+好的辩题应当：表述为一个明确的命题，正反双方都有充分的论证空间；不带倾向性用语；不涉及人身攻击或违法内容；一句话即可说清。
+
+请按以下JSON格式返回:
+{
+  "topics": [
+    {"topic": "辩题", "rationale": "一句话说明这个辩题为什么适合辩论"}
+  ]
+}`
+
+// GenerateTopics asks the model for count debate topics, avoiding the given ones
+func (c *ChatGPTClient) GenerateTopics(req *GenerateTopicsRequest, avoid []string) ([]*DebateTopic, error) {
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "请提出%d个辩题。\n", req.Count)
+	if req.Category != "" {
+		fmt.Fprintf(&prompt, "分类: %s\n", req.Category)
+	}
+	fmt.Fprintf(&prompt, "难度: %s\n", difficultyNames[req.Difficulty])
+	if len(avoid) > 0 {
+		prompt.WriteString("\n请不要与以下已有辩题重复:\n")
+		for _, topic := range avoid {
+			fmt.Fprintf(&prompt, "- %s\n", topic)
+		}
+	}
+
+	response, err := c.SendMessage([]ChatGPTMessage{
+		{Role: "system", Content: topicsPrompt},
+		{Role: "user", Content: prompt.String()},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get topics: %w", err)
+	}
+
+	startIdx := strings.Index(response, "{")
+	endIdx := strings.LastIndex(response, "}")
+	if startIdx == -1 || endIdx < startIdx {
+		return nil, fmt.Errorf("no JSON found in response")
+	}
+	var parsed struct {
+		Topics []struct {
+			Topic     string `json:"topic"`
+			Rationale string `json:"rationale"`
+		} `json:"topics"`
+	}
+	if err := json.Unmarshal([]byte(response[startIdx:endIdx+1]), &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	topics := []*DebateTopic{}
+	for _, suggestion := range parsed.Topics {
+		topic := strings.TrimSpace(suggestion.Topic)
+		if topic == "" {
+			continue
+		}
+		topics = append(topics, &DebateTopic{
+			Topic:      topic,
+			Category:   req.Category,
+			Difficulty: req.Difficulty,
+			Rationale:  strings.TrimSpace(suggestion.Rationale),
+		})
+		if len(topics) == req.Count {
+			break
+		}
+	}
+	if len(topics) == 0 {
+		return nil, fmt.Errorf("no topics in response")
+	}
+	return topics, nil
+}
+
+// handleGenerateTopics handles POST /api/topics/generate
+func handleGenerateTopics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !config.ChatGPT.Topics.Enabled {
+		http.Error(w, "Topic generator disabled", http.StatusNotFound)
+		return
+	}
+	if topicGenerator == nil || topicGenerator.APIKey == "" || topicGenerator.APIKey == "your-api-key-here" {
+		http.Error(w, "Topic generator not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req GenerateTopicsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	req.Category = strings.ToLower(strings.TrimSpace(req.Category))
+	if req.Difficulty == "" {
+		req.Difficulty = DifficultyMedium
+	}
+	if !validDifficulty(req.Difficulty) {
+		http.Error(w, "Difficulty must be easy, medium or hard", http.StatusBadRequest)
+		return
+	}
+	if req.Count == 0 {
+		req.Count = min(5, config.ChatGPT.Topics.MaxSuggestions)
+	}
+	if req.Count < 0 || req.Count > config.ChatGPT.Topics.MaxSuggestions {
+		http.Error(w, fmt.Sprintf("Count must be between 1 and %d", config.ChatGPT.Topics.MaxSuggestions), http.StatusBadRequest)
+		return
+	}
+
+	existing, err := db.GetTopics("", req.Category)
+	if err != nil {
+		http.Error(w, "Failed to fetch topics", http.StatusInternalServerError)
+		return
+	}
+	var avoid []string
+	for _, topic := range existing {
+		if len(avoid) == topicsAvoidLimit {
+			break
+		}
+		avoid = append(avoid, topic.Topic)
+	}
+
+	suggestions, err := topicGenerator.forDebate("", UsageTopics).GenerateTopics(&req, avoid)
+	if errors.Is(err, errLLMBudget) {
+		http.Error(w, "LLM budget exhausted, try again later", http.StatusTooManyRequests)
+		return
+	} else if err != nil {
+		log.Printf("Topic generation failed: %v", err)
+		http.Error(w, "Failed to generate topics", http.StatusBadGateway)
+		return
+	}
+
+	resp := GenerateTopicsResponse{Suggestions: []*DebateTopic{}}
+	now := time.Now()
+	for _, topic := range suggestions {
+		topic.ID = "topic-" + uuid.New().String()[:8]
+		topic.Status = TopicSuggested
+		topic.Source = TopicSourceAI
+		topic.CreatedAt = now
+		topic.UpdatedAt = now
+		added, err := db.AddTopic(topic)
+		if err != nil {
+			log.Printf("Failed to store topic suggestion: %v", err)
+			http.Error(w, "Failed to store topics", http.StatusInternalServerError)
+			return
+		}
+		if !added {
+			resp.Duplicates++
+			continue
+		}
+		resp.Suggestions = append(resp.Suggestions, topic)
+	}
+
+	log.Printf("Generated %d topic suggestions (category %q, %s)", len(resp.Suggestions), req.Category, req.Difficulty)
+	writeJSON(w, resp)
+}
+
+// handleTopics handles /api/admin/topics (list, add) and
+// /api/admin/topics/{id} (get, update, delete). The list takes ?status= and
+// ?category= filters.
+func handleTopics(w http.ResponseWriter, r *http.Request) {
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/admin/topics"), "/")
+
+	if id == "" {
+		switch r.Method {
+		case http.MethodGet:
+			query := r.URL.Query()
+			topics, err := db.GetTopics(query.Get("status"), strings.ToLower(query.Get("category")))
+			if err != nil {
+				http.Error(w, "Failed to fetch topics", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, topics)
+		case http.MethodPost:
+			createTopic(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		topic, err := db.GetTopic(id)
+		if err != nil {
+			http.Error(w, "Topic not found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, topic)
+	case http.MethodPut:
+		updateTopic(w, r, id)
+	case http.MethodDelete:
+		if err := db.DeleteTopic(id); err == sql.ErrNoRows {
+			http.Error(w, "Topic not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			http.Error(w, "Failed to delete topic", http.StatusInternalServerError)
+			return
+		}
+		invalidateTopicPool()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// validate rejects topics the pool cannot hold
+func (t *DebateTopic) validate() error {
+	if t.Topic == "" {
+		return fmt.Errorf("topic is required")
+	}
+	if t.Difficulty != "" && !validDifficulty(t.Difficulty) {
+		return fmt.Errorf("difficulty must be easy, medium or hard")
+	}
+	switch t.Status {
+	case TopicSuggested, TopicAccepted, TopicRejected:
+	default:
+		return fmt.Errorf("status must be suggested, accepted or rejected")
+	}
+	return nil
+}
+
+// createTopic adds a topic by hand; it is accepted unless the request says otherwise
+func createTopic(w http.ResponseWriter, r *http.Request) {
+	var topic DebateTopic
+	if err := json.NewDecoder(r.Body).Decode(&topic); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	topic.Topic = strings.TrimSpace(topic.Topic)
+	topic.Category = strings.ToLower(strings.TrimSpace(topic.Category))
+	if topic.Status == "" {
+		topic.Status = TopicAccepted
+	}
+	if err := topic.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	topic.ID = "topic-" + uuid.New().String()[:8]
+	topic.Source = TopicSourceAdmin
+	topic.CreatedAt = time.Now()
+	topic.UpdatedAt = topic.CreatedAt
+
+	if added, err := db.AddTopic(&topic); err != nil {
+		http.Error(w, "Failed to create topic", http.StatusInternalServerError)
+		return
+	} else if !added {
+		http.Error(w, "Topic already exists", http.StatusConflict)
+		return
+	}
+
+	log.Printf("Debate topic added: %s (%s)", topic.ID, topic.Topic)
+	invalidateTopicPool()
+	writeJSONStatus(w, http.StatusCreated, topic)
+}
+
+// updateTopic changes a topic's fields; omitted fields are kept. Accepting
+// or rejecting a suggestion is an update of its status.
+func updateTopic(w http.ResponseWriter, r *http.Request, id string) {
+	existing, err := db.GetTopic(id)
+	if err != nil {
+		http.Error(w, "Topic not found", http.StatusNotFound)
+		return
+	}
+
+	var req DebateTopic
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if topic := strings.TrimSpace(req.Topic); topic != "" {
+		existing.Topic = topic
+	}
+	if category := strings.ToLower(strings.TrimSpace(req.Category)); category != "" {
+		existing.Category = category
+	}
+	if req.Difficulty != "" {
+		existing.Difficulty = req.Difficulty
+	}
+	if req.Rationale != "" {
+		existing.Rationale = req.Rationale
+	}
+	if req.Status != "" {
+		existing.Status = req.Status
+	}
+	if err := existing.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	existing.UpdatedAt = time.Now()
+
+	if err := db.UpdateTopic(existing); err != nil {
+		http.Error(w, "Failed to update topic", http.StatusConflict)
+		return
+	}
+	invalidateTopicPool()
+	writeJSON(w, existing)
+}
+
+// matchmakingTopics returns the matchmaking pool: debate.matchmaking.topics,
+// then with topic_pool the accepted topics
+func matchmakingTopics() []*DebateTopic {
+	pool := []*DebateTopic{}
+	for _, topic := range config.Debate.Matchmaking.Topics {
+		pool = append(pool, &DebateTopic{Topic: topic})
+	}
+	if !config.Debate.Matchmaking.TopicPool {
+		return pool
+	}
+	return append(pool, acceptedTopics()...)
+}
+
+// acceptedTopics returns the accepted topics, from the cache while it is
+// fresh; a failed load keeps the stale pool
+func acceptedTopics() []*DebateTopic {
+	topicPool.mutex.Lock()
+	defer topicPool.mutex.Unlock()
+	if time.Since(topicPool.loaded) < topicPoolTTL {
+		return topicPool.topics
+	}
+	accepted, err := db.GetTopics(TopicAccepted, "")
+	if err != nil {
+		log.Printf("Failed to load accepted topics: %v", err)
+		return topicPool.topics
+	}
+	topicPool.topics = accepted
+	topicPool.loaded = time.Now()
+	return accepted
+}
+
+// invalidateTopicPool makes the next matchmaking look reload the accepted topics
+func invalidateTopicPool() {
+	topicPool.mutex.Lock()
+	defer topicPool.mutex.Unlock()
+	topicPool.loaded = time.Time{}
+}
+
+// AddTopic stores a topic; false if the pool already has the same topic
+func (d *Database) AddTopic(t *DebateTopic) (bool, error) {
+	query := `INSERT OR IGNORE INTO topics (id, topic, category, difficulty, rationale, status, source, created_at, updated_at)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	res, err := d.db.Exec(query, t.ID, t.Topic, t.Category, t.Difficulty, t.Rationale, t.Status, t.Source, t.CreatedAt, t.UpdatedAt)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// UpdateTopic updates an existing topic
+func (d *Database) UpdateTopic(t *DebateTopic) error {
+	query := `UPDATE topics SET topic = ?, category = ?, difficulty = ?, rationale = ?, status = ?, updated_at = ? WHERE id = ?`
+	res, err := d.db.Exec(query, t.Topic, t.Category, t.Difficulty, t.Rationale, t.Status, t.UpdatedAt, t.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteTopic removes a topic
+func (d *Database) DeleteTopic(id string) error {
+	res, err := d.db.Exec(`DELETE FROM topics WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// topicColumns are the columns scanTopic reads
+const topicColumns = `id, topic, category, difficulty, rationale, status, source, created_at, updated_at`
+
+// scanTopic scans topicColumns
+func scanTopic(scan func(dest ...interface{}) error) (*DebateTopic, error) {
+	t := &DebateTopic{}
+	if err := scan(&t.ID, &t.Topic, &t.Category, &t.Difficulty, &t.Rationale, &t.Status, &t.Source, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// GetTopic returns a topic
+func (d *Database) GetTopic(id string) (*DebateTopic, error) {
+	return scanTopic(d.db.QueryRow(`SELECT `+topicColumns+` FROM topics WHERE id = ?`, id).Scan)
+}
+
+// GetTopics lists topics, newest first, optionally of one status and category
+func (d *Database) GetTopics(status, category string) ([]*DebateTopic, error) {
+	query := `SELECT ` + topicColumns + ` FROM topics WHERE 1 = 1`
+	var args []interface{}
+	if status != "" {
+		query += ` AND status = ?`
+		args = append(args, status)
+	}
+	if category != "" {
+		query += ` AND category = ?`
+		args = append(args, category)
+	}
+	rows, err := d.db.Query(query+` ORDER BY created_at DESC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	topics := []*DebateTopic{}
+	for rows.Next() {
+		t, err := scanTopic(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		topics = append(topics, t)
+	}
+	return topics, rows.Err()
+}
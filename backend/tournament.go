@@ -0,0 +1,565 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tournaments run single-elimination brackets over ordinary ranked debates.
+// Each match debate is reserved for its two bots: a bot logging in without a
+// debate_id is sent to its pending match and other bots cannot join it. When
+// a match debate ends its winner moves on to the next round. Bracket updates
+// are pushed to spectators subscribed on the instance that ran the match.
+
+// Tournament statuses
+const (
+	TournamentActive    = "active"
+	TournamentCompleted = "completed"
+)
+
+// Tournament match statuses
+const (
+	MatchPending   = "pending"   // Waiting for the winners of earlier matches
+	MatchScheduled = "scheduled" // Match debate created
+	MatchBye       = "bye"       // No opponent, the bot advances without debating
+	MatchCompleted = "completed"
+)
+
+// How a completed match was decided
+const (
+	DecidedByJudge    = "judge"    // The judge named a winner
+	DecidedByWalkover = "walkover" // Only one bot showed up
+	DecidedByScores   = "scores"   // No winner was named; the higher score advances
+	DecidedBySeed     = "seed"     // Scores were level too, or nobody debated; the better seed advances
+)
+
+// maxTournamentBots bounds the bracket size
+const maxTournamentBots = 64
+
+// tournamentMutex serializes bracket advancement on this instance
+var tournamentMutex sync.Mutex
+
+// TournamentEntrant is a bot entered into a tournament
+type TournamentEntrant struct {
+	Seed    int    `json:"seed"` // 1 is the strongest
+	BotName string `json:"bot_name"`
+	BotUUID string `json:"bot_uuid"`
+}
+
+// TournamentMatch is one pairing of the bracket
+type TournamentMatch struct {
+	TournamentID string `json:"-"`
+	Round        int    `json:"round"` // Bracket round, 1 is the first
+	Slot         int    `json:"slot"`  // Position within the round; slots 2n and 2n+1 feed slot n of the next round
+	BotA         string `json:"bot_a_uuid,omitempty"`
+	BotB         string `json:"bot_b_uuid,omitempty"`
+	DebateID     string `json:"debate_id,omitempty"`
+	Winner       string `json:"winner_uuid,omitempty"`
+	Status       string `json:"status"`
+	DecidedBy    string `json:"decided_by,omitempty"` // How the winner of a completed match was picked
+	Drawn        bool   `json:"drawn,omitempty"`      // The judge called the match debate a draw
+}
+
+// Tournament is a single-elimination bracket and its current state
+type Tournament struct {
+	ID            string              `json:"tournament_id"`
+	Name          string              `json:"name"`
+	Topic         string              `json:"topic"`
+	DebateRounds  int                 `json:"debate_rounds"` // Rounds of each match debate
+	BracketRounds int                 `json:"bracket_rounds"`
+	Status        string              `json:"status"`
+	Winner        string              `json:"winner_uuid,omitempty"`
+	Entrants      []TournamentEntrant `json:"entrants"`
+	Matches       []TournamentMatch   `json:"matches"` // By round, then slot
+	CreatedAt     time.Time           `json:"created_at"`
+	UpdatedAt     time.Time           `json:"updated_at"`
+}
+
+// CreateTournamentRequest enters bots into a new tournament
+type CreateTournamentRequest struct {
+	Name        string              `json:"name"`
+	Topic       string              `json:"topic"`
+	TotalRounds int                 `json:"total_rounds"` // Rounds of each match debate
+	Bots        []TournamentEntrant `json:"bots"`         // In seed order, strongest first; seeds are assigned from the order
+}
+
+// SubscribeTournament asks for a tournament's bracket state and its updates
+type SubscribeTournament struct {
+	TournamentID string `json:"tournament_id"`
+}
+
+// match returns the match at a bracket position
+func (t *Tournament) match(round, slot int) *TournamentMatch {
+	for i := range t.Matches {
+		if t.Matches[i].Round == round && t.Matches[i].Slot == slot {
+			return &t.Matches[i]
+		}
+	}
+	return nil
+}
+
+// seed returns an entrant's seed, 0 if the bot is not entered
+func (t *Tournament) seed(botUUID string) int {
+	for _, entrant := range t.Entrants {
+		if entrant.BotUUID == botUUID {
+			return entrant.Seed
+		}
+	}
+	return 0
+}
+
+// bracketOrder lists the seeds of a bracket of the given size in slot order,
+// so that the top seeds can only meet in the late rounds
+func bracketOrder(size int) []int {
+	order := []int{1, 2}
+	for len(order) < size {
+		next := make([]int, 0, len(order)*2)
+		for _, seed := range order {
+			next = append(next, seed, len(order)*2+1-seed)
+		}
+		order = next
+	}
+	return order
+}
+
+// newTournament seeds the entrants and lays out every match of the bracket.
+// Seeds beyond the number of entrants are byes.
+func newTournament(req *CreateTournamentRequest) *Tournament {
+	now := time.Now()
+	t := &Tournament{
+		ID:           "tournament-" + uuid.New().String()[:8],
+		Name:         req.Name,
+		Topic:        req.Topic,
+		DebateRounds: req.TotalRounds,
+		Status:       TournamentActive,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	for i, bot := range req.Bots {
+		t.Entrants = append(t.Entrants, TournamentEntrant{Seed: i + 1, BotName: bot.BotName, BotUUID: bot.BotUUID})
+	}
+
+	size := 2
+	t.BracketRounds = 1
+	for size < len(t.Entrants) {
+		size *= 2
+		t.BracketRounds++
+	}
+	order := bracketOrder(size)
+	for slot := 0; slot < size/2; slot++ {
+		match := TournamentMatch{TournamentID: t.ID, Round: 1, Slot: slot, Status: MatchPending}
+		if seed := order[2*slot]; seed <= len(t.Entrants) {
+			match.BotA = t.Entrants[seed-1].BotUUID
+		}
+		if seed := order[2*slot+1]; seed <= len(t.Entrants) {
+			match.BotB = t.Entrants[seed-1].BotUUID
+		}
+		t.Matches = append(t.Matches, match)
+	}
+	for round, matches := 2, size/4; round <= t.BracketRounds; round, matches = round+1, matches/2 {
+		for slot := 0; slot < matches; slot++ {
+			t.Matches = append(t.Matches, TournamentMatch{TournamentID: t.ID, Round: round, Slot: slot, Status: MatchPending})
+		}
+	}
+	return t
+}
+
+// recordMatchWinner decides a match and moves its winner into the next round,
+// or completes the tournament after the final. The caller sets how the match
+// was decided on match.
+func recordMatchWinner(t *Tournament, match *TournamentMatch, winner, status string) error {
+	if err := db.SetTournamentMatchWinner(t.ID, match.Round, match.Slot, winner, status, match.DecidedBy, match.Drawn); err != nil {
+		return err
+	}
+	match.Winner = winner
+	match.Status = status
+
+	if match.Round == t.BracketRounds {
+		t.Status = TournamentCompleted
+		t.Winner = winner
+		log.Printf("Tournament %s won by %s", t.ID, winner)
+		return db.CompleteTournament(t.ID, winner)
+	}
+
+	next := t.match(match.Round+1, match.Slot/2)
+	if match.Slot%2 == 0 {
+		next.BotA = winner
+	} else {
+		next.BotB = winner
+	}
+	return db.SetTournamentMatchBots(t.ID, next.Round, next.Slot, next.BotA, next.BotB)
+}
+
+// scheduleReadyMatches advances byes and creates the debates of matches
+// whose two bots are known
+func scheduleReadyMatches(t *Tournament) {
+	for i := range t.Matches {
+		match := &t.Matches[i]
+		if match.Status != MatchPending {
+			continue
+		}
+		// Only the first round has byes; later matches wait for both feeders
+		if match.Round == 1 && (match.BotA == "") != (match.BotB == "") {
+			winner := match.BotA + match.BotB
+			if err := recordMatchWinner(t, match, winner, MatchBye); err != nil {
+				log.Printf("Failed to record bye in tournament %s: %v", t.ID, err)
+			}
+			continue
+		}
+		if match.BotA == "" || match.BotB == "" {
+			continue
+		}
+
+		// Another instance may be scheduling the same match
+		claimed, err := db.ClaimTournamentMatch(t.ID, match.Round, match.Slot)
+		if err != nil || !claimed {
+			continue
+		}
+		debate, err := debateManager.CreateDebate(t.Topic, t.DebateRounds, DebateOptions{
+			Ranked:       true,
+			Format:       FormatSequential,
+			Scoring:      ScoringHolistic,
+			TournamentID: t.ID,
+		})
+		if err != nil {
+			log.Printf("Failed to create debate for tournament %s round %d slot %d: %v", t.ID, match.Round, match.Slot, err)
+			continue
+		}
+		if err := db.SetTournamentMatchDebate(t.ID, match.Round, match.Slot, debate.ID); err != nil {
+			log.Printf("Failed to link debate %s to tournament %s: %v", debate.ID, t.ID, err)
+			continue
+		}
+		match.Status = MatchScheduled
+		match.DebateID = debate.ID
+		log.Printf("Tournament %s round %d: %s vs %s in debate %s", t.ID, match.Round, match.BotA, match.BotB, debate.ID)
+	}
+}
+
+// decideMatchWinner picks the bot advancing from a finished match debate
+// and how it was picked: the judged winner, else the only bot that showed up,
+// else the higher score, else the better seed. A knockout match cannot end
+// level, so a judged draw goes to the score and then the seed tiebreaks.
+// result is nil when the debate never started.
+func decideMatchWinner(t *Tournament, match *TournamentMatch, result *DebateResult) (winner, decidedBy string) {
+	sides, joined := matchAttendance(match.DebateID)
+	if result != nil && sides[result.Winner] != "" {
+		return sides[result.Winner], DecidedByJudge
+	}
+	if joined[match.BotA] != joined[match.BotB] {
+		if joined[match.BotA] {
+			return match.BotA, DecidedByWalkover
+		}
+		return match.BotB, DecidedByWalkover
+	}
+	if result != nil && sides["supporting"] != "" && sides["opposing"] != "" && result.SupportingScore != result.OpposingScore {
+		if result.SupportingScore > result.OpposingScore {
+			return sides["supporting"], DecidedByScores
+		}
+		return sides["opposing"], DecidedByScores
+	}
+	if t.seed(match.BotB) < t.seed(match.BotA) {
+		return match.BotB, DecidedBySeed
+	}
+	return match.BotA, DecidedBySeed
+}
+
+// advanceTournament moves the winner of a finished match debate on through
+// the bracket. result is nil when the debate timed out before it started.
+func advanceTournament(debateID string, result *DebateResult) {
+	tournamentMutex.Lock()
+	defer tournamentMutex.Unlock()
+
+	match, err := db.GetTournamentMatchByDebate(debateID)
+	if err != nil || match.Status != MatchScheduled {
+		return
+	}
+	t, err := db.GetTournament(match.TournamentID)
+	if err != nil {
+		log.Printf("Failed to load tournament %s: %v", match.TournamentID, err)
+		return
+	}
+	match = t.match(match.Round, match.Slot)
+
+	winner, decidedBy := decideMatchWinner(t, match, result)
+	match.DecidedBy = decidedBy
+	match.Drawn = result != nil && result.Winner == "draw"
+	if match.Drawn {
+		log.Printf("Tournament %s round %d slot %d was drawn, %s advances on %s", t.ID, match.Round, match.Slot, winner, decidedBy)
+	}
+	if err := recordMatchWinner(t, match, winner, MatchCompleted); err != nil {
+		log.Printf("Failed to advance tournament %s: %v", t.ID, err)
+		return
+	}
+	scheduleReadyMatches(t)
+	tournamentSubscribers.Publish(t)
+}
+
+// TournamentHub tracks the spectator connections watching each tournament
+type TournamentHub struct {
+	mutex sync.Mutex
+	conns map[string]map[*WSConn]bool
+}
+
+var tournamentSubscribers = &TournamentHub{conns: make(map[string]map[*WSConn]bool)}
+
+// Subscribe adds a spectator connection to a tournament
+func (h *TournamentHub) Subscribe(tournamentID string, conn *WSConn) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.conns[tournamentID] == nil {
+		h.conns[tournamentID] = make(map[*WSConn]bool)
+	}
+	h.conns[tournamentID][conn] = true
+}
+
+// Unsubscribe removes a spectator connection from a tournament
+func (h *TournamentHub) Unsubscribe(tournamentID string, conn *WSConn) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	delete(h.conns[tournamentID], conn)
+	if len(h.conns[tournamentID]) == 0 {
+		delete(h.conns, tournamentID)
+	}
+}
+
+// Publish sends a tournament's bracket state to its spectators
+func (h *TournamentHub) Publish(t *Tournament) {
+	msg := createMessage("tournament_state", t)
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for conn := range h.conns[t.ID] {
+		if err := conn.WriteJSON(msg); err != nil {
+			log.Printf("Error sending tournament state: %v", err)
+		}
+	}
+}
+
+// handleCreateTournament handles POST /api/tournament/create
+func handleCreateTournament(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req CreateTournamentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	req.Topic = strings.TrimSpace(req.Topic)
+	if req.Topic == "" {
+		http.Error(w, "Topic is required", http.StatusBadRequest)
+		return
+	}
+	if req.TotalRounds <= 0 {
+		req.TotalRounds = 3
+	}
+	if err := validateRounds(req.TotalRounds); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.Bots) < 2 || len(req.Bots) > maxTournamentBots {
+		http.Error(w, fmt.Sprintf("A tournament needs between 2 and %d bots", maxTournamentBots), http.StatusBadRequest)
+		return
+	}
+	entered := map[string]bool{}
+	for _, bot := range req.Bots {
+		if bot.BotName == "" || len(bot.BotUUID) < 8 {
+			http.Error(w, "Every bot needs a bot_name and bot_uuid", http.StatusBadRequest)
+			return
+		}
+		if entered[bot.BotUUID] {
+			http.Error(w, fmt.Sprintf("Bot %s is entered twice", bot.BotUUID), http.StatusBadRequest)
+			return
+		}
+		entered[bot.BotUUID] = true
+	}
+	if req.Name == "" {
+		req.Name = req.Topic
+	}
+
+	t := newTournament(&req)
+	tournamentMutex.Lock()
+	err := db.CreateTournament(t)
+	if err == nil {
+		scheduleReadyMatches(t)
+	}
+	tournamentMutex.Unlock()
+	if err != nil {
+		log.Printf("Failed to create tournament: %v", err)
+		http.Error(w, "Failed to create tournament", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Tournament %s created with %d bots (%d bracket rounds)", t.ID, len(t.Entrants), t.BracketRounds)
+	writeJSONStatus(w, http.StatusCreated, t)
+}
+
+// handleTournamentRoutes handles GET /api/tournament/{id}
+func handleTournamentRoutes(w http.ResponseWriter, r *http.Request) {
+	tournamentID := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/tournament/"), "/")
+	if tournamentID == "" || strings.Contains(tournamentID, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	t, err := db.GetTournament(tournamentID)
+	if err != nil {
+		http.Error(w, "Tournament not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, t)
+}
+
+// CreateTournament stores a new tournament with its entrants and matches
+func (d *Database) CreateTournament(t *Tournament) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`INSERT INTO tournaments (id, name, topic, debate_rounds, bracket_rounds, status, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		t.ID, t.Name, t.Topic, t.DebateRounds, t.BracketRounds, t.Status, t.CreatedAt, t.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	for _, entrant := range t.Entrants {
+		_, err := tx.Exec(`INSERT INTO tournament_entrants (tournament_id, seed, bot_uuid, bot_name) VALUES (?, ?, ?, ?)`,
+			t.ID, entrant.Seed, entrant.BotUUID, entrant.BotName)
+		if err != nil {
+			return err
+		}
+	}
+	for _, match := range t.Matches {
+		_, err := tx.Exec(`INSERT INTO tournament_matches (tournament_id, round, slot, bot_a_uuid, bot_b_uuid, status) VALUES (?, ?, ?, ?, ?, ?)`,
+			t.ID, match.Round, match.Slot, match.BotA, match.BotB, match.Status)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetTournament loads a tournament with its entrants and matches
+func (d *Database) GetTournament(tournamentID string) (*Tournament, error) {
+	t := &Tournament{}
+	err := d.db.QueryRow(`SELECT id, name, topic, debate_rounds, bracket_rounds, status, winner_uuid, created_at, updated_at
+		FROM tournaments WHERE id = ?`, tournamentID).
+		Scan(&t.ID, &t.Name, &t.Topic, &t.DebateRounds, &t.BracketRounds, &t.Status, &t.Winner, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := d.db.Query(`SELECT seed, bot_uuid, bot_name FROM tournament_entrants WHERE tournament_id = ? ORDER BY seed`, tournamentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var entrant TournamentEntrant
+		if err := rows.Scan(&entrant.Seed, &entrant.BotUUID, &entrant.BotName); err != nil {
+			return nil, err
+		}
+		t.Entrants = append(t.Entrants, entrant)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	matchRows, err := d.db.Query(`SELECT tournament_id, round, slot, bot_a_uuid, bot_b_uuid, debate_id, winner_uuid, status, decided_by, drawn
+		FROM tournament_matches WHERE tournament_id = ? ORDER BY round, slot`, tournamentID)
+	if err != nil {
+		return nil, err
+	}
+	defer matchRows.Close()
+	for matchRows.Next() {
+		var match TournamentMatch
+		if err := matchRows.Scan(&match.TournamentID, &match.Round, &match.Slot, &match.BotA, &match.BotB,
+			&match.DebateID, &match.Winner, &match.Status, &match.DecidedBy, &match.Drawn); err != nil {
+			return nil, err
+		}
+		t.Matches = append(t.Matches, match)
+	}
+	return t, matchRows.Err()
+}
+
+// GetTournamentMatchByDebate returns the match played in a debate
+func (d *Database) GetTournamentMatchByDebate(debateID string) (*TournamentMatch, error) {
+	match := &TournamentMatch{}
+	err := d.db.QueryRow(`SELECT tournament_id, round, slot, bot_a_uuid, bot_b_uuid, debate_id, winner_uuid, status
+		FROM tournament_matches WHERE debate_id = ?`, debateID).
+		Scan(&match.TournamentID, &match.Round, &match.Slot, &match.BotA, &match.BotB, &match.DebateID, &match.Winner, &match.Status)
+	if err != nil {
+		return nil, err
+	}
+	return match, nil
+}
+
+// GetTournamentDebateForBot returns the oldest waiting match debate of a bot
+func (d *Database) GetTournamentDebateForBot(botUUID string) (string, error) {
+	var debateID string
+	err := d.db.QueryRow(`
+		SELECT m.debate_id FROM tournament_matches m
+		JOIN debates d ON d.id = m.debate_id
+		WHERE m.status = ? AND d.status = 'waiting' AND (m.bot_a_uuid = ? OR m.bot_b_uuid = ?)
+		ORDER BY d.created_at LIMIT 1`, MatchScheduled, botUUID, botUUID).Scan(&debateID)
+	return debateID, err
+}
+
+// SetTournamentMatchBots fills in the bots of a later-round match
+func (d *Database) SetTournamentMatchBots(tournamentID string, round, slot int, botA, botB string) error {
+	_, err := d.db.Exec(`UPDATE tournament_matches SET bot_a_uuid = ?, bot_b_uuid = ? WHERE tournament_id = ? AND round = ? AND slot = ?`,
+		botA, botB, tournamentID, round, slot)
+	return err
+}
+
+// ClaimTournamentMatch marks a ready match as scheduled. It reports false if
+// the match was already scheduled.
+func (d *Database) ClaimTournamentMatch(tournamentID string, round, slot int) (bool, error) {
+	res, err := d.db.Exec(`UPDATE tournament_matches SET status = ?
+		WHERE tournament_id = ? AND round = ? AND slot = ? AND status = ? AND bot_a_uuid != '' AND bot_b_uuid != ''`,
+		MatchScheduled, tournamentID, round, slot, MatchPending)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// SetTournamentMatchDebate links a scheduled match to its debate
+func (d *Database) SetTournamentMatchDebate(tournamentID string, round, slot int, debateID string) error {
+	_, err := d.db.Exec(`UPDATE tournament_matches SET debate_id = ? WHERE tournament_id = ? AND round = ? AND slot = ?`,
+		debateID, tournamentID, round, slot)
+	return err
+}
+
+// SetTournamentMatchWinner records the outcome of a match
+func (d *Database) SetTournamentMatchWinner(tournamentID string, round, slot int, winner, status, decidedBy string, drawn bool) error {
+	_, err := d.db.Exec(`UPDATE tournament_matches SET winner_uuid = ?, status = ?, decided_by = ?, drawn = ? WHERE tournament_id = ? AND round = ? AND slot = ?`,
+		winner, status, decidedBy, drawn, tournamentID, round, slot)
+	if err != nil {
+		return err
+	}
+	_, err = d.db.Exec(`UPDATE tournaments SET updated_at = ? WHERE id = ?`, time.Now(), tournamentID)
+	return err
+}
+
+// CompleteTournament records the tournament winner
+func (d *Database) CompleteTournament(tournamentID, winner string) error {
+	_, err := d.db.Exec(`UPDATE tournaments SET status = ?, winner_uuid = ?, updated_at = ? WHERE id = ?`,
+		TournamentCompleted, winner, time.Now(), tournamentID)
+	return err
+}
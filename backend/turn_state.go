@@ -0,0 +1,109 @@
+package main
+
+import (
+	"time"
+)
+
+// StateRequest asks for the authoritative state of the sender's debate
+type StateRequest struct {
+	DebateID  string `json:"debate_id"`
+	DebateKey string `json:"debate_key"`
+}
+
+// HandleStateRequest answers get_state with a debate_state message so a bot
+// that lost track (e.g. after an internal crash) can resync at any time
+func (dm *DebateManager) HandleStateRequest(req *StateRequest, senderConn *WSConn, replyTo string) *ErrorMessage {
+	dm.mutex.RLock()
+	activeDebate, exists := dm.debates[req.DebateID]
+	dm.mutex.RUnlock()
+
+	if !exists {
+		return &ErrorMessage{
+			ErrorCode:   "DEBATE_NOT_FOUND",
+			Message:     "Debate not found or no longer active",
+			DebateID:    req.DebateID,
+			Recoverable: false,
+		}
+	}
+
+	activeDebate.mutex.RLock()
+	var bot *ConnectedBot
+	for _, candidate := range activeDebate.Bots {
+		if candidate.Bot.DebateKey == req.DebateKey {
+			bot = candidate
+		}
+	}
+	if bot == nil {
+		activeDebate.mutex.RUnlock()
+		return &ErrorMessage{
+			ErrorCode:   "INVALID_DEBATE_KEY",
+			Message:     "Invalid debate key",
+			DebateID:    req.DebateID,
+			Recoverable: false,
+		}
+	}
+	state := dm.debateState(activeDebate, bot)
+	activeDebate.mutex.RUnlock()
+
+	senderConn.WriteJSON(createReply(replyTo, "debate_state", state))
+	return nil
+}
+
+// debateState builds the DebateUpdate a bot would act on right now, plus the
+// current deadline and remaining time budget. Caller holds activeDebate.mutex.
+func (dm *DebateManager) debateState(activeDebate *ActiveDebate, bot *ConnectedBot) DebateUpdate {
+	debate := activeDebate.Debate
+	limits := formatLimitsFor(debate)
+	state := DebateUpdate{
+		DebateID:         debate.ID,
+		Topic:            debate.Topic,
+		TotalRounds:      debate.TotalRounds,
+		CurrentRound:     debate.CurrentRound,
+		YourSide:         bot.Bot.Side,
+		YourIdentifier:   bot.Bot.BotIdentifier,
+		TimeoutSeconds:   limits.SpeechTimeout,
+		MinContentLength: limits.MinContentLength,
+		MaxContentLength: limits.MaxContentLength,
+		DebateLog:        activeDebate.DebateLog,
+		Format:           debate.Format,
+		Status:           debate.Status,
+		Scores:           activeDebate.runningScore(),
+		Spectators:       activeDebate.spectatorTotal(),
+	}
+	if activeDebate.SupportingBot != nil {
+		state.SupportingSide = activeDebate.teamName("supporting")
+		state.OpposingSide = activeDebate.teamName("opposing")
+		state.Participants = activeDebate.participants()
+	}
+
+	switch {
+	case debate.Status == "waiting":
+		// Only a blind opening can be submitted before the start
+		if debate.BlindOpening && !activeDebate.OpeningsClosed {
+			if _, submitted := activeDebate.Openings[bot.Bot.BotIdentifier]; !submitted {
+				state.NextSpeaker = bot.Bot.BotIdentifier
+			}
+		}
+	case !isInProgress(debate.Status):
+		// Closing or ended; nobody speaks
+	case debate.Format == FormatSimultaneous:
+		if _, submitted := activeDebate.PendingSpeeches[bot.Bot.BotIdentifier]; !submitted {
+			state.NextSpeaker = bot.Bot.BotIdentifier
+		}
+	default:
+		state.NextSpeaker = dm.getNextSpeaker(activeDebate)
+	}
+
+	now := time.Now()
+	if state.NextSpeaker != "" && activeDebate.TurnDeadline.After(now) {
+		state.Deadline = activeDebate.TurnDeadline.Format(time.RFC3339)
+		state.RemainingSeconds = int(activeDebate.TurnDeadline.Sub(now).Seconds())
+	}
+	if isInProgress(debate.Status) && !activeDebate.StartTime.IsZero() {
+		budget := activeDebate.StartTime.Add(time.Duration(config.Debate.MaxDuration) * time.Second).Sub(now)
+		if budget > 0 {
+			state.DebateRemainingSeconds = int(budget.Seconds())
+		}
+	}
+	return state
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Every completed LLM call records its prompt and completion tokens in
+// llm_usage, against the debate it was made for and what it was made for,
+// with its cost estimated at chatgpt.budget's per-1K prices. GET
+// /api/stats/usage reports the totals, or one debate's usage with
+// ?debate_id=. The same records seed BudgetGuard's daily and monthly
+// counters at startup; once chatgpt.budget.max_tokens_per_month tokens have
+// been used in the current UTC month, the AI judge is unavailable and
+// debates are judged heuristically until the next month.
+
+// What an LLM call was made for
+const (
+	UsageJudge       = "judge"
+	UsageHouseBot    = "house_bot"
+	UsageTranslation = "translation"
+	UsageSummary     = "summary"
+	UsageSandbox     = "sandbox"
+	UsageTopics      = "topics"
+)
+
+// JudgeUnavailableMonthlyBudget means max_tokens_per_month has been used up
+const JudgeUnavailableMonthlyBudget = "monthly_budget"
+
+// LLMUsage is the token usage of one LLM call
+type LLMUsage struct {
+	DebateID         string    `json:"debate_id,omitempty"`
+	Purpose          string    `json:"purpose"`
+	Model            string    `json:"model"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	Cost             float64   `json:"cost"` // USD estimate
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// UsageTotals adds up the usage of several calls
+type UsageTotals struct {
+	Calls            int     `json:"calls"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	Cost             float64 `json:"cost"` // USD estimate
+}
+
+// forDebate returns a copy of the client whose usage is recorded against a
+// debate and purpose; a nil client stays nil
+func (c *ChatGPTClient) forDebate(debateID, purpose string) *ChatGPTClient {
+	if c == nil {
+		return nil
+	}
+	scoped := *c
+	scoped.DebateID = debateID
+	scoped.Purpose = purpose
+	return &scoped
+}
+
+// recordUsage counts the tokens of a completed call against the budgets and
+// stores them
+func (c *ChatGPTClient) recordUsage(promptTokens, completionTokens int) {
+	llmBudget.Record(promptTokens, completionTokens)
+	if promptTokens == 0 && completionTokens == 0 {
+		return
+	}
+
+	budget := config.ChatGPT.Budget
+	usage := &LLMUsage{
+		DebateID:         c.DebateID,
+		Purpose:          c.Purpose,
+		Model:            c.Model,
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		Cost:             float64(promptTokens)/1000*budget.PromptCostPer1K + float64(completionTokens)/1000*budget.CompletionCostPer1K,
+		CreatedAt:        time.Now().UTC(),
+	}
+	if usage.Purpose == "" {
+		usage.Purpose = UsageJudge
+	}
+	go func() {
+		if err := db.SaveLLMUsage(usage); err != nil {
+			log.Printf("Failed to save LLM usage: %v", err)
+		}
+	}()
+}
+
+// SaveLLMUsage stores the usage of one call
+func (d *Database) SaveLLMUsage(u *LLMUsage) error {
+	query := `INSERT INTO llm_usage (debate_id, purpose, model, prompt_tokens, completion_tokens, cost, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`
+	_, err := d.db.Exec(query, u.DebateID, u.Purpose, u.Model, u.PromptTokens, u.CompletionTokens, u.Cost, u.CreatedAt)
+	return err
+}
+
+// usageTotalsColumns selects the totals of a group of llm_usage rows
+const usageTotalsColumns = `COUNT(*), COALESCE(SUM(prompt_tokens), 0), COALESCE(SUM(completion_tokens), 0), COALESCE(SUM(cost), 0)`
+
+// scanUsageTotals scans usageTotalsColumns, after dest
+func scanUsageTotals(scan func(dest ...interface{}) error, dest ...interface{}) (UsageTotals, error) {
+	var t UsageTotals
+	err := scan(append(dest, &t.Calls, &t.PromptTokens, &t.CompletionTokens, &t.Cost)...)
+	t.TotalTokens = t.PromptTokens + t.CompletionTokens
+	return t, err
+}
+
+// GetUsageTotals adds up the usage recorded since a time
+func (d *Database) GetUsageTotals(since time.Time) (UsageTotals, error) {
+	row := d.db.QueryRow(`SELECT `+usageTotalsColumns+` FROM llm_usage WHERE created_at >= ?`, since)
+	return scanUsageTotals(row.Scan)
+}
+
+// GetUsageBy adds up the usage recorded since a time per value of a column,
+// purpose or model
+func (d *Database) GetUsageBy(column string, since time.Time) (map[string]UsageTotals, error) {
+	if column != "purpose" && column != "model" {
+		return nil, fmt.Errorf("cannot group usage by %q", column)
+	}
+	rows, err := d.db.Query(`SELECT `+column+`, `+usageTotalsColumns+` FROM llm_usage WHERE created_at >= ? GROUP BY `+column, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := make(map[string]UsageTotals)
+	for rows.Next() {
+		var key string
+		t, err := scanUsageTotals(rows.Scan, &key)
+		if err != nil {
+			return nil, err
+		}
+		totals[key] = t
+	}
+	return totals, rows.Err()
+}
+
+// GetDebateUsage adds up a debate's usage per purpose
+func (d *Database) GetDebateUsage(debateID string) (map[string]UsageTotals, error) {
+	rows, err := d.db.Query(`SELECT purpose, `+usageTotalsColumns+` FROM llm_usage WHERE debate_id = ? GROUP BY purpose`, debateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := make(map[string]UsageTotals)
+	for rows.Next() {
+		var purpose string
+		t, err := scanUsageTotals(rows.Scan, &purpose)
+		if err != nil {
+			return nil, err
+		}
+		totals[purpose] = t
+	}
+	return totals, rows.Err()
+}
+
+// UsageStats is the response of GET /api/stats/usage
+type UsageStats struct {
+	AllTime           UsageTotals            `json:"all_time"`
+	Month             UsageTotals            `json:"month"` // Current UTC month
+	MonthByPurpose    map[string]UsageTotals `json:"month_by_purpose"`
+	MonthByModel      map[string]UsageTotals `json:"month_by_model"`
+	MaxTokensPerMonth int                    `json:"max_tokens_per_month"` // 0 is unlimited
+	Exhausted         bool                   `json:"exhausted"`            // AI judging is off until next month
+}
+
+// DebateUsage is the response of GET /api/stats/usage?debate_id=
+type DebateUsage struct {
+	DebateID  string                 `json:"debate_id"`
+	Total     UsageTotals            `json:"total"`
+	ByPurpose map[string]UsageTotals `json:"by_purpose"`
+}
+
+// handleStatsUsage handles GET /api/stats/usage[?debate_id=]
+func handleStatsUsage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if debateID := r.URL.Query().Get("debate_id"); debateID != "" {
+		byPurpose, err := db.GetDebateUsage(debateID)
+		if err != nil {
+			http.Error(w, "Failed to fetch usage", http.StatusInternalServerError)
+			return
+		}
+		usage := DebateUsage{DebateID: debateID, ByPurpose: byPurpose}
+		for _, t := range byPurpose {
+			usage.Total.Calls += t.Calls
+			usage.Total.PromptTokens += t.PromptTokens
+			usage.Total.CompletionTokens += t.CompletionTokens
+			usage.Total.TotalTokens += t.TotalTokens
+			usage.Total.Cost += t.Cost
+		}
+		writeJSON(w, usage)
+		return
+	}
+
+	since := monthStart(time.Now())
+	allTime, err := db.GetUsageTotals(time.Time{})
+	if err != nil {
+		http.Error(w, "Failed to fetch usage", http.StatusInternalServerError)
+		return
+	}
+	month, err := db.GetUsageTotals(since)
+	if err != nil {
+		http.Error(w, "Failed to fetch usage", http.StatusInternalServerError)
+		return
+	}
+	byPurpose, err := db.GetUsageBy("purpose", since)
+	if err != nil {
+		http.Error(w, "Failed to fetch usage", http.StatusInternalServerError)
+		return
+	}
+	byModel, err := db.GetUsageBy("model", since)
+	if err != nil {
+		http.Error(w, "Failed to fetch usage", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, UsageStats{
+		AllTime:           allTime,
+		Month:             month,
+		MonthByPurpose:    byPurpose,
+		MonthByModel:      byModel,
+		MaxTokensPerMonth: config.ChatGPT.Budget.MaxTokensPerMonth,
+		Exhausted:         llmBudget.MonthlyExhausted(),
+	})
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// The verdict summary the judge writes can be shaped per deployment under
+// chatgpt.judge.verdict and per debate with the verdict field of a create
+// request: a terse verdict for small embeds, a long one for research runs.
+// The defaults add nothing to the rubric, so their verdicts and rubric hashes
+// match those from before the setting existed.
+
+// Verdict lengths
+const (
+	VerdictBrief    = "brief"
+	VerdictStandard = "standard"
+	VerdictDetailed = "detailed"
+)
+
+// Verdict structures
+const (
+	VerdictProse   = "prose"
+	VerdictBullets = "bullets"
+)
+
+// VerdictStyle shapes the judge's summary. Empty fields of a per-debate
+// style fall back to the configured ones.
+type VerdictStyle struct {
+	Length          string `yaml:"length" json:"length,omitempty"`                     // brief, standard or detailed
+	Structure       string `yaml:"structure" json:"structure,omitempty"`               // prose or bullets
+	RoundCommentary *bool  `yaml:"round_commentary" json:"round_commentary,omitempty"` // Add a comment on each round
+}
+
+// RoundComment is the judge's comment on one round, rendered into the summary
+type RoundComment struct {
+	Round   int    `json:"round"`
+	Comment string `json:"comment"`
+}
+
+// validate rejects unknown lengths and structures
+func (s *VerdictStyle) validate() error {
+	if s == nil {
+		return nil
+	}
+	switch s.Length {
+	case "", VerdictBrief, VerdictStandard, VerdictDetailed:
+	default:
+		return fmt.Errorf("verdict length must be brief, standard or detailed")
+	}
+	switch s.Structure {
+	case "", VerdictProse, VerdictBullets:
+	default:
+		return fmt.Errorf("verdict structure must be prose or bullets")
+	}
+	return nil
+}
+
+// verdictStyleFor returns the configured verdict style with a debate's
+// overrides applied
+func verdictStyleFor(debate *Debate) VerdictStyle {
+	style := config.ChatGPT.Judge.Verdict
+	if debate == nil || debate.Verdict == nil {
+		return style
+	}
+	if debate.Verdict.Length != "" {
+		style.Length = debate.Verdict.Length
+	}
+	if debate.Verdict.Structure != "" {
+		style.Structure = debate.Verdict.Structure
+	}
+	if debate.Verdict.RoundCommentary != nil {
+		style.RoundCommentary = debate.Verdict.RoundCommentary
+	}
+	return style
+}
+
+// rubric returns the instructions appended to the judge rubric
+func (s VerdictStyle) rubric() string {
+	var b strings.Builder
+	switch s.Length {
+	case VerdictBrief:
+		b.WriteString("\n\nsummary 请控制在 100 字以内，只说明决定胜负的关键理由。")
+	case VerdictDetailed:
+		b.WriteString("\n\nsummary 请写 600-1000 字，按五项评分标准逐项分析双方的表现，并说明关键交锋如何影响了胜负。")
+	}
+	if s.Structure == VerdictBullets {
+		b.WriteString("\n\nsummary 请使用 Markdown 无序列表，每条一个要点，不要写成段落。")
+	}
+	if s.RoundCommentary != nil && *s.RoundCommentary {
+		b.WriteString(`
+
+另外，请在 JSON 中增加 "round_commentary" 字段，每轮一条简评:
+  "round_commentary": [{"round": 轮次编号, "comment": "该轮双方交锋的简评"}]`)
+	}
+	return b.String()
+}
+
+// appendRoundCommentary renders the judge's per-round comments as a section
+// of the summary
+func appendRoundCommentary(summary string, comments []RoundComment) string {
+	if len(comments) == 0 {
+		return summary
+	}
+	var b strings.Builder
+	b.WriteString(summary)
+	b.WriteString("\n\n### 逐轮点评\n")
+	for _, c := range comments {
+		if c.Round > 0 && c.Comment != "" {
+			b.WriteString(fmt.Sprintf("\n- **第%d轮**：%s", c.Round, c.Comment))
+		}
+	}
+	return b.String()
+}
+
+// encodeVerdictStyle stores a per-debate verdict style as JSON, "" for none
+func encodeVerdictStyle(style *VerdictStyle) string {
+	if style == nil {
+		return ""
+	}
+	data, _ := json.Marshal(style)
+	return string(data)
+}
+
+// decodeVerdictStyle reads a style stored by encodeVerdictStyle
+func decodeVerdictStyle(data string) *VerdictStyle {
+	if data == "" {
+		return nil
+	}
+	var style VerdictStyle
+	if json.Unmarshal([]byte(data), &style) != nil {
+		return nil
+	}
+	return &style
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// violationCodes are the recoverable speech errors a bot causes by breaking
+// the debate rules. Errors it cannot help, like DEBATE_PAUSED, do not count.
+var violationCodes = map[string]bool{
+	"NOT_YOUR_TURN":      true,
+	"CONTENT_TOO_SHORT":  true,
+	"CONTENT_TOO_LONG":   true,
+	"ALREADY_SUBMITTED":  true,
+	"INVALID_ATTACHMENT": true,
+}
+
+// WarningIssued tells a debate's bots and spectators that a bot broke the
+// debate rules and how close it is to disqualification
+type WarningIssued struct {
+	DebateID     string `json:"debate_id"`
+	Bot          string `json:"bot"`
+	Side         string `json:"side"`
+	ErrorCode    string `json:"error_code"`   // The violation
+	Strikes      int    `json:"strikes"`      // Consecutive violations so far
+	MaxStrikes   int    `json:"max_strikes"`  // Strikes at which the bot is disqualified
+	Disqualified bool   `json:"disqualified"` // This strike reached the threshold
+}
+
+// trackViolations counts a bot's consecutive rule violations and resets the
+// count once a speech is accepted. Every violation is announced as a warning;
+// at max_violations the bot is disqualified and the debate ends, and the
+// returned error is then no longer recoverable.
+func (dm *DebateManager) trackViolations(speech *DebateSpeech, errMsg *ErrorMessage) *ErrorMessage {
+	threshold := config.Debate.MaxViolations
+	if threshold <= 0 {
+		return errMsg
+	}
+
+	dm.mutex.RLock()
+	activeDebate, exists := dm.debates[speech.DebateID]
+	dm.mutex.RUnlock()
+	if !exists {
+		return errMsg
+	}
+	bot := activeDebate.findBot(speech.Speaker)
+	if bot == nil || bot.Bot.DebateKey != speech.DebateKey {
+		return errMsg
+	}
+
+	activeDebate.mutex.Lock()
+	if errMsg == nil {
+		delete(activeDebate.Violations, speech.Speaker)
+		activeDebate.mutex.Unlock()
+		return nil
+	}
+	if !violationCodes[errMsg.ErrorCode] {
+		activeDebate.mutex.Unlock()
+		return errMsg
+	}
+	if activeDebate.Violations == nil {
+		activeDebate.Violations = make(map[string]int)
+	}
+	activeDebate.Violations[speech.Speaker]++
+	count := activeDebate.Violations[speech.Speaker]
+	activeDebate.mutex.Unlock()
+
+	dm.notifyDebate(activeDebate, createMessage("warning_issued", WarningIssued{
+		DebateID:     speech.DebateID,
+		Bot:          speech.Speaker,
+		Side:         bot.Bot.Side,
+		ErrorCode:    errMsg.ErrorCode,
+		Strikes:      count,
+		MaxStrikes:   threshold,
+		Disqualified: count == threshold,
+	}))
+	if count != threshold {
+		return errMsg
+	}
+
+	log.Printf("Bot %s disqualified from debate %s after %d consecutive violations (last: %s)",
+		speech.Speaker, speech.DebateID, count, errMsg.ErrorCode)
+	errMsg.Message = fmt.Sprintf("%s; %d consecutive violations, you are disqualified", errMsg.Message, count)
+	errMsg.Recoverable = false
+	dm.endDebate(speech.DebateID, "completed", "disqualified_"+speech.Speaker)
+	return errMsg
+}
+
+// disqualifiedBot returns the bot named by a disqualification end reason
+func disqualifiedBot(reason string) string {
+	if !strings.HasPrefix(reason, "disqualified_") {
+		return ""
+	}
+	return strings.TrimPrefix(reason, "disqualified_")
+}
+
+// disqualificationResult awards the debate to the side opposing a
+// disqualified bot without judging it
+func (dm *DebateManager) disqualificationResult(activeDebate *ActiveDebate, identifier string) *DebateResult {
+	loser := "supporting"
+	if bot := activeDebate.findBot(identifier); bot != nil && bot.Bot.Side == "opposing" {
+		loser = "opposing"
+	}
+	winner := "opposing"
+	if loser == "opposing" {
+		winner = "supporting"
+	}
+
+	result := &DebateResult{
+		Winner:  winner,
+		Summary: SpeechMessage{Format: "markdown"},
+		Reason:  "disqualified_" + identifier,
+		Source:  ResultSourceForfeit,
+	}
+	if winner == "supporting" {
+		result.SupportingScore = 100
+	} else {
+		result.OpposingScore = 100
+	}
+	result.Summary.Content = fmt.Sprintf(`## 辩论终止
+
+**辩题**: %s
+
+### 正方: %s
+
+### 反方: %s
+
+### 结果
+%s 连续 %d 次违规发言（未轮到发言、内容过短或过长等），被取消资格。
+
+**获胜方**: %s`, activeDebate.Debate.Topic,
+		activeDebate.teamName("supporting"), activeDebate.teamName("opposing"),
+		identifier, config.Debate.MaxViolations, sideName(winner))
+	return result
+}
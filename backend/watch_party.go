@@ -0,0 +1,415 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A watch party replays a finished debate to a group of spectators in step.
+// The host starts a session with POST /api/debate/{id}/replay and gets a
+// host token; spectators join over the spectator WebSocket with join_replay
+// and receive the speeches one replay_event at a time, at the pace the host
+// sets through POST /api/replay/{session_id}/control (play, pause, seek,
+// speed). Joining, seeking and every play or pause send replay_state, which
+// carries the speeches revealed so far so late joiners catch up. Sessions
+// live on the instance that created them and are dropped after sitting idle
+// with nobody watching for replay.idle_timeout.
+
+// Replay control actions
+const (
+	ReplayPlay  = "play"
+	ReplayPause = "pause"
+	ReplaySeek  = "seek"
+	ReplaySpeed = "speed"
+)
+
+// maxReplaySpeed is the fastest pace a host may set
+const maxReplaySpeed = 10
+
+// errTooManyReplays is returned when replay.max_sessions are running
+var errTooManyReplays = errors.New("too many replay sessions, try again later")
+
+// CreateReplayRequest starts a watch party
+type CreateReplayRequest struct {
+	Speed float64 `json:"speed,omitempty"` // Defaults to 1
+}
+
+// CreateReplayResponse carries the host token; it is shown only once
+type CreateReplayResponse struct {
+	SessionID    string  `json:"session_id"`
+	DebateID     string  `json:"debate_id"`
+	HostToken    string  `json:"host_token"` // Required for /api/replay/{session_id}/control
+	TotalEntries int     `json:"total_entries"`
+	Speed        float64 `json:"speed"`
+}
+
+// ReplayControl is a host command
+type ReplayControl struct {
+	HostToken string  `json:"host_token"`
+	Action    string  `json:"action"`             // play, pause, seek or speed
+	Position  int     `json:"position,omitempty"` // seek: speeches to have revealed
+	Speed     float64 `json:"speed,omitempty"`    // speed: pace relative to replay.speech_interval
+}
+
+// JoinReplay joins a spectator to a watch party
+type JoinReplay struct {
+	SessionID string `json:"session_id"`
+}
+
+// ReplayState is a watch party's state, sent on join and on every host command
+type ReplayState struct {
+	SessionID      string           `json:"session_id"`
+	DebateID       string           `json:"debate_id"`
+	Topic          string           `json:"topic"`
+	SupportingSide string           `json:"supporting_side"`
+	OpposingSide   string           `json:"opposing_side"`
+	TotalRounds    int              `json:"total_rounds"`
+	Position       int              `json:"position"` // Speeches revealed so far
+	TotalEntries   int              `json:"total_entries"`
+	Playing        bool             `json:"playing"`
+	Speed          float64          `json:"speed"`
+	Finished       bool             `json:"finished"` // Every speech is revealed
+	Spectators     int              `json:"spectators"`
+	DebateLog      []DebateLogEntry `json:"debate_log,omitempty"` // The revealed speeches; omitted in REST responses
+	Result         *DebateResult    `json:"result,omitempty"`     // Once every speech is revealed
+}
+
+// ReplayEvent reveals the next speech of a watch party
+type ReplayEvent struct {
+	SessionID string         `json:"session_id"`
+	Position  int            `json:"position"` // Speeches revealed including this one
+	Entry     DebateLogEntry `json:"entry"`
+}
+
+// ReplaySession is one watch party
+type ReplaySession struct {
+	ID             string
+	DebateID       string
+	hostToken      string
+	topic          string
+	supportingSide string
+	opposingSide   string
+	totalRounds    int
+	log            []DebateLogEntry
+	result         *DebateResult
+
+	mutex      sync.Mutex
+	position   int
+	playing    bool
+	speed      float64
+	timer      *time.Timer
+	conns      map[*WSConn]bool
+	lastActive time.Time
+}
+
+// ReplayManager holds this instance's watch parties
+type ReplayManager struct {
+	mutex    sync.Mutex
+	sessions map[string]*ReplaySession
+}
+
+var replays = &ReplayManager{sessions: make(map[string]*ReplaySession)}
+
+// generateReplayToken returns a random token with a prefix
+func generateReplayToken(prefix string) string {
+	buf := make([]byte, 12)
+	rand.Read(buf)
+	return prefix + hex.EncodeToString(buf)
+}
+
+// Create starts a paused watch party of a finished debate
+func (m *ReplayManager) Create(debate *Debate, speed float64) (*ReplaySession, error) {
+	debateLog, err := db.GetDebateLog(debate.ID)
+	if err != nil {
+		return nil, err
+	}
+	bots, _ := db.GetBots(debate.ID)
+	result, _ := db.GetDebateResult(debate.ID)
+	if result != nil {
+		attachCitations(debateLog, result.Citations)
+	}
+
+	s := &ReplaySession{
+		ID:          generateReplayToken("replay-"),
+		DebateID:    debate.ID,
+		hostToken:   generateReplayToken("host-"),
+		topic:       redactor.Debate(debate).Topic,
+		totalRounds: debate.TotalRounds,
+		log:         redactor.Log(debateLog),
+		result:      redactor.Result(result),
+		speed:       speed,
+		conns:       make(map[*WSConn]bool),
+		lastActive:  time.Now(),
+	}
+	if supporting, opposing := findSides(bots); supporting != nil && opposing != nil {
+		s.supportingSide, s.opposingSide = supporting.BotIdentifier, opposing.BotIdentifier
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.prune()
+	if len(m.sessions) >= config.Replay.MaxSessions {
+		return nil, errTooManyReplays
+	}
+	m.sessions[s.ID] = s
+	log.Printf("Replay session %s started for debate %s", s.ID, debate.ID)
+	return s, nil
+}
+
+// Get returns a watch party
+func (m *ReplayManager) Get(sessionID string) *ReplaySession {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.prune()
+	return m.sessions[sessionID]
+}
+
+// prune drops sessions idle with nobody watching. Caller holds m.mutex.
+func (m *ReplayManager) prune() {
+	idle := time.Duration(config.Replay.IdleTimeout) * time.Second
+	for id, s := range m.sessions {
+		s.mutex.Lock()
+		expired := len(s.conns) == 0 && !s.playing && time.Since(s.lastActive) > idle
+		s.mutex.Unlock()
+		if expired {
+			delete(m.sessions, id)
+			log.Printf("Replay session %s expired", id)
+		}
+	}
+}
+
+// Join adds a spectator and sends it the current state
+func (s *ReplaySession) Join(conn *WSConn, req *Message) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.conns[conn] = true
+	s.lastActive = time.Now()
+	writeReply(conn, req, "replay_state", s.state(true))
+}
+
+// Leave removes a spectator
+func (s *ReplaySession) Leave(conn *WSConn) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.conns, conn)
+	s.lastActive = time.Now()
+}
+
+// Control applies a host command and tells the spectators
+func (s *ReplaySession) Control(c *ReplayControl) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.lastActive = time.Now()
+
+	switch c.Action {
+	case ReplayPlay:
+		if s.position >= len(s.log) {
+			s.position = 0 // Play again from the start
+		}
+		s.playing = true
+		s.schedule()
+	case ReplayPause:
+		s.playing = false
+		s.stop()
+	case ReplaySeek:
+		if c.Position < 0 || c.Position > len(s.log) {
+			return fmt.Errorf("position must be between 0 and %d", len(s.log))
+		}
+		s.position = c.Position
+		if s.playing {
+			s.schedule()
+		}
+	case ReplaySpeed:
+		if c.Speed <= 0 || c.Speed > maxReplaySpeed {
+			return fmt.Errorf("speed must be above 0 and at most %d", maxReplaySpeed)
+		}
+		s.speed = c.Speed
+		if s.playing {
+			s.schedule()
+		}
+	default:
+		return fmt.Errorf("action must be %s, %s, %s or %s", ReplayPlay, ReplayPause, ReplaySeek, ReplaySpeed)
+	}
+	s.publish(createMessage("replay_state", s.state(true)))
+	return nil
+}
+
+// State returns the session state without the debate log
+func (s *ReplaySession) State() ReplayState {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.state(false)
+}
+
+// state builds the session state. Caller holds s.mutex.
+func (s *ReplaySession) state(withLog bool) ReplayState {
+	state := ReplayState{
+		SessionID:      s.ID,
+		DebateID:       s.DebateID,
+		Topic:          s.topic,
+		SupportingSide: s.supportingSide,
+		OpposingSide:   s.opposingSide,
+		TotalRounds:    s.totalRounds,
+		Position:       s.position,
+		TotalEntries:   len(s.log),
+		Playing:        s.playing,
+		Speed:          s.speed,
+		Finished:       s.position >= len(s.log),
+		Spectators:     len(s.conns),
+	}
+	if withLog {
+		state.DebateLog = s.log[:s.position]
+	}
+	if state.Finished {
+		state.Result = s.result
+	}
+	return state
+}
+
+// schedule (re)starts the timer revealing the next speech. Caller holds s.mutex.
+func (s *ReplaySession) schedule() {
+	s.stop()
+	if s.position >= len(s.log) {
+		s.playing = false
+		return
+	}
+	interval := time.Duration(float64(config.Replay.SpeechInterval) / s.speed * float64(time.Second))
+	s.timer = time.AfterFunc(interval, s.advance)
+}
+
+// stop cancels the pending reveal. Caller holds s.mutex.
+func (s *ReplaySession) stop() {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+}
+
+// advance reveals the next speech; the last one ends playback
+func (s *ReplaySession) advance() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !s.playing || s.position >= len(s.log) {
+		return
+	}
+
+	s.position++
+	s.publish(createMessage("replay_event", ReplayEvent{
+		SessionID: s.ID,
+		Position:  s.position,
+		Entry:     s.log[s.position-1],
+	}))
+	if s.position >= len(s.log) {
+		s.playing = false
+		s.timer = nil
+		s.publish(createMessage("replay_state", s.state(true)))
+		return
+	}
+	s.schedule()
+}
+
+// publish sends a message to every spectator. Caller holds s.mutex.
+func (s *ReplaySession) publish(msg Message) {
+	for conn := range s.conns {
+		if err := conn.WriteJSON(msg); err != nil {
+			log.Printf("Error sending replay message: %v", err)
+		}
+	}
+}
+
+// handleCreateReplay handles POST /api/debate/{id}/replay
+func handleCreateReplay(w http.ResponseWriter, r *http.Request, debateID string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req CreateReplayRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+	}
+	if req.Speed == 0 {
+		req.Speed = 1
+	}
+	if req.Speed < 0 || req.Speed > maxReplaySpeed {
+		http.Error(w, "speed must be above 0 and at most 10", http.StatusBadRequest)
+		return
+	}
+
+	debate, err := db.GetDebate(debateID)
+	if err != nil || checkSpectatorAccess(debate, r.URL.Query().Get("token")) != nil {
+		http.Error(w, "Debate not found", http.StatusNotFound)
+		return
+	}
+	if !isFinished(debate.Status) {
+		http.Error(w, "Only finished debates can be replayed", http.StatusConflict)
+		return
+	}
+
+	s, err := replays.Create(debate, req.Speed)
+	if err == errTooManyReplays {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to load debate", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(CreateReplayResponse{
+		SessionID:    s.ID,
+		DebateID:     s.DebateID,
+		HostToken:    s.hostToken,
+		TotalEntries: len(s.log),
+		Speed:        req.Speed,
+	})
+}
+
+// handleReplayRoutes handles GET /api/replay/{session_id} and
+// POST /api/replay/{session_id}/control
+func handleReplayRoutes(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/replay/"), "/"), "/")
+	s := replays.Get(parts[0])
+	if s == nil {
+		http.Error(w, "Replay session not found", http.StatusNotFound)
+		return
+	}
+
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		writeJSON(w, s.State())
+	case len(parts) == 2 && parts[1] == "control" && r.Method == http.MethodPost:
+		var c ReplayControl
+		if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(c.HostToken), []byte(s.hostToken)) != 1 {
+			http.Error(w, "Invalid host token", http.StatusForbidden)
+			return
+		}
+		if err := s.Control(&c); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, s.State())
+	case len(parts) <= 2:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.NotFound(w, r)
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhooks POST debate lifecycle events to the URLs under webhooks.endpoints
+// in config.yml. Each endpoint has its own queue, so a slow or failing
+// receiver neither blocks debates nor delays the others, and it receives
+// events in the order they happened. Failed deliveries are retried with
+// exponential backoff. With a secret configured, every request carries
+// X-Debate-Signature: sha256=HMAC(secret, timestamp + "." + body), and
+// X-Debate-Timestamp lets the receiver reject replays.
+
+// Webhook events
+const (
+	WebhookDebateCreated = "debate_created"
+	WebhookDebateStart   = "debate_start"
+	WebhookDebateEnd     = "debate_end"
+	WebhookError         = "error" // A server-side failure affecting a debate, e.g. a failed judge call
+)
+
+// webhookEvents lists the events an endpoint may subscribe to
+var webhookEvents = []string{WebhookDebateCreated, WebhookDebateStart, WebhookDebateEnd, WebhookError}
+
+// webhookQueueSize bounds the deliveries waiting per endpoint
+const webhookQueueSize = 256
+
+// WebhookEndpoint configures one receiver
+type WebhookEndpoint struct {
+	URL    string   `yaml:"url"`
+	Secret string   `yaml:"secret"` // Signs requests; empty sends them unsigned
+	Events []string `yaml:"events"` // Events to receive; empty receives all
+}
+
+// WebhookPayload is the JSON body of a webhook request
+type WebhookPayload struct {
+	ID        string      `json:"id"` // Same across retries, so receivers can drop duplicates
+	Event     string      `json:"event"`
+	DebateID  string      `json:"debate_id"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
+
+// WebhookDebateStartData is the data of debate_start
+type WebhookDebateStartData struct {
+	Debate         *Debate       `json:"debate"`
+	SupportingSide string        `json:"supporting_side"`
+	OpposingSide   string        `json:"opposing_side"`
+	Participants   []Participant `json:"participants,omitempty"`
+}
+
+// WebhookErrorData is the data of error
+type WebhookErrorData struct {
+	Stage   string `json:"stage"` // judge or save_result
+	Message string `json:"message"`
+}
+
+// WebhookDispatcher delivers events to the configured endpoints
+type WebhookDispatcher struct {
+	client      *http.Client
+	maxAttempts int
+	backoff     time.Duration
+	endpoints   []*webhookEndpoint
+}
+
+type webhookEndpoint struct {
+	WebhookEndpoint
+	queue chan WebhookPayload
+}
+
+// webhooks is nil when no endpoint is configured
+var webhooks *WebhookDispatcher
+
+// NewWebhookDispatcher starts a delivery queue per configured endpoint; nil
+// if there are none
+func NewWebhookDispatcher(cfg *Config) *WebhookDispatcher {
+	w := cfg.Webhooks
+	if len(w.Endpoints) == 0 {
+		return nil
+	}
+	d := &WebhookDispatcher{
+		client:      &http.Client{Timeout: time.Duration(w.Timeout) * time.Second},
+		maxAttempts: w.MaxAttempts,
+		backoff:     time.Duration(w.Backoff) * time.Second,
+	}
+	for _, e := range w.Endpoints {
+		endpoint := &webhookEndpoint{WebhookEndpoint: e, queue: make(chan WebhookPayload, webhookQueueSize)}
+		d.endpoints = append(d.endpoints, endpoint)
+		go d.deliverQueued(endpoint)
+		log.Printf("Webhook endpoint registered: %s", e.URL)
+	}
+	return d
+}
+
+// Send queues an event for every endpoint subscribed to it
+func (d *WebhookDispatcher) Send(event, debateID string, data interface{}) {
+	if d == nil {
+		return
+	}
+	payload := WebhookPayload{
+		ID:        uuid.New().String(),
+		Event:     event,
+		DebateID:  debateID,
+		Timestamp: time.Now().UTC(),
+		Data:      data,
+	}
+	for _, endpoint := range d.endpoints {
+		if !endpoint.wants(event) {
+			continue
+		}
+		select {
+		case endpoint.queue <- payload:
+		default:
+			log.Printf("Webhook queue for %s full, dropped %s for debate %s", endpoint.URL, event, debateID)
+		}
+	}
+}
+
+// wants reports whether the endpoint subscribed to an event
+func (e *webhookEndpoint) wants(event string) bool {
+	if len(e.Events) == 0 {
+		return true
+	}
+	for _, subscribed := range e.Events {
+		if subscribed == event {
+			return true
+		}
+	}
+	return false
+}
+
+// deliverQueued delivers an endpoint's events one at a time
+func (d *WebhookDispatcher) deliverQueued(endpoint *webhookEndpoint) {
+	for payload := range endpoint.queue {
+		body, err := json.Marshal(payload)
+		if err != nil {
+			log.Printf("Failed to encode webhook %s for debate %s: %v", payload.Event, payload.DebateID, err)
+			continue
+		}
+
+		delay := d.backoff
+		for attempt := 1; ; attempt++ {
+			retry, err := d.deliver(endpoint, payload, body)
+			if err == nil {
+				break
+			}
+			if !retry || attempt >= d.maxAttempts {
+				log.Printf("Webhook %s for debate %s to %s failed after %d attempts: %v",
+					payload.Event, payload.DebateID, endpoint.URL, attempt, err)
+				break
+			}
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+}
+
+// deliver makes one delivery attempt. Network errors, 429 and 5xx responses
+// are worth retrying; other failures are not.
+func (d *WebhookDispatcher) deliver(endpoint *webhookEndpoint, payload WebhookPayload, body []byte) (retry bool, err error) {
+	req, err := http.NewRequest(http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "bot-debate-webhooks")
+	req.Header.Set("X-Debate-Event", payload.Event)
+	req.Header.Set("X-Debate-Delivery", payload.ID)
+	req.Header.Set("X-Debate-Timestamp", timestamp)
+	if endpoint.Secret != "" {
+		req.Header.Set("X-Debate-Signature", "sha256="+signWebhook(endpoint.Secret, timestamp, body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("receiver returned %s", resp.Status)
+}
+
+// signWebhook computes the hex HMAC-SHA256 of timestamp + "." + body
+func signWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Gorilla allows one concurrent writer per connection and panics on a second,
+// but the server writes to the same socket from its read loop, the broadcast
+// loop, turn timers and judging goroutines. Every connection is wrapped in a
+// WSConn when it is upgraded or dialed, and all data writes go through its
+// mutex. Control frames and Close are safe to call concurrently as they are.
+
+// backgroundWriteTimeout bounds the writes of timers and judging goroutines,
+// so one stalled client cannot hold them up for everyone else
+const backgroundWriteTimeout = 10 * time.Second
+
+// WSConn is a WebSocket connection whose writes are serialized
+type WSConn struct {
+	*websocket.Conn
+	writeMutex sync.Mutex
+}
+
+// newWSConn wraps a connection
+func newWSConn(conn *websocket.Conn) *WSConn {
+	return &WSConn{Conn: conn}
+}
+
+// WriteJSON writes v as a JSON message once any other write has finished
+func (c *WSConn) WriteJSON(v interface{}) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	return c.Conn.WriteJSON(v)
+}
+
+// WriteMessage writes a data message once any other write has finished
+func (c *WSConn) WriteMessage(messageType int, data []byte) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	return c.Conn.WriteMessage(messageType, data)
+}
+
+// WriteJSONWithin is WriteJSON failing after timeout, for background writers
+// that must not be held up by one slow client
+func (c *WSConn) WriteJSONWithin(v interface{}, timeout time.Duration) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	c.Conn.SetWriteDeadline(time.Now().Add(timeout))
+	defer c.Conn.SetWriteDeadline(time.Time{})
+	return c.Conn.WriteJSON(v)
+}
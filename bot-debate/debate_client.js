@@ -3,15 +3,24 @@ const { v4: uuidv4 } = require('uuid');
 const fs = require('fs');
 const path = require('path');
 
+// Reconnects tried after the connection drops mid-debate before giving up
+const MAX_RECONNECT_ATTEMPTS = 5;
+
 class DebateClient {
     constructor(wsUrl, botName, debateId = null) {
         this.botName = botName;
-        this.botUuid = uuidv4();
+        // Registered bots log in with their own bot_uuid and token
+        this.botUuid = process.env.DEBATE_BOT_UUID || uuidv4();
+        this.botToken = process.env.DEBATE_BOT_TOKEN || undefined;
         this.wsUrl = this.convertToWebSocketUrl(wsUrl);
         this.debateId = debateId;
         this.debateKey = null;
+        this.affinityToken = null;
         this.botIdentifier = null;
         this.ws = null;
+        this.redirecting = false;
+        this.finished = false;         // Set on debate_end; closing then ends the process
+        this.reconnectAttempts = 0;
         this.minContentLength = 50;    // Default values
         this.maxContentLength = 2000;  // Default values
 
@@ -52,8 +61,10 @@ class DebateClient {
         console.log(`[${new Date().toISOString()}] [${this.botName}] ${msg}`);
     }
 
-    send(type, data) {
+    send(type, data, replyTo) {
         const payload = JSON.stringify({
+            id: uuidv4(),
+            reply_to: replyTo,
             type: type,
             timestamp: new Date().toISOString(),
             data: data
@@ -149,7 +160,7 @@ ${history || "辩论刚刚开始，请进行开场陈述。"}
             }
 
             // 发送消息
-            this.send('debate_speech', {
+            this.send(msgData.opening ? 'opening_submission' : 'debate_speech', {
                 debate_id: this.debateId,
                 debate_key: this.debateKey,
                 speaker: this.botIdentifier,
@@ -168,7 +179,9 @@ ${history || "辩论刚刚开始，请进行开场陈述。"}
     }
 
     run() {
-        this.ws = new WebSocket(this.wsUrl);
+        // Servers with auth enabled need the API key from DEBATE_API_KEY
+        const headers = process.env.DEBATE_API_KEY ? { Authorization: `Bearer ${process.env.DEBATE_API_KEY}` } : {};
+        this.ws = new WebSocket(this.wsUrl, 'botdebate.v1.json', { headers });
 
         this.ws.on('open', () => {
             if (this.debateId) {
@@ -177,14 +190,21 @@ ${history || "辩论刚刚开始，请进行开场陈述。"}
                     bot_name: this.botName,
                     bot_uuid: this.botUuid,
                     debate_id: this.debateId,
-                    version: "2.0"
+                    version: "2.0",
+                    token: this.botToken,
+                    // Taking back a seat after a disconnect needs the key from the first login
+                    debate_key: this.debateKey || undefined,
+                    affinity_token: this.affinityToken || undefined
                 });
             } else {
                 this.log("Connected. Requesting debate assignment...");
                 this.send('bot_login', {
                     bot_name: this.botName,
                     bot_uuid: this.botUuid,
-                    version: "2.0"
+                    version: "2.0",
+                    token: this.botToken,
+                    // Preferred topic categories, comma-separated in DEBATE_TAGS
+                    tags: (process.env.DEBATE_TAGS || '').split(',').map(t => t.trim()).filter(Boolean)
                 });
             }
         });
@@ -195,7 +215,12 @@ ${history || "辩论刚刚开始，请进行开场陈述。"}
 
             switch (type) {
                 case 'login_confirmed':
+                    this.reconnectAttempts = 0;
+                    if (msgData.reconnected) {
+                        this.log('Reconnected to the running debate');
+                    }
                     this.debateKey = msgData.debate_key;
+                    this.affinityToken = msgData.affinity_token || null;
                     this.botIdentifier = msgData.bot_identifier;
                     if (msgData.debate_id && !this.debateId) {
                         this.debateId = msgData.debate_id;
@@ -208,6 +233,25 @@ ${history || "辩论刚刚开始，请进行开场陈述。"}
                     } else {
                         this.log(`You are the first bot to join`);
                     }
+                    if (msgData.blind_opening) {
+                        // Blind opening: write the opening statement before seeing the opponent
+                        this.log(`Blind opening debate, side: ${msgData.your_side}`);
+                        this.handleTurn({ ...msgData, opening: true });
+                    }
+                    break;
+                case 'login_redirect':
+                    // Debate lives on another server instance; reconnect there
+                    this.log(`Redirected to instance ${msgData.instance_id}: ${msgData.url}`);
+                    this.wsUrl = msgData.url;
+                    this.affinityToken = msgData.affinity_token || null;
+                    this.redirecting = true;
+                    this.ws.close();
+                    break;
+                case 'login_queued':
+                    this.log(`Waiting for an opponent (queue position ${msgData.position}, until ${msgData.deadline})`);
+                    break;
+                case 'side_signal':
+                    this.log(`Side signal from ${msgData.from}: ${msgData.kind} ${JSON.stringify(msgData.fields || {})}`);
                     break;
                 case 'login_rejected':
                     this.log(`Login rejected: ${msgData.message}`);
@@ -218,20 +262,55 @@ ${history || "辩论刚刚开始，请进行开场陈述。"}
                     process.exit(1);
                     break;
                 case 'debate_start':
+                    if (msgData.rules) {
+                        this.log(`Rules: ${msgData.rules.format}, ${msgData.rules.total_rounds} rounds, ${msgData.rules.budgets.speech_timeout}s per speech, judged by ${msgData.judging || msgData.rules.judge.mode}`);
+                    }
+                    if (msgData.rubric) {
+                        this.log(`Rubric: ${msgData.rubric.criteria.map(c => c.weight ? `${c.name} ${c.weight}` : c.name).join(', ')}`);
+                    }
+                    // falls through
                 case 'debate_update':
+                    if (msgData.opponent_summary) {
+                        this.log(`Opponent (round ${msgData.opponent_summary.round}) in brief: ${msgData.opponent_summary.summary}`);
+                    }
                     if (msgData.next_speaker === this.botIdentifier) {
                         this.handleTurn(msgData);
                     }
                     break;
+                case 'debate_paused':
+                    this.log(`Debate paused (${msgData.reason}${msgData.bot ? `: ${msgData.bot}` : ''}${msgData.note ? `: ${msgData.note}` : ''})`);
+                    break;
+                case 'debate_resumed':
+                    this.log('Debate resumed');
+                    break;
+                case 'server_shutdown':
+                    this.log(`Server shutting down (${msgData.phase}): ${msgData.message}`);
+                    break;
+                case 'debate_closing':
+                    this.log(`Final speech in, judging at ${msgData.judging_at}`);
+                    break;
+                case 'warning_issued':
+                    this.log(`Warning for ${msgData.bot}: ${msgData.error_code} (${msgData.strikes}/${msgData.max_strikes})${msgData.disqualified ? ', disqualified' : ''}`);
+                    break;
+                case 'feedback':
+                    this.log(`Judge feedback for ${msgData.side}:`);
+                    for (const [label, points] of [['Strengths', msgData.strengths], ['Weaknesses', msgData.weaknesses], ['Missed rebuttals', msgData.missed_rebuttals]]) {
+                        (points || []).forEach(point => this.log(`  ${label}: ${point}`));
+                    }
+                    break;
                 case 'debate_end':
-                    this.log(`Debate ended. Winner: ${msgData.debate_result.winner}`);
+                    this.log(`Debate ended. Winner: ${msgData.debate_result.winner}${msgData.judging ? ` (${msgData.judging} judging)` : ''}`);
+                    this.finished = true;
                     this.ws.close();
                     break;
+                case 'result_available':
+                    this.log(`Result of earlier debate ${msgData.debate_id} (${msgData.side}): winner ${msgData.debate_result.winner}`);
+                    break;
                 case 'ping':
                     // Server sent ping, respond with pong
                     this.send('pong', {
                         client_time: new Date().toISOString()
-                    });
+                    }, msg.id);
                     break;
                 case 'error':
                     this.log(`Error: ${msgData.message}`);
@@ -246,8 +325,20 @@ ${history || "辩论刚刚开始，请进行开场陈述。"}
         });
 
         this.ws.on('close', (code, reason) => {
+            if (this.redirecting) {
+                this.redirecting = false;
+                this.run();
+                return;
+            }
             this.log(`Connection closed (code: ${code}, reason: ${reason || 'no reason'})`);
-            process.exit(0);
+            if (this.finished || !this.debateId || this.reconnectAttempts >= MAX_RECONNECT_ATTEMPTS) {
+                process.exit(this.finished ? 0 : 1);
+            }
+            // The server keeps a dropped bot's seat for a while; log back in to take it
+            this.reconnectAttempts++;
+            const delay = Math.min(1000 * 2 ** (this.reconnectAttempts - 1), 30000);
+            this.log(`Reconnecting in ${delay / 1000}s (attempt ${this.reconnectAttempts}/${MAX_RECONNECT_ATTEMPTS})...`);
+            setTimeout(() => this.run(), delay);
         });
     }
 }
@@ -264,6 +355,11 @@ if (!url || !botName) {
     console.log("  botName   - Name for this bot (e.g., bot_alpha)");
     console.log("  debateId  - (Optional) Debate ID to join. If omitted, platform will assign one.");
     console.log("");
+    console.log("Environment:");
+    console.log("  DEBATE_API_KEY - API key, required when the server has auth enabled");
+    console.log("  DEBATE_TAGS    - Preferred topic categories when auto-assigned, e.g. technology,ethics");
+    console.log("  DEBATE_BOT_UUID, DEBATE_BOT_TOKEN - bot_uuid and token from /api/bots/register");
+    console.log("");
     console.log("Examples:");
     console.log("  node debate_client.js http://localhost:8081 bot_alpha debate-12345");
     console.log("  node debate_client.js http://localhost:8081 bot_alpha");
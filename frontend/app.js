@@ -1,11 +1,38 @@
 // Global state
 let currentDebateId = null;
 let ws = null;
+let eventSource = null; // SSE fallback when the WebSocket cannot connect
+
+// API key for servers with auth enabled, from ?api_key= and remembered for later visits
+const apiKey = (() => {
+    const key = new URLSearchParams(window.location.search).get('api_key');
+    if (key) {
+        localStorage.setItem('api_key', key);
+    }
+    return key || localStorage.getItem('api_key');
+})();
+
+// Add the API key, if any, to request headers
+function authHeaders(headers = {}) {
+    if (apiKey) {
+        headers['Authorization'] = `Bearer ${apiKey}`;
+    }
+    return headers;
+}
+
+// Watch party being watched: joined from ?replay=, hosted with ?host_token=
+let replaySession = null;
+let replayLog = [];
 
 // Initialize
 document.addEventListener('DOMContentLoaded', () => {
     setupEventListeners();
     loadExistingDebates();
+
+    const params = new URLSearchParams(window.location.search);
+    if (params.get('replay')) {
+        joinReplay(params.get('replay'), params.get('host_token'));
+    }
 });
 
 // Setup event listeners
@@ -28,9 +55,9 @@ async function handleCreateDebate(e) {
     try {
         const response = await fetch('/api/debate/create', {
             method: 'POST',
-            headers: {
+            headers: authHeaders({
                 'Content-Type': 'application/json',
-            },
+            }),
             body: JSON.stringify({
                 topic: topic,
                 total_rounds: rounds,
@@ -47,8 +74,8 @@ async function handleCreateDebate(e) {
         // Show debate info
         showDebateInfo(data);
 
-        // Connect to WebSocket
-        connectWebSocket(data.debate_id);
+        // Connect to WebSocket (private debates need the spectator token)
+        connectWebSocket(data.debate_id, data.spectator_token);
 
         // Clear form
         document.getElementById('create-form').reset();
@@ -67,7 +94,9 @@ function showDebateInfo(data) {
     infoSection.style.display = 'block';
 
     document.getElementById('debate-id').textContent = data.debate_id;
+    document.getElementById('debate-short-id').textContent = data.short_id || '-';
     document.getElementById('debate-topic').textContent = data.topic;
+    document.getElementById('spectator-count-item').style.display = 'none';
     updateDebateStatus('waiting');
     document.getElementById('current-round').textContent = `1 / ${data.total_rounds}`;
 
@@ -79,27 +108,47 @@ function showDebateInfo(data) {
     infoSection.scrollIntoView({ behavior: 'smooth' });
 }
 
+// Event categories requested via ?events=speeches,results (lightweight embeds)
+function subscribedEvents() {
+    const events = new URLSearchParams(window.location.search).get('events');
+    return events ? events.split(',').map(e => e.trim()).filter(Boolean) : undefined;
+}
+
 // Connect to WebSocket
-function connectWebSocket(debateId) {
+function connectWebSocket(debateId, token) {
+    openFrontendSocket({
+        type: 'subscribe_debate',
+        timestamp: new Date().toISOString(),
+        data: {
+            debate_id: debateId,
+            token: token || undefined,
+            events: subscribedEvents(),
+            language: new URLSearchParams(window.location.search).get('lang') || undefined,
+        },
+    });
+}
+
+// Open the spectator WebSocket and send its first message
+function openFrontendSocket(firstMessage) {
     if (ws) {
         ws.close();
     }
+    closeEventStream();
 
     const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:';
-    const wsUrl = `${protocol}//${window.location.host}/frontend`;
+    let wsUrl = `${protocol}//${window.location.host}/frontend`;
+    if (apiKey) {
+        wsUrl += `?api_key=${encodeURIComponent(apiKey)}`;
+    }
 
-    ws = new WebSocket(wsUrl);
+    const socket = new WebSocket(wsUrl, 'botdebate.v1.json');
+    let opened = false;
+    ws = socket;
 
     ws.onopen = () => {
         console.log('WebSocket connected');
-        // Subscribe to debate
-        ws.send(JSON.stringify({
-            type: 'subscribe_debate',
-            timestamp: new Date().toISOString(),
-            data: {
-                debate_id: debateId,
-            },
-        }));
+        opened = true;
+        ws.send(JSON.stringify(firstMessage));
     };
 
     ws.onmessage = (event) => {
@@ -117,14 +166,72 @@ function connectWebSocket(debateId) {
 
     ws.onclose = () => {
         console.log('WebSocket closed');
+        // Proxies that block WebSockets: follow the debate over SSE instead
+        if (!opened && ws === socket && firstMessage.type === 'subscribe_debate') {
+            ws = null;
+            openEventStream(firstMessage.data);
+        }
     };
 }
 
+// Message types the SSE stream delivers as named events
+const STREAM_EVENTS = [
+    'debate_snapshot', 'debate_start', 'debate_waiting', 'debate_update', 'debate_overtime',
+    'judging_in_progress', 'judging_stage', 'judge_stream', 'debate_paused', 'debate_resumed',
+    'participant_status', 'server_shutdown', 'debate_closing', 'warning_issued',
+    'speech_translation', 'round_result', 'debate_end', 'spectator_count', 'chat_history', 'chat_message',
+];
+
+// Follow a debate over Server-Sent Events; read-only, so chat is not available
+function openEventStream(subscription) {
+    closeEventStream();
+
+    const params = new URLSearchParams();
+    if (apiKey) {
+        params.set('api_key', apiKey);
+    }
+    if (subscription.token) {
+        params.set('token', subscription.token);
+    }
+    if (subscription.events) {
+        params.set('events', subscription.events.join(','));
+    }
+    if (subscription.language) {
+        params.set('lang', subscription.language);
+    }
+
+    console.log('Falling back to SSE');
+    eventSource = new EventSource(`/api/debate/${encodeURIComponent(subscription.debate_id)}/events?${params}`);
+    STREAM_EVENTS.forEach(type => {
+        eventSource.addEventListener(type, (event) => {
+            try {
+                handleWebSocketMessage(JSON.parse(event.data));
+            } catch (error) {
+                console.error('Error parsing SSE message:', error);
+            }
+        });
+    });
+    eventSource.onerror = () => {
+        console.log('SSE stream interrupted');
+    };
+}
+
+// Stop following a debate over SSE
+function closeEventStream() {
+    if (eventSource) {
+        eventSource.close();
+        eventSource = null;
+    }
+}
+
 // Handle WebSocket messages
 function handleWebSocketMessage(message) {
     console.log('Received message:', message);
 
     switch (message.type) {
+        case 'debate_snapshot':
+            handleDebateSnapshot(message.data);
+            break;
         case 'debate_start':
             handleDebateStart(message.data);
             break;
@@ -134,17 +241,199 @@ function handleWebSocketMessage(message) {
         case 'debate_update':
             handleDebateUpdate(message.data);
             break;
+        case 'debate_overtime':
+            handleDebateOvertime(message.data);
+            break;
+        case 'judging_in_progress':
+            handleJudgingProgress(message.data);
+            break;
+        case 'judging_stage':
+            handleJudgingStage(message.data);
+            break;
+        case 'judge_stream':
+            handleJudgeStream(message.data);
+            break;
+        case 'debate_paused':
+            handleDebatePaused(message.data);
+            break;
+        case 'debate_resumed':
+            handleDebateResumed(message.data);
+            break;
+        case 'participant_status':
+            handleParticipantStatus(message.data);
+            break;
+        case 'server_shutdown':
+            handleServerShutdown(message.data);
+            break;
+        case 'debate_closing':
+            handleDebateClosing(message.data);
+            break;
+        case 'warning_issued':
+            handleWarningIssued(message.data);
+            break;
+        case 'speech_translation':
+            handleSpeechTranslation(message.data);
+            break;
+        case 'round_result':
+            handleRoundResult(message.data);
+            break;
         case 'debate_end':
             handleDebateEnd(message.data);
             break;
         case 'pong':
             // Heartbeat response
             break;
+        case 'subscribe_rejected':
+            handleSubscribeRejected(message.data);
+            break;
+        case 'room_full':
+            handleRoomFull(message.data);
+            break;
+        case 'replay_state':
+            handleReplayState(message.data);
+            break;
+        case 'replay_event':
+            handleReplayEvent(message.data);
+            break;
+        case 'replay_start':
+            handleTimedReplayStart(message.data);
+            break;
+        case 'replay_entry':
+            handleTimedReplayEntry(message.data);
+            break;
+        case 'replay_end':
+            handleTimedReplayEnd(message.data);
+            break;
+        case 'spectator_count':
+            updateSpectatorCount(message.data.spectators);
+            break;
+        case 'chat_history':
+            handleChatHistory(message.data);
+            break;
+        case 'chat_message':
+            appendChatMessage(message.data);
+            break;
+        case 'error':
+            console.error(`Server error ${message.data.error_code}: ${message.data.message}`, message.data.details || '');
+            if (message.reply_to && message.reply_to === pendingChatId) {
+                document.getElementById('chat-status').textContent = chatErrorText(message.data);
+            }
+            break;
         default:
             console.log('Unknown message type:', message.type);
     }
 }
 
+// Show how many spectators are watching the live debate
+function updateSpectatorCount(count) {
+    if (count === undefined) {
+        return;
+    }
+    document.getElementById('spectator-count-item').style.display = 'block';
+    document.getElementById('spectator-count').textContent = count;
+}
+
+// Id of the last chat message sent, so its error can be shown next to the chat
+let pendingChatId = null;
+
+// Show a debate's saved chat, sent on subscribe when spectator chat is enabled
+function handleChatHistory(data) {
+    document.getElementById('chat-section').style.display = 'block';
+    document.getElementById('chat-messages').innerHTML = '';
+    document.getElementById('chat-status').textContent = '';
+    (data.messages || []).forEach(appendChatMessage);
+}
+
+// Append one chat message, keeping the newest in view
+function appendChatMessage(data) {
+    const container = document.getElementById('chat-messages');
+    const item = document.createElement('div');
+    item.className = 'chat-message';
+
+    const time = document.createElement('span');
+    time.className = 'chat-time';
+    time.textContent = new Date(data.created_at).toLocaleTimeString('zh-CN', { hour: '2-digit', minute: '2-digit' });
+    const name = document.createElement('span');
+    name.className = 'chat-name';
+    name.textContent = data.name;
+    const content = document.createElement('span');
+    content.textContent = data.content;
+
+    item.append(time, name, content);
+    container.appendChild(item);
+    container.scrollTop = container.scrollHeight;
+}
+
+// Send a chat message to everyone watching the current debate
+function sendChat(event) {
+    event.preventDefault();
+    const input = document.getElementById('chat-content');
+    const content = input.value.trim();
+    if (!content || !ws || ws.readyState !== WebSocket.OPEN) {
+        return;
+    }
+    pendingChatId = `chat-${Date.now()}`;
+    ws.send(JSON.stringify({
+        id: pendingChatId,
+        type: 'chat_message',
+        timestamp: new Date().toISOString(),
+        data: {
+            name: document.getElementById('chat-name').value.trim() || undefined,
+            content: content,
+        },
+    }));
+    input.value = '';
+    document.getElementById('chat-status').textContent = '';
+}
+
+// Explain why a chat message was not sent
+function chatErrorText(data) {
+    const messages = {
+        RATE_LIMITED: `发送太频繁，请 ${data.details} 秒后再试`,
+        CONTENT_TOO_LONG: `消息过长，最多 ${data.details} 个字符`,
+        DUPLICATE_MESSAGE: '请不要重复发送相同的消息',
+        NOT_SUBSCRIBED: '辩论已结束，聊天已关闭',
+        CHAT_DISABLED: '本服务器未开启观众聊天',
+    };
+    return messages[data.error_code] || data.message;
+}
+
+// Handle a rejected subscription (e.g. private debate without a valid token)
+function handleSubscribeRejected(data) {
+    const messages = {
+        token_required: '这是一场私密辩论，需要观战令牌',
+        invalid_token: '观战令牌无效',
+        debate_not_found: '辩论不存在',
+    };
+    const logContainer = document.getElementById('log-container');
+    logContainer.innerHTML = `<p class="loading">${messages[data.reason] || data.message}</p>`;
+}
+
+// Handle a full debate room: we are queued and the snapshot arrives once admitted
+function handleRoomFull(data) {
+    const logContainer = document.getElementById('log-container');
+    logContainer.innerHTML = `<p class="loading">观众已满（上限 ${data.max_spectators} 人），正在排队：第 ${data.position} 位，有空位时将自动进入</p>`;
+}
+
+// Handle the full debate state sent on subscribe
+function handleDebateSnapshot(data) {
+    switch (data.status) {
+        case 'waiting':
+            handleDebateWaiting(data);
+            break;
+        case 'completed':
+        case 'timeout':
+            if (data.debate_result) {
+                handleDebateEnd(data);
+            } else {
+                handleDebateUpdate(data);
+            }
+            break;
+        default:
+            handleDebateUpdate(data);
+    }
+}
+
 // Handle debate waiting (before start)
 function handleDebateWaiting(data) {
     updateDebateStatus('waiting');
@@ -190,13 +479,41 @@ function handleDebateStart(data) {
     // Clear loading message and show prompt indicator
     const logContainer = document.getElementById('log-container');
     logContainer.innerHTML = '';
+    if (data.rules) {
+        appendRulesNotice(logContainer, data.rules);
+    }
     appendPromptIndicator(logContainer, data.next_speaker, data.current_round);
 }
 
+// Summarize the rules card a debate runs under
+function appendRulesNotice(container, rules) {
+    const phases = {blind_opening: '盲开篇', rounds: '正式轮次', tiebreak: '加时赛', closing: '收尾', judging: '评判'};
+    const parts = [
+        rules.format === 'simultaneous' ? '同时发言' : '轮流发言',
+        `${rules.total_rounds} 轮`,
+        `每次发言 ${rules.budgets.speech_timeout} 秒`,
+        `${rules.budgets.min_content_length}-${rules.budgets.max_content_length} 字`,
+        `流程：${rules.phases.map(p => phases[p.name] || p.name).join(' → ')}`,
+        rules.judge.mode === 'ai' ? `AI 评委（${rules.judge.rubric}）` : '简单计分（未使用 AI 评委）'
+    ];
+    if (rules.moderation.max_violations > 0) {
+        parts.push(`连续 ${rules.moderation.max_violations} 次违规取消资格`);
+    }
+    if (rules.languages && rules.languages.length > 0) {
+        parts.push(`语言：${rules.languages.join(' / ')}`);
+    }
+
+    const notice = document.createElement('div');
+    notice.className = 'overtime-notice';
+    notice.textContent = `规则：${parts.join('，')}`;
+    container.appendChild(notice);
+}
+
 // Handle debate update
 function handleDebateUpdate(data) {
-    updateDebateStatus('active');
-    updateSidebarStatus(data.debate_id, 'active');
+    const status = data.status || 'active';
+    updateDebateStatus(status);
+    updateSidebarStatus(data.debate_id, status);
 
     if (data.supporting_side) {
         document.getElementById('supporting-bot').textContent = data.supporting_side;
@@ -206,6 +523,7 @@ function handleDebateUpdate(data) {
     }
 
     document.getElementById('current-round').textContent = `${data.current_round} / ${data.total_rounds}`;
+    updateSpectatorCount(data.spectators);
 
     // Update debate log
     if (data.debate_log) {
@@ -219,6 +537,182 @@ function handleDebateUpdate(data) {
     }
 }
 
+// Handle tiebreak round announcement
+function handleDebateOvertime(data) {
+    updateDebateStatus('overtime');
+    updateSidebarStatus(data.debate_id, 'overtime');
+
+    const logContainer = document.getElementById('log-container');
+    const notice = document.createElement('div');
+    notice.className = 'overtime-notice';
+    const reason = data.reason === 'draw' ? '评委判定平局' : '双方得分接近';
+    notice.textContent = `${reason}（${data.supporting_score} : ${data.opposing_score}），进入第 ${data.round} 轮加时赛`;
+    logContainer.appendChild(notice);
+}
+
+// Show that the debate is paused, e.g. while a disconnected bot reconnects
+function handleDebatePaused(data) {
+    updateDebateStatus('paused');
+
+    let notice = document.getElementById('pause-notice');
+    if (!notice) {
+        notice = document.createElement('div');
+        notice.id = 'pause-notice';
+        notice.className = 'pause-notice';
+        document.getElementById('log-container').appendChild(notice);
+    }
+    if (data.reason === 'bot_disconnected') {
+        const deadline = data.resume_deadline ? new Date(data.resume_deadline).toLocaleTimeString() : '';
+        notice.textContent = `${data.bot} 断开连接，辩论已暂停，等待其重连${deadline ? `（${deadline} 前）` : ''}`;
+    } else if (data.reason === 'moderator') {
+        notice.textContent = `辩论已由主持人暂停${data.note ? `：${data.note}` : ''}`;
+    } else {
+        notice.textContent = '辩论已暂停';
+    }
+}
+
+// Remove the pause notice once the debate continues
+function handleDebateResumed(data) {
+    updateDebateStatus('active');
+    const notice = document.getElementById('pause-notice');
+    if (notice) {
+        notice.remove();
+    }
+}
+
+// Show a banner while a bot lags behind on pings or reconnects; it goes
+// away once the bot is healthy again
+function handleParticipantStatus(data) {
+    const id = `participant-${data.bot}`;
+    let notice = document.getElementById(id);
+    if (data.status === 'connected' && !data.missed_pings) {
+        if (notice) {
+            notice.remove();
+        }
+        return;
+    }
+    if (!notice) {
+        notice = document.createElement('div');
+        notice.id = id;
+        notice.className = 'pause-notice';
+        document.getElementById('log-container').appendChild(notice);
+    }
+    switch (data.status) {
+        case 'reconnecting': {
+            const deadline = data.reconnect_deadline ? new Date(data.reconnect_deadline).toLocaleTimeString() : '';
+            notice.textContent = `${data.bot} 正在重连…${deadline ? `（${deadline} 前）` : ''}`;
+            break;
+        }
+        case 'disconnected':
+            notice.textContent = `${data.bot} 已断开连接`;
+            break;
+        default:
+            notice.textContent = `${data.bot} 网络不稳定，已有 ${data.missed_pings} 次心跳未响应`;
+    }
+}
+
+// Show that the server is going away, and whether the debate was cut off
+function handleServerShutdown(data) {
+    if (data.interrupted) {
+        updateDebateStatus('interrupted');
+        updateSidebarStatus(data.debate_id, 'interrupted');
+    }
+
+    let notice = document.getElementById('pause-notice');
+    if (!notice) {
+        notice = document.createElement('div');
+        notice.id = 'pause-notice';
+        notice.className = 'pause-notice';
+        document.getElementById('log-container').appendChild(notice);
+    }
+    if (data.interrupted) {
+        notice.textContent = '服务器关闭，辩论已中断';
+    } else {
+        const deadline = data.deadline ? new Date(data.deadline).toLocaleTimeString() : '';
+        notice.textContent = `服务器即将关闭，进行中的辩论需在${deadline ? ` ${deadline} ` : ''}前结束`;
+    }
+}
+
+// Show that the last speech is in and judging starts shortly
+function handleDebateClosing(data) {
+    updateDebateStatus('closing');
+    updateSidebarStatus(data.debate_id, 'closing');
+
+    const notice = document.createElement('div');
+    notice.className = 'overtime-notice';
+    notice.textContent = `辩论发言结束，${new Date(data.judging_at).toLocaleTimeString()} 开始评判`;
+    document.getElementById('log-container').appendChild(notice);
+}
+
+// Show a strike against a bot that broke the debate rules
+function handleWarningIssued(data) {
+    const notice = document.createElement('div');
+    notice.className = 'overtime-notice';
+    notice.textContent = data.disqualified
+        ? `${data.bot} 连续第 ${data.strikes} 次违规（${data.error_code}），已被取消资格`
+        : `${data.bot} 违规发言（${data.error_code}），警告 ${data.strikes}/${data.max_strikes}`;
+    document.getElementById('log-container').appendChild(notice);
+}
+
+// Show where the debate stands in the judging queue
+function handleJudgingProgress(data) {
+    let notice = document.getElementById('judging-notice');
+    if (!notice) {
+        notice = document.createElement('div');
+        notice.id = 'judging-notice';
+        notice.className = 'overtime-notice';
+        document.getElementById('log-container').appendChild(notice);
+    }
+    notice.textContent = data.status === 'judging'
+        ? '评委正在评判...'
+        : `等待评判，排队第 ${data.position} 位（共 ${data.queue_length} 场）`;
+}
+
+// Handle a judging stage update; the notice shows how far the AI judge has got
+function handleJudgingStage(data) {
+    const stages = {
+        transcript_prepared: '评委正在阅读辩论记录...',
+        llm_call_started: '评委正在评判...',
+        parsing: '正在整理评判结果...',
+        saved: '评判结果已保存',
+        failed: '评委评判失败，将使用简单计分规则',
+    };
+    handleJudgingProgress({ status: 'judging' });
+    const notice = document.getElementById('judging-notice');
+    notice.textContent = `${stages[data.stage] || data.stage}（已用时 ${Math.round(data.elapsed_ms / 1000)} 秒）`;
+}
+
+// Handle the next piece of the verdict summary the judge is writing
+function handleJudgeStream(data) {
+    const container = document.getElementById('log-container');
+    let stream = document.getElementById('judge-stream');
+    if (!stream) {
+        stream = document.createElement('div');
+        stream.id = 'judge-stream';
+        stream.className = 'judge-stream';
+        container.appendChild(stream);
+    }
+    if (data.restart) {
+        stream.textContent = '';
+    }
+    stream.textContent += data.delta;
+    container.scrollTop = container.scrollHeight;
+}
+
+// Handle a judged round (round scoring mode)
+function handleRoundResult(data) {
+    const winnerText = data.winner === 'supporting' ? '正方' : data.winner === 'opposing' ? '反方' : '平局';
+    const logContainer = document.getElementById('log-container');
+    const notice = document.createElement('div');
+    notice.className = 'round-result-notice';
+    notice.textContent = `第 ${data.round} 轮评判: ${winnerText}胜（${data.supporting_score} : ${data.opposing_score}）`;
+    if (data.scores) {
+        notice.textContent += `，累计 ${data.scores.supporting_total} : ${data.scores.opposing_total}`;
+    }
+    notice.title = data.comment || '';
+    logContainer.appendChild(notice);
+}
+
 // Handle debate end
 function handleDebateEnd(data) {
     const endStatus = data.status || 'completed';
@@ -238,6 +732,7 @@ function handleDebateEnd(data) {
         ws.close();
         ws = null;
     }
+    closeEventStream();
 
     // Reload debates list to update status
     loadExistingDebates();
@@ -257,6 +752,18 @@ function updateDebateStatus(status) {
             statusBadge.classList.add('active');
             statusBadge.textContent = '进行中';
             break;
+        case 'overtime':
+            statusBadge.classList.add('overtime');
+            statusBadge.textContent = '加时赛';
+            break;
+        case 'paused':
+            statusBadge.classList.add('paused');
+            statusBadge.textContent = '已暂停';
+            break;
+        case 'closing':
+            statusBadge.classList.add('closing');
+            statusBadge.textContent = '收尾中';
+            break;
         case 'completed':
             statusBadge.classList.add('completed');
             statusBadge.textContent = '已完成';
@@ -265,6 +772,14 @@ function updateDebateStatus(status) {
             statusBadge.classList.add('timeout');
             statusBadge.textContent = '已超时';
             break;
+        case 'interrupted':
+            statusBadge.classList.add('timeout');
+            statusBadge.textContent = '已中断';
+            break;
+        case 'cancelled':
+            statusBadge.classList.add('timeout');
+            statusBadge.textContent = '已取消';
+            break;
         default:
             statusBadge.textContent = status;
     }
@@ -278,6 +793,8 @@ function displayDebateLog(debateLog) {
     debateLog.forEach((entry) => {
         const logEntry = document.createElement('div');
         logEntry.className = `log-entry ${entry.side}`;
+        logEntry.dataset.round = entry.round;
+        logEntry.dataset.speaker = entry.speaker;
 
         const header = document.createElement('div');
         header.className = 'log-entry-header';
@@ -298,7 +815,10 @@ function displayDebateLog(debateLog) {
 
         const content = document.createElement('div');
         content.className = 'log-entry-content';
-        content.innerHTML = marked.parse(entry.message.content);
+        content.innerHTML = renderEntryContent(entry);
+        if (entry.highlights && entry.highlights.length > 0) {
+            logEntry.classList.add('cited');
+        }
 
         logEntry.appendChild(header);
         logEntry.appendChild(content);
@@ -310,6 +830,47 @@ function displayDebateLog(debateLog) {
     container.scrollTop = container.scrollHeight;
 }
 
+// Replace a displayed speech with its translation (bilingual debates, ?lang=)
+function handleSpeechTranslation(data) {
+    const entries = document.querySelectorAll('#log-container .log-entry');
+    entries.forEach((logEntry) => {
+        if (logEntry.dataset.round === String(data.round) && logEntry.dataset.speaker === data.speaker) {
+            logEntry.querySelector('.log-entry-content').innerHTML = marked.parse(data.content);
+        }
+    });
+}
+
+// Render a speech, marking the passages the verdict hinged on
+function renderEntryContent(entry) {
+    let content = entry.message.content;
+    const wholeSpeechPoints = [];
+
+    (entry.highlights || []).forEach((highlight) => {
+        const idx = highlight.quote ? content.indexOf(highlight.quote) : -1;
+        if (idx === -1) {
+            wholeSpeechPoints.push(highlight.point);
+            return;
+        }
+        const title = highlight.point.replace(/&/g, '&amp;').replace(/"/g, '&quot;').replace(/</g, '&lt;');
+        content = content.slice(0, idx) +
+            `<mark class="verdict-highlight" title="${title}">${highlight.quote}</mark>` +
+            content.slice(idx + highlight.quote.length);
+    });
+
+    let html = marked.parse(content);
+    if (wholeSpeechPoints.length > 0) {
+        const items = wholeSpeechPoints.map(point => `<li>${marked.parseInline(point)}</li>`).join('');
+        html += `<div class="verdict-points"><strong>评委引用：</strong><ul>${items}</ul></div>`;
+    }
+    if (entry.message.attachments && entry.message.attachments.length > 0) {
+        const escape = (text) => String(text).replace(/&/g, '&amp;').replace(/"/g, '&quot;').replace(/</g, '&lt;');
+        const items = entry.message.attachments.map(a =>
+            `<li><a href="${escape(a.url)}" download>${escape(a.name)}</a>（${escape(a.content_type)}，${a.size} 字节）</li>`).join('');
+        html += `<div class="speech-attachments"><strong>附件：</strong><ul>${items}</ul></div>`;
+    }
+    return html;
+}
+
 // Display result
 function displayResult(data) {
     const resultSection = document.getElementById('result-section');
@@ -355,16 +916,45 @@ function displayResult(data) {
 
     resultContainer.innerHTML = '';
     resultContainer.appendChild(scoresDiv);
+    if (result.breakdown && result.breakdown.length > 0) {
+        resultContainer.appendChild(renderBreakdown(result.breakdown));
+    }
     resultContainer.appendChild(summaryDiv);
 
+    // Offer the transcript for download
+    if (currentDebateId) {
+        const exportDiv = document.createElement('div');
+        exportDiv.className = 'result-export';
+        const base = `/api/debate/${encodeURIComponent(currentDebateId)}/export?format=`;
+        exportDiv.innerHTML = `导出记录：<a href="${base}markdown">Markdown</a> · <a href="${base}pdf">PDF</a> · <a href="${base}json">JSON</a>`;
+        resultContainer.appendChild(exportDiv);
+    }
+
     // Scroll to result
     resultSection.scrollIntoView({ behavior: 'smooth' });
 }
 
+// Render the judge's per-criterion scores as a table
+function renderBreakdown(breakdown) {
+    const table = document.createElement('table');
+    table.className = 'result-breakdown';
+    table.innerHTML = '<tr><th>评分项</th><th>正方</th><th>反方</th></tr>';
+    breakdown.forEach(score => {
+        const row = document.createElement('tr');
+        [score.name || score.criterion, score.supporting, score.opposing].forEach(value => {
+            const cell = document.createElement('td');
+            cell.textContent = value;
+            row.appendChild(cell);
+        });
+        table.appendChild(row);
+    });
+    return table;
+}
+
 // Load existing debates
 async function loadExistingDebates() {
     try {
-        const response = await fetch('/api/debates');
+        const response = await fetch('/api/debates', { headers: authHeaders() });
         if (!response.ok) {
             throw new Error('Failed to load debates');
         }
@@ -412,6 +1002,14 @@ function displayExistingDebates(debates) {
                 status.classList.add('active');
                 status.textContent = '进行中';
                 break;
+            case 'overtime':
+                status.classList.add('overtime');
+                status.textContent = '加时赛';
+                break;
+            case 'closing':
+                status.classList.add('closing');
+                status.textContent = '收尾中';
+                break;
             case 'completed':
                 status.classList.add('completed');
                 status.textContent = '已完成';
@@ -420,6 +1018,14 @@ function displayExistingDebates(debates) {
                 status.classList.add('timeout');
                 status.textContent = '已超时';
                 break;
+            case 'interrupted':
+                status.classList.add('timeout');
+                status.textContent = '已中断';
+                break;
+            case 'cancelled':
+                status.classList.add('timeout');
+                status.textContent = '已取消';
+                break;
             default:
                 status.textContent = debate.status;
         }
@@ -460,7 +1066,7 @@ function viewDebate(debateId, clickedItem) {
             }
 
             // Connect WebSocket if active
-            if (data.debate.status === 'active' || data.debate.status === 'waiting') {
+            if (data.debate.status === 'active' || data.debate.status === 'overtime' || data.debate.status === 'closing' || data.debate.status === 'waiting') {
                 connectWebSocket(debateId);
             }
         })
@@ -508,7 +1114,9 @@ function displayDebateDesktop(data) {
 
     // Populate the fixed panel
     document.getElementById('debate-id').textContent = data.debate.debate_id;
+    document.getElementById('debate-short-id').textContent = data.debate.short_id || '-';
     document.getElementById('debate-topic').textContent = data.debate.topic;
+    document.getElementById('spectator-count-item').style.display = 'none';
     updateDebateStatus(data.debate.status);
     document.getElementById('current-round').textContent = `${data.debate.current_round} / ${data.debate.total_rounds}`;
 
@@ -527,8 +1135,13 @@ function displayDebateDesktop(data) {
         document.getElementById('log-container').innerHTML = '<p class="loading">暂无发言记录</p>';
     }
 
+    // Finished debates can be replayed to a watch party
+    const finished = data.debate.status === 'completed' || data.debate.status === 'timeout';
+    document.getElementById('replay-start').style.display = finished ? 'block' : 'none';
+    document.getElementById('replay-controls').style.display = 'none';
+
     // Show result if completed or timeout
-    if ((data.debate.status === 'completed' || data.debate.status === 'timeout') && data.result) {
+    if (finished && data.result) {
         displayResult({
             supporting_side: supportingBot?.bot_identifier,
             opposing_side: opposingBot?.bot_identifier,
@@ -566,6 +1179,14 @@ function buildDebateDetailsHTML(data) {
             statusBadgeClass = 'active';
             statusText = '进行中';
             break;
+        case 'overtime':
+            statusBadgeClass = 'overtime';
+            statusText = '加时赛';
+            break;
+        case 'closing':
+            statusBadgeClass = 'closing';
+            statusText = '收尾中';
+            break;
         case 'completed':
             statusBadgeClass = 'completed';
             statusText = '已完成';
@@ -585,9 +1206,11 @@ function buildDebateDetailsHTML(data) {
         </div>
         <div class="mobile-debate-info">
             <p><strong>辩论 ID:</strong> ${data.debate.debate_id}</p>
+            ${data.debate.short_id ? `<p><strong>短 ID:</strong> ${data.debate.short_id}</p>` : ''}
             <p><strong>轮次:</strong> ${data.debate.current_round} / ${data.debate.total_rounds}</p>
             <p><strong>正方:</strong> ${supportingBot ? supportingBot.bot_identifier : '等待连接...'}</p>
             <p><strong>反方:</strong> ${opposingBot ? opposingBot.bot_identifier : '等待连接...'}</p>
+            ${data.debate.imported_from ? `<p><strong>导入自:</strong> ${data.debate.imported_from}</p>` : ''}
         </div>
     `;
 
@@ -604,7 +1227,7 @@ function buildDebateDetailsHTML(data) {
                             <span>${new Date(entry.timestamp).toLocaleString('zh-CN')}</span>
                         </div>
                     </div>
-                    <div class="log-entry-content">${marked.parse(entry.message.content)}</div>
+                    <div class="log-entry-content">${renderEntryContent(entry)}</div>
                 </div>
             `;
         });
@@ -639,6 +1262,181 @@ function buildDebateDetailsHTML(data) {
     return html;
 }
 
+// Start a watch party of the debate on display and host it
+async function startReplay() {
+    try {
+        const response = await fetch(`/api/debate/${encodeURIComponent(currentDebateId)}/replay`, {
+            method: 'POST',
+            headers: authHeaders(),
+        });
+        if (!response.ok) {
+            throw new Error(await response.text());
+        }
+        const data = await response.json();
+
+        // Keep the host link in the address bar; spectators get it without the host token
+        history.replaceState(null, '', `?replay=${encodeURIComponent(data.session_id)}&host_token=${encodeURIComponent(data.host_token)}`);
+        const link = `${window.location.origin}${window.location.pathname}?replay=${encodeURIComponent(data.session_id)}`;
+        navigator.clipboard.writeText(link).catch(() => {});
+        alert(`放映已创建，观众链接已复制到剪贴板：\n${link}`);
+
+        joinReplay(data.session_id, data.host_token);
+    } catch (error) {
+        console.error('Error starting replay:', error);
+        alert('创建放映失败');
+    }
+}
+
+// Join a watch party; the host token enables the playback controls
+function joinReplay(sessionId, hostToken) {
+    replaySession = { id: sessionId, hostToken: hostToken, playing: false };
+    replayLog = [];
+    openFrontendSocket({
+        type: 'join_replay',
+        timestamp: new Date().toISOString(),
+        data: { session_id: sessionId },
+    });
+}
+
+// Show the watch party as the host left it: sent on join and on every host command
+function handleReplayState(data) {
+    currentDebateId = data.debate_id;
+    replaySession.playing = data.playing;
+    replayLog = data.debate_log || [];
+
+    document.getElementById('detail-placeholder').style.display = 'none';
+    document.getElementById('debate-info').style.display = 'block';
+    document.getElementById('debate-log').style.display = 'block';
+    document.getElementById('chat-section').style.display = 'none';
+    document.getElementById('replay-start').style.display = 'none';
+    document.getElementById('debate-id').textContent = data.debate_id;
+    document.getElementById('debate-topic').textContent = data.topic;
+    document.getElementById('supporting-bot').textContent = data.supporting_side;
+    document.getElementById('opposing-bot').textContent = data.opposing_side;
+    document.getElementById('debate-status').textContent = data.playing ? '放映中' : '放映暂停';
+
+    if (replayLog.length > 0) {
+        displayDebateLog(replayLog);
+    } else {
+        document.getElementById('log-container').innerHTML = '<p class="loading">等待主持人开始放映...</p>';
+    }
+    updateReplayControls(data);
+
+    if (data.result) {
+        displayResult({
+            supporting_side: data.supporting_side,
+            opposing_side: data.opposing_side,
+            debate_result: data.result,
+        });
+    } else {
+        document.getElementById('result-section').style.display = 'none';
+    }
+}
+
+// Reveal the next speech of the watch party
+function handleReplayEvent(data) {
+    replayLog.push(data.entry);
+    displayDebateLog(replayLog);
+    document.getElementById('current-round').textContent = `${data.entry.round}`;
+    document.getElementById('replay-seek').value = data.position;
+    document.getElementById('replay-position').textContent = `${data.position} / ${document.getElementById('replay-seek').max}`;
+}
+
+// Replay the debate on display on its own, at the pace it was argued
+function replayDebate() {
+    replaySession = null;
+    replayLog = [];
+    openFrontendSocket({
+        type: 'replay_debate',
+        timestamp: new Date().toISOString(),
+        data: {
+            debate_id: currentDebateId,
+            speed: parseFloat(new URLSearchParams(window.location.search).get('speed')) || undefined,
+        },
+    });
+}
+
+// Clear the log before a timed replay's first speech
+function handleTimedReplayStart(data) {
+    document.getElementById('replay-start').style.display = 'none';
+    document.getElementById('result-section').style.display = 'none';
+    document.getElementById('debate-status').textContent = '回放中';
+    document.getElementById('current-round').textContent = `0 / ${data.total_rounds}`;
+    document.getElementById('log-container').innerHTML = data.total_entries > 0
+        ? '<p class="loading">回放即将开始...</p>'
+        : '<p class="loading">暂无发言记录</p>';
+}
+
+// Show the next speech of a timed replay
+function handleTimedReplayEntry(data) {
+    replayLog.push(data.entry);
+    displayDebateLog(replayLog);
+    document.getElementById('current-round').textContent = `${data.entry.round}`;
+}
+
+// Show the result once a timed replay has played out
+function handleTimedReplayEnd(data) {
+    document.getElementById('replay-start').style.display = 'block';
+    updateDebateStatus(data.status);
+    if (data.result) {
+        displayResult({
+            supporting_side: document.getElementById('supporting-bot').textContent,
+            opposing_side: document.getElementById('opposing-bot').textContent,
+            debate_result: data.result,
+        });
+    }
+}
+
+// Sync the playback controls; only the host may use them
+function updateReplayControls(data) {
+    const isHost = Boolean(replaySession.hostToken);
+    document.getElementById('replay-controls').style.display = 'flex';
+
+    const toggle = document.getElementById('replay-toggle');
+    toggle.textContent = data.playing ? '暂停' : '播放';
+    toggle.disabled = !isHost;
+
+    const seek = document.getElementById('replay-seek');
+    seek.max = data.total_entries;
+    seek.value = data.position;
+    seek.disabled = !isHost;
+
+    const speed = document.getElementById('replay-speed');
+    speed.value = String(data.speed);
+    speed.disabled = !isHost;
+
+    document.getElementById('replay-position').textContent = `${data.position} / ${data.total_entries}`;
+    document.getElementById('replay-spectators').textContent = `${data.spectators} 人观看`;
+}
+
+// Send a host command to the watch party
+async function sendReplayControl(control) {
+    try {
+        const response = await fetch(`/api/replay/${encodeURIComponent(replaySession.id)}/control`, {
+            method: 'POST',
+            headers: authHeaders({ 'Content-Type': 'application/json' }),
+            body: JSON.stringify({ host_token: replaySession.hostToken, ...control }),
+        });
+        if (!response.ok) {
+            throw new Error(await response.text());
+        }
+    } catch (error) {
+        console.error('Error controlling replay:', error);
+    }
+}
+
+function toggleReplay() {
+    sendReplayControl({ action: replaySession.playing ? 'pause' : 'play' });
+}
+
+function seekReplay(position) {
+    sendReplayControl({ action: 'seek', position: parseInt(position) });
+}
+
+function setReplaySpeed(speed) {
+    sendReplayControl({ action: 'speed', speed: parseFloat(speed) });
+}
+
 // Copy debate ID to clipboard
 function copyDebateId() {
     const debateId = document.getElementById('debate-id').textContent;
@@ -687,6 +1485,14 @@ function updateSidebarStatus(debateId, status) {
             badge.classList.add('active');
             badge.textContent = '进行中';
             break;
+        case 'overtime':
+            badge.classList.add('overtime');
+            badge.textContent = '加时赛';
+            break;
+        case 'closing':
+            badge.classList.add('closing');
+            badge.textContent = '收尾中';
+            break;
         case 'completed':
             badge.classList.add('completed');
             badge.textContent = '已完成';
@@ -695,6 +1501,14 @@ function updateSidebarStatus(debateId, status) {
             badge.classList.add('timeout');
             badge.textContent = '已超时';
             break;
+        case 'interrupted':
+            badge.classList.add('timeout');
+            badge.textContent = '已中断';
+            break;
+        case 'cancelled':
+            badge.classList.add('timeout');
+            badge.textContent = '已取消';
+            break;
         default:
             badge.textContent = status;
     }